@@ -0,0 +1,4 @@
+// Package lrpb contains the generated grpc bindings for api/lr.proto.
+package lrpb
+
+//go:generate protoc -I .. --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative lr.proto
@@ -0,0 +1,1033 @@
+// lr grpc api: query, search and index operations for local tools (ide plugins,
+// scripts) that embed lr as a service. start the server with `lr serve`.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.9
+// 	protoc        v5.28.3
+// source: lr.proto
+
+package lrpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type QueryRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Question string                 `protobuf:"bytes,1,opt,name=question,proto3" json:"question,omitempty"`
+	// number of chunks to retrieve (default: by question type, see lr query --top-k)
+	TopK int32 `protobuf:"varint,2,opt,name=top_k,json=topK,proto3" json:"top_k,omitempty"`
+	// restrict retrieval to these sources (default: all)
+	Sources       []string `protobuf:"bytes,3,rep,name=sources,proto3" json:"sources,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryRequest) Reset() {
+	*x = QueryRequest{}
+	mi := &file_lr_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryRequest) ProtoMessage() {}
+
+func (x *QueryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lr_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryRequest.ProtoReflect.Descriptor instead.
+func (*QueryRequest) Descriptor() ([]byte, []int) {
+	return file_lr_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *QueryRequest) GetQuestion() string {
+	if x != nil {
+		return x.Question
+	}
+	return ""
+}
+
+func (x *QueryRequest) GetTopK() int32 {
+	if x != nil {
+		return x.TopK
+	}
+	return 0
+}
+
+func (x *QueryRequest) GetSources() []string {
+	if x != nil {
+		return x.Sources
+	}
+	return nil
+}
+
+type QueryEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*QueryEvent_Status
+	//	*QueryEvent_Sources
+	//	*QueryEvent_Answer
+	Event         isQueryEvent_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *QueryEvent) Reset() {
+	*x = QueryEvent{}
+	mi := &file_lr_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *QueryEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*QueryEvent) ProtoMessage() {}
+
+func (x *QueryEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_lr_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use QueryEvent.ProtoReflect.Descriptor instead.
+func (*QueryEvent) Descriptor() ([]byte, []int) {
+	return file_lr_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *QueryEvent) GetEvent() isQueryEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *QueryEvent) GetStatus() string {
+	if x != nil {
+		if x, ok := x.Event.(*QueryEvent_Status); ok {
+			return x.Status
+		}
+	}
+	return ""
+}
+
+func (x *QueryEvent) GetSources() *Sources {
+	if x != nil {
+		if x, ok := x.Event.(*QueryEvent_Sources); ok {
+			return x.Sources
+		}
+	}
+	return nil
+}
+
+func (x *QueryEvent) GetAnswer() string {
+	if x != nil {
+		if x, ok := x.Event.(*QueryEvent_Answer); ok {
+			return x.Answer
+		}
+	}
+	return ""
+}
+
+type isQueryEvent_Event interface {
+	isQueryEvent_Event()
+}
+
+type QueryEvent_Status struct {
+	// progress, e.g. "retrieving" or "synthesizing"
+	Status string `protobuf:"bytes,1,opt,name=status,proto3,oneof"`
+}
+
+type QueryEvent_Sources struct {
+	// retrieved chunks, sent before the answer
+	Sources *Sources `protobuf:"bytes,2,opt,name=sources,proto3,oneof"`
+}
+
+type QueryEvent_Answer struct {
+	// answer text
+	Answer string `protobuf:"bytes,3,opt,name=answer,proto3,oneof"`
+}
+
+func (*QueryEvent_Status) isQueryEvent_Event() {}
+
+func (*QueryEvent_Sources) isQueryEvent_Event() {}
+
+func (*QueryEvent_Answer) isQueryEvent_Event() {}
+
+type Sources struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*SearchResult        `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Sources) Reset() {
+	*x = Sources{}
+	mi := &file_lr_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Sources) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Sources) ProtoMessage() {}
+
+func (x *Sources) ProtoReflect() protoreflect.Message {
+	mi := &file_lr_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Sources.ProtoReflect.Descriptor instead.
+func (*Sources) Descriptor() ([]byte, []int) {
+	return file_lr_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Sources) GetResults() []*SearchResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type SearchRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Query         string                 `protobuf:"bytes,1,opt,name=query,proto3" json:"query,omitempty"`
+	TopK          int32                  `protobuf:"varint,2,opt,name=top_k,json=topK,proto3" json:"top_k,omitempty"`
+	Sources       []string               `protobuf:"bytes,3,rep,name=sources,proto3" json:"sources,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchRequest) Reset() {
+	*x = SearchRequest{}
+	mi := &file_lr_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchRequest) ProtoMessage() {}
+
+func (x *SearchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lr_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchRequest.ProtoReflect.Descriptor instead.
+func (*SearchRequest) Descriptor() ([]byte, []int) {
+	return file_lr_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *SearchRequest) GetQuery() string {
+	if x != nil {
+		return x.Query
+	}
+	return ""
+}
+
+func (x *SearchRequest) GetTopK() int32 {
+	if x != nil {
+		return x.TopK
+	}
+	return 0
+}
+
+func (x *SearchRequest) GetSources() []string {
+	if x != nil {
+		return x.Sources
+	}
+	return nil
+}
+
+type SearchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Results       []*SearchResult        `protobuf:"bytes,1,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchResponse) Reset() {
+	*x = SearchResponse{}
+	mi := &file_lr_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchResponse) ProtoMessage() {}
+
+func (x *SearchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_lr_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchResponse.ProtoReflect.Descriptor instead.
+func (*SearchResponse) Descriptor() ([]byte, []int) {
+	return file_lr_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *SearchResponse) GetResults() []*SearchResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+type SearchResult struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// file path within the indexed source
+	Source string `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	// index (source) name the chunk came from
+	Index      string            `protobuf:"bytes,2,opt,name=index,proto3" json:"index,omitempty"`
+	Similarity float64           `protobuf:"fixed64,3,opt,name=similarity,proto3" json:"similarity,omitempty"`
+	Text       string            `protobuf:"bytes,4,opt,name=text,proto3" json:"text,omitempty"`
+	Metadata   map[string]string `protobuf:"bytes,5,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// other origins ("index:path") of identical chunks collapsed into this one
+	AlsoIn        []string `protobuf:"bytes,6,rep,name=also_in,json=alsoIn,proto3" json:"also_in,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SearchResult) Reset() {
+	*x = SearchResult{}
+	mi := &file_lr_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SearchResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SearchResult) ProtoMessage() {}
+
+func (x *SearchResult) ProtoReflect() protoreflect.Message {
+	mi := &file_lr_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SearchResult.ProtoReflect.Descriptor instead.
+func (*SearchResult) Descriptor() ([]byte, []int) {
+	return file_lr_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SearchResult) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *SearchResult) GetIndex() string {
+	if x != nil {
+		return x.Index
+	}
+	return ""
+}
+
+func (x *SearchResult) GetSimilarity() float64 {
+	if x != nil {
+		return x.Similarity
+	}
+	return 0
+}
+
+func (x *SearchResult) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *SearchResult) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *SearchResult) GetAlsoIn() []string {
+	if x != nil {
+		return x.AlsoIn
+	}
+	return nil
+}
+
+type ListIndexesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListIndexesRequest) Reset() {
+	*x = ListIndexesRequest{}
+	mi := &file_lr_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListIndexesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListIndexesRequest) ProtoMessage() {}
+
+func (x *ListIndexesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lr_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListIndexesRequest.ProtoReflect.Descriptor instead.
+func (*ListIndexesRequest) Descriptor() ([]byte, []int) {
+	return file_lr_proto_rawDescGZIP(), []int{6}
+}
+
+type ListIndexesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Indexes       []*IndexInfo           `protobuf:"bytes,1,rep,name=indexes,proto3" json:"indexes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListIndexesResponse) Reset() {
+	*x = ListIndexesResponse{}
+	mi := &file_lr_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListIndexesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListIndexesResponse) ProtoMessage() {}
+
+func (x *ListIndexesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_lr_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListIndexesResponse.ProtoReflect.Descriptor instead.
+func (*ListIndexesResponse) Descriptor() ([]byte, []int) {
+	return file_lr_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *ListIndexesResponse) GetIndexes() []*IndexInfo {
+	if x != nil {
+		return x.Indexes
+	}
+	return nil
+}
+
+type IndexInfo struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Name           string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	File           string                 `protobuf:"bytes,2,opt,name=file,proto3" json:"file,omitempty"`
+	Path           string                 `protobuf:"bytes,3,opt,name=path,proto3" json:"path,omitempty"`
+	SourcePath     string                 `protobuf:"bytes,4,opt,name=source_path,json=sourcePath,proto3" json:"source_path,omitempty"`
+	IndexedAt      string                 `protobuf:"bytes,5,opt,name=indexed_at,json=indexedAt,proto3" json:"indexed_at,omitempty"`
+	Chunks         int32                  `protobuf:"varint,6,opt,name=chunks,proto3" json:"chunks,omitempty"`
+	FilesIndexed   int32                  `protobuf:"varint,7,opt,name=files_indexed,json=filesIndexed,proto3" json:"files_indexed,omitempty"`
+	EmbeddingModel string                 `protobuf:"bytes,8,opt,name=embedding_model,json=embeddingModel,proto3" json:"embedding_model,omitempty"`
+	Dimensions     int32                  `protobuf:"varint,9,opt,name=dimensions,proto3" json:"dimensions,omitempty"`
+	SizeBytes      int64                  `protobuf:"varint,10,opt,name=size_bytes,json=sizeBytes,proto3" json:"size_bytes,omitempty"`
+	Format         string                 `protobuf:"bytes,11,opt,name=format,proto3" json:"format,omitempty"`
+	LastCommit     string                 `protobuf:"bytes,12,opt,name=last_commit,json=lastCommit,proto3" json:"last_commit,omitempty"`
+	// true when the source changed since indexing; unset when it can't be checked
+	Stale         *bool  `protobuf:"varint,13,opt,name=stale,proto3,oneof" json:"stale,omitempty"`
+	ChangedFiles  int32  `protobuf:"varint,14,opt,name=changed_files,json=changedFiles,proto3" json:"changed_files,omitempty"`
+	Error         string `protobuf:"bytes,15,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IndexInfo) Reset() {
+	*x = IndexInfo{}
+	mi := &file_lr_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IndexInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IndexInfo) ProtoMessage() {}
+
+func (x *IndexInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_lr_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IndexInfo.ProtoReflect.Descriptor instead.
+func (*IndexInfo) Descriptor() ([]byte, []int) {
+	return file_lr_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *IndexInfo) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *IndexInfo) GetFile() string {
+	if x != nil {
+		return x.File
+	}
+	return ""
+}
+
+func (x *IndexInfo) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *IndexInfo) GetSourcePath() string {
+	if x != nil {
+		return x.SourcePath
+	}
+	return ""
+}
+
+func (x *IndexInfo) GetIndexedAt() string {
+	if x != nil {
+		return x.IndexedAt
+	}
+	return ""
+}
+
+func (x *IndexInfo) GetChunks() int32 {
+	if x != nil {
+		return x.Chunks
+	}
+	return 0
+}
+
+func (x *IndexInfo) GetFilesIndexed() int32 {
+	if x != nil {
+		return x.FilesIndexed
+	}
+	return 0
+}
+
+func (x *IndexInfo) GetEmbeddingModel() string {
+	if x != nil {
+		return x.EmbeddingModel
+	}
+	return ""
+}
+
+func (x *IndexInfo) GetDimensions() int32 {
+	if x != nil {
+		return x.Dimensions
+	}
+	return 0
+}
+
+func (x *IndexInfo) GetSizeBytes() int64 {
+	if x != nil {
+		return x.SizeBytes
+	}
+	return 0
+}
+
+func (x *IndexInfo) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *IndexInfo) GetLastCommit() string {
+	if x != nil {
+		return x.LastCommit
+	}
+	return ""
+}
+
+func (x *IndexInfo) GetStale() bool {
+	if x != nil && x.Stale != nil {
+		return *x.Stale
+	}
+	return false
+}
+
+func (x *IndexInfo) GetChangedFiles() int32 {
+	if x != nil {
+		return x.ChangedFiles
+	}
+	return 0
+}
+
+func (x *IndexInfo) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+type IndexRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// source directory to index
+	Src string `protobuf:"bytes,1,opt,name=src,proto3" json:"src,omitempty"`
+	// index name (saved as {name}_YYYYMMDD.lrindex)
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// incrementally update the existing index instead of rebuilding it
+	Update bool `protobuf:"varint,3,opt,name=update,proto3" json:"update,omitempty"`
+	// index even if the scan exceeds the safety caps
+	Force         bool `protobuf:"varint,4,opt,name=force,proto3" json:"force,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IndexRequest) Reset() {
+	*x = IndexRequest{}
+	mi := &file_lr_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IndexRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IndexRequest) ProtoMessage() {}
+
+func (x *IndexRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_lr_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IndexRequest.ProtoReflect.Descriptor instead.
+func (*IndexRequest) Descriptor() ([]byte, []int) {
+	return file_lr_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *IndexRequest) GetSrc() string {
+	if x != nil {
+		return x.Src
+	}
+	return ""
+}
+
+func (x *IndexRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *IndexRequest) GetUpdate() bool {
+	if x != nil {
+		return x.Update
+	}
+	return false
+}
+
+func (x *IndexRequest) GetForce() bool {
+	if x != nil {
+		return x.Force
+	}
+	return false
+}
+
+type IndexEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*IndexEvent_Log
+	//	*IndexEvent_Result
+	Event         isIndexEvent_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IndexEvent) Reset() {
+	*x = IndexEvent{}
+	mi := &file_lr_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IndexEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IndexEvent) ProtoMessage() {}
+
+func (x *IndexEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_lr_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IndexEvent.ProtoReflect.Descriptor instead.
+func (*IndexEvent) Descriptor() ([]byte, []int) {
+	return file_lr_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *IndexEvent) GetEvent() isIndexEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *IndexEvent) GetLog() string {
+	if x != nil {
+		if x, ok := x.Event.(*IndexEvent_Log); ok {
+			return x.Log
+		}
+	}
+	return ""
+}
+
+func (x *IndexEvent) GetResult() *IndexResult {
+	if x != nil {
+		if x, ok := x.Event.(*IndexEvent_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+type isIndexEvent_Event interface {
+	isIndexEvent_Event()
+}
+
+type IndexEvent_Log struct {
+	// a line of indexing output
+	Log string `protobuf:"bytes,1,opt,name=log,proto3,oneof"`
+}
+
+type IndexEvent_Result struct {
+	// final result; exit_code 0 means success (see `lr --help` exit codes)
+	Result *IndexResult `protobuf:"bytes,2,opt,name=result,proto3,oneof"`
+}
+
+func (*IndexEvent_Log) isIndexEvent_Event() {}
+
+func (*IndexEvent_Result) isIndexEvent_Event() {}
+
+type IndexResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ExitCode      int32                  `protobuf:"varint,1,opt,name=exit_code,json=exitCode,proto3" json:"exit_code,omitempty"`
+	Error         string                 `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IndexResult) Reset() {
+	*x = IndexResult{}
+	mi := &file_lr_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IndexResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IndexResult) ProtoMessage() {}
+
+func (x *IndexResult) ProtoReflect() protoreflect.Message {
+	mi := &file_lr_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IndexResult.ProtoReflect.Descriptor instead.
+func (*IndexResult) Descriptor() ([]byte, []int) {
+	return file_lr_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *IndexResult) GetExitCode() int32 {
+	if x != nil {
+		return x.ExitCode
+	}
+	return 0
+}
+
+func (x *IndexResult) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+var File_lr_proto protoreflect.FileDescriptor
+
+const file_lr_proto_rawDesc = "" +
+	"\n" +
+	"\blr.proto\x12\x05lr.v1\"Y\n" +
+	"\fQueryRequest\x12\x1a\n" +
+	"\bquestion\x18\x01 \x01(\tR\bquestion\x12\x13\n" +
+	"\x05top_k\x18\x02 \x01(\x05R\x04topK\x12\x18\n" +
+	"\asources\x18\x03 \x03(\tR\asources\"u\n" +
+	"\n" +
+	"QueryEvent\x12\x18\n" +
+	"\x06status\x18\x01 \x01(\tH\x00R\x06status\x12*\n" +
+	"\asources\x18\x02 \x01(\v2\x0e.lr.v1.SourcesH\x00R\asources\x12\x18\n" +
+	"\x06answer\x18\x03 \x01(\tH\x00R\x06answerB\a\n" +
+	"\x05event\"8\n" +
+	"\aSources\x12-\n" +
+	"\aresults\x18\x01 \x03(\v2\x13.lr.v1.SearchResultR\aresults\"T\n" +
+	"\rSearchRequest\x12\x14\n" +
+	"\x05query\x18\x01 \x01(\tR\x05query\x12\x13\n" +
+	"\x05top_k\x18\x02 \x01(\x05R\x04topK\x12\x18\n" +
+	"\asources\x18\x03 \x03(\tR\asources\"?\n" +
+	"\x0eSearchResponse\x12-\n" +
+	"\aresults\x18\x01 \x03(\v2\x13.lr.v1.SearchResultR\aresults\"\x85\x02\n" +
+	"\fSearchResult\x12\x16\n" +
+	"\x06source\x18\x01 \x01(\tR\x06source\x12\x14\n" +
+	"\x05index\x18\x02 \x01(\tR\x05index\x12\x1e\n" +
+	"\n" +
+	"similarity\x18\x03 \x01(\x01R\n" +
+	"similarity\x12\x12\n" +
+	"\x04text\x18\x04 \x01(\tR\x04text\x12=\n" +
+	"\bmetadata\x18\x05 \x03(\v2!.lr.v1.SearchResult.MetadataEntryR\bmetadata\x12\x17\n" +
+	"\aalso_in\x18\x06 \x03(\tR\x06alsoIn\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x14\n" +
+	"\x12ListIndexesRequest\"A\n" +
+	"\x13ListIndexesResponse\x12*\n" +
+	"\aindexes\x18\x01 \x03(\v2\x10.lr.v1.IndexInfoR\aindexes\"\xc5\x03\n" +
+	"\tIndexInfo\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04file\x18\x02 \x01(\tR\x04file\x12\x12\n" +
+	"\x04path\x18\x03 \x01(\tR\x04path\x12\x1f\n" +
+	"\vsource_path\x18\x04 \x01(\tR\n" +
+	"sourcePath\x12\x1d\n" +
+	"\n" +
+	"indexed_at\x18\x05 \x01(\tR\tindexedAt\x12\x16\n" +
+	"\x06chunks\x18\x06 \x01(\x05R\x06chunks\x12#\n" +
+	"\rfiles_indexed\x18\a \x01(\x05R\ffilesIndexed\x12'\n" +
+	"\x0fembedding_model\x18\b \x01(\tR\x0eembeddingModel\x12\x1e\n" +
+	"\n" +
+	"dimensions\x18\t \x01(\x05R\n" +
+	"dimensions\x12\x1d\n" +
+	"\n" +
+	"size_bytes\x18\n" +
+	" \x01(\x03R\tsizeBytes\x12\x16\n" +
+	"\x06format\x18\v \x01(\tR\x06format\x12\x1f\n" +
+	"\vlast_commit\x18\f \x01(\tR\n" +
+	"lastCommit\x12\x19\n" +
+	"\x05stale\x18\r \x01(\bH\x00R\x05stale\x88\x01\x01\x12#\n" +
+	"\rchanged_files\x18\x0e \x01(\x05R\fchangedFiles\x12\x14\n" +
+	"\x05error\x18\x0f \x01(\tR\x05errorB\b\n" +
+	"\x06_stale\"b\n" +
+	"\fIndexRequest\x12\x10\n" +
+	"\x03src\x18\x01 \x01(\tR\x03src\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x16\n" +
+	"\x06update\x18\x03 \x01(\bR\x06update\x12\x14\n" +
+	"\x05force\x18\x04 \x01(\bR\x05force\"W\n" +
+	"\n" +
+	"IndexEvent\x12\x12\n" +
+	"\x03log\x18\x01 \x01(\tH\x00R\x03log\x12,\n" +
+	"\x06result\x18\x02 \x01(\v2\x12.lr.v1.IndexResultH\x00R\x06resultB\a\n" +
+	"\x05event\"@\n" +
+	"\vIndexResult\x12\x1b\n" +
+	"\texit_code\x18\x01 \x01(\x05R\bexitCode\x12\x14\n" +
+	"\x05error\x18\x02 \x01(\tR\x05error2\xed\x01\n" +
+	"\bLocalRag\x121\n" +
+	"\x05Query\x12\x13.lr.v1.QueryRequest\x1a\x11.lr.v1.QueryEvent0\x01\x125\n" +
+	"\x06Search\x12\x14.lr.v1.SearchRequest\x1a\x15.lr.v1.SearchResponse\x12D\n" +
+	"\vListIndexes\x12\x19.lr.v1.ListIndexesRequest\x1a\x1a.lr.v1.ListIndexesResponse\x121\n" +
+	"\x05Index\x12\x13.lr.v1.IndexRequest\x1a\x11.lr.v1.IndexEvent0\x01B\x12Z\x10lr/api/lrpb;lrpbb\x06proto3"
+
+var (
+	file_lr_proto_rawDescOnce sync.Once
+	file_lr_proto_rawDescData []byte
+)
+
+func file_lr_proto_rawDescGZIP() []byte {
+	file_lr_proto_rawDescOnce.Do(func() {
+		file_lr_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_lr_proto_rawDesc), len(file_lr_proto_rawDesc)))
+	})
+	return file_lr_proto_rawDescData
+}
+
+var file_lr_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_lr_proto_goTypes = []any{
+	(*QueryRequest)(nil),        // 0: lr.v1.QueryRequest
+	(*QueryEvent)(nil),          // 1: lr.v1.QueryEvent
+	(*Sources)(nil),             // 2: lr.v1.Sources
+	(*SearchRequest)(nil),       // 3: lr.v1.SearchRequest
+	(*SearchResponse)(nil),      // 4: lr.v1.SearchResponse
+	(*SearchResult)(nil),        // 5: lr.v1.SearchResult
+	(*ListIndexesRequest)(nil),  // 6: lr.v1.ListIndexesRequest
+	(*ListIndexesResponse)(nil), // 7: lr.v1.ListIndexesResponse
+	(*IndexInfo)(nil),           // 8: lr.v1.IndexInfo
+	(*IndexRequest)(nil),        // 9: lr.v1.IndexRequest
+	(*IndexEvent)(nil),          // 10: lr.v1.IndexEvent
+	(*IndexResult)(nil),         // 11: lr.v1.IndexResult
+	nil,                         // 12: lr.v1.SearchResult.MetadataEntry
+}
+var file_lr_proto_depIdxs = []int32{
+	2,  // 0: lr.v1.QueryEvent.sources:type_name -> lr.v1.Sources
+	5,  // 1: lr.v1.Sources.results:type_name -> lr.v1.SearchResult
+	5,  // 2: lr.v1.SearchResponse.results:type_name -> lr.v1.SearchResult
+	12, // 3: lr.v1.SearchResult.metadata:type_name -> lr.v1.SearchResult.MetadataEntry
+	8,  // 4: lr.v1.ListIndexesResponse.indexes:type_name -> lr.v1.IndexInfo
+	11, // 5: lr.v1.IndexEvent.result:type_name -> lr.v1.IndexResult
+	0,  // 6: lr.v1.LocalRag.Query:input_type -> lr.v1.QueryRequest
+	3,  // 7: lr.v1.LocalRag.Search:input_type -> lr.v1.SearchRequest
+	6,  // 8: lr.v1.LocalRag.ListIndexes:input_type -> lr.v1.ListIndexesRequest
+	9,  // 9: lr.v1.LocalRag.Index:input_type -> lr.v1.IndexRequest
+	1,  // 10: lr.v1.LocalRag.Query:output_type -> lr.v1.QueryEvent
+	4,  // 11: lr.v1.LocalRag.Search:output_type -> lr.v1.SearchResponse
+	7,  // 12: lr.v1.LocalRag.ListIndexes:output_type -> lr.v1.ListIndexesResponse
+	10, // 13: lr.v1.LocalRag.Index:output_type -> lr.v1.IndexEvent
+	10, // [10:14] is the sub-list for method output_type
+	6,  // [6:10] is the sub-list for method input_type
+	6,  // [6:6] is the sub-list for extension type_name
+	6,  // [6:6] is the sub-list for extension extendee
+	0,  // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_lr_proto_init() }
+func file_lr_proto_init() {
+	if File_lr_proto != nil {
+		return
+	}
+	file_lr_proto_msgTypes[1].OneofWrappers = []any{
+		(*QueryEvent_Status)(nil),
+		(*QueryEvent_Sources)(nil),
+		(*QueryEvent_Answer)(nil),
+	}
+	file_lr_proto_msgTypes[8].OneofWrappers = []any{}
+	file_lr_proto_msgTypes[10].OneofWrappers = []any{
+		(*IndexEvent_Log)(nil),
+		(*IndexEvent_Result)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_lr_proto_rawDesc), len(file_lr_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   13,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_lr_proto_goTypes,
+		DependencyIndexes: file_lr_proto_depIdxs,
+		MessageInfos:      file_lr_proto_msgTypes,
+	}.Build()
+	File_lr_proto = out.File
+	file_lr_proto_goTypes = nil
+	file_lr_proto_depIdxs = nil
+}
@@ -0,0 +1,255 @@
+// lr grpc api: query, search and index operations for local tools (ide plugins,
+// scripts) that embed lr as a service. start the server with `lr serve`.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             v5.28.3
+// source: lr.proto
+
+package lrpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	LocalRag_Query_FullMethodName       = "/lr.v1.LocalRag/Query"
+	LocalRag_Search_FullMethodName      = "/lr.v1.LocalRag/Search"
+	LocalRag_ListIndexes_FullMethodName = "/lr.v1.LocalRag/ListIndexes"
+	LocalRag_Index_FullMethodName       = "/lr.v1.LocalRag/Index"
+)
+
+// LocalRagClient is the client API for LocalRag service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type LocalRagClient interface {
+	// Query retrieves context and synthesizes an answer. events are streamed as
+	// they happen: status updates, then the sources, then the answer.
+	Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[QueryEvent], error)
+	// Search returns the most similar chunks without llm synthesis.
+	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error)
+	// ListIndexes describes the available indexes (same fields as `lr list --json`).
+	ListIndexes(ctx context.Context, in *ListIndexesRequest, opts ...grpc.CallOption) (*ListIndexesResponse, error)
+	// Index creates or incrementally updates an index, streaming its output.
+	Index(ctx context.Context, in *IndexRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[IndexEvent], error)
+}
+
+type localRagClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLocalRagClient(cc grpc.ClientConnInterface) LocalRagClient {
+	return &localRagClient{cc}
+}
+
+func (c *localRagClient) Query(ctx context.Context, in *QueryRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[QueryEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LocalRag_ServiceDesc.Streams[0], LocalRag_Query_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[QueryRequest, QueryEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LocalRag_QueryClient = grpc.ServerStreamingClient[QueryEvent]
+
+func (c *localRagClient) Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SearchResponse)
+	err := c.cc.Invoke(ctx, LocalRag_Search_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *localRagClient) ListIndexes(ctx context.Context, in *ListIndexesRequest, opts ...grpc.CallOption) (*ListIndexesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListIndexesResponse)
+	err := c.cc.Invoke(ctx, LocalRag_ListIndexes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *localRagClient) Index(ctx context.Context, in *IndexRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[IndexEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LocalRag_ServiceDesc.Streams[1], LocalRag_Index_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[IndexRequest, IndexEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LocalRag_IndexClient = grpc.ServerStreamingClient[IndexEvent]
+
+// LocalRagServer is the server API for LocalRag service.
+// All implementations must embed UnimplementedLocalRagServer
+// for forward compatibility.
+type LocalRagServer interface {
+	// Query retrieves context and synthesizes an answer. events are streamed as
+	// they happen: status updates, then the sources, then the answer.
+	Query(*QueryRequest, grpc.ServerStreamingServer[QueryEvent]) error
+	// Search returns the most similar chunks without llm synthesis.
+	Search(context.Context, *SearchRequest) (*SearchResponse, error)
+	// ListIndexes describes the available indexes (same fields as `lr list --json`).
+	ListIndexes(context.Context, *ListIndexesRequest) (*ListIndexesResponse, error)
+	// Index creates or incrementally updates an index, streaming its output.
+	Index(*IndexRequest, grpc.ServerStreamingServer[IndexEvent]) error
+	mustEmbedUnimplementedLocalRagServer()
+}
+
+// UnimplementedLocalRagServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedLocalRagServer struct{}
+
+func (UnimplementedLocalRagServer) Query(*QueryRequest, grpc.ServerStreamingServer[QueryEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method Query not implemented")
+}
+func (UnimplementedLocalRagServer) Search(context.Context, *SearchRequest) (*SearchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Search not implemented")
+}
+func (UnimplementedLocalRagServer) ListIndexes(context.Context, *ListIndexesRequest) (*ListIndexesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListIndexes not implemented")
+}
+func (UnimplementedLocalRagServer) Index(*IndexRequest, grpc.ServerStreamingServer[IndexEvent]) error {
+	return status.Errorf(codes.Unimplemented, "method Index not implemented")
+}
+func (UnimplementedLocalRagServer) mustEmbedUnimplementedLocalRagServer() {}
+func (UnimplementedLocalRagServer) testEmbeddedByValue()                  {}
+
+// UnsafeLocalRagServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LocalRagServer will
+// result in compilation errors.
+type UnsafeLocalRagServer interface {
+	mustEmbedUnimplementedLocalRagServer()
+}
+
+func RegisterLocalRagServer(s grpc.ServiceRegistrar, srv LocalRagServer) {
+	// If the following call pancis, it indicates UnimplementedLocalRagServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&LocalRag_ServiceDesc, srv)
+}
+
+func _LocalRag_Query_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(QueryRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LocalRagServer).Query(m, &grpc.GenericServerStream[QueryRequest, QueryEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LocalRag_QueryServer = grpc.ServerStreamingServer[QueryEvent]
+
+func _LocalRag_Search_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LocalRagServer).Search(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LocalRag_Search_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LocalRagServer).Search(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LocalRag_ListIndexes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListIndexesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LocalRagServer).ListIndexes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: LocalRag_ListIndexes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LocalRagServer).ListIndexes(ctx, req.(*ListIndexesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _LocalRag_Index_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(IndexRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LocalRagServer).Index(m, &grpc.GenericServerStream[IndexRequest, IndexEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LocalRag_IndexServer = grpc.ServerStreamingServer[IndexEvent]
+
+// LocalRag_ServiceDesc is the grpc.ServiceDesc for LocalRag service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var LocalRag_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "lr.v1.LocalRag",
+	HandlerType: (*LocalRagServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Search",
+			Handler:    _LocalRag_Search_Handler,
+		},
+		{
+			MethodName: "ListIndexes",
+			Handler:    _LocalRag_ListIndexes_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Query",
+			Handler:       _LocalRag_Query_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Index",
+			Handler:       _LocalRag_Index_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "lr.proto",
+}
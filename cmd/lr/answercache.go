@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"lr/pkg/llm"
+)
+
+// answers are cached under a key of the normalized question, the query
+// parameters and models, and fingerprints of the indexes it searches, so a
+// repeated question is answered instantly and for free until an index it
+// searched changes. --no-cache asks again (and caches the new answer)
+
+// answerCacheMaxAge bounds how long a cached answer is reused, in case the
+// answer depended on something the key doesn't cover (e.g. the model changed
+// behind an alias)
+const answerCacheMaxAge = 7 * 24 * time.Hour
+
+// cachedAnswer is a cache entry
+type cachedAnswer struct {
+	Question string              `json:"question"`
+	Created  time.Time           `json:"created"`
+	Response remoteQueryResponse `json:"response"`
+}
+
+// answerCacheDir returns the directory holding cached answers
+func answerCacheDir() string {
+	return filepath.Join(getCacheDir(), "answers")
+}
+
+// normalizeQuestion makes trivially different phrasings of a question (case,
+// spacing, trailing punctuation) share a cache entry
+func normalizeQuestion(question string) string {
+	return strings.TrimRight(strings.Join(strings.Fields(strings.ToLower(question)), " "), "?.! ")
+}
+
+// indexFingerprints identifies the index files (and their segments) of the
+// given sources, or of all indexes, by name, size and modification time, along
+// with the descriptions and hints that steer routing and prompts
+func indexFingerprints(dir string, sources []string) []string {
+	files, _ := listIndexFiles(dir)
+	var paths []string
+	for _, file := range files {
+		name := indexNameFromFile(file)
+		if len(sources) > 0 && !slices.Contains(sources, name) &&
+			!slices.Contains(sources, strings.TrimPrefix(strings.TrimPrefix(name, "nats_"), "lr_")) {
+			continue
+		}
+		paths = append(paths, file)
+		segments, _ := segmentPaths(file)
+		paths = append(paths, segments...)
+	}
+	meta, _ := filepath.Glob(filepath.Join(dir, "*.lrmeta"))
+	paths = append(paths, meta...)
+
+	var fingerprints []string
+	for _, path := range paths {
+		if st, err := os.Stat(path); err == nil {
+			fingerprints = append(fingerprints, fmt.Sprintf("%s %d %d", filepath.Base(path), st.Size(), st.ModTime().UnixNano()))
+		}
+	}
+	slices.Sort(fingerprints)
+	return fingerprints
+}
+
+// answerCacheKey returns the cache key of a query with the given chat and
+// embedding models over indexes with the given fingerprints
+func answerCacheKey(req remoteQueryRequest, chat, embedding string, opts *ChatOptions, fingerprints []string) string {
+	sources := slices.Clone(req.Sources)
+	slices.Sort(sources)
+	req.Question, req.Sources = normalizeQuestion(req.Question), sources
+	data, _ := json.Marshal(struct {
+		Request      remoteQueryRequest `json:"request"`
+		Chat         string             `json:"chat"`
+		Fallbacks    []string           `json:"fallbacks,omitempty"`
+		Embedding    string             `json:"embedding"`
+		Options      *ChatOptions       `json:"options,omitempty"`
+		ScoreNorm    string             `json:"score_norm"`
+		TopFiles     int                `json:"top_files"`
+		Fingerprints []string           `json:"fingerprints"`
+	}{req, chat, fallbackModels, embedding, opts, scoreNorm, topFiles, fingerprints})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// loadCachedAnswer returns the answer cached under key, if it is fresh
+func loadCachedAnswer(key string) (*cachedAnswer, bool) {
+	data, err := os.ReadFile(filepath.Join(answerCacheDir(), key+".json"))
+	if err != nil {
+		return nil, false
+	}
+	var entry cachedAnswer
+	if json.Unmarshal(data, &entry) != nil || time.Since(entry.Created) > answerCacheMaxAge {
+		return nil, false
+	}
+	return &entry, true
+}
+
+// saveCachedAnswer caches resp under key and drops expired entries. the cache
+// is best effort: failures only warn
+func saveCachedAnswer(key, question string, resp *remoteQueryResponse) {
+	dir := answerCacheDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to cache the answer: %v\n", err)
+		return
+	}
+	data, err := json.Marshal(cachedAnswer{Question: question, Created: time.Now(), Response: *resp})
+	if err == nil {
+		path := filepath.Join(dir, key+".json")
+		tmp := path + ".tmp"
+		if err = os.WriteFile(tmp, data, 0600); err == nil {
+			err = os.Rename(tmp, path)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to cache the answer: %v\n", err)
+	}
+	pruneCacheDir(dir)
+}
+
+// pruneCacheDir removes the entries of a cache directory older than
+// answerCacheMaxAge
+func pruneCacheDir(dir string) {
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if info, err := e.Info(); err == nil && time.Since(info.ModTime()) > answerCacheMaxAge {
+			os.Remove(filepath.Join(dir, e.Name()))
+		}
+	}
+}
+
+// printCachedAnswer prints and returns the answer cached under key, or returns
+// nil if there is none
+func printCachedAnswer(question, key string) *remoteQueryResponse {
+	entry, ok := loadCachedAnswer(key)
+	if !ok {
+		return nil
+	}
+	fmt.Println(dim(fmt.Sprintf("cached answer from %s (--no-cache to ask again)", entry.Created.Format("2006-01-02 15:04"))))
+	printRemoteRetrieval(&entry.Response)
+	printRemoteAnswer(question, &entry.Response, nil)
+	return &entry.Response
+}
+
+// currentChatModel returns the chat model queries use
+func currentChatModel() string {
+	if mockLLM {
+		return llm.MockModel
+	}
+	return resolveChatModel(chatModel)
+}
+
+// ragResponse collects what rag found and answered for a question, as a
+// served query reports it
+func ragResponse(rag *RAG, answer string, results []SearchResult) *remoteQueryResponse {
+	resp := &remoteQueryResponse{
+		Answer:     answer,
+		Results:    results,
+		Routed:     rag.Routed,
+		FollowUps:  rag.FollowUps,
+		Citations:  rag.Citations,
+		Confidence: rag.Confidence,
+		Abstained:  rag.Abstained,
+	}
+	for _, derr := range rag.Skipped {
+		resp.Skipped = append(resp.Skipped, derr.Error())
+	}
+	return resp
+}
+
+// queryEmbeddingCache is a client that keeps the embeddings of questions on
+// disk, so asking again (e.g. after lr query --retrieve-only) doesn't embed the
+// question again. chat requests are forwarded
+type queryEmbeddingCache struct {
+	LLMClient
+	model string
+}
+
+var _ ContextLLMClient = (*queryEmbeddingCache)(nil)
+
+// queryEmbeddingCacheDir returns the directory holding cached question embeddings
+func queryEmbeddingCacheDir() string {
+	return filepath.Join(getCacheDir(), "query-embeddings")
+}
+
+// newQueryEmbeddingCache caches the question embeddings client makes with
+// model, dropping expired ones
+func newQueryEmbeddingCache(client LLMClient, model string) *queryEmbeddingCache {
+	pruneCacheDir(queryEmbeddingCacheDir())
+	return &queryEmbeddingCache{LLMClient: client, model: model}
+}
+
+// GetEmbedding returns the cached embedding of text, or embeds and caches it
+func (c *queryEmbeddingCache) GetEmbedding(text string) ([]float64, error) {
+	return c.GetEmbeddingContext(context.Background(), text)
+}
+
+// GetEmbeddingContext returns the cached embedding of text, or embeds and
+// caches it
+func (c *queryEmbeddingCache) GetEmbeddingContext(ctx context.Context, text string) ([]float64, error) {
+	if data, err := os.ReadFile(c.path(text)); err == nil {
+		var embedding []float64
+		if json.Unmarshal(data, &embedding) == nil && len(embedding) > 0 {
+			return embedding, nil
+		}
+	}
+	return c.refresh(ctx, text)
+}
+
+// refresh embeds text, cached or not, and caches the embedding
+func (c *queryEmbeddingCache) refresh(ctx context.Context, text string) ([]float64, error) {
+	embedding, err := getEmbeddingContext(ctx, c.LLMClient, text)
+	if err != nil {
+		return nil, err
+	}
+	// best effort: a question that can't be cached is embedded again next time
+	path := c.path(text)
+	if data, err := json.Marshal(embedding); err == nil && os.MkdirAll(filepath.Dir(path), 0700) == nil {
+		if os.WriteFile(path+".tmp", data, 0600) == nil {
+			os.Rename(path+".tmp", path)
+		}
+	}
+	return embedding, nil
+}
+
+// path returns the file caching the embedding of text
+func (c *queryEmbeddingCache) path(text string) string {
+	sum := sha256.Sum256([]byte(c.model + "\x00" + text))
+	return filepath.Join(queryEmbeddingCacheDir(), hex.EncodeToString(sum[:])+".json")
+}
+
+// Chat forwards a chat request
+func (c *queryEmbeddingCache) Chat(messages []Message) (string, error) {
+	return c.ChatContext(context.Background(), messages)
+}
+
+// ChatContext forwards a chat request
+func (c *queryEmbeddingCache) ChatContext(ctx context.Context, messages []Message) (string, error) {
+	return chatContext(ctx, c.LLMClient, messages)
+}
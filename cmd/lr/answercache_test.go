@@ -0,0 +1,49 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAnswerCacheKey(t *testing.T) {
+	fingerprints := []string{"a_20250101.lrindex 10 1"}
+	key := answerCacheKey(remoteQueryRequest{Question: "How do I  create a consumer?", Sources: []string{"b", "a"}}, "m", "e", nil, fingerprints)
+
+	same := answerCacheKey(remoteQueryRequest{Question: "how do i create a consumer", Sources: []string{"a", "b"}}, "m", "e", nil, fingerprints)
+	if same != key {
+		t.Fatal("case, spacing, punctuation or source order changed the key")
+	}
+	reindexed := answerCacheKey(remoteQueryRequest{Question: "how do i create a consumer", Sources: []string{"a", "b"}}, "m", "e", nil, []string{"a_20250101.lrindex 12 2"})
+	if reindexed == key {
+		t.Fatal("an updated index kept the key")
+	}
+	if answerCacheKey(remoteQueryRequest{Question: "how do i create a consumer", Sources: []string{"a", "b"}}, "other", "e", nil, fingerprints) == key {
+		t.Fatal("another chat model kept the key")
+	}
+}
+
+func TestAnswerCacheRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_CACHE_HOME", dir)
+
+	if _, ok := loadCachedAnswer("k"); ok {
+		t.Fatal("empty cache had an answer")
+	}
+	saveCachedAnswer("k", "q", &remoteQueryResponse{Answer: "a", Routed: []string{"s"}})
+	entry, ok := loadCachedAnswer("k")
+	if !ok || entry.Response.Answer != "a" || len(entry.Response.Routed) != 1 {
+		t.Fatalf("cached answer = %+v, %v", entry, ok)
+	}
+
+	// expired entries are neither used nor kept
+	old := time.Now().Add(-answerCacheMaxAge - time.Hour)
+	if err := os.Chtimes(filepath.Join(answerCacheDir(), "k.json"), old, old); err != nil {
+		t.Fatal(err)
+	}
+	saveCachedAnswer("other", "q", &remoteQueryResponse{Answer: "b"})
+	if _, err := os.Stat(filepath.Join(answerCacheDir(), "k.json")); !os.IsNotExist(err) {
+		t.Fatalf("expired entry kept: %v", err)
+	}
+}
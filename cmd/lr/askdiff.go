@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var askDiffCmd = &cobra.Command{
+	Use:   "ask-diff [question]",
+	Short: "Ask a question about your changes using the review index",
+	Long: `Collect the same diff and indexed context as the get_diff_context MCP tool and
+have the chat model answer a question about it, without running an MCP server.
+
+Requires an active review session (lr review start).`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runAskDiff,
+}
+
+func runAskDiff(_ *cobra.Command, args []string) error {
+	question := strings.Join(args, " ")
+	ctx := context.Background()
+
+	session, err := loadReviewSession()
+	if err != nil {
+		return withKind(fmt.Errorf("no active review session. run 'lr review start' first"), ErrNoIndex)
+	}
+
+	diff, emptyMsg, err := reviewDiff(ctx, session.ProjectPath, askDiffUncommitted)
+	if err != nil {
+		return err
+	}
+	if emptyMsg != "" {
+		fmt.Println(emptyMsg)
+		return nil
+	}
+
+	store, err := loadReviewIndex(session)
+	if err != nil {
+		return fmt.Errorf("failed to load review index: %w", err)
+	}
+	changedFiles := extractChangedFiles(diff)
+
+	llm, err := getLLMClient()
+	if err != nil {
+		return err
+	}
+
+	systemPrompt := `you are an experienced code reviewer.
+you are given a git diff followed by relevant code from the project it belongs to.
+answer the user's question about the change based on the diff and the provided context.
+be specific: cite files and line changes, and say so if the context is not enough to answer.`
+
+	messages := []Message{
+		{Role: "system", Content: systemPrompt},
+		{Role: "user", Content: fmt.Sprintf("%s\n\nquestion: %s", diffWithContext(ctx, diff, store, changedFiles, topK), question)},
+	}
+
+	var failover FailoverResult
+	answer, err := chatContext(withFailoverResult(ctx, &failover), llm, messages)
+	if err != nil {
+		return fmt.Errorf("failed to get chat response: %w", err)
+	}
+
+	if askDiffShowDiff {
+		fmt.Println(colorDiff(diff))
+	}
+	fmt.Println("\n" + dim(strings.Repeat("=", 80)))
+	fmt.Printf("%s %s\n", bold("question:"), question)
+	fmt.Println(dim(strings.Repeat("=", 80)))
+	fmt.Printf("\n%s\n%s\n", bold("answer:"), answer)
+	printFailoverNote(&failover)
+	if len(changedFiles) > 0 {
+		fmt.Println("\n" + bold("changed files:"))
+		for _, f := range changedFiles {
+			fmt.Printf("  %s\n", cyan(f))
+		}
+	}
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"lr/pkg/store"
+)
+
+// lr query --as-of / --commit load an older version of each index instead of
+// the newest: the current versions in the index dir and the superseded ones
+// kept in its archive dir (see --keep-versions) are all candidates
+
+// versionStamp returns the YYYYMMDD stamp of an index version file
+func versionStamp(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), ".lrindex")
+	return name[strings.LastIndex(name, "_")+1:]
+}
+
+// allIndexVersions returns the versions of source in the index dir and its
+// archive, oldest first. sources are also found under the nats_ and lr_ file
+// prefixes lr query strips from source names
+func allIndexVersions(indexDir, source string) ([]string, error) {
+	var versions []string
+	for _, dir := range []string{indexDir, filepath.Join(indexDir, indexArchiveDir)} {
+		for _, name := range []string{source, "nats_" + source, "lr_" + source} {
+			found, err := indexVersions(dir, name)
+			if err != nil {
+				return nil, err
+			}
+			versions = append(versions, found...)
+		}
+	}
+	sort.SliceStable(versions, func(i, j int) bool { return versionStamp(versions[i]) < versionStamp(versions[j]) })
+	return versions, nil
+}
+
+// pinnedVersion returns the version of source to query: the newest one indexed
+// on or before asOf (YYYYMMDD), or the newest one indexed at a commit starting
+// with commit. it returns "" when the source has no such version
+func pinnedVersion(indexDir, source, asOf, commit string) (string, error) {
+	versions, err := allIndexVersions(indexDir, source)
+	if err != nil {
+		return "", err
+	}
+	for i := len(versions) - 1; i >= 0; i-- {
+		v := versions[i]
+		if asOf != "" {
+			if versionStamp(v) <= asOf {
+				return v, nil
+			}
+			continue
+		}
+		vs := NewVectorStore()
+		if err := vs.Load(v); err != nil {
+			return "", fmt.Errorf("failed to load %s: %w", filepath.Base(v), err)
+		}
+		if vs.Metadata.LastCommit != "" && strings.HasPrefix(vs.Metadata.LastCommit, commit) {
+			return v, nil
+		}
+	}
+	return "", nil
+}
+
+// loadPinnedSources loads the versions of sources (all sources when empty)
+// selected by asOf or commit into mss. sources without such a version are
+// skipped with a note, unless they were named explicitly
+func loadPinnedSources(mss *MultiSourceStore, sources []string, asOf, commit string) error {
+	if asOf != "" && !isDateStamp(asOf) {
+		return fmt.Errorf("--as-of takes a date as YYYYMMDD, got %q", asOf)
+	}
+	if commit != "" && len(commit) < 4 {
+		return fmt.Errorf("--commit needs at least 4 characters of the commit hash")
+	}
+	pinned := "as of " + asOf
+	if commit != "" {
+		pinned = "at commit " + commit
+	}
+
+	explicit := len(sources) > 0
+	if !explicit {
+		names, err := mss.SourceNames()
+		if err != nil {
+			return fmt.Errorf("error listing sources: %w", err)
+		}
+		sources = names
+	}
+
+	for _, source := range sources {
+		version, err := pinnedVersion(mss.BaseDir, source, asOf, commit)
+		if err != nil {
+			return err
+		}
+		if version == "" {
+			if explicit {
+				return withKind(fmt.Errorf("no version of %s indexed %s (older versions are kept with --keep-versions)", source, pinned), ErrNoIndex)
+			}
+			fmt.Printf("note: skipping %s, no version indexed %s\n", source, pinned)
+			continue
+		}
+		if err := mss.LoadSourceFile(source, version); err != nil {
+			return err
+		}
+		vs := mss.Sources[source]
+		fmt.Printf("using %s %s: %s", source, pinned, filepath.Base(version))
+		if vs.Metadata.LastCommit != "" {
+			fmt.Printf(" (commit %.8s)", vs.Metadata.LastCommit)
+		}
+		fmt.Println()
+	}
+
+	// descriptions are optional, routing just searches every source without them
+	mss.Descriptions, _ = store.LoadDescriptions(mss.BaseDir)
+	return nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPinnedVersion(t *testing.T) {
+	indexDir := t.TempDir()
+	os.MkdirAll(filepath.Join(indexDir, indexArchiveDir), 0755)
+	save := func(path, commit string) {
+		vs := NewVectorStore()
+		vs.Metadata.LastCommit = commit
+		if err := atomicSave(vs, path); err != nil {
+			t.Fatal(err)
+		}
+	}
+	save(filepath.Join(indexDir, indexArchiveDir, "proj_20250101.lrindex"), "aaaa1111")
+	save(filepath.Join(indexDir, indexArchiveDir, "proj_20250301.lrindex"), "bbbb2222")
+	save(filepath.Join(indexDir, "proj_20250601.lrindex"), "cccc3333")
+	save(filepath.Join(indexDir, "proj_other_20250701.lrindex"), "dddd4444")
+
+	for _, tc := range []struct{ asOf, commit, want string }{
+		{asOf: "20250415", want: "proj_20250301.lrindex"},
+		{asOf: "20250601", want: "proj_20250601.lrindex"},
+		{asOf: "20241231", want: ""},
+		{commit: "aaaa", want: "proj_20250101.lrindex"},
+		{commit: "dddd", want: ""},
+	} {
+		got, err := pinnedVersion(indexDir, "proj", tc.asOf, tc.commit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if filepath.Base(got) != tc.want && !(got == "" && tc.want == "") {
+			t.Errorf("as of %q commit %q: expected %q, got %q", tc.asOf, tc.commit, tc.want, got)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"lr/pkg/rag"
+)
+
+// maxAttachmentFileSize is the largest file lr query --file will read
+const maxAttachmentFileSize = 10 * 1024 * 1024
+
+// loadAttachments reads files given with lr query --file as documents
+func loadAttachments(paths []string) ([]Document, error) {
+	var docs []Document
+	for _, path := range paths {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return nil, fmt.Errorf("invalid file %s: %w", path, err)
+		}
+		info, err := os.Stat(abs)
+		if err != nil {
+			return nil, fmt.Errorf("cannot attach %s: %w", path, err)
+		}
+		if info.IsDir() {
+			return nil, fmt.Errorf("cannot attach %s: is a directory", path)
+		}
+		if info.Size() > maxAttachmentFileSize {
+			return nil, fmt.Errorf("cannot attach %s: too large (%s)", path, formatBytes(info.Size()))
+		}
+
+		// reuse the loader so attachments get the same file typing as indexed files
+		result, err := LoadSpecificFiles(filepath.Dir(abs), []string{filepath.Base(abs)}, "text", maxAttachmentFileSize, true)
+		if err != nil {
+			return nil, fmt.Errorf("cannot attach %s: %w", path, err)
+		}
+		if len(result.SkippedFiles) > 0 {
+			return nil, fmt.Errorf("cannot attach %s: %s", path, result.SkippedFiles[0].Reason)
+		}
+		for _, doc := range result.Documents {
+			doc.Source = path
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+// readStdinAttachment reads piped input for lr query --stdin as a document
+func readStdinAttachment() (Document, error) {
+	if isTerminal(os.Stdin) {
+		return Document{}, fmt.Errorf("--stdin expects piped input (e.g. git diff | lr query --stdin \"...\")")
+	}
+	data, err := io.ReadAll(io.LimitReader(os.Stdin, maxAttachmentFileSize+1))
+	if err != nil {
+		return Document{}, fmt.Errorf("failed to read stdin: %w", err)
+	}
+	if len(data) > maxAttachmentFileSize {
+		return Document{}, fmt.Errorf("stdin input too large (over %s)", formatBytes(maxAttachmentFileSize))
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return Document{}, fmt.Errorf("no input on stdin")
+	}
+
+	docType := "text"
+	if strings.HasPrefix(string(data), "diff --git") {
+		docType = "diff"
+	}
+	return Document{
+		Content:  string(data),
+		Source:   "stdin",
+		Metadata: map[string]string{"type": docType},
+	}, nil
+}
+
+// checkAttachmentCost refuses attachments with too many chunks to embed, and
+// prints what embedding the rest will cost before it starts. attachments that
+// fit in the prompt whole aren't embedded
+func checkAttachmentCost(attachments []Document) error {
+	if err := rag.CheckAttachments(attachments); err != nil {
+		return err
+	}
+	chunks := rag.AttachmentChunksToEmbed(attachments)
+	if len(chunks) == 0 {
+		return nil
+	}
+	if cost, model := estimateEmbeddingCost(len(chunks)); model != "" {
+		fmt.Fprintf(os.Stderr, "attachments: embedding %d chunks to pick the relevant parts (estimated cost: $%.4f, %s)\n", len(chunks), cost, model)
+	} else {
+		fmt.Fprintf(os.Stderr, "attachments: embedding %d chunks to pick the relevant parts\n", len(chunks))
+	}
+	return nil
+}
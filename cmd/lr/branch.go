@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// an index records the git branch it was built from (Metadata.Branch). an
+// index named <name>@<branch> is pinned to that branch, so one repository can
+// keep an index per branch; "<name>@" names the branch checked out in the
+// source. "/" in branch names becomes "-" in index names (feature/x ->
+// myrepo@feature-x)
+
+// getGitBranch returns the branch checked out in repoDir, or "" when HEAD is detached
+func getGitBranch(repoDir string) (string, error) {
+	cmd := exec.Command("git", "rev-parse", "--abbrev-ref", "HEAD")
+	cmd.Dir = repoDir
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to get git branch: %w", err)
+	}
+	branch := strings.TrimSpace(string(output))
+	if branch == "HEAD" {
+		return "", nil
+	}
+	return branch, nil
+}
+
+// branchNamePart returns branch as it appears in an index name
+func branchNamePart(branch string) string {
+	return strings.ReplaceAll(branch, "/", "-")
+}
+
+// pinnedBranch returns the branch part of a <name>@<branch> index name, or
+// "" if the index isn't pinned to a branch
+func pinnedBranch(name string) string {
+	if _, branch, found := cutLast(name, "@"); found {
+		return branch
+	}
+	return ""
+}
+
+// resolveIndexName expands "<name>@" to the branch checked out in srcPath, and
+// checks that a <name>@<branch> index is built from that branch
+func resolveIndexName(name, srcPath string) (string, error) {
+	if !strings.Contains(name, "@") {
+		return name, nil
+	}
+	branch, err := getGitBranch(srcPath)
+	if err != nil || branch == "" {
+		return "", fmt.Errorf("%s names a branch, but %s has no branch checked out", name, srcPath)
+	}
+	if strings.HasSuffix(name, "@") {
+		return name + branchNamePart(branch), nil
+	}
+	if pinned := pinnedBranch(name); pinned != branchNamePart(branch) && pinned != branch {
+		return "", fmt.Errorf("%s is the index of branch %s, but %s has %s checked out (check it out, or use %s@%s)",
+			name, pinned, srcPath, branch, strings.TrimSuffix(name, "@"+pinned), branchNamePart(branch))
+	}
+	return strings.TrimSuffix(name, pinnedBranch(name)) + branchNamePart(branch), nil
+}
+
+// branchMoved returns the branch now checked out in srcPath when it
+// differs from the branch the index was built from. an incremental update
+// would then diff across branches, turning the index into one of the other
+// branch. indexes that predate recorded branches, and detached checkouts,
+// never count as moved
+func branchMoved(vs *VectorStore, srcPath string) string {
+	if vs.Metadata.Branch == "" || srcPath == "" {
+		return ""
+	}
+	current, err := getGitBranch(srcPath)
+	if err != nil || current == "" || current == vs.Metadata.Branch {
+		return ""
+	}
+	return current
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func TestResolveIndexName(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	for _, args := range [][]string{
+		{"init", "-q", "-b", "main"},
+		{"-c", "user.name=t", "-c", "user.email=t@t", "commit", "-q", "--allow-empty", "-m", "init"},
+		{"checkout", "-q", "-b", "feature/x"},
+	} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	for name, want := range map[string]string{
+		"repo":           "repo",
+		"repo@":          "repo@feature-x",
+		"repo@feature/x": "repo@feature-x",
+		"repo@feature-x": "repo@feature-x",
+	} {
+		if got, err := resolveIndexName(name, dir); err != nil || got != want {
+			t.Errorf("resolveIndexName(%q) = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := resolveIndexName("repo@main", dir); err == nil {
+		t.Error("an index pinned to another branch should be refused")
+	}
+}
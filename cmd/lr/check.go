@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// maxCheckListed caps how many changed files --check prints per category
+const maxCheckListed = 20
+
+// IndexCheckResult is the outcome of lr index --check (printed as json with --json)
+type IndexCheckResult struct {
+	Index      string   `json:"index"`
+	SourcePath string   `json:"source_path"`
+	Since      string   `json:"since"`
+	UpToDate   bool     `json:"up_to_date"`
+	Added      []string `json:"added"`
+	Modified   []string `json:"modified"`
+	Deleted    []string `json:"deleted"`
+}
+
+// runIndexCheck compares an existing index to its source without modifying
+// anything, returning ErrStaleIndex (non-zero exit) if the index is out of date
+func runIndexCheck() error {
+	existingIndex, err := findExistingIndex(getDefaultIndexDir(), outName)
+	if err != nil {
+		return fmt.Errorf("cannot check: %w", err)
+	}
+
+	vs := NewVectorStore()
+	if err := vs.Load(existingIndex); err != nil {
+		return fmt.Errorf("failed to load existing index: %w", err)
+	}
+	migrateIndexedFiles(vs)
+
+	// default to the source the index was built from
+	src := srcPath
+	if src == "" {
+		src = vs.Metadata.SourcePath
+	}
+	if src == "" {
+		return fmt.Errorf("index %s has no recorded source path - pass --src", outName)
+	}
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return fmt.Errorf("source directory not found: %s", src)
+	}
+
+	extensions, _ := indexExtensions()
+	changeSet, since, err := detectIndexChanges(vs, existingIndex, src, extensions)
+	if err != nil {
+		return err
+	}
+
+	result := IndexCheckResult{
+		Index:      filepath.Base(existingIndex),
+		SourcePath: src,
+		Since:      since,
+		UpToDate:   !changeSet.HasChanges(),
+		Added:      nonNil(changeSet.Added),
+		Modified:   nonNil(changeSet.Modified),
+		Deleted:    nonNil(changeSet.Deleted),
+	}
+
+	if jsonOutput {
+		if err := json.NewEncoder(os.Stdout).Encode(result); err != nil {
+			return err
+		}
+	} else {
+		printIndexCheck(result)
+	}
+
+	if !result.UpToDate {
+		total := len(result.Added) + len(result.Modified) + len(result.Deleted)
+		return withKind(fmt.Errorf("index %s is out of date: %d file(s) changed - run 'lr index --update --out-name %s'", outName, total, outName), ErrStaleIndex)
+	}
+	return nil
+}
+
+// printIndexCheck prints the human-readable changed-file summary for --check
+func printIndexCheck(r IndexCheckResult) {
+	fmt.Printf("\n=== INDEX CHECK ===\n")
+	fmt.Printf("index:  %s\n", r.Index)
+	fmt.Printf("source: %s\n", r.SourcePath)
+	fmt.Printf("since:  %s\n", r.Since)
+	if r.UpToDate {
+		fmt.Println("status: up to date")
+		return
+	}
+	fmt.Println("status: out of date")
+	printCheckFiles("added", "+", r.Added)
+	printCheckFiles("modified", "~", r.Modified)
+	printCheckFiles("deleted", "-", r.Deleted)
+	fmt.Println()
+}
+
+func printCheckFiles(label, marker string, files []string) {
+	if len(files) == 0 {
+		return
+	}
+	fmt.Printf("\n%s (%d):\n", label, len(files))
+	for i, f := range files {
+		if i == maxCheckListed {
+			fmt.Printf("  ... and %d more\n", len(files)-maxCheckListed)
+			break
+		}
+		fmt.Printf("  %s %s\n", marker, f)
+	}
+}
+
+// nonNil returns an empty slice for nil so json output has [] instead of null
+func nonNil(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// checkpointPathFor returns the checkpoint file used while building outputFile
+// (same name but with .checkpoint before the extension)
+func checkpointPathFor(outputFile string) string {
+	if strings.HasSuffix(outputFile, ".lrindex") {
+		return strings.Replace(outputFile, ".lrindex", ".checkpoint.lrindex", 1)
+	}
+	return strings.Replace(outputFile, ".json", ".checkpoint.json", 1)
+}
+
+// EmbeddingCache holds embeddings recovered from a checkpoint so an interrupted
+// update can resume without paying for the same chunks twice
+type EmbeddingCache map[string][]float64
+
+// chunkCacheKey identifies a chunk by its source file and exact text
+func chunkCacheKey(chunk Chunk) string {
+	return chunk.Source + "\x00" + chunk.Text
+}
+
+// loadEmbeddingCache loads the checkpoint at path into a cache
+// returns an empty cache if there is no checkpoint or it cannot be read
+func loadEmbeddingCache(path string) EmbeddingCache {
+	cache := make(EmbeddingCache)
+	if _, err := os.Stat(path); err != nil {
+		return cache
+	}
+
+	vs := NewVectorStore()
+	if err := vs.Load(path); err != nil {
+		return cache
+	}
+	for i, chunk := range vs.Chunks {
+		if i < len(vs.Embeddings) {
+			cache[chunkCacheKey(chunk)] = vs.Embeddings[i]
+		}
+	}
+	return cache
+}
+
+// Lookup returns the cached embedding for chunk, if any
+func (c EmbeddingCache) Lookup(chunk Chunk) ([]float64, bool) {
+	embedding, ok := c[chunkCacheKey(chunk)]
+	return embedding, ok
+}
+
+// removeCheckpoint deletes the checkpoint file if it exists
+func removeCheckpoint(path string) {
+	if _, err := os.Stat(path); err == nil {
+		os.Remove(path)
+	}
+}
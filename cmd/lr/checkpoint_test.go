@@ -0,0 +1,38 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestEmbeddingCacheFromCheckpoint(t *testing.T) {
+	tmpDir := t.TempDir()
+	outputFile := filepath.Join(tmpDir, "test_20250101.lrindex")
+	checkpointFile := checkpointPathFor(outputFile)
+
+	if want := filepath.Join(tmpDir, "test_20250101.checkpoint.lrindex"); checkpointFile != want {
+		t.Fatalf("expected checkpoint path %s, got %s", want, checkpointFile)
+	}
+
+	// no checkpoint yet - cache should be empty
+	if cache := loadEmbeddingCache(checkpointFile); len(cache) != 0 {
+		t.Fatalf("expected empty cache, got %d entries", len(cache))
+	}
+
+	vs := NewVectorStore()
+	done := Chunk{Text: "func a() {}", Source: "a.go"}
+	vs.Add(done, []float64{0.1, 0.2})
+	if err := vs.Save(checkpointFile); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	cache := loadEmbeddingCache(checkpointFile)
+	if _, ok := cache.Lookup(done); !ok {
+		t.Fatal("expected checkpointed chunk to be cached")
+	}
+
+	// same text from a different file must not hit the cache
+	if _, ok := cache.Lookup(Chunk{Text: done.Text, Source: "b.go"}); ok {
+		t.Fatal("expected cache miss for different source")
+	}
+}
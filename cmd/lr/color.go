@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"strings"
+)
+
+// ansi escape codes used for terminal emphasis
+const (
+	ansiReset  = "\033[0m"
+	ansiBold   = "\033[1m"
+	ansiDim    = "\033[2m"
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+	ansiCyan   = "\033[36m"
+)
+
+// colorEnabled reports whether output should be colored: not with --no-color or
+// NO_COLOR set, and not when stdout is piped or redirected
+func colorEnabled() bool {
+	return !noColor && os.Getenv("NO_COLOR") == "" && isTerminal(os.Stdout)
+}
+
+// colorize wraps s in the given ansi code when color is enabled
+func colorize(code, s string) string {
+	if !colorEnabled() {
+		return s
+	}
+	return code + s + ansiReset
+}
+
+func bold(s string) string   { return colorize(ansiBold, s) }
+func dim(s string) string    { return colorize(ansiDim, s) }
+func red(s string) string    { return colorize(ansiRed, s) }
+func green(s string) string  { return colorize(ansiGreen, s) }
+func yellow(s string) string { return colorize(ansiYellow, s) }
+func cyan(s string) string   { return colorize(ansiCyan, s) }
+
+// similarityColor highlights a similarity score: green for strong matches,
+// yellow for moderate ones and dim for weak ones
+func similarityColor(score float64, s string) string {
+	switch {
+	case score >= 0.5:
+		return green(s)
+	case score >= 0.3:
+		return yellow(s)
+	}
+	return dim(s)
+}
+
+// colorDiff colors unified diff lines: additions green, removals red, hunk headers cyan
+func colorDiff(diff string) string {
+	if !colorEnabled() {
+		return diff
+	}
+	lines := strings.Split(diff, "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"), strings.HasPrefix(line, "diff "):
+			lines[i] = bold(line)
+		case strings.HasPrefix(line, "+"):
+			lines[i] = green(line)
+		case strings.HasPrefix(line, "-"):
+			lines[i] = red(line)
+		case strings.HasPrefix(line, "@@"):
+			lines[i] = cyan(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// compactChunkChars bounds a chunk body in compact mcp output
+const compactChunkChars = 600
+
+// compactOutput reports whether mcp responses should be trimmed to save agent
+// tokens (mcp --compact, or LR_MCP_COMPACT=1)
+func compactOutput() bool {
+	if mcpCompact {
+		return true
+	}
+	v := strings.ToLower(os.Getenv("LR_MCP_COMPACT"))
+	return v != "" && v != "0" && v != "false"
+}
+
+// compactChunkText cuts a chunk body to about compactChunkChars, at a line break
+// where possible, with expand (a tool call) as the hint on how to get the rest
+func compactChunkText(text, expand string) string {
+	if len(text) <= compactChunkChars {
+		return text
+	}
+	cut := compactChunkChars
+	if nl := strings.LastIndexByte(text[:cut], '\n'); nl > compactChunkChars/2 {
+		cut = nl
+	}
+	for cut > 0 && !utf8.RuneStart(text[cut]) {
+		cut--
+	}
+	hint := fmt.Sprintf("… (+%d chars", len(text)-cut)
+	if expand != "" {
+		hint += "; expand: " + expand
+	}
+	return strings.TrimRight(text[:cut], "\n") + "\n" + hint + ")"
+}
+
+// expandChunk is the tool call that returns a whole chunk
+func expandChunk(id string) string {
+	if id == "" {
+		return ""
+	}
+	return fmt.Sprintf("get_chunk_neighbors chunk_id=%q before=0 after=0", id)
+}
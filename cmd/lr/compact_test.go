@@ -0,0 +1,27 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCompactChunkText(t *testing.T) {
+	short := "func a() {}"
+	if got := compactChunkText(short, expandChunk("repo:a.go#1")); got != short {
+		t.Errorf("short chunk changed: %q", got)
+	}
+
+	long := strings.Repeat("// a line of commentary é\n", 50)
+	got := compactChunkText(long, expandChunk("repo:a.go#2"))
+	if len(got) > compactChunkChars+200 || !utf8.ValidString(got) {
+		t.Fatalf("expected a valid string cut to about %d chars, got %d", compactChunkChars, len(got))
+	}
+	if !strings.Contains(got, `expand: get_chunk_neighbors chunk_id="repo:a.go#2" before=0 after=0`) {
+		t.Errorf("missing expand hint: %q", got[len(got)-120:])
+	}
+	body := got[:strings.LastIndex(got, "\n")]
+	if !strings.HasSuffix(body, "é") {
+		t.Errorf("expected the cut at a line break, got %q", body[len(body)-30:])
+	}
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// maxConventionsSummary bounds the conventions summary in a review prompt
+	maxConventionsSummary = 4000
+	// maxConventionLine bounds one rule in the summary
+	maxConventionLine = 200
+)
+
+// conventionNames are the base names (lowercased, without extension) of files
+// that document a project's standards
+var conventionNames = map[string]bool{
+	"conventions": true, "contributing": true, "style": true, "styleguide": true,
+	"style-guide": true, "style_guide": true, "coding-style": true, "coding_style": true,
+	"codingstyle": true, "coding-standards": true, "coding_standards": true,
+	"code-style": true, "code_style": true, "guidelines": true,
+}
+
+// ruleWordPattern matches prose lines that state a rule rather than explain one
+var ruleWordPattern = regexp.MustCompile(`(?i)\b(must|should|never|always|avoid|prefer|don't|do not|required?)\b`)
+
+// isConventionFile reports whether an indexed source is a conventions document
+// such as CONVENTIONS.md, CONTRIBUTING.md or a style guide
+func isConventionFile(source string) bool {
+	// split sources are named "path (part n)"
+	if i := strings.Index(source, " (part "); i >= 0 {
+		source = source[:i]
+	}
+	base := strings.ToLower(filepath.Base(source))
+	ext := filepath.Ext(base)
+	switch ext {
+	case "", ".md", ".markdown", ".rst", ".txt", ".adoc":
+	default:
+		return false
+	}
+	return conventionNames[strings.TrimSuffix(base, ext)]
+}
+
+// conventionChunks returns the chunks of the project's conventions documents,
+// grouped by file in index order
+func conventionChunks(store *VectorStore) (files []string, chunks map[string][]Chunk) {
+	chunks = make(map[string][]Chunk)
+	for _, chunk := range store.Chunks {
+		if !isConventionFile(chunk.Source) {
+			continue
+		}
+		if _, ok := chunks[chunk.Source]; !ok {
+			files = append(files, chunk.Source)
+		}
+		chunks[chunk.Source] = append(chunks[chunk.Source], chunk)
+	}
+	return files, chunks
+}
+
+// summarizeConventions extracts the headings and rules (list items and lines
+// that say must, should, never, ...) of a conventions document
+func summarizeConventions(text string) []string {
+	var lines []string
+	inFence := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence || line == "" {
+			continue
+		}
+		isHeading := strings.HasPrefix(line, "#")
+		isItem := strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ") || strings.HasPrefix(line, "+ ") || numberedItem(line)
+		if !isHeading && !isItem && !ruleWordPattern.MatchString(line) {
+			continue
+		}
+		if r := []rune(line); len(r) > maxConventionLine {
+			line = string(r[:maxConventionLine]) + "..."
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// numberedItem reports whether line is a numbered list item like "3. ..."
+func numberedItem(line string) bool {
+	i := 0
+	for i < len(line) && line[i] >= '0' && line[i] <= '9' {
+		i++
+	}
+	return i > 0 && i+1 < len(line) && (line[i] == '.' || line[i] == ')') && line[i+1] == ' '
+}
+
+// conventionsContext returns the review prompt section for the project's
+// documented standards: a summary of every conventions document, always, and
+// in full the conventions chunks that mention identifiers or files from the
+// change. it is "" if the project has no conventions documents
+func conventionsContext(store *VectorStore, diff string, changedFiles []string, topK int) string {
+	files, chunks := conventionChunks(store)
+	if len(files) == 0 {
+		return ""
+	}
+
+	response := "=== PROJECT CONVENTIONS (summary) ===\n\n"
+	size, truncated := 0, false
+	seen := make(map[string]bool)
+	for _, file := range files {
+		var text strings.Builder
+		for _, chunk := range chunks[file] {
+			text.WriteString(chunk.Text + "\n")
+		}
+		header := fmt.Sprintf("--- %s ---\n", file)
+		response += header
+		for _, line := range summarizeConventions(text.String()) {
+			if seen[line] {
+				continue
+			}
+			seen[line] = true
+			if size+len(line) > maxConventionsSummary {
+				truncated = true
+				break
+			}
+			size += len(line)
+			response += line + "\n"
+		}
+		response += "\n"
+		if truncated {
+			response += "(summary truncated)\n\n"
+			break
+		}
+	}
+
+	relevant := relevantConventionChunks(files, chunks, diff, changedFiles, topK)
+	if len(relevant) > 0 {
+		response += "=== CONVENTIONS RELEVANT TO THE CHANGE ===\n\n"
+		for _, chunk := range relevant {
+			response += fmt.Sprintf("--- from %s ---\n%s\n\n", chunk.Source, chunk.Text)
+		}
+	}
+	return response
+}
+
+// relevantConventionChunks boosts the conventions chunks that mention what the
+// change touches: its identifiers, changed file names and their extensions
+func relevantConventionChunks(files []string, chunks map[string][]Chunk, diff string, changedFiles []string, limit int) []Chunk {
+	terms := make(map[string]bool)
+	for ident := range diffIdentifiers(diff) {
+		terms[strings.ToLower(ident)] = true
+	}
+	for _, f := range changedFiles {
+		terms[strings.ToLower(filepath.Base(f))] = true
+		if ext := filepath.Ext(f); ext != "" {
+			terms[strings.ToLower(ext)] = true
+		}
+	}
+
+	type candidate struct {
+		chunk Chunk
+		score int
+	}
+	var candidates []candidate
+	for _, file := range files {
+		for _, chunk := range chunks[file] {
+			text := strings.ToLower(chunk.Text)
+			score := 0
+			for term := range terms {
+				if strings.Contains(text, term) {
+					score++
+				}
+			}
+			if score > 0 {
+				candidates = append(candidates, candidate{chunk, score})
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+
+	var relevant []Chunk
+	for i := 0; i < len(candidates) && i < limit; i++ {
+		relevant = append(relevant, candidates[i].chunk)
+	}
+	return relevant
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIsConventionFile(t *testing.T) {
+	for source, want := range map[string]bool{
+		"CONVENTIONS.md":                true,
+		".github/CONTRIBUTING.md":       true,
+		"docs/style-guide.md":           true,
+		"docs/STYLEGUIDE (part 2)":      true,
+		"CONTRIBUTING":                  true,
+		"pkg/style/style.go":            false,
+		"docs/contributing-examples.md": false,
+		"README.md":                     false,
+	} {
+		if got := isConventionFile(source); got != want {
+			t.Errorf("isConventionFile(%q) = %v, want %v", source, got, want)
+		}
+	}
+}
+
+func TestSummarizeConventions(t *testing.T) {
+	doc := "# Style\n\nsome history that isn't a rule.\n\n- use gofmt\nerrors must be wrapped\n```go\n- not a rule, code\n```\n2. tests are table driven\n"
+	want := []string{"# Style", "- use gofmt", "errors must be wrapped", "2. tests are table driven"}
+	if got := summarizeConventions(doc); !reflect.DeepEqual(got, want) {
+		t.Errorf("summarizeConventions() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,496 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// lr daemon keeps the indexes and llm clients loaded and answers lr query over
+// a unix socket, so a query skips loading every index and creating clients.
+// lr query uses a running daemon when its settings (index directory, models)
+// match the query's and answers itself otherwise, or with --no-daemon
+
+const (
+	daemonSocketEnv   = "LR_DAEMON_SOCKET"
+	daemonInfoPath    = "/v1/daemon"
+	daemonQueryPath   = "/v1/daemon/query"
+	daemonDialTimeout = 200 * time.Millisecond
+	daemonStopTimeout = 10 * time.Second
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "Keep indexes and clients loaded so lr query answers without loading them",
+	Long: `Run a daemon that keeps every index and the llm clients loaded and answers
+lr query over a unix socket (LR_DAEMON_SOCKET, default $XDG_RUNTIME_DIR/lr/daemon.sock).
+lr query uses it whenever it runs with the same index directory and models, and
+loads indexes itself otherwise or with --no-daemon. Changed indexes are reloaded
+before the next query.`,
+	Args: cobra.NoArgs,
+	RunE: runDaemon,
+}
+
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show whether the daemon runs and what it serves",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonStatus,
+}
+
+var daemonStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the daemon",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonStop,
+}
+
+// daemonConfig is what a daemon's answers depend on besides the question; a
+// query only goes to a daemon with the same config
+type daemonConfig struct {
+	IndexDir       string   `json:"index_dir"`
+	EmbeddingModel string   `json:"embedding_model"`
+	ChatModel      string   `json:"chat_model"`
+	Fallbacks      []string `json:"fallbacks,omitempty"`
+	Mock           bool     `json:"mock,omitempty"`
+}
+
+func currentDaemonConfig() daemonConfig {
+	return daemonConfig{
+		IndexDir:       getDefaultIndexDir(),
+		EmbeddingModel: getCurrentEmbeddingModel(),
+		ChatModel:      resolveChatModel(chatModel),
+		Fallbacks:      fallbackModels,
+		Mock:           mockLLM,
+	}
+}
+
+func (c daemonConfig) equal(o daemonConfig) bool {
+	return c.IndexDir == o.IndexDir && c.EmbeddingModel == o.EmbeddingModel && c.ChatModel == o.ChatModel &&
+		slices.Equal(c.Fallbacks, o.Fallbacks) && c.Mock == o.Mock
+}
+
+// daemonInfo is the reply to GET /v1/daemon
+type daemonInfo struct {
+	PID       int          `json:"pid"`
+	StartedAt time.Time    `json:"started_at"`
+	Config    daemonConfig `json:"config"`
+	Sources   []string     `json:"sources"`
+	Queries   int64        `json:"queries"`
+}
+
+// daemonQueryRequest is the body of a POST to /v1/daemon/query
+type daemonQueryRequest struct {
+	remoteQueryRequest
+	Stream bool `json:"stream,omitempty"`
+}
+
+// daemonEvent is a line of the reply to a query: the retrieved chunks, the
+// tokens of the answer as it's generated (with stream), then the response or
+// an error
+type daemonEvent struct {
+	Retrieved *remoteQueryResponse `json:"retrieved,omitempty"`
+	Token     string               `json:"token,omitempty"`
+	Response  *remoteQueryResponse `json:"response,omitempty"`
+	Error     *remoteError         `json:"error,omitempty"`
+}
+
+// daemonSocketPath returns where the daemon listens
+func daemonSocketPath() string {
+	if path := os.Getenv(daemonSocketEnv); path != "" {
+		return path
+	}
+	if runtime := os.Getenv("XDG_RUNTIME_DIR"); runtime != "" {
+		return filepath.Join(runtime, "lr", "daemon.sock")
+	}
+	return filepath.Join(getCacheDir(), "daemon.sock")
+}
+
+// daemonServer answers queries with the stores it keeps loaded
+type daemonServer struct {
+	remoteServer
+	config    daemonConfig
+	startedAt time.Time
+	queries   atomic.Int64
+
+	mu        sync.Mutex
+	signature string // of the index files the stores were loaded from
+}
+
+// indexDirSignature identifies the index files in dir by name, size and
+// modification time, so replaced or added indexes are noticed
+func indexDirSignature(dir string) string {
+	files, err := listIndexFiles(dir)
+	if err != nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, file := range files {
+		if st, err := os.Stat(file); err == nil {
+			fmt.Fprintf(&b, "%s %d %d\n", file, st.Size(), st.ModTime().UnixNano())
+		}
+	}
+	return b.String()
+}
+
+// refresh reloads the stores if the index files changed since they were loaded
+func (d *daemonServer) refresh() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	signature := indexDirSignature(d.config.IndexDir)
+	if signature == d.signature && preloadedMSS != nil {
+		return nil
+	}
+	if err := reloadVectorStores(); err != nil {
+		return err
+	}
+	d.signature = signature
+	return nil
+}
+
+func (d *daemonServer) handleInfo(w http.ResponseWriter, _ *http.Request) {
+	info := daemonInfo{PID: os.Getpid(), StartedAt: d.startedAt, Config: d.config, Queries: d.queries.Load()}
+	preloadMutex.RLock()
+	if preloadedMSS != nil {
+		info.Sources = preloadedMSS.ListSources()
+	}
+	preloadMutex.RUnlock()
+	writeRemoteJSON(w, http.StatusOK, info)
+}
+
+func (d *daemonServer) handleQuery(w http.ResponseWriter, r *http.Request) {
+	var req daemonQueryRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, remoteMaxBody)).Decode(&req); err != nil {
+		writeRemoteError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+	d.queries.Add(1)
+	if err := d.refresh(); err != nil {
+		writeRemoteError(w, remoteStatus(err), err)
+		return
+	}
+
+	// the reply is a stream of events, one json object per line
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	flusher, _ := w.(http.Flusher)
+	var writeMu sync.Mutex
+	send := func(ev daemonEvent) {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		json.NewEncoder(w).Encode(ev)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	ctx := r.Context()
+	if req.Stream {
+		ctx = withTokenHandler(ctx, func(token string) { send(daemonEvent{Token: token}) })
+	}
+	resp, _, err := d.answer(ctx, req.remoteQueryRequest, func(retrieved *remoteQueryResponse) {
+		send(daemonEvent{Retrieved: retrieved})
+	})
+	if err != nil {
+		kind, _ := errorKind(err)
+		send(daemonEvent{Error: &remoteError{Error: err.Error(), Kind: kind}})
+		return
+	}
+	send(daemonEvent{Response: resp})
+}
+
+func runDaemon(_ *cobra.Command, _ []string) error {
+	if daemonDetach {
+		return detachDaemon()
+	}
+	socket := daemonSocketPath()
+	if info, err := daemonStatus(socket); err == nil {
+		return fmt.Errorf("lr daemon is already running (pid %d) on %s", info.PID, socket)
+	}
+	d := &daemonServer{config: currentDaemonConfig(), startedAt: time.Now()}
+	d.operation = "query"
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+
+	// load everything before listening: until then queries load indexes
+	// themselves rather than wait
+	start := time.Now()
+	if _, err := d.client(); err != nil {
+		return fmt.Errorf("failed to create the llm client: %w", err)
+	}
+	if err := d.refresh(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(socket), 0700); err != nil {
+		return fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	os.Remove(socket) // left behind by a daemon that didn't stop cleanly
+	lis, err := net.Listen("unix", socket)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", socket, err)
+	}
+	defer os.Remove(socket)
+	if err := os.Chmod(socket, 0600); err != nil {
+		lis.Close()
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+daemonInfoPath, d.handleInfo)
+	mux.HandleFunc("POST "+daemonQueryPath, d.handleQuery)
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGUSR1)
+	go func() {
+		for sig := range sigChan {
+			if sig == syscall.SIGUSR1 {
+				// reload on demand, as lr mcp does
+				d.mu.Lock()
+				d.signature = ""
+				d.mu.Unlock()
+				if err := d.refresh(); err != nil {
+					logger.Printf("error reloading: %v", err)
+				}
+				continue
+			}
+			logger.Println("stopping lr daemon...")
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			srv.Shutdown(ctx)
+			cancel()
+			return
+		}
+	}()
+
+	preloadMutex.RLock()
+	sources := len(preloadedMSS.Sources)
+	preloadMutex.RUnlock()
+	logger.Printf("lr daemon (pid %d) serving %d sources on %s (loaded in %s)",
+		os.Getpid(), sources, socket, time.Since(start).Round(time.Millisecond))
+	if err := srv.Serve(lis); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// detachDaemon starts the daemon in the background, logging to daemon.log in
+// the cache directory, and returns once it answers
+func detachDaemon() error {
+	socket := daemonSocketPath()
+	if info, err := daemonStatus(socket); err == nil {
+		return fmt.Errorf("lr daemon is already running (pid %d) on %s", info.PID, socket)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(getCacheDir(), 0755); err != nil {
+		return err
+	}
+	logPath := filepath.Join(getCacheDir(), "daemon.log")
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+
+	args := slices.DeleteFunc(slices.Clone(os.Args[1:]), func(a string) bool { return a == "--detach" || a == "--detach=true" })
+	child := exec.Command(exe, args...)
+	child.Stdout, child.Stderr = logFile, logFile
+	child.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+	if err := child.Start(); err != nil {
+		return fmt.Errorf("failed to start lr daemon: %w", err)
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- child.Wait() }()
+
+	// loading every index can take a while
+	for {
+		select {
+		case <-exited:
+			return fmt.Errorf("lr daemon exited during startup (see %s)", logPath)
+		case <-time.After(100 * time.Millisecond):
+		}
+		if info, err := daemonStatus(socket); err == nil {
+			fmt.Printf("lr daemon started (pid %d, %d sources), logging to %s\n", info.PID, len(info.Sources), logPath)
+			return nil
+		}
+	}
+}
+
+// daemonClient returns an http client that connects to the daemon's socket
+func daemonClient(socket string, timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				d := net.Dialer{Timeout: daemonDialTimeout}
+				return d.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+}
+
+// daemonStatus asks the daemon at socket about itself; an error means none runs
+func daemonStatus(socket string) (*daemonInfo, error) {
+	resp, err := daemonClient(socket, 2*time.Second).Get("http://lr" + daemonInfoPath)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("lr daemon: %s", resp.Status)
+	}
+	var info daemonInfo
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, fmt.Errorf("failed to parse daemon status: %w", err)
+	}
+	return &info, nil
+}
+
+func runDaemonStatus(_ *cobra.Command, _ []string) error {
+	socket := daemonSocketPath()
+	info, err := daemonStatus(socket)
+	if err != nil {
+		if jsonOutput {
+			fmt.Println(`{"running": false}`)
+			return nil
+		}
+		fmt.Println("lr daemon is not running")
+		fmt.Println("start it with 'lr daemon --detach'")
+		return nil
+	}
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Running bool `json:"running"`
+			*daemonInfo
+		}{true, info})
+	}
+	fmt.Printf("lr daemon is running (pid %d) on %s\n", info.PID, socket)
+	fmt.Printf("  up: %s, %d queries answered\n", time.Since(info.StartedAt).Round(time.Second), info.Queries)
+	fmt.Printf("  indexes: %s (%d sources: %v)\n", info.Config.IndexDir, len(info.Sources), info.Sources)
+	fmt.Printf("  embedding model: %s\n", info.Config.EmbeddingModel)
+	if info.Config.ChatModel != "" {
+		fmt.Printf("  chat model: %s\n", info.Config.ChatModel)
+	}
+	if !info.Config.equal(currentDaemonConfig()) {
+		fmt.Printf("  %s its settings differ from this shell's, so lr query here loads indexes itself\n", yellow("note:"))
+	}
+	return nil
+}
+
+func runDaemonStop(_ *cobra.Command, _ []string) error {
+	socket := daemonSocketPath()
+	info, err := daemonStatus(socket)
+	if err != nil {
+		fmt.Println("lr daemon is not running")
+		return nil
+	}
+	process, err := os.FindProcess(info.PID)
+	if err != nil {
+		return err
+	}
+	if err := process.Signal(syscall.SIGTERM); err != nil {
+		return fmt.Errorf("failed to stop lr daemon (pid %d): %w", info.PID, err)
+	}
+	for deadline := time.Now().Add(daemonStopTimeout); time.Now().Before(deadline); time.Sleep(100 * time.Millisecond) {
+		if _, err := daemonStatus(socket); err != nil {
+			fmt.Printf("stopped lr daemon (pid %d)\n", info.PID)
+			return nil
+		}
+	}
+	return fmt.Errorf("lr daemon (pid %d) didn't stop within %s", info.PID, daemonStopTimeout)
+}
+
+// daemonUsable reports whether lr query can hand this query to a daemon: the
+// daemon answers with its own defaults for everything a request doesn't carry
+func daemonUsable(cmd *cobra.Command, attachments []Document) bool {
+	if noDaemon || len(attachments) > 0 || queryAsOf != "" || queryCommit != "" {
+		return false
+	}
+	for _, flag := range []string{"score-norm", "candidate-files", "max-tokens", "temperature", "stop"} {
+		if cmd.Flags().Changed(flag) {
+			return false
+		}
+	}
+	return true
+}
+
+// runDaemonQuery answers question with a running daemon whose settings match
+// this process's. handled is false when there's none (or it went away before
+// answering), and the query should be answered locally. the answer is cached
+// under cacheKey, if given
+func runDaemonQuery(question, cacheKey string) (handled bool, err error) {
+	socket := daemonSocketPath()
+	info, err := daemonStatus(socket)
+	if err != nil || !info.Config.equal(currentDaemonConfig()) {
+		return false, nil
+	}
+
+	req := daemonQueryRequest{remoteQueryRequest: remoteQueryFromFlags(question)}
+	req.Stream = !noStream && minSimilarity == 0 && !noSynthesize
+	body, err := json.Marshal(req)
+	if err != nil {
+		return false, err
+	}
+	resp, err := daemonClient(socket, remoteClientTimeout).Post("http://lr"+daemonQueryPath, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return false, nil
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var rerr remoteError
+		if err := json.NewDecoder(resp.Body).Decode(&rerr); err != nil || rerr.Error == "" {
+			return true, fmt.Errorf("lr daemon: %s", resp.Status)
+		}
+		return true, remoteErrorWithKind(rerr)
+	}
+
+	fmt.Println(dim(fmt.Sprintf("answered by lr daemon (pid %d)", info.PID)))
+	out := &answerPrinter{question: question}
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var ev daemonEvent
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			return true, fmt.Errorf("failed to parse daemon reply: %w", err)
+		}
+		switch {
+		case ev.Retrieved != nil:
+			printRemoteRetrieval(ev.Retrieved)
+		case ev.Token != "":
+			out.token(ev.Token)
+		case ev.Error != nil:
+			if out.streamed.Len() > 0 {
+				fmt.Println()
+			}
+			return true, remoteErrorWithKind(*ev.Error)
+		case ev.Response != nil:
+			printRemoteAnswer(question, ev.Response, out)
+			if cacheKey != "" {
+				saveCachedAnswer(cacheKey, question, ev.Response)
+			}
+			return true, nil
+		}
+	}
+	return true, fmt.Errorf("lr daemon closed the connection before answering: %v", scanner.Err())
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestDaemonQueryFallsBackOnConfigMismatch(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dir)
+	t.Setenv(daemonSocketEnv, filepath.Join(dir, "d.sock"))
+	prevMock := mockLLM
+	mockLLM = true
+	defer func() {
+		mockLLM = prevMock
+		preloadedMSS = nil
+	}()
+
+	if handled, _ := runDaemonQuery("q", ""); handled {
+		t.Fatal("no daemon runs, yet the query was handled")
+	}
+
+	d := &daemonServer{config: currentDaemonConfig(), startedAt: time.Now()}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+daemonInfoPath, d.handleInfo)
+	mux.HandleFunc("POST "+daemonQueryPath, d.handleQuery)
+	lis, err := net.Listen("unix", daemonSocketPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &http.Server{Handler: mux}
+	go srv.Serve(lis)
+	defer srv.Close()
+
+	// no indexes: the daemon's error keeps its kind
+	handled, err := runDaemonQuery("q", "")
+	if !handled || !errors.Is(err, ErrNoIndex) {
+		t.Fatalf("expected a handled no index error, got %v, %v", handled, err)
+	}
+
+	// a query with other settings than the daemon's answers itself
+	mockLLM = false
+	t.Setenv("LR_EMBEDDING_MODEL", "nomic-embed-text")
+	if handled, _ := runDaemonQuery("q", ""); handled {
+		t.Error("a daemon with other settings handled the query")
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"lr/pkg/store"
+)
+
+// describeSample* bound the sample of an index shown to the chat model when
+// generating its description
+const (
+	describeSampleChunks = 12
+	describeSampleChars  = 600
+	describeSampleFiles  = 40
+)
+
+var describeCmd = &cobra.Command{
+	Use:   "describe <name> [description]",
+	Short: "Set or show the description used to route questions to an index",
+	Long: `Describe what an index contains. when several indexes are loaded, lr query
+compares the question with each description and only searches the indexes
+that match, which cuts latency and noise.
+
+  lr describe nats-go "go client for nats: connections, jetstream, kv, object store"
+  lr describe nats-go --generate    # let the chat model write it from a sample
+  lr describe nats-go               # show the current description
+  lr describe nats-go --clear`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runDescribe,
+}
+
+func runDescribe(_ *cobra.Command, args []string) error {
+	name := args[0]
+	indexDir := getDefaultIndexDir()
+	if !store.SourceExists(indexDir, name) {
+		return withKind(fmt.Errorf("no index named %s - see 'lr list'", name), ErrNoIndex)
+	}
+
+	if describeClear {
+		if err := store.SaveDescription(indexDir, name, nil); err != nil {
+			return err
+		}
+		fmt.Printf("cleared description for %s\n", name)
+		return nil
+	}
+
+	if len(args) == 1 && !describeGenerate {
+		descriptions, err := store.LoadDescriptions(indexDir)
+		if err != nil {
+			return err
+		}
+		d, ok := descriptions[name]
+		if !ok {
+			fmt.Printf("%s has no description (set one with: lr describe %s \"...\" or --generate)\n", name, name)
+			return nil
+		}
+		fmt.Println(d.Text)
+		return nil
+	}
+	if len(args) == 2 && describeGenerate {
+		return fmt.Errorf("give a description or --generate, not both")
+	}
+
+	llm, err := getLLMClient()
+	if err != nil {
+		return err
+	}
+	defer flushUsageFor(llm, "describe", name)
+
+	text := ""
+	if describeGenerate {
+		text, err = generateDescription(llm, indexDir, name)
+		if err != nil {
+			return err
+		}
+	} else {
+		text = strings.TrimSpace(args[1])
+	}
+
+	embedding, err := llm.GetEmbedding(text)
+	if err != nil {
+		return fmt.Errorf("failed to embed description: %w", err)
+	}
+	d := &store.SourceDescription{
+		Text:           text,
+		Embedding:      embedding,
+		EmbeddingModel: getCurrentEmbeddingModel(),
+		UpdatedAt:      time.Now().Format(time.RFC3339),
+	}
+	if err := store.SaveDescription(indexDir, name, d); err != nil {
+		return err
+	}
+
+	fmt.Printf("%s: %s\n", bold(name), text)
+	return nil
+}
+
+// generateDescription asks the chat model to summarize an index from a sample of
+// its files and chunks
+func generateDescription(llm LLMClient, indexDir, name string) (string, error) {
+	mss := NewMultiSourceStore(indexDir)
+	if err := mss.LoadSource(name); err != nil {
+		return "", err
+	}
+	vs := mss.Sources[name]
+	if len(vs.Chunks) == 0 {
+		return "", fmt.Errorf("index %s is empty", name)
+	}
+
+	var sample strings.Builder
+	files := vs.Metadata.IndexedFiles
+	if len(files) > describeSampleFiles {
+		files = files[:describeSampleFiles]
+	}
+	if len(files) > 0 {
+		sample.WriteString("some of the indexed files:\n" + strings.Join(files, "\n") + "\n\n")
+	}
+
+	// spread the sample across the index rather than taking the first files
+	step := len(vs.Chunks) / describeSampleChunks
+	if step == 0 {
+		step = 1
+	}
+	for i := 0; i < len(vs.Chunks) && i/step < describeSampleChunks; i += step {
+		text := vs.Chunks[i].Text
+		if len(text) > describeSampleChars {
+			text = text[:describeSampleChars]
+		}
+		sample.WriteString(fmt.Sprintf("--- %s ---\n%s\n\n", vs.Chunks[i].Source, text))
+	}
+
+	messages := []Message{
+		{Role: "system", Content: `you describe indexed code repositories and documentation so questions can be routed to the right index.
+reply with two or three sentences naming the project, its language or format, and the main topics it covers. reply with the description only.`},
+		{Role: "user", Content: fmt.Sprintf("index name: %s\n\n%s", name, sample.String())},
+	}
+	answer, err := llm.Chat(messages)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate description: %w", err)
+	}
+	return strings.TrimSpace(answer), nil
+}
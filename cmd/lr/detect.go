@@ -0,0 +1,33 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// addDetectedFileTypes adds the file names of --special-files and the
+// interpreters of --shebangs, given as name=type, to those indexed without a
+// telling extension
+func addDetectedFileTypes() error {
+	for _, set := range []struct {
+		flag    string
+		entries []string
+		types   map[string]string
+	}{
+		{"--special-files", specialFiles, specialFileTypes},
+		{"--shebangs", shebangs, shebangTypes},
+	} {
+		for _, entry := range set.entries {
+			name, docType, ok := strings.Cut(entry, "=")
+			name, docType = strings.TrimSpace(name), strings.TrimSpace(docType)
+			if !ok || name == "" || docType == "" {
+				return fmt.Errorf("invalid %s entry %q (use name=type, e.g. Earthfile=dockerfile)", set.flag, entry)
+			}
+			if set.flag == "--special-files" {
+				name = strings.ToLower(name)
+			}
+			set.types[name] = strings.ToLower(docType)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// diffIndexGrowthFiles bounds the files listed as growing the most
+const diffIndexGrowthFiles = 10
+
+var diffIndexCmd = &cobra.Command{
+	Use:   "diff-index <name> [old] [new]",
+	Short: "Compare two versions of an index",
+	Long: `Compare two versions of an index: files added and removed, chunk churn,
+size on disk and embedding model changes. use it to see what an update actually
+changed, and to catch an index ballooning because of generated files.
+
+versions are dates (YYYYMMDD, the newest version built on or before it) or
+index file paths. without them the newest version is compared with the one
+before it, which needs superseded versions kept with --keep-versions.
+
+  lr diff-index myproject                      # newest vs the version before
+  lr diff-index myproject 20250601             # as of 2025-06-01 vs newest
+  lr diff-index myproject 20250601 20250701`,
+	Args: cobra.RangeArgs(1, 3),
+	RunE: runDiffIndex,
+}
+
+// fileGrowth is the change in the number of chunks of a file
+type fileGrowth struct {
+	File   string `json:"file"`
+	Chunks int    `json:"chunks"`
+}
+
+// indexDiff describes what changed between two versions of an index
+type indexDiff struct {
+	Old           string       `json:"old"`
+	New           string       `json:"new"`
+	OldCommit     string       `json:"old_commit,omitempty"`
+	NewCommit     string       `json:"new_commit,omitempty"`
+	OldFiles      int          `json:"old_files"`
+	NewFiles      int          `json:"new_files"`
+	AddedFiles    []string     `json:"added_files"`
+	RemovedFiles  []string     `json:"removed_files"`
+	ChangedFiles  []string     `json:"changed_files"`
+	OldChunks     int          `json:"old_chunks"`
+	NewChunks     int          `json:"new_chunks"`
+	AddedChunks   int          `json:"added_chunks"`
+	RemovedChunks int          `json:"removed_chunks"`
+	OldSizeBytes  int64        `json:"old_size_bytes"`
+	NewSizeBytes  int64        `json:"new_size_bytes"`
+	OldModel      string       `json:"old_embedding_model,omitempty"`
+	NewModel      string       `json:"new_embedding_model,omitempty"`
+	OldDimensions int          `json:"old_dimensions"`
+	NewDimensions int          `json:"new_dimensions"`
+	LargestGrowth []fileGrowth `json:"largest_growth"`
+	SuspectGrowth bool         `json:"suspect_growth"`
+}
+
+// resolveIndexVersion returns the index file a diff-index version argument
+// names: a path to an index file, or a date
+func resolveIndexVersion(indexDir, name, version string) (string, error) {
+	if strings.HasSuffix(version, ".lrindex") || strings.HasSuffix(version, ".json") {
+		if _, err := os.Stat(version); err != nil {
+			return "", fmt.Errorf("cannot read index %s: %w", version, err)
+		}
+		return version, nil
+	}
+	if !isDateStamp(version) {
+		return "", fmt.Errorf("version %q is neither a date (YYYYMMDD) nor an index file", version)
+	}
+	path, err := pinnedVersion(indexDir, name, version, "")
+	if err != nil {
+		return "", err
+	}
+	if path == "" {
+		return "", withKind(fmt.Errorf("no version of %s indexed as of %s", name, version), ErrNoIndex)
+	}
+	return path, nil
+}
+
+func runDiffIndex(_ *cobra.Command, args []string) error {
+	name := args[0]
+	indexDir := getDefaultIndexDir()
+	versions, err := allIndexVersions(indexDir, name)
+	if err != nil {
+		return err
+	}
+
+	var oldPath, newPath string
+	switch len(args) {
+	case 1:
+		if len(versions) < 2 {
+			return withKind(fmt.Errorf("%s has %d version(s), need two to compare (keep superseded versions with --keep-versions, or pass an index file)", name, len(versions)), ErrNoIndex)
+		}
+		oldPath, newPath = versions[len(versions)-2], versions[len(versions)-1]
+	case 2:
+		if len(versions) == 0 {
+			return withKind(fmt.Errorf("no index named %s - see 'lr list'", name), ErrNoIndex)
+		}
+		if oldPath, err = resolveIndexVersion(indexDir, name, args[1]); err != nil {
+			return err
+		}
+		newPath = versions[len(versions)-1]
+	default:
+		if oldPath, err = resolveIndexVersion(indexDir, name, args[1]); err != nil {
+			return err
+		}
+		if newPath, err = resolveIndexVersion(indexDir, name, args[2]); err != nil {
+			return err
+		}
+	}
+
+	oldStore, err := loadWithSegments(oldPath)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", filepath.Base(oldPath), err)
+	}
+	newStore, err := loadWithSegments(newPath)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", filepath.Base(newPath), err)
+	}
+
+	d := diffIndexes(oldStore, newStore)
+	d.Old, d.New = filepath.Base(oldPath), filepath.Base(newPath)
+	d.OldSizeBytes, _, _ = reviewIndexFiles(oldPath)
+	d.NewSizeBytes, _, _ = reviewIndexFiles(newPath)
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(d)
+	}
+	printIndexDiff(d)
+	return nil
+}
+
+// diffIndexes compares the chunks of two versions of an index. chunks are
+// matched by file and text, so a re-embedded but unchanged chunk is not churn
+func diffIndexes(oldStore, newStore *VectorStore) *indexDiff {
+	count := func(vs *VectorStore) (map[string]int, map[string]int) {
+		chunks, files := make(map[string]int), make(map[string]int)
+		for _, c := range vs.Chunks {
+			chunks[chunkKey(c)]++
+			files[c.Source]++
+		}
+		return chunks, files
+	}
+	oldChunks, oldFiles := count(oldStore)
+	newChunks, newFiles := count(newStore)
+
+	d := &indexDiff{
+		OldCommit:     oldStore.Metadata.LastCommit,
+		NewCommit:     newStore.Metadata.LastCommit,
+		OldFiles:      len(oldFiles),
+		NewFiles:      len(newFiles),
+		OldChunks:     len(oldStore.Chunks),
+		NewChunks:     len(newStore.Chunks),
+		OldModel:      inferEmbeddingModel(oldStore),
+		NewModel:      inferEmbeddingModel(newStore),
+		OldDimensions: oldStore.Dimensions(),
+		NewDimensions: newStore.Dimensions(),
+		AddedFiles:    []string{},
+		RemovedFiles:  []string{},
+		ChangedFiles:  []string{},
+		LargestGrowth: []fileGrowth{},
+	}
+
+	// churn per file, counting duplicate chunks as often as they occur
+	churn := make(map[string]int)
+	for key, n := range newChunks {
+		if added := n - oldChunks[key]; added > 0 {
+			d.AddedChunks += added
+			churn[strings.SplitN(key, "\x00", 2)[0]]++
+		}
+	}
+	for key, n := range oldChunks {
+		if removed := n - newChunks[key]; removed > 0 {
+			d.RemovedChunks += removed
+			churn[strings.SplitN(key, "\x00", 2)[0]]++
+		}
+	}
+
+	for file, n := range newFiles {
+		switch {
+		case oldFiles[file] == 0:
+			d.AddedFiles = append(d.AddedFiles, file)
+		case churn[file] > 0:
+			d.ChangedFiles = append(d.ChangedFiles, file)
+		}
+		if grown := n - oldFiles[file]; grown > 0 {
+			d.LargestGrowth = append(d.LargestGrowth, fileGrowth{file, grown})
+		}
+	}
+	for file := range oldFiles {
+		if newFiles[file] == 0 {
+			d.RemovedFiles = append(d.RemovedFiles, file)
+		}
+	}
+	sort.Strings(d.AddedFiles)
+	sort.Strings(d.RemovedFiles)
+	sort.Strings(d.ChangedFiles)
+	sort.Slice(d.LargestGrowth, func(i, j int) bool {
+		if d.LargestGrowth[i].Chunks != d.LargestGrowth[j].Chunks {
+			return d.LargestGrowth[i].Chunks > d.LargestGrowth[j].Chunks
+		}
+		return d.LargestGrowth[i].File < d.LargestGrowth[j].File
+	})
+	if len(d.LargestGrowth) > diffIndexGrowthFiles {
+		d.LargestGrowth = d.LargestGrowth[:diffIndexGrowthFiles]
+	}
+
+	// an index that grows by half (and by at least 100 chunks) in one update
+	// usually picked up generated or vendored files
+	grown := d.NewChunks - d.OldChunks
+	d.SuspectGrowth = grown >= 100 && grown*2 >= d.OldChunks
+	return d
+}
+
+// signed formats a delta with its sign
+func signed(n int) string {
+	if n > 0 {
+		return fmt.Sprintf("+%d", n)
+	}
+	return fmt.Sprintf("%d", n)
+}
+
+func printIndexDiff(d *indexDiff) {
+	fmt.Printf("comparing %s → %s\n", d.Old, d.New)
+	if d.OldCommit != "" || d.NewCommit != "" {
+		fmt.Printf("  commit: %.8s → %.8s\n", d.OldCommit, d.NewCommit)
+	}
+	fmt.Printf("  files: %d → %d (%d added, %d removed, %d changed)\n",
+		d.OldFiles, d.NewFiles, len(d.AddedFiles), len(d.RemovedFiles), len(d.ChangedFiles))
+	fmt.Printf("  chunks: %d → %d (%s; %d added, %d removed)\n",
+		d.OldChunks, d.NewChunks, signed(d.NewChunks-d.OldChunks), d.AddedChunks, d.RemovedChunks)
+	sizeDelta := formatBytes(max(d.NewSizeBytes-d.OldSizeBytes, d.OldSizeBytes-d.NewSizeBytes))
+	if d.NewSizeBytes >= d.OldSizeBytes {
+		sizeDelta = "+" + sizeDelta
+	} else {
+		sizeDelta = "-" + sizeDelta
+	}
+	fmt.Printf("  size: %s → %s (%s)\n", formatBytes(d.OldSizeBytes), formatBytes(d.NewSizeBytes), sizeDelta)
+	if d.OldModel != d.NewModel || d.OldDimensions != d.NewDimensions {
+		fmt.Printf("  embedding model: %s (%d dims) → %s (%d dims) - changed\n", d.OldModel, d.OldDimensions, d.NewModel, d.NewDimensions)
+	} else {
+		fmt.Printf("  embedding model: %s (%d dims)\n", d.NewModel, d.NewDimensions)
+	}
+
+	printFiles := func(title, mark string, files []string) {
+		if len(files) == 0 {
+			return
+		}
+		fmt.Printf("\n%s:\n", title)
+		for _, f := range files {
+			fmt.Printf("  %s %s\n", mark, f)
+		}
+	}
+	printFiles("added files", "+", d.AddedFiles)
+	printFiles("removed files", "-", d.RemovedFiles)
+	printFiles("changed files", "~", d.ChangedFiles)
+
+	if len(d.LargestGrowth) > 0 {
+		fmt.Println("\nlargest growth:")
+		for _, g := range d.LargestGrowth {
+			fmt.Printf("  %s chunks  %s\n", signed(g.Chunks), g.File)
+		}
+	}
+	if d.SuspectGrowth {
+		fmt.Printf("\n⚠ the index grew by %d chunks (%d → %d). if that's generated or vendored code, add it to .lrignore and re-index\n",
+			d.NewChunks-d.OldChunks, d.OldChunks, d.NewChunks)
+	}
+}
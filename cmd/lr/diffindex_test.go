@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDiffIndexes(t *testing.T) {
+	oldStore := NewVectorStore()
+	oldStore.Add(Chunk{Text: "a", Source: "a.go"}, []float64{1})
+	oldStore.Add(Chunk{Text: "b", Source: "b.go"}, []float64{1})
+	oldStore.Add(Chunk{Text: "gone", Source: "c.go"}, []float64{1})
+
+	newStore := NewVectorStore()
+	newStore.Add(Chunk{Text: "a", Source: "a.go"}, []float64{1})
+	newStore.Add(Chunk{Text: "b changed", Source: "b.go"}, []float64{1})
+	for i := 0; i < 150; i++ {
+		newStore.Add(Chunk{Text: fmt.Sprintf("msg %d", i), Source: "api.pb.go"}, []float64{1})
+	}
+
+	d := diffIndexes(oldStore, newStore)
+	if len(d.AddedFiles) != 1 || d.AddedFiles[0] != "api.pb.go" || len(d.RemovedFiles) != 1 || d.RemovedFiles[0] != "c.go" {
+		t.Errorf("unexpected added/removed files: %v %v", d.AddedFiles, d.RemovedFiles)
+	}
+	if len(d.ChangedFiles) != 1 || d.ChangedFiles[0] != "b.go" {
+		t.Errorf("expected b.go changed, got %v", d.ChangedFiles)
+	}
+	if d.AddedChunks != 151 || d.RemovedChunks != 2 {
+		t.Errorf("expected 151 added and 2 removed chunks, got %d and %d", d.AddedChunks, d.RemovedChunks)
+	}
+	if len(d.LargestGrowth) != 1 || d.LargestGrowth[0].File != "api.pb.go" || !d.SuspectGrowth {
+		t.Errorf("expected api.pb.go flagged as suspect growth, got %+v", d.LargestGrowth)
+	}
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"lr/pkg/chunk"
+)
+
+// dryRunStdout is where lr index --dry-run --json writes its report; the
+// progress printed along the way goes to stderr instead
+var dryRunStdout = os.Stdout
+
+// dryRunReport is what lr index --dry-run --json prints: the work indexing
+// would do and what its embeddings would cost, for pre-index review and ci
+// budget gates
+type dryRunReport struct {
+	Source     string        `json:"source"`
+	Index      string        `json:"index,omitempty"`
+	TotalFiles int           `json:"total_files"`
+	Files      []dryRunFile  `json:"files"`
+	Skipped    []SkippedFile `json:"skipped"`
+	// with --update, the changes since the index was built; files and chunks
+	// then cover only the added and modified files
+	Added      []string       `json:"added,omitempty"`
+	Modified   []string       `json:"modified,omitempty"`
+	Deleted    []string       `json:"deleted,omitempty"`
+	Chunks     int            `json:"chunks"`
+	Bytes      int64          `json:"bytes"`
+	Tokens     int            `json:"tokens"`          // estimated embedding tokens
+	Noise      map[string]int `json:"noise,omitempty"` // noise chunks dropped or down-weighted, by kind
+	Costs      []dryRunCost   `json:"costs"`
+	QueryCost  *dryRunCost    `json:"query_cost,omitempty"` // a typical answered question, with the chat model
+	Violations []string       `json:"limit_violations,omitempty"`
+	Preview    []dryRunChunk  `json:"chunk_preview,omitempty"` // the chunks of the --show-chunks files
+}
+
+// dryRunFile is a file indexing would embed
+type dryRunFile struct {
+	Path       string `json:"path"`
+	Bytes      int64  `json:"bytes"`
+	Chunks     int    `json:"chunks"`
+	Tokens     int    `json:"tokens"`
+	CappedFrom int    `json:"capped_from,omitempty"` // the chunks before --max-chunks-per-file
+}
+
+// dryRunCost is the estimated cost of embedding with one model
+type dryRunCost struct {
+	Provider string  `json:"provider"`
+	Model    string  `json:"model"`
+	Cost     float64 `json:"cost"`
+	Selected bool    `json:"selected,omitempty"` // the model indexing would embed with
+}
+
+// newDryRunReport describes indexing docs into chunks, pricing the embedding
+// tokens with every embedding model lr knows the price of and the selected one
+func newDryRunReport(source string, docs []Document, chunks []Chunk, noise NoiseReport) *dryRunReport {
+	r := &dryRunReport{Source: source, Files: []dryRunFile{}, Skipped: []SkippedFile{}, Chunks: len(chunks)}
+	files := make(map[string]*dryRunFile)
+	var order []string
+	for _, doc := range docs {
+		path := chunkFilePath(doc.Source)
+		f, ok := files[path]
+		if !ok {
+			f = &dryRunFile{Path: path}
+			files[path] = f
+			order = append(order, path)
+		}
+		f.Bytes += int64(len(doc.Content))
+		r.Bytes += int64(len(doc.Content))
+	}
+	for _, c := range chunks {
+		tokens := estimateTokens(c.Text)
+		r.Tokens += tokens
+		if f, ok := files[chunkFilePath(c.Source)]; ok {
+			f.Chunks++
+			f.Tokens += tokens
+			f.CappedFrom = chunk.CappedTotal(c)
+		}
+	}
+	for _, path := range order {
+		r.Files = append(r.Files, *files[path])
+	}
+	for kind, n := range noise.Chunks {
+		if r.Noise == nil {
+			r.Noise = make(map[string]int)
+		}
+		r.Noise[string(kind)] = n
+	}
+
+	selected := getCurrentEmbeddingModel()
+	models := []string{selected}
+	for model, price := range loadPrices().Models {
+		if price.Kind == "embedding" && model != selected {
+			models = append(models, model)
+		}
+	}
+	sort.Strings(models[1:])
+	for _, model := range models {
+		price, ok := priceOf(model)
+		if !ok {
+			continue
+		}
+		r.Costs = append(r.Costs, dryRunCost{
+			Provider: price.Provider,
+			Model:    model,
+			Cost:     price.tokenCost(r.Tokens, 0),
+			Selected: model == selected,
+		})
+	}
+
+	chat := currentChatModel()
+	if cost, ok := queryCost(selected, chat); ok {
+		price, _ := priceOf(chat)
+		r.QueryCost = &dryRunCost{Provider: price.Provider, Model: chat, Cost: cost}
+	}
+	return r
+}
+
+// updateDryRunReport describes updating an index with changeSet: the added
+// and modified files are loaded and chunked with settings as the update would
+func updateDryRunReport(changeSet *ChangeSet, docType string, settings ChunkSettings) (*dryRunReport, error) {
+	var docs []Document
+	var chunks []Chunk
+	var noise NoiseReport
+	var skipped []SkippedFile
+	changed := changeSet.ChangedFiles()
+	if len(changed) > 0 {
+		loadResult, err := LoadSpecificFiles(srcPath, changed, docType, maxFileSize, splitLarge)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load changed files: %w", err)
+		}
+		chunks, noise = chunkDocuments(loadResult.Documents, settings)
+		docs, skipped = loadResult.Documents, loadResult.SkippedFiles
+	}
+
+	r := newDryRunReport(srcPath, docs, chunks, noise)
+	r.Index = outName
+	r.TotalFiles = len(changed)
+	r.Skipped = append(r.Skipped, skipped...)
+	r.Added, r.Modified, r.Deleted = changeSet.Added, changeSet.Modified, changeSet.Deleted
+	return r, nil
+}
+
+// write prints the report as indented json
+func (r *dryRunReport) write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// dryRunChunk is a chunk of a file --show-chunks previews
+type dryRunChunk struct {
+	File      string            `json:"file"`
+	N         int               `json:"n"` // position among the file's chunks, from 1
+	StartLine int               `json:"start_line,omitempty"`
+	EndLine   int               `json:"end_line,omitempty"`
+	Bytes     int               `json:"bytes"`
+	Tokens    int               `json:"tokens"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+	Text      string            `json:"text"`
+}
+
+// previewChunks chunks the --show-chunks files with settings, as indexing
+// would. the files are relative to --src, or paths inside it
+func previewChunks(docType string, settings ChunkSettings) ([]dryRunChunk, []SkippedFile, error) {
+	root, err := filepath.Abs(srcPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	var files []string
+	var missing []SkippedFile
+	for _, f := range showChunks {
+		abs, err := filepath.Abs(f)
+		rel, relErr := filepath.Rel(root, abs)
+		switch {
+		case err == nil && relErr == nil && !strings.HasPrefix(rel, "..") && fileExists(abs):
+			files = append(files, rel)
+		case fileExists(filepath.Join(srcPath, f)):
+			files = append(files, filepath.Clean(f))
+		default:
+			missing = append(missing, SkippedFile{Path: f, Reason: "not found in " + srcPath})
+		}
+	}
+	loadResult, err := LoadSpecificFiles(srcPath, files, docType, maxFileSize, splitLarge)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load --show-chunks files: %w", err)
+	}
+	loadResult.SkippedFiles = append(missing, loadResult.SkippedFiles...)
+
+	chunks, _ := chunkDocuments(loadResult.Documents, settings)
+	preview := []dryRunChunk{}
+	counts := make(map[string]int)
+	for _, c := range chunks {
+		file := chunkFilePath(c.Source)
+		counts[file]++
+		pc := dryRunChunk{File: file, N: counts[file], Bytes: len(c.Text), Tokens: estimateTokens(c.Text), Metadata: c.Metadata, Text: c.Text}
+		pc.StartLine, _ = strconv.Atoi(c.Metadata["start_line"])
+		pc.EndLine, _ = strconv.Atoi(c.Metadata["end_line"])
+		preview = append(preview, pc)
+	}
+	return preview, loadResult.SkippedFiles, nil
+}
+
+// fileExists reports whether path is an existing file
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
+
+// previewMetadataShown is the chunk metadata printChunkPreview shows on the
+// chunk's own line, rather than with the rest
+var previewMetadataShown = []string{"start_line", "end_line", "source", "type", chunkIDKey, "chunk_index"}
+
+// printChunkPreview prints how the --show-chunks files would be chunked: each
+// chunk's lines, size and metadata, and its first and last lines. scanned, if
+// not nil, holds the files the scan selected, to note those it didn't
+func printChunkPreview(preview []dryRunChunk, skipped []SkippedFile, settings ChunkSettings, scanned map[string]bool) {
+	fmt.Printf("\n=== CHUNK PREVIEW ===\n")
+	fmt.Printf("chunking: %s\n", describeChunking(settings))
+	for _, sf := range skipped {
+		fmt.Printf("\n%s %s: %s\n", yellow("warning:"), sf.Path, sf.Reason)
+	}
+	for i, c := range preview {
+		if i == 0 || preview[i-1].File != c.File {
+			total := 0
+			for _, o := range preview {
+				if o.File == c.File {
+					total++
+				}
+			}
+			fmt.Printf("\n%s %d chunks\n", bold(c.File+":"), total)
+			if scanned != nil && !scanned[c.File] {
+				fmt.Println(dim("  (not selected by the scan: a real run wouldn't index this file)"))
+			}
+		}
+		lines := ""
+		if c.StartLine > 0 {
+			lines = fmt.Sprintf("lines %d-%d, ", c.StartLine, c.EndLine)
+		}
+		fmt.Printf("  %d  %s%s, ~%d tokens%s\n", c.N, lines, formatBytes(int64(c.Bytes)), c.Tokens, chunkFlags(c.Metadata))
+
+		var keys []string
+		for k := range c.Metadata {
+			if !slices.Contains(previewMetadataShown, k) && k != chunk.NoiseMetadataKey && k != chunk.CappedMetadataKey {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Printf("     %s %s\n", dim(k+":"), c.Metadata[k])
+		}
+
+		text := strings.Split(strings.TrimSpace(c.Text), "\n")
+		if len(text) > 3 {
+			text = []string{text[0], dim(fmt.Sprintf("... %d lines ...", len(text)-2)), text[len(text)-1]}
+		}
+		for _, line := range text {
+			fmt.Printf("     │ %s\n", line)
+		}
+	}
+	if len(preview) > 0 {
+		fmt.Println(dim("\nthe full text of each chunk is in --json output (chunk_preview)"))
+	}
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"lr/pkg/chunk"
+)
+
+func TestDryRunReport(t *testing.T) {
+	prevMock := mockLLM
+	mockLLM = true
+	defer func() { mockLLM = prevMock }()
+
+	docs := []Document{{Source: "big.md (part 1)", Content: "aaaa"}, {Source: "big.md (part 2)", Content: "bb"}, {Source: "x.go", Content: "cccccccc"}}
+	chunks := []Chunk{{Source: "big.md (part 1)", Text: "aaaa"}, {Source: "big.md (part 2)", Text: "bb"}, {Source: "x.go", Text: "cccccccc"}}
+	r := newDryRunReport("src", docs, chunks, NoiseReport{})
+
+	if len(r.Files) != 2 || r.Files[0].Path != "big.md" || r.Files[0].Chunks != 2 || r.Files[0].Bytes != 6 {
+		t.Fatalf("files = %+v, want big.md's parts counted together", r.Files)
+	}
+	if r.Chunks != 3 || r.Tokens != 1+1+2 || r.Bytes != 14 {
+		t.Fatalf("totals = %d chunks, %d tokens, %d bytes", r.Chunks, r.Tokens, r.Bytes)
+	}
+	if !r.Costs[0].Selected || r.Costs[0].Provider != "mock" || r.Costs[0].Cost != 0 {
+		t.Fatalf("first cost = %+v, want the selected mock model", r.Costs[0])
+	}
+	for _, c := range r.Costs[1:] {
+		if c.Selected {
+			t.Fatalf("%s is marked selected too", c.Model)
+		}
+	}
+}
+
+func TestPreviewChunks(t *testing.T) {
+	prevSrc, prevShow := srcPath, showChunks
+	defer func() { srcPath, showChunks = prevSrc, prevShow }()
+	srcPath = t.TempDir()
+	code := "package a\n\n// Parse parses\nfunc Parse() {\n\treturn\n}\n\n// Print prints\nfunc Print() {\n\treturn\n}\n"
+	if err := os.WriteFile(filepath.Join(srcPath, "a.go"), []byte(code), 0644); err != nil {
+		t.Fatal(err)
+	}
+	showChunks = []string{"a.go", filepath.Join(srcPath, "a.go"), "missing.go"}
+
+	preview, skipped, err := previewChunks("code", chunk.NewSettings(1500, 0, chunk.NoiseFilter{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(skipped) != 1 || skipped[0].Path != "missing.go" {
+		t.Fatalf("skipped = %+v, want missing.go", skipped)
+	}
+	if len(preview) == 0 || preview[0].File != "a.go" || preview[0].N != 1 || preview[0].StartLine != 1 {
+		t.Fatalf("preview = %+v", preview)
+	}
+	for i := 1; i < len(preview); i++ {
+		if preview[i].File == preview[i-1].File && preview[i].N != preview[i-1].N+1 {
+			t.Fatalf("chunks of %s numbered %d after %d", preview[i].File, preview[i].N, preview[i-1].N)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"lr/internal/errkind"
+	"lr/pkg/llm"
+	"lr/pkg/store"
+)
+
+// error kinds that scripts and wrappers can react to; check with errors.Is
+var (
+	ErrNoIndex      = store.ErrNoIndex
+	ErrAuth         = llm.ErrAuth
+	ErrRateLimited  = llm.ErrRateLimited
+	ErrCorruptIndex = store.ErrCorruptIndex
+	ErrStaleIndex   = errors.New("index out of date")
+
+	ErrDimensionMismatch = store.ErrDimensionMismatch
+)
+
+// process exit codes for each error kind
+const (
+	exitGeneric      = 1
+	exitNoIndex      = 3
+	exitAuth         = 4
+	exitRateLimited  = 5
+	exitCorruptIndex = 6
+	exitIndexBusy    = 7
+	exitStaleIndex   = 8
+	exitDimensions   = 9
+)
+
+// errorKinds maps each kind to its machine-readable name and exit code, in match order
+var errorKinds = []struct {
+	err  error
+	name string
+	code int
+}{
+	{ErrNoIndex, "no_index", exitNoIndex},
+	{ErrAuth, "auth", exitAuth},
+	{ErrRateLimited, "rate_limited", exitRateLimited},
+	{ErrCorruptIndex, "corrupt_index", exitCorruptIndex},
+	{ErrIndexBusy, "index_busy", exitIndexBusy},
+	{ErrStaleIndex, "stale_index", exitStaleIndex},
+	{ErrDimensionMismatch, "dimension_mismatch", exitDimensions},
+}
+
+// withKind tags err with one of the error kinds above (nil stays nil)
+func withKind(err, kind error) error {
+	return errkind.With(err, kind)
+}
+
+// dimensionAdvice adds how to fix an embedding dimension mismatch to err
+func dimensionAdvice(err error) error {
+	var derr *store.DimensionError
+	if !errors.As(err, &derr) {
+		return err
+	}
+
+	var fixes []string
+	switch model := derr.EmbeddingModel; {
+	case model == llm.MockModel:
+		fixes = append(fixes, "query with --mock-llm")
+	case strings.ContainsAny(model, " '\""):
+		fixes = append(fixes, fmt.Sprintf("query with --embedding-model %s", shellQuote(model)))
+	case model != "":
+		fixes = append(fixes, fmt.Sprintf("query with --embedding-model %s", model))
+	}
+	rebuild := "rebuild the index with the current model: lr index --src <path> --out-name <name>"
+	if derr.SourcePath != "" && derr.Source != "" {
+		rebuild = fmt.Sprintf("rebuild the index with the current model: lr index --src %s --out-name %s", derr.SourcePath, derr.Source)
+	}
+	fixes = append(fixes, rebuild)
+	return fmt.Errorf("%w\nto fix: %s", err, strings.Join(fixes, ", or "))
+}
+
+// errorKind returns the machine-readable name and exit code for err
+func errorKind(err error) (string, int) {
+	for _, k := range errorKinds {
+		if errors.Is(err, k.err) {
+			return k.name, k.code
+		}
+	}
+	return "error", exitGeneric
+}
+
+// exitWithError prints err (as json with --json) and exits with its code
+func exitWithError(err error) {
+	kind, code := errorKind(err)
+	if jsonOutput {
+		json.NewEncoder(os.Stdout).Encode(map[string]interface{}{
+			"error":     err.Error(),
+			"kind":      kind,
+			"exit_code": code,
+		})
+	} else {
+		fmt.Println(err)
+	}
+	os.Exit(code)
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"lr/pkg/store"
+)
+
+var excludeCmd = &cobra.Command{
+	Use:   "exclude <name> [pattern...]",
+	Short: "Set or show the paths left out of an index's search results",
+	Long: `Leave the files matching gitignore-style patterns out of an index's search
+results, without rebuilding it: for noise that is already indexed (vendored
+code, generated files, fixtures). every query of the index applies them, on top
+of lr query --exclude-path. to stop indexing such files, use --exclude when
+indexing instead.
+
+  lr exclude nats-server 'vendor/**' '*.pb.go'
+  lr exclude nats-server --remove '*.pb.go'
+  lr exclude nats-server              # show the current patterns
+  lr exclude nats-server --clear`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runExclude,
+}
+
+func runExclude(_ *cobra.Command, args []string) error {
+	name, add := args[0], args[1:]
+	indexDir := getDefaultIndexDir()
+	if !store.SourceExists(indexDir, name) {
+		return withKind(fmt.Errorf("no index named %s - see 'lr list'", name), ErrNoIndex)
+	}
+
+	if excludeClear {
+		if err := store.SaveExcludes(indexDir, name, nil); err != nil {
+			return err
+		}
+		fmt.Printf("cleared excluded paths for %s\n", name)
+		return nil
+	}
+
+	all, err := store.LoadExcludes(indexDir)
+	if err != nil {
+		return err
+	}
+	patterns := all[name]
+	if len(add) == 0 && len(excludeRemove) == 0 {
+		printExcludes(name, patterns)
+		return nil
+	}
+
+	for _, p := range add {
+		if p = strings.TrimSpace(p); p != "" && !slices.Contains(patterns, p) {
+			patterns = append(patterns, p)
+		}
+	}
+	for _, p := range excludeRemove {
+		i := slices.Index(patterns, p)
+		if i < 0 {
+			return fmt.Errorf("%s has no excluded path %q", name, p)
+		}
+		patterns = slices.Delete(patterns, i, i+1)
+	}
+
+	if err := store.SaveExcludes(indexDir, name, patterns); err != nil {
+		return err
+	}
+	printExcludes(name, patterns)
+	return nil
+}
+
+// printExcludes prints the excluded path patterns of index name
+func printExcludes(name string, patterns []string) {
+	if len(patterns) == 0 {
+		fmt.Printf("%s excludes no paths from its results (add some with: lr exclude %s 'vendor/**')\n", name, name)
+		return
+	}
+	fmt.Printf("%s leaves out of its results:\n", bold(name))
+	for _, p := range patterns {
+		fmt.Printf("  %s\n", p)
+	}
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"lr/pkg/store"
+)
+
+// exportTimeout bounds an export (a large index is many requests)
+const exportTimeout = 30 * time.Minute
+
+var exportCmd = &cobra.Command{
+	Use:   "export <index>",
+	Short: "Copy an index's chunks and embeddings into a qdrant or chroma collection",
+	Long: `Write every chunk of an index, with its embedding and metadata, to a collection
+in qdrant or chroma, so other rag stacks can search it without re-embedding.
+Exporting again overwrites the chunks exported before (ids derive from the index
+name and the chunk's position); use --recreate to drop chunks that no longer exist.
+QDRANT_API_KEY and CHROMA_TOKEN authenticate, if set.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runExport,
+}
+
+func runExport(_ *cobra.Command, args []string) error {
+	name := args[0]
+	if !slices.Contains(store.ExportFormats, exportFormat) {
+		return fmt.Errorf("unknown export format %q (use %s)", exportFormat, strings.Join(store.ExportFormats, " or "))
+	}
+	path, err := findExistingIndex(getDefaultIndexDir(), name)
+	if err != nil {
+		return err
+	}
+	vs := NewVectorStore()
+	if err := vs.Load(path); err != nil {
+		return fmt.Errorf("failed to load %s: %w", filepath.Base(path), err)
+	}
+
+	opts := store.ExportOptions{
+		Format:     exportFormat,
+		URL:        exportURL,
+		Collection: exportColl,
+		Recreate:   exportRecreate,
+		BatchSize:  exportBatch,
+		Progress: func(done, total int) {
+			fmt.Fprintf(os.Stderr, "\r  exported %d/%d chunks", done, total)
+			if done == total {
+				fmt.Fprintln(os.Stderr)
+			}
+		},
+	}
+	switch exportFormat {
+	case "qdrant":
+		opts.APIKey = os.Getenv("QDRANT_API_KEY")
+	case "chroma":
+		opts.APIKey = os.Getenv("CHROMA_TOKEN")
+	}
+	if opts.Collection == "" {
+		opts.Collection = name
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+	if err := store.Export(ctx, vs, name, opts); err != nil {
+		return err
+	}
+	fmt.Printf("✓ exported %s to %s collection %s (%d chunks, %d dimensions)\n",
+		name, exportFormat, opts.Collection, len(vs.Chunks), vs.Dimensions())
+	return nil
+}
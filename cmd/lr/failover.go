@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"lr/pkg/llm"
+)
+
+// chatBackend creates a chat-only client for one model in the failover chain:
+// a claude or gpt model (or alias), "ollama" / "ollama:<model>" for a local model,
+// or an external chat target (plugin:<command> or an http(s) endpoint)
+func chatBackend(model string) (LLMClient, string, error) {
+	if model == "ollama" || strings.HasPrefix(model, "ollama:") {
+		client := llm.NewOllamaChatClient(strings.TrimPrefix(strings.TrimPrefix(model, "ollama"), ":"))
+		return client, "ollama:" + client.Model, nil
+	}
+
+	resolved := resolveChatModel(model)
+	switch {
+	case isExternalChatModel(resolved):
+		return NewExternalChatClient(nil, resolved), resolved, nil
+	case strings.HasPrefix(resolved, "claude-"):
+		key := os.Getenv("ANTHROPIC_API_KEY")
+		if key == "" {
+			return nil, "", withKind(fmt.Errorf("chat model %s needs ANTHROPIC_API_KEY", model), ErrAuth)
+		}
+		return llm.NewAnthropicClient(key, resolved), resolved, nil
+	case strings.HasPrefix(resolved, "gpt-") || strings.HasPrefix(resolved, "o1") || strings.HasPrefix(resolved, "o3"):
+		key := os.Getenv("OPENAI_API_KEY")
+		if key == "" {
+			return nil, "", withKind(fmt.Errorf("chat model %s needs OPENAI_API_KEY", model), ErrAuth)
+		}
+		return NewOpenAIClient(key, resolved, ""), resolved, nil
+	}
+	return nil, "", fmt.Errorf("unknown chat model %q (use a claude or gpt model, ollama[:<model>], plugin:<command> or a url)", model)
+}
+
+// withFailover wraps primary so chat falls back to --fallback-models in order
+func withFailover(primary LLMClient) (LLMClient, error) {
+	if len(fallbackModels) == 0 {
+		return primary, nil
+	}
+
+	primaryModel := resolveChatModel(chatModel)
+	if m, ok := primary.(*UsageMeter); ok {
+		primaryModel = m.ChatModel
+	}
+
+	chain := []string{primaryModel}
+	var fallbacks []llm.ChatBackend
+	for _, model := range fallbackModels {
+		model = strings.TrimSpace(model)
+		if model == "" {
+			continue
+		}
+		client, resolved, err := chatBackend(model)
+		if err != nil {
+			return nil, err
+		}
+		fallbacks = append(fallbacks, llm.ChatBackend{Model: resolved, Client: newUsageMeter(client, "", resolved)})
+		chain = append(chain, resolved)
+	}
+
+	fmt.Printf("chat failover: %s\n", strings.Join(chain, " → "))
+	return llm.NewFailoverClient(primary, primaryModel, fallbacks), nil
+}
+
+// failoverNote says which model answered when the primary chat model failed,
+// or "" if the primary answered (or there is no failover chain)
+func failoverNote(result *FailoverResult) string {
+	model, failures := result.AnsweredBy()
+	if model == "" || len(failures) == 0 {
+		return ""
+	}
+	reasons := make([]string, len(failures))
+	for i, err := range failures {
+		reasons[i] = firstLine(err.Error())
+	}
+	return fmt.Sprintf("note: answered by %s (failed over from: %s)", model, strings.Join(reasons, "; "))
+}
+
+// firstLine trims multi-line provider errors for the failover note
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// printFailoverNote prints failoverNote, if any, after an answer
+func printFailoverNote(result *FailoverResult) {
+	if note := failoverNote(result); note != "" {
+		fmt.Println("\n" + yellow(note))
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"lr/pkg/chunk"
+)
+
+// summaryFileChars bounds how much of a file is shown to the chat model when
+// summarizing it
+const summaryFileChars = 12000
+
+// summarizeFiles asks the chat model for a short summary of each file in docs
+// (the parts of a split file together) and embeds it, for the file stage of a
+// hierarchical search
+func summarizeFiles(llm LLMClient, docs []Document) ([]FileSummary, error) {
+	var files []string
+	contents := make(map[string]*strings.Builder)
+	for _, doc := range docs {
+		file := chunk.FilePath(doc.Source)
+		b, ok := contents[file]
+		if !ok {
+			b = &strings.Builder{}
+			contents[file] = b
+			files = append(files, file)
+		}
+		if b.Len() < summaryFileChars {
+			b.WriteString(doc.Content)
+			b.WriteString("\n")
+		}
+	}
+	sort.Strings(files)
+
+	bar := newProgressBar(len(files), "summarizing files")
+	summaries := make([]FileSummary, 0, len(files))
+	for _, file := range files {
+		content := contents[file].String()
+		if len(content) > summaryFileChars {
+			content = content[:summaryFileChars]
+		}
+		summary, err := summarizeFile(llm, file, content)
+		if err != nil {
+			return nil, err
+		}
+		embedding, err := llm.GetEmbedding(file + ": " + summary)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed summary of %s: %w", file, err)
+		}
+		summaries = append(summaries, FileSummary{File: file, Summary: summary, Embedding: embedding})
+		bar.Add(1)
+
+		if !mockLLM {
+			time.Sleep(50 * time.Millisecond) // rate limit
+		}
+	}
+	bar.Finish()
+	fmt.Println()
+	return summaries, nil
+}
+
+// summarizeFile asks the chat model what a file is for
+func summarizeFile(llm LLMClient, file, content string) (string, error) {
+	messages := []Message{
+		{Role: "system", Content: `you summarize source files so a search can find the files a question is about.
+reply with two or three sentences on what the file is for and the main types, functions or sections it defines. reply with the summary only.`},
+		{Role: "user", Content: fmt.Sprintf("file: %s\n\n%s", file, content)},
+	}
+	answer, err := llm.Chat(messages)
+	if err != nil {
+		return "", fmt.Errorf("failed to summarize %s: %w", file, err)
+	}
+	return strings.TrimSpace(answer), nil
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxGetFileBytes bounds the content get_file returns; larger files must be
+// read by line range. maxGetFileSize is the largest file read at all
+const (
+	maxGetFileBytes = 256 * 1024
+	maxGetFileSize  = 64 * 1024 * 1024
+)
+
+// indexedFile is a file of an indexed source
+type indexedFile struct {
+	index string // the index name
+	path  string // relative to the source directory
+	vs    *VectorStore
+}
+
+// stripPart removes the " (part n)" suffix of a split file's source
+func stripPart(source string) string {
+	if i := strings.Index(source, " (part "); i >= 0 {
+		return source[:i]
+	}
+	return source
+}
+
+// indexedFiles returns the files recorded for an index, from its chunks for
+// indexes that predate the file list
+func indexedFiles(vs *VectorStore) []string {
+	set := make(map[string]bool)
+	for _, f := range vs.Metadata.IndexedFiles {
+		set[stripPart(f)] = true
+	}
+	if len(set) == 0 {
+		for _, chunk := range vs.Chunks {
+			set[stripPart(chunk.Source)] = true
+		}
+	}
+	files := make([]string, 0, len(set))
+	for f := range set {
+		files = append(files, f)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// findIndexedFile resolves path to a file of an indexed source: an exact match of
+// its indexed path or, failing that, a unique match of its end (e.g. server.go
+// for server/server.go). only indexed files of sources with a recorded source
+// path can be read, so an agent can't reach anything else on disk
+func findIndexedFile(mss *MultiSourceStore, index, path string) (indexedFile, error) {
+	path = filepath.ToSlash(filepath.Clean(stripPart(strings.TrimSpace(path))))
+	if filepath.IsAbs(path) || path == ".." || strings.HasPrefix(path, "../") {
+		return indexedFile{}, fmt.Errorf("path must be relative to the indexed source, as shown in search results: %s", path)
+	}
+
+	if index != "" && mss.Sources[index] == nil {
+		return indexedFile{}, fmt.Errorf("index '%s' not found. available: %v", index, mss.ListSources())
+	}
+
+	var exact, partial []indexedFile
+	for name, vs := range mss.Sources {
+		if index != "" && name != index {
+			continue
+		}
+		if vs.Metadata.SourcePath == "" {
+			continue
+		}
+		for _, f := range indexedFiles(vs) {
+			switch {
+			case filepath.ToSlash(f) == path:
+				exact = append(exact, indexedFile{name, f, vs})
+			case strings.HasSuffix(filepath.ToSlash(f), "/"+path):
+				partial = append(partial, indexedFile{name, f, vs})
+			}
+		}
+	}
+	matches := exact
+	if len(matches) == 0 {
+		matches = partial
+	}
+	switch len(matches) {
+	case 0:
+		return indexedFile{}, fmt.Errorf("no indexed file matches '%s' (search_by_file lists indexed files by partial path)", path)
+	case 1:
+		return matches[0], nil
+	}
+	var names []string
+	for _, m := range matches {
+		names = append(names, m.index+":"+m.path)
+	}
+	sort.Strings(names)
+	return indexedFile{}, fmt.Errorf("'%s' matches %d files, pass the full path or the source: %s", path, len(matches), strings.Join(names, ", "))
+}
+
+// openIndexedFile opens an indexed file in its source directory, refusing paths
+// that resolve (e.g. through a symlink) outside it and files over maxGetFileSize
+func openIndexedFile(f indexedFile) (*os.File, os.FileInfo, error) {
+	root, err := filepath.EvalSymlinks(f.vs.Metadata.SourcePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("source directory of %s is not available: %w", f.index, err)
+	}
+	full, err := filepath.EvalSymlinks(filepath.Join(root, f.path))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s no longer exists in %s", f.path, root)
+	}
+	if rel, err := filepath.Rel(root, full); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, nil, fmt.Errorf("%s resolves outside the source directory of %s", f.path, f.index)
+	}
+
+	info, err := os.Stat(full)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !info.Mode().IsRegular() {
+		return nil, nil, fmt.Errorf("%s is not a regular file", f.path)
+	}
+	if info.Size() > maxGetFileSize {
+		return nil, nil, fmt.Errorf("%s is %s, too large to read (over %s)", f.path, formatBytes(info.Size()), formatBytes(maxGetFileSize))
+	}
+	file, err := os.Open(full)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read %s: %w", f.path, err)
+	}
+	return file, info, nil
+}
+
+// fileLines is the part of a file get_file returns
+type fileLines struct {
+	text        string
+	first, last int // the lines returned
+	total       int // the file's line count
+}
+
+// lineRange reads lines start through end of r (1-based, inclusive; 0 means
+// the first or last line) and counts the rest, holding at most maxGetFileBytes
+// of them in memory
+func lineRange(r io.Reader, start, end int) (fileLines, error) {
+	first := max(start, 1)
+	var text strings.Builder
+	total := 0
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxGetFileBytes)
+	scanner.Split(scanLinesWithEnds)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if bytes.IndexByte(line, 0) >= 0 {
+			return fileLines{}, fmt.Errorf("binary file")
+		}
+		total++
+		if total < first || (end > 0 && total > end) {
+			continue
+		}
+		if text.Len()+len(line) > maxGetFileBytes {
+			return fileLines{}, fmt.Errorf("lines %d-%d are over the %d byte limit; request a smaller range", first, max(end, total), maxGetFileBytes)
+		}
+		text.Write(line)
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return fileLines{}, fmt.Errorf("line %d is over the %d byte limit", total+1, maxGetFileBytes)
+		}
+		return fileLines{}, err
+	}
+
+	if total == 0 && start <= 1 {
+		return fileLines{}, nil
+	}
+	last := end
+	if last <= 0 || last > total {
+		last = total
+	}
+	if first > total || first > last {
+		return fileLines{first: first, last: last, total: total}, fmt.Errorf("line range %d-%d is outside the file (%d lines)", first, last, total)
+	}
+	return fileLines{text: text.String(), first: first, last: last, total: total}, nil
+}
+
+// scanLinesWithEnds is bufio.ScanLines keeping each line's newline
+func scanLinesWithEnds(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if i := bytes.IndexByte(data, '\n'); i >= 0 {
+		return i + 1, data[:i+1], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+func handleGetFile(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok {
+		return mcp.NewToolResultError("invalid arguments"), nil
+	}
+	path, ok := args["path"].(string)
+	if !ok || path == "" {
+		return mcp.NewToolResultError("path parameter is required"), nil
+	}
+	source, _ := args["source"].(string)
+	var start, end int
+	if v, ok := args["start_line"].(float64); ok {
+		start = int(v)
+	}
+	if v, ok := args["end_line"].(float64); ok {
+		end = int(v)
+	}
+
+	mss, err := currentStores()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load indexes: %v", err)), nil
+	}
+
+	// "index:path", as search results from several sources show it
+	if source == "" {
+		if name, rest, found := strings.Cut(path, ":"); found && (mss.Sources[name] != nil || slices.Contains(unloadedSources(mss), name)) {
+			source, path = name, rest
+		}
+	}
+	var sources []string
+	if source != "" {
+		sources = append(sources, source)
+	}
+	if mss, err = storesFor(sources...); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load indexes: %v", err)), nil
+	}
+
+	f, err := findIndexedFile(mss, source, path)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	file, info, err := openIndexedFile(f)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	defer file.Close()
+
+	ranged := start > 0 || end > 0
+	if !ranged && info.Size() > maxGetFileBytes {
+		return mcp.NewToolResultError(fmt.Sprintf("%s is %d bytes, over the %d byte limit; request part of it with start_line and end_line",
+			f.path, info.Size(), maxGetFileBytes)), nil
+	}
+	lines, err := lineRange(file, start, end)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("%s: %v", f.path, err)), nil
+	}
+
+	response := fmt.Sprintf("=== %s:%s (%d lines, %d bytes) ===\n", f.index, f.path, lines.total, info.Size())
+	if ranged {
+		response += fmt.Sprintf("lines %d-%d\n", lines.first, lines.last)
+	}
+	if indexedAt, err := time.Parse(time.RFC3339, f.vs.Metadata.IndexedAt); err == nil && info.ModTime().After(indexedAt) {
+		response += fmt.Sprintf("note: modified since the index was built (%s); search results may be stale\n", f.vs.Metadata.IndexedAt)
+	}
+	response += "\n" + lines.text
+	return mcp.NewToolResultText(response), nil
+}
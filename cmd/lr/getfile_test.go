@@ -0,0 +1,115 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGetFilePathValidation(t *testing.T) {
+	root := t.TempDir()
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	for path, content := range map[string]string{
+		"server/auth.go": "package server\n\nfunc Auth() {}\n",
+		".env":           "TOKEN=x\n",
+		outside:          "secret\n",
+	} {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(root, path)
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// an indexed file that was replaced by a symlink out of the source directory
+	if err := os.Symlink(outside, filepath.Join(root, "link.go")); err != nil {
+		t.Fatal(err)
+	}
+
+	vs := NewVectorStore()
+	vs.Metadata.SourcePath = root
+	vs.Metadata.IndexedFiles = []string{"server/auth.go", "link.go"}
+	mss := NewMultiSourceStore(t.TempDir())
+	mss.Sources["proj"] = vs
+
+	f, err := findIndexedFile(mss, "", "auth.go")
+	if err != nil || f.path != "server/auth.go" {
+		t.Fatalf("expected a partial path to resolve to server/auth.go, got %+v, %v", f, err)
+	}
+	file, _, err := openIndexedFile(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines, err := lineRange(file, 0, 0)
+	file.Close()
+	if err != nil || !strings.Contains(lines.text, "func Auth") {
+		t.Fatalf("expected the file content, got %q, %v", lines.text, err)
+	}
+
+	for _, path := range []string{".env", "../secret.txt", "server/../../secret.txt", outside} {
+		if _, err := findIndexedFile(mss, "", path); err == nil {
+			t.Errorf("expected %s to be refused", path)
+		}
+	}
+
+	f, err = findIndexedFile(mss, "", "link.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := openIndexedFile(f); err == nil || !strings.Contains(err.Error(), "outside") {
+		t.Errorf("expected a symlink out of the source directory to be refused, got %v", err)
+	}
+
+	// files over the hard cap aren't read at all, not even by line range
+	huge, err := os.Create(filepath.Join(root, "huge.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	huge.Truncate(maxGetFileSize + 1)
+	huge.Close()
+	if _, _, err := openIndexedFile(indexedFile{index: "proj", path: "huge.log", vs: vs}); err == nil || !strings.Contains(err.Error(), "too large") {
+		t.Errorf("expected a file over the cap refused, got %v", err)
+	}
+}
+
+func TestLineRange(t *testing.T) {
+	lines, err := lineRange(strings.NewReader("a\nb\nc"), 2, 0)
+	if err != nil || lines.text != "b\nc" || lines.first != 2 || lines.last != 3 || lines.total != 3 {
+		t.Errorf("unexpected range: %+v, %v", lines, err)
+	}
+	if _, err := lineRange(strings.NewReader("a\n"), 5, 9); err == nil {
+		t.Error("expected an error for a range past the end")
+	}
+
+	// a range of a large file holds only its lines; too many are refused
+	big := strings.Repeat(strings.Repeat("x", 99)+"\n", 10000)
+	if lines, err := lineRange(strings.NewReader(big), 5000, 5001); err != nil || len(lines.text) != 200 || lines.total != 10000 {
+		t.Errorf("unexpected range of a large file: %d bytes, %d lines, %v", len(lines.text), lines.total, err)
+	}
+	if _, err := lineRange(strings.NewReader(big), 1, 5000); err == nil || !strings.Contains(err.Error(), "byte limit") {
+		t.Errorf("expected a range over the byte limit refused, got %v", err)
+	}
+	if _, err := lineRange(strings.NewReader("a\x00b\n"), 0, 0); err == nil || !strings.Contains(err.Error(), "binary") {
+		t.Errorf("expected a binary file refused, got %v", err)
+	}
+}
+
+func TestParseChunkID(t *testing.T) {
+	index, file, ref, err := parseChunkID("nats-server:server/auth#2.go#3f2a9c1e0b4d")
+	if err != nil || index != "nats-server" || file != "server/auth#2.go" || ref != "3f2a9c1e0b4d" {
+		t.Errorf("unexpected parse: %q %q %q %v", index, file, ref, err)
+	}
+	// positional ids from earlier versions are still accepted
+	if _, _, ref, err := parseChunkID("repo:auth.go#3"); err != nil || ref != "3" {
+		t.Errorf("unexpected parse of a positional id: %q %v", ref, err)
+	}
+	for _, bad := range []string{"auth.go#3", "repo:auth.go", "repo:auth.go#0", "repo:#1", ":a.go#1", "repo:a.go#3f2a9c1e"} {
+		if _, _, _, err := parseChunkID(bad); err == nil {
+			t.Errorf("expected %q to be rejected", bad)
+		}
+	}
+}
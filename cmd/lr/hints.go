@@ -0,0 +1,93 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"lr/pkg/store"
+)
+
+var hintsCmd = &cobra.Command{
+	Use:   "hints <name>",
+	Short: "Set or show the prompt hints (about blurb and glossary) for an index",
+	Long: `Attach a short "about this codebase" blurb and a glossary of its terminology
+to an index. whenever the index contributes chunks to an answer, the hints are
+added to the synthesis prompt, which helps with domain-specific naming.
+
+  lr hints nats-server --about "nats message broker server written in go"
+  lr hints nats-server --term "JS=jetstream, the persistence layer" --term "KV=key-value store built on streams"
+  lr hints nats-server --remove-term KV
+  lr hints nats-server              # show the current hints
+  lr hints nats-server --clear`,
+	Args: cobra.ExactArgs(1),
+	RunE: runHints,
+}
+
+func runHints(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	indexDir := getDefaultIndexDir()
+	if !store.SourceExists(indexDir, name) {
+		return withKind(fmt.Errorf("no index named %s - see 'lr list'", name), ErrNoIndex)
+	}
+
+	if hintsClear {
+		if err := store.SaveHints(indexDir, name, nil); err != nil {
+			return err
+		}
+		fmt.Printf("cleared hints for %s\n", name)
+		return nil
+	}
+
+	all, err := store.LoadHints(indexDir)
+	if err != nil {
+		return err
+	}
+	hints := all[name]
+
+	flags := cmd.Flags()
+	if !flags.Changed("about") && len(hintsTerms) == 0 && len(hintsRemoveTerms) == 0 {
+		if hints.Empty() {
+			fmt.Printf("%s has no hints (set them with: lr hints %s --about \"...\" --term \"name=meaning\")\n", name, name)
+			return nil
+		}
+		fmt.Print(hints.Format(name))
+		return nil
+	}
+
+	if flags.Changed("about") {
+		hints.About = strings.TrimSpace(hintsAbout)
+	}
+	glossary := make(map[string]string, len(hints.Glossary))
+	for term, meaning := range hints.Glossary {
+		glossary[term] = meaning
+	}
+	for _, t := range hintsTerms {
+		term, meaning, ok := strings.Cut(t, "=")
+		term, meaning = strings.TrimSpace(term), strings.TrimSpace(meaning)
+		if !ok || term == "" || meaning == "" {
+			return fmt.Errorf("invalid --term %q (use name=meaning)", t)
+		}
+		glossary[term] = meaning
+	}
+	for _, term := range hintsRemoveTerms {
+		if _, ok := glossary[term]; !ok {
+			return fmt.Errorf("%s has no glossary term %q", name, term)
+		}
+		delete(glossary, term)
+	}
+	hints.Glossary = glossary
+	hints.UpdatedAt = time.Now().Format(time.RFC3339)
+
+	if err := store.SaveHints(indexDir, name, &hints); err != nil {
+		return err
+	}
+	if hints.Empty() {
+		fmt.Printf("cleared hints for %s\n", name)
+		return nil
+	}
+	fmt.Print(hints.Format(name))
+	return nil
+}
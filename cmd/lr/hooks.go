@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// markers delimiting the block lr manages inside a git hook, so existing hooks are preserved
+const (
+	hookBeginMarker = "# >>> lr auto-update >>>"
+	hookEndMarker   = "# <<< lr auto-update <<<"
+)
+
+// gitHookNames are the hooks that fire when the working tree picks up new commits
+var gitHookNames = []string{"post-commit", "post-merge"}
+
+var hooksCmd = &cobra.Command{
+	Use:   "hooks",
+	Short: "Manage git hooks that keep an index up to date",
+	Long:  `Install or remove git hooks that incrementally update an index after commits and merges.`,
+}
+
+var hooksInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install post-commit and post-merge hooks in the current repo",
+	Long: `Write post-commit and post-merge hooks into the current git repository that run
+'lr index --update' in the background, so the index tracks the repo automatically.
+
+Existing hooks are kept; lr only manages its own marked block, placed right
+after the shebang so it runs even if the hook exits early.`,
+	Args: cobra.NoArgs,
+	RunE: runHooksInstall,
+}
+
+var hooksUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Remove lr's hooks from the current repo",
+	Args:  cobra.NoArgs,
+	RunE:  runHooksUninstall,
+}
+
+// gitRepoPaths returns the top-level directory and the hooks directory of the
+// repo containing the current directory (honoring core.hooksPath)
+func gitRepoPaths() (topLevel, hooksDir string, err error) {
+	out, err := exec.Command("git", "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return "", "", fmt.Errorf("not inside a git repository")
+	}
+	topLevel = strings.TrimSpace(string(out))
+
+	cmd := exec.Command("git", "rev-parse", "--git-path", "hooks")
+	cmd.Dir = topLevel
+	out, err = cmd.Output()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to locate git hooks dir: %w", err)
+	}
+	hooksDir = strings.TrimSpace(string(out))
+	if !filepath.IsAbs(hooksDir) {
+		hooksDir = filepath.Join(topLevel, hooksDir)
+	}
+	return topLevel, hooksDir, nil
+}
+
+// hookBlock returns the shell snippet that updates the index in the background
+func hookBlock(lrPath, srcDir, name string) string {
+	logFile := filepath.Join(filepath.Dir(getDataDir()), "hooks.log")
+	return fmt.Sprintf(`%s
+# installed by 'lr hooks install' - updates the %s index in the background
+(%s index --update --src %s --out-name %s --wait >>%s 2>&1 &)
+%s
+`, hookBeginMarker, name, shellQuote(lrPath), shellQuote(srcDir), shellQuote(name), shellQuote(logFile), hookEndMarker)
+}
+
+// shellQuote single-quotes s for use in a sh script
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// stripHookBlock removes lr's block from a hook script, returning the rest unchanged
+func stripHookBlock(script string) string {
+	start := strings.Index(script, hookBeginMarker)
+	if start < 0 {
+		return script
+	}
+	end := strings.Index(script[start:], hookEndMarker)
+	if end < 0 {
+		return script
+	}
+	end += start + len(hookEndMarker)
+	if end < len(script) && script[end] == '\n' {
+		end++
+	}
+	return script[:start] + script[end:]
+}
+
+// insertHookBlock puts lr's block in a hook script right after its shebang,
+// replacing any earlier block. hooks often end in exit or exec (husky,
+// pre-commit), so a block appended after them would never run
+func insertHookBlock(script, block string) string {
+	script = stripHookBlock(script)
+	if !strings.HasPrefix(script, "#!") {
+		return "#!/bin/sh\n" + block + script
+	}
+	shebang, rest, _ := strings.Cut(script, "\n")
+	return shebang + "\n" + block + rest
+}
+
+func runHooksInstall(_ *cobra.Command, _ []string) error {
+	topLevel, hooksDir, err := gitRepoPaths()
+	if err != nil {
+		return err
+	}
+
+	name := hookIndexName
+	if name == "" {
+		name = filepath.Base(topLevel)
+	}
+
+	execPath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to determine executable path: %w", err)
+	}
+	if realPath, err := filepath.EvalSymlinks(execPath); err == nil {
+		execPath = realPath
+	}
+
+	if _, err := findExistingIndex(getDefaultIndexDir(), name); err != nil {
+		fmt.Printf("warning: no index named %s yet - create it first with:\n", name)
+		fmt.Printf("  lr index --src %s --out-name %s\n\n", topLevel, name)
+	}
+
+	if err := ensureDir(hooksDir); err != nil {
+		return fmt.Errorf("failed to create hooks dir: %w", err)
+	}
+
+	block := hookBlock(execPath, topLevel, name)
+	for _, hook := range gitHookNames {
+		path := filepath.Join(hooksDir, hook)
+		var existing string
+		if data, err := os.ReadFile(path); err == nil {
+			existing = string(data)
+		}
+		if err := os.WriteFile(path, []byte(insertHookBlock(existing, block)), 0755); err != nil {
+			return fmt.Errorf("failed to write %s hook: %w", hook, err)
+		}
+		// WriteFile keeps the mode of an existing file, so make sure it's executable
+		if err := os.Chmod(path, 0755); err != nil {
+			return fmt.Errorf("failed to make %s hook executable: %w", hook, err)
+		}
+		fmt.Printf("installed %s\n", path)
+	}
+
+	fmt.Printf("\nindex %s will be updated after each commit and merge\n", name)
+	fmt.Println("run 'lr hooks uninstall' to remove the hooks")
+	return nil
+}
+
+func runHooksUninstall(_ *cobra.Command, _ []string) error {
+	_, hooksDir, err := gitRepoPaths()
+	if err != nil {
+		return err
+	}
+
+	removed := 0
+	for _, hook := range gitHookNames {
+		path := filepath.Join(hooksDir, hook)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		script := stripHookBlock(string(data))
+		if script == string(data) {
+			continue
+		}
+
+		// drop the hook entirely if only the shebang is left
+		if strings.TrimSpace(script) == "#!/bin/sh" || strings.TrimSpace(script) == "" {
+			err = os.Remove(path)
+		} else {
+			err = os.WriteFile(path, []byte(script), 0755)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to update %s hook: %w", hook, err)
+		}
+		fmt.Printf("removed lr from %s\n", path)
+		removed++
+	}
+
+	if removed == 0 {
+		fmt.Println("no lr hooks installed")
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestInsertHookBlock(t *testing.T) {
+	block := hookBlock("/usr/local/bin/lr", "/src/proj", "proj")
+	existing := "#!/usr/bin/env bash\n. \"$(dirname \"$0\")/_/husky.sh\"\nnpx lint-staged\nexit 0\n"
+
+	script := insertHookBlock(existing, block)
+	if !strings.HasPrefix(script, "#!/usr/bin/env bash\n"+hookBeginMarker) {
+		t.Fatalf("expected the block right after the shebang, got:\n%s", script)
+	}
+	if strings.Index(script, hookEndMarker) > strings.Index(script, "exit 0") {
+		t.Errorf("the block runs after the hook's exit:\n%s", script)
+	}
+	if stripHookBlock(script) != existing {
+		t.Errorf("removing the block doesn't restore the hook:\n%s", stripHookBlock(script))
+	}
+
+	// installing again replaces the block instead of adding another
+	if again := insertHookBlock(script, block); again != script {
+		t.Errorf("reinstalling changed the hook:\n%s", again)
+	}
+
+	if script := insertHookBlock("", block); script != "#!/bin/sh\n"+block {
+		t.Errorf("new hook = %q", script)
+	}
+}
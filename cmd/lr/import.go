@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"lr/pkg/store"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import <dump>",
+	Short: "Import a corpus embedded by langchain, llamaindex or another tool as an index",
+	Long: `Turn a vector dump into an lr index, so lr query and the mcp server search it
+alongside code indexes without re-embedding it. Reads langchain InMemoryVectorStore
+dumps, llamaindex persist directories, json/jsonl records (text, embedding,
+metadata) and parquet tables of them (with the duckdb cli).
+
+Queries embed questions with lr's embedding model, so the dump must have been
+embedded with the same one: set it with --embedding-model.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runImport,
+}
+
+func runImport(_ *cobra.Command, args []string) error {
+	if !slices.Contains(store.ImportFormats, importFormat) {
+		return fmt.Errorf("unknown import format %q (use %s)", importFormat, strings.Join(store.ImportFormats, ", "))
+	}
+	indexDir := getDefaultIndexDir()
+
+	// don't replace an index built from a source with a dump
+	if existing, err := findExistingIndex(indexDir, importName); err == nil {
+		prev := NewVectorStore()
+		if prev.Load(existing) == nil && prev.Metadata.ImportedFrom == "" {
+			return fmt.Errorf("index %s exists and wasn't imported; choose another --out-name", importName)
+		}
+	}
+
+	vs, err := store.ImportDump(args[0], importFormat)
+	if err != nil {
+		return fmt.Errorf("failed to import %s: %w", args[0], err)
+	}
+	vs.Metadata.EmbeddingModel = getCurrentEmbeddingModel()
+
+	// queries must produce embeddings of the dump's size
+	if err := checkImportDimensions(vs); err != nil {
+		return err
+	}
+
+	lock, err := acquireIndexLock(indexDir, importName, waitLock)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	path := filepath.Join(indexDir, fmt.Sprintf("%s_%s.lrindex", importName, time.Now().Format("20060102")))
+	if err := atomicSave(vs, path); err != nil {
+		return fmt.Errorf("failed to save vector store: %w", err)
+	}
+	fmt.Printf("✓ imported %d chunks from %d source(s) as %s (%d dims, queried with %s)\n",
+		len(vs.Chunks), vs.Metadata.FileCount, importName, vs.Dimensions(), vs.Metadata.EmbeddingModel)
+	cleanupSupersededIndexes(indexDir, importName, path)
+	return mirrorToPG(path, vs)
+}
+
+// checkImportDimensions embeds a probe with the query embedding model and
+// compares its size to the dump's. without a working embedder it only warns
+func checkImportDimensions(vs *VectorStore) error {
+	llm, err := getLLMClient()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: can't check the dump against the embedding model: %v\n", err)
+		return nil
+	}
+	defer flushUsageFor(llm, "import", importName)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	probe, err := getEmbeddingContext(ctx, llm, "lr import")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: can't check the dump against the embedding model: %v\n", err)
+		return nil
+	}
+	if len(probe) != vs.Dimensions() {
+		return fmt.Errorf("the dump's embeddings have %d dims but %s makes %d; set --embedding-model to the model the dump was embedded with",
+			vs.Dimensions(), vs.Metadata.EmbeddingModel, len(probe))
+	}
+	return nil
+}
@@ -77,6 +77,21 @@ func getGitBehindCount(repoDir string) int {
 	return count
 }
 
+// getGitCommitsSince returns how many commits HEAD is ahead of commit, 0 if
+// it isn't or the check fails (not a git repository, commit unknown)
+func getGitCommitsSince(repoDir, commit string) int {
+	cmd := exec.Command("git", "rev-list", "--count", commit+"..HEAD")
+	cmd.Dir = repoDir
+	output, err := cmd.Output()
+	if err != nil {
+		return 0
+	}
+
+	var count int
+	fmt.Sscanf(strings.TrimSpace(string(output)), "%d", &count)
+	return count
+}
+
 // detectChangesGit uses git diff to find changed files since last commit
 func detectChangesGit(repoDir string, lastCommit string, extensions []string) (*ChangeSet, error) {
 	cs := &ChangeSet{}
@@ -109,7 +124,7 @@ func detectChangesGit(repoDir string, lastCommit string, extensions []string) (*
 		path := parts[len(parts)-1] // use last part (handles renames)
 
 		// filter by extension
-		if !hasMatchingExtension(path, extensions) {
+		if !selectsFile(filepath.Join(repoDir, path), extensions) {
 			continue
 		}
 
@@ -124,7 +139,7 @@ func detectChangesGit(repoDir string, lastCommit string, extensions []string) (*
 			// treat as delete old + add new
 			if len(parts) >= 3 {
 				oldPath := parts[1]
-				if hasMatchingExtension(oldPath, extensions) {
+				if selectsFile(filepath.Join(repoDir, oldPath), extensions) {
 					cs.Deleted = append(cs.Deleted, oldPath)
 				}
 			}
@@ -167,7 +182,7 @@ func detectChangesMtime(rootDir string, indexedAt time.Time, indexedFiles []stri
 		relPath, _ := filepath.Rel(rootDir, path)
 
 		// filter by extension
-		if !hasMatchingExtension(relPath, extensions) {
+		if !selectsFile(path, extensions) {
 			return nil
 		}
 
@@ -204,16 +219,6 @@ func detectChangesMtime(rootDir string, indexedAt time.Time, indexedFiles []stri
 	return cs, nil
 }
 
-// hasMatchingExtension checks if path has one of the given extensions
-func hasMatchingExtension(path string, extensions []string) bool {
-	for _, ext := range extensions {
-		if strings.HasSuffix(strings.ToLower(path), ext) {
-			return true
-		}
-	}
-	return false
-}
-
 // findExistingIndex finds the most recent index file matching the name pattern
 func findExistingIndex(indexDir, name string) (string, error) {
 	pattern := filepath.Join(indexDir, name+"_*.lrindex")
@@ -222,13 +227,21 @@ func findExistingIndex(indexDir, name string) (string, error) {
 		return "", err
 	}
 
-	if len(matches) == 0 {
-		return "", fmt.Errorf("no existing index found matching %s", name)
+	// ignore in-progress checkpoint and temp files
+	var valid []string
+	for _, m := range matches {
+		if !isPartialIndexFile(m) {
+			valid = append(valid, m)
+		}
+	}
+
+	if len(valid) == 0 {
+		return "", withKind(fmt.Errorf("no existing index found matching %s", name), ErrNoIndex)
 	}
 
 	// return most recent (they're date-stamped, so alphabetically last)
 	var latest string
-	for _, m := range matches {
+	for _, m := range valid {
 		if m > latest {
 			latest = m
 		}
@@ -236,39 +249,3 @@ func findExistingIndex(indexDir, name string) (string, error) {
 
 	return latest, nil
 }
-
-// atomicSave saves to a temp file, validates, then renames to final path
-func atomicSave(vs *VectorStore, finalPath string) error {
-	// write to temp file (keep .lrindex extension for proper gzip compression)
-	var tempPath string
-	if strings.HasSuffix(finalPath, ".lrindex") {
-		tempPath = strings.Replace(finalPath, ".lrindex", ".tmp.lrindex", 1)
-	} else {
-		tempPath = finalPath + ".tmp"
-	}
-	if err := vs.Save(tempPath); err != nil {
-		return fmt.Errorf("failed to save temp file: %w", err)
-	}
-
-	// validate by loading
-	testVs := NewVectorStore()
-	if err := testVs.Load(tempPath); err != nil {
-		os.Remove(tempPath)
-		return fmt.Errorf("validation failed - temp file corrupt: %w", err)
-	}
-
-	// verify chunk count matches
-	if len(testVs.Chunks) != len(vs.Chunks) {
-		os.Remove(tempPath)
-		return fmt.Errorf("validation failed - chunk count mismatch: got %d, expected %d",
-			len(testVs.Chunks), len(vs.Chunks))
-	}
-
-	// atomic rename
-	if err := os.Rename(tempPath, finalPath); err != nil {
-		os.Remove(tempPath)
-		return fmt.Errorf("failed to rename temp file: %w", err)
-	}
-
-	return nil
-}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// IndexInfo is the machine-readable description of an index printed by lr list --json
+type IndexInfo struct {
+	Name           string `json:"name"`
+	File           string `json:"file"`
+	Path           string `json:"path"`
+	SourcePath     string `json:"source_path,omitempty"`
+	ImportedFrom   string `json:"imported_from,omitempty"` // set for indexes made by lr import
+	IndexedAt      string `json:"indexed_at,omitempty"`
+	Chunks         int    `json:"chunks"`
+	FilesIndexed   int    `json:"files_indexed"`
+	EmbeddingModel string `json:"embedding_model,omitempty"`
+	Dimensions     int    `json:"dimensions"`
+	Compatible     *bool  `json:"compatible,omitempty"` // nil when no embedding model is configured
+	SizeBytes      int64  `json:"size_bytes"`
+	Format         string `json:"format"` // gzip or json
+	LastCommit     string `json:"last_commit,omitempty"`
+	Branch         string `json:"branch,omitempty"`
+	Review         bool   `json:"review,omitempty"`
+	Stale          *bool  `json:"stale,omitempty"` // nil when the source can't be checked
+	ChangedFiles   int    `json:"changed_files"`
+	CommitsBehind  int    `json:"commits_behind,omitempty"` // commits on the source since the indexed one
+	Description    string `json:"description,omitempty"`    // set with lr describe
+	Error          string `json:"error,omitempty"`
+}
+
+// listIndexFiles returns the index files in indexDir (.lrindex, or .json for backward
+// compat), skipping checkpoint and temp files. a missing dir has no indexes
+func listIndexFiles(indexDir string) ([]string, error) {
+	if _, err := os.Stat(indexDir); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	patterns := []string{
+		filepath.Join(indexDir, "*.lrindex"),
+		filepath.Join(indexDir, "*.json"),
+	}
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("error searching for indexes: %w", err)
+		}
+		for _, file := range matches {
+			if !isPartialIndexFile(file) {
+				files = append(files, file)
+			}
+		}
+	}
+	return files, nil
+}
+
+// inferEmbeddingModel returns the recorded embedding model, falling back to
+// a guess from the vector dimensions for indexes created before it was stored.
+// returns "" when the model can't be determined
+func inferEmbeddingModel(vs *VectorStore) string {
+	if vs.Metadata.EmbeddingModel != "" {
+		return vs.Metadata.EmbeddingModel
+	}
+	dims := vs.Dimensions()
+	switch dims {
+	case 768:
+		return "nomic-embed-text"
+	case 1536:
+		return "text-embedding-3-small"
+	case 1024:
+		return "voyage-code-2"
+	}
+	return ""
+}
+
+// indexFileFormat reports whether an index file is gzip compressed or plain json
+func indexFileFormat(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return "unknown"
+	}
+	defer f.Close()
+	header := make([]byte, 2)
+	if n, _ := f.Read(header); n == 2 && header[0] == 0x1f && header[1] == 0x8b {
+		return "gzip"
+	}
+	return "json"
+}
+
+// indexedExtensions returns the file extensions present in the index, so staleness
+// checks look at the same kinds of files that were indexed
+func indexedExtensions(files []string) []string {
+	seen := make(map[string]bool)
+	var exts []string
+	for _, f := range files {
+		ext := strings.ToLower(filepath.Ext(f))
+		if ext == "" && strings.HasSuffix(strings.ToLower(f), "dockerfile") {
+			ext = "dockerfile"
+		} else if _, special := specialFileTypes[strings.ToLower(filepath.Base(f))]; special || ext == "" {
+			ext = detectedFiles // build files and scripts
+		}
+		if ext != "" && !seen[ext] {
+			seen[ext] = true
+			exts = append(exts, ext)
+		}
+	}
+	return exts
+}
+
+// indexStaleness is how far an index is behind its source
+type indexStaleness struct {
+	ChangedFiles int // files added, modified or deleted since indexing
+	Commits      int // commits since the indexed one, for git sources
+}
+
+// Stale reports whether the index should be updated before its answers are trusted
+func (s indexStaleness) Stale() bool {
+	return s.ChangedFiles > 0 || s.Commits > 0
+}
+
+// String describes the staleness, e.g. "3 file(s) changed, 2 commit(s) since indexing"
+func (s indexStaleness) String() string {
+	var parts []string
+	if s.ChangedFiles > 0 {
+		parts = append(parts, fmt.Sprintf("%d file(s) changed", s.ChangedFiles))
+	}
+	if s.Commits > 0 {
+		parts = append(parts, fmt.Sprintf("%d commit(s)", s.Commits))
+	}
+	return strings.Join(parts, ", ") + " since indexing"
+}
+
+// sourceChanges compares the source to the index: the files added, modified or
+// deleted since it was built, and for git sources the commits made since its
+// LastCommit. ok is false if the source is missing or the index has no timestamp
+func sourceChanges(vs *VectorStore) (s indexStaleness, ok bool) {
+	if vs.Metadata.SourcePath == "" || vs.Metadata.IndexedAt == "" {
+		return s, false
+	}
+	if _, err := os.Stat(vs.Metadata.SourcePath); err != nil {
+		return s, false
+	}
+	indexedAt, err := time.Parse(time.RFC3339, vs.Metadata.IndexedAt)
+	if err != nil {
+		return s, false
+	}
+	exts := indexedExtensions(vs.Metadata.IndexedFiles)
+	if len(exts) == 0 {
+		return s, false
+	}
+	cs, err := detectChangesMtime(vs.Metadata.SourcePath, indexedAt, vs.Metadata.IndexedFiles, exts)
+	if err != nil {
+		return s, false
+	}
+	s.ChangedFiles = len(cs.Added) + len(cs.Modified) + len(cs.Deleted)
+	if vs.Metadata.LastCommit != "" {
+		s.Commits = getGitCommitsSince(vs.Metadata.SourcePath, vs.Metadata.LastCommit)
+	}
+	return s, true
+}
+
+// describeIndex loads an index file and collects what lr list shows about it
+func describeIndex(path, currentModel string) IndexInfo {
+	info := IndexInfo{
+		Name:   indexNameFromFile(path),
+		File:   filepath.Base(path),
+		Path:   path,
+		Format: indexFileFormat(path),
+	}
+	if st, err := os.Stat(path); err == nil {
+		info.SizeBytes = st.Size()
+	}
+
+	vs := NewVectorStore()
+	if err := vs.Load(path); err != nil {
+		info.Error = err.Error()
+		return info
+	}
+
+	info.SourcePath = vs.Metadata.SourcePath
+	info.ImportedFrom = vs.Metadata.ImportedFrom
+	info.IndexedAt = vs.Metadata.IndexedAt
+	info.Chunks = len(vs.Chunks)
+	info.FilesIndexed = vs.Metadata.FileCount
+	info.EmbeddingModel = inferEmbeddingModel(vs)
+	info.Dimensions = vs.Dimensions()
+	info.LastCommit = vs.Metadata.LastCommit
+	info.Branch = vs.Metadata.Branch
+	info.Review = vs.Metadata.ReviewIndex
+	if currentModel != "" && info.EmbeddingModel != "" {
+		compatible := info.EmbeddingModel == currentModel
+		info.Compatible = &compatible
+	}
+	if changes, ok := sourceChanges(vs); ok {
+		stale := changes.Stale()
+		info.Stale = &stale
+		info.ChangedFiles = changes.ChangedFiles
+		info.CommitsBehind = changes.Commits
+	}
+	return info
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"lr/pkg/chunk"
+	"lr/pkg/store"
+)
+
+// maxInspectLines caps the matching lines --grep prints per chunk, and
+// maxInspectWidth the bytes printed of each
+const (
+	maxInspectLines = 3
+	maxInspectWidth = 100
+)
+
+var inspectCmd = &cobra.Command{
+	Use:   "inspect <file|index>",
+	Short: "Show an index's metadata and chunks",
+	Long: `Print an index's metadata (source, commit, embedding model, chunking settings)
+and list its chunks with their sources, line ranges and sizes, to see how files
+were chunked without writing scripts. the argument is an .lrindex file, or the
+name of an index in the index directory.
+
+--grep lists only the chunks whose text or source matches a regular expression,
+with the matching lines; --chunk prints the full text and metadata of chunks by
+their number in the list.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runInspect,
+}
+
+// inspectReport is lr inspect's output (printed as json with --json)
+type inspectReport struct {
+	Path       string                    `json:"path"`
+	SizeBytes  int64                     `json:"size_bytes"`
+	Format     string                    `json:"format"`
+	Segments   int                       `json:"segments"` // appended updates not yet compacted
+	Dimensions int                       `json:"dimensions"`
+	Summaries  int                       `json:"file_summaries"`
+	Metadata   store.VectorStoreMetadata `json:"metadata"`
+	Chunks     []inspectChunk            `json:"chunks"`
+}
+
+// inspectChunk is a chunk as lr inspect lists it. its text is included for
+// the chunks asked for with --chunk
+type inspectChunk struct {
+	N        int               `json:"n"` // position in the index
+	Source   string            `json:"source"`
+	Bytes    int               `json:"bytes"`
+	Tokens   int               `json:"tokens"` // estimated
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Matches  []string          `json:"matches,omitempty"` // lines matching --grep
+	Text     string            `json:"text,omitempty"`
+}
+
+func runInspect(_ *cobra.Command, args []string) error {
+	path := args[0]
+	if _, err := os.Stat(path); err != nil {
+		if path, err = findExistingIndex(getDefaultIndexDir(), args[0]); err != nil {
+			return withKind(fmt.Errorf("%s is neither an index file nor an index name: %w", args[0], err), ErrNoIndex)
+		}
+	}
+	var pattern *regexp.Regexp
+	if inspectGrep != "" {
+		var err error
+		if pattern, err = regexp.Compile(inspectGrep); err != nil {
+			return fmt.Errorf("invalid --grep pattern: %w", err)
+		}
+	}
+
+	vs, err := loadWithSegments(path)
+	if err != nil {
+		return fmt.Errorf("failed to load %s: %w", filepath.Base(path), err)
+	}
+	for _, n := range inspectChunks {
+		if n < 0 || n >= len(vs.Chunks) {
+			return fmt.Errorf("no chunk %d: %s has chunks 0-%d", n, filepath.Base(path), len(vs.Chunks)-1)
+		}
+	}
+	report := newInspectReport(path, vs, pattern, inspectChunks)
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	report.print(pattern != nil)
+	return nil
+}
+
+// newInspectReport describes the index vs loaded from path, listing the chunks
+// matching pattern (all when nil), or only those numbered show when given
+func newInspectReport(path string, vs *VectorStore, pattern *regexp.Regexp, show []int) inspectReport {
+	r := inspectReport{
+		Path:       path,
+		Format:     indexFileFormat(path),
+		Dimensions: vs.Dimensions(),
+		Summaries:  len(vs.Summaries),
+		Metadata:   vs.Metadata,
+	}
+	if info, err := os.Stat(path); err == nil {
+		r.SizeBytes = info.Size()
+	}
+	if segments, err := segmentPaths(path); err == nil {
+		r.Segments = len(segments)
+	}
+
+	for n, c := range vs.Chunks {
+		if len(show) > 0 && !slices.Contains(show, n) {
+			continue
+		}
+		var matches []string
+		if pattern != nil {
+			for _, line := range strings.Split(c.Text, "\n") {
+				if pattern.MatchString(line) {
+					matches = append(matches, strings.TrimSpace(line))
+				}
+			}
+			if len(matches) == 0 && !pattern.MatchString(c.Source) {
+				continue
+			}
+		}
+		ic := inspectChunk{N: n, Source: c.Source, Bytes: len(c.Text), Tokens: estimateTokens(c.Text), Metadata: c.Metadata, Matches: matches}
+		if len(show) > 0 {
+			ic.Text = c.Text
+		}
+		r.Chunks = append(r.Chunks, ic)
+	}
+	return r
+}
+
+// print prints the report for a terminal
+func (r inspectReport) print(grep bool) {
+	m := r.Metadata
+	fmt.Printf("%s %s (%s, %s)\n", bold("index:"), r.Path, formatBytes(r.SizeBytes), r.Format)
+	if m.SourcePath != "" {
+		fmt.Printf("source: %s\n", m.SourcePath)
+	}
+	if m.ImportedFrom != "" {
+		fmt.Printf("imported from: %s\n", m.ImportedFrom)
+	}
+	if m.IndexedAt != "" {
+		fmt.Printf("indexed: %s\n", m.IndexedAt)
+	}
+	if m.LastCommit != "" {
+		commit := m.LastCommit
+		if m.Branch != "" {
+			commit += " on " + m.Branch
+		}
+		fmt.Printf("commit: %s\n", commit)
+	}
+	model := m.EmbeddingModel
+	if model == "" {
+		model = "not recorded"
+	}
+	fmt.Printf("embedding model: %s (%d dimensions)\n", model, r.Dimensions)
+	if s := m.Chunking; s != nil {
+		fmt.Printf("chunking: %s\n", describeChunking(*s))
+	} else {
+		fmt.Println("chunking: not recorded (indexed before chunking settings were)")
+	}
+	fmt.Printf("files: %d indexed, %d skipped\n", len(m.IndexedFiles), len(m.SkippedFiles))
+	fmt.Printf("chunks: %d", m.ChunkCount)
+	if r.Summaries > 0 {
+		fmt.Printf(", %d file summaries", r.Summaries)
+	}
+	if r.Segments > 0 {
+		fmt.Printf(", %d update segment(s) not yet compacted", r.Segments)
+	}
+	fmt.Println()
+	if m.ReviewIndex {
+		fmt.Println("review index (temporary)")
+	}
+
+	switch {
+	case len(r.Chunks) == 0 && grep:
+		fmt.Println("\nno chunks match")
+		return
+	case len(r.Chunks) > 0 && r.Chunks[0].Text != "":
+		for _, c := range r.Chunks {
+			fmt.Printf("\n%s %s (%s, ~%d tokens)\n", bold(fmt.Sprintf("chunk %d:", c.N)), c.Source, formatBytes(int64(c.Bytes)), c.Tokens)
+			keys := make([]string, 0, len(c.Metadata))
+			for k := range c.Metadata {
+				keys = append(keys, k)
+			}
+			slices.Sort(keys)
+			for _, k := range keys {
+				fmt.Printf("  %s %s\n", dim(k+":"), c.Metadata[k])
+			}
+			fmt.Printf("%s\n%s\n", dim(strings.Repeat("─", 40)), c.Text)
+		}
+		return
+	}
+
+	fmt.Printf("\n%s\n", bold("chunks:"))
+	width := 1
+	if len(r.Chunks) > 0 {
+		width = len(fmt.Sprint(r.Chunks[len(r.Chunks)-1].N))
+	}
+	for _, c := range r.Chunks {
+		fmt.Printf("  %*d  %s  %s\n", width, c.N, chunkLocation(c), dim(fmt.Sprintf("%s, ~%d tokens%s", formatBytes(int64(c.Bytes)), c.Tokens, chunkFlags(c.Metadata))))
+		for i, line := range c.Matches {
+			if i == maxInspectLines {
+				fmt.Printf("  %*s    %s\n", width, "", dim(fmt.Sprintf("... %d more matching lines", len(c.Matches)-i)))
+				break
+			}
+			if len(line) > maxInspectWidth {
+				line = line[:maxInspectWidth] + "…"
+			}
+			fmt.Printf("  %*s    %s\n", width, "", line)
+		}
+	}
+	fmt.Printf("\nshow a chunk's text: lr inspect %s --chunk <n>\n", r.Path)
+}
+
+// chunkLocation is a chunk's source with its line range, if recorded
+func chunkLocation(c inspectChunk) string {
+	start, end := c.Metadata["start_line"], c.Metadata["end_line"]
+	if start == "" || end == "" {
+		return c.Source
+	}
+	return fmt.Sprintf("%s:%s-%s", c.Source, start, end)
+}
+
+// chunkFlags notes how chunking treated a chunk: its type, down-weighted
+// noise, and whether its file was capped
+func chunkFlags(metadata map[string]string) string {
+	var flags string
+	if t := metadata["type"]; t != "" {
+		flags += ", " + t
+	}
+	if noise := metadata[chunk.NoiseMetadataKey]; noise != "" {
+		flags += ", noise: " + noise
+	}
+	if capped := metadata[chunk.CappedMetadataKey]; capped != "" {
+		flags += ", capped " + capped
+	}
+	return flags
+}
+
+// describeChunking describes chunking settings in a line
+func describeChunking(s ChunkSettings) string {
+	d := fmt.Sprintf("strategy %d, max %d, min %d", s.Strategy, s.MaxSize, s.MinSize)
+	if len(s.Noise) > 0 {
+		kinds := make([]string, len(s.Noise))
+		for i, k := range s.Noise {
+			kinds[i] = string(k)
+		}
+		d += fmt.Sprintf(", noise %s (%s)", strings.Join(kinds, ","), s.NoiseMode)
+	}
+	if s.MaxPerFile > 0 {
+		d += fmt.Sprintf(", at most %d chunks per file (by %s)", s.MaxPerFile, s.CapBy)
+	}
+	return d
+}
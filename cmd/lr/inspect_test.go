@@ -0,0 +1,31 @@
+package main
+
+import (
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+func TestInspectReport(t *testing.T) {
+	vs := NewVectorStore()
+	vs.Add(Chunk{Source: "a.go", Text: "package a\nfunc Parse() {}", Metadata: map[string]string{"start_line": "1", "end_line": "2"}}, []float64{1, 0})
+	vs.Add(Chunk{Source: "b.md", Text: "# usage\nrun it"}, []float64{0, 1})
+	vs.Add(Chunk{Source: "parse/c.go", Text: "package parse"}, []float64{1, 1})
+	path := filepath.Join(t.TempDir(), "t_20260101.lrindex")
+
+	r := newInspectReport(path, vs, regexp.MustCompile(`Parse|parse/`), nil)
+	if len(r.Chunks) != 2 || r.Chunks[0].N != 0 || r.Chunks[1].N != 2 || r.Dimensions != 2 {
+		t.Fatalf("chunks = %+v, want those matching by text or source", r.Chunks)
+	}
+	if len(r.Chunks[0].Matches) != 1 || r.Chunks[0].Matches[0] != "func Parse() {}" || r.Chunks[0].Text != "" {
+		t.Fatalf("chunk 0 = %+v, want its matching line and no text", r.Chunks[0])
+	}
+	if loc := chunkLocation(r.Chunks[0]); loc != "a.go:1-2" {
+		t.Fatalf("location = %q", loc)
+	}
+
+	r = newInspectReport(path, vs, nil, []int{1})
+	if len(r.Chunks) != 1 || r.Chunks[0].Text != "# usage\nrun it" {
+		t.Fatalf("--chunk 1 = %+v", r.Chunks)
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/spf13/cobra"
+)
+
+// defaultKeywordLimit bounds the matches keyword_search returns
+const defaultKeywordLimit = 50
+
+var keywordCmd = &cobra.Command{
+	Use:   "keyword <pattern>",
+	Short: "Search the text of indexed chunks with a regular expression",
+	Long: `Print the lines of indexed chunks that match a regular expression (go syntax),
+like grep over every index at once. No embedding is made, so it is instant and
+free, and finds exact names and strings a similarity search can miss:
+
+  lr keyword 'NewVectorStore\('
+  lr keyword -F 'max_payload' --sources nats-server`,
+	Args: cobra.ExactArgs(1),
+	RunE: runKeyword,
+}
+
+// keywordPattern compiles a keyword search pattern, quoted first when fixed
+func keywordPattern(pattern string, ignoreCase, fixed bool) (*regexp.Regexp, error) {
+	if fixed {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if ignoreCase {
+		pattern = "(?i)" + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern (use -F to search for it literally): %w", err)
+	}
+	return re, nil
+}
+
+// loadKeywordSources loads the named indexes, or all of them
+func loadKeywordSources(sources []string) (*MultiSourceStore, error) {
+	mss := NewMultiSourceStore(getDefaultIndexDir())
+	if len(sources) == 0 {
+		if err := mss.LoadAll(); err != nil {
+			return nil, fmt.Errorf("error loading vector stores: %w", err)
+		}
+	}
+	for _, name := range sources {
+		if err := mss.LoadSource(name); err != nil {
+			return nil, withKind(fmt.Errorf("failed to load index %s: %w", name, err), ErrNoIndex)
+		}
+	}
+	if len(mss.Sources) == 0 {
+		return nil, withKind(fmt.Errorf("no vector stores found\nrun 'lr index' to index repositories first"), ErrNoIndex)
+	}
+	return mss, nil
+}
+
+// keywordMatchLine renders a match as grep does: index:path:line: text
+func keywordMatchLine(m KeywordMatch) string {
+	where := m.Source + ":" + m.File
+	if m.Line > 0 {
+		where += fmt.Sprintf(":%d", m.Line)
+	}
+	return where + ": " + strings.TrimSpace(m.Text)
+}
+
+func runKeyword(_ *cobra.Command, args []string) error {
+	re, err := keywordPattern(args[0], keywordIgnoreCase, keywordFixed)
+	if err != nil {
+		return err
+	}
+	mss, err := loadKeywordSources(querySources)
+	if err != nil {
+		return err
+	}
+
+	// one more than the limit tells whether it cut the matches short
+	limit := keywordLimit
+	if limit > 0 {
+		limit++
+	}
+	matches := mss.KeywordSearch(re, querySources, limit)
+	truncated := keywordLimit > 0 && len(matches) > keywordLimit
+	if truncated {
+		matches = matches[:keywordLimit]
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Pattern   string         `json:"pattern"`
+			Matches   []KeywordMatch `json:"matches"`
+			Truncated bool           `json:"truncated"`
+		}{re.String(), append([]KeywordMatch{}, matches...), truncated})
+	}
+
+	if len(matches) == 0 {
+		fmt.Printf("no matches for %s\n", args[0])
+		return nil
+	}
+	for _, m := range matches {
+		fmt.Println(keywordMatchLine(m))
+	}
+	if truncated {
+		fmt.Fprintf(os.Stderr, "%s\n", yellow(fmt.Sprintf("showing the first %d matches (raise --limit for more)", keywordLimit)))
+	}
+	return nil
+}
+
+func handleKeywordSearch(_ context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok {
+		return mcp.NewToolResultError("invalid arguments"), nil
+	}
+	pattern, ok := args["pattern"].(string)
+	if !ok || pattern == "" {
+		return mcp.NewToolResultError("pattern parameter is required"), nil
+	}
+	ignoreCase, _ := args["ignore_case"].(bool)
+	fixed, _ := args["fixed"].(bool)
+	limit := defaultKeywordLimit
+	if v, ok := args["limit"].(float64); ok && v > 0 {
+		limit = int(v)
+	}
+	var sources []string
+	if sourcesArg, _ := args["sources"].(string); sourcesArg != "" {
+		for _, s := range strings.Split(sourcesArg, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				sources = append(sources, s)
+			}
+		}
+	}
+
+	re, err := keywordPattern(pattern, ignoreCase, fixed)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	mss, err := storesFor(sources...)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load indexes: %v", err)), nil
+	}
+	for _, name := range sources {
+		if mss.Sources[name] == nil {
+			return mcp.NewToolResultError(fmt.Sprintf("index '%s' not found. available: %v", name, mss.ListSources())), nil
+		}
+	}
+
+	matches := mss.KeywordSearch(re, sources, limit+1)
+	if len(matches) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("no indexed chunk matches %s", pattern)), nil
+	}
+	var response strings.Builder
+	for i, m := range matches {
+		if i == limit {
+			fmt.Fprintf(&response, "(more matches: raise limit or narrow the pattern or sources)\n")
+			break
+		}
+		response.WriteString(keywordMatchLine(m))
+		if m.ChunkID != "" {
+			fmt.Fprintf(&response, "  [id: %s]", m.ChunkID)
+		}
+		response.WriteString("\n")
+	}
+	return mcp.NewToolResultText(response.String()), nil
+}
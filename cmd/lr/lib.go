@@ -0,0 +1,107 @@
+package main
+
+import (
+	"lr/pkg/chunk"
+	"lr/pkg/llm"
+	"lr/pkg/loader"
+	"lr/pkg/rag"
+	"lr/pkg/store"
+)
+
+// the cli is a thin wrapper over the pkg/ libraries; these aliases keep the
+// command code reading the way it did before the split
+
+type (
+	Document         = loader.Document
+	SkippedFile      = loader.SkippedFile
+	IgnoreRules      = loader.IgnoreRules
+	Chunk            = chunk.Chunk
+	NoiseFilter      = chunk.NoiseFilter
+	NoiseReport      = chunk.NoiseReport
+	ChunkSettings    = chunk.Settings
+	VectorStore      = store.VectorStore
+	Segment          = store.Segment
+	JournalEntry     = store.JournalEntry
+	MultiSourceStore = store.MultiSourceStore
+	SearchResult     = store.SearchResult
+	KeywordMatch     = store.KeywordMatch
+	FileSummary      = store.FileSummary
+	RAG              = rag.RAG
+	Citation         = rag.Citation
+	Confidence       = rag.Confidence
+	LLMClient        = llm.LLMClient
+	ContextLLMClient = llm.ContextLLMClient
+	Embedder         = llm.Embedder
+	Message          = llm.Message
+	ChatOptions      = llm.ChatOptions
+	OllamaClient     = llm.OllamaClient
+	FailoverClient   = llm.FailoverClient
+	FailoverResult   = llm.FailoverResult
+)
+
+var (
+	ShouldExcludeFile                      = loader.ShouldExcludeFile
+	LoadFilesByExtensionsWithStatsAndSplit = loader.LoadFilesByExtensionsWithStatsAndSplit
+	LoadSpecificFiles                      = loader.LoadSpecificFiles
+	LoadCodeFiles                          = loader.LoadCodeFiles
+	codeExtensions                         = loader.CodeExtensions
+	configExtensions                       = loader.ConfigExtensions
+	dataExtensions                         = loader.DataExtensions
+	LoadIgnoreRules                        = loader.LoadIgnoreRules
+	selectsFile                            = loader.Selects
+	specialFileTypes                       = loader.SpecialFiles
+	shebangTypes                           = loader.Shebangs
+	ChunkDocument                          = chunk.ChunkDocument
+	ChunkDocumentSized                     = chunk.ChunkDocumentSized
+	chunkLineRange                         = chunk.LineRange
+	chunkStructure                         = chunk.Structure
+	chunkFilePath                          = chunk.FilePath
+	fileChunkIDs                           = chunk.FileIDs
+	isChunkIDHash                          = chunk.IsIDHash
+
+	NewVectorStore      = store.NewVectorStore
+	NewMultiSourceStore = store.NewMultiSourceStore
+	NewStoreCache       = store.NewStoreCache
+	atomicSave          = store.AtomicSave
+	isPartialIndexFile  = store.IsPartialIndexFile
+	loadWithSegments    = store.LoadWithSegments
+	appendSegment       = store.AppendSegment
+	compactSegments     = store.CompactSegments
+	removeSegments      = store.RemoveSegments
+	segmentPaths        = store.SegmentPaths
+	openJournal         = store.OpenJournal
+	readJournal         = store.ReadJournal
+	removeJournal       = store.RemoveJournal
+	journalPath         = store.JournalPath
+	parseRefDirection   = store.ParseRefDirection
+
+	NewRAGBackend = rag.NewRAGBackend
+
+	NewOpenAIClient       = llm.NewOpenAIClient
+	NewHybridClient       = llm.NewHybridClient
+	NewVoyageClient       = llm.NewVoyageClient
+	NewVoyageClaudeClient = llm.NewVoyageClaudeClient
+	NewOllamaClient       = llm.NewOllamaClient
+	NewOllamaClaudeClient = llm.NewOllamaClaudeClient
+	NewPluginEmbedder     = llm.NewPluginEmbedder
+	NewPluginClaudeClient = llm.NewPluginClaudeClient
+	NewExternalChatClient = llm.NewExternalChatClient
+	isPluginModel         = llm.IsPluginModel
+	isExternalChatModel   = llm.IsExternalChatModel
+	getEmbeddingContext   = llm.GetEmbeddingContext
+	chatContext           = llm.ChatContext
+	withTokenHandler      = llm.WithTokenHandler
+	withFailoverResult    = llm.WithFailoverResult
+)
+
+const (
+	pluginPrefix     = llm.PluginPrefix
+	confidenceHigh   = rag.ConfidenceHigh
+	confidenceMedium = rag.ConfidenceMedium
+	deepRounds       = rag.DefaultDeepRounds
+	chunkIDKey       = chunk.IDMetadataKey
+	detectedFiles    = loader.DetectedFiles
+
+	questionSymbol       = rag.QuestionSymbol
+	questionArchitecture = rag.QuestionArchitecture
+)
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// ErrIndexBusy is returned when another lr process holds the write lock for an index
+var ErrIndexBusy = errors.New("index busy")
+
+// IndexLock is an advisory (flock) write lock on a single index
+type IndexLock struct {
+	file *os.File
+	name string
+}
+
+// indexLockPath returns the lock file for an index name (shared by all dated versions)
+func indexLockPath(dir, name string) string {
+	return filepath.Join(dir, name+".lock")
+}
+
+// acquireIndexLock takes the write lock for the named index in dir
+// if wait is false and another process holds the lock, returns an error wrapping ErrIndexBusy
+func acquireIndexLock(dir, name string, wait bool) (*IndexLock, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create lock directory: %w", err)
+	}
+
+	path := indexLockPath(dir, name)
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		if !errors.Is(err, syscall.EWOULDBLOCK) {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", name, err)
+		}
+
+		holder := lockHolder(f)
+		if !wait {
+			f.Close()
+			return nil, fmt.Errorf("%w: %s is being written by %s (use --wait to wait for it)", ErrIndexBusy, name, holder)
+		}
+
+		fmt.Printf("waiting for %s to release %s...\n", holder, name)
+		if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", name, err)
+		}
+	}
+
+	// record our pid so a blocked process can say who holds the lock
+	f.Truncate(0)
+	f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+
+	return &IndexLock{file: f, name: name}, nil
+}
+
+// lockHolder describes the process recorded in a lock file
+func lockHolder(f *os.File) string {
+	data := make([]byte, 32)
+	n, _ := f.ReadAt(data, 0)
+	if pid := strings.TrimSpace(string(data[:n])); pid != "" {
+		return "pid " + pid
+	}
+	return "another lr process"
+}
+
+// Release drops the lock; safe to call on a nil lock
+func (l *IndexLock) Release() {
+	if l == nil || l.file == nil {
+		return
+	}
+	l.file.Truncate(0)
+	syscall.Flock(int(l.file.Fd()), syscall.LOCK_UN)
+	l.file.Close()
+	l.file = nil
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestIndexLockBusy(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	lock, err := acquireIndexLock(tmpDir, "proj", false)
+	if err != nil {
+		t.Fatalf("first lock failed: %v", err)
+	}
+
+	if _, err := acquireIndexLock(tmpDir, "proj", false); !errors.Is(err, ErrIndexBusy) {
+		t.Fatalf("expected ErrIndexBusy, got %v", err)
+	}
+
+	// a different index is independent
+	other, err := acquireIndexLock(tmpDir, "docs", false)
+	if err != nil {
+		t.Fatalf("lock on other index failed: %v", err)
+	}
+	other.Release()
+
+	lock.Release()
+	again, err := acquireIndexLock(tmpDir, "proj", false)
+	if err != nil {
+		t.Fatalf("lock after release failed: %v", err)
+	}
+	again.Release()
+}
@@ -0,0 +1,2431 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/schollz/progressbar/v3"
+	"github.com/spf13/cobra"
+
+	"lr/pkg/chunk"
+	"lr/pkg/llm"
+	"lr/pkg/store"
+)
+
+const (
+	maxChunkSize       = chunk.DefaultMaxSize
+	checkpointInterval = 100 // save every 100 chunks
+)
+
+var (
+	// index command flags
+	srcPath      string
+	useCode      bool
+	useDocs      bool
+	useConfig    bool
+	useData      bool
+	outPath      string
+	outName      string
+	dryRun       bool
+	maxFileSize  int64
+	splitLarge   bool
+	includeTests bool
+	updateIndex  bool
+	checkIndex   bool
+	useGit       bool
+	forceIndex   bool
+	maxFiles     int
+	maxTotalSize int64
+	maxCost      float64
+	keepVersions int
+	keepBackups  int
+	waitLock     bool
+	noiseFilters []string
+	noiseMode    string
+	minChunkSize int
+	maxPerFile   int
+	capBy        string
+	summarize    bool
+	rechunk      bool
+	specialFiles []string
+	shebangs     []string
+	showChunks   []string
+
+	// usage command flags
+	usageSince string
+
+	// top command flags
+	topSince string
+	topLimit int
+
+	// hooks command flags
+	hookIndexName string
+
+	// sync command flags
+	syncFrom     string
+	syncWrite    bool
+	syncForce    bool
+	syncNoReload bool
+
+	// export command flags
+	exportFormat   string
+	exportURL      string
+	exportColl     string
+	exportRecreate bool
+	exportBatch    int
+
+	// daemon command flags
+	daemonDetach bool
+
+	// import command flags
+	importName   string
+	importFormat string
+
+	// keyword command flags
+	keywordIgnoreCase bool
+	keywordFixed      bool
+	keywordLimit      int
+
+	// ask-diff command flags
+	askDiffUncommitted bool
+	askDiffShowDiff    bool
+
+	// review run command flags
+	reviewWithCmds    []string
+	reviewWithOutputs []string
+	reviewSecurity    bool
+
+	// review start command flags
+	reviewUseIndex string
+
+	// review watch flags (also used by review start)
+	reviewDebounce  time.Duration
+	reviewBatchSize int
+
+	// serve command flags
+	serveAddr    string
+	serveRemote  bool
+	serveTLSCert string
+	serveTLSKey  string
+
+	// describe command flags
+	describeGenerate bool
+	describeClear    bool
+
+	// hints command flags
+	hintsAbout       string
+	hintsTerms       []string
+	hintsRemoveTerms []string
+	hintsClear       bool
+
+	// exclude command flags
+	excludeRemove []string
+	excludeClear  bool
+
+	// inspect command flags
+	inspectChunks []int
+	inspectGrep   string
+
+	// query command flags
+	topK         int
+	querySources []string
+	useMCP       bool
+	noSynthesize bool
+	retrieveOnly bool
+	saveQueryAs  string
+	runSaved     string
+	attachFiles  []string
+	readStdin    bool
+	noRoute      bool
+	scoreNorm    string
+	queryAsOf    string
+	queryCommit  string
+	topFiles     int
+	expandImport int
+	queryRefs    string
+	excludePaths []string
+	remoteURL    string
+	pgWhere      string
+	noDaemon     bool
+
+	// chat parameters (query and interactive)
+	maxTokens     int
+	temperature   float64
+	stopSequences []string
+	noStream      bool
+	noCache       bool
+	selfCheck     bool
+	minSimilarity float64
+	deepQuery     bool
+
+	// interactive command flags
+	transcriptPath string
+
+	// mcp command flags
+	noPreload      bool
+	reloadPid      int
+	reloadAll      bool
+	mcpToolTimeout time.Duration
+	mcpCompact     bool
+	mcpCacheTTL    time.Duration
+	mcpMemory      string
+	mcpWarmup      []string
+
+	// model configuration flags
+	chatModel      string
+	embeddingModel string
+
+	// offline mode: deterministic embeddings and canned chat, no api keys or network
+	mockLLM bool
+
+	// chat models to try in order when the primary chat model fails
+	fallbackModels []string
+
+	// output flags
+	jsonOutput bool
+	assumeYes  bool
+	noProgress bool
+	noColor    bool
+)
+
+// model aliases for convenience
+var chatModelAliases = map[string]string{
+	"sonnet":      "claude-sonnet-4-5-20250929",
+	"haiku":       "claude-haiku-4-5-20251001",
+	"opus":        "claude-opus-4-5-20251101",
+	"gpt-4o":      "gpt-4o",
+	"gpt-4o-mini": "gpt-4o-mini",
+}
+
+var embeddingModelAliases = map[string]string{
+	"openai":  "text-embedding-3-small",
+	"voyage":  "voyage-code-2",
+	"voyage3": "voyage-3",
+	"ollama":  "nomic-embed-text",
+}
+
+// default chat model
+const defaultChatModel = "claude-sonnet-4-5-20250929"
+
+// resolveChatModel resolves a model alias to its full model ID
+func resolveChatModel(model string) string {
+	if model == "" {
+		return defaultChatModel
+	}
+	if resolved, ok := chatModelAliases[model]; ok {
+		return resolved
+	}
+	return model // assume it's a full model ID
+}
+
+// resolveEmbeddingModel resolves an embedding model alias to its full model ID
+func resolveEmbeddingModel(model string) string {
+	if model == "" {
+		return "" // will be auto-detected
+	}
+	if resolved, ok := embeddingModelAliases[model]; ok {
+		return resolved
+	}
+	return model // assume it's a full model ID
+}
+
+// getCurrentEmbeddingModel returns the embedding model that would be used for queries
+// based on the --embedding-model flag and environment variables
+func getCurrentEmbeddingModel() string {
+	if mockLLM {
+		return llm.MockModel
+	}
+	resolved := resolveEmbeddingModel(embeddingModel)
+
+	// explicit ollama
+	if embeddingModel == "ollama" || resolved == "nomic-embed-text" {
+		if resolved == "" {
+			return "nomic-embed-text"
+		}
+		return resolved
+	}
+
+	// explicit model specified
+	if resolved != "" {
+		return resolved
+	}
+
+	// auto-detect based on available api keys
+	voyageKey := os.Getenv("VOYAGE_API_KEY")
+	openaiKey := os.Getenv("OPENAI_API_KEY")
+	claudeKey := os.Getenv("ANTHROPIC_API_KEY")
+
+	externalChat := isExternalChatModel(resolveChatModel(chatModel))
+	if voyageKey != "" && (claudeKey != "" || externalChat) {
+		return "voyage-code-2"
+	}
+	if openaiKey != "" {
+		return "text-embedding-3-small"
+	}
+
+	// no keys available - would need ollama or fail
+	return ""
+}
+
+var rootCmd = &cobra.Command{
+	Use:   "lr",
+	Short: "LocalRag - local-first RAG system for code and documentation",
+	Long:  `LocalRag indexes and queries code repositories and documentation using local vector storage.`,
+	PersistentPreRun: func(cmd *cobra.Command, _ []string) {
+		// LR_EMBEDDING_MODEL (e.g. from the .env config) is the default for --embedding-model
+		if embeddingModel == "" {
+			embeddingModel = os.Getenv("LR_EMBEDDING_MODEL")
+		}
+		// LR_FALLBACK_MODELS (comma-separated) is the default for --fallback-models
+		if len(fallbackModels) == 0 && os.Getenv("LR_FALLBACK_MODELS") != "" {
+			fallbackModels = strings.Split(os.Getenv("LR_FALLBACK_MODELS"), ",")
+		}
+		// with --json the error is reported once, as json, by main
+		if jsonOutput {
+			cmd.Root().SilenceErrors = true
+			cmd.Root().SilenceUsage = true
+		}
+	},
+}
+
+var indexCmd = &cobra.Command{
+	Use:   "index",
+	Short: "Index a repository or directory",
+	Long:  `Index code and/or documentation from a local directory or git repository.`,
+	RunE:  runIndex,
+}
+
+var queryCmd = &cobra.Command{
+	Use:   "query [question]",
+	Short: "Query indexed repositories",
+	Long: `Ask a question and get answers from indexed repositories.
+
+Questions can be saved with --save <name> and replayed later with --run <name>.`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if runSaved != "" {
+			if len(args) > 0 {
+				return fmt.Errorf("--run takes no question (it replays the saved one)")
+			}
+			return nil
+		}
+		return cobra.MinimumNArgs(1)(cmd, args)
+	},
+	RunE: runQuery,
+}
+
+var interactiveCmd = &cobra.Command{
+	Use:   "interactive",
+	Short: "Start interactive query mode",
+	Long: `Start an interactive session to ask multiple questions.
+
+type /save <file> to write the session so far as markdown, or start with
+--transcript <file> to keep it written after every answer. /model <model> and
+/embedding-model <model> switch models without leaving the session.`,
+	RunE: runInteractive,
+}
+
+var mcpCmd = &cobra.Command{
+	Use:   "mcp",
+	Short: "Start MCP server for Claude Code integration",
+	Long:  `Start a Model Context Protocol server on stdio for integration with Claude Code.`,
+	RunE:  runMCP,
+}
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List all available vector store indexes",
+	Long:  `Display all available vector store indexes with their metadata.`,
+	RunE:  runList,
+}
+
+var setupCmd = &cobra.Command{
+	Use:   "setup",
+	Short: "Print MCP configuration for Claude Code integration",
+	Long:  `Print the MCP server configuration to add to Claude Code's config file.`,
+	RunE:  runSetup,
+}
+
+var pathsCmd = &cobra.Command{
+	Use:   "paths",
+	Short: "Show where lr stores data and config",
+	Long:  `Display the directories used for storing indexes and configuration.`,
+	Run:   runPaths,
+}
+
+var updateAllCmd = &cobra.Command{
+	Use:   "update-all",
+	Short: "Update all indexes that have source paths",
+	Long:  `Incrementally update all indexes that have recorded source paths. Creates a backup before updating.`,
+	RunE:  runUpdateAll,
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore [backup]",
+	Short: "Restore indexes from an update-all backup",
+	Long:  `Validate and restore a backup set created by update-all. Without arguments, lists the available backups.`,
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runRestore,
+}
+
+var syncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Download newer shared index versions",
+	Long: `Download the indexes a team publishes (an s3:// or gs:// prefix, or an http manifest) that are newer than the local ones.
+Each download is checked against the manifest's checksum and the configured embedding model before it replaces the local version,
+and running lr mcp servers are told to reload. With --write-manifest, writes the manifest of the local indexes for publishing instead.`,
+	Args: cobra.NoArgs,
+	RunE: runSync,
+}
+
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Summarize estimated api token usage and cost",
+	Long:  `Summarize the local usage ledger by provider, index and operation.`,
+	RunE:  runUsage,
+}
+
+var topCmd = &cobra.Command{
+	Use:   "top",
+	Short: "Show which indexed files questions retrieve most, and which never",
+	Long: `Summarize the retrieval log: the files and chunks questions retrieve most, the
+indexed files no question has retrieved, and the questions whose best match
+was weak. Use it to decide what to exclude from an index and what docs to write.`,
+	Args: cobra.NoArgs,
+	RunE: runTop,
+}
+
+var reviewCmd = &cobra.Command{
+	Use:   "review",
+	Short: "Code review context using local ollama embeddings",
+	Long:  `Start/stop a review session that indexes your project locally for code review context.`,
+}
+
+var reviewStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start a review session (indexes current directory with ollama)",
+	Long: `Start a review session. This will:
+1. Start ollama if not running
+2. Pull the embedding model if needed
+3. Index the current directory
+4. Enable watch mode for live updates`,
+	RunE: runReviewStart,
+}
+
+var reviewStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the review session and delete the index",
+	RunE:  runReviewStop,
+}
+
+var reviewStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show current review session status",
+	RunE:  runReviewStatus,
+}
+
+var reviewWatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Watch for file changes and update the index in real-time",
+	Long:  `Start watching for file changes. When a file is saved, it will be re-indexed automatically.`,
+	RunE:  runReviewWatch,
+}
+
+func init() {
+	// load .env file if it exists (check current dir, then config dir)
+	envPath := getEnvFilePath()
+	if err := LoadEnv(envPath); err != nil {
+		// silently ignore if .env doesn't exist
+		if !os.IsNotExist(err) {
+			fmt.Printf("warning: error loading .env file: %v\n", err)
+		}
+	}
+
+	// index command flags
+	indexCmd.Flags().StringVar(&srcPath, "src", "", "source directory or URL to index (required)")
+	indexCmd.Flags().BoolVar(&useCode, "code", true, "index code files (.go, .js, .ts, etc) [default: true]")
+	indexCmd.Flags().BoolVar(&useDocs, "docs", true, "index documentation files (.md) [default: true]")
+	indexCmd.Flags().BoolVar(&useConfig, "config", true, "index config files (.yaml, .toml, .json, .hcl/.tf, Dockerfile) [default: true]")
+	indexCmd.Flags().BoolVar(&useData, "data", true, "index a schema summary of data files (.csv, .tsv, .jsonl) [default: true]")
+	indexCmd.Flags().StringVar(&outPath, "out", "", "exact output path (e.g., indexes/myindex.lrindex)")
+	indexCmd.Flags().StringVar(&outName, "out-name", "", "output name (saved as indexes/{name}_YYYYMMDD.lrindex)")
+	indexCmd.Flags().BoolVar(&dryRun, "dry-run", false, "show what would be indexed without actually indexing")
+	indexCmd.Flags().StringArrayVar(&showChunks, "show-chunks", nil, "with --dry-run, print how this file (relative to --src) would be chunked: boundaries, sizes and metadata (repeatable)")
+	indexCmd.Flags().Int64Var(&maxFileSize, "max-file-size", 100*1024, "maximum file size in bytes (default 100KB)")
+	indexCmd.Flags().BoolVar(&splitLarge, "split-large", false, "split large files into sections instead of skipping them")
+	indexCmd.Flags().BoolVar(&includeTests, "include-tests", true, "include test files (useful usage examples) [default: true]")
+	indexCmd.Flags().BoolVar(&updateIndex, "update", false, "incrementally update existing index (only re-index changed files)")
+	indexCmd.Flags().BoolVar(&useGit, "git", false, "use git to detect changes (default: file mtime)")
+	indexCmd.Flags().BoolVar(&checkIndex, "check", false, "exit non-zero if the --out-name index is out of date with its source (no changes are made)")
+	indexCmd.Flags().BoolVar(&forceIndex, "force", false, "index even if the scan exceeds the safety caps")
+	indexCmd.Flags().IntVar(&maxFiles, "max-files", defaultMaxFiles, "abort if more files than this would be indexed (0 disables)")
+	indexCmd.Flags().Int64Var(&maxTotalSize, "max-total-size", defaultMaxTotalSize, "abort if total source size in bytes exceeds this (0 disables)")
+	indexCmd.Flags().Float64Var(&maxCost, "max-cost", defaultMaxCost, "abort if estimated embedding cost in dollars exceeds this (0 disables)")
+	indexCmd.Flags().IntVar(&keepVersions, "keep-versions", 0, "number of superseded index versions to keep in the archive dir (older ones are deleted; 0 leaves the archive alone)")
+	indexCmd.Flags().BoolVar(&waitLock, "wait", false, "wait for another lr process writing the same index instead of failing")
+	for _, cmd := range []*cobra.Command{indexCmd, updateAllCmd} {
+		cmd.Flags().StringSliceVar(&noiseFilters, "noise-filter", []string{"all"}, "noise chunks to filter before embedding: license, imports, generated, all or none (comma-separated)")
+		cmd.Flags().StringVar(&noiseMode, "noise-mode", "drop", "what to do with noise chunks: drop them, or downweight them in search results")
+		cmd.Flags().IntVar(&minChunkSize, "min-chunk-size", chunk.DefaultMinSize, "merge sections shorter than this many characters into a neighboring chunk")
+		cmd.Flags().IntVar(&maxPerFile, "max-chunks-per-file", 0, "keep at most this many chunks of a file, picked by --cap-by (0 disables)")
+		cmd.Flags().StringVar(&capBy, "cap-by", string(chunk.CapDensity), "which chunks of a file over --max-chunks-per-file to keep: density (the most information-dense), spread (evenly over the file) or head (the first)")
+		cmd.Flags().BoolVar(&summarize, "summarize-files", false, "have the chat model summarize each file, so queries pick the files to search by their summaries (one chat call per file)")
+		cmd.Flags().BoolVar(&rechunk, "rechunk", false, "when an index's chunking settings differ from the current ones, re-chunk and re-embed all its files without asking")
+		cmd.Flags().StringSliceVar(&specialFiles, "special-files", nil, "more extensionless file names to index with code, as name=type (e.g. Earthfile=dockerfile,Taskfile=yaml)")
+		cmd.Flags().StringSliceVar(&shebangs, "shebangs", nil, "more script interpreters whose extensionless scripts are indexed with code, as interpreter=type (e.g. nu=shell)")
+	}
+	indexCmd.MarkFlagRequired("src")
+
+	// query command flags
+	queryCmd.Flags().IntVar(&topK, "top-k", 0, "number of relevant chunks to retrieve (default: by question type: 2 for symbol lookups, 8 for architecture questions (at most 2 per file), otherwise 3)")
+	queryCmd.Flags().StringSliceVar(&querySources, "sources", []string{}, "filter by source names (comma-separated, e.g., nats-server,docs)")
+	queryCmd.Flags().BoolVar(&useMCP, "use-mcp", false, "use running MCP server instead of loading indexes directly")
+	queryCmd.Flags().BoolVar(&noSynthesize, "no-synthesize", false, "return raw chunks without LLM synthesis (only works with --use-mcp or --remote)")
+	queryCmd.Flags().BoolVar(&retrieveOnly, "retrieve-only", false, "print the chunks the chat model would be given, with the prompt's estimated size and cost, without asking it")
+	queryCmd.Flags().StringSliceVar(&attachFiles, "file", []string{}, "attach a local file to the question (repeatable; need not be indexed)")
+	queryCmd.Flags().BoolVar(&readStdin, "stdin", false, "include piped stdin (e.g. a git diff) as context for the question")
+	queryCmd.Flags().StringVar(&saveQueryAs, "save", "", "save the question (with --sources and --top-k) under this name, then run it")
+	queryCmd.Flags().StringVar(&runSaved, "run", "", "run a saved query by name")
+	queryCmd.Flags().StringVar(&scoreNorm, "score-norm", string(store.NormalizeZScore), "how scores from different sources are made comparable before merging: zscore, minmax or none")
+	queryCmd.Flags().BoolVar(&deepQuery, "deep", false, "let the chat model run follow-up searches before answering, for questions spanning several places in the code")
+	queryCmd.Flags().BoolVar(&noRoute, "no-route", false, "search every loaded source instead of routing by index descriptions")
+	queryCmd.Flags().StringVar(&queryAsOf, "as-of", "", "query the index versions as of this date (YYYYMMDD) instead of the newest")
+	queryCmd.Flags().StringVar(&queryCommit, "commit", "", "query the index versions built at this git commit (hash prefix)")
+	queryCmd.Flags().IntVar(&topFiles, "candidate-files", store.DefaultCandidateFiles, "in indexes built with --summarize-files, search the chunks of this many files picked by their summaries (0 searches every chunk)")
+	queryCmd.Flags().IntVar(&expandImport, "expand-imports", 0, "add up to this many chunks from the go packages imported by, or importing, the top go result's file (0 disables)")
+	queryCmd.Flags().StringVar(&queryRefs, "refs", "", "add the callers or callees of the functions retrieved, from the call graph recorded at index time: callers, callees or both")
+	queryCmd.Flags().StringArrayVar(&excludePaths, "exclude-path", nil, "leave files matching this gitignore-style pattern out of the results, e.g. 'vendor/**' (repeatable; see also lr exclude)")
+	queryCmd.Flags().StringVar(&remoteURL, "remote", "", "query the indexes of an lr serve --remote server at this url (e.g. https://host:7766) instead of local ones; the token is read from LR_REMOTE_TOKEN")
+	queryCmd.Flags().BoolVar(&noDaemon, "no-daemon", false, "load indexes in this process even if an lr daemon is running")
+	queryCmd.Flags().StringVar(&pgWhere, "where", "", "sql condition on index_name, source, text and metadata (jsonb) that results must meet (postgres backend, LR_PG_DSN)")
+	queryCmd.MarkFlagsMutuallyExclusive("as-of", "commit")
+	queryCmd.MarkFlagsMutuallyExclusive("remote", "use-mcp")
+
+	// chat parameter flags (query and interactive)
+	for _, cmd := range []*cobra.Command{queryCmd, interactiveCmd} {
+		cmd.Flags().IntVar(&maxTokens, "max-tokens", 0, "maximum answer length in tokens (default: the provider's, 8192 for claude)")
+		cmd.Flags().Float64Var(&temperature, "temperature", 0, "sampling temperature (default: the provider's)")
+		cmd.Flags().StringSliceVar(&stopSequences, "stop", nil, "stop generating at any of these sequences (comma-separated)")
+		cmd.Flags().BoolVar(&noStream, "no-stream", false, "print the answer once it is complete instead of as it is generated")
+		cmd.Flags().Float64Var(&minSimilarity, "min-similarity", 0, "answer \"not covered by indexed sources\" instead of guessing when the best match is below this similarity or the answer cites no retrieved chunk (0 disables)")
+		cmd.Flags().BoolVar(&selfCheck, "self-check", false, "ask the chat model whether the sources support its answer, refining the confidence (one extra chat request)")
+		cmd.Flags().BoolVar(&noCache, "no-cache", false, "ask the chat model even if the question was answered before from the same indexes (the new answer is cached)")
+	}
+	queryCmd.MarkFlagsMutuallyExclusive("save", "run")
+
+	// interactive command flags
+	interactiveCmd.Flags().StringVar(&transcriptPath, "transcript", "", "write the session (questions, answers and sources) to this markdown file after every answer")
+
+	// mcp command flags
+	mcpCmd.Flags().BoolVar(&noPreload, "no-preload", false, "disable vector store preloading (allows on-the-fly updates)")
+	mcpCmd.Flags().IntVar(&reloadPid, "reload", 0, "send reload signal to mcp server with given pid")
+	mcpCmd.Flags().BoolVar(&reloadAll, "reload-all", false, "send reload signal to all lr mcp processes")
+	mcpCmd.Flags().BoolVar(&mcpCompact, "compact", false, "token-efficient responses: no banners or separators, chunk bodies cut short with expand hints (also LR_MCP_COMPACT=1)")
+	mcpCmd.Flags().DurationVar(&mcpCacheTTL, "cache-ttl", store.DefaultStoreCacheTTL, "with --no-preload, drop cached indexes unused for this long (0 keeps them)")
+	mcpCmd.Flags().StringVar(&mcpMemory, "memory-budget", "", "keep the preloaded indexes within this much memory (e.g. 4GB), evicting the least recently queried and loading them again on demand (default: no limit)")
+	mcpCmd.Flags().StringArrayVar(&mcpWarmup, "warmup", nil, "embed this question at startup so the first tool call doesn't wait on the embedding provider, or the name of a saved query (repeatable)")
+	mcpCmd.Flags().DurationVar(&mcpToolTimeout, "tool-timeout", 2*time.Minute, "maximum time per tool call; synthesis that times out falls back to raw chunks (0 disables)")
+
+	// model configuration flags (persistent, available to all commands)
+	rootCmd.PersistentFlags().StringVar(&chatModel, "model", "", "chat model to use (aliases: sonnet, haiku, opus, gpt-4o, gpt-4o-mini)")
+	rootCmd.PersistentFlags().StringVar(&embeddingModel, "embedding-model", "", "embedding model (aliases: openai, voyage, voyage3, ollama)")
+	rootCmd.PersistentFlags().StringSliceVar(&fallbackModels, "fallback-models", nil, "chat models to fail over to, in order, when the chat model errors (e.g. gpt-4o-mini,ollama)")
+	rootCmd.PersistentFlags().BoolVar(&mockLLM, "mock-llm", false, "use deterministic offline embeddings and canned chat answers (no api keys or network; for testing)")
+	rootCmd.PersistentFlags().BoolVar(&jsonOutput, "json", false, "machine-readable json output (errors are printed as json with a kind and exit code)")
+	rootCmd.PersistentFlags().BoolVarP(&assumeYes, "yes", "y", false, "answer yes to confirmation prompts (prompts are declined when stdin is not a terminal)")
+	rootCmd.PersistentFlags().BoolVar(&noProgress, "no-progress", false, "disable progress bars (also off when stdout is not a terminal)")
+	rootCmd.PersistentFlags().BoolVar(&noColor, "no-color", false, "disable colored output (also off when stdout is not a terminal or NO_COLOR is set)")
+
+	// update-all command flags
+	updateAllCmd.Flags().BoolVar(&useGit, "git", false, "use git to detect changes (default: file mtime)")
+	updateAllCmd.Flags().IntVar(&keepVersions, "keep-versions", 0, "number of superseded index versions to keep in the archive dir (older ones are deleted; 0 leaves the archive alone)")
+	updateAllCmd.Flags().BoolVar(&waitLock, "wait", false, "wait for busy indexes instead of skipping them")
+	updateAllCmd.Flags().IntVar(&keepBackups, "keep-backups", defaultKeepBackups, "number of backup directories to keep (0 keeps all)")
+
+	// restore command flags
+	restoreCmd.Flags().BoolVar(&dryRun, "dry-run", false, "show which indexes would change without restoring")
+	restoreCmd.Flags().BoolVar(&waitLock, "wait", false, "wait for busy indexes instead of failing")
+
+	// sync command flags
+	syncCmd.Flags().StringVar(&syncFrom, "from", "", "where the shared indexes are: s3://bucket/prefix, gs://bucket/prefix or an http(s) manifest url (default: LR_SYNC_FROM)")
+	syncCmd.Flags().BoolVar(&syncWrite, "write-manifest", false, "write "+syncManifestFile+" for the local indexes (to publish alongside them) instead of syncing")
+	syncCmd.Flags().BoolVar(&syncForce, "force", false, "sync indexes built with a different embedding model than queries use")
+	syncCmd.Flags().BoolVar(&syncNoReload, "no-reload", false, "don't signal running lr mcp servers to reload")
+	syncCmd.Flags().BoolVar(&dryRun, "dry-run", false, "show which indexes would be downloaded")
+	syncCmd.Flags().BoolVar(&waitLock, "wait", false, "wait for busy indexes instead of failing")
+	syncCmd.MarkFlagsMutuallyExclusive("from", "write-manifest")
+
+	// usage command flags
+	usageCmd.Flags().StringVar(&usageSince, "since", "30d", "only include usage from this far back (e.g. 7d, 2w, 12h; empty for all time)")
+
+	// review command flags
+	reviewStartCmd.Flags().BoolVar(&waitLock, "wait", false, "wait for another process holding the review index lock")
+	reviewWatchCmd.Flags().BoolVar(&waitLock, "wait", false, "wait for another process holding the review index lock")
+	reviewStopCmd.Flags().BoolVar(&waitLock, "wait", false, "wait for the session's watcher to exit instead of failing")
+	reviewStartCmd.Flags().StringVar(&reviewUseIndex, "use-index", "", "reuse this existing index for context and only index files changed since it was built")
+	for _, cmd := range []*cobra.Command{reviewStartCmd, reviewWatchCmd} {
+		cmd.Flags().DurationVar(&reviewDebounce, "debounce", 500*time.Millisecond, "wait this long after the last change before updating the index")
+		cmd.Flags().IntVar(&reviewBatchSize, "batch-size", 50, "number of chunks to embed per request when updating the index")
+	}
+	reviewRunCmd.Flags().StringArrayVar(&reviewWithCmds, "with-cmd", nil, "run this command in the project and include its output in the review (repeatable)")
+	reviewRunCmd.Flags().StringArrayVar(&reviewWithOutputs, "with-output", nil, "include command output saved to this file, or - for stdin (repeatable)")
+	reviewRunCmd.Flags().BoolVar(&reviewSecurity, "security", false, "security review: favor auth, crypto, input-parsing and query code and tag findings by severity")
+	reviewRunCmd.Flags().IntVar(&topK, "top-k", 3, "number of context chunks per changed or referenced file")
+	reviewRunCmd.Flags().BoolVar(&askDiffUncommitted, "uncommitted", false, "only review uncommitted and staged changes (default: branch vs main/master)")
+	reviewRunCmd.Flags().BoolVar(&askDiffShowDiff, "show-diff", false, "print the diff before the review")
+
+	// add commands
+	rootCmd.AddCommand(indexCmd)
+	rootCmd.AddCommand(queryCmd)
+	rootCmd.AddCommand(interactiveCmd)
+	rootCmd.AddCommand(mcpCmd)
+	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(setupCmd)
+	rootCmd.AddCommand(pathsCmd)
+	rootCmd.AddCommand(updateAllCmd)
+	rootCmd.AddCommand(restoreCmd)
+	rootCmd.AddCommand(syncCmd)
+	rootCmd.AddCommand(usageCmd)
+
+	topCmd.Flags().StringVar(&topSince, "since", "30d", "only include questions from this far back (e.g. 7d, 2w, 12h; empty for all time)")
+	topCmd.Flags().IntVar(&topLimit, "limit", 10, "list at most this many files, chunks and questions (0 for all)")
+	topCmd.Flags().StringSliceVar(&querySources, "sources", []string{}, "only report on these indexes (comma-separated)")
+	rootCmd.AddCommand(topCmd)
+
+	// ask-diff command flags
+	askDiffCmd.Flags().IntVar(&topK, "top-k", 3, "number of context chunks per changed file")
+	askDiffCmd.Flags().BoolVar(&askDiffUncommitted, "uncommitted", false, "only ask about uncommitted and staged changes (default: branch vs main/master)")
+	askDiffCmd.Flags().BoolVar(&askDiffShowDiff, "show-diff", false, "print the diff before the answer")
+	rootCmd.AddCommand(askDiffCmd)
+
+	// serve command flags
+	serveCmd.Flags().StringVar(&serveAddr, "addr", defaultServeAddr, "address to listen on")
+	serveCmd.Flags().BoolVar(&serveRemote, "remote", false, "serve the http api lr query --remote uses instead of grpc (requests must carry LR_REMOTE_TOKEN)")
+	serveCmd.Flags().StringVar(&serveTLSCert, "tls-cert", "", "certificate file to serve https with (--remote)")
+	serveCmd.Flags().StringVar(&serveTLSKey, "tls-key", "", "private key file of --tls-cert")
+	rootCmd.AddCommand(serveCmd)
+
+	// describe command flags
+	describeCmd.Flags().BoolVar(&describeGenerate, "generate", false, "have the chat model write the description from a sample of the index")
+	describeCmd.Flags().BoolVar(&describeClear, "clear", false, "remove the description")
+	describeCmd.MarkFlagsMutuallyExclusive("generate", "clear")
+	rootCmd.AddCommand(describeCmd)
+
+	// hints command flags
+	hintsCmd.Flags().StringVar(&hintsAbout, "about", "", "short description of the codebase for the chat model (\"\" removes it)")
+	hintsCmd.Flags().StringArrayVar(&hintsTerms, "term", nil, "glossary entry as name=meaning (repeatable)")
+	hintsCmd.Flags().StringSliceVar(&hintsRemoveTerms, "remove-term", nil, "remove glossary terms (comma-separated)")
+	hintsCmd.Flags().BoolVar(&hintsClear, "clear", false, "remove all hints")
+	rootCmd.AddCommand(hintsCmd)
+
+	// exclude command flags
+	excludeCmd.Flags().StringSliceVar(&excludeRemove, "remove", nil, "remove excluded path patterns (comma-separated)")
+	excludeCmd.Flags().BoolVar(&excludeClear, "clear", false, "remove all excluded path patterns")
+	rootCmd.AddCommand(excludeCmd)
+
+	inspectCmd.Flags().IntSliceVar(&inspectChunks, "chunk", nil, "print the full text and metadata of the chunks with these numbers (comma-separated or repeated)")
+	inspectCmd.Flags().StringVar(&inspectGrep, "grep", "", "list only the chunks whose text or source matches this regular expression")
+	rootCmd.AddCommand(inspectCmd)
+
+	rootCmd.AddCommand(diffIndexCmd)
+
+	// hooks command with subcommands
+	hooksInstallCmd.Flags().StringVar(&hookIndexName, "out-name", "", "index name to update (default: repo directory name)")
+	hooksCmd.AddCommand(hooksInstallCmd)
+	hooksCmd.AddCommand(hooksUninstallCmd)
+	rootCmd.AddCommand(hooksCmd)
+
+	// postgres backend
+	pgCmd.AddCommand(pgPushCmd)
+	pgCmd.AddCommand(pgListCmd)
+	pgCmd.AddCommand(pgDropCmd)
+	rootCmd.AddCommand(pgCmd)
+
+	// export command flags
+	exportCmd.Flags().StringVar(&exportFormat, "format", "", "vector database to export to: qdrant or chroma (required)")
+	exportCmd.Flags().StringVar(&exportURL, "url", "", "database url (default: http://localhost:6333 for qdrant, http://localhost:8000 for chroma)")
+	exportCmd.Flags().StringVar(&exportColl, "collection", "", "collection to write to (default: the index name)")
+	exportCmd.Flags().BoolVar(&exportRecreate, "recreate", false, "delete the collection first instead of adding to it")
+	exportCmd.Flags().IntVar(&exportBatch, "batch", store.DefaultExportBatch, "chunks per request")
+	exportCmd.MarkFlagRequired("format")
+	rootCmd.AddCommand(exportCmd)
+
+	// import command flags
+	importCmd.Flags().StringVar(&importName, "out-name", "", "name of the index to create (required)")
+	importCmd.Flags().StringVar(&importFormat, "format", "auto", "dump format: auto, langchain, llamaindex, records (json/jsonl) or parquet")
+	importCmd.Flags().BoolVar(&waitLock, "wait", false, "wait for another lr process writing the same index instead of failing")
+	importCmd.MarkFlagRequired("out-name")
+	rootCmd.AddCommand(importCmd)
+
+	// keyword command flags
+	keywordCmd.Flags().StringSliceVar(&querySources, "sources", []string{}, "only search these indexes (comma-separated)")
+	keywordCmd.Flags().BoolVarP(&keywordIgnoreCase, "ignore-case", "i", false, "match case-insensitively")
+	keywordCmd.Flags().BoolVarP(&keywordFixed, "fixed-strings", "F", false, "match the pattern literally instead of as a regular expression")
+	keywordCmd.Flags().IntVar(&keywordLimit, "limit", 200, "print at most this many matching lines (0 for all)")
+	rootCmd.AddCommand(keywordCmd)
+
+	// daemon command with subcommands
+	daemonCmd.Flags().BoolVar(&daemonDetach, "detach", false, "run in the background, logging to daemon.log in the cache directory")
+	daemonCmd.AddCommand(daemonStatusCmd)
+	daemonCmd.AddCommand(daemonStopCmd)
+	rootCmd.AddCommand(daemonCmd)
+
+	// review command with subcommands
+	reviewCmd.AddCommand(reviewStartCmd)
+	reviewCmd.AddCommand(reviewStopCmd)
+	reviewCmd.AddCommand(reviewStatusCmd)
+	reviewCmd.AddCommand(reviewWatchCmd)
+	reviewCmd.AddCommand(reviewRunCmd)
+	rootCmd.AddCommand(reviewCmd)
+}
+
+func main() {
+	cmd, err := rootCmd.ExecuteC()
+	// record whatever api usage the command didn't attribute itself (including failed runs)
+	if cmd != nil {
+		flushUsage(cmd.Name())
+	}
+	if err == nil {
+		err = pushIndexDir()
+	}
+	if err != nil {
+		exitWithError(err)
+	}
+}
+
+func getLLMClient() (LLMClient, error) {
+	client, err := getPrimaryLLMClient()
+	if err != nil || mockLLM {
+		return client, err
+	}
+	return withFailover(client)
+}
+
+// getPrimaryLLMClient picks the embedding and chat providers from the flags and
+// available api keys
+func getPrimaryLLMClient() (LLMClient, error) {
+	// offline testing: not metered, since nothing is billed
+	if mockLLM {
+		fmt.Println("using mock embeddings + mock chat (--mock-llm)")
+		return llm.NewMockClient(), nil
+	}
+
+	openaiKey := os.Getenv("OPENAI_API_KEY")
+	claudeKey := os.Getenv("ANTHROPIC_API_KEY")
+	voyageKey := os.Getenv("VOYAGE_API_KEY")
+
+	// resolve model aliases
+	resolvedChatModel := resolveChatModel(chatModel)
+	resolvedEmbeddingModel := resolveEmbeddingModel(embeddingModel)
+
+	// external chat: synthesis delegated to a command or http endpoint
+	if isExternalChatModel(resolvedChatModel) {
+		embedder, embModel, err := getEmbedder(resolvedEmbeddingModel)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("using %s embeddings + external chat (%s)\n", embModel, strings.TrimPrefix(resolvedChatModel, pluginPrefix))
+		return newUsageMeter(NewExternalChatClient(embedder, resolvedChatModel), embModel, resolvedChatModel), nil
+	}
+
+	// plugin: embeddings from an external command (no api key needed for embeddings)
+	if isPluginModel(resolvedEmbeddingModel) {
+		fmt.Printf("using plugin embeddings (%s) + claude chat (%s)\n", strings.TrimPrefix(resolvedEmbeddingModel, pluginPrefix), resolvedChatModel)
+		return newUsageMeter(NewPluginClaudeClient(resolvedEmbeddingModel, resolvedChatModel), resolvedEmbeddingModel, resolvedChatModel), nil
+	}
+
+	// ollama: local embeddings (no api key needed, just needs ollama running)
+	if embeddingModel == "ollama" || resolvedEmbeddingModel == "nomic-embed-text" {
+		embModel := resolvedEmbeddingModel
+		if embModel == "" {
+			embModel = "nomic-embed-text"
+		}
+		fmt.Printf("using ollama embeddings (%s) + claude chat (%s)\n", embModel, resolvedChatModel)
+		return newUsageMeter(NewOllamaClaudeClient(embModel, resolvedChatModel), embModel, resolvedChatModel), nil
+	}
+
+	// priority order for embedding+chat combinations
+	if voyageKey != "" && claudeKey != "" {
+		embModel := resolvedEmbeddingModel
+		if embModel == "" {
+			embModel = "voyage-code-2"
+		}
+		fmt.Printf("using voyage ai embeddings (%s) + claude chat (%s)\n", embModel, resolvedChatModel)
+		return newUsageMeter(NewVoyageClaudeClient(voyageKey, claudeKey, embModel, resolvedChatModel), embModel, resolvedChatModel), nil
+	} else if openaiKey != "" && claudeKey != "" {
+		embModel := resolvedEmbeddingModel
+		if embModel == "" {
+			embModel = "text-embedding-3-small"
+		}
+		fmt.Printf("using openai embeddings (%s) + claude chat (%s)\n", embModel, resolvedChatModel)
+		return newUsageMeter(NewHybridClient(openaiKey, claudeKey, embModel, resolvedChatModel), embModel, resolvedChatModel), nil
+	} else if openaiKey != "" {
+		embModel := resolvedEmbeddingModel
+		if embModel == "" {
+			embModel = "text-embedding-3-small"
+		}
+		// for openai-only, use gpt model if no claude model specified
+		chatModelToUse := resolvedChatModel
+		if chatModel == "" {
+			chatModelToUse = "gpt-4o-mini"
+		}
+		fmt.Printf("using openai for embeddings (%s) and chat (%s)\n", embModel, chatModelToUse)
+		return newUsageMeter(NewOpenAIClient(openaiKey, chatModelToUse, embModel), embModel, chatModelToUse), nil
+	}
+
+	return nil, withKind(fmt.Errorf("no api key found. please set one of:\n"+
+		"  - OPENAI_API_KEY (for openai only)\n"+
+		"  - OPENAI_API_KEY + ANTHROPIC_API_KEY (hybrid mode)\n"+
+		"  - VOYAGE_API_KEY + ANTHROPIC_API_KEY (recommended for code!)\n"+
+		"  - --embedding-model=ollama (local embeddings, no api key needed)"), ErrAuth)
+}
+
+// getEmbedder returns an embedding-only client for use with an external chat target,
+// following the same provider priority as getLLMClient
+func getEmbedder(resolvedEmbeddingModel string) (Embedder, string, error) {
+	switch {
+	case isPluginModel(resolvedEmbeddingModel):
+		return NewPluginEmbedder(resolvedEmbeddingModel), resolvedEmbeddingModel, nil
+	case embeddingModel == "ollama" || resolvedEmbeddingModel == "nomic-embed-text":
+		model := resolvedEmbeddingModel
+		if model == "" {
+			model = "nomic-embed-text"
+		}
+		return NewOllamaClient(model), model, nil
+	}
+
+	model := resolvedEmbeddingModel
+	if key := os.Getenv("VOYAGE_API_KEY"); key != "" {
+		if model == "" {
+			model = "voyage-code-2"
+		}
+		return NewVoyageClient(key, model), model, nil
+	}
+	if key := os.Getenv("OPENAI_API_KEY"); key != "" {
+		if model == "" {
+			model = "text-embedding-3-small"
+		}
+		return NewOpenAIClient(key, "", model), model, nil
+	}
+	return nil, "", withKind(fmt.Errorf("no embedding provider found for external chat. set VOYAGE_API_KEY or OPENAI_API_KEY, or use --embedding-model=ollama or plugin:<command>"), ErrAuth)
+}
+
+// embedderForModel returns an embedding-only client for the model an existing
+// index was embedded with, so new chunks can be added to it
+func embedderForModel(model string) (Embedder, error) {
+	switch {
+	case model == llm.MockModel:
+		return llm.NewMockClient(), nil
+	case isPluginModel(model):
+		return NewPluginEmbedder(model), nil
+	case strings.HasPrefix(model, "voyage-"):
+		key := os.Getenv("VOYAGE_API_KEY")
+		if key == "" {
+			return nil, withKind(fmt.Errorf("VOYAGE_API_KEY is required to embed with %s", model), ErrAuth)
+		}
+		return NewVoyageClient(key, model), nil
+	case strings.HasPrefix(model, "text-embedding-"):
+		key := os.Getenv("OPENAI_API_KEY")
+		if key == "" {
+			return nil, withKind(fmt.Errorf("OPENAI_API_KEY is required to embed with %s", model), ErrAuth)
+		}
+		return NewOpenAIClient(key, "", model), nil
+	}
+	return NewOllamaClient(model), nil
+}
+
+// average chunk size is around 1000 characters = ~250 tokens
+const avgTokensPerChunk = 250
+
+// estimateCost prints what indexing numChunks chunks costs, and what
+// answering a typical question from the index will (see pricing.go)
+func estimateCost(numChunks int) {
+	totalTokens := numChunks * avgTokensPerChunk
+
+	cost, model := estimateEmbeddingCost(numChunks)
+	price, _ := priceOf(model)
+	switch {
+	case model == "":
+		fmt.Println("Estimated cost: unable to determine (no api keys configured, or no price for the embedding model)")
+	case price.Input == 0:
+		fmt.Printf("Estimated cost: free (%s embeddings, %s)\n", model, price.Provider)
+	default:
+		fmt.Printf("Estimated cost: $%.4f (%s embeddings)\n", cost, model)
+		fmt.Printf("  - %d chunks × %d tokens/chunk = %d tokens\n", numChunks, avgTokensPerChunk, totalTokens)
+		fmt.Printf("  - %s (%s): $%.3f per 1M tokens\n", model, price.Provider, price.Input)
+	}
+
+	chat := currentChatModel()
+	if perQuery, known := queryCost(getCurrentEmbeddingModel(), chat); known && perQuery == 0 {
+		fmt.Printf("Estimated query cost: free (%s chat)\n", chat)
+	} else if known {
+		fmt.Printf("Estimated query cost: ~$%.4f per answered question (%s chat)\n", perQuery, chat)
+	} else {
+		fmt.Printf("Estimated query cost: unable to determine (no price for %s; add it to %s)\n", chat, pricesPath())
+	}
+}
+
+func runIndex(cmd *cobra.Command, _ []string) error {
+	chunkingChanged = chunkFlagsChanged(cmd)
+	if err := addDetectedFileTypes(); err != nil {
+		return err
+	}
+
+	// <name>@ and <name>@<branch> name per-branch indexes
+	if outName != "" && srcPath != "" {
+		name, err := resolveIndexName(outName, srcPath)
+		if err != nil {
+			return err
+		}
+		outName = name
+	}
+
+	// --check only inspects an existing index
+	if checkIndex {
+		if outName == "" {
+			return fmt.Errorf("--check requires --out-name to find the index")
+		}
+		if updateIndex || dryRun {
+			return fmt.Errorf("--check cannot be combined with --update or --dry-run")
+		}
+		return runIndexCheck()
+	}
+
+	// a --json dry run prints only its report on stdout
+	if dryRun && jsonOutput {
+		dryRunStdout, os.Stdout = os.Stdout, os.Stderr
+		defer func() { os.Stdout = dryRunStdout }()
+	}
+
+	// validate flags
+	if !dryRun {
+		if outPath == "" && outName == "" {
+			return fmt.Errorf("either --out or --out-name is required when not using --dry-run")
+		}
+		if outPath != "" && outName != "" {
+			return fmt.Errorf("cannot specify both --out and --out-name")
+		}
+	}
+
+	// --update requires --out-name (to find existing index)
+	if updateIndex && outName == "" {
+		return fmt.Errorf("--update requires --out-name to find existing index")
+	}
+
+	// --git requires --update
+	if useGit && !updateIndex {
+		return fmt.Errorf("--git only works with --update")
+	}
+
+	if len(showChunks) > 0 && !dryRun {
+		return fmt.Errorf("--show-chunks previews chunking in a dry run: add --dry-run")
+	}
+
+	// construct final output path
+	var finalOutPath string
+	if outName != "" {
+		timestamp := time.Now().Format("20060102")
+		indexDir := getDefaultIndexDir()
+		finalOutPath = filepath.Join(indexDir, fmt.Sprintf("%s_%s.lrindex", outName, timestamp))
+	} else {
+		finalOutPath = outPath
+	}
+
+	// take the per-index write lock so concurrent writers can't clobber each other
+	if !dryRun {
+		lockName := outName
+		if lockName == "" {
+			lockName = indexNameFromFile(finalOutPath)
+		}
+		lock, err := acquireIndexLock(filepath.Dir(finalOutPath), lockName, waitLock)
+		if err != nil {
+			return err
+		}
+		defer lock.Release()
+	}
+
+	// handle incremental update
+	if updateIndex {
+		return runIncrementalIndex(finalOutPath)
+	}
+
+	fmt.Printf("analyzing source: %s\n", srcPath)
+
+	// check if source exists
+	if _, err := os.Stat(srcPath); os.IsNotExist(err) {
+		return fmt.Errorf("source directory not found: %s", srcPath)
+	}
+
+	// determine which extensions to load
+	extensions, docType := indexExtensions()
+
+	// load files with statistics
+	fmt.Printf("scanning files from %s...\n", srcPath)
+	loadResult, err := LoadFilesByExtensionsWithStatsAndSplit(srcPath, extensions, docType, maxFileSize, splitLarge, includeTests)
+	if err != nil {
+		return fmt.Errorf("failed to load files: %w", err)
+	}
+
+	fmt.Printf("\n=== SCAN RESULTS ===\n")
+	fmt.Printf("Total files found: %d\n", loadResult.TotalFiles)
+	fmt.Printf("Files to index: %d\n", len(loadResult.Documents))
+	fmt.Printf("Files skipped: %d\n", len(loadResult.SkippedFiles))
+
+	if len(loadResult.SkippedFiles) > 0 {
+		fmt.Println("\nSkipped files:")
+		for _, sf := range loadResult.SkippedFiles {
+			fmt.Printf("  - %s (%s)\n", sf.Path, sf.Reason)
+		}
+	}
+
+	// chunk documents
+	fmt.Println("\nchunking files...")
+	settings, err := chunkSettingsFromFlags()
+	if err != nil {
+		return err
+	}
+	chunks, noiseReport := chunkDocuments(loadResult.Documents, settings)
+	fmt.Printf("created %d chunks\n", len(chunks))
+	var preview []dryRunChunk
+	if dryRun {
+		printNoiseReport(noiseReport, settings.Filter())
+		printChunkCaps(chunks, settings)
+	}
+	if dryRun && len(showChunks) > 0 {
+		var skipped []SkippedFile
+		if preview, skipped, err = previewChunks(docType, settings); err != nil {
+			return err
+		}
+		if !jsonOutput {
+			scanned := make(map[string]bool)
+			for _, doc := range loadResult.Documents {
+				scanned[chunkFilePath(doc.Source)] = true
+			}
+			printChunkPreview(preview, skipped, settings, scanned)
+		}
+	}
+
+	// check safety caps before spending anything on embeddings
+	limits := IndexLimits{MaxFiles: maxFiles, MaxTotalSize: maxTotalSize, MaxCost: maxCost}
+	summary := summarizeDocuments(loadResult.Documents, len(chunks))
+	violations := limits.Violations(summary)
+
+	// if dry run, just show summary and exit
+	if dryRun && jsonOutput {
+		report := newDryRunReport(srcPath, loadResult.Documents, chunks, noiseReport)
+		report.Index = outName
+		if report.Index == "" {
+			report.Index = outPath
+		}
+		report.TotalFiles = loadResult.TotalFiles
+		report.Skipped = append(report.Skipped, loadResult.SkippedFiles...)
+		report.Violations = violations
+		report.Preview = preview
+		return report.write(dryRunStdout)
+	}
+	if dryRun {
+		printLimitViolations(summary, violations)
+		if len(violations) > 0 {
+			fmt.Println("a real run would abort unless --force is set")
+		}
+
+		fmt.Println("\n=== DRY RUN SUMMARY ===")
+		fmt.Printf("Would index %d files into %d chunks\n", len(loadResult.Documents), len(chunks))
+		fmt.Printf("Estimated embeddings to generate: %d\n", len(chunks))
+
+		// estimate cost based on available api keys
+		estimateCost(len(chunks))
+
+		fmt.Printf("Estimated time: ~%d minutes\n", (len(chunks)*50)/1000/60)
+		return nil
+	}
+
+	if len(violations) > 0 && !forceIndex {
+		printLimitViolations(summary, violations)
+		// not a prompt: --yes answers prompts, and only --force lifts the caps
+		return fmt.Errorf("aborting: scan of %s exceeds safety caps (re-run with --force to index anyway)", srcPath)
+	}
+
+	// proceed with actual indexing
+	llm, err := getLLMClient()
+	if err != nil {
+		return err
+	}
+
+	// create simple loader that returns already loaded docs
+	loader := func(dir string) ([]Document, error) {
+		return loadResult.Documents, nil
+	}
+
+	fmt.Printf("\nindexing source: %s\n", srcPath)
+	if err := indexSingleSource(llm, srcPath, finalOutPath, loader); err != nil {
+		return fmt.Errorf("error indexing source: %w", err)
+	}
+	flushUsageFor(llm, "index", indexNameFromFile(finalOutPath))
+	if outName != "" {
+		cleanupSupersededIndexes(getDefaultIndexDir(), outName, finalOutPath)
+	}
+	fmt.Println("indexing complete!")
+	return nil
+}
+
+func runQuery(cmd *cobra.Command, args []string) error {
+	question := strings.Join(args, " ")
+
+	// without --top-k (or a saved one) the kind of question picks it
+	if !cmd.Flags().Changed("top-k") {
+		topK = 0
+	}
+
+	// replay a saved query; flags given on the command line take precedence
+	if runSaved != "" {
+		q, err := lookupSavedQuery(savedQueriesPath(), runSaved)
+		if err != nil {
+			return err
+		}
+		question = q.Question
+		if !cmd.Flags().Changed("sources") && len(q.Sources) > 0 {
+			querySources = q.Sources
+		}
+		if !cmd.Flags().Changed("top-k") && q.TopK > 0 {
+			topK = q.TopK
+		}
+	}
+
+	if saveQueryAs != "" {
+		q := SavedQuery{Question: question, Sources: querySources}
+		if cmd.Flags().Changed("top-k") {
+			q.TopK = topK
+		}
+		if err := saveQuery(savedQueriesPath(), saveQueryAs, q); err != nil {
+			return err
+		}
+		fmt.Printf("saved query %s (replay with: lr query --run %s)\n", saveQueryAs, saveQueryAs)
+	}
+
+	attachments, err := loadAttachments(attachFiles)
+	if err != nil {
+		return err
+	}
+	if readStdin {
+		doc, err := readStdinAttachment()
+		if err != nil {
+			return err
+		}
+		attachments = append(attachments, doc)
+	}
+
+	// a dry run retrieves from the local indexes and stops before synthesis
+	if retrieveOnly {
+		if remoteURL != "" || useMCP || pgDSN() != "" {
+			return fmt.Errorf("--retrieve-only needs local indexes (use --no-synthesize with --remote or --use-mcp)")
+		}
+		if deepQuery {
+			return fmt.Errorf("--deep asks the chat model for follow-up searches, so it can't be combined with --retrieve-only")
+		}
+	}
+
+	// with --remote the server searches its indexes and answers
+	if remoteURL != "" {
+		if len(attachments) > 0 {
+			return fmt.Errorf("--file and --stdin are not supported with --remote")
+		}
+		if queryAsOf != "" || queryCommit != "" {
+			return fmt.Errorf("--as-of and --commit are not supported with --remote")
+		}
+		return runRemoteQuery(question)
+	}
+
+	// if --use-mcp flag is set, query via MCP server
+	if useMCP {
+		if len(attachments) > 0 {
+			return fmt.Errorf("--file and --stdin are not supported with --use-mcp")
+		}
+		if queryAsOf != "" || queryCommit != "" {
+			return fmt.Errorf("--as-of and --commit are not supported with --use-mcp")
+		}
+
+		result, err := queryViaMCP(mcpQueryArgs(question))
+		if err != nil {
+			return fmt.Errorf("error querying via MCP: %w", err)
+		}
+
+		fmt.Println(result)
+		return nil
+	}
+
+	if err := checkAttachmentCost(attachments); err != nil {
+		return err
+	}
+
+	// with the postgres backend, search the indexes there
+	if pgDSN() != "" {
+		if queryAsOf != "" || queryCommit != "" {
+			return fmt.Errorf("--as-of and --commit are not supported with the postgres backend (LR_PG_DSN)")
+		}
+		return runPGQuery(cmd, question, attachments)
+	}
+	if pgWhere != "" {
+		return fmt.Errorf("--where needs the postgres backend (set LR_PG_DSN)")
+	}
+
+	// a question asked before of the same indexes is answered from the cache
+	cacheKey := ""
+	if !noCache && !noSynthesize && !retrieveOnly && len(attachments) == 0 && queryAsOf == "" && queryCommit == "" {
+		opts, err := chatOptionsFromFlags(cmd)
+		if err != nil {
+			return err
+		}
+		cacheKey = answerCacheKey(remoteQueryFromFlags(question), currentChatModel(), getCurrentEmbeddingModel(), opts,
+			indexFingerprints(getDefaultIndexDir(), querySources))
+		if printCachedAnswer(question, cacheKey) != nil {
+			return nil
+		}
+	}
+
+	// a running lr daemon has the indexes and clients loaded already
+	if !retrieveOnly && daemonUsable(cmd, attachments) {
+		if handled, err := runDaemonQuery(question, cacheKey); handled {
+			return err
+		}
+	}
+
+	// standard query mode (load indexes directly)
+	normalize, err := store.ParseScoreNormalization(scoreNorm)
+	if err != nil {
+		return err
+	}
+	refs, err := store.ParseRefDirection(queryRefs)
+	if err != nil {
+		return err
+	}
+	chatOptions, err := chatOptionsFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+	llm, err := getLLMClient()
+	if err != nil {
+		return err
+	}
+
+	// load vector stores
+	indexDir := getDefaultIndexDir()
+	mss := NewMultiSourceStore(indexDir)
+	mss.Normalize = normalize
+	mss.CandidateFiles = topFiles
+	if topFiles <= 0 {
+		mss.CandidateFiles = -1
+	}
+
+	// an older version of the indexes, or only those requested, or all
+	if queryAsOf != "" || queryCommit != "" {
+		if err := loadPinnedSources(mss, querySources, queryAsOf, queryCommit); err != nil {
+			return err
+		}
+	} else if len(querySources) > 0 {
+		for _, source := range querySources {
+			if err := mss.LoadSource(source); err != nil {
+				return fmt.Errorf("error loading source %s: %w", source, err)
+			}
+		}
+	} else {
+		// otherwise load all
+		if err := mss.LoadAll(); err != nil {
+			return fmt.Errorf("error loading vector stores: %w\nrun 'lr index' to index repositories first", err)
+		}
+	}
+
+	if len(mss.Sources) == 0 {
+		return withKind(fmt.Errorf("no vector stores found\nrun 'lr index' to index repositories first"), ErrNoIndex)
+	}
+
+	fmt.Printf("loaded %d sources: %v\n", len(mss.Sources), mss.ListSources())
+
+	// the question's embedding is cached, so a dry run doesn't cost the real one
+	rag := NewRAGMultiSource(mss, newQueryEmbeddingCache(llm, getCurrentEmbeddingModel()))
+	rag.Attachments = attachments
+	rag.Route = !noRoute
+	rag.ChatOptions = chatOptions
+	rag.SelfCheck = selfCheck
+	rag.MinSimilarity = minSimilarity
+	if deepQuery {
+		rag.Deep = deepRounds
+	}
+	rag.ExpandImports = expandImport
+	rag.Refs = refs
+	rag.ExcludePaths = excludePaths
+
+	if retrieveOnly {
+		err := runRetrieveOnly(rag, question, topK, querySources)
+		flushUsageFor(llm, "query", strings.Join(querySources, ","))
+		if err != nil {
+			return dimensionAdvice(fmt.Errorf("error retrieving: %w", err))
+		}
+		return nil
+	}
+
+	answer, results, err := answerQuestion(rag, question, topK, querySources)
+	flushUsageFor(llm, "query", strings.Join(querySources, ","))
+	if err != nil {
+		return dimensionAdvice(fmt.Errorf("error querying: %w", err))
+	}
+	logRetrieval("query", question, results)
+	if cacheKey != "" {
+		saveCachedAnswer(cacheKey, question, ragResponse(rag, answer, results))
+	}
+	return nil
+}
+
+func runList(_ *cobra.Command, _ []string) error {
+	validFiles, err := listIndexFiles(getDefaultIndexDir())
+	if err != nil {
+		return err
+	}
+
+	if len(validFiles) == 0 {
+		if jsonOutput {
+			fmt.Println("[]")
+			return nil
+		}
+		fmt.Println("no vector stores found")
+		fmt.Println("run 'lr index' to create your first index")
+		return nil
+	}
+
+	// get current embedding model for compatibility check
+	currentModel := getCurrentEmbeddingModel()
+
+	// descriptions are optional, so a broken descriptions file shouldn't hide the list
+	descriptions, _ := store.LoadDescriptions(getDefaultIndexDir())
+
+	infos := make([]IndexInfo, 0, len(validFiles))
+	for _, file := range validFiles {
+		info := describeIndex(file, currentModel)
+		// descriptions use the source name lr query loads the index under
+		sourceName := strings.TrimPrefix(strings.TrimPrefix(info.Name, "nats_"), "lr_")
+		info.Description = descriptions[sourceName].Text
+		infos = append(infos, info)
+	}
+
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(infos)
+	}
+
+	fmt.Printf("found %d vector store(s):\n\n", len(infos))
+
+	// display metadata for each vector store
+	stale := 0
+	for _, info := range infos {
+		if info.Error != "" {
+			fmt.Printf("  %s %s (error loading: %s)\n", red("✗"), info.File, info.Error)
+			continue
+		}
+
+		// strip nats_ prefix if present
+		sourceName := strings.TrimPrefix(info.Name, "nats_")
+
+		fmt.Printf("  • %s\n", bold(sourceName))
+		if info.Description != "" {
+			fmt.Printf("    %s\n", dim(info.Description))
+		}
+		fmt.Printf("    file: %s (%s, %s)\n", info.File, formatBytes(info.SizeBytes), info.Format)
+		fmt.Printf("    chunks: %d\n", info.Chunks)
+		if info.FilesIndexed > 0 {
+			fmt.Printf("    files indexed: %d\n", info.FilesIndexed)
+		}
+		if info.SourcePath != "" {
+			fmt.Printf("    source: %s\n", info.SourcePath)
+		}
+		if info.ImportedFrom != "" {
+			fmt.Printf("    imported from: %s\n", info.ImportedFrom)
+		}
+		if info.IndexedAt != "" {
+			fmt.Printf("    indexed: %s\n", info.IndexedAt)
+		}
+		if info.LastCommit != "" {
+			fmt.Printf("    commit: %.8s\n", info.LastCommit)
+		}
+		if info.Branch != "" {
+			fmt.Printf("    branch: %s\n", info.Branch)
+		}
+		if info.Stale != nil && *info.Stale {
+			changes := indexStaleness{ChangedFiles: info.ChangedFiles, Commits: info.CommitsBehind}
+			fmt.Printf("    %s %s - update with 'lr index --update --out-name %s'\n", yellow("stale:"), changes, info.Name)
+			stale++
+		}
+
+		// show embedding model and compatibility
+		if info.Dimensions > 0 {
+			model := info.EmbeddingModel
+			if model == "" {
+				model = "unknown"
+			}
+			compat := ""
+			if info.Compatible != nil {
+				if *info.Compatible {
+					compat = " " + green("✓")
+				} else {
+					compat = " " + red("✗")
+				}
+			}
+			fmt.Printf("    embedding: %s (%d dims)%s\n", model, info.Dimensions, compat)
+		}
+		fmt.Println()
+	}
+
+	if stale > 0 {
+		fmt.Printf("%s %d of %d index(es) are behind their source: answers may miss recent changes (lr update-all updates them all)\n",
+			yellow("warning:"), stale, len(infos))
+	}
+	return nil
+}
+
+func runUpdateAll(cmd *cobra.Command, _ []string) error {
+	chunkingChanged = chunkFlagsChanged(cmd)
+	if err := addDetectedFileTypes(); err != nil {
+		return err
+	}
+	indexDir := getDefaultIndexDir()
+	if _, err := noiseFilterFromFlags(); err != nil {
+		return err
+	}
+
+	// check if directory exists
+	if _, err := os.Stat(indexDir); os.IsNotExist(err) {
+		return withKind(fmt.Errorf("no indexes found - run 'lr index' first"), ErrNoIndex)
+	}
+
+	// find all index files
+	pattern := filepath.Join(indexDir, "*.lrindex")
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return fmt.Errorf("error searching for indexes: %w", err)
+	}
+
+	// filter out checkpoint and temp files
+	var validFiles []string
+	for _, file := range files {
+		if !isPartialIndexFile(file) {
+			validFiles = append(validFiles, file)
+		}
+	}
+
+	if len(validFiles) == 0 {
+		return withKind(fmt.Errorf("no indexes found"), ErrNoIndex)
+	}
+
+	// find indexes that have source paths and scan for changes
+	type indexInfo struct {
+		path        string
+		name        string
+		sourcePath  string
+		isGitRepo   bool
+		vs          *VectorStore
+		changeSet   *ChangeSet
+		needsPull   bool
+		behindCount int
+		movedTo     string   // branch checked out instead of the indexed one
+		rechunk     []string // how its chunking settings changed, if they did
+	}
+	var updatable []indexInfo
+
+	fmt.Println("scanning indexes for changes...")
+	for _, file := range validFiles {
+		vs := NewVectorStore()
+		if err := vs.Load(file); err != nil {
+			fmt.Printf("  ✗ %s: error loading\n", filepath.Base(file))
+			continue
+		}
+
+		if vs.Metadata.SourcePath == "" {
+			fmt.Printf("  - %s: no source path\n", filepath.Base(file))
+			continue
+		}
+
+		// check if source path exists
+		if _, err := os.Stat(vs.Metadata.SourcePath); os.IsNotExist(err) {
+			fmt.Printf("  ✗ %s: source not found: %s\n", filepath.Base(file), vs.Metadata.SourcePath)
+			continue
+		}
+
+		// extract name from filename
+		name := strings.TrimSuffix(filepath.Base(file), ".lrindex")
+		// remove date suffix
+		if parts := strings.Split(name, "_"); len(parts) > 1 {
+			lastPart := parts[len(parts)-1]
+			if len(lastPart) == 8 {
+				name = strings.Join(parts[:len(parts)-1], "_")
+			}
+		}
+
+		info := indexInfo{
+			path:       file,
+			name:       name,
+			sourcePath: vs.Metadata.SourcePath,
+			isGitRepo:  isGitRepo(vs.Metadata.SourcePath),
+			vs:         vs,
+		}
+
+		// determine extensions (default to code, config and data)
+		extensions := slices.Concat(codeExtensions, configExtensions, dataExtensions)
+
+		// an index of another branch than the one checked out isn't updated:
+		// the diff would cross branches and turn it into an index of this one
+		if info.isGitRepo {
+			info.movedTo = branchMoved(vs, vs.Metadata.SourcePath)
+		}
+
+		// detect changes
+		if info.movedTo != "" {
+			// skipped, reported below
+		} else if info.isGitRepo && vs.Metadata.LastCommit != "" {
+			// check if behind remote
+			behind := getGitBehindCount(vs.Metadata.SourcePath)
+			if behind > 0 {
+				info.needsPull = true
+				info.behindCount = behind
+			}
+
+			// git-based change detection
+			cs, err := detectChangesGit(vs.Metadata.SourcePath, vs.Metadata.LastCommit, extensions)
+			if err == nil {
+				info.changeSet = cs
+			}
+		} else if vs.Metadata.IndexedAt != "" {
+			// mtime-based change detection
+			indexedAt, err := time.Parse(time.RFC3339, vs.Metadata.IndexedAt)
+			if err == nil {
+				cs, err := detectChangesMtime(vs.Metadata.SourcePath, indexedAt, vs.Metadata.IndexedFiles, extensions)
+				if err == nil {
+					info.changeSet = cs
+				}
+			}
+		}
+
+		if _, changes, err := chunkChanges(vs); err == nil {
+			info.rechunk = changes
+		}
+
+		updatable = append(updatable, info)
+	}
+
+	if len(updatable) == 0 {
+		fmt.Println("\nno updatable indexes found (indexes need source paths)")
+		return nil
+	}
+
+	// show scan results
+	fmt.Printf("\n=== SCAN RESULTS ===\n")
+	var totalChanges int
+	var needsWork []indexInfo
+	var pullWarnings []string
+
+	for _, idx := range updatable {
+		if idx.needsPull {
+			pullWarnings = append(pullWarnings, fmt.Sprintf("  ⚠ %s: %d commits behind remote (consider: cd %s && git pull)",
+				idx.name, idx.behindCount, idx.sourcePath))
+		}
+
+		if idx.movedTo != "" {
+			hint := fmt.Sprintf("index it as %s@ or check out %s", idx.name, idx.vs.Metadata.Branch)
+			if pinnedBranch(idx.name) != "" {
+				hint = "check out " + idx.vs.Metadata.Branch + " to update it"
+			}
+			fmt.Printf("  ⚠ %s: built from branch %s, but %s is checked out - skipped (%s)\n",
+				idx.name, idx.vs.Metadata.Branch, idx.movedTo, hint)
+		} else if idx.changeSet != nil && idx.changeSet.HasChanges() {
+			changes := len(idx.changeSet.Added) + len(idx.changeSet.Modified) + len(idx.changeSet.Deleted)
+			totalChanges += changes
+			needsWork = append(needsWork, idx)
+			fmt.Printf("  ✓ %s: %d added, %d modified, %d deleted\n",
+				idx.name, len(idx.changeSet.Added), len(idx.changeSet.Modified), len(idx.changeSet.Deleted))
+			if len(idx.rechunk) > 0 {
+				fmt.Printf("    chunking changed (%s): all files need re-chunking\n", strings.Join(idx.rechunk, ", "))
+			}
+		} else if len(idx.rechunk) > 0 {
+			needsWork = append(needsWork, idx)
+			fmt.Printf("  ✓ %s: chunking changed (%s): all files need re-chunking\n", idx.name, strings.Join(idx.rechunk, ", "))
+		} else if idx.changeSet != nil {
+			fmt.Printf("  - %s: up to date\n", idx.name)
+		} else {
+			fmt.Printf("  ? %s: could not detect changes\n", idx.name)
+		}
+	}
+
+	// show pull warnings
+	if len(pullWarnings) > 0 {
+		fmt.Printf("\n=== GIT WARNINGS ===\n")
+		for _, w := range pullWarnings {
+			fmt.Println(w)
+		}
+	}
+
+	// if no work needed, exit early
+	if len(needsWork) == 0 {
+		skipped := 0
+		for _, idx := range updatable {
+			if idx.movedTo != "" {
+				skipped++
+			}
+		}
+		if skipped > 0 {
+			fmt.Printf("\nno index to update - %d skipped because another branch is checked out\n", skipped)
+			return nil
+		}
+		fmt.Println("\nall indexes are up to date - nothing to do")
+		return nil
+	}
+
+	fmt.Printf("\n%d index(es) need updating with %d total file changes\n", len(needsWork), totalChanges)
+
+	// create backup directory
+	backupDir := filepath.Join(indexDir, fmt.Sprintf("backup_%s", time.Now().Format("20060102_150405")))
+	if err := os.MkdirAll(backupDir, 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+	fmt.Printf("\ncreating backup in %s...\n", filepath.Base(backupDir))
+
+	// backup all index files
+	for _, file := range validFiles {
+		dst := filepath.Join(backupDir, filepath.Base(file))
+		if err := copyFile(file, dst); err != nil {
+			return fmt.Errorf("failed to backup %s: %w", filepath.Base(file), err)
+		}
+	}
+	fmt.Printf("backed up %d index files\n", len(validFiles))
+
+	// apply backup retention
+	if removed, err := pruneBackups(indexDir, keepBackups); err != nil {
+		fmt.Printf("warning: %v\n", err)
+	} else if len(removed) > 0 {
+		fmt.Printf("removed %d old backup(s): %s\n", len(removed), strings.Join(removed, ", "))
+	}
+
+	// get LLM client
+	llm, err := getLLMClient()
+	if err != nil {
+		return fmt.Errorf("failed to initialize LLM client: %w", err)
+	}
+
+	// update only indexes that need work
+	fmt.Println("\nupdating indexes...")
+	var successCount, failCount int
+	var rolledBack, rollbackFailed []string
+
+	for _, idx := range needsWork {
+		fmt.Printf("\n=== Updating %s ===\n", idx.name)
+
+		// set global variables for runIncrementalIndex
+		srcPath = idx.sourcePath
+		outName = idx.name
+
+		// determine output path
+		finalOutPath := filepath.Join(indexDir, fmt.Sprintf("%s_%s.lrindex", idx.name, time.Now().Format("20060102")))
+
+		lock, err := acquireIndexLock(indexDir, idx.name, waitLock)
+		if err != nil {
+			fmt.Printf("✗ skipping %s: %v\n", idx.name, err)
+			failCount++
+			continue
+		}
+
+		// run incremental update using existing function
+		err = runIncrementalIndexWithLLM(llm, finalOutPath)
+		lock.Release()
+		flushUsageFor(llm, "update-all", idx.name)
+		if err != nil {
+			fmt.Printf("✗ failed to update %s: %v\n", idx.name, err)
+			failCount++
+
+			// roll this index back to the backup taken above
+			actions, rbErr := rollbackIndex(backupDir, idx.path, finalOutPath)
+			for _, a := range actions {
+				fmt.Printf("  ↺ %s\n", a)
+			}
+			if rbErr != nil {
+				fmt.Printf("  ✗ rollback failed: %v (restore manually with: lr restore %s)\n", rbErr, filepath.Base(backupDir))
+				rollbackFailed = append(rollbackFailed, idx.name)
+			} else if len(actions) == 0 {
+				fmt.Println("  ↺ index unchanged on disk, nothing to roll back")
+			} else {
+				rolledBack = append(rolledBack, idx.name)
+			}
+			continue
+		}
+
+		successCount++
+	}
+
+	fmt.Printf("\n=== Summary ===\n")
+	fmt.Printf("updated: %d\n", successCount)
+	fmt.Printf("failed: %d\n", failCount)
+	if len(rolledBack) > 0 {
+		fmt.Printf("rolled back: %s\n", strings.Join(rolledBack, ", "))
+	}
+	if len(rollbackFailed) > 0 {
+		fmt.Printf("rollback failed: %s\n", strings.Join(rollbackFailed, ", "))
+	}
+	fmt.Printf("backup: %s\n", backupDir)
+
+	return nil
+}
+
+func runInteractive(cmd *cobra.Command, _ []string) error {
+	chatOptions, err := chatOptionsFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+
+	llm, err := getLLMClient()
+	if err != nil {
+		return err
+	}
+
+	// load all vector stores
+	indexDir := getDefaultIndexDir()
+	mss := NewMultiSourceStore(indexDir)
+	if err := mss.LoadAll(); err != nil {
+		return fmt.Errorf("error loading vector stores: %w\nrun 'lr index' to index repositories first", err)
+	}
+
+	if len(mss.Sources) == 0 {
+		return withKind(fmt.Errorf("no vector stores found\nrun 'lr index' to index repositories first"), ErrNoIndex)
+	}
+
+	fmt.Printf("loaded %d sources: %v\n", len(mss.Sources), mss.ListSources())
+
+	// there's no --top-k: the kind of each question picks it
+	topK = 0
+
+	// the models can be switched during the session
+	client := newSessionClient(llm)
+	rag := NewRAGMultiSource(mss, client)
+	rag.ChatOptions = chatOptions
+	rag.SelfCheck = selfCheck
+	rag.MinSimilarity = minSimilarity
+
+	fmt.Println("=== localrag interactive mode ===")
+	fmt.Println("ask questions about your indexed repositories. type '/save <file>' to save the session, '/model <model>' or '/embedding-model <model>' to switch models, 'exit' to quit.")
+	fmt.Println()
+
+	session := newTranscript(mss.ListSources())
+
+	// answers are cached against the indexes as loaded now
+	fingerprints := indexFingerprints(indexDir, nil)
+
+	scanner := bufio.NewScanner(os.Stdin)
+	prompt := isTerminal(os.Stdin)
+
+	for {
+		// questions piped on stdin are answered without prompting
+		if prompt {
+			fmt.Print("question: ")
+		}
+		if !scanner.Scan() {
+			break
+		}
+
+		question := strings.TrimSpace(scanner.Text())
+		if question == "" {
+			continue
+		}
+
+		if strings.ToLower(question) == "exit" || strings.ToLower(question) == "quit" {
+			fmt.Println("goodbye!")
+			break
+		}
+
+		if question == "/save" || strings.HasPrefix(question, "/save ") {
+			path := strings.TrimSpace(strings.TrimPrefix(question, "/save"))
+			if path == "" {
+				fmt.Printf("usage: /save <file>\n\n")
+				continue
+			}
+			if err := session.save(path); err != nil {
+				fmt.Printf("error: %v\n\n", err)
+				continue
+			}
+			fmt.Printf("saved %d question(s) to %s\n\n", len(session.entries), path)
+			continue
+		}
+
+		if runModelCommand(client, question) {
+			rag.EmbeddingModel = client.embedModel
+			continue
+		}
+
+		// a question asked before is answered from the cache
+		var resp *remoteQueryResponse
+		cacheKey := ""
+		if !noCache && !noSynthesize {
+			req := remoteQueryFromFlags(question)
+			req.Sources = nil
+			cacheKey = answerCacheKey(req, client.chatModel, client.embedModel, chatOptions, fingerprints)
+			resp = printCachedAnswer(question, cacheKey)
+		}
+
+		// query the rag system
+		if resp == nil {
+			answer, results, err := answerQuestion(rag, question, topK, nil)
+			if err != nil {
+				fmt.Printf("error: %v\n\n", dimensionAdvice(err))
+				continue
+			}
+			logRetrieval("interactive", question, results)
+			resp = ragResponse(rag, answer, results)
+			if cacheKey != "" {
+				saveCachedAnswer(cacheKey, question, resp)
+			}
+		}
+
+		session.add(question, resp)
+		if transcriptPath != "" {
+			if err := session.save(transcriptPath); err != nil {
+				fmt.Printf("error: %v\n\n", err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func runMCP(_ *cobra.Command, _ []string) error {
+	return serveMCP()
+}
+
+func runPaths(_ *cobra.Command, _ []string) {
+	fmt.Println("=== lr data directories ===")
+	fmt.Println()
+	if rawURL := indexDirURL(); rawURL != "" {
+		fmt.Printf("indexes:  %s (cached in %s)\n", rawURL, objectCacheDir(rawURL))
+	} else {
+		fmt.Printf("indexes:  %s\n", getDefaultIndexDir())
+	}
+	fmt.Printf("config:   %s\n", getConfigDir())
+	fmt.Printf("env file: %s\n", getEnvFilePath())
+	fmt.Println()
+	fmt.Println("these directories follow the XDG base directory specification")
+	fmt.Println("you can override them with environment variables:")
+	fmt.Println("  XDG_DATA_HOME   - base directory for data files")
+	fmt.Println("  XDG_CONFIG_HOME - base directory for config files")
+	fmt.Println("  XDG_CACHE_HOME  - base directory for cached object store indexes")
+	fmt.Println("  LR_INDEX_DIR    - index directory, a path or an s3:// or gs:// url")
+}
+
+func runSetup(_ *cobra.Command, _ []string) error {
+	// get the absolute path to the lr binary
+	execPath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to determine executable path: %w", err)
+	}
+
+	// resolve symlinks to get the actual binary path
+	realPath, err := filepath.EvalSymlinks(execPath)
+	if err != nil {
+		realPath = execPath
+	}
+
+	fmt.Println("=== claude code mcp setup ===")
+	fmt.Println()
+	fmt.Println("run this command to register lr with claude code:")
+	fmt.Println()
+	fmt.Printf("  claude mcp add --transport stdio lr -- %s mcp\n", realPath)
+	fmt.Println()
+	fmt.Println("after running this command:")
+	fmt.Println("  - restart claude code to activate the mcp server")
+	fmt.Println("  - ask questions about your indexed repositories naturally")
+	fmt.Println()
+	fmt.Println("notes:")
+	fmt.Println("  - the mcp server preloads indexes at startup for fast queries")
+	fmt.Println("  - to pick up newly indexed repositories, restart claude code")
+	fmt.Println("  - use 'lr paths' to see where your indexes are stored")
+	fmt.Println()
+
+	return nil
+}
+
+func indexSingleSource(llm LLMClient, srcPath, outPath string, loader func(string) ([]Document, error)) error {
+	start := time.Now()
+
+	// check if source exists
+	if _, err := os.Stat(srcPath); os.IsNotExist(err) {
+		return fmt.Errorf("source directory not found: %s", srcPath)
+	}
+
+	// load files
+	fmt.Printf("loading files from %s...\n", srcPath)
+	docs, err := loader(srcPath)
+	if err != nil {
+		return fmt.Errorf("failed to load files: %w", err)
+	}
+	fmt.Printf("loaded %d files\n", len(docs))
+
+	// chunk documents
+	fmt.Println("chunking files...")
+	settings, err := chunkSettingsFromFlags()
+	if err != nil {
+		return err
+	}
+	chunks, noiseReport := chunkDocuments(docs, settings)
+	fmt.Printf("created %d chunks\n", len(chunks))
+	printNoiseReport(noiseReport, settings.Filter())
+	printChunkCaps(chunks, settings)
+
+	// use the output path as-is (timestamp already applied in runIndex if using --out-name)
+	outputFile := outPath
+
+	// ensure output directory exists
+	outputDir := filepath.Dir(outputFile)
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	// checkpoint file (same name but with .checkpoint before extension)
+	checkpointFile := checkpointPathFor(outputFile)
+
+	// try to load checkpoint if it exists
+	vs := NewVectorStore()
+	startIdx := 0
+
+	if _, err := os.Stat(checkpointFile); err == nil {
+		fmt.Printf("found checkpoint, resuming...\n")
+		if err := vs.Load(checkpointFile); err != nil {
+			fmt.Printf("warning: could not load checkpoint: %v\n", err)
+		} else {
+			startIdx = len(vs.Chunks)
+			fmt.Printf("resuming from chunk %d/%d\n", startIdx, len(chunks))
+		}
+	}
+
+	// create embeddings
+	var bar *progressbar.ProgressBar
+	if startIdx == 0 {
+		bar = newProgressBar(len(chunks), "generating embeddings")
+	} else {
+		bar = newProgressBar(len(chunks)-startIdx, "resuming embeddings")
+	}
+
+	for i := startIdx; i < len(chunks); i++ {
+		chunk := chunks[i]
+		embedding, err := llm.GetEmbedding(chunk.Text)
+		if err != nil {
+			return fmt.Errorf("failed to get embedding for chunk %d (size: %d chars, ~%d tokens): %w",
+				i, len(chunk.Text), len(chunk.Text)/4, err)
+		}
+
+		vs.Add(chunk, embedding)
+		bar.Add(1)
+
+		// save checkpoint periodically
+		if (i+1)%checkpointInterval == 0 {
+			if err := atomicSave(vs, checkpointFile); err != nil {
+				fmt.Printf("\nwarning: failed to save checkpoint: %v\n", err)
+			}
+		}
+
+		// small delay to avoid rate limits (the mock client has none)
+		if !mockLLM {
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+	bar.Finish()
+	fmt.Println()
+
+	if summarize {
+		if vs.Summaries, err = summarizeFiles(llm, docs); err != nil {
+			return err
+		}
+	}
+
+	// set metadata before saving
+	absPath, _ := filepath.Abs(srcPath)
+	vs.Metadata.SourcePath = absPath
+	vs.Metadata.IndexedAt = time.Now().Format(time.RFC3339)
+	vs.Metadata.ChunkCount = len(vs.Chunks)
+	vs.Metadata.FileCount = len(docs)
+	vs.Metadata.EmbeddingModel = getCurrentEmbeddingModel()
+	vs.Metadata.Chunking = &settings
+
+	// populate indexed files list
+	fileSet := make(map[string]bool)
+	for _, doc := range docs {
+		fileSet[doc.Source] = true
+	}
+	vs.Metadata.IndexedFiles = make([]string, 0, len(fileSet))
+	for f := range fileSet {
+		vs.Metadata.IndexedFiles = append(vs.Metadata.IndexedFiles, f)
+	}
+
+	// record git commit and branch if in a git repo
+	if isGitRepo(srcPath) {
+		if commit, err := getGitHeadCommit(srcPath); err == nil {
+			vs.Metadata.LastCommit = commit
+		}
+		vs.Metadata.Branch, _ = getGitBranch(srcPath)
+	}
+
+	// save final vector store
+	fmt.Printf("saving %s...\n", outputFile)
+	if err := atomicSave(vs, outputFile); err != nil {
+		return fmt.Errorf("failed to save vector store: %w", err)
+	}
+
+	// remove checkpoint file since we completed successfully
+	removeCheckpoint(checkpointFile)
+	if err := mirrorToPG(outputFile, vs); err != nil {
+		return err
+	}
+
+	elapsed := time.Since(start)
+	fmt.Printf("✓ indexed successfully (%d chunks in %s)\n", len(chunks), elapsed.Round(time.Second))
+	return nil
+}
+
+func runIncrementalIndex(finalOutPath string) error {
+	// get LLM client
+	llm, err := getLLMClient()
+	if err != nil {
+		return err
+	}
+	defer flushUsageFor(llm, "index", outName)
+	return runIncrementalIndexWithLLM(llm, finalOutPath)
+}
+
+// migrateIndexedFiles populates IndexedFiles from chunk sources for old indexes
+// that predate it. returns true if the index was migrated
+func migrateIndexedFiles(vs *VectorStore) bool {
+	if len(vs.Metadata.IndexedFiles) > 0 || len(vs.Chunks) == 0 {
+		return false
+	}
+	fileSet := make(map[string]bool)
+	for _, chunk := range vs.Chunks {
+		fileSet[chunk.Source] = true
+	}
+	vs.Metadata.IndexedFiles = make([]string, 0, len(fileSet))
+	for f := range fileSet {
+		vs.Metadata.IndexedFiles = append(vs.Metadata.IndexedFiles, f)
+	}
+	return true
+}
+
+// indexExtensions returns the file extensions and doc type selected by
+// --code/--config/--data/--docs
+func indexExtensions() ([]string, string) {
+	var extensions, kinds []string
+	if useCode {
+		extensions, kinds = append(extensions, codeExtensions...), append(kinds, "code")
+	}
+	if useConfig {
+		extensions, kinds = append(extensions, configExtensions...), append(kinds, "config")
+	}
+	if useData {
+		extensions, kinds = append(extensions, dataExtensions...), append(kinds, "data")
+	}
+	if useDocs {
+		extensions, kinds = append(extensions, ".md"), append(kinds, "markdown")
+	}
+	switch len(kinds) {
+	case 0:
+		return codeExtensions, "code"
+	case 1:
+		return extensions, kinds[0]
+	}
+	return extensions, "mixed"
+}
+
+// detectIndexChanges finds source files changed since the index was built, and describes
+// the baseline it compared against. uses git if the index has LastCommit and source is
+// a git repo (or --git is set), otherwise mtimes
+func detectIndexChanges(vs *VectorStore, existingIndex, srcPath string, extensions []string) (*ChangeSet, string, error) {
+	var changeSet *ChangeSet
+	var since string
+	var err error
+	canUseGit := vs.Metadata.LastCommit != "" && isGitRepo(srcPath)
+	if useGit || canUseGit {
+		// git-based detection
+		if !isGitRepo(srcPath) {
+			return nil, "", fmt.Errorf("--git specified but %s is not a git repository", srcPath)
+		}
+		if vs.Metadata.LastCommit == "" {
+			return nil, "", fmt.Errorf("existing index has no LastCommit - full re-index required")
+		}
+		since = "commit " + vs.Metadata.LastCommit[:8]
+		changeSet, err = detectChangesGit(srcPath, vs.Metadata.LastCommit, extensions)
+		if err != nil {
+			return nil, "", fmt.Errorf("git change detection failed: %w", err)
+		}
+	} else {
+		// mtime-based detection
+		var indexedAt time.Time
+		if vs.Metadata.IndexedAt != "" {
+			indexedAt, err = time.Parse(time.RFC3339, vs.Metadata.IndexedAt)
+			if err != nil {
+				return nil, "", fmt.Errorf("cannot parse IndexedAt timestamp: %w", err)
+			}
+		} else {
+			// fallback: extract date from index filename (e.g., name_20251109.lrindex)
+			baseName := filepath.Base(existingIndex)
+			// find the date part (8 digits before .lrindex)
+			if idx := strings.LastIndex(baseName, "_"); idx > 0 {
+				datePart := strings.TrimSuffix(baseName[idx+1:], ".lrindex")
+				if len(datePart) == 8 {
+					indexedAt, err = time.Parse("20060102", datePart)
+					if err != nil {
+						return nil, "", fmt.Errorf("cannot extract date from index filename: %w", err)
+					}
+				}
+			}
+			if indexedAt.IsZero() {
+				// last resort: use file modification time
+				info, err := os.Stat(existingIndex)
+				if err != nil {
+					return nil, "", fmt.Errorf("cannot stat index file: %w", err)
+				}
+				indexedAt = info.ModTime()
+			}
+		}
+		since = indexedAt.Format("2006-01-02 15:04:05")
+		changeSet, err = detectChangesMtime(srcPath, indexedAt, vs.Metadata.IndexedFiles, extensions)
+		if err != nil {
+			return nil, "", fmt.Errorf("mtime change detection failed: %w", err)
+		}
+	}
+	return changeSet, since, nil
+}
+
+func runIncrementalIndexWithLLM(llm LLMClient, finalOutPath string) error {
+	start := time.Now()
+
+	// find existing index
+	indexDir := getDefaultIndexDir()
+	existingIndex, err := findExistingIndex(indexDir, outName)
+	if err != nil {
+		return fmt.Errorf("cannot update: %w", err)
+	}
+	fmt.Printf("found existing index: %s\n", filepath.Base(existingIndex))
+
+	// load existing index
+	vs := NewVectorStore()
+	if err := vs.Load(existingIndex); err != nil {
+		return fmt.Errorf("failed to load existing index: %w", err)
+	}
+	fmt.Printf("loaded %d existing chunks\n", len(vs.Chunks))
+
+	// migrate old indexes: populate IndexedFiles from chunk sources if empty
+	if migrateIndexedFiles(vs) {
+		fmt.Printf("migrated index: found %d indexed files from chunks\n", len(vs.Metadata.IndexedFiles))
+	}
+
+	// check source exists
+	if _, err := os.Stat(srcPath); os.IsNotExist(err) {
+		return fmt.Errorf("source directory not found: %s", srcPath)
+	}
+
+	// an index that isn't pinned to a branch follows the checked-out one
+	if moved := branchMoved(vs, srcPath); moved != "" {
+		fmt.Printf("%s %s was built from branch %s, but %s is checked out; the update follows %s (use --out-name %s@ to keep an index per branch)\n",
+			yellow("warning:"), outName, vs.Metadata.Branch, moved, moved, outName)
+	}
+
+	// determine extensions
+	extensions, docType := indexExtensions()
+
+	// detect changes
+	changeSet, since, err := detectIndexChanges(vs, existingIndex, srcPath, extensions)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("detecting changes since %s...\n", since)
+
+	// changed files are chunked as the rest of the index, or all are re-chunked
+	settings, rechunkAll, err := updateChunkSettings(vs)
+	if err != nil {
+		return err
+	}
+	if rechunkAll {
+		changeSet = rechunkChanges(vs, changeSet)
+		fmt.Printf("re-chunking all %d files\n", len(changeSet.ChangedFiles()))
+	}
+
+	// report changes
+	fmt.Printf("\n=== CHANGES DETECTED ===\n")
+	fmt.Printf("Added:    %d files\n", len(changeSet.Added))
+	fmt.Printf("Modified: %d files\n", len(changeSet.Modified))
+	fmt.Printf("Deleted:  %d files\n", len(changeSet.Deleted))
+
+	if dryRun && jsonOutput {
+		report, err := updateDryRunReport(changeSet, docType, settings)
+		if err != nil {
+			return err
+		}
+		if len(showChunks) > 0 {
+			if report.Preview, _, err = previewChunks(docType, settings); err != nil {
+				return err
+			}
+		}
+		return report.write(dryRunStdout)
+	}
+	if dryRun && len(showChunks) > 0 {
+		preview, skipped, err := previewChunks(docType, settings)
+		if err != nil {
+			return err
+		}
+		printChunkPreview(preview, skipped, settings, nil)
+	}
+
+	if !changeSet.HasChanges() {
+		fmt.Println("\nno changes detected - index is up to date")
+		return nil
+	}
+
+	// dry run - just show what would happen
+	if dryRun {
+		fmt.Println("\n=== DRY RUN ===")
+		if len(changeSet.Added) > 0 {
+			fmt.Println("Files to add:")
+			for _, f := range changeSet.Added {
+				fmt.Printf("  + %s\n", f)
+			}
+		}
+		if len(changeSet.Modified) > 0 {
+			fmt.Println("Files to re-index:")
+			for _, f := range changeSet.Modified {
+				fmt.Printf("  ~ %s\n", f)
+			}
+		}
+		if len(changeSet.Deleted) > 0 {
+			fmt.Println("Files to remove:")
+			for _, f := range changeSet.Deleted {
+				fmt.Printf("  - %s\n", f)
+			}
+		}
+		return nil
+	}
+
+	// checkpoint for resuming an interrupted update
+	checkpointFile := checkpointPathFor(finalOutPath)
+
+	// an index built with file summaries keeps them current
+	summarizeChanged := summarize || len(vs.Summaries) > 0
+
+	// remove chunks from modified/deleted files
+	toRemove := changeSet.RemovedFiles()
+	if len(toRemove) > 0 {
+		removed := vs.RemoveBySource(toRemove)
+		fmt.Printf("removed %d chunks from %d changed/deleted files\n", removed, len(toRemove))
+	}
+
+	// load changed files
+	changedFiles := changeSet.ChangedFiles()
+	if len(changedFiles) > 0 {
+		fmt.Printf("loading %d changed files...\n", len(changedFiles))
+		loadResult, err := LoadSpecificFiles(srcPath, changedFiles, docType, maxFileSize, splitLarge)
+		if err != nil {
+			return fmt.Errorf("failed to load changed files: %w", err)
+		}
+
+		// chunk new documents
+		newChunks, noiseReport := chunkDocuments(loadResult.Documents, settings)
+		fmt.Printf("created %d new chunks\n", len(newChunks))
+		printNoiseReport(noiseReport, settings.Filter())
+		printChunkCaps(newChunks, settings)
+
+		if len(newChunks) > 0 {
+			// reuse embeddings from an interrupted update if a checkpoint exists
+			cache := loadEmbeddingCache(checkpointFile)
+			if len(cache) > 0 {
+				fmt.Printf("found checkpoint, resuming with %d cached embeddings...\n", len(cache))
+			}
+
+			// generate embeddings for new chunks
+			bar := newProgressBar(len(newChunks), "generating embeddings")
+
+			embedded := 0
+			for _, chunk := range newChunks {
+				if embedding, ok := cache.Lookup(chunk); ok {
+					vs.Add(chunk, embedding)
+					bar.Add(1)
+					continue
+				}
+
+				embedding, err := llm.GetEmbedding(chunk.Text)
+				if err != nil {
+					return fmt.Errorf("failed to get embedding: %w", err)
+				}
+				vs.Add(chunk, embedding)
+				bar.Add(1)
+				embedded++
+
+				// save checkpoint periodically
+				if embedded%checkpointInterval == 0 {
+					if err := atomicSave(vs, checkpointFile); err != nil {
+						fmt.Printf("\nwarning: failed to save checkpoint: %v\n", err)
+					}
+				}
+
+				if !mockLLM {
+					time.Sleep(50 * time.Millisecond) // rate limit
+				}
+			}
+			bar.Finish()
+			fmt.Println()
+		}
+
+		if summarizeChanged {
+			summaries, err := summarizeFiles(llm, loadResult.Documents)
+			if err != nil {
+				return err
+			}
+			vs.Summaries = append(vs.Summaries, summaries...)
+		}
+
+		// update indexed files list
+		// remove deleted files, add new files
+		fileSet := make(map[string]bool)
+		for _, f := range vs.Metadata.IndexedFiles {
+			fileSet[f] = true
+		}
+		for _, f := range changeSet.Deleted {
+			delete(fileSet, f)
+		}
+		for _, f := range changeSet.Added {
+			fileSet[f] = true
+		}
+		vs.Metadata.IndexedFiles = make([]string, 0, len(fileSet))
+		for f := range fileSet {
+			vs.Metadata.IndexedFiles = append(vs.Metadata.IndexedFiles, f)
+		}
+	}
+
+	// update metadata
+	absPath, _ := filepath.Abs(srcPath)
+	vs.Metadata.SourcePath = absPath
+	vs.Metadata.IndexedAt = time.Now().Format(time.RFC3339)
+	vs.Metadata.ChunkCount = len(vs.Chunks)
+	vs.Metadata.FileCount = len(vs.Metadata.IndexedFiles)
+	vs.Metadata.EmbeddingModel = getCurrentEmbeddingModel()
+	vs.Metadata.Chunking = &settings
+	if useGit {
+		commit, _ := getGitHeadCommit(srcPath)
+		vs.Metadata.LastCommit = commit
+	}
+	if isGitRepo(srcPath) {
+		vs.Metadata.Branch, _ = getGitBranch(srcPath)
+	}
+
+	// atomic save
+	fmt.Printf("saving %s...\n", filepath.Base(finalOutPath))
+	if err := atomicSave(vs, finalOutPath); err != nil {
+		return fmt.Errorf("failed to save index: %w", err)
+	}
+	removeCheckpoint(checkpointFile)
+	cleanupSupersededIndexes(indexDir, outName, finalOutPath)
+	if err := mirrorToPG(finalOutPath, vs); err != nil {
+		return err
+	}
+
+	elapsed := time.Since(start)
+	fmt.Printf("✓ incremental update complete (%d total chunks in %s)\n", len(vs.Chunks), elapsed.Round(time.Second))
+	return nil
+}
+
+// printSkippedSources warns about indexes a query couldn't search because they
+// were built with a different embedding model
+func printSkippedSources(skipped []*store.DimensionError) {
+	for _, derr := range skipped {
+		fmt.Printf("%s skipped %s\n", yellow("warning:"), dimensionAdvice(derr))
+	}
+}
+
+// printCitationProblems warns about citations in an answer that don't match the
+// documents it was given
+func printCitationProblems(citations []Citation) {
+	for _, problem := range citationProblems(citations) {
+		fmt.Printf("%s possibly fabricated citation: %s\n", yellow("warning:"), problem)
+	}
+}
+
+// citationProblems lists what's wrong with each suspect citation
+func citationProblems(citations []Citation) []string {
+	var problems []string
+	for _, c := range citations {
+		if c.Problem != "" {
+			problems = append(problems, c.Problem)
+		}
+	}
+	return problems
+}
+
+// printConfidence shows how far an answer can be trusted
+func printConfidence(c *Confidence) {
+	if c == nil {
+		return
+	}
+	level := c.Level
+	switch c.Level {
+	case confidenceHigh:
+		level = green(level)
+	case confidenceMedium:
+		level = yellow(level)
+	default:
+		level = red(level)
+	}
+	fmt.Printf("%s %s %s\n", bold("confidence:"), level, dim(fmt.Sprintf("(%.2f: %s)", c.Score, strings.Join(c.Reasons, ", "))))
+}
+
+// chatOptionsFromFlags returns the generation parameters set with --max-tokens,
+// --temperature and --stop, or nil if none were set
+func chatOptionsFromFlags(cmd *cobra.Command) (*ChatOptions, error) {
+	flags := cmd.Flags()
+	if !flags.Changed("max-tokens") && !flags.Changed("temperature") && !flags.Changed("stop") {
+		return nil, nil
+	}
+	if maxTokens < 0 {
+		return nil, fmt.Errorf("--max-tokens must be positive")
+	}
+	opts := &ChatOptions{MaxTokens: maxTokens, Stop: stopSequences}
+	if flags.Changed("temperature") {
+		if temperature < 0 || temperature > 2 {
+			return nil, fmt.Errorf("--temperature must be between 0 and 2")
+		}
+		t := temperature
+		opts.Temperature = &t
+	}
+	return opts, nil
+}
+
+func printResults(question, answer string, results []SearchResult) {
+	printQuestion(question)
+	fmt.Printf("\n%s\n%s\n", bold("answer:"), answer)
+	printSources(results)
+}
+
+// printQuestion prints the header above an answer
+func printQuestion(question string) {
+	fmt.Println("\n" + dim(strings.Repeat("=", 80)))
+	fmt.Printf("%s %s\n", bold("question:"), question)
+	fmt.Println(dim(strings.Repeat("=", 80)))
+}
+
+// printSources lists the chunks an answer was based on
+func printSources(results []SearchResult) {
+	fmt.Println("\n" + bold("sources:"))
+	for i, result := range results {
+		score := fmt.Sprintf("similarity: %.3f", result.Similarity)
+		fmt.Printf("  [%d] %s (%s)\n", i+1, cyan(result.Chunk.Source), similarityColor(result.Similarity, score))
+		if structure := chunkStructure(result.Chunk); structure != "" {
+			fmt.Printf("      %s\n", dim(structure))
+		}
+		if len(result.AlsoIn) > 0 {
+			fmt.Printf("      %s\n", dim("also in: "+strings.Join(result.AlsoIn, ", ")))
+		}
+	}
+	fmt.Println()
+}
@@ -0,0 +1,1142 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// global preloaded resources
+var (
+	preloadedMSS *MultiSourceStore
+	preloadedLLM LLMClient
+	preloadMutex sync.RWMutex
+)
+
+// mcpLog reports the mcp server's progress on stderr. the standard logger is
+// discarded while serving, and goroutines must not toggle its output
+var mcpLog = log.New(os.Stderr, "", log.LstdFlags)
+
+// withToolTimeout bounds every tool call by mcpToolTimeout; client cancellation
+// propagates through the same ctx
+func withToolTimeout(handler server.ToolHandlerFunc) server.ToolHandlerFunc {
+	return func(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		if mcpToolTimeout <= 0 {
+			return handler(ctx, request)
+		}
+		ctx, cancel := context.WithTimeout(ctx, mcpToolTimeout)
+		defer cancel()
+		return handler(ctx, request)
+	}
+}
+
+func createMCPServer() *server.MCPServer {
+	// create mcp server
+	s := server.NewMCPServer(
+		"localrag",
+		"1.0.0",
+		server.WithToolCapabilities(true),
+	)
+
+	// add query tool
+	queryTool := mcp.NewTool("query_repositories",
+		mcp.WithDescription("Query indexed code repositories and documentation. Returns relevant information from indexed sources."),
+		mcp.WithString("query",
+			mcp.Required(),
+			mcp.Description("The question to ask about the indexed repositories")),
+		mcp.WithNumber("top_k",
+			mcp.Description("Number of relevant chunks to retrieve (default: by question type: 2 for symbol lookups, 8 for architecture questions (at most 2 per file), otherwise 3)")),
+		mcp.WithBoolean("synthesize",
+			mcp.Description("Use LLM to synthesize an answer from the chunks (default: true). Set to false to return raw chunks only.")),
+		mcp.WithString("sources",
+			mcp.Description("Comma-separated list of source names to search (e.g., 'jwt,nats-server'). If not specified, searches all sources.")),
+		mcp.WithNumber("min_similarity",
+			mcp.Description("Reply 'not covered by indexed sources' with the nearest matches, instead of a guess, when the best match is below this similarity or the answer cites no retrieved chunk (default: LR_MIN_SIMILARITY, or 0 to disable).")),
+		mcp.WithBoolean("deep",
+			mcp.Description("For multi-hop questions (e.g. how a setting flows into a component): let the LLM run a few follow-up searches before answering (default: false; more LLM calls).")),
+		mcp.WithNumber("expand_imports",
+			mcp.Description("For Go code: also return up to this many chunks from the packages the top Go result's file imports or that import it, for the cross-package context (types, callers) it relies on (default: 0).")),
+		mcp.WithString("refs",
+			mcp.Description("Also return the callers ('callers'), the callees ('callees') or both ('both') of the functions retrieved, from the call graph built at index time. Use 'callers' to answer who uses a function (default: none).")),
+		mcp.WithString("exclude_paths",
+			mcp.Description("Comma-separated gitignore-style patterns of files to leave out of the results (e.g. 'vendor/**,*_test.go'), on top of those set with lr exclude.")),
+		mcp.WithBoolean("self_check",
+			mcp.Description("Also ask the LLM whether the retrieved chunks support its answer, refining the reported confidence (default: false; one extra LLM call).")),
+	)
+
+	s.AddTool(queryTool, withToolTimeout(handleQuery))
+
+	// add list_indexes tool
+	listTool := mcp.NewTool("list_indexes",
+		mcp.WithDescription("List all available indexed repositories with metadata. Use this to see what's indexed before querying."),
+	)
+	s.AddTool(listTool, withToolTimeout(handleListIndexes))
+
+	// add get_index_stats tool
+	statsTool := mcp.NewTool("get_index_stats",
+		mcp.WithDescription("Get detailed statistics about a specific index including file list."),
+		mcp.WithString("name",
+			mcp.Required(),
+			mcp.Description("The index name (e.g., 'nats-server', 'docs')")),
+	)
+	s.AddTool(statsTool, withToolTimeout(handleGetIndexStats))
+
+	// add search_by_file tool
+	fileTool := mcp.NewTool("search_by_file",
+		mcp.WithDescription("Get all indexed chunks from a specific file. Use this when user asks about a specific file rather than a concept."),
+		mcp.WithString("path",
+			mcp.Required(),
+			mcp.Description("The file path to search for (can be partial, e.g., 'server.go' or 'cmd/main.go')")),
+	)
+	s.AddTool(fileTool, withToolTimeout(handleSearchByFile))
+
+	// add get_file tool
+	getFileTool := mcp.NewTool("get_file",
+		mcp.WithDescription("Read the full current content of an indexed file from its source directory. Use this after a search points you at a file, to see all of it rather than the retrieved chunks. Only files that were indexed can be read; large files must be read by line range."),
+		mcp.WithString("path",
+			mcp.Required(),
+			mcp.Description("The file path as shown in search results (e.g. 'server/auth.go' or 'nats-server:server/auth.go'); a unique partial path such as 'auth.go' also works")),
+		mcp.WithString("source",
+			mcp.Description("The index to read from, when the path exists in several")),
+		mcp.WithNumber("start_line",
+			mcp.Description("First line to return, 1-based (default: 1)")),
+		mcp.WithNumber("end_line",
+			mcp.Description("Last line to return (default: the end of the file)")),
+	)
+	s.AddTool(getFileTool, withToolTimeout(handleGetFile))
+
+	// add keyword_search tool
+	keywordTool := mcp.NewTool("keyword_search",
+		mcp.WithDescription("Find the lines of indexed chunks matching a regular expression (Go syntax), like grep across every index. Use this instead of query_repositories for exact identifiers, error messages or config keys: it needs no embedding and returns every occurrence."),
+		mcp.WithString("pattern",
+			mcp.Required(),
+			mcp.Description("The regular expression to search for (e.g. 'NewVectorStore\\(' or 'max_payload')")),
+		mcp.WithString("sources",
+			mcp.Description("Comma-separated list of source names to search. If not specified, searches all sources.")),
+		mcp.WithBoolean("ignore_case",
+			mcp.Description("Match case-insensitively (default: false)")),
+		mcp.WithBoolean("fixed",
+			mcp.Description("Match the pattern literally rather than as a regular expression (default: false)")),
+		mcp.WithNumber("limit",
+			mcp.Description("Maximum number of matching lines to return (default: 50)")),
+	)
+	s.AddTool(keywordTool, withToolTimeout(handleKeywordSearch))
+
+	// add get_chunk_neighbors tool
+	neighborsTool := mcp.NewTool("get_chunk_neighbors",
+		mcp.WithDescription("Get the chunks before and after a chunk returned by query_repositories, from the same file. Use this to expand context around a result incrementally instead of re-querying with a higher top_k."),
+		mcp.WithString("chunk_id",
+			mcp.Required(),
+			mcp.Description("The chunk id from query_repositories results (index:path#id, e.g. 'nats-server:server/auth.go#3f2a9c1e0b4d')")),
+		mcp.WithNumber("before",
+			mcp.Description("Number of preceding chunks to return (default: 1, max: 10)")),
+		mcp.WithNumber("after",
+			mcp.Description("Number of following chunks to return (default: 1, max: 10)")),
+	)
+	s.AddTool(neighborsTool, withToolTimeout(handleGetChunkNeighbors))
+
+	// add server_status tool
+	statusTool := mcp.NewTool("server_status",
+		mcp.WithDescription("Report the health of this lr server: preload state, loaded indexes with chunk counts, embedding and chat model configuration, memory usage and uptime. Use this when asked whether lr is working or which indexes it sees."),
+	)
+	s.AddTool(statusTool, withToolTimeout(handleServerStatus))
+
+	// add get_diff_context tool for code review
+	diffTool := mcp.NewTool("get_diff_context",
+		mcp.WithDescription("Get git diff with relevant indexed context for code review. Requires an active review session (lr review start). By default returns all changes on current branch vs main/master, plus relevant code context from the review index (the changed files and semantically related code elsewhere: callers, interfaces, docs) and a summary of the project's conventions documents (CONVENTIONS.md, CONTRIBUTING.md, style guides) to check the change against."),
+		mcp.WithNumber("top_k",
+			mcp.Description("Number of relevant context chunks per changed file (default: 3)")),
+		mcp.WithBoolean("uncommitted_only",
+			mcp.Description("Only show uncommitted and staged changes instead of full branch diff (default: false)")),
+	)
+	s.AddTool(diffTool, withToolTimeout(handleGetDiffContext))
+
+	return s
+}
+
+// onDemandCache reuses unchanged indexes between requests in --no-preload mode.
+// concurrent requests share a single read of each changed index
+var onDemandCache = NewStoreCache()
+
+// currentStores returns the preloaded stores, or loads them from disk in no-preload mode
+// and while the background preload is still running. on-demand loads go through
+// onDemandCache so a concurrent update never yields a partial read, and indexes the
+// preload has already read (or is reading) are shared rather than read again
+func currentStores() (*MultiSourceStore, error) {
+	preloadMutex.RLock()
+	mss := preloadedMSS
+	preloadMutex.RUnlock()
+	if mss != nil {
+		return mss, nil
+	}
+
+	mss = NewMultiSourceStore(getDefaultIndexDir())
+	mss.Cache = onDemandCache
+	if err := mss.LoadAll(); err != nil {
+		return nil, err
+	}
+	return mss, nil
+}
+
+func handleQuery(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// get arguments as map
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok {
+		return mcp.NewToolResultError("invalid arguments"), nil
+	}
+
+	// get query parameter
+	query, ok := args["query"].(string)
+	if !ok || query == "" {
+		return mcp.NewToolResultError("query parameter is required"), nil
+	}
+
+	// get top_k parameter (optional, 0 picks it by question type)
+	topKVal := 0.0
+	if topKArg, ok := args["top_k"]; ok {
+		if topKFloat, ok := topKArg.(float64); ok {
+			topKVal = topKFloat
+		}
+	}
+	topK := int(topKVal)
+
+	// get synthesize parameter (optional, default from env or true)
+	synthesize := true
+	if synthEnv := os.Getenv("LR_SYNTHESIZE"); synthEnv != "" {
+		synthesize = synthEnv != "false"
+	}
+	if synthArg, ok := args["synthesize"]; ok {
+		if synthBool, ok := synthArg.(bool); ok {
+			synthesize = synthBool
+		}
+	}
+
+	// get self_check and deep parameters (optional)
+	selfCheck, _ := args["self_check"].(bool)
+	deep, _ := args["deep"].(bool)
+
+	// get expand_imports parameter (optional)
+	expandImports := 0
+	if v, ok := args["expand_imports"].(float64); ok && v > 0 {
+		expandImports = int(v)
+	}
+
+	// get refs parameter (optional)
+	refsArg, _ := args["refs"].(string)
+	refs, err := parseRefDirection(refsArg)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	// get exclude_paths parameter (optional)
+	var exclude []string
+	if v, ok := args["exclude_paths"].(string); ok {
+		for _, p := range strings.Split(v, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				exclude = append(exclude, p)
+			}
+		}
+	}
+
+	// get min_similarity parameter (optional, default from env)
+	var minSim float64
+	if env := os.Getenv("LR_MIN_SIMILARITY"); env != "" {
+		v, err := strconv.ParseFloat(env, 64)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("invalid LR_MIN_SIMILARITY %q", env)), nil
+		}
+		minSim = v
+	}
+	if v, ok := args["min_similarity"].(float64); ok {
+		minSim = v
+	}
+
+	// get sources parameter (optional)
+	var sources []string
+	if sourcesArg, ok := args["sources"].(string); ok && sourcesArg != "" {
+		for _, s := range strings.Split(sourcesArg, ",") {
+			s = strings.TrimSpace(s)
+			if s != "" {
+				sources = append(sources, s)
+			}
+		}
+	}
+
+	// load vector store (always needed)
+	mss, err := storesFor(sources...)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load vector stores: %v", err)), nil
+	}
+	if ctx.Err() != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("query cancelled: %v", ctx.Err())), nil
+	}
+
+	if len(mss.Sources) == 0 {
+		return mcp.NewToolResultError("no vector stores found. run 'lr index' to index repositories first"), nil
+	}
+
+	// if raw mode (no synthesis), we only need vector store
+	if !synthesize {
+		// get embedding for search (need minimal llm client just for embeddings)
+		var llm LLMClient
+		preloadMutex.RLock()
+		if preloadedLLM != nil {
+			llm = preloadedLLM
+		}
+		preloadMutex.RUnlock()
+
+		if llm == nil {
+			// temporarily redirect stdout to stderr to avoid polluting json-rpc
+			oldStdout := os.Stdout
+			os.Stdout = os.Stderr
+			llm, err = getLLMClient()
+			os.Stdout = oldStdout
+
+			if err != nil {
+				return mcp.NewToolResultError(fmt.Sprintf("failed to initialize LLM for embeddings: %v", err)), nil
+			}
+		}
+
+		defer flushUsageFor(llm, "mcp", strings.Join(sources, ","))
+
+		// search for relevant chunks, without routing
+		rag := NewRAGMultiSource(mss, mcpEmbeddings(llm))
+		rag.Route = false
+		rag.ExpandImports = expandImports
+		rag.Refs = refs
+		rag.ExcludePaths = exclude
+		results, _, err := rag.Retrieve(ctx, query, topK, sources)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("search failed: %v", dimensionAdvice(err))), nil
+		}
+		logRetrieval("mcp", query, results)
+
+		return mcp.NewToolResultText(formatRawResults(mss, sources, query, results)), nil
+	}
+
+	// synthesized mode - need llm for chat
+	var llm LLMClient
+	preloadMutex.RLock()
+	if preloadedLLM != nil {
+		llm = preloadedLLM
+	}
+	preloadMutex.RUnlock()
+
+	if llm == nil {
+		// temporarily redirect stdout to stderr to avoid polluting json-rpc
+		oldStdout := os.Stdout
+		os.Stdout = os.Stderr
+		llm, err = getLLMClient()
+		os.Stdout = oldStdout
+
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("failed to initialize LLM: %v", err)), nil
+		}
+	}
+
+	defer flushUsageFor(llm, "mcp", strings.Join(sources, ","))
+
+	// create rag and query
+	rag := NewRAGMultiSource(mss, mcpEmbeddings(llm))
+	rag.SelfCheck = selfCheck
+	rag.MinSimilarity = minSim
+	if deep {
+		rag.Deep = deepRounds
+	}
+	rag.ExpandImports = expandImports
+	rag.Refs = refs
+	rag.ExcludePaths = exclude
+	var failover FailoverResult
+	answer, results, err := rag.QueryWithSourcesContext(withFailoverResult(ctx, &failover), query, topK, sources)
+	logRetrieval("mcp", query, results)
+	if err != nil {
+		// synthesis timed out but retrieval finished - return the raw chunks rather than nothing
+		if errors.Is(err, context.DeadlineExceeded) && len(results) > 0 {
+			response := fmt.Sprintf("synthesis timed out after %s, returning raw chunks instead\n\n", mcpToolTimeout)
+			response += formatRawResults(mss, sources, query, results)
+			return mcp.NewToolResultText(response), nil
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("query failed: %v", dimensionAdvice(err))), nil
+	}
+
+	// format response
+	compact := compactOutput()
+	var response string
+	if !compact {
+		response = searchBanner(mss, sources)
+	}
+	if len(rag.FollowUps) > 0 {
+		response += fmt.Sprintf("also searched for: %s\n\n", strings.Join(rag.FollowUps, "; "))
+	}
+	if compact {
+		response += answer + "\n\n"
+	} else {
+		response += fmt.Sprintf("================================================================================\n")
+		response += fmt.Sprintf("question: %s\n", query)
+		response += fmt.Sprintf("================================================================================\n\n")
+		response += fmt.Sprintf("answer:\n%s\n\n", answer)
+	}
+	if rag.Confidence != nil {
+		response += fmt.Sprintf("confidence: %s\n\n", rag.Confidence)
+	}
+	if note := failoverNote(&failover); note != "" {
+		response += note + "\n\n"
+	}
+	if rag.Abstained {
+		// the reply already lists the nearest matches
+		return mcp.NewToolResultText(response), nil
+	}
+	response += fmt.Sprintf("sources:\n")
+	for i, result := range results {
+		if compact {
+			response += fmt.Sprintf("[%d] %s %.2f\n", i+1, resultLabel(mss, result), result.Similarity)
+			continue
+		}
+		response += fmt.Sprintf("  [%d] %s (similarity: %.3f%s)\n", i+1, result.Chunk.Source, result.Similarity, chunkIDNote(mss, result))
+	}
+	if problems := citationProblems(rag.Citations); len(problems) > 0 {
+		response += "\npossibly fabricated citations (verify before relying on them):\n"
+		for _, problem := range problems {
+			response += "  - " + problem + "\n"
+		}
+	}
+
+	return mcp.NewToolResultText(response), nil
+}
+
+// searchBanner names the sources a query searched
+func searchBanner(mss *MultiSourceStore, sources []string) string {
+	if len(sources) > 0 {
+		return fmt.Sprintf("searching %d of %d sources: %v\n\n", len(sources), len(mss.Sources), sources)
+	}
+	return fmt.Sprintf("searching all %d sources: %v\n\n", len(mss.Sources), mss.ListSources())
+}
+
+// resultLabel names a result by its chunk id, or its source if the chunk can't
+// be identified
+func resultLabel(mss *MultiSourceStore, result SearchResult) string {
+	if id := chunkID(mss, result); id != "" {
+		return id
+	}
+	return result.Chunk.Source
+}
+
+// structureNote names the config key paths, sql tables or components a
+// result's chunk holds, if any
+func structureNote(result SearchResult) string {
+	if structure := chunkStructure(result.Chunk); structure != "" {
+		return ", " + structure
+	}
+	return ""
+}
+
+// formatRawResults formats search results without synthesis
+func formatRawResults(mss *MultiSourceStore, sources []string, query string, results []SearchResult) string {
+	if compactOutput() {
+		// the agent knows what it asked: just the chunks, bodies cut short
+		var response string
+		for i, result := range results {
+			id := chunkID(mss, result)
+			label := id
+			if label == "" {
+				label = result.Chunk.Source
+			}
+			response += fmt.Sprintf("[%d] %s %.2f", i+1, label, result.Similarity)
+			if len(result.AlsoIn) > 0 {
+				response += " (also in: " + strings.Join(result.AlsoIn, ", ") + ")"
+			}
+			response += "\n" + compactChunkText(result.Chunk.Text, expandChunk(id)) + "\n\n"
+		}
+		if response == "" {
+			return "no results\n"
+		}
+		return response
+	}
+
+	response := searchBanner(mss, sources)
+	response += fmt.Sprintf("================================================================================\n")
+	response += fmt.Sprintf("query: %s\n", query)
+	response += fmt.Sprintf("================================================================================\n\n")
+	response += fmt.Sprintf("found %d relevant chunks:\n\n", len(results))
+
+	for i, result := range results {
+		source := result.Chunk.Source
+		if len(result.AlsoIn) > 0 {
+			source += ", also in: " + strings.Join(result.AlsoIn, ", ")
+		}
+		response += fmt.Sprintf("--- chunk %d (source: %s, similarity: %.3f%s%s) ---\n", i+1, source, result.Similarity, structureNote(result), chunkIDNote(mss, result))
+		response += result.Chunk.Text
+		response += "\n\n"
+	}
+	return response
+}
+
+func handleListIndexes(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// use preloaded stores if available
+	mss, err := currentStores()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load indexes: %v", err)), nil
+	}
+
+	// indexes evicted under the memory budget are listed without reading them
+	unloaded := unloadedSources(mss)
+	if len(mss.Sources) == 0 && len(unloaded) == 0 {
+		return mcp.NewToolResultText("no indexes found. run 'lr index' to index repositories first."), nil
+	}
+
+	if compactOutput() {
+		var response string
+		for name, vs := range mss.Sources {
+			response += fmt.Sprintf("%s: %d chunks", name, len(vs.Chunks))
+			if vs.Metadata.FileCount > 0 {
+				response += fmt.Sprintf(", %d files", vs.Metadata.FileCount)
+			}
+			if vs.Metadata.IndexedAt != "" {
+				response += ", indexed " + vs.Metadata.IndexedAt
+			}
+			if changes, ok := sourceChanges(vs); ok && changes.Stale() {
+				response += ", stale: " + changes.String()
+			}
+			response += "\n"
+		}
+		for _, name := range unloaded {
+			response += name + ": not loaded (memory budget), loaded when queried\n"
+		}
+		return mcp.NewToolResultText(response), nil
+	}
+
+	response := fmt.Sprintf("found %d indexed repositories:\n\n", len(mss.Sources)+len(unloaded))
+
+	for name, vs := range mss.Sources {
+		response += fmt.Sprintf("• %s\n", name)
+		response += fmt.Sprintf("  chunks: %d\n", len(vs.Chunks))
+		if vs.Metadata.FileCount > 0 {
+			response += fmt.Sprintf("  files: %d\n", vs.Metadata.FileCount)
+		}
+		if vs.Metadata.SourcePath != "" {
+			response += fmt.Sprintf("  source: %s\n", vs.Metadata.SourcePath)
+		}
+		if vs.Metadata.IndexedAt != "" {
+			response += fmt.Sprintf("  indexed: %s\n", vs.Metadata.IndexedAt)
+		}
+		if changes, ok := sourceChanges(vs); ok && changes.Stale() {
+			response += fmt.Sprintf("  stale: %s - answers may miss recent changes; ask the user to run 'lr index --update --out-name %s'\n", changes, name)
+		}
+		response += "\n"
+	}
+	for _, name := range unloaded {
+		response += fmt.Sprintf("• %s\n  not loaded, to stay within the memory budget; loaded when queried\n\n", name)
+	}
+
+	return mcp.NewToolResultText(response), nil
+}
+
+func handleGetIndexStats(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// get arguments
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok {
+		return mcp.NewToolResultError("invalid arguments"), nil
+	}
+
+	name, ok := args["name"].(string)
+	if !ok || name == "" {
+		return mcp.NewToolResultError("name parameter is required"), nil
+	}
+
+	// use preloaded stores if available
+	mss, err := currentStores()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load indexes: %v", err)), nil
+	}
+
+	// an index evicted under the memory budget is loaded again
+	if mss.Sources[name] == nil {
+		for _, n := range unloadedSources(mss) {
+			if n == name || strings.Contains(strings.ToLower(n), strings.ToLower(name)) {
+				if mss, err = storesFor(n); err != nil {
+					return mcp.NewToolResultError(fmt.Sprintf("failed to load indexes: %v", err)), nil
+				}
+				break
+			}
+		}
+	}
+
+	// find the index (try exact match first, then partial)
+	var vs *VectorStore
+	var foundName string
+	for n, store := range mss.Sources {
+		if n == name {
+			vs = store
+			foundName = n
+			break
+		}
+	}
+	if vs == nil {
+		// try partial match
+		for n, store := range mss.Sources {
+			if strings.Contains(strings.ToLower(n), strings.ToLower(name)) {
+				vs = store
+				foundName = n
+				break
+			}
+		}
+	}
+
+	if vs == nil {
+		available := make([]string, 0, len(mss.Sources))
+		for n := range mss.Sources {
+			available = append(available, n)
+		}
+		return mcp.NewToolResultError(fmt.Sprintf("index '%s' not found. available: %v", name, available)), nil
+	}
+
+	response := fmt.Sprintf("index: %s\n\n", foundName)
+	response += fmt.Sprintf("chunks: %d\n", len(vs.Chunks))
+	response += fmt.Sprintf("files: %d\n", vs.Metadata.FileCount)
+	if vs.Metadata.SourcePath != "" {
+		response += fmt.Sprintf("source path: %s\n", vs.Metadata.SourcePath)
+	}
+	if vs.Metadata.IndexedAt != "" {
+		response += fmt.Sprintf("indexed at: %s\n", vs.Metadata.IndexedAt)
+	}
+	if vs.Metadata.LastCommit != "" {
+		response += fmt.Sprintf("git commit: %s\n", vs.Metadata.LastCommit)
+	}
+	if vs.Metadata.Branch != "" {
+		response += fmt.Sprintf("git branch: %s\n", vs.Metadata.Branch)
+	}
+
+	// list indexed files
+	if len(vs.Metadata.IndexedFiles) > 0 {
+		response += fmt.Sprintf("\nindexed files (%d):\n", len(vs.Metadata.IndexedFiles))
+		for _, f := range vs.Metadata.IndexedFiles {
+			response += fmt.Sprintf("  • %s\n", f)
+		}
+	}
+
+	// list skipped files if any
+	if len(vs.Metadata.SkippedFiles) > 0 {
+		response += fmt.Sprintf("\nskipped files (%d):\n", len(vs.Metadata.SkippedFiles))
+		for _, sf := range vs.Metadata.SkippedFiles {
+			response += fmt.Sprintf("  • %s (%s)\n", sf.Path, sf.Reason)
+		}
+	}
+
+	return mcp.NewToolResultText(response), nil
+}
+
+func handleSearchByFile(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// get arguments
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok {
+		return mcp.NewToolResultError("invalid arguments"), nil
+	}
+
+	path, ok := args["path"].(string)
+	if !ok || path == "" {
+		return mcp.NewToolResultError("path parameter is required"), nil
+	}
+
+	// use preloaded stores if available
+	mss, err := storesFor()
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load indexes: %v", err)), nil
+	}
+
+	// search all indexes for chunks matching the file path
+	var matches []struct {
+		source string
+		chunk  Chunk
+	}
+
+	pathLower := strings.ToLower(path)
+	for _, vs := range mss.Sources {
+		for _, chunk := range vs.Chunks {
+			if strings.Contains(strings.ToLower(chunk.Source), pathLower) {
+				matches = append(matches, struct {
+					source string
+					chunk  Chunk
+				}{source: chunk.Source, chunk: chunk})
+			}
+		}
+	}
+
+	if len(matches) == 0 {
+		return mcp.NewToolResultText(fmt.Sprintf("no chunks found matching path '%s'", path)), nil
+	}
+
+	// group by source file
+	byFile := make(map[string][]Chunk)
+	for _, m := range matches {
+		byFile[m.source] = append(byFile[m.source], m.chunk)
+	}
+
+	if compactOutput() {
+		var response string
+		for file, chunks := range byFile {
+			expand := fmt.Sprintf("get_file path=%q", stripPart(file))
+			for i, chunk := range chunks {
+				response += fmt.Sprintf("[%s %d/%d]\n%s\n\n", file, i+1, len(chunks), compactChunkText(chunk.Text, expand))
+			}
+		}
+		return mcp.NewToolResultText(response), nil
+	}
+
+	response := fmt.Sprintf("found %d chunks from %d files matching '%s':\n\n", len(matches), len(byFile), path)
+
+	for file, chunks := range byFile {
+		response += fmt.Sprintf("=== %s (%d chunks) ===\n\n", file, len(chunks))
+		for i, chunk := range chunks {
+			response += fmt.Sprintf("--- chunk %d ---\n", i+1)
+			response += chunk.Text
+			response += "\n\n"
+		}
+	}
+
+	return mcp.NewToolResultText(response), nil
+}
+
+func handleGetDiffContext(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	// get arguments
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	topK := 3
+	uncommittedOnly := false
+	if ok {
+		if tk, ok := args["top_k"].(float64); ok {
+			topK = int(tk)
+		}
+		if uo, ok := args["uncommitted_only"].(bool); ok {
+			uncommittedOnly = uo
+		}
+	}
+
+	// load review session
+	session, err := loadReviewSession()
+	if err != nil {
+		return mcp.NewToolResultError("no active review session. run 'lr review start' first"), nil
+	}
+
+	fullDiff, emptyMsg, err := reviewDiff(ctx, session.ProjectPath, uncommittedOnly)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+	if emptyMsg != "" {
+		return mcp.NewToolResultText(emptyMsg), nil
+	}
+
+	// extract changed file paths from diff
+	changedFiles := extractChangedFiles(fullDiff)
+	if len(changedFiles) == 0 {
+		return mcp.NewToolResultText("git diff:\n\n" + fullDiff), nil
+	}
+
+	// load review index
+	store, err := loadReviewIndex(session)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load review index: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(diffWithContext(ctx, fullDiff, store, changedFiles, topK)), nil
+}
+
+// reviewDiff returns the diff to review: uncommitted and staged changes, or by default the
+// current branch vs main/master. new files git doesn't track yet are added as diffs
+// from an empty file (untrackedDiff). emptyMsg is set instead when there are no changes
+func reviewDiff(ctx context.Context, projectPath string, uncommittedOnly bool) (diff, emptyMsg string, err error) {
+	untracked := untrackedDiff(ctx, projectPath)
+
+	if uncommittedOnly {
+		// get only uncommitted/staged changes
+		cmd := exec.CommandContext(ctx, "git", "-C", projectPath, "diff", "--no-ext-diff")
+		diffOutput, err := cmd.Output()
+		if err != nil {
+			return "", "", fmt.Errorf("failed to get git diff: %v", err)
+		}
+
+		// also get staged changes
+		cmdStaged := exec.CommandContext(ctx, "git", "-C", projectPath, "diff", "--cached", "--no-ext-diff")
+		stagedOutput, _ := cmdStaged.Output()
+
+		diff = string(diffOutput)
+		if len(stagedOutput) > 0 {
+			diff += "\n=== STAGED CHANGES ===\n" + string(stagedOutput)
+		}
+		if untracked != "" {
+			diff += "\n=== UNTRACKED FILES ===\n" + untracked
+		}
+
+		if diff == "" {
+			return "", "no uncommitted changes found", nil
+		}
+		return diff, "", nil
+	}
+
+	// default: get diff of current branch vs main/master
+	baseBranch := detectBaseBranch(ctx, projectPath)
+	diffSpec := baseBranch + "...HEAD"
+	cmd := exec.CommandContext(ctx, "git", "-C", projectPath, "diff", "--no-ext-diff", diffSpec)
+	diffOutput, err := cmd.Output()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to get branch diff (%s): %v", diffSpec, err)
+	}
+	if len(diffOutput) == 0 && untracked == "" {
+		return "", fmt.Sprintf("no changes on current branch vs %s", baseBranch), nil
+	}
+
+	diff = fmt.Sprintf("=== BRANCH DIFF (%s) ===\n\n%s", diffSpec, diffOutput)
+	if untracked != "" {
+		diff += "\n=== UNTRACKED FILES ===\n" + untracked
+	}
+	return diff, "", nil
+}
+
+// maxUntrackedDiffSize is the largest untracked file whose content is included in a
+// review diff, the same limit review sessions index files with
+const maxUntrackedDiffSize = 100 * 1024
+
+// untrackedDiff returns the files in projectPath git doesn't track (and doesn't
+// ignore) as diffs adding them, so brand-new code is reviewed with its content.
+// only files review sessions index (reviewExtensions, up to maxUntrackedDiffSize)
+// are included; paths are relative to projectPath, like the review index sources
+func untrackedDiff(ctx context.Context, projectPath string) string {
+	out, err := exec.CommandContext(ctx, "git", "-C", projectPath, "ls-files", "--others", "--exclude-standard", "-z").Output()
+	if err != nil {
+		return ""
+	}
+	var files []string
+	for _, name := range strings.Split(string(out), "\x00") {
+		if name != "" && hasReviewExtension(name) && !ShouldExcludeFile(name) {
+			files = append(files, name)
+		}
+	}
+	sort.Strings(files)
+
+	var diff strings.Builder
+	for _, name := range files {
+		info, err := os.Stat(filepath.Join(projectPath, name))
+		if err != nil || !info.Mode().IsRegular() || info.Size() > maxUntrackedDiffSize {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(projectPath, name))
+		if err != nil || bytes.IndexByte(content, 0) >= 0 {
+			continue // unreadable or binary
+		}
+		lines := strings.Split(strings.TrimSuffix(string(content), "\n"), "\n")
+		if len(content) == 0 {
+			lines = nil
+		}
+		fmt.Fprintf(&diff, "diff --git a/%s b/%s\nnew file mode 100644\n--- /dev/null\n+++ b/%s\n", name, name, name)
+		if len(lines) > 0 {
+			fmt.Fprintf(&diff, "@@ -0,0 +1,%d @@\n", len(lines))
+			for _, line := range lines {
+				diff.WriteString("+" + line + "\n")
+			}
+		}
+	}
+	return diff.String()
+}
+
+// diffWithContext combines a diff with the indexed code it changes (up to topK
+// chunks per changed file: the functions and sections its hunks touch, or the
+// file's first chunks when they can't be mapped), the code referencing the
+// changed symbols, and the code elsewhere in the index most related to its hunks
+func diffWithContext(ctx context.Context, diff string, store *VectorStore, changedFiles []string, topK int) string {
+	response := "=== GIT DIFF ===\n\n" + diff + "\n\n"
+	response += "=== RELEVANT CONTEXT ===\n\n"
+
+	// for each changed file, the chunks its hunks change
+	hunks := diffHunks(diff)
+	shown := make(map[string]bool)
+	var changed []symbolChunk
+	for _, file := range changedFiles {
+		symbols := changedSymbols(store, file, hunks)
+		if len(symbols) == 0 {
+			response += fileContext(store, file, topK)
+			for i, chunk := range fileChunks(store, file) {
+				if i < topK {
+					shown[chunkKey(chunk)] = true
+				}
+			}
+			continue
+		}
+		response += formatSymbolContext(file, symbols, topK)
+		for _, s := range symbols {
+			shown[chunkKey(s.chunk)] = true
+		}
+		changed = append(changed, symbols...)
+	}
+
+	// callers and other users of what changed
+	response += referencesContext(store, changed, shown, topK)
+
+	// interfaces, docs and other code related to the change
+	response += relatedContext(ctx, store, hunks, shown, topK)
+
+	// documented standards (CONVENTIONS.md, CONTRIBUTING.md, style guides)
+	response += conventionsContext(store, diff, changedFiles, topK)
+
+	return response
+}
+
+// fileContext returns up to topK indexed chunks of file, or "" if it isn't indexed
+func fileContext(store *VectorStore, file string, topK int) string {
+	return formatFileContext(file, fileChunks(store, file), topK)
+}
+
+// fileChunks returns the indexed chunks of file, in index order
+func fileChunks(store *VectorStore, file string) []Chunk {
+	var chunks []Chunk
+	for _, chunk := range store.Chunks {
+		if strings.Contains(chunk.Source, file) {
+			chunks = append(chunks, chunk)
+		}
+	}
+	return chunks
+}
+
+// formatFileContext formats up to topK chunks of file for a review prompt
+func formatFileContext(file string, chunks []Chunk, topK int) string {
+	if len(chunks) == 0 {
+		return ""
+	}
+	response := fmt.Sprintf("--- context from %s ---\n", file)
+	for i, chunk := range chunks {
+		if i >= topK {
+			break
+		}
+		response += chunk.Text + "\n\n"
+	}
+	return response
+}
+
+// detectBaseBranch detects whether the repo uses main or master as the base branch
+func detectBaseBranch(ctx context.Context, projectPath string) string {
+	// check if 'main' branch exists
+	cmd := exec.CommandContext(ctx, "git", "-C", projectPath, "rev-parse", "--verify", "main")
+	if err := cmd.Run(); err == nil {
+		return "main"
+	}
+
+	// check if 'master' branch exists
+	cmd = exec.CommandContext(ctx, "git", "-C", projectPath, "rev-parse", "--verify", "master")
+	if err := cmd.Run(); err == nil {
+		return "master"
+	}
+
+	// default to main
+	return "main"
+}
+
+// extractChangedFiles parses a git diff and returns the list of changed file paths
+func extractChangedFiles(diff string) []string {
+	files := make(map[string]bool)
+	lines := strings.Split(diff, "\n")
+	for _, line := range lines {
+		if strings.HasPrefix(line, "+++ b/") {
+			file := strings.TrimPrefix(line, "+++ b/")
+			files[file] = true
+		} else if strings.HasPrefix(line, "--- a/") {
+			file := strings.TrimPrefix(line, "--- a/")
+			if file != "/dev/null" {
+				files[file] = true
+			}
+		}
+	}
+
+	result := make([]string, 0, len(files))
+	for f := range files {
+		result = append(result, f)
+	}
+	sort.Strings(result)
+	return result
+}
+
+// reloadAllProcesses finds all lr processes and sends SIGUSR1 to them
+func reloadAllProcesses() error {
+	myPid := os.Getpid()
+
+	// use pgrep to find lr processes
+	cmd := exec.Command("pgrep", "-f", "lr mcp")
+	output, err := cmd.Output()
+	if err != nil {
+		// pgrep returns exit code 1 if no processes found
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 {
+			fmt.Println("no lr mcp processes found")
+			return nil
+		}
+		return fmt.Errorf("failed to find lr processes: %w", err)
+	}
+
+	var signaled int
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		pidStr := strings.TrimSpace(scanner.Text())
+		if pidStr == "" {
+			continue
+		}
+
+		pid, err := strconv.Atoi(pidStr)
+		if err != nil {
+			continue
+		}
+
+		// skip our own process
+		if pid == myPid {
+			continue
+		}
+
+		process, err := os.FindProcess(pid)
+		if err != nil {
+			fmt.Printf("warning: could not find process %d: %v\n", pid, err)
+			continue
+		}
+
+		if err := process.Signal(syscall.SIGUSR1); err != nil {
+			fmt.Printf("warning: could not signal process %d: %v\n", pid, err)
+			continue
+		}
+
+		fmt.Printf("sent reload signal to pid %d\n", pid)
+		signaled++
+	}
+
+	if signaled == 0 {
+		fmt.Println("no lr mcp processes found to reload")
+	} else {
+		fmt.Printf("reloaded %d process(es)\n", signaled)
+	}
+
+	return nil
+}
+
+func serveMCP() error {
+	// handle --reload flag
+	if reloadPid > 0 {
+		// send SIGUSR1 to the specified pid
+		process, err := os.FindProcess(reloadPid)
+		if err != nil {
+			return fmt.Errorf("failed to find process %d: %w", reloadPid, err)
+		}
+
+		if err := process.Signal(syscall.SIGUSR1); err != nil {
+			return fmt.Errorf("failed to send reload signal to pid %d: %w", reloadPid, err)
+		}
+
+		fmt.Printf("sent reload signal to pid %d\n", reloadPid)
+		return nil
+	}
+
+	// handle --reload-all flag
+	if reloadAll {
+		return reloadAllProcesses()
+	}
+
+	serverStartedAt = time.Now()
+	onDemandCache.TTL = mcpCacheTTL
+	if mcpMemory != "" {
+		budget, err := parseByteSize(mcpMemory)
+		if err != nil {
+			return fmt.Errorf("invalid --memory-budget: %w", err)
+		}
+		if noPreload {
+			return fmt.Errorf("--memory-budget limits preloaded indexes; with --no-preload, --cache-ttl bounds how long they stay loaded")
+		}
+		memoryBudget = budget
+	}
+
+	// silence the standard logger that libraries write to; the server's own
+	// messages go through mcpLog, which goroutines share safely
+	log.SetOutput(io.Discard)
+
+	// preload resources unless --no-preload flag is set
+	if !noPreload {
+		// preload llm client
+		llm, err := getLLMClient()
+		if err != nil {
+			return fmt.Errorf("failed to preload LLM client: %w", err)
+		}
+		preloadMutex.Lock()
+		preloadedLLM = llm
+		preloadMutex.Unlock()
+
+		// preload vector stores in the background so the client can connect
+		// right away; tools load what they need on demand until it finishes
+		go func() {
+			if err := reloadVectorStores(); err != nil {
+				mcpLog.Printf("error preloading: %v", err)
+			}
+		}()
+	}
+
+	// embed the --warmup questions in the background, so the first tool call
+	// finds the provider connected
+	questions, err := warmupQuestions()
+	if err != nil {
+		return fmt.Errorf("invalid --warmup: %w", err)
+	}
+	if len(questions) > 0 {
+		client := preloadedLLM
+		if client == nil {
+			// temporarily redirect stdout to stderr to avoid polluting json-rpc
+			oldStdout := os.Stdout
+			os.Stdout = os.Stderr
+			client, err = getLLMClient()
+			os.Stdout = oldStdout
+			if err != nil {
+				return fmt.Errorf("failed to create the LLM client for --warmup: %w", err)
+			}
+		}
+		warmupState.running = true
+		go warmUp(client, questions)
+	}
+
+	// setup signal handler for reload
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGUSR1)
+
+	go func() {
+		for range sigChan {
+			mcpLog.Println("received reload signal, reloading vector stores...")
+
+			if err := reloadVectorStores(); err != nil {
+				mcpLog.Printf("error reloading: %v", err)
+			}
+		}
+	}()
+
+	// print pid so user knows how to reload
+	mcpLog.Printf("mcp server started (pid: %d)", os.Getpid())
+	mcpLog.Printf("to reload indexes: lr mcp --reload %d", os.Getpid())
+
+	mcpServer := createMCPServer()
+
+	// lr query --use-mcp connects to the control socket
+	if stop, err := serveMCPControl(mcpServer); err != nil {
+		mcpLog.Printf("warning: lr query --use-mcp can't reach this server: %v", err)
+	} else {
+		defer stop()
+	}
+
+	if err := server.ServeStdio(mcpServer); err != nil {
+		return fmt.Errorf("mcp server error: %w", err)
+	}
+
+	return nil
+}
@@ -2,14 +2,18 @@ package main
 
 import (
 	"bufio"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
 )
 
-// mcpclient handles communication with a running MCP server
+// mcpclient handles communication with an MCP server
 
 type mcpRequest struct {
 	JSONRPC string      `json:"jsonrpc"`
@@ -42,8 +46,52 @@ type toolCallResult struct {
 	} `json:"content"`
 }
 
-// queryViaMCP sends a query to the running MCP server
-func queryViaMCP(query string, topK int, synthesize bool) (string, error) {
+// mcpQueryArgs returns the query_repositories arguments for question with lr
+// query's flags
+func mcpQueryArgs(question string) map[string]any {
+	args := map[string]any{
+		"query":      question,
+		"top_k":      float64(topK),
+		"synthesize": !noSynthesize,
+	}
+	if len(querySources) > 0 {
+		args["sources"] = strings.Join(querySources, ",")
+	}
+	if minSimilarity > 0 {
+		args["min_similarity"] = minSimilarity
+	}
+	if deepQuery {
+		args["deep"] = true
+	}
+	if expandImport > 0 {
+		args["expand_imports"] = float64(expandImport)
+	}
+	if queryRefs != "" {
+		args["refs"] = queryRefs
+	}
+	if len(excludePaths) > 0 {
+		args["exclude_paths"] = strings.Join(excludePaths, ",")
+	}
+	if selfCheck {
+		args["self_check"] = true
+	}
+	return args
+}
+
+// queryViaMCP calls query_repositories with args on a running MCP server,
+// starting one for the query when none runs
+func queryViaMCP(args map[string]any) (string, error) {
+	if sockets := runningMCPSockets(); len(sockets) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), remoteClientTimeout)
+		defer cancel()
+		return callMCPControl(ctx, sockets[0], "query_repositories", args)
+	}
+	return queryViaSpawnedMCP(args)
+}
+
+// queryViaSpawnedMCP starts an mcp server without preloading and sends it the
+// query over stdio
+func queryViaSpawnedMCP(args map[string]any) (string, error) {
 	// find the lr binary path
 	lrPath, err := os.Executable()
 	if err != nil {
@@ -57,8 +105,18 @@ func queryViaMCP(query string, topK int, synthesize bool) (string, error) {
 		lrPath, _ = os.Executable()
 	}
 
-	// start the mcp server as a subprocess
-	cmd := exec.Command(lrPath, "mcp", "--no-preload")
+	// start the mcp server as a subprocess, with this process's models
+	argv := []string{"mcp", "--no-preload"}
+	if mockLLM {
+		argv = append(argv, "--mock-llm")
+	}
+	if embeddingModel != "" {
+		argv = append(argv, "--embedding-model", embeddingModel)
+	}
+	if chatModel != "" {
+		argv = append(argv, "--model", chatModel)
+	}
+	cmd := exec.Command(lrPath, argv...)
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
 		return "", fmt.Errorf("failed to create stdin pipe: %w", err)
@@ -74,7 +132,11 @@ func queryViaMCP(query string, topK int, synthesize bool) (string, error) {
 	if err := cmd.Start(); err != nil {
 		return "", fmt.Errorf("failed to start MCP server: %w", err)
 	}
-	defer cmd.Process.Kill()
+	defer func() {
+		cmd.Process.Kill()
+		// killed, it can't remove its control socket itself
+		os.Remove(filepath.Join(mcpControlDir(), strconv.Itoa(cmd.Process.Pid)+".sock"))
+	}()
 
 	// send initialize request
 	initReq := mcpRequest{
@@ -125,12 +187,8 @@ func queryViaMCP(query string, topK int, synthesize bool) (string, error) {
 		ID:      2,
 		Method:  "tools/call",
 		Params: toolCallParams{
-			Name: "query_repositories",
-			Arguments: map[string]interface{}{
-				"query":      query,
-				"top_k":      float64(topK),
-				"synthesize": synthesize,
-			},
+			Name:      "query_repositories",
+			Arguments: args,
 		},
 	}
 
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// besides stdio, every lr mcp server serves its tools on a control socket with
+// mcp's streamable http transport, so lr query --use-mcp asks a server that
+// already has the indexes loaded instead of starting one per query. sockets are
+// named after the server's pid, so ones left by a crashed server are noticed
+
+const mcpControlPath = "/mcp"
+
+// mcpControlDir returns the directory holding the control sockets
+func mcpControlDir() string {
+	if runtime := os.Getenv("XDG_RUNTIME_DIR"); runtime != "" {
+		return filepath.Join(runtime, "lr", "mcp")
+	}
+	return filepath.Join(getCacheDir(), "mcp")
+}
+
+// serveMCPControl serves s on this process's control socket until the
+// returned stop is called
+func serveMCPControl(s *server.MCPServer) (stop func(), err error) {
+	dir := mcpControlDir()
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create control socket directory: %w", err)
+	}
+	socket := filepath.Join(dir, strconv.Itoa(os.Getpid())+".sock")
+	os.Remove(socket)
+	lis, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", socket, err)
+	}
+	if err := os.Chmod(socket, 0600); err != nil {
+		lis.Close()
+		os.Remove(socket)
+		return nil, err
+	}
+
+	// stdout belongs to the stdio transport, so the http transport logs to stderr
+	logger := &mcpControlLogger{log.New(os.Stderr, "mcp control: ", log.LstdFlags)}
+	mux := http.NewServeMux()
+	mux.Handle(mcpControlPath, server.NewStreamableHTTPServer(s, server.WithStateLess(true), server.WithLogger(logger)))
+	srv := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go srv.Serve(lis)
+	return func() {
+		srv.Close()
+		os.Remove(socket)
+	}, nil
+}
+
+// mcpControlLogger adapts a log.Logger to the mcp transport's logger
+type mcpControlLogger struct{ l *log.Logger }
+
+func (m *mcpControlLogger) Infof(format string, v ...any)  {}
+func (m *mcpControlLogger) Errorf(format string, v ...any) { m.l.Printf(format, v...) }
+
+// runningMCPSockets returns the control sockets of running lr mcp servers, the
+// most recently started first. sockets of servers that are gone are removed
+func runningMCPSockets() []string {
+	entries, err := os.ReadDir(mcpControlDir())
+	if err != nil {
+		return nil
+	}
+	type candidate struct {
+		path    string
+		started time.Time
+	}
+	var live []candidate
+	for _, e := range entries {
+		pid, err := strconv.Atoi(strings.TrimSuffix(e.Name(), ".sock"))
+		if err != nil || !strings.HasSuffix(e.Name(), ".sock") {
+			continue
+		}
+		path := filepath.Join(mcpControlDir(), e.Name())
+		if err := syscall.Kill(pid, 0); errors.Is(err, syscall.ESRCH) {
+			os.Remove(path)
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		live = append(live, candidate{path, info.ModTime()})
+	}
+	sort.Slice(live, func(i, j int) bool { return live[i].started.After(live[j].started) })
+	sockets := make([]string, len(live))
+	for i, c := range live {
+		sockets[i] = c.path
+	}
+	return sockets
+}
+
+// callMCPControl calls tool with args on the server at socket and returns the
+// text of its result
+func callMCPControl(ctx context.Context, socket, tool string, args map[string]any) (string, error) {
+	httpClient := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		},
+	}}
+	c, err := client.NewStreamableHttpClient("http://lr"+mcpControlPath, transport.WithHTTPBasicClient(httpClient))
+	if err != nil {
+		return "", err
+	}
+	defer c.Close()
+	if err := c.Start(ctx); err != nil {
+		return "", fmt.Errorf("failed to connect to lr mcp: %w", err)
+	}
+
+	init := mcp.InitializeRequest{}
+	init.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	init.Params.ClientInfo = mcp.Implementation{Name: "lr-cli", Version: "1.0.0"}
+	if _, err := c.Initialize(ctx, init); err != nil {
+		return "", fmt.Errorf("failed to initialize lr mcp session: %w", err)
+	}
+
+	req := mcp.CallToolRequest{}
+	req.Params.Name = tool
+	req.Params.Arguments = args
+	result, err := c.CallTool(ctx, req)
+	if err != nil {
+		return "", fmt.Errorf("tool call error: %w", err)
+	}
+	var text strings.Builder
+	for _, content := range result.Content {
+		if t, ok := content.(mcp.TextContent); ok {
+			text.WriteString(t.Text)
+		}
+	}
+	if result.IsError {
+		return "", errors.New(text.String())
+	}
+	if text.Len() == 0 {
+		return "", fmt.Errorf("unexpected result format")
+	}
+	return text.String(), nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+func TestMCPControlSocket(t *testing.T) {
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+
+	s := server.NewMCPServer("test", "1.0.0")
+	s.AddTool(mcp.NewTool("echo", mcp.WithString("text")), func(_ context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("echo: " + req.GetString("text", "")), nil
+	})
+	stop, err := serveMCPControl(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a socket left by a server that's gone is skipped and removed
+	stale := filepath.Join(mcpControlDir(), "999999999.sock")
+	if err := os.WriteFile(stale, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	sockets := runningMCPSockets()
+	if len(sockets) != 1 {
+		t.Fatalf("expected this process's socket, got %v", sockets)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Error("stale socket wasn't removed")
+	}
+
+	text, err := callMCPControl(context.Background(), sockets[0], "echo", map[string]any{"text": "hi"})
+	if err != nil || text != "echo: hi" {
+		t.Fatalf("got %q, %v", text, err)
+	}
+
+	stop()
+	if sockets := runningMCPSockets(); len(sockets) != 0 {
+		t.Errorf("socket left after stop: %v", sockets)
+	}
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+)
+
+// with --memory-budget, lr mcp keeps its preloaded indexes under a memory
+// budget: the preload stops reading indexes once the budget is spent, and
+// when a request needs one that isn't loaded, the least recently queried
+// indexes are evicted to make room for it. the published stores are replaced
+// rather than changed, so requests already using them aren't disturbed
+
+// memoryBudget is --memory-budget in bytes, 0 for no budget
+var memoryBudget int64
+
+// sourceUsage is how a preloaded source uses memory, for eviction and
+// server_status. guarded by preloadMutex
+type sourceUsage struct {
+	lastUsed  time.Time // last requested, zero if never
+	bytes     int64     // estimated memory when loaded (see VectorStore.MemoryBytes)
+	loaded    bool
+	evictions int
+	reloads   int
+}
+
+var sourceUse = make(map[string]*sourceUsage)
+
+// usageOf returns the usage record of source name, creating it. preloadMutex must be held
+func usageOf(name string) *sourceUsage {
+	u, ok := sourceUse[name]
+	if !ok {
+		u = &sourceUsage{}
+		sourceUse[name] = u
+	}
+	return u
+}
+
+// byRecentUse sorts names most recently used first (never used last, by name)
+func byRecentUse(names []string) {
+	sort.SliceStable(names, func(i, j int) bool {
+		a, b := usageOf(names[i]).lastUsed, usageOf(names[j]).lastUsed
+		if !a.Equal(b) {
+			return a.After(b)
+		}
+		return names[i] < names[j]
+	})
+}
+
+// recordUsage starts the usage records of a preload of names into mss,
+// keeping when the sources were last used. preloadMutex must be held
+func recordUsage(mss *MultiSourceStore, names []string) {
+	for name := range sourceUse {
+		if !slices.Contains(names, name) {
+			delete(sourceUse, name)
+		}
+	}
+	for _, name := range names {
+		u := usageOf(name)
+		u.loaded = mss.Sources[name] != nil
+		if u.loaded {
+			u.bytes = mss.Sources[name].MemoryBytes()
+		}
+	}
+}
+
+// loadWithinBudget loads the named sources into mss, the most recently used
+// first, until the memory budget is spent; the rest are left to be loaded on
+// demand. ready is called for each source, loaded or not
+func loadWithinBudget(mss *MultiSourceStore, names []string, ready func(name string, err error)) error {
+	preloadMutex.Lock()
+	names = slices.Clone(names)
+	byRecentUse(names)
+	for _, name := range names {
+		usageOf(name).loaded = false
+	}
+	preloadMutex.Unlock()
+
+	var errs []error
+	var total int64
+	for _, name := range names {
+		if total >= memoryBudget {
+			ready(name, nil)
+			continue
+		}
+		err := mss.LoadSource(name)
+		if err != nil {
+			errs = append(errs, err)
+			ready(name, err)
+			continue
+		}
+		bytes := mss.Sources[name].MemoryBytes()
+		fits := total == 0 || total+bytes <= memoryBudget
+		if fits {
+			total += bytes
+		} else {
+			delete(mss.Sources, name)
+		}
+		preloadMutex.Lock()
+		u := usageOf(name)
+		u.bytes, u.loaded = bytes, fits
+		preloadMutex.Unlock()
+		ready(name, nil)
+	}
+	if err := mss.LoadSourceInfo(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// storesFor returns the stores to serve a request for the named sources (all
+// when none are named). under a memory budget the sources the request needs
+// are loaded if they were evicted, evicting others least recently used first;
+// without one it is currentStores
+func storesFor(names ...string) (*MultiSourceStore, error) {
+	preloadMutex.RLock()
+	mss := preloadedMSS
+	preloadMutex.RUnlock()
+	if mss == nil {
+		return currentStores()
+	}
+
+	missing := func(mss *MultiSourceStore) []string {
+		preloadMutex.Lock()
+		defer preloadMutex.Unlock()
+		wanted := names
+		if len(wanted) == 0 {
+			for name := range sourceUse {
+				wanted = append(wanted, name)
+			}
+		}
+		var missing []string
+		now := time.Now()
+		for _, name := range wanted {
+			u, ok := sourceUse[name]
+			if !ok {
+				continue // not an index the preload knows
+			}
+			u.lastUsed = now
+			if mss.Sources[name] == nil {
+				missing = append(missing, name)
+			}
+		}
+		return missing
+	}
+	if len(missing(mss)) == 0 || memoryBudget <= 0 {
+		return mss, nil
+	}
+
+	// reloads are serialized with preloads, so the stores published meanwhile
+	// are the ones extended
+	preloadRun.Lock()
+	defer preloadRun.Unlock()
+	preloadMutex.RLock()
+	mss = preloadedMSS
+	preloadMutex.RUnlock()
+	load := missing(mss)
+	if len(load) == 0 {
+		return mss, nil
+	}
+
+	next := mss.WithSources(func(string) bool { return true })
+	next.Cache = nil
+	for _, name := range load {
+		if err := next.LoadSource(name); err != nil {
+			return nil, err
+		}
+	}
+
+	preloadMutex.Lock()
+	for _, name := range load {
+		u := usageOf(name)
+		u.bytes, u.loaded = next.Sources[name].MemoryBytes(), true
+		u.reloads++
+	}
+	evicted := evictOverBudget(next, load)
+	preloadedMSS = next
+	preloadMutex.Unlock()
+
+	mcpLog.Printf("loaded %v on demand (memory budget %s)", load, formatBytes(memoryBudget))
+	if len(evicted) > 0 {
+		mcpLog.Printf("evicted %v, the least recently queried", evicted)
+	}
+	return next, nil
+}
+
+// unloadedSources returns the preloaded sources missing from mss because the
+// memory budget evicted them or left them to be loaded on demand, sorted
+func unloadedSources(mss *MultiSourceStore) []string {
+	preloadMutex.RLock()
+	defer preloadMutex.RUnlock()
+	var names []string
+	for name := range sourceUse {
+		if mss.Sources[name] == nil {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// evictOverBudget removes the least recently used sources of mss, other than
+// keep, until the loaded ones fit the memory budget, returning their names.
+// preloadMutex must be held
+func evictOverBudget(mss *MultiSourceStore, keep []string) []string {
+	var total int64
+	var candidates []string
+	for name := range mss.Sources {
+		total += usageOf(name).bytes
+		if !slices.Contains(keep, name) {
+			candidates = append(candidates, name)
+		}
+	}
+	byRecentUse(candidates)
+
+	var evicted []string
+	for i := len(candidates) - 1; i >= 0 && total > memoryBudget; i-- {
+		name := candidates[i]
+		u := usageOf(name)
+		total -= u.bytes
+		u.loaded = false
+		u.evictions++
+		delete(mss.Sources, name)
+		evicted = append(evicted, name)
+	}
+	return evicted
+}
+
+// formatMemoryUsage describes the memory of the preloaded sources for
+// server_status, the loaded ones first
+func formatMemoryUsage() string {
+	preloadMutex.RLock()
+	defer preloadMutex.RUnlock()
+	if len(sourceUse) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(sourceUse))
+	var loaded int64
+	for name, u := range sourceUse {
+		names = append(names, name)
+		if u.loaded {
+			loaded += u.bytes
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := sourceUse[names[i]], sourceUse[names[j]]
+		if a.loaded != b.loaded {
+			return a.loaded
+		}
+		return a.bytes > b.bytes
+	})
+
+	response := fmt.Sprintf("  indexes loaded: %s (estimated)", formatBytes(loaded))
+	if memoryBudget > 0 {
+		response += fmt.Sprintf(" of a %s budget", formatBytes(memoryBudget))
+	}
+	response += "\n"
+	for _, name := range names {
+		u := sourceUse[name]
+		state := "loaded"
+		if !u.loaded {
+			state = "evicted, loaded on demand"
+			if u.bytes == 0 {
+				state = "not loaded yet, loaded on demand"
+			}
+		}
+		response += fmt.Sprintf("    %s: %s, %s", name, formatBytes(u.bytes), state)
+		if u.evictions > 0 {
+			response += fmt.Sprintf(", evicted %d time(s)", u.evictions)
+		}
+		if u.reloads > 0 {
+			response += fmt.Sprintf(", loaded on demand %d time(s)", u.reloads)
+		}
+		if !u.lastUsed.IsZero() {
+			response += fmt.Sprintf(", last queried %s ago", time.Since(u.lastUsed).Round(time.Second))
+		}
+		response += "\n"
+	}
+	return response
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseByteSize(t *testing.T) {
+	for s, want := range map[string]int64{"512": 512, "1KB": 1024, "1.5k": 1536, "2GiB": 2 << 30, " 4 MB ": 4 << 20} {
+		if got, err := parseByteSize(s); err != nil || got != want {
+			t.Errorf("parseByteSize(%q) = %d, %v, want %d", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"", "lots", "-1GB", "1XB"} {
+		if _, err := parseByteSize(s); err == nil {
+			t.Errorf("parseByteSize(%q) succeeded", s)
+		}
+	}
+}
+
+func TestEvictOverBudget(t *testing.T) {
+	prevBudget, prevUse := memoryBudget, sourceUse
+	defer func() { memoryBudget, sourceUse = prevBudget, prevUse }()
+	memoryBudget = 250
+	sourceUse = make(map[string]*sourceUsage)
+
+	now := time.Now()
+	mss := &MultiSourceStore{Sources: map[string]*VectorStore{}}
+	for i, name := range []string{"old", "recent", "never", "wanted"} {
+		mss.Sources[name] = &VectorStore{}
+		u := usageOf(name)
+		u.bytes, u.loaded = 100, true
+		if name != "never" {
+			u.lastUsed = now.Add(time.Duration(i) * time.Minute)
+		}
+	}
+
+	evicted := evictOverBudget(mss, []string{"wanted"})
+	if !slices.Equal(evicted, []string{"never", "old"}) {
+		t.Fatalf("evicted %v, want the never and least recently used first", evicted)
+	}
+	if mss.Sources["wanted"] == nil || mss.Sources["recent"] == nil || usageOf("old").loaded || usageOf("old").evictions != 1 {
+		t.Fatalf("sources left %v", mss.Sources)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"lr/pkg/llm"
+)
+
+// sessionClient embeds and chats with clients an interactive session replaces
+// separately: /model swaps only the chat client and /embedding-model only the
+// embedder, so the loaded indexes and the session so far are kept
+type sessionClient struct {
+	embed      LLMClient
+	chat       LLMClient
+	embedModel string
+	chatModel  string
+}
+
+// newSessionClient starts a session with client for both embeddings and chat
+func newSessionClient(client LLMClient) *sessionClient {
+	s := &sessionClient{embed: client, chat: client, embedModel: getCurrentEmbeddingModel(), chatModel: resolveChatModel(chatModel)}
+	switch c := client.(type) {
+	case *UsageMeter:
+		s.chatModel = c.ChatModel
+	case *FailoverClient:
+		s.chatModel = c.Model
+	}
+	if mockLLM {
+		s.chatModel = llm.MockModel
+	}
+	return s
+}
+
+// GetEmbedding embeds with the session's embedder
+func (s *sessionClient) GetEmbedding(text string) ([]float64, error) {
+	return s.GetEmbeddingContext(context.Background(), text)
+}
+
+// GetEmbeddingContext embeds with the session's embedder
+func (s *sessionClient) GetEmbeddingContext(ctx context.Context, text string) ([]float64, error) {
+	return getEmbeddingContext(ctx, s.embed, text)
+}
+
+// Chat chats with the session's chat client
+func (s *sessionClient) Chat(messages []Message) (string, error) {
+	return s.ChatContext(context.Background(), messages)
+}
+
+// ChatContext chats with the session's chat client
+func (s *sessionClient) ChatContext(ctx context.Context, messages []Message) (string, error) {
+	return chatContext(ctx, s.chat, messages)
+}
+
+// switchChat replaces the chat client with one for model (a claude or gpt
+// model or alias, ollama[:<model>] or an external chat target). the
+// --fallback-models chain still applies
+func (s *sessionClient) switchChat(model string) error {
+	if mockLLM {
+		return fmt.Errorf("--mock-llm sessions only use the mock model")
+	}
+	client, resolved, err := chatBackend(model)
+	if err != nil {
+		return err
+	}
+	chat, err := withFailover(newUsageMeter(client, "", resolved))
+	if err != nil {
+		return err
+	}
+	s.chat, s.chatModel, chatModel = chat, resolved, model
+	return nil
+}
+
+// switchEmbedding replaces the embedder with one for model (a model id or
+// alias, or plugin:<command>)
+func (s *sessionClient) switchEmbedding(model string) error {
+	if mockLLM {
+		return fmt.Errorf("--mock-llm sessions only use the mock model")
+	}
+	resolved := resolveEmbeddingModel(model)
+	embedder, err := embedderForModel(resolved)
+	if err != nil {
+		return err
+	}
+	s.embed, s.embedModel, embeddingModel = newUsageMeter(embedderClient{embedder}, resolved, ""), resolved, model
+	return nil
+}
+
+// embedderClient is an embedder used where a client is expected; it can't chat
+type embedderClient struct {
+	Embedder
+}
+
+func (embedderClient) Chat([]Message) (string, error) {
+	return "", fmt.Errorf("embedding-only client can't chat")
+}
+
+// runModelCommand handles /model and /embedding-model in an interactive
+// session, reporting whether line was one of them
+func runModelCommand(s *sessionClient, line string) bool {
+	name, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	switch name {
+	case "/model":
+		if arg == "" {
+			fmt.Printf("chat model: %s (switch with /model <model>)\n\n", s.chatModel)
+			return true
+		}
+		if err := s.switchChat(arg); err != nil {
+			fmt.Printf("error: %v\n\n", err)
+			return true
+		}
+		fmt.Printf("chat model: %s\n\n", s.chatModel)
+	case "/embedding-model":
+		if arg == "" {
+			fmt.Printf("embedding model: %s (switch with /embedding-model <model>)\n\n", s.embedModel)
+			return true
+		}
+		if err := s.switchEmbedding(arg); err != nil {
+			fmt.Printf("error: %v\n\n", err)
+			return true
+		}
+		fmt.Printf("embedding model: %s\n\n", s.embedModel)
+	default:
+		return false
+	}
+	return true
+}
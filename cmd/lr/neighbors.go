@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// maxNeighbors bounds the chunks get_chunk_neighbors returns on each side
+const maxNeighbors = 10
+
+// fileChunksOf returns the chunks of file in vs, in file order. the parts of a
+// split file ("path (part n)") count as one file
+func fileChunksOf(vs *VectorStore, file string) []Chunk {
+	var chunks []Chunk
+	for _, chunk := range vs.Chunks {
+		if stripPart(chunk.Source) == file {
+			chunks = append(chunks, chunk)
+		}
+	}
+	return chunks
+}
+
+// chunkID identifies a search result's chunk as index:path#hash, the chunk's
+// stable id (see chunk.IDMetadataKey) in its index. chunks of indexes built
+// before ids were recorded get theirs computed from the chunks of the file; it
+// is "" if the chunk can't be found, e.g. because the index changed since the search
+func chunkID(mss *MultiSourceStore, result SearchResult) string {
+	index := result.Chunk.Metadata["vector_source"]
+	if id := result.Chunk.Metadata[chunkIDKey]; id != "" {
+		return index + ":" + id
+	}
+	vs := mss.Sources[index]
+	if vs == nil {
+		return ""
+	}
+	file := stripPart(result.Chunk.Source)
+	chunks := fileChunksOf(vs, file)
+	ids := fileChunkIDs(file, chunks)
+	for i, chunk := range chunks {
+		if chunk.Text == result.Chunk.Text {
+			return index + ":" + ids[i]
+		}
+	}
+	return ""
+}
+
+// chunkIDNote renders a result's chunk id for a result header, e.g. ", id: repo:a.go#3f2a9c1e0b4d"
+func chunkIDNote(mss *MultiSourceStore, result SearchResult) string {
+	if id := chunkID(mss, result); id != "" {
+		return ", id: " + id
+	}
+	return ""
+}
+
+// parseChunkID splits a chunk id into its index, file and the reference to
+// the chunk in the file: its content hash, or its position (1-based) for the
+// positional ids (index:path#n) earlier versions returned
+func parseChunkID(id string) (index, file, ref string, err error) {
+	rest, ref, ok := cutLast(id, "#")
+	index, file, found := strings.Cut(rest, ":")
+	if ok && !isChunkIDHash(ref) {
+		if n, err := strconv.Atoi(ref); err != nil || n < 1 {
+			ok = false
+		}
+	}
+	if !ok || !found || index == "" || file == "" {
+		return "", "", "", fmt.Errorf("invalid chunk id %q (expected index:path#id, as shown by query_repositories)", id)
+	}
+	return index, file, ref, nil
+}
+
+// findChunk returns the position (0-based) in chunks, the chunks of file, of
+// the chunk ref refers to
+func findChunk(file string, chunks []Chunk, ref string) (int, bool) {
+	if !isChunkIDHash(ref) {
+		n, _ := strconv.Atoi(ref)
+		return n - 1, n <= len(chunks)
+	}
+	for i, id := range fileChunkIDs(file, chunks) {
+		if id == file+"#"+ref {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// cutLast is strings.Cut around the last sep
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+func handleGetChunkNeighbors(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, ok := request.Params.Arguments.(map[string]interface{})
+	if !ok {
+		return mcp.NewToolResultError("invalid arguments"), nil
+	}
+	id, ok := args["chunk_id"].(string)
+	if !ok || id == "" {
+		return mcp.NewToolResultError("chunk_id parameter is required"), nil
+	}
+	before, after := 1, 1
+	if v, ok := args["before"].(float64); ok {
+		before = int(v)
+	}
+	if v, ok := args["after"].(float64); ok {
+		after = int(v)
+	}
+	if before < 0 || after < 0 || before > maxNeighbors || after > maxNeighbors {
+		return mcp.NewToolResultError(fmt.Sprintf("before and after must be between 0 and %d", maxNeighbors)), nil
+	}
+
+	index, file, ref, err := parseChunkID(id)
+	if err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	mss, err := storesFor(index)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("failed to load indexes: %v", err)), nil
+	}
+	vs := mss.Sources[index]
+	if vs == nil {
+		return mcp.NewToolResultError(fmt.Sprintf("index '%s' not found. available: %v", index, mss.ListSources())), nil
+	}
+	chunks := fileChunksOf(vs, file)
+	if len(chunks) == 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("no chunks of %s in %s (the index may have been rebuilt; search again)", file, index)), nil
+	}
+	pos, ok := findChunk(file, chunks, ref)
+	if !ok {
+		return mcp.NewToolResultError(fmt.Sprintf("no chunk %s in %s (the chunk changed when the index was updated; search again)", id, index)), nil
+	}
+
+	ids := fileChunkIDs(file, chunks)
+	n := pos + 1
+	first, last := max(n-before, 1), min(n+after, len(chunks))
+	response := fmt.Sprintf("%s:%s, chunks %d-%d of %d around #%d\n\n", index, file, first, last, len(chunks), n)
+	if first > 1 {
+		response += fmt.Sprintf("(%d earlier chunks)\n\n", first-1)
+	}
+	for i := first; i <= last; i++ {
+		marker := ""
+		if i == n {
+			marker = ", requested"
+		}
+		response += fmt.Sprintf("--- chunk %d: %s:%s%s ---\n", i, index, ids[i-1], marker)
+		response += chunks[i-1].Text
+		response += "\n\n"
+	}
+	if last < len(chunks) {
+		response += fmt.Sprintf("(%d later chunks)\n", len(chunks)-last)
+	}
+	return mcp.NewToolResultText(response), nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+
+	"lr/pkg/chunk"
+)
+
+// noiseFilterFromFlags returns the noise filter --noise-filter and --noise-mode select
+func noiseFilterFromFlags() (NoiseFilter, error) {
+	kinds, err := chunk.ParseNoiseKinds(noiseFilters)
+	if err != nil {
+		return NoiseFilter{}, err
+	}
+	switch noiseMode {
+	case "", "drop":
+		return NoiseFilter{Kinds: kinds}, nil
+	case "downweight":
+		return NoiseFilter{Kinds: kinds, DownWeight: true}, nil
+	}
+	return NoiseFilter{}, fmt.Errorf("unknown --noise-mode %q (use drop or downweight)", noiseMode)
+}
+
+// chunkSettingsFromFlags returns the chunking --min-chunk-size, --noise-filter,
+// --noise-mode, --max-chunks-per-file and --cap-by select
+func chunkSettingsFromFlags() (ChunkSettings, error) {
+	filter, err := noiseFilterFromFlags()
+	if err != nil {
+		return ChunkSettings{}, err
+	}
+	settings := chunk.NewSettings(maxChunkSize, minChunkSize, filter)
+	if maxPerFile > 0 {
+		strategy, err := chunk.ParseCapStrategy(capBy)
+		if err != nil {
+			return ChunkSettings{}, err
+		}
+		settings.MaxPerFile, settings.CapBy = maxPerFile, strategy
+	}
+	return settings, nil
+}
+
+// chunkDocuments chunks docs for indexing with settings, filtering their
+// noise chunks
+func chunkDocuments(docs []Document, settings ChunkSettings) ([]Chunk, NoiseReport) {
+	var chunks []Chunk
+	var report NoiseReport
+	for _, doc := range docs {
+		chunks = append(chunks, settings.Chunk(doc, &report)...)
+	}
+	return chunks, report
+}
+
+// printNoiseReport reports how many noise chunks the filter dropped or marked
+func printNoiseReport(report NoiseReport, filter NoiseFilter) {
+	if report.Total() == 0 {
+		return
+	}
+	action := "dropped"
+	if filter.DownWeight {
+		action = "down-weighted"
+	}
+	fmt.Printf("%s %d noise chunks (%s): %s\n", action, report.Total(), formatBytes(int64(report.Bytes)), report)
+}
+
+// printChunkCaps reports the files among chunks that settings capped
+func printChunkCaps(chunks []Chunk, settings ChunkSettings) {
+	totals := make(map[string]int)
+	kept := make(map[string]int)
+	for _, c := range chunks {
+		if total := chunk.CappedTotal(c); total > 0 {
+			file := chunkFilePath(c.Source)
+			totals[file] = total
+			kept[file]++
+		}
+	}
+	if len(totals) == 0 {
+		return
+	}
+	dropped := 0
+	for file, total := range totals {
+		dropped += total - kept[file]
+	}
+	fmt.Printf("capped %d file(s) at %d chunks, leaving out %d chunks (kept by %s)\n", len(totals), settings.MaxPerFile, dropped, settings.CapBy)
+}
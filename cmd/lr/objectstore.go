@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"lr/pkg/store"
+)
+
+// LR_INDEX_DIR moves the index directory: a local path, or an s3:// or gs://
+// url whose objects are cached locally, pulled when the index directory is
+// first used (and again at most every objectPullInterval in long running
+// commands), and pushed back after a command succeeds
+
+// objectPullInterval is how stale the local cache of an object store may get
+const objectPullInterval = time.Minute
+
+// objectSyncTimeout bounds a whole pull or push
+const objectSyncTimeout = 30 * time.Minute
+
+var objectCache struct {
+	sync.Mutex
+	store    *store.ObjectStore
+	dir      string
+	lastPull time.Time
+}
+
+// indexDirURL returns the object store LR_INDEX_DIR names, "" if it's local
+func indexDirURL() string {
+	if dir := os.Getenv("LR_INDEX_DIR"); store.IsObjectStoreURL(dir) {
+		return dir
+	}
+	return ""
+}
+
+// getCacheDir returns the directory for local copies of remote data
+// follows XDG base directory specification
+func getCacheDir() string {
+	if cacheHome := os.Getenv("XDG_CACHE_HOME"); cacheHome != "" {
+		return filepath.Join(cacheHome, "lr")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "lr")
+	}
+	return filepath.Join(home, ".cache", "lr")
+}
+
+// objectCacheDir returns where the objects of an object store url are cached
+func objectCacheDir(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return filepath.Join(getCacheDir(), "remote", "invalid")
+	}
+	return filepath.Join(getCacheDir(), "remote", u.Scheme, u.Host, filepath.FromSlash(u.Path))
+}
+
+// syncedIndexDir returns the local cache of the object store at rawURL,
+// pulling it if it's stale. a failed pull warns and falls back to the cache
+func syncedIndexDir(rawURL string) string {
+	objectCache.Lock()
+	defer objectCache.Unlock()
+	if objectCache.dir == "" {
+		objectCache.dir = objectCacheDir(rawURL)
+	}
+	if time.Since(objectCache.lastPull) < objectPullInterval {
+		return objectCache.dir
+	}
+	objectCache.lastPull = time.Now()
+
+	if objectCache.store == nil {
+		o, err := store.OpenObjectStore(rawURL)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v (using cached indexes in %s)\n", err, objectCache.dir)
+			return objectCache.dir
+		}
+		objectCache.store = o
+	}
+
+	// progress goes to stderr: mcp's stdout is the protocol
+	ctx, cancel := context.WithTimeout(context.Background(), objectSyncTimeout)
+	defer cancel()
+	stats, err := objectCache.store.Pull(ctx, objectCache.dir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to sync indexes from %s: %v (using cached copies)\n", rawURL, err)
+	} else if stats.Transferred > 0 || stats.Deleted > 0 {
+		fmt.Fprintf(os.Stderr, "synced indexes from %s: %d downloaded, %d removed\n", rawURL, stats.Transferred, stats.Deleted)
+	}
+	return objectCache.dir
+}
+
+// pushIndexDir uploads local changes to the index directory when it's an
+// object store this command used
+func pushIndexDir() error {
+	objectCache.Lock()
+	defer objectCache.Unlock()
+	if objectCache.store == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), objectSyncTimeout)
+	defer cancel()
+	stats, err := objectCache.store.Push(ctx, objectCache.dir)
+	if err != nil {
+		return fmt.Errorf("failed to upload indexes to %s: %w", objectCache.store.URL, err)
+	}
+	if stats.Transferred > 0 || stats.Deleted > 0 {
+		fmt.Fprintf(os.Stderr, "synced indexes to %s: %d uploaded, %d removed\n", objectCache.store.URL, stats.Transferred, stats.Deleted)
+	}
+	return nil
+}
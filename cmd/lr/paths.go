@@ -46,9 +46,15 @@ func ensureDir(path string) error {
 	return os.MkdirAll(path, 0755)
 }
 
-// getDefaultIndexDir returns the default directory for indexes
+// getDefaultIndexDir returns the default directory for indexes: LR_INDEX_DIR
+// if set (for an object store url, its local cache) or the data directory
 func getDefaultIndexDir() string {
 	dir := getDataDir()
+	if rawURL := indexDirURL(); rawURL != "" {
+		dir = syncedIndexDir(rawURL)
+	} else if env := os.Getenv("LR_INDEX_DIR"); env != "" {
+		dir = env
+	}
 	ensureDir(dir) // create if doesn't exist
 	return dir
 }
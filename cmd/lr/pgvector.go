@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"lr/pkg/store"
+)
+
+// LR_PG_DSN turns on the postgres/pgvector backend: indexes are still built
+// and saved locally, then copied into postgres, and lr query searches
+// postgres instead of loading local indexes
+
+// pgTimeout bounds a pgvector operation (copying a large index included)
+const pgTimeout = 30 * time.Minute
+
+var pgCmd = &cobra.Command{
+	Use:   "pg",
+	Short: "Manage indexes in the postgres/pgvector backend",
+	Long: `Copy indexes into postgres (with the pgvector extension), list and remove them.
+The database is set with LR_PG_DSN, a postgres:// url or libpq keyword string.
+With LR_PG_DSN set, lr index and update-all also copy what they build and lr query searches postgres.`,
+}
+
+var pgPushCmd = &cobra.Command{
+	Use:   "push [index...]",
+	Short: "Copy local indexes into postgres (all if none are named)",
+	RunE:  runPGPush,
+}
+
+var pgListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the indexes in postgres",
+	Args:  cobra.NoArgs,
+	RunE:  runPGList,
+}
+
+var pgDropCmd = &cobra.Command{
+	Use:   "drop <index>...",
+	Short: "Remove indexes from postgres",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runPGDrop,
+}
+
+// pgDSN returns the postgres backend's dsn, "" when it's off
+func pgDSN() string {
+	return os.Getenv("LR_PG_DSN")
+}
+
+// openPGVector connects to the postgres backend
+func openPGVector(ctx context.Context) (*store.PGVectorStore, error) {
+	dsn := pgDSN()
+	if dsn == "" {
+		return nil, fmt.Errorf("LR_PG_DSN is not set (e.g. postgres://lr@db.internal/lr)")
+	}
+	return store.OpenPGVectorStore(ctx, dsn)
+}
+
+// mirrorToPG copies the index just saved at path into postgres when the
+// backend is on
+func mirrorToPG(path string, vs *VectorStore) error {
+	if pgDSN() == "" {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), pgTimeout)
+	defer cancel()
+	pg, err := openPGVector(ctx)
+	if err != nil {
+		return fmt.Errorf("saved %s locally, but not to postgres: %w", filepath.Base(path), err)
+	}
+	defer pg.Close()
+	name := indexNameFromFile(path)
+	if err := pg.SaveIndex(ctx, name, vs); err != nil {
+		return fmt.Errorf("saved %s locally, but not to postgres: %w", filepath.Base(path), err)
+	}
+	fmt.Printf("✓ saved %s to postgres (%d chunks)\n", name, len(vs.Chunks))
+	return nil
+}
+
+// runPGPush copies the newest version of local indexes into postgres
+func runPGPush(_ *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), pgTimeout)
+	defer cancel()
+	pg, err := openPGVector(ctx)
+	if err != nil {
+		return err
+	}
+	defer pg.Close()
+
+	files, err := listIndexFiles(getDefaultIndexDir())
+	if err != nil {
+		return err
+	}
+	latest := make(map[string]string)
+	for _, file := range files {
+		name := indexNameFromFile(file)
+		if file > latest[name] {
+			latest[name] = file
+		}
+	}
+	names := args
+	if len(names) == 0 {
+		for name := range latest {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	if len(names) == 0 {
+		return withKind(fmt.Errorf("no local indexes to push\nrun 'lr index' to index repositories first"), ErrNoIndex)
+	}
+
+	for _, name := range names {
+		file, ok := latest[name]
+		if !ok {
+			return withKind(fmt.Errorf("no local index named %s", name), ErrNoIndex)
+		}
+		vs := NewVectorStore()
+		if err := vs.Load(file); err != nil {
+			return fmt.Errorf("failed to load %s: %w", filepath.Base(file), err)
+		}
+		if vs.Metadata.ReviewIndex {
+			fmt.Printf("  - %s: skipped (review session index)\n", name)
+			continue
+		}
+		if err := pg.SaveIndex(ctx, name, vs); err != nil {
+			return err
+		}
+		fmt.Printf("  ✓ %s: %d chunks\n", name, len(vs.Chunks))
+	}
+	return nil
+}
+
+func runPGList(_ *cobra.Command, _ []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), pgTimeout)
+	defer cancel()
+	pg, err := openPGVector(ctx)
+	if err != nil {
+		return err
+	}
+	defer pg.Close()
+
+	infos, err := pg.ListIndexes(ctx)
+	if err != nil {
+		return err
+	}
+	if len(infos) == 0 {
+		fmt.Println("no indexes in postgres")
+		fmt.Println("run 'lr pg push' to copy local indexes")
+		return nil
+	}
+	fmt.Printf("found %d index(es) in postgres:\n\n", len(infos))
+	for _, info := range infos {
+		fmt.Printf("  • %s\n", info.Name)
+		fmt.Printf("    chunks: %d, dimensions: %d\n", info.Chunks, info.Dimensions)
+		if info.Metadata.EmbeddingModel != "" {
+			fmt.Printf("    embedding model: %s\n", info.Metadata.EmbeddingModel)
+		}
+		if info.Metadata.SourcePath != "" {
+			fmt.Printf("    source: %s\n", info.Metadata.SourcePath)
+		}
+		fmt.Printf("    updated: %s\n\n", info.UpdatedAt.Local().Format("2006-01-02 15:04"))
+	}
+	return nil
+}
+
+func runPGDrop(_ *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), pgTimeout)
+	defer cancel()
+	pg, err := openPGVector(ctx)
+	if err != nil {
+		return err
+	}
+	defer pg.Close()
+
+	for _, name := range args {
+		found, err := pg.DropIndex(ctx, name)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return withKind(fmt.Errorf("no index named %s in postgres", name), ErrNoIndex)
+		}
+		fmt.Printf("removed %s from postgres\n", name)
+	}
+	return nil
+}
+
+// runPGQuery answers a question from the indexes in postgres
+func runPGQuery(cmd *cobra.Command, question string, attachments []Document) error {
+	chatOptions, err := chatOptionsFromFlags(cmd)
+	if err != nil {
+		return err
+	}
+	llm, err := getLLMClient()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), pgTimeout)
+	defer cancel()
+	pg, err := openPGVector(ctx)
+	if err != nil {
+		return err
+	}
+	defer pg.Close()
+	pg.Where = pgWhere
+	if err := pg.CheckSources(ctx, querySources); err != nil {
+		return err
+	}
+
+	rag := NewRAGBackend(pg, llm)
+	rag.Attachments = attachments
+	rag.ChatOptions = chatOptions
+	rag.SelfCheck = selfCheck
+	rag.MinSimilarity = minSimilarity
+	rag.ExcludePaths = excludePaths
+	if deepQuery {
+		rag.Deep = deepRounds
+	}
+
+	_, results, err := answerQuestion(rag, question, topK, querySources)
+	flushUsageFor(llm, "query", "pg")
+	if err != nil {
+		return fmt.Errorf("error querying: %w", err)
+	}
+	logRetrieval("query", question, results)
+	return nil
+}
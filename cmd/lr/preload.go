@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// preloadWorkers bounds how many indexes a preload reads at once
+const preloadWorkers = 4
+
+// preloadProgress tracks the current or last preload, for server_status.
+// guarded by preloadMutex
+type preloadProgress struct {
+	running    bool
+	startedAt  time.Time
+	finishedAt time.Time
+	total      int      // sources being loaded
+	ready      int      // sources loaded so far
+	failed     []string // errors of sources that failed to load
+	err        error    // why the last preload wasn't published
+}
+
+var preload preloadProgress
+
+// preloadRun serializes preloads, e.g. a reload signal during the startup load
+var preloadRun sync.Mutex
+
+// reloadVectorStores loads every index and publishes them as the preloaded
+// stores. sources load concurrently through onDemandCache, so tools that fall
+// back to on-demand loading meanwhile share the reads. if any source fails the
+// previous stores (or on-demand loading) stay in use
+func reloadVectorStores() error {
+	preloadRun.Lock()
+	defer preloadRun.Unlock()
+
+	mss := NewMultiSourceStore(getDefaultIndexDir())
+	mss.Cache = onDemandCache
+	names, err := mss.SourceNames()
+	if err != nil {
+		return fmt.Errorf("failed to reload vector stores: %w", err)
+	}
+
+	preloadMutex.Lock()
+	preload = preloadProgress{running: true, startedAt: time.Now(), total: len(names)}
+	preloadMutex.Unlock()
+
+	ready := func(name string, err error) {
+		preloadMutex.Lock()
+		defer preloadMutex.Unlock()
+		if err != nil {
+			preload.failed = append(preload.failed, err.Error())
+		} else {
+			preload.ready++
+		}
+	}
+	if memoryBudget > 0 {
+		err = loadWithinBudget(mss, names, ready)
+	} else {
+		err = mss.LoadSources(names, preloadWorkers, ready)
+	}
+	mss.Cache = nil
+
+	preloadMutex.Lock()
+	preload.running = false
+	preload.finishedAt = time.Now()
+	if err != nil {
+		err = fmt.Errorf("failed to reload vector stores: %w", err)
+		preload.err = err
+	} else {
+		preloadedMSS = mss
+		lastLoadAt = preload.finishedAt
+		loads++
+		recordUsage(mss, names)
+	}
+	published := preloadedMSS != nil
+	elapsed := preload.finishedAt.Sub(preload.startedAt)
+	preloadMutex.Unlock()
+
+	// the published stores hold what they need; cached copies would only pin
+	// superseded versions in memory
+	if published {
+		onDemandCache.Clear()
+	}
+	if err != nil {
+		return err
+	}
+
+	mcpLog.Printf("reloaded %d vector store sources in %s: %v", len(mss.Sources), elapsed.Round(time.Millisecond), mss.ListSources())
+	if deferred := len(names) - len(mss.Sources); deferred > 0 {
+		mcpLog.Printf("%d more sources are loaded on demand, to stay within the %s memory budget", deferred, formatBytes(memoryBudget))
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"lr/pkg/llm"
+)
+
+// bundledPrices is the price table lr ships with; a prices.json in the config
+// directory (or at $LR_PRICES) overrides and extends it, e.g. for new models or
+// negotiated rates
+//
+//go:embed prices.json
+var bundledPrices []byte
+
+// modelPrice is a model's price per 1M tokens in dollars. embedding models only
+// have input
+type modelPrice struct {
+	Provider string  `json:"provider,omitempty"`
+	Kind     string  `json:"kind,omitempty"` // embedding or chat
+	Input    float64 `json:"input,omitempty"`
+	Output   float64 `json:"output,omitempty"`
+}
+
+// typicalQuery is the token counts an answered question usually takes, for
+// estimating what queries cost
+type typicalQuery struct {
+	QuestionTokens int `json:"question_tokens,omitempty"` // embedded
+	PromptTokens   int `json:"prompt_tokens,omitempty"`   // instructions around the chunks
+	Chunks         int `json:"chunks,omitempty"`
+	ChunkTokens    int `json:"chunk_tokens,omitempty"`
+	AnswerTokens   int `json:"answer_tokens,omitempty"`
+}
+
+// priceTable is the format of prices.json
+type priceTable struct {
+	Updated      string                `json:"updated,omitempty"`
+	Models       map[string]modelPrice `json:"models"`
+	TypicalQuery typicalQuery          `json:"typical_query"`
+}
+
+var (
+	prices     priceTable
+	pricesOnce sync.Once
+)
+
+// pricesPath returns the prices.json that overrides the bundled prices
+func pricesPath() string {
+	if path := os.Getenv("LR_PRICES"); path != "" {
+		return path
+	}
+	return filepath.Join(getConfigDir(), "prices.json")
+}
+
+// loadPrices returns the bundled price table with the overrides applied. a
+// broken override is warned about and ignored
+func loadPrices() priceTable {
+	pricesOnce.Do(func() {
+		if err := json.Unmarshal(bundledPrices, &prices); err != nil {
+			panic(fmt.Sprintf("bundled prices.json: %v", err))
+		}
+		data, err := os.ReadFile(pricesPath())
+		if err != nil {
+			return
+		}
+		var override priceTable
+		if err := json.Unmarshal(data, &override); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: ignoring %s: %v\n", pricesPath(), err)
+			return
+		}
+		for model, price := range override.Models {
+			bundled, ok := prices.Models[model]
+			if !ok {
+				bundled = modelPrice{Provider: providerForModel(model)}
+			}
+			if price.Provider == "" {
+				price.Provider = bundled.Provider
+			}
+			if price.Kind == "" {
+				price.Kind = bundled.Kind
+			}
+			prices.Models[model] = price
+		}
+		if override.Updated != "" {
+			prices.Updated = override.Updated
+		}
+		t, o := &prices.TypicalQuery, override.TypicalQuery
+		for _, f := range []struct{ dst, src *int }{
+			{&t.QuestionTokens, &o.QuestionTokens}, {&t.PromptTokens, &o.PromptTokens}, {&t.Chunks, &o.Chunks},
+			{&t.ChunkTokens, &o.ChunkTokens}, {&t.AnswerTokens, &o.AnswerTokens},
+		} {
+			if *f.src > 0 {
+				*f.dst = *f.src
+			}
+		}
+	})
+	return prices
+}
+
+// priceOf returns model's price and whether it is known. models run by ollama
+// (and the mock ones) are free
+func priceOf(model string) (modelPrice, bool) {
+	if model == "" {
+		return modelPrice{}, false
+	}
+	if price, ok := loadPrices().Models[model]; ok {
+		return price, true
+	}
+	if model == llm.MockModel {
+		return modelPrice{Provider: "mock"}, true
+	}
+	if provider := providerForModel(model); provider == "ollama" {
+		return modelPrice{Provider: provider}, true
+	}
+	return modelPrice{Provider: providerForModel(model)}, false
+}
+
+// tokenCost returns the cost in dollars of input and output tokens at price
+func (p modelPrice) tokenCost(input, output int) float64 {
+	return float64(input)/1_000_000.0*p.Input + float64(output)/1_000_000.0*p.Output
+}
+
+// queryCost estimates what a typical answered question costs: embedding it
+// with embeddingModel, and the prompt and answer with chatModel. known is
+// false when either price is unknown
+func queryCost(embeddingModel, chatModel string) (cost float64, known bool) {
+	q := loadPrices().TypicalQuery
+	embedding, embeddingKnown := priceOf(embeddingModel)
+	chat, chatKnown := priceOf(chatModel)
+	input := q.PromptTokens + q.Chunks*q.ChunkTokens + q.QuestionTokens
+	return embedding.tokenCost(q.QuestionTokens, 0) + chat.tokenCost(input, q.AnswerTokens), embeddingKnown && chatKnown
+}
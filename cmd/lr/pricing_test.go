@@ -0,0 +1,43 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestPricesOverride(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prices.json")
+	if err := os.WriteFile(path, []byte(`{"models": {"gpt-4o": {"input": 1, "output": 2}, "my-model": {"kind": "chat", "input": 4}}, "typical_query": {"answer_tokens": 1000}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("LR_PRICES", path)
+	prices, pricesOnce = priceTable{}, sync.Once{}
+	defer func() { prices, pricesOnce = priceTable{}, sync.Once{} }()
+
+	if p, ok := priceOf("gpt-4o"); !ok || p.Input != 1 || p.Provider != "openai" || p.Kind != "chat" {
+		t.Errorf("overridden gpt-4o = %+v, %v", p, ok)
+	}
+	if p, ok := priceOf("my-model"); !ok || p.Input != 4 {
+		t.Errorf("added my-model = %+v, %v", p, ok)
+	}
+	if p, ok := priceOf("text-embedding-3-small"); !ok || p.Input != 0.02 {
+		t.Errorf("bundled text-embedding-3-small = %+v, %v", p, ok)
+	}
+	if p, ok := priceOf("ollama:llama3"); !ok || p.Input != 0 || p.Provider != "ollama" {
+		t.Errorf("ollama model = %+v, %v, want free", p, ok)
+	}
+	if _, ok := priceOf("plugin:embed.py"); ok {
+		t.Error("a plugin model has a price")
+	}
+
+	q := loadPrices().TypicalQuery
+	if q.AnswerTokens != 1000 || q.Chunks != 3 {
+		t.Errorf("typical query = %+v, want the answer tokens overridden and the rest bundled", q)
+	}
+	cost, known := queryCost("nomic-embed-text", "gpt-4o")
+	if want := float64(q.PromptTokens+q.Chunks*q.ChunkTokens+q.QuestionTokens)/1e6 + 1000*2/1e6; !known || cost != want {
+		t.Errorf("query cost = %v, %v, want %v", cost, known, want)
+	}
+}
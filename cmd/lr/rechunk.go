@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// chunkingChanged is set when lr index or lr update-all is given chunking
+// flags; without them, an update chunks as its index was built
+var chunkingChanged bool
+
+// chunkFlagsChanged reports whether cmd was given a flag that changes chunking
+func chunkFlagsChanged(cmd *cobra.Command) bool {
+	for _, name := range []string{"min-chunk-size", "noise-filter", "noise-mode", "max-chunks-per-file", "cap-by"} {
+		if f := cmd.Flags().Lookup(name); f != nil && f.Changed {
+			return true
+		}
+	}
+	return false
+}
+
+// chunkChanges returns the settings to chunk an update of vs with and how
+// they differ from those it was built with: a changed chunking flag, or an lr
+// that chunks differently. without chunking flags an update keeps the index's
+func chunkChanges(vs *VectorStore) (ChunkSettings, []string, error) {
+	settings, err := chunkSettingsFromFlags()
+	if err != nil {
+		return ChunkSettings{}, nil, err
+	}
+	built := vs.Metadata.Chunking
+	if built == nil {
+		// built before the settings were recorded: assume they were these
+		return settings, nil, nil
+	}
+	if !chunkingChanged {
+		settings.MinSize, settings.Noise, settings.NoiseMode = built.MinSize, built.Noise, built.NoiseMode
+		settings.MaxPerFile, settings.CapBy = built.MaxPerFile, built.CapBy
+	}
+	return settings, built.Changes(settings), nil
+}
+
+// updateChunkSettings returns the settings to chunk an update of vs with, and
+// whether all of its files must be re-chunked because the settings changed
+// (see chunkChanges). rather than mix chunk shapes in one index, the re-chunk
+// (and re-embed) is asked for, or done without asking with --rechunk
+func updateChunkSettings(vs *VectorStore) (ChunkSettings, bool, error) {
+	settings, changes, err := chunkChanges(vs)
+	if err != nil || len(changes) == 0 {
+		return settings, false, err
+	}
+
+	fmt.Printf("%s %s was chunked with other settings: %s\n", yellow("warning:"), outName, strings.Join(changes, ", "))
+	if rechunk || dryRun || confirm(fmt.Sprintf("re-chunk and re-embed all %d files?", len(vs.Metadata.IndexedFiles))) {
+		return settings, true, nil
+	}
+	hint := "re-run with --rechunk to re-chunk and re-embed every file"
+	if chunkingChanged {
+		hint += ", or without the chunking flags to keep the index's"
+	}
+	return ChunkSettings{}, false, fmt.Errorf("chunking settings changed (%s): updating only the changed files would mix chunk shapes; %s",
+		strings.Join(changes, ", "), hint)
+}
+
+// rechunkChanges extends cs so every indexed file is re-chunked: those not
+// added or deleted become modified
+func rechunkChanges(vs *VectorStore, cs *ChangeSet) *ChangeSet {
+	seen := make(map[string]bool)
+	for _, f := range slices.Concat(cs.Added, cs.Modified, cs.Deleted) {
+		seen[f] = true
+	}
+	all := &ChangeSet{Added: cs.Added, Modified: slices.Clone(cs.Modified), Deleted: cs.Deleted}
+	for _, f := range vs.Metadata.IndexedFiles {
+		if !seen[f] {
+			all.Modified = append(all.Modified, f)
+			seen[f] = true
+		}
+	}
+	return all
+}
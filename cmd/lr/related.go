@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// the review context of a change includes code elsewhere in the project that
+// is semantically related to it (callers, interfaces, docs): each hunk of the
+// diff is embedded and searched for across the review index
+
+const (
+	maxRelatedHunks    = 20   // hunks embedded per diff; the largest are kept
+	maxRelatedHunkText = 2000 // characters of a hunk that are embedded
+)
+
+// diffHunk is one hunk of a diff: the file it changes, the lines of the new
+// file it spans, its lines without the diff prefixes, and the lines it adds
+type diffHunk struct {
+	file       string
+	start, end int
+	text       string
+	added      []string
+}
+
+// hunkLines returns the lines of the new file a hunk header ("@@ -a,b +c,d @@")
+// spans. a hunk that only deletes lines spans the line before the deletion
+func hunkLines(header string) (start, end int) {
+	_, rest, _ := strings.Cut(header, " +")
+	rest, _, _ = strings.Cut(rest, " ")
+	first, count, found := strings.Cut(rest, ",")
+	start, _ = strconv.Atoi(first)
+	n := 1
+	if found {
+		n, _ = strconv.Atoi(count)
+	}
+	return start, start + max(n, 1) - 1
+}
+
+// diffHunks splits a git diff into its hunks
+func diffHunks(diff string) []diffHunk {
+	var hunks []diffHunk
+	var file string
+	var current *diffHunk
+	flush := func() {
+		if current != nil && strings.TrimSpace(current.text) != "" {
+			hunks = append(hunks, *current)
+		}
+		current = nil
+	}
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "), strings.HasPrefix(line, "==="):
+			flush()
+		case current == nil && strings.HasPrefix(line, "--- a/"):
+			file = strings.TrimPrefix(line, "--- a/")
+		case current == nil && strings.HasPrefix(line, "+++ b/"):
+			file = strings.TrimPrefix(line, "+++ b/")
+		case strings.HasPrefix(line, "@@"):
+			flush()
+			start, end := hunkLines(line)
+			current = &diffHunk{file: file, start: start, end: end}
+		case current != nil && line != "" && strings.ContainsRune(" +-", rune(line[0])):
+			current.text += line[1:] + "\n"
+			if line[0] == '+' {
+				current.added = append(current.added, strings.TrimSpace(line[1:]))
+			}
+		}
+	}
+	flush()
+	return hunks
+}
+
+// relatedChunk is a chunk related to the change, with the best similarity to
+// any of its hunks
+type relatedChunk struct {
+	chunk      Chunk
+	similarity float64
+	hunkFile   string
+}
+
+// chunkKey identifies a chunk's content in a review context, to show it once
+func chunkKey(c Chunk) string {
+	return c.Source + "\x00" + c.Text
+}
+
+// relatedContext returns the review prompt section with up to 2*topK chunks
+// semantically related to hunks, or "" if there are none. chunks that are part
+// of the change itself, already shown, or from conventions documents (which
+// have their own section) are left out. embedding failures only drop the section
+func relatedContext(ctx context.Context, store *VectorStore, hunks []diffHunk, shown map[string]bool, topK int) string {
+	if len(hunks) == 0 || len(store.Chunks) == 0 {
+		return ""
+	}
+	hunks = append([]diffHunk(nil), hunks...)
+	sort.SliceStable(hunks, func(i, j int) bool { return len(hunks[i].text) > len(hunks[j].text) })
+	if len(hunks) > maxRelatedHunks {
+		hunks = hunks[:maxRelatedHunks]
+	}
+
+	model := store.Metadata.EmbeddingModel
+	if model == "" {
+		model = "nomic-embed-text"
+	}
+	embedder, err := embedderForModel(model)
+	if err != nil {
+		return fmt.Sprintf("(related code unavailable: %v)\n\n", err)
+	}
+
+	best := make(map[string]*relatedChunk)
+	for _, hunk := range hunks {
+		text := hunk.file + "\n" + hunk.text
+		if len(text) > maxRelatedHunkText {
+			text = text[:maxRelatedHunkText]
+		}
+		embedding, err := embedder.GetEmbeddingContext(ctx, text)
+		if err != nil {
+			return fmt.Sprintf("(related code unavailable: %v)\n\n", err)
+		}
+		for _, result := range store.Search(embedding, topK+len(hunks)) {
+			key := chunkKey(result.Chunk)
+			if shown[key] || partOfHunk(result.Chunk, hunk) || isConventionFile(result.Chunk.Source) {
+				continue
+			}
+			if r, ok := best[key]; !ok || result.Similarity > r.similarity {
+				best[key] = &relatedChunk{result.Chunk, result.Similarity, hunk.file}
+			}
+		}
+	}
+	if len(best) == 0 {
+		return ""
+	}
+
+	related := make([]*relatedChunk, 0, len(best))
+	for _, r := range best {
+		related = append(related, r)
+	}
+	sort.Slice(related, func(i, j int) bool { return related[i].similarity > related[j].similarity })
+	if len(related) > 2*topK {
+		related = related[:2*topK]
+	}
+
+	response := "=== RELATED CODE ===\n\n"
+	for _, r := range related {
+		response += fmt.Sprintf("--- context from %s (related to %s, similarity %.2f) ---\n%s\n\n", r.chunk.Source, r.hunkFile, r.similarity, r.chunk.Text)
+	}
+	return response
+}
+
+// partOfHunk reports whether chunk is the code hunk changes: a chunk of the
+// same file containing one of the lines the hunk adds
+func partOfHunk(chunk Chunk, hunk diffHunk) bool {
+	if hunk.file == "" || !strings.Contains(chunk.Source, hunk.file) {
+		return false
+	}
+	for _, line := range hunk.added {
+		if len(line) >= 8 && strings.Contains(chunk.Text, line) {
+			return true
+		}
+	}
+	return false
+}
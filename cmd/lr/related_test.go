@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiffHunks(t *testing.T) {
+	diff := `diff --git a/parse.go b/parse.go
+--- a/parse.go
++++ b/parse.go
+@@ -1,3 +1,4 @@ package p
+ func ParseToken(raw string) (string, error) {
++	if raw == "" { return "", ErrEmpty }
+ 	return raw, nil
+ }
+@@ -10,2 +11,1 @@
+-// unused
+ var x = 1
+
+=== STAGED CHANGES ===
+diff --git a/old.go b/old.go
+--- a/old.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package p
+`
+	hunks := diffHunks(diff)
+	if len(hunks) != 3 {
+		t.Fatalf("expected 3 hunks, got %d: %+v", len(hunks), hunks)
+	}
+	if hunks[0].file != "parse.go" || len(hunks[0].added) != 1 || hunks[1].file != "parse.go" || hunks[2].file != "old.go" ||
+		hunks[0].start != 1 || hunks[0].end != 4 || hunks[1].start != 11 || hunks[1].end != 11 {
+		t.Fatalf("unexpected hunks: %+v", hunks)
+	}
+
+	changed := Chunk{Source: "parse.go", Text: "func ParseToken(raw string) (string, error) {\n\tif raw == \"\" { return \"\", ErrEmpty }\n"}
+	caller := Chunk{Source: "handler.go", Text: "tok, err := ParseToken(r.Header.Get(\"token\"))"}
+	if !partOfHunk(changed, hunks[0]) || partOfHunk(caller, hunks[0]) {
+		t.Error("only the changed code should count as part of the hunk")
+	}
+}
+
+func TestChangedSymbols(t *testing.T) {
+	store := NewVectorStore()
+	add := func(text string, start, end string) {
+		store.Add(Chunk{Text: text, Source: "parse.go", Metadata: map[string]string{"start_line": start, "end_line": end}}, []float64{1})
+	}
+	add("func ParseToken(raw string) (string, error) {", "1", "4")
+	add("func (p *Parser) Next() Token {", "6", "20")
+	store.Add(Chunk{Text: "tok, err := ParseToken(header)", Source: "handler.go"}, []float64{1})
+
+	symbols := changedSymbols(store, "parse.go", []diffHunk{{file: "parse.go", start: 2, end: 3}})
+	if len(symbols) != 1 || symbols[0].name != "ParseToken" {
+		t.Fatalf("expected the hunk to map to ParseToken, got %+v", symbols)
+	}
+	if chunkSymbol(store.Chunks[1].Text) != "Next" {
+		t.Errorf("expected the method name, got %q", chunkSymbol(store.Chunks[1].Text))
+	}
+
+	shown := map[string]bool{chunkKey(symbols[0].chunk): true}
+	if refs := referencesContext(store, symbols, shown, 3); !strings.Contains(refs, "ParseToken referenced in handler.go") {
+		t.Errorf("expected the caller as a reference, got %q", refs)
+	}
+}
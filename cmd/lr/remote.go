@@ -0,0 +1,402 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// lr serve --remote hosts the indexes of one machine for a team: laptops run
+// lr query --remote <url> and the server searches its indexes and synthesizes
+// the answer with its own keys, so neither indexes nor api keys are copied to
+// each machine. requests carry the shared token in LR_REMOTE_TOKEN as a bearer
+// token
+
+const (
+	remoteTokenEnv      = "LR_REMOTE_TOKEN"
+	remoteQueryPath     = "/v1/query"
+	remoteIndexesPath   = "/v1/indexes"
+	remoteMaxBody       = 1 << 20
+	remoteClientTimeout = 10 * time.Minute // synthesis (and --deep) can take a while
+)
+
+// remoteQueryRequest is the body of a POST to /v1/query
+type remoteQueryRequest struct {
+	Question      string   `json:"question"`
+	TopK          int      `json:"top_k,omitempty"`
+	Sources       []string `json:"sources,omitempty"`
+	Synthesize    bool     `json:"synthesize"`
+	NoRoute       bool     `json:"no_route,omitempty"`
+	Deep          bool     `json:"deep,omitempty"`
+	SelfCheck     bool     `json:"self_check,omitempty"`
+	MinSimilarity float64  `json:"min_similarity,omitempty"`
+	ExpandImports int      `json:"expand_imports,omitempty"`
+	Refs          string   `json:"refs,omitempty"`
+	ExcludePaths  []string `json:"exclude_paths,omitempty"`
+}
+
+// remoteQueryResponse is the reply to a query
+type remoteQueryResponse struct {
+	Answer     string         `json:"answer,omitempty"`
+	Results    []SearchResult `json:"results"`
+	Routed     []string       `json:"routed,omitempty"`
+	FollowUps  []string       `json:"follow_ups,omitempty"`
+	Skipped    []string       `json:"skipped,omitempty"`
+	Citations  []Citation     `json:"citations,omitempty"`
+	Confidence *Confidence    `json:"confidence,omitempty"`
+	Abstained  bool           `json:"abstained,omitempty"`
+	Failover   string         `json:"failover,omitempty"`
+}
+
+// remoteError is the body of a failed request; kind is one of errorKinds
+type remoteError struct {
+	Error string `json:"error"`
+	Kind  string `json:"kind"`
+}
+
+// remoteServer serves queries over http
+type remoteServer struct {
+	token     string
+	operation string // usage ledger operation of queries ("" is remote)
+
+	llmOnce sync.Once
+	llm     LLMClient
+	llmErr  error
+}
+
+// client returns the llm client, created on first use
+func (s *remoteServer) client() (LLMClient, error) {
+	s.llmOnce.Do(func() {
+		s.llm, s.llmErr = getLLMClient()
+	})
+	return s.llm, s.llmErr
+}
+
+// authorized wraps a handler with the bearer token check
+func (s *remoteServer) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) != 1 {
+			writeRemoteError(w, http.StatusUnauthorized, withKind(errors.New("missing or wrong remote token (set "+remoteTokenEnv+")"), ErrAuth))
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (s *remoteServer) handleQuery(w http.ResponseWriter, r *http.Request) {
+	var req remoteQueryRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, remoteMaxBody)).Decode(&req); err != nil {
+		writeRemoteError(w, http.StatusBadRequest, fmt.Errorf("invalid request: %w", err))
+		return
+	}
+	resp, status, err := s.answer(r.Context(), req, nil)
+	if err != nil {
+		writeRemoteError(w, status, err)
+		return
+	}
+	writeRemoteJSON(w, http.StatusOK, resp)
+}
+
+// answer searches the indexes for req and synthesizes the answer (if asked),
+// calling retrieved, if set, with the results before synthesis. failures come
+// with the http status to reply with
+func (s *remoteServer) answer(ctx context.Context, req remoteQueryRequest, retrieved func(*remoteQueryResponse)) (*remoteQueryResponse, int, error) {
+	if strings.TrimSpace(req.Question) == "" {
+		return nil, http.StatusBadRequest, errors.New("question is required")
+	}
+	refs, err := parseRefDirection(req.Refs)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+
+	mss, err := storesFor(req.Sources...)
+	if err != nil {
+		return nil, remoteStatus(err), err
+	}
+	if len(mss.Sources) == 0 {
+		err := withKind(errors.New("no vector stores found on the server"), ErrNoIndex)
+		return nil, remoteStatus(err), err
+	}
+	llm, err := s.client()
+	if err != nil {
+		return nil, remoteStatus(err), err
+	}
+	operation := s.operation
+	if operation == "" {
+		operation = "remote"
+	}
+	defer flushUsageFor(llm, operation, strings.Join(req.Sources, ","))
+
+	rag := NewRAGMultiSource(mss, llm)
+	rag.Route = !req.NoRoute
+	rag.SelfCheck = req.SelfCheck
+	rag.MinSimilarity = req.MinSimilarity
+	rag.ExpandImports = req.ExpandImports
+	rag.Refs = refs
+	rag.ExcludePaths = req.ExcludePaths
+	if req.Deep {
+		rag.Deep = deepRounds
+	}
+
+	results, queryEmbedding, err := rag.RetrieveDeep(ctx, req.Question, req.TopK, req.Sources, rag.Deep)
+	if err != nil {
+		return nil, remoteStatus(err), dimensionAdvice(err)
+	}
+	logRetrieval(operation, req.Question, results)
+	resp := &remoteQueryResponse{Results: results, Routed: rag.Routed, FollowUps: rag.FollowUps}
+	for _, derr := range rag.Skipped {
+		resp.Skipped = append(resp.Skipped, derr.Error())
+	}
+	if retrieved != nil {
+		retrieved(resp)
+	}
+	if req.Synthesize {
+		var failover FailoverResult
+		answer, err := rag.Synthesize(withFailoverResult(ctx, &failover), req.Question, queryEmbedding, results)
+		if err != nil {
+			return nil, remoteStatus(err), err
+		}
+		resp.Answer, resp.Citations, resp.Confidence, resp.Abstained = answer, rag.Citations, rag.Confidence, rag.Abstained
+		resp.Failover = failoverNote(&failover)
+	}
+	return resp, http.StatusOK, nil
+}
+
+func (s *remoteServer) handleIndexes(w http.ResponseWriter, _ *http.Request) {
+	files, err := listIndexFiles(getDefaultIndexDir())
+	if err != nil {
+		writeRemoteError(w, remoteStatus(err), err)
+		return
+	}
+	currentModel := getCurrentEmbeddingModel()
+	infos := make([]IndexInfo, 0, len(files))
+	for _, file := range files {
+		info := describeIndex(file, currentModel)
+		info.Path, info.SourcePath = "", "" // server paths are of no use to clients
+		infos = append(infos, info)
+	}
+	writeRemoteJSON(w, http.StatusOK, infos)
+}
+
+// remoteStatus maps lr error kinds to http status codes
+func remoteStatus(err error) int {
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, ErrNoIndex):
+		return http.StatusNotFound
+	case errors.Is(err, ErrAuth):
+		return http.StatusBadGateway // the server's chat or embedding key was rejected
+	case errors.Is(err, ErrRateLimited):
+		return http.StatusTooManyRequests
+	case errors.Is(err, ErrIndexBusy), errors.Is(err, ErrDimensionMismatch):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+func writeRemoteJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeRemoteError(w http.ResponseWriter, status int, err error) {
+	kind, _ := errorKind(err)
+	writeRemoteJSON(w, status, remoteError{Error: err.Error(), Kind: kind})
+}
+
+// runRemoteServe serves the http api of lr serve --remote
+func runRemoteServe() error {
+	token := os.Getenv(remoteTokenEnv)
+	if token == "" {
+		return fmt.Errorf("set %s to the token clients must send (e.g. openssl rand -hex 32)", remoteTokenEnv)
+	}
+	if (serveTLSCert == "") != (serveTLSKey == "") {
+		return fmt.Errorf("--tls-cert and --tls-key go together")
+	}
+
+	s := &remoteServer{token: token}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+remoteQueryPath, s.authorized(s.handleQuery))
+	mux.HandleFunc("GET "+remoteIndexesPath, s.authorized(s.handleIndexes))
+	srv := &http.Server{Addr: serveAddr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	lis, err := net.Listen("tcp", serveAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", serveAddr, err)
+	}
+
+	// stop gracefully on Ctrl+C / kill
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		fmt.Println("\nstopping remote server...")
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		srv.Shutdown(ctx)
+	}()
+
+	scheme := "http"
+	if serveTLSCert != "" {
+		scheme = "https"
+	} else if host, _, _ := net.SplitHostPort(serveAddr); !isLoopbackHost(host) {
+		fmt.Printf("%s serving plain http on %s: the token and answers travel unencrypted (use --tls-cert/--tls-key or a tls proxy)\n", yellow("warning:"), serveAddr)
+	}
+	fmt.Printf("lr remote api listening on %s://%s\n", scheme, lis.Addr())
+
+	if serveTLSCert != "" {
+		err = srv.ServeTLS(lis, serveTLSCert, serveTLSKey)
+	} else {
+		err = srv.Serve(lis)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// isLoopbackHost reports whether host is this machine only
+func isLoopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// queryRemote sends a query to an lr serve --remote server
+func queryRemote(baseURL string, req remoteQueryRequest) (*remoteQueryResponse, error) {
+	token := os.Getenv(remoteTokenEnv)
+	if token == "" {
+		return nil, withKind(fmt.Errorf("set %s to the remote server's token", remoteTokenEnv), ErrAuth)
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequest(http.MethodPost, strings.TrimRight(baseURL, "/")+remoteQueryPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote url: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+token)
+
+	client := &http.Client{Timeout: remoteClientTimeout}
+	httpResp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("remote query failed: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		var rerr remoteError
+		if err := json.NewDecoder(httpResp.Body).Decode(&rerr); err != nil || rerr.Error == "" {
+			return nil, fmt.Errorf("remote query failed: %s", httpResp.Status)
+		}
+		rerr.Error = "remote: " + rerr.Error
+		return nil, remoteErrorWithKind(rerr)
+	}
+	var resp remoteQueryResponse
+	if err := json.NewDecoder(httpResp.Body).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to parse remote response: %w", err)
+	}
+	return &resp, nil
+}
+
+// remoteErrorWithKind turns an error reply back into an error of its kind
+func remoteErrorWithKind(rerr remoteError) error {
+	err := errors.New(rerr.Error)
+	for _, k := range errorKinds {
+		if k.name == rerr.Kind {
+			return withKind(err, k.err)
+		}
+	}
+	return err
+}
+
+// runRemoteQuery answers question with the server at remoteURL, printing the
+// answer as a local query does (or the chunks with --no-synthesize)
+func runRemoteQuery(question string) error {
+	resp, err := queryRemote(remoteURL, remoteQueryFromFlags(question))
+	if err != nil {
+		return err
+	}
+	printRemoteRetrieval(resp)
+	printRemoteAnswer(question, resp, nil)
+	return nil
+}
+
+// remoteQueryFromFlags is the request for question with lr query's flags
+func remoteQueryFromFlags(question string) remoteQueryRequest {
+	return remoteQueryRequest{
+		Question:      question,
+		TopK:          topK,
+		Sources:       querySources,
+		Synthesize:    !noSynthesize,
+		NoRoute:       noRoute,
+		Deep:          deepQuery,
+		SelfCheck:     selfCheck,
+		MinSimilarity: minSimilarity,
+		ExpandImports: expandImport,
+		Refs:          queryRefs,
+		ExcludePaths:  excludePaths,
+	}
+}
+
+// printRemoteRetrieval prints what a served query searched, before its answer
+func printRemoteRetrieval(resp *remoteQueryResponse) {
+	if len(resp.Routed) > 0 {
+		fmt.Println(dim(fmt.Sprintf("routed to %d sources: %v", len(resp.Routed), resp.Routed)))
+	}
+	if len(resp.FollowUps) > 0 {
+		fmt.Println(dim(fmt.Sprintf("also searched for: %s", strings.Join(resp.FollowUps, "; "))))
+	}
+	for _, skipped := range resp.Skipped {
+		fmt.Printf("%s skipped %s\n", yellow("warning:"), skipped)
+	}
+}
+
+// printRemoteAnswer prints a served answer as a local query does; out holds
+// what was already streamed of it, if anything
+func printRemoteAnswer(question string, resp *remoteQueryResponse, out *answerPrinter) {
+	if noSynthesize {
+		printQuestion(question)
+		for i, result := range resp.Results {
+			fmt.Printf("\n%s %s (similarity: %.3f)\n%s\n", bold(fmt.Sprintf("[%d]", i+1)), cyan(result.Chunk.Source), result.Similarity, result.Chunk.Text)
+		}
+		return
+	}
+	if out != nil && out.streamed.Len() > 0 {
+		// end the streamed answer
+		fmt.Println()
+	}
+	if resp.Abstained {
+		printQuestion(question)
+		fmt.Printf("\n%s\n%s\n\n", bold("answer:"), yellow(resp.Answer))
+		return
+	}
+	if out == nil || out.streamed.String() != resp.Answer {
+		printResults(question, resp.Answer, resp.Results)
+	} else {
+		printSources(resp.Results)
+	}
+	printCitationProblems(resp.Citations)
+	printConfidence(resp.Confidence)
+	if resp.Failover != "" {
+		fmt.Println(dim(resp.Failover))
+	}
+}
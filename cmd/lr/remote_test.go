@@ -0,0 +1,27 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRemoteAuthAndErrorKinds(t *testing.T) {
+	s := &remoteServer{token: "secret"}
+	srv := httptest.NewServer(s.authorized(func(w http.ResponseWriter, _ *http.Request) {
+		writeRemoteError(w, remoteStatus(ErrNoIndex), withKind(errors.New("no vector stores found on the server"), ErrNoIndex))
+	}))
+	defer srv.Close()
+
+	t.Setenv(remoteTokenEnv, "wrong")
+	if _, err := queryRemote(srv.URL, remoteQueryRequest{Question: "q"}); !errors.Is(err, ErrAuth) {
+		t.Errorf("expected an auth error with the wrong token, got %v", err)
+	}
+
+	// the error kind survives the round trip, so exit codes match local queries
+	t.Setenv(remoteTokenEnv, "secret")
+	if _, err := queryRemote(srv.URL+"/", remoteQueryRequest{Question: "q"}); !errors.Is(err, ErrNoIndex) {
+		t.Errorf("expected a no index error, got %v", err)
+	}
+}
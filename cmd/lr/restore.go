@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// restoreAction describes what restoring one backed up index would do
+type restoreAction struct {
+	name       string   // index name
+	backupFile string   // file in the backup dir
+	current    []string // current versions that will be archived
+	unchanged  bool     // current index is byte-identical to the backup
+}
+
+// runRestore restores an update-all backup set into the index directory
+func runRestore(_ *cobra.Command, args []string) error {
+	indexDir := getDefaultIndexDir()
+
+	// without arguments, list the available backups
+	if len(args) == 0 {
+		backups, err := listBackups(indexDir)
+		if err != nil {
+			return fmt.Errorf("error searching for backups: %w", err)
+		}
+		if len(backups) == 0 {
+			fmt.Println("no backups found")
+			return nil
+		}
+		fmt.Printf("found %d backup(s):\n\n", len(backups))
+		for _, b := range backups {
+			files, _ := filepath.Glob(filepath.Join(b, "*.lrindex"))
+			fmt.Printf("  • %s (%d indexes)\n", filepath.Base(b), len(files))
+		}
+		fmt.Println("\nrun 'lr restore <backup>' to restore one")
+		return nil
+	}
+
+	backupDir := args[0]
+	if !filepath.IsAbs(backupDir) && !strings.ContainsRune(backupDir, filepath.Separator) {
+		backupDir = filepath.Join(indexDir, backupDir)
+	}
+	if info, err := os.Stat(backupDir); err != nil || !info.IsDir() {
+		return fmt.Errorf("backup not found: %s", args[0])
+	}
+
+	actions, err := planRestore(indexDir, backupDir)
+	if err != nil {
+		return err
+	}
+	if len(actions) == 0 {
+		return fmt.Errorf("backup %s contains no indexes", filepath.Base(backupDir))
+	}
+
+	fmt.Printf("=== RESTORE PLAN (%s) ===\n", filepath.Base(backupDir))
+	changes := 0
+	for _, a := range actions {
+		switch {
+		case a.unchanged:
+			fmt.Printf("  = %s: unchanged\n", a.name)
+		case len(a.current) == 0:
+			fmt.Printf("  + %s: restore %s (not currently indexed)\n", a.name, filepath.Base(a.backupFile))
+			changes++
+		default:
+			current := make([]string, len(a.current))
+			for i, c := range a.current {
+				current[i] = filepath.Base(c)
+			}
+			fmt.Printf("  ~ %s: %s → %s\n", a.name, strings.Join(current, ", "), filepath.Base(a.backupFile))
+			changes++
+		}
+	}
+
+	if changes == 0 {
+		fmt.Println("\nall indexes already match the backup - nothing to do")
+		return nil
+	}
+	if dryRun {
+		fmt.Printf("\ndry run: %d index(es) would change\n", changes)
+		return nil
+	}
+
+	// lock every index we are about to replace
+	for _, a := range actions {
+		if a.unchanged {
+			continue
+		}
+		lock, err := acquireIndexLock(indexDir, a.name, waitLock)
+		if err != nil {
+			return err
+		}
+		defer lock.Release()
+	}
+
+	restored, err := applyRestore(indexDir, actions)
+	fmt.Printf("\nrestored %d index(es)\n", restored)
+	if err != nil {
+		return err
+	}
+	fmt.Println("replaced versions were moved to the archive dir")
+	return nil
+}
+
+// planRestore validates every index in the backup and works out what restoring it changes
+func planRestore(indexDir, backupDir string) ([]restoreAction, error) {
+	files, err := filepath.Glob(filepath.Join(backupDir, "*.lrindex"))
+	if err != nil {
+		return nil, fmt.Errorf("error reading backup: %w", err)
+	}
+
+	var actions []restoreAction
+	for _, file := range files {
+		// validate before touching anything
+		vs := NewVectorStore()
+		if err := vs.Load(file); err != nil {
+			return nil, fmt.Errorf("backup file %s is corrupt: %w", filepath.Base(file), err)
+		}
+
+		name := indexNameFromFile(file)
+		versions, err := indexVersions(indexDir, name)
+		if err != nil {
+			return nil, err
+		}
+
+		action := restoreAction{name: name, backupFile: file}
+		backupHash, err := fileHash(file)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range versions {
+			if filepath.Base(v) == filepath.Base(file) {
+				if h, err := fileHash(v); err == nil && h == backupHash && len(versions) == 1 {
+					action.unchanged = true
+					continue
+				}
+			}
+			action.current = append(action.current, v)
+		}
+		actions = append(actions, action)
+	}
+	return actions, nil
+}
+
+// applyRestore archives the current versions and copies the backup files into place
+func applyRestore(indexDir string, actions []restoreAction) (int, error) {
+	archiveDir := filepath.Join(indexDir, indexArchiveDir)
+	restored := 0
+	for _, a := range actions {
+		if a.unchanged {
+			continue
+		}
+
+		// copy to a temp file first so a failure never leaves a partial index
+		target := filepath.Join(indexDir, filepath.Base(a.backupFile))
+		tempPath := strings.TrimSuffix(target, ".lrindex") + ".tmp.lrindex"
+		if err := copyFile(a.backupFile, tempPath); err != nil {
+			os.Remove(tempPath)
+			return restored, fmt.Errorf("failed to restore %s: %w", a.name, err)
+		}
+
+		for _, c := range a.current {
+			if err := os.MkdirAll(archiveDir, 0755); err != nil {
+				os.Remove(tempPath)
+				return restored, fmt.Errorf("failed to create archive directory: %w", err)
+			}
+			if err := os.Rename(c, filepath.Join(archiveDir, filepath.Base(c))); err != nil {
+				os.Remove(tempPath)
+				return restored, fmt.Errorf("failed to archive %s: %w", filepath.Base(c), err)
+			}
+		}
+
+		if err := os.Rename(tempPath, target); err != nil {
+			os.Remove(tempPath)
+			return restored, fmt.Errorf("failed to restore %s: %w", a.name, err)
+		}
+		fmt.Printf("  ✓ restored %s\n", filepath.Base(target))
+		restored++
+	}
+	return restored, nil
+}
+
+// rollbackIndex puts a single index back to its state in backupDir after a failed update.
+// originalPath is the index file that was backed up and newPath is where the update was
+// writing. returns a description of each action taken
+func rollbackIndex(backupDir, originalPath, newPath string) ([]string, error) {
+	var actions []string
+	backupFile := filepath.Join(backupDir, filepath.Base(originalPath))
+
+	// drop a new version the failed update may have written alongside the original
+	if newPath != originalPath {
+		if _, err := os.Stat(newPath); err == nil {
+			if err := os.Remove(newPath); err != nil {
+				return actions, fmt.Errorf("failed to remove partial update %s: %w", filepath.Base(newPath), err)
+			}
+			actions = append(actions, fmt.Sprintf("removed %s", filepath.Base(newPath)))
+		}
+	}
+
+	// restore the original if it is missing or differs from the backup
+	backupHash, err := fileHash(backupFile)
+	if err != nil {
+		return actions, fmt.Errorf("backup of %s unreadable: %w", filepath.Base(originalPath), err)
+	}
+	if h, err := fileHash(originalPath); err == nil && h == backupHash {
+		return actions, nil
+	}
+
+	tempPath := strings.TrimSuffix(originalPath, ".lrindex") + ".tmp.lrindex"
+	if err := copyFile(backupFile, tempPath); err != nil {
+		os.Remove(tempPath)
+		return actions, fmt.Errorf("failed to restore %s: %w", filepath.Base(originalPath), err)
+	}
+	if err := os.Rename(tempPath, originalPath); err != nil {
+		os.Remove(tempPath)
+		return actions, fmt.Errorf("failed to restore %s: %w", filepath.Base(originalPath), err)
+	}
+	actions = append(actions, fmt.Sprintf("restored %s from %s", filepath.Base(originalPath), filepath.Base(backupDir)))
+	return actions, nil
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// saveTestIndex writes an index with one chunk of text to path
+func saveTestIndex(t *testing.T, path, text string) {
+	t.Helper()
+	vs := NewVectorStore()
+	vs.Add(Chunk{Source: "a.go", Text: text}, []float64{1, 0})
+	if err := vs.Save(path); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	indexDir := t.TempDir()
+	backupDir := filepath.Join(indexDir, "backup_20250301")
+	if err := os.Mkdir(backupDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a", "b", "c", "d"} {
+		saveTestIndex(t, filepath.Join(backupDir, name+"_20250101.lrindex"), "backed up "+name)
+	}
+	saveTestIndex(t, filepath.Join(indexDir, "a_20250201.lrindex"), "updated a")
+	saveTestIndex(t, filepath.Join(indexDir, "b_20250101.lrindex"), "backed up b")
+	saveTestIndex(t, filepath.Join(indexDir, "d_20250201.lrindex"), "updated d")
+
+	actions, err := planRestore(indexDir, backupDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 4 {
+		t.Fatalf("expected an action per backed up index, got %+v", actions)
+	}
+	a, b, c := actions[0], actions[1], actions[2]
+	if a.name != "a" || a.unchanged || len(a.current) != 1 || filepath.Base(a.current[0]) != "a_20250201.lrindex" {
+		t.Errorf("a: %+v, want its updated version replaced", a)
+	}
+	if b.name != "b" || !b.unchanged || len(b.current) != 0 {
+		t.Errorf("b: %+v, want unchanged", b)
+	}
+	if c.name != "c" || c.unchanged || len(c.current) != 0 {
+		t.Errorf("c: %+v, want restored with nothing to archive", c)
+	}
+
+	// d's backup goes missing after planning: a and c are restored, d's
+	// current version is left in place
+	if err := os.Remove(actions[3].backupFile); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := applyRestore(indexDir, actions)
+	if err == nil || !strings.Contains(err.Error(), "failed to restore d") {
+		t.Fatalf("expected d's restore to fail, got %v", err)
+	}
+	if restored != 2 {
+		t.Errorf("restored %d indexes before the failure, want 2", restored)
+	}
+	for _, name := range []string{"a_20250101.lrindex", "b_20250101.lrindex", "c_20250101.lrindex", "d_20250201.lrindex", "archive/a_20250201.lrindex"} {
+		if _, err := os.Stat(filepath.Join(indexDir, name)); err != nil {
+			t.Errorf("expected %s: %v", name, err)
+		}
+	}
+	for _, name := range []string{"a_20250201.lrindex", "d_20250101.lrindex", "d_20250101.tmp.lrindex", "archive/d_20250201.lrindex"} {
+		if _, err := os.Stat(filepath.Join(indexDir, name)); err == nil {
+			t.Errorf("expected no %s", name)
+		}
+	}
+
+	// a corrupt backup is refused before anything changes
+	if err := os.WriteFile(filepath.Join(backupDir, "e_20250101.lrindex"), []byte("not an index"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := planRestore(indexDir, backupDir); err == nil || !strings.Contains(err.Error(), "corrupt") {
+		t.Errorf("expected the corrupt backup refused, got %v", err)
+	}
+}
+
+func TestRollbackIndex(t *testing.T) {
+	indexDir := t.TempDir()
+	backupDir := filepath.Join(indexDir, "backup_20250301")
+	if err := os.Mkdir(backupDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	original := filepath.Join(indexDir, "p_20250101.lrindex")
+	newPath := filepath.Join(indexDir, "p_20250201.lrindex")
+	saveTestIndex(t, original, "original")
+	if err := copyFile(original, filepath.Join(backupDir, filepath.Base(original))); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := os.ReadFile(original)
+
+	// the failed update wrote a partial new version and clobbered the original
+	if err := os.WriteFile(newPath, []byte("partial"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(original, []byte("clobbered"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	actions, err := rollbackIndex(backupDir, original, newPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actions) != 2 {
+		t.Errorf("actions = %v, want the partial version removed and the original restored", actions)
+	}
+	if _, err := os.Stat(newPath); err == nil {
+		t.Errorf("the partial update %s was left", filepath.Base(newPath))
+	}
+	if got, _ := os.ReadFile(original); string(got) != string(want) {
+		t.Errorf("the original index isn't the backed up one")
+	}
+	if vs, err := loadWithSegments(original); err != nil || vs.Chunks[0].Text != "original" {
+		t.Errorf("the restored index doesn't load: %v", err)
+	}
+
+	// an original the update didn't touch is left alone
+	actions, err = rollbackIndex(backupDir, original, newPath)
+	if err != nil || len(actions) != 0 {
+		t.Errorf("second rollback: %v, %v, want nothing to do", actions, err)
+	}
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// indexArchiveDir is the subdirectory of the index dir holding superseded index versions
+// (it is not scanned by LoadAll, so archived versions are never queried)
+const indexArchiveDir = "archive"
+
+// isDateStamp reports whether s is an 8 digit YYYYMMDD stamp
+func isDateStamp(s string) bool {
+	if len(s) != 8 {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// indexVersions returns all finished versions of the named index (name_YYYYMMDD.lrindex),
+// oldest first. unlike a plain name_* glob it won't match a different index such as name_other
+func indexVersions(indexDir, name string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(indexDir, name+"_*.lrindex"))
+	if err != nil {
+		return nil, err
+	}
+
+	var versions []string
+	for _, m := range matches {
+		if isPartialIndexFile(m) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(m), name+"_"), ".lrindex")
+		if isDateStamp(stamp) {
+			versions = append(versions, m)
+		}
+	}
+	sort.Strings(versions)
+	return versions, nil
+}
+
+// pruneSupersededIndexes removes older versions of the named index after currentPath was
+// written successfully. the newest `keep` superseded versions are moved into the archive
+// directory instead of being deleted
+func pruneSupersededIndexes(indexDir, name, currentPath string, keep int) (archived, removed []string, err error) {
+	versions, err := indexVersions(indexDir, name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	current, _ := filepath.Abs(currentPath)
+	var superseded []string
+	for _, v := range versions {
+		if abs, _ := filepath.Abs(v); abs != current {
+			superseded = append(superseded, v)
+		}
+	}
+
+	// newest first so the first `keep` entries are archived
+	sort.Sort(sort.Reverse(sort.StringSlice(superseded)))
+	archiveDir := filepath.Join(indexDir, indexArchiveDir)
+	for i, v := range superseded {
+		if i < keep {
+			if err := os.MkdirAll(archiveDir, 0755); err != nil {
+				return archived, removed, fmt.Errorf("failed to create archive directory: %w", err)
+			}
+			if err := os.Rename(v, filepath.Join(archiveDir, filepath.Base(v))); err != nil {
+				return archived, removed, fmt.Errorf("failed to archive %s: %w", filepath.Base(v), err)
+			}
+			archived = append(archived, filepath.Base(v))
+			continue
+		}
+		if err := os.Remove(v); err != nil {
+			return archived, removed, fmt.Errorf("failed to remove %s: %w", filepath.Base(v), err)
+		}
+		removed = append(removed, filepath.Base(v))
+	}
+
+	// trim the archive itself down to `keep` versions. without --keep-versions
+	// it is left alone: versions an earlier run archived were kept on purpose
+	if keep == 0 {
+		return archived, removed, nil
+	}
+	archivedVersions, err := indexVersions(archiveDir, name)
+	if err == nil && len(archivedVersions) > keep {
+		for _, v := range archivedVersions[:len(archivedVersions)-keep] {
+			if err := os.Remove(v); err == nil {
+				removed = append(removed, filepath.Join(indexArchiveDir, filepath.Base(v)))
+			}
+		}
+	}
+
+	return archived, removed, nil
+}
+
+// cleanupSupersededIndexes prunes old versions of name and reports what happened
+// failures are reported as warnings since the new index is already saved
+func cleanupSupersededIndexes(indexDir, name, currentPath string) {
+	archived, removed, err := pruneSupersededIndexes(indexDir, name, currentPath, keepVersions)
+	for _, f := range archived {
+		fmt.Printf("archived superseded index: %s\n", f)
+	}
+	for _, f := range removed {
+		fmt.Printf("removed superseded index: %s\n", f)
+	}
+	if err != nil {
+		fmt.Printf("warning: cleanup of superseded indexes failed: %v\n", err)
+	}
+}
+
+// defaultKeepBackups is the number of update-all backup directories kept by default
+const defaultKeepBackups = 5
+
+// indexNameFromFile extracts the index name from a filename (strips extension and date stamp)
+func indexNameFromFile(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), ".lrindex")
+	name = strings.TrimSuffix(name, ".json")
+	if idx := strings.LastIndex(name, "_"); idx > 0 && isDateStamp(name[idx+1:]) {
+		name = name[:idx]
+	}
+	return name
+}
+
+// listBackups returns the update-all backup directories in indexDir, oldest first
+func listBackups(indexDir string) ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join(indexDir, "backup_*"))
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for _, m := range matches {
+		if info, err := os.Stat(m); err == nil && info.IsDir() {
+			dirs = append(dirs, m)
+		}
+	}
+	// backup_YYYYMMDD_HHMMSS sorts chronologically
+	sort.Strings(dirs)
+	return dirs, nil
+}
+
+// pruneBackups deletes all but the newest `keep` backup directories
+// a keep value of zero or less disables pruning
+func pruneBackups(indexDir string, keep int) ([]string, error) {
+	if keep <= 0 {
+		return nil, nil
+	}
+	dirs, err := listBackups(indexDir)
+	if err != nil || len(dirs) <= keep {
+		return nil, err
+	}
+
+	var removed []string
+	for _, dir := range dirs[:len(dirs)-keep] {
+		if err := os.RemoveAll(dir); err != nil {
+			return removed, fmt.Errorf("failed to remove backup %s: %w", filepath.Base(dir), err)
+		}
+		removed = append(removed, filepath.Base(dir))
+	}
+	return removed, nil
+}
+
+// copyFile copies src to dst, syncing dst to disk
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// fileHash returns the sha256 of a file's contents
+func fileHash(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
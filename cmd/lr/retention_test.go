@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPruneSupersededIndexes(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{
+		"proj_20250101.lrindex",
+		"proj_20250201.lrindex",
+		"proj_20250301.lrindex",
+		"proj_other_20250101.lrindex", // different index, must survive
+	} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("x"), 0644); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+
+	current := filepath.Join(tmpDir, "proj_20250301.lrindex")
+	archived, removed, err := pruneSupersededIndexes(tmpDir, "proj", current, 1)
+	if err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+
+	if len(archived) != 1 || archived[0] != "proj_20250201.lrindex" {
+		t.Fatalf("expected newest superseded version archived, got %v", archived)
+	}
+	if len(removed) != 1 || removed[0] != "proj_20250101.lrindex" {
+		t.Fatalf("expected oldest version removed, got %v", removed)
+	}
+
+	for _, name := range []string{"proj_20250301.lrindex", "proj_other_20250101.lrindex", "archive/proj_20250201.lrindex"} {
+		if _, err := os.Stat(filepath.Join(tmpDir, name)); err != nil {
+			t.Errorf("expected %s to exist: %v", name, err)
+		}
+	}
+}
+
+func TestPruneKeepsArchiveByDefault(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"proj_20250101.lrindex", "proj_20250201.lrindex", "archive/proj_20241201.lrindex"} {
+		os.MkdirAll(filepath.Dir(filepath.Join(tmpDir, name)), 0755)
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte("x"), 0644); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+
+	// without --keep-versions the superseded version is deleted, and the one an
+	// earlier run archived stays
+	_, removed, err := pruneSupersededIndexes(tmpDir, "proj", filepath.Join(tmpDir, "proj_20250201.lrindex"), 0)
+	if err != nil {
+		t.Fatalf("prune failed: %v", err)
+	}
+	if len(removed) != 1 || removed[0] != "proj_20250101.lrindex" {
+		t.Fatalf("expected only the superseded version removed, got %v", removed)
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "archive/proj_20241201.lrindex")); err != nil {
+		t.Errorf("expected the archived version to survive: %v", err)
+	}
+}
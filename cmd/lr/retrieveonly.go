@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// runRetrieveOnly retrieves the chunks for question as answerQuestion does and
+// prints them as the chat model would be given them, with an estimate of the
+// prompt's size and cost, without asking the chat model anything
+func runRetrieveOnly(rag *RAG, question string, topK int, sources []string) error {
+	ctx := context.Background()
+	results, queryEmbedding, err := rag.Retrieve(ctx, question, topK, sources)
+	printQuestionKind(rag)
+	if len(rag.Routed) > 0 {
+		fmt.Println(dim(fmt.Sprintf("routed to %d of %d sources: %v", len(rag.Routed), len(rag.MultiSourceStore.Sources), rag.Routed)))
+	}
+	if err != nil {
+		return err
+	}
+	printSkippedSources(rag.Skipped)
+
+	printQuestion(question)
+	for i, result := range results {
+		score := fmt.Sprintf("similarity: %.3f", result.Similarity)
+		fmt.Printf("\n%s %s (%s)\n", bold(fmt.Sprintf("[%d]", i+1)), cyan(result.Chunk.Source), similarityColor(result.Similarity, score))
+		if structure := chunkStructure(result.Chunk); structure != "" {
+			fmt.Println(dim(structure))
+		}
+		if len(result.AlsoIn) > 0 {
+			fmt.Println(dim("also in: " + strings.Join(result.AlsoIn, ", ")))
+		}
+		fmt.Println(strings.TrimRight(result.Chunk.Text, "\n"))
+	}
+	if len(rag.Attachments) > 0 {
+		names := make([]string, len(rag.Attachments))
+		for i, doc := range rag.Attachments {
+			names[i] = doc.Source
+		}
+		fmt.Printf("\n%s %s\n", bold("attached:"), strings.Join(names, ", "))
+	}
+
+	// the prompt as synthesis would send it, to size it
+	messages, err := rag.Prompt(ctx, question, queryEmbedding, results)
+	if err != nil {
+		return err
+	}
+	tokens := 0
+	for _, m := range messages {
+		tokens += estimateTokens(m.Content)
+	}
+	model := currentChatModel()
+	summary := fmt.Sprintf("%d chunks, ~%d prompt tokens for %s", len(results), tokens, model)
+	if price, ok := priceOf(model); ok && price.Input > 0 {
+		summary += fmt.Sprintf(" (~$%.4f before the answer)", price.tokenCost(tokens, 0))
+	} else if ok {
+		summary += " (free)"
+	}
+	fmt.Printf("\n%s\n", dim(summary))
+	if minSimilarity > 0 {
+		if best := bestResultSimilarity(results); best < minSimilarity {
+			fmt.Println(yellow(fmt.Sprintf("the best match (%.2f) is below --min-similarity %.2f: the answer would be \"not covered\"", best, minSimilarity)))
+		}
+	}
+	fmt.Println(dim("to answer from these, run the query again without --retrieve-only; narrow them with --top-k or --sources"))
+	return nil
+}
+
+// bestResultSimilarity returns the highest similarity among results, 0 if none
+func bestResultSimilarity(results []SearchResult) float64 {
+	best := 0.0
+	for _, r := range results {
+		best = max(best, r.Similarity)
+	}
+	return best
+}
@@ -0,0 +1,969 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/cobra"
+)
+
+// ReviewSession represents an active review session
+type ReviewSession struct {
+	SessionID   string    `json:"session_id"` // unique session identifier
+	ProjectPath string    `json:"project_path"`
+	IndexPath   string    `json:"index_path"`           // full path to the review index
+	BaseIndex   string    `json:"base_index,omitempty"` // existing index the review index overlays (--use-index)
+	StartedAt   time.Time `json:"started_at"`
+}
+
+// reviewExtensions are the files review sessions index (code and docs)
+var reviewExtensions = append(slices.Clone(codeExtensions), ".md")
+
+// generateSessionID creates a unique session identifier
+func generateSessionID() string {
+	// timestamp + random hash for uniqueness
+	h := sha256.Sum256([]byte(fmt.Sprintf("%d", time.Now().UnixNano())))
+	return hex.EncodeToString(h[:])[:12]
+}
+
+// getReviewIndexName generates a unique index name for this session
+func getReviewIndexName(sessionID string, projectPath string) string {
+	base := filepath.Base(projectPath)
+	return fmt.Sprintf("review_%s_%s", base, sessionID)
+}
+
+// isOllamaRunning checks if ollama server is responding
+func isOllamaRunning() bool {
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get("http://localhost:11434/api/tags")
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+// startOllama starts ollama serve in background
+func startOllama() error {
+	if isOllamaRunning() {
+		return nil
+	}
+
+	fmt.Println("starting ollama...")
+	cmd := exec.Command("ollama", "serve")
+	cmd.Stdout = nil
+	cmd.Stderr = nil
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start ollama: %w", err)
+	}
+
+	// wait for ollama to be ready
+	for i := 0; i < 30; i++ {
+		time.Sleep(500 * time.Millisecond)
+		if isOllamaRunning() {
+			fmt.Println("ollama ready")
+			return nil
+		}
+	}
+
+	return fmt.Errorf("ollama failed to start within 15 seconds")
+}
+
+// ensureEmbeddingModel ensures the embedding model is available
+func ensureEmbeddingModel(model string) error {
+	fmt.Printf("checking embedding model: %s\n", model)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "ollama", "pull", model)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+// getReviewSessionPath returns the path to the review session file
+func getReviewSessionPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	sessionDir := filepath.Join(configDir, "lr")
+	if err := os.MkdirAll(sessionDir, 0755); err != nil {
+		return "", err
+	}
+	return filepath.Join(sessionDir, "review_session.json"), nil
+}
+
+// getReviewIndexDir returns the path for review indexes (separate from regular indexes)
+func getReviewIndexDir() (string, error) {
+	dataDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	reviewDir := filepath.Join(dataDir, ".local", "share", "lr", "review")
+	if err := os.MkdirAll(reviewDir, 0755); err != nil {
+		return "", err
+	}
+	return reviewDir, nil
+}
+
+// runReviewStart starts a review session
+func runReviewStart(_ *cobra.Command, _ []string) error {
+	// check if there's already an active session
+	existingSession, err := loadReviewSession()
+	if err == nil {
+		// check if the index file still exists (session might be stale from crash)
+		if _, statErr := os.Stat(existingSession.IndexPath); os.IsNotExist(statErr) {
+			// stale session - clean it up
+			fmt.Printf("cleaning up stale session (index missing): %s\n", existingSession.SessionID)
+			_ = clearReviewSession()
+		} else {
+			return fmt.Errorf("review session already active for: %s\nrun 'lr review stop' first", existingSession.ProjectPath)
+		}
+	}
+
+	// get current directory
+	projectPath, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %w", err)
+	}
+
+	fmt.Printf("starting review session for: %s\n\n", projectPath)
+
+	// reuse an existing index, only embedding the changes on top of it
+	if reviewUseIndex != "" {
+		return runReviewStartOverlay(projectPath)
+	}
+
+	// start ollama if not running
+	if err := startOllama(); err != nil {
+		return err
+	}
+
+	// ensure embedding model is available
+	embModel := "nomic-embed-text"
+	if err := ensureEmbeddingModel(embModel); err != nil {
+		return fmt.Errorf("failed to pull embedding model: %w", err)
+	}
+
+	// create ollama client for indexing
+	ollamaClient := NewOllamaClient(embModel)
+
+	// generate unique session ID and index path
+	sessionID := generateSessionID()
+	reviewDir, err := getReviewIndexDir()
+	if err != nil {
+		return err
+	}
+	indexName := getReviewIndexName(sessionID, projectPath)
+	indexPath := filepath.Join(reviewDir, indexName+".lrindex")
+
+	// hold the index lock for the lifetime of the session
+	lock, err := acquireIndexLock(reviewDir, indexName, waitLock)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	// load files (code + docs)
+	fmt.Printf("scanning files...\n")
+	loadResult, err := LoadFilesByExtensionsWithStatsAndSplit(projectPath, reviewExtensions, "mixed", 100*1024, false, true)
+	if err != nil {
+		return fmt.Errorf("failed to load files: %w", err)
+	}
+
+	fmt.Printf("found %d files to index\n", len(loadResult.Documents))
+	if len(loadResult.SkippedFiles) > 0 {
+		fmt.Printf("skipped %d files\n", len(loadResult.SkippedFiles))
+	}
+
+	// chunk documents
+	fmt.Println("chunking files...")
+	var chunks []Chunk
+	for _, doc := range loadResult.Documents {
+		docChunks := ChunkDocument(doc, 1000)
+		chunks = append(chunks, docChunks...)
+	}
+	fmt.Printf("created %d chunks\n", len(chunks))
+
+	// create embeddings using batch API for faster indexing
+	fmt.Println("generating embeddings with ollama (batch mode)...")
+	store := NewVectorStore()
+	store.Metadata.SourcePath = projectPath
+	store.Metadata.ReviewIndex = true
+	store.Metadata.EmbeddingModel = embModel
+
+	batchSize := max(reviewBatchSize, 1)
+	for i := 0; i < len(chunks); i += batchSize {
+		end := i + batchSize
+		if end > len(chunks) {
+			end = len(chunks)
+		}
+		batch := chunks[i:end]
+
+		// collect texts for batch embedding
+		texts := make([]string, len(batch))
+		for j, chunk := range batch {
+			texts[j] = chunk.Text
+		}
+
+		embeddings, err := ollamaClient.GetBatchEmbeddings(texts)
+		if err != nil {
+			return fmt.Errorf("failed to get embeddings for batch starting at %d: %w", i, err)
+		}
+
+		// add chunks with their embeddings
+		for j, chunk := range batch {
+			store.Add(chunk, embeddings[j])
+		}
+
+		// progress indicator
+		if showProgress() {
+			fmt.Printf("\r  embedded %d/%d chunks", end, len(chunks))
+		}
+	}
+	if showProgress() {
+		fmt.Println()
+	}
+	fmt.Printf("  embedded %d chunks\n", len(chunks))
+
+	// set metadata
+	store.Metadata.IndexedAt = time.Now().Format(time.RFC3339)
+	store.Metadata.ChunkCount = len(chunks)
+	store.Metadata.FileCount = len(loadResult.Documents)
+
+	// save index
+	if err := atomicSave(store, indexPath); err != nil {
+		return fmt.Errorf("failed to save index: %w", err)
+	}
+
+	// save session info
+	session := ReviewSession{
+		SessionID:   sessionID,
+		ProjectPath: projectPath,
+		IndexPath:   indexPath,
+		StartedAt:   time.Now(),
+	}
+	if err := saveReviewSession(&session); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+
+	fmt.Printf("\n%s\n", green(bold("review session started!")))
+	fmt.Printf("  session: %s\n", sessionID)
+	fmt.Printf("  index: %s\n", indexPath)
+	fmt.Printf("  chunks: %d\n", len(chunks))
+	fmt.Println("\nwatching for changes... (Ctrl+C to stop)")
+
+	// start watching - this blocks until interrupted
+	return startWatching(&session, store, indexPath, ollamaClient)
+}
+
+// runReviewStop stops the review session
+func runReviewStop(_ *cobra.Command, _ []string) error {
+	session, err := loadReviewSession()
+	if err != nil {
+		return fmt.Errorf("no active review session: %w", err)
+	}
+
+	// a running session holds the index lock, so this waits for (or refuses to
+	// pull the index from under) its watcher. the lock file itself is kept:
+	// removing it would let a process that has it open lock an unlinked file
+	lock, err := acquireIndexLock(filepath.Dir(session.IndexPath), indexNameFromFile(session.IndexPath), waitLock)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	// delete the index using the stored path
+	if err := os.Remove(session.IndexPath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete index: %w", err)
+	}
+	removeJournal(session.IndexPath)
+	removeSegments(session.IndexPath)
+	removeWatchStatus(session.IndexPath)
+
+	// clear session
+	if err := clearReviewSession(); err != nil {
+		return fmt.Errorf("failed to clear session: %w", err)
+	}
+
+	fmt.Printf("review session stopped (session %s)\n", session.SessionID)
+	fmt.Printf("  deleted: %s\n", session.IndexPath)
+
+	return nil
+}
+
+// runReviewStatus shows the current review session status
+func runReviewStatus(_ *cobra.Command, _ []string) error {
+	session, err := loadReviewSession()
+	if err != nil {
+		fmt.Println("no active review session")
+		return nil
+	}
+
+	fmt.Println(bold("active review session:"))
+	fmt.Printf("  session: %s\n", session.SessionID)
+	fmt.Printf("  project: %s\n", session.ProjectPath)
+	fmt.Printf("  index: %s\n", session.IndexPath)
+	if session.BaseIndex != "" {
+		fmt.Printf("  base index: %s (the review index holds only changed files)\n", session.BaseIndex)
+	}
+	fmt.Printf("  started: %s\n", session.StartedAt.Format(time.RFC3339))
+	fmt.Printf("  duration: %s\n", time.Since(session.StartedAt).Round(time.Second))
+
+	// the index as readers see it: the last full save plus its segments
+	if store, err := loadReviewIndex(session); err != nil {
+		fmt.Printf("  index: %s %v\n", red("unreadable:"), err)
+	} else {
+		fmt.Printf("  chunks: %d from %d files\n", len(store.Chunks), len(uniqueSources(store)))
+	}
+	size, segments, lastUpdate := reviewIndexFiles(session.IndexPath)
+	fmt.Printf("  size on disk: %s (%d segment(s))\n", formatBytes(size), segments)
+	if !lastUpdate.IsZero() {
+		fmt.Printf("  last update: %s (%s ago)\n", lastUpdate.Format(time.RFC3339), time.Since(lastUpdate).Round(time.Second))
+	}
+
+	// the watcher, from the status it publishes
+	watch, err := readWatchStatus(session.IndexPath)
+	if err != nil {
+		fmt.Printf("  %s %v\n", yellow("warning:"), err)
+	}
+	health, running := watcherHealth(watch, time.Now())
+	fmt.Printf("  watcher: %s\n", health)
+	if running {
+		fmt.Printf("  watching: %d directories\n", watch.WatchedDirs)
+		pending := fmt.Sprintf("%d changed file(s)", watch.Pending)
+		if watch.Updating {
+			pending += ", update in progress"
+		}
+		fmt.Printf("  pending: %s\n", pending)
+	}
+
+	// check if ollama is running
+	if isOllamaRunning() {
+		fmt.Printf("  ollama: %s\n", green("running"))
+	} else {
+		fmt.Printf("  ollama: %s\n", red("not running"))
+	}
+
+	return nil
+}
+
+// saveReviewSession saves the session to disk
+func saveReviewSession(session *ReviewSession) error {
+	sessionPath, err := getReviewSessionPath()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(sessionPath, data, 0644)
+}
+
+// loadReviewSession loads the session from disk
+func loadReviewSession() (*ReviewSession, error) {
+	sessionPath, err := getReviewSessionPath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(sessionPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var session ReviewSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// clearReviewSession removes the session file
+func clearReviewSession() error {
+	sessionPath, err := getReviewSessionPath()
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(sessionPath)
+}
+
+// runReviewWatch starts watching for file changes and updates the index (standalone command)
+func runReviewWatch(_ *cobra.Command, _ []string) error {
+	session, err := loadReviewSession()
+	if err != nil {
+		return fmt.Errorf("no active review session. run 'lr review start' first")
+	}
+
+	// only one watcher may write the review index at a time
+	lock, err := acquireIndexLock(filepath.Dir(session.IndexPath), indexNameFromFile(session.IndexPath), waitLock)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	// load existing index (and the updates a previous watcher appended to it)
+	store, err := loadWithSegments(session.IndexPath)
+	if err != nil {
+		return fmt.Errorf("failed to load index: %w", err)
+	}
+
+	// changes are embedded with the model the index was built with: ollama for
+	// a local review index, the base index's model for an overlay
+	embedder, err := reviewEmbedder(store.Metadata.EmbeddingModel)
+	if err != nil {
+		return err
+	}
+
+	fmt.Println("watching for changes... (Ctrl+C to stop)")
+	return startWatching(session, store, session.IndexPath, embedder)
+}
+
+// segmentsPerCompaction is how many updates the watcher appends as segments
+// before rewriting the review index with them
+const segmentsPerCompaction = 20
+
+// startWatching is the shared watch loop used by both start and watch commands
+func startWatching(session *ReviewSession, store *VectorStore, indexPath string, embedder Embedder) error {
+	// create watcher
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	// the project's .gitignore and .lrignore, as honored when indexing
+	rules := LoadIgnoreRules(session.ProjectPath)
+
+	// add directories recursively
+	watched := make(map[string]bool)
+	if _, err := watchTree(watcher, session.ProjectPath, session.ProjectPath, rules, watched); err != nil {
+		return fmt.Errorf("failed to walk directory: %w", err)
+	}
+
+	fmt.Printf("watching %d directories for changes...\n", len(watched))
+
+	// track extensions we care about
+	watchedExts := make(map[string]bool)
+	for _, ext := range reviewExtensions {
+		watchedExts[ext] = true
+	}
+
+	// changes are collected until none arrive for the debounce window, then
+	// processed together as one update
+	pendingChanges := make(map[string]bool)
+	takePending := func() []string {
+		files := make([]string, 0, len(pendingChanges))
+		for f := range pendingChanges {
+			files = append(files, f)
+		}
+		pendingChanges = make(map[string]bool)
+		return files
+	}
+
+	// busy is set while an update is being processed
+	busy := false
+
+	// the watcher's state is published for `lr review status`; it is
+	// informational, so failing to write it is not an error
+	status := WatchStatus{PID: os.Getpid(), StartedAt: time.Now()}
+	publishStatus := func() {
+		status.Heartbeat = time.Now()
+		status.WatchedDirs = len(watched)
+		status.Pending = len(pendingChanges)
+		status.Updating = busy
+		writeWatchStatus(indexPath, status)
+	}
+	publishStatus()
+
+	// stopping cancels an update in progress when the session ends
+	stopping, stop := context.WithCancel(context.Background())
+	defer stop()
+
+	// every file's update is journaled as soon as it is embedded, so a crash or
+	// sleep before the update reaches a segment doesn't lose it
+	journal, err := openJournal(indexPath)
+	if err != nil {
+		return err
+	}
+	defer journal.Close()
+
+	// segments appended since the index was last rewritten
+	segments := 0
+	if paths, err := segmentPaths(indexPath); err == nil {
+		segments = len(paths)
+	}
+
+	// saveUpdate appends seg to the index, folding segments into the index now
+	// and then, and empties the journal once the update is on disk
+	saveUpdate := func(seg *Segment) {
+		segments++
+		if err := appendSegment(store, indexPath, seg); err != nil {
+			// the update is applied in memory; rewriting the index persists it
+			fmt.Printf("  %s %v, rewriting the index\n", yellow("warning:"), err)
+			segments = segmentsPerCompaction
+		}
+		if segments >= segmentsPerCompaction {
+			store.Metadata.FileCount = len(uniqueSources(store))
+			if err := compactSegments(store, indexPath); err != nil {
+				fmt.Printf("  %s %v\n", red("error saving index:"), err)
+				return
+			}
+			segments = 0
+		}
+		if err := journal.Reset(); err != nil {
+			fmt.Printf("  %s %v\n", yellow("warning:"), err)
+		}
+	}
+
+	// journalUpdate records a file's finished update and adds it to seg
+	journalUpdate := func(seg *Segment, entry JournalEntry) {
+		if err := journal.Append(entry); err != nil {
+			fmt.Printf("  %s %v\n", yellow("warning:"), err)
+		}
+		seg.AddEntry(entry)
+	}
+
+	processChanges := func(files []string) {
+		fmt.Printf("\nupdating %d file(s)...\n", len(files))
+
+		// the update is written as a segment holding only the changed files; the
+		// full index is rewritten only when segments are compacted
+		seg := &Segment{}
+
+		// collect all chunks from all files for batch embedding
+		var updates []*JournalEntry
+		for _, filePath := range files {
+			relPath, _ := filepath.Rel(session.ProjectPath, filePath)
+
+			// check if file still exists
+			info, err := os.Stat(filePath)
+			if err != nil {
+				// file deleted - remove from index
+				if removed := countSourceChunks(store, relPath); removed > 0 {
+					journalUpdate(seg, JournalEntry{Source: relPath})
+					fmt.Printf("  %s %d chunks from deleted file: %s\n", yellow("removed"), removed, filepath.Base(filePath))
+				}
+				continue
+			}
+
+			// skip if too large
+			if info.Size() > 100*1024 {
+				continue
+			}
+
+			// read file content
+			content, err := os.ReadFile(filePath)
+			if err != nil {
+				continue
+			}
+
+			doc := Document{
+				Content:  string(content),
+				Source:   relPath,
+				Metadata: map[string]string{"type": "code"},
+			}
+			chunks := ChunkDocument(doc, 1000)
+			if len(chunks) == 0 {
+				// nothing left to index: the old chunks are dropped
+				journalUpdate(seg, JournalEntry{Source: relPath, ModTime: info.ModTime()})
+				continue
+			}
+			updates = append(updates, &JournalEntry{
+				Source:     relPath,
+				ModTime:    info.ModTime(),
+				Chunks:     chunks,
+				Embeddings: make([][]float64, len(chunks)),
+			})
+		}
+
+		// batch embed the chunks of all files together (--batch-size per request).
+		// a file is journaled once all its chunks are embedded; a file whose
+		// embedding fails keeps its old chunks
+		type chunkRef struct {
+			update *JournalEntry
+			i      int
+		}
+		var toEmbed []chunkRef
+		for _, u := range updates {
+			for i := range u.Chunks {
+				toEmbed = append(toEmbed, chunkRef{u, i})
+			}
+		}
+		remaining := make(map[*JournalEntry]int)
+		for _, u := range updates {
+			remaining[u] = len(u.Chunks)
+		}
+		batchSize := max(reviewBatchSize, 1)
+
+		// large updates show a progress line, cleared before each message
+		progress := showProgress() && len(toEmbed) > batchSize
+		clearProgress := func() {
+			if progress {
+				fmt.Print("\r\033[K")
+			}
+		}
+		for i := 0; i < len(toEmbed); i += batchSize {
+			if stopping.Err() != nil {
+				clearProgress()
+				return // the session is ending and the index is about to be deleted
+			}
+			end := i + batchSize
+			if end > len(toEmbed) {
+				end = len(toEmbed)
+			}
+			batch := toEmbed[i:end]
+
+			texts := make([]string, len(batch))
+			for j, ref := range batch {
+				texts[j] = ref.update.Chunks[ref.i].Text
+			}
+
+			embeddings, err := embedBatch(embedder, texts)
+			if err != nil {
+				clearProgress()
+				fmt.Printf("  %s %v\n", red("error batch embedding:"), err)
+				for _, ref := range batch {
+					remaining[ref.update] = -1
+				}
+				continue
+			}
+
+			for j, ref := range batch {
+				if remaining[ref.update] < 0 {
+					continue
+				}
+				ref.update.Embeddings[ref.i] = embeddings[j]
+				if remaining[ref.update]--; remaining[ref.update] == 0 {
+					journalUpdate(seg, *ref.update)
+					clearProgress()
+					fmt.Printf("  %s %s (%d chunks)\n", green("updated:"), filepath.Base(ref.update.Source), len(ref.update.Chunks))
+				}
+			}
+			if progress {
+				fmt.Printf("\r  embedded %d/%d chunks", end, len(toEmbed))
+			}
+		}
+		clearProgress()
+		for _, u := range updates {
+			if remaining[u] != 0 {
+				fmt.Printf("  %s %s, will retry on its next change\n", yellow("not updated:"), u.Source)
+			}
+		}
+
+		if len(seg.Sources) > 0 {
+			saveUpdate(seg)
+		}
+	}
+
+	// updates journaled by a watcher that stopped before saving them are
+	// replayed, then files changed while no watcher was running are indexed.
+	// the changed files are listed first, while the index still dates from the
+	// last save, and a replayed file is skipped unless it changed again
+	changed := filesModifiedSince(session.ProjectPath, store, watchedExts)
+	entries, err := readJournal(indexPath)
+	if err != nil {
+		fmt.Printf("%s %v, ignoring it\n", yellow("warning:"), err)
+		entries = nil
+	}
+	replayed := make(map[string]time.Time)
+	if len(entries) > 0 {
+		seg := &Segment{}
+		for _, entry := range entries {
+			seg.AddEntry(entry)
+			replayed[entry.Source] = entry.ModTime
+		}
+		fmt.Printf("recovered %d file update(s) from the journal\n", len(entries))
+		saveUpdate(seg)
+	} else if err := journal.Reset(); err != nil {
+		fmt.Printf("%s %v\n", yellow("warning:"), err)
+	}
+	for _, f := range changed {
+		relPath, _ := filepath.Rel(session.ProjectPath, f)
+		if modTime, ok := replayed[relPath]; ok {
+			if info, err := os.Stat(f); err == nil && info.ModTime().Equal(modTime) {
+				continue
+			}
+		}
+		pendingChanges[f] = true
+	}
+	if len(pendingChanges) > 0 {
+		fmt.Printf("catching up on %d file(s) changed since the last update\n", len(pendingChanges))
+		busy = true
+		publishStatus()
+		processChanges(takePending())
+		busy = false
+		publishStatus()
+	}
+
+	// updates run on their own goroutine so the event loop keeps collecting
+	// changes while a large update embeds; changes that arrive meanwhile are
+	// processed as the next update
+	work := make(chan []string)
+	done := make(chan struct{})
+	go func() {
+		for files := range work {
+			processChanges(files)
+			done <- struct{}{}
+		}
+	}()
+	defer close(work)
+	dispatch := func() {
+		if busy || len(pendingChanges) == 0 {
+			return
+		}
+		busy = true
+		work <- takePending()
+	}
+	var debounceTimer *time.Timer
+	var debounced <-chan time.Time
+	heartbeat := time.NewTicker(watchHeartbeat)
+	defer heartbeat.Stop()
+
+	// handle signals for graceful shutdown (Ctrl+C, Ctrl+Z, kill)
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGTSTP)
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			// a directory moved or deleted away is no longer watched
+			if event.Op&(fsnotify.Remove|fsnotify.Rename) != 0 && watched[event.Name] {
+				unwatchTree(watcher, event.Name, watched)
+				continue
+			}
+
+			// only care about write/create events
+			if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+
+			// edited ignore rules apply from the next event on
+			if filepath.Dir(event.Name) == session.ProjectPath {
+				if base := filepath.Base(event.Name); base == ".gitignore" || base == ".lrignore" {
+					rules = LoadIgnoreRules(session.ProjectPath)
+					fmt.Printf("reloaded ignore rules from %s\n", base)
+					continue
+				}
+			}
+
+			// a new directory (e.g. a new package) is watched too, and files
+			// written into it before the watch was in place are picked up
+			changed := []string{event.Name}
+			if event.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					files, err := watchTree(watcher, session.ProjectPath, event.Name, rules, watched)
+					if err != nil {
+						fmt.Printf("%s failed to watch %s: %v\n", yellow("warning:"), event.Name, err)
+					}
+					changed = files
+				}
+			}
+
+			queued := false
+			for _, name := range changed {
+				// check extension
+				ext := strings.ToLower(filepath.Ext(name))
+				if !watchedExts[ext] {
+					continue
+				}
+
+				// skip excluded and ignored files
+				if ShouldExcludeFile(name) {
+					continue
+				}
+				if relPath, err := filepath.Rel(session.ProjectPath, name); err == nil && rules.Ignored(relPath) {
+					continue
+				}
+
+				// add to pending changes
+				pendingChanges[name] = true
+				queued = true
+			}
+			if !queued {
+				continue
+			}
+
+			// reset debounce timer
+			if debounceTimer != nil {
+				debounceTimer.Stop()
+			}
+			debounceTimer = time.NewTimer(reviewDebounce)
+			debounced = debounceTimer.C
+			publishStatus()
+
+		case <-debounced:
+			debounced = nil
+			dispatch()
+			publishStatus()
+
+		case <-done:
+			busy = false
+			if debounced == nil {
+				dispatch() // changes that settled while the last update ran
+			}
+			publishStatus()
+
+		case <-heartbeat.C:
+			publishStatus()
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			fmt.Printf("%s %v\n", red("watcher error:"), err)
+
+		case <-sigChan:
+			fmt.Println("\nstopping review session...")
+			// the index is deleted, so pending changes are dropped and an update
+			// in progress is stopped before it writes again
+			if debounceTimer != nil {
+				debounceTimer.Stop()
+			}
+			stop()
+			if busy {
+				<-done
+			}
+			// clean up: delete index and clear session
+			if err := os.Remove(indexPath); err != nil && !os.IsNotExist(err) {
+				fmt.Printf("%s failed to delete index: %v\n", yellow("warning:"), err)
+			}
+			journal.Close()
+			removeJournal(indexPath)
+			removeSegments(indexPath)
+			removeWatchStatus(indexPath)
+			if err := clearReviewSession(); err != nil {
+				fmt.Printf("%s failed to clear session: %v\n", yellow("warning:"), err)
+			}
+			fmt.Printf("session stopped, index deleted\n")
+			return nil
+		}
+	}
+}
+
+// skipWatchDir reports whether a directory is never watched (dependencies,
+// version control and build output)
+func skipWatchDir(name string) bool {
+	switch name {
+	case "node_modules", ".git", "vendor", "dist", "build", ".next":
+		return true
+	}
+	return false
+}
+
+// watchTree adds root and its subdirectories to the watcher, recording them in
+// watched, and returns the files already in them. directories the project's
+// ignore rules exclude as a whole are left out
+func watchTree(watcher *fsnotify.Watcher, projectPath, root string, rules *IgnoreRules, watched map[string]bool) ([]string, error) {
+	var files []string
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil // skip errors
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			return nil
+		}
+		// skip common non-code directories and ignored ones (e.g. .venv, coverage)
+		if skipWatchDir(info.Name()) {
+			return filepath.SkipDir
+		}
+		if relPath, err := filepath.Rel(projectPath, path); err == nil && rules.SkipDir(relPath) {
+			return filepath.SkipDir
+		}
+		if err := watcher.Add(path); err == nil {
+			watched[path] = true
+		}
+		return nil
+	})
+	return files, err
+}
+
+// unwatchTree stops watching root and the directories under it
+func unwatchTree(watcher *fsnotify.Watcher, root string, watched map[string]bool) {
+	for dir := range watched {
+		if dir == root || strings.HasPrefix(dir, root+string(filepath.Separator)) {
+			watcher.Remove(dir) // already gone if the directory was deleted
+			delete(watched, dir)
+		}
+	}
+}
+
+// countSourceChunks returns how many chunks of the file source the store holds
+func countSourceChunks(store *VectorStore, source string) int {
+	n := 0
+	for _, chunk := range store.Chunks {
+		if chunk.Source == source {
+			n++
+		}
+	}
+	return n
+}
+
+// uniqueSources returns the set of files the store has chunks of
+func uniqueSources(store *VectorStore) map[string]bool {
+	sources := make(map[string]bool)
+	for _, chunk := range store.Chunks {
+		sources[chunk.Source] = true
+	}
+	return sources
+}
+
+// filesModifiedSince returns absolute paths of watched files changed after the store was last saved,
+// plus indexed files that no longer exist
+func filesModifiedSince(projectPath string, store *VectorStore, watchedExts map[string]bool) []string {
+	indexedAt, err := time.Parse(time.RFC3339, store.Metadata.IndexedAt)
+	if err != nil {
+		return nil
+	}
+
+	indexed := uniqueSources(store)
+	indexedFiles := make([]string, 0, len(indexed))
+	for f := range indexed {
+		indexedFiles = append(indexedFiles, f)
+	}
+
+	extensions := make([]string, 0, len(watchedExts))
+	for ext := range watchedExts {
+		extensions = append(extensions, ext)
+	}
+
+	cs, err := detectChangesMtime(projectPath, indexedAt, indexedFiles, extensions)
+	if err != nil {
+		return nil
+	}
+
+	var files []string
+	for _, f := range cs.Modified {
+		files = append(files, filepath.Join(projectPath, f))
+	}
+	for _, f := range cs.Deleted {
+		files = append(files, filepath.Join(projectPath, f))
+	}
+	return files
+}
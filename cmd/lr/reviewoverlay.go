@@ -0,0 +1,274 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// a review session started with --use-index reuses an existing index for
+// context instead of embedding the whole project with ollama. its review index
+// is an overlay holding only the files changed since the base index was built,
+// embedded with the base index's model; readers see the base index with those
+// files replaced (loadReviewIndex)
+
+// batchEmbedder is implemented by clients that embed several texts per request
+type batchEmbedder interface {
+	GetBatchEmbeddings(texts []string) ([][]float64, error)
+}
+
+// embedBatch embeds texts in one request when the client supports it, one at a
+// time otherwise
+func embedBatch(embedder Embedder, texts []string) ([][]float64, error) {
+	if b, ok := embedder.(batchEmbedder); ok {
+		return b.GetBatchEmbeddings(texts)
+	}
+	embeddings := make([][]float64, len(texts))
+	for i, text := range texts {
+		embedding, err := embedder.GetEmbedding(text)
+		if err != nil {
+			return nil, err
+		}
+		embeddings[i] = embedding
+	}
+	return embeddings, nil
+}
+
+// reviewEmbedder returns the client that embeds changes for a review index
+// built with model, starting ollama when the model is local
+func reviewEmbedder(model string) (Embedder, error) {
+	if model == "" {
+		model = "nomic-embed-text" // review indexes from before the model was recorded
+	}
+	embedder, err := embedderForModel(model)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := embedder.(*OllamaClient); ok {
+		if err := startOllama(); err != nil {
+			return nil, err
+		}
+	}
+	return embedder, nil
+}
+
+// baseIndexPath returns the newest version of the named index a review session
+// overlays
+func baseIndexPath(name string) (string, error) {
+	versions, err := indexVersions(getDefaultIndexDir(), name)
+	if err != nil {
+		return "", err
+	}
+	if len(versions) == 0 {
+		return "", withKind(fmt.Errorf("no index named %s (see 'lr list')", name), ErrNoIndex)
+	}
+	return versions[len(versions)-1], nil
+}
+
+// runReviewStartOverlay starts a review session on top of the existing index
+// named by --use-index
+func runReviewStartOverlay(projectPath string) error {
+	basePath, err := baseIndexPath(reviewUseIndex)
+	if err != nil {
+		return err
+	}
+	base := NewVectorStore()
+	if err := base.Load(basePath); err != nil {
+		return fmt.Errorf("failed to load %s: %w", reviewUseIndex, err)
+	}
+	model := inferEmbeddingModel(base)
+	if model == "" {
+		return fmt.Errorf("can't tell which embedding model %s was built with; re-index it first", reviewUseIndex)
+	}
+	if base.Metadata.SourcePath != "" && base.Metadata.SourcePath != projectPath {
+		fmt.Printf("%s %s was indexed from %s, not this directory\n", yellow("warning:"), reviewUseIndex, base.Metadata.SourcePath)
+	}
+	fmt.Printf("using index %s (%d chunks, %s)\n", reviewUseIndex, len(base.Chunks), model)
+
+	embedder, err := reviewEmbedder(model)
+	if err != nil {
+		return err
+	}
+
+	sessionID := generateSessionID()
+	reviewDir, err := getReviewIndexDir()
+	if err != nil {
+		return err
+	}
+	indexName := getReviewIndexName(sessionID, projectPath)
+	indexPath := filepath.Join(reviewDir, indexName+".lrindex")
+
+	// hold the index lock for the lifetime of the session
+	lock, err := acquireIndexLock(reviewDir, indexName, waitLock)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	// the overlay starts with the files changed since the base index was built
+	changed, err := changedSinceIndex(projectPath, base.Metadata.LastCommit)
+	if err != nil {
+		return err
+	}
+	rules := LoadIgnoreRules(projectPath)
+	var chunks []Chunk
+	files := 0
+	for _, relPath := range changed {
+		if !hasReviewExtension(relPath) || ShouldExcludeFile(relPath) || rules.Ignored(relPath) {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(projectPath, relPath))
+		if err != nil || len(content) > 100*1024 {
+			continue // deleted files are hidden from the base index when it is read
+		}
+		doc := Document{
+			Content:  string(content),
+			Source:   relPath,
+			Metadata: map[string]string{"type": "code"},
+		}
+		chunks = append(chunks, ChunkDocument(doc, 1000)...)
+		files++
+	}
+	fmt.Printf("indexing %d changed file(s) (%d chunks)\n", files, len(chunks))
+
+	overlay := NewVectorStore()
+	overlay.Metadata.SourcePath = projectPath
+	overlay.Metadata.ReviewIndex = true
+	overlay.Metadata.EmbeddingModel = model
+	batchSize := max(reviewBatchSize, 1)
+	for i := 0; i < len(chunks); i += batchSize {
+		end := min(i+batchSize, len(chunks))
+		batch := chunks[i:end]
+		texts := make([]string, len(batch))
+		for j, chunk := range batch {
+			texts[j] = chunk.Text
+		}
+		embeddings, err := embedBatch(embedder, texts)
+		if err != nil {
+			return fmt.Errorf("failed to get embeddings for batch starting at %d: %w", i, err)
+		}
+		for j, chunk := range batch {
+			overlay.Add(chunk, embeddings[j])
+		}
+	}
+	overlay.Metadata.IndexedAt = time.Now().Format(time.RFC3339)
+	overlay.Metadata.ChunkCount = len(chunks)
+	overlay.Metadata.FileCount = files
+	if err := atomicSave(overlay, indexPath); err != nil {
+		return fmt.Errorf("failed to save index: %w", err)
+	}
+
+	session := ReviewSession{
+		SessionID:   sessionID,
+		ProjectPath: projectPath,
+		IndexPath:   indexPath,
+		BaseIndex:   reviewUseIndex,
+		StartedAt:   time.Now(),
+	}
+	if err := saveReviewSession(&session); err != nil {
+		return fmt.Errorf("failed to save session: %w", err)
+	}
+
+	fmt.Printf("\n%s\n", green(bold("review session started!")))
+	fmt.Printf("  session: %s\n", sessionID)
+	fmt.Printf("  base index: %s\n", reviewUseIndex)
+	fmt.Printf("  overlay: %s (%d chunks)\n", indexPath, len(chunks))
+	fmt.Println("\nwatching for changes... (Ctrl+C to stop)")
+
+	return startWatching(&session, overlay, indexPath, embedder)
+}
+
+// changedSinceIndex lists the files in projectPath, relative to it, that differ
+// from the commit the base index was built at (HEAD if unknown): committed,
+// staged and unstaged changes, and untracked files
+func changedSinceIndex(projectPath, commit string) ([]string, error) {
+	ctx := context.Background()
+	git := func(args ...string) ([]byte, error) {
+		return exec.CommandContext(ctx, "git", append([]string{"-C", projectPath}, args...)...).Output()
+	}
+
+	rev := "HEAD"
+	if commit != "" {
+		if _, err := git("rev-parse", "--verify", "--quiet", commit+"^{commit}"); err == nil {
+			rev = commit
+		}
+	}
+	diff, err := git("diff", "--name-only", "--relative", "-z", rev)
+	if err != nil {
+		// no commits yet: every tracked file is new
+		if diff, err = git("ls-files", "-z"); err != nil {
+			return nil, fmt.Errorf("failed to list changed files (is %s a git repository?): %w", projectPath, err)
+		}
+	}
+	untracked, err := git("ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list untracked files: %w", err)
+	}
+
+	var files []string
+	seen := make(map[string]bool)
+	for _, name := range bytes.Split(append(diff, untracked...), []byte{0}) {
+		if f := string(name); f != "" && !seen[f] {
+			seen[f] = true
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// hasReviewExtension reports whether a file is one review sessions index
+func hasReviewExtension(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range reviewExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// loadReviewIndex loads the session's review index as readers see it. for a
+// session overlaying an existing index that is the base index, minus files
+// the overlay replaces and files deleted from the project, plus the overlay
+func loadReviewIndex(session *ReviewSession) (*VectorStore, error) {
+	overlay, err := loadWithSegments(session.IndexPath)
+	if err != nil || session.BaseIndex == "" {
+		return overlay, err
+	}
+	basePath, err := baseIndexPath(session.BaseIndex)
+	if err != nil {
+		return nil, err
+	}
+	base := NewVectorStore()
+	if err := base.Load(basePath); err != nil {
+		return nil, fmt.Errorf("failed to load %s: %w", session.BaseIndex, err)
+	}
+
+	merged := NewVectorStore()
+	merged.Metadata = overlay.Metadata
+	replaced := uniqueSources(overlay)
+	exists := make(map[string]bool)
+	for i, chunk := range base.Chunks {
+		if replaced[chunk.Source] {
+			continue
+		}
+		present, ok := exists[chunk.Source]
+		if !ok {
+			_, err := os.Stat(filepath.Join(session.ProjectPath, chunk.Source))
+			present = err == nil
+			exists[chunk.Source] = present
+		}
+		if present {
+			merged.Add(chunk, base.Embeddings[i])
+		}
+	}
+	for i, chunk := range overlay.Chunks {
+		merged.Add(chunk, overlay.Embeddings[i])
+	}
+	return merged, nil
+}
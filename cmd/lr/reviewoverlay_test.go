@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadReviewIndexOverlay(t *testing.T) {
+	dataHome := t.TempDir()
+	t.Setenv("XDG_DATA_HOME", dataHome)
+	project := t.TempDir()
+	for _, name := range []string{"a.go", "b.go"} {
+		os.WriteFile(filepath.Join(project, name), []byte("package p"), 0644)
+	}
+
+	// the base index has a.go, b.go and c.go, which was deleted since
+	base := NewVectorStore()
+	base.Add(Chunk{Text: "a old", Source: "a.go"}, []float64{1, 0})
+	base.Add(Chunk{Text: "b", Source: "b.go"}, []float64{0, 1})
+	base.Add(Chunk{Text: "c", Source: "c.go"}, []float64{1, 1})
+	if err := atomicSave(base, filepath.Join(getDefaultIndexDir(), "proj_20260101.lrindex")); err != nil {
+		t.Fatal(err)
+	}
+
+	// the overlay replaces a.go
+	overlay := NewVectorStore()
+	overlay.Add(Chunk{Text: "a new", Source: "a.go"}, []float64{1, 0})
+	overlayPath := filepath.Join(t.TempDir(), "review.lrindex")
+	if err := atomicSave(overlay, overlayPath); err != nil {
+		t.Fatal(err)
+	}
+
+	merged, err := loadReviewIndex(&ReviewSession{ProjectPath: project, IndexPath: overlayPath, BaseIndex: "proj"})
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	var texts []string
+	for _, chunk := range merged.Chunks {
+		texts = append(texts, chunk.Text)
+	}
+	if len(texts) != 2 || texts[0] != "b" || texts[1] != "a new" {
+		t.Fatalf("expected the base index with a.go replaced and c.go dropped, got %v", texts)
+	}
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var reviewRunCmd = &cobra.Command{
+	Use:   "run [focus]",
+	Short: "Review your changes with the chat model, optionally with lint/test output",
+	Long: `Have the chat model review the same diff and indexed context as the
+get_diff_context MCP tool. compiler, lint and test output can be added with
+--with-cmd (the command is run in the project directory) or --with-output (a
+file, or - for stdin); code the output refers to (file:line) is looked up in
+the review index, so the review can point at the likely cause of a failure.
+
+  lr review run --with-cmd "go test ./..."
+  lr review run --with-cmd "go vet ./..." --with-cmd "golangci-lint run"
+  go test ./... 2>&1 | lr review run --with-output -
+  lr review run "is the new retry logic safe?"
+  lr review run --security
+
+with --security the review looks for vulnerabilities instead, favoring auth,
+crypto, input-parsing and query code in the context, and tags each finding
+with a severity (critical, high, medium, low or info).
+
+Requires an active review session (lr review start).`,
+	RunE: runReviewRun,
+}
+
+// maxCheckOutput bounds the command output included in the review prompt
+const maxCheckOutput = 16000
+
+// outputFilePattern matches file:line references in compiler, lint and test output
+var outputFilePattern = regexp.MustCompile(`([\w./-]+\.[A-Za-z]\w*):\d+`)
+
+// checkOutput is the output of a lint or test command added to the review
+type checkOutput struct {
+	label    string // the command, or the file the output was read from
+	output   string
+	exitCode int // -1 if unknown (output read from a file)
+}
+
+// status describes how the command finished
+func (c checkOutput) status() string {
+	switch c.exitCode {
+	case -1:
+		return "output"
+	case 0:
+		return "passed"
+	}
+	return fmt.Sprintf("failed, exit status %d", c.exitCode)
+}
+
+// runCheckCmd runs command in dir through the shell. a failing command is
+// expected (that's why its output is wanted), so only failing to start it is
+// an error
+func runCheckCmd(ctx context.Context, dir, command string) (checkOutput, error) {
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	check := checkOutput{label: "$ " + command, output: string(out)}
+	var exitErr *exec.ExitError
+	switch {
+	case errors.As(err, &exitErr):
+		check.exitCode = exitErr.ExitCode()
+	case err != nil:
+		return check, fmt.Errorf("failed to run %q: %w", command, err)
+	}
+	return check, nil
+}
+
+// readCheckOutput reads command output saved to path, or from stdin if path is -
+func readCheckOutput(path string) (checkOutput, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return checkOutput{}, fmt.Errorf("failed to read command output: %w", err)
+	}
+	label := path
+	if path == "-" {
+		label = "stdin"
+	}
+	return checkOutput{label: label, output: string(data), exitCode: -1}, nil
+}
+
+// truncateOutput keeps the start and end of long output, where compiler errors
+// and test summaries are
+func truncateOutput(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	half := max / 2
+	return fmt.Sprintf("%s\n... (%d bytes omitted) ...\n%s", s[:half], len(s)-2*half, s[len(s)-half:])
+}
+
+// referencedFiles returns the files the command output refers to, in order,
+// leaving out those already in skip
+func referencedFiles(checks []checkOutput, skip []string) []string {
+	seen := make(map[string]bool)
+	for _, f := range skip {
+		seen[f] = true
+	}
+	var files []string
+	for _, c := range checks {
+		for _, m := range outputFilePattern.FindAllStringSubmatch(c.output, -1) {
+			file := strings.TrimPrefix(m[1], "./")
+			if !seen[file] {
+				seen[file] = true
+				files = append(files, file)
+			}
+		}
+	}
+	return files
+}
+
+// checksContext formats the command output and the indexed code it refers to
+func checksContext(checks []checkOutput, store *VectorStore, changedFiles []string, topK int) string {
+	response := "=== COMMAND OUTPUT ===\n\n"
+	for _, c := range checks {
+		response += fmt.Sprintf("--- %s (%s) ---\n%s\n\n", c.label, c.status(), truncateOutput(strings.TrimSpace(c.output), maxCheckOutput))
+	}
+
+	var referenced string
+	for _, file := range referencedFiles(checks, changedFiles) {
+		referenced += fileContext(store, file, topK)
+	}
+	if referenced != "" {
+		response += "=== CODE REFERENCED BY THE OUTPUT ===\n\n" + referenced
+	}
+	return response
+}
+
+func runReviewRun(_ *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	session, err := loadReviewSession()
+	if err != nil {
+		return withKind(fmt.Errorf("no active review session. run 'lr review start' first"), ErrNoIndex)
+	}
+
+	// collect the command output first, so a command that can't run fails fast
+	var checks []checkOutput
+	for _, command := range reviewWithCmds {
+		fmt.Println(dim("running: " + command))
+		check, err := runCheckCmd(ctx, session.ProjectPath, command)
+		if err != nil {
+			return err
+		}
+		checks = append(checks, check)
+	}
+	for _, path := range reviewWithOutputs {
+		check, err := readCheckOutput(path)
+		if err != nil {
+			return err
+		}
+		checks = append(checks, check)
+	}
+
+	diff, emptyMsg, err := reviewDiff(ctx, session.ProjectPath, askDiffUncommitted)
+	if err != nil {
+		return err
+	}
+	// without changes there is still something to review if a check failed
+	if emptyMsg != "" && len(checks) == 0 {
+		fmt.Println(emptyMsg)
+		return nil
+	}
+
+	store, err := loadReviewIndex(session)
+	if err != nil {
+		return fmt.Errorf("failed to load review index: %w", err)
+	}
+	changedFiles := extractChangedFiles(diff)
+
+	llm, err := getLLMClient()
+	if err != nil {
+		return err
+	}
+
+	systemPrompt := `you are an experienced code reviewer.
+you are given a git diff followed by relevant code from the project it belongs to,
+and possibly the output of compiler, lint or test commands run on it.
+review the change: point out bugs, risky or unclear code and missing tests, citing files and line changes.
+if the project's conventions are included, also point out where the change departs from them, naming the rule.
+if the command output shows errors or failing tests, explain the likely cause first,
+pointing at the change or the indexed code responsible, and suggest a fix.
+say so if the context is not enough to tell.`
+
+	prompt := diffWithContext(ctx, diff, store, changedFiles, topK)
+	if reviewSecurity {
+		systemPrompt = securityReviewPrompt
+		prompt = securityDiffContext(diff, store, changedFiles, topK)
+	}
+	if emptyMsg != "" {
+		prompt = "=== GIT DIFF ===\n\n" + emptyMsg + "\n\n" + conventionsContext(store, "", nil, topK)
+	}
+	if len(checks) > 0 {
+		prompt += checksContext(checks, store, changedFiles, topK)
+	}
+	focus := "review this change"
+	if reviewSecurity {
+		focus = "review this change for security issues"
+	}
+	if len(args) > 0 {
+		focus = strings.Join(args, " ")
+	}
+
+	messages := []Message{
+		{Role: "system", Content: systemPrompt},
+		{Role: "user", Content: fmt.Sprintf("%s\n\nquestion: %s", prompt, focus)},
+	}
+
+	var failover FailoverResult
+	answer, err := chatContext(withFailoverResult(ctx, &failover), llm, messages)
+	if err != nil {
+		return fmt.Errorf("failed to get chat response: %w", err)
+	}
+
+	if askDiffShowDiff {
+		fmt.Println(colorDiff(diff))
+	}
+	fmt.Println("\n" + dim(strings.Repeat("=", 80)))
+	fmt.Printf("%s %s\n", bold("review:"), focus)
+	fmt.Println(dim(strings.Repeat("=", 80)))
+	fmt.Printf("\n%s\n", answer)
+	if reviewSecurity {
+		fmt.Printf("\n%s %s\n", bold("findings:"), findingsSummary(countFindings(answer)))
+	}
+	printFailoverNote(&failover)
+	if len(changedFiles) > 0 {
+		fmt.Println("\n" + bold("changed files:"))
+		for _, f := range changedFiles {
+			fmt.Printf("  %s\n", cyan(f))
+		}
+	}
+	if len(checks) > 0 {
+		fmt.Println("\n" + bold("command output:"))
+		for _, c := range checks {
+			status := c.status()
+			if c.exitCode > 0 {
+				status = red(status)
+			}
+			fmt.Printf("  %s (%s)\n", c.label, status)
+		}
+	}
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// securityReviewPrompt replaces the review system prompt with --security
+const securityReviewPrompt = `you are an application security engineer reviewing a change before it is merged.
+you are given a git diff, the code around it and security-sensitive code related to it
+(authentication, authorization, cryptography, input parsing, queries and command execution),
+and possibly the output of compiler, lint or test commands run on it.
+look for vulnerabilities the change introduces or leaves in place: injection (sql, command, path),
+broken authentication or authorization, secrets in code or logs, weak or misused cryptography,
+unvalidated or unbounded input, unsafe deserialization, race conditions and resource exhaustion.
+report each finding on its own line as:
+[severity] file:line - the problem - how to fix it
+where severity is one of critical, high, medium, low or info.
+order findings by severity and only report what the diff and context support.
+if there are no findings, say so and name what you checked.`
+
+// severities in the order findings are reported
+var severities = []string{"critical", "high", "medium", "low", "info"}
+
+var (
+	// securityPattern matches code that handles authentication, authorization,
+	// cryptography, input parsing, queries or command execution
+	securityPattern = regexp.MustCompile(`(?i)\b(auth\w*|login\w*|passw\w*|secret\w*|token\w*|jwt|session\w*|cookie\w*|csrf|permission\w*|privilege\w*|crypt\w*|cipher\w*|encrypt\w*|decrypt\w*|hmac|sha\d*|md5|bcrypt|scrypt|argon2|tls|x509|cert\w*|nonce|signature\w*|sql\w*|query\w*|exec\w*|command\w*|unmarshal\w*|deserializ\w*|parse\w*|decode\w*|sanitiz\w*|escape\w*|validat\w*|redirect\w*|upload\w*)\b`)
+
+	// identifierPattern matches identifiers worth following from the diff
+	identifierPattern = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]{3,}`)
+
+	// findingPattern matches a severity-tagged finding at the start of a line
+	findingPattern = regexp.MustCompile(`(?im)^[\s*>-]*\**\[(critical|high|medium|low|info)\]`)
+)
+
+// commonWords are identifiers too common to relate code to a change
+var commonWords = map[string]bool{
+	"func": true, "return": true, "package": true, "import": true, "const": true,
+	"type": true, "struct": true, "interface": true, "string": true, "error": true,
+	"range": true, "else": true, "true": true, "false": true, "defer": true,
+	"self": true, "this": true, "null": true, "none": true, "void": true,
+	"public": true, "private": true, "static": true, "class": true,
+	"from": true, "with": true, "async": true, "await": true, "throw": true,
+	"switch": true, "case": true, "default": true, "break": true, "continue": true,
+	"context": true, "bool": true, "int64": true, "float64": true, "byte": true,
+	"make": true, "append": true,
+}
+
+// securityScore counts the distinct security-sensitive terms in text
+func securityScore(text string) int {
+	terms := make(map[string]bool)
+	for _, m := range securityPattern.FindAllString(text, -1) {
+		terms[strings.ToLower(m)] = true
+	}
+	return len(terms)
+}
+
+// rankBySecurity orders chunks by their security score, keeping index order
+// among equals
+func rankBySecurity(chunks []Chunk) []Chunk {
+	ranked := append([]Chunk(nil), chunks...)
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return securityScore(ranked[i].Text) > securityScore(ranked[j].Text)
+	})
+	return ranked
+}
+
+// diffIdentifiers returns the identifiers in the hunks of a diff: the changed
+// lines, the context around them and the hunk headers, which name the
+// enclosing function
+func diffIdentifiers(diff string) map[string]bool {
+	idents := make(map[string]bool)
+	for _, line := range strings.Split(diff, "\n") {
+		var code string
+		switch {
+		case strings.HasPrefix(line, "+++") || strings.HasPrefix(line, "---"):
+			continue
+		case strings.HasPrefix(line, "@@"):
+			// @@ -12,6 +12,8 @@ func ParseToken(raw string) (string, error) {
+			if i := strings.Index(line[2:], "@@"); i >= 0 {
+				code = line[i+4:]
+			}
+		case strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-") || strings.HasPrefix(line, " "):
+			code = line[1:]
+		default:
+			continue
+		}
+		for _, ident := range identifierPattern.FindAllString(code, -1) {
+			if !commonWords[strings.ToLower(ident)] {
+				idents[ident] = true
+			}
+		}
+	}
+	return idents
+}
+
+// securityDiffContext is diffWithContext for a security review: the chunks of
+// each changed file are ranked by how security-sensitive they are, and
+// security-sensitive code elsewhere that shares identifiers with the change is
+// added, e.g. the handler that calls a changed parser
+func securityDiffContext(diff string, store *VectorStore, changedFiles []string, topK int) string {
+	response := "=== GIT DIFF ===\n\n" + diff + "\n\n"
+	response += "=== RELEVANT CONTEXT ===\n\n"
+	for _, file := range changedFiles {
+		response += formatFileContext(file, rankBySecurity(fileChunks(store, file)), topK)
+	}
+
+	related := relatedSecurityChunks(store, diffIdentifiers(diff), changedFiles, topK)
+	if len(related) > 0 {
+		response += "=== SECURITY-SENSITIVE CODE RELATED TO THE CHANGE ===\n\n"
+		for _, chunk := range related {
+			response += fmt.Sprintf("--- context from %s ---\n%s\n\n", chunk.Source, chunk.Text)
+		}
+	}
+	response += conventionsContext(store, diff, changedFiles, topK)
+	return response
+}
+
+// relatedSecurityChunks returns up to limit security-sensitive chunks outside the
+// changed files that use identifiers from the change, most related first
+func relatedSecurityChunks(store *VectorStore, idents map[string]bool, changedFiles []string, limit int) []Chunk {
+	type candidate struct {
+		chunk Chunk
+		score int
+	}
+	var candidates []candidate
+	for _, chunk := range store.Chunks {
+		if inFiles(chunk.Source, changedFiles) {
+			continue
+		}
+		sensitive := securityScore(chunk.Text)
+		if sensitive == 0 {
+			continue
+		}
+		shared := 0
+		for _, ident := range identifierPattern.FindAllString(chunk.Text, -1) {
+			if idents[ident] {
+				shared++
+			}
+		}
+		if shared == 0 {
+			continue
+		}
+		candidates = append(candidates, candidate{chunk, shared + sensitive})
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+
+	var chunks []Chunk
+	for i := 0; i < len(candidates) && i < limit; i++ {
+		chunks = append(chunks, candidates[i].chunk)
+	}
+	return chunks
+}
+
+// inFiles reports whether an indexed source is one of files
+func inFiles(source string, files []string) bool {
+	for _, f := range files {
+		if strings.Contains(source, f) {
+			return true
+		}
+	}
+	return false
+}
+
+// countFindings counts the severity-tagged findings of a security review
+func countFindings(review string) map[string]int {
+	counts := make(map[string]int)
+	for _, m := range findingPattern.FindAllStringSubmatch(review, -1) {
+		counts[strings.ToLower(m[1])]++
+	}
+	return counts
+}
+
+// findingsSummary renders finding counts, most severe first, e.g. "1 high, 2 low"
+func findingsSummary(counts map[string]int) string {
+	var parts []string
+	for _, s := range severities {
+		if n := counts[s]; n > 0 {
+			part := fmt.Sprintf("%d %s", n, s)
+			switch s {
+			case "critical", "high":
+				part = red(part)
+			case "medium":
+				part = yellow(part)
+			}
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return green("none")
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,39 @@
+package main
+
+import "testing"
+
+func TestCountFindings(t *testing.T) {
+	review := `findings:
+- [high] auth.go:12 - token compared with == - use subtle.ConstantTimeCompare
+**[high]** db.go:40 - query built with fmt.Sprintf - use placeholders
+[Low] log.go:7 - request logged with headers - redact authorization
+the [medium] tag mid-sentence isn't a finding`
+
+	counts := countFindings(review)
+	if counts["high"] != 2 || counts["low"] != 1 || counts["medium"] != 0 {
+		t.Errorf("unexpected counts: %v", counts)
+	}
+}
+
+func TestDiffIdentifiers(t *testing.T) {
+	diff := `--- a/parse.go
++++ b/parse.go
+@@ -1,4 +1,5 @@ func ParseToken(raw string) (string, error) {
+ 	trimmed := strings.TrimSpace(raw)
+-	return trimmed, nil
++	if trimmed == "" {
++		return "", ErrEmptyToken
++	}`
+
+	idents := diffIdentifiers(diff)
+	for _, want := range []string{"ParseToken", "trimmed", "TrimSpace", "ErrEmptyToken"} {
+		if !idents[want] {
+			t.Errorf("expected %s in %v", want, idents)
+		}
+	}
+	for _, common := range []string{"return", "string", "error", "parse"} {
+		if idents[common] {
+			t.Errorf("did not expect %s", common)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// default safety caps for a single indexing run
+const (
+	defaultMaxFiles     = 10000
+	defaultMaxTotalSize = 50 * 1024 * 1024 // 50MB of source text
+	defaultMaxCost      = 1.00             // dollars of embedding cost
+)
+
+// IndexLimits holds the safety caps checked before any embeddings are generated
+type IndexLimits struct {
+	MaxFiles     int
+	MaxTotalSize int64
+	MaxCost      float64
+}
+
+// IndexScanSummary describes the work an indexing run is about to do
+type IndexScanSummary struct {
+	Files     int
+	TotalSize int64
+	Chunks    int
+	Cost      float64 // estimated embedding cost, 0 if unknown
+}
+
+// Violations returns a human readable entry for every cap the summary exceeds
+// a zero or negative cap disables that check
+func (l IndexLimits) Violations(s IndexScanSummary) []string {
+	var violations []string
+	if l.MaxFiles > 0 && s.Files > l.MaxFiles {
+		violations = append(violations, fmt.Sprintf("%d files exceeds --max-files %d", s.Files, l.MaxFiles))
+	}
+	if l.MaxTotalSize > 0 && s.TotalSize > l.MaxTotalSize {
+		violations = append(violations, fmt.Sprintf("%s of source exceeds --max-total-size %s",
+			formatBytes(s.TotalSize), formatBytes(l.MaxTotalSize)))
+	}
+	if l.MaxCost > 0 && s.Cost > l.MaxCost {
+		violations = append(violations, fmt.Sprintf("estimated cost $%.2f exceeds --max-cost $%.2f", s.Cost, l.MaxCost))
+	}
+	return violations
+}
+
+// summarizeDocuments builds a scan summary for the loaded documents and their chunks
+func summarizeDocuments(docs []Document, numChunks int) IndexScanSummary {
+	var total int64
+	for _, doc := range docs {
+		total += int64(len(doc.Content))
+	}
+	cost, _ := estimateEmbeddingCost(numChunks)
+	return IndexScanSummary{
+		Files:     len(docs),
+		TotalSize: total,
+		Chunks:    numChunks,
+		Cost:      cost,
+	}
+}
+
+// printLimitViolations prints the safety cap summary shown by dry-run and on abort
+func printLimitViolations(s IndexScanSummary, violations []string) {
+	fmt.Println("\n=== SAFETY CAPS ===")
+	fmt.Printf("files: %d, size: %s, chunks: %d, estimated cost: $%.4f\n",
+		s.Files, formatBytes(s.TotalSize), s.Chunks, s.Cost)
+	if len(violations) == 0 {
+		fmt.Println("within limits")
+		return
+	}
+	for _, v := range violations {
+		fmt.Printf("  ✗ %s\n", v)
+	}
+}
+
+// formatBytes renders a byte count using the largest sensible unit
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%cB", float64(n)/float64(div), "KMGT"[exp])
+}
+
+// parseByteSize parses a size like 512MB, 4GB or 1.5G (units of 1024, as
+// formatBytes prints them), or a plain number of bytes
+func parseByteSize(s string) (int64, error) {
+	number := strings.TrimSuffix(strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(s)), "B"), "I")
+	scale := 1.0
+	if i := strings.IndexAny(number, "KMGT"); i >= 0 && i == len(number)-1 {
+		scale = math.Pow(1024, float64(strings.IndexByte("KMGT", number[i])+1))
+		number = number[:i]
+	}
+	n, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q (use e.g. 512MB or 4GB)", s)
+	}
+	return int64(n * scale), nil
+}
+
+// estimateEmbeddingCost returns the estimated cost in dollars of embedding
+// numChunks chunks, and the embedding model priced. the model is empty when the
+// price is unknown (no api keys configured, or a plugin or unlisted model)
+func estimateEmbeddingCost(numChunks int) (float64, string) {
+	model := getCurrentEmbeddingModel()
+	price, ok := priceOf(model)
+	if !ok {
+		return 0, ""
+	}
+	return price.tokenCost(numChunks*avgTokensPerChunk, 0), model
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SavedQuery is a named question that can be replayed with lr query --run
+type SavedQuery struct {
+	Question string   `json:"question"`
+	Sources  []string `json:"sources,omitempty"`
+	TopK     int      `json:"top_k,omitempty"`
+}
+
+// savedQueriesPath returns the file holding saved queries. it lives in the
+// config dir so it can be checked into dotfiles or shared with a team
+func savedQueriesPath() string {
+	return filepath.Join(getConfigDir(), "queries.json")
+}
+
+// loadSavedQueries reads the saved queries file; a missing file is an empty set
+func loadSavedQueries(path string) (map[string]SavedQuery, error) {
+	queries := make(map[string]SavedQuery)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return queries, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read saved queries: %w", err)
+	}
+	if err := json.Unmarshal(data, &queries); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return queries, nil
+}
+
+// saveQuery adds or replaces a named query in the saved queries file
+func saveQuery(path, name string, q SavedQuery) error {
+	if name == "" || strings.ContainsAny(name, " \t\n") {
+		return fmt.Errorf("invalid query name %q (use letters, digits, - or _)", name)
+	}
+	queries, err := loadSavedQueries(path)
+	if err != nil {
+		return err
+	}
+	queries[name] = q
+
+	data, err := json.MarshalIndent(queries, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ensureDir(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("failed to create config dir: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write saved queries: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// lookupSavedQuery returns the named query, listing the available names if it doesn't exist
+func lookupSavedQuery(path, name string) (SavedQuery, error) {
+	queries, err := loadSavedQueries(path)
+	if err != nil {
+		return SavedQuery{}, err
+	}
+	q, ok := queries[name]
+	if !ok {
+		if len(queries) == 0 {
+			return SavedQuery{}, fmt.Errorf("no saved query named %s (none saved yet - use lr query --save <name> \"question\")", name)
+		}
+		names := make([]string, 0, len(queries))
+		for n := range queries {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return SavedQuery{}, fmt.Errorf("no saved query named %s (available: %s)", name, strings.Join(names, ", "))
+	}
+	return q, nil
+}
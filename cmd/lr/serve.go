@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+
+	"lr/api/lrpb"
+
+	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// defaultServeAddr keeps the grpc api local to this machine
+const defaultServeAddr = "127.0.0.1:7766"
+
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve the grpc api for programmatic access",
+	Long: `Start a gRPC server exposing query, search, list and index operations
+(see api/lr.proto). Indexes are read from disk on each request, so updates
+are picked up without a restart.
+
+With --remote it serves an authenticated http api instead, which
+lr query --remote uses, so a team can query the indexes of one machine:
+
+  LR_REMOTE_TOKEN=<secret> lr serve --remote --addr 0.0.0.0:7766 --tls-cert cert.pem --tls-key key.pem
+  LR_REMOTE_TOKEN=<secret> lr query --remote https://host:7766 "how are retries configured?"`,
+	Args: cobra.NoArgs,
+	RunE: runServe,
+}
+
+// grpcServer implements the LocalRag grpc service
+type grpcServer struct {
+	lrpb.UnimplementedLocalRagServer
+
+	llmOnce sync.Once
+	llm     LLMClient
+	llmErr  error
+}
+
+// client returns the llm client, created on first use
+func (s *grpcServer) client() (LLMClient, error) {
+	s.llmOnce.Do(func() {
+		s.llm, s.llmErr = getLLMClient()
+	})
+	return s.llm, s.llmErr
+}
+
+// grpcError maps lr error kinds to grpc status codes
+func grpcError(err error) error {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, ErrNoIndex):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, ErrAuth):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, ErrRateLimited):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, ErrIndexBusy):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, ErrCorruptIndex):
+		return status.Error(codes.DataLoss, err.Error())
+	case errors.Is(err, ErrDimensionMismatch):
+		return status.Error(codes.FailedPrecondition, dimensionAdvice(err).Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// retrieve loads the indexes and finds the chunks most similar to question
+func (s *grpcServer) retrieve(ctx context.Context, question string, topK int32, sources []string) (*RAG, []SearchResult, []float64, error) {
+	if strings.TrimSpace(question) == "" {
+		return nil, nil, nil, status.Error(codes.InvalidArgument, "question is required")
+	}
+	mss, err := storesFor(sources...)
+	if err != nil {
+		return nil, nil, nil, grpcError(err)
+	}
+	if len(mss.Sources) == 0 {
+		return nil, nil, nil, grpcError(withKind(fmt.Errorf("no vector stores found. run 'lr index' to index repositories first"), ErrNoIndex))
+	}
+
+	llm, err := s.client()
+	if err != nil {
+		return nil, nil, nil, grpcError(err)
+	}
+
+	rag := NewRAGMultiSource(mss, llm)
+	results, queryEmbedding, err := rag.Retrieve(ctx, question, int(topK), sources)
+	if err != nil {
+		return nil, nil, nil, grpcError(err)
+	}
+	logRetrieval("grpc", question, results)
+	return rag, results, queryEmbedding, nil
+}
+
+func (s *grpcServer) Query(req *lrpb.QueryRequest, stream lrpb.LocalRag_QueryServer) error {
+	ctx := stream.Context()
+	defer func() {
+		if llm, _ := s.client(); llm != nil {
+			flushUsageFor(llm, "grpc", strings.Join(req.Sources, ","))
+		}
+	}()
+
+	if err := stream.Send(&lrpb.QueryEvent{Event: &lrpb.QueryEvent_Status{Status: "retrieving"}}); err != nil {
+		return err
+	}
+	rag, results, queryEmbedding, err := s.retrieve(ctx, req.Question, req.TopK, req.Sources)
+	if err != nil {
+		return err
+	}
+	sources := &lrpb.Sources{Results: toProtoResults(results)}
+	if err := stream.Send(&lrpb.QueryEvent{Event: &lrpb.QueryEvent_Sources{Sources: sources}}); err != nil {
+		return err
+	}
+
+	if err := stream.Send(&lrpb.QueryEvent{Event: &lrpb.QueryEvent_Status{Status: "synthesizing"}}); err != nil {
+		return err
+	}
+	var failover FailoverResult
+	answer, err := rag.Synthesize(withFailoverResult(ctx, &failover), req.Question, queryEmbedding, results)
+	if err != nil {
+		return grpcError(err)
+	}
+	if note := failoverNote(&failover); note != "" {
+		if err := stream.Send(&lrpb.QueryEvent{Event: &lrpb.QueryEvent_Status{Status: note}}); err != nil {
+			return err
+		}
+	}
+	return stream.Send(&lrpb.QueryEvent{Event: &lrpb.QueryEvent_Answer{Answer: answer}})
+}
+
+func (s *grpcServer) Search(ctx context.Context, req *lrpb.SearchRequest) (*lrpb.SearchResponse, error) {
+	defer func() {
+		if llm, _ := s.client(); llm != nil {
+			flushUsageFor(llm, "grpc", strings.Join(req.Sources, ","))
+		}
+	}()
+
+	_, results, _, err := s.retrieve(ctx, req.Query, req.TopK, req.Sources)
+	if err != nil {
+		return nil, err
+	}
+	return &lrpb.SearchResponse{Results: toProtoResults(results)}, nil
+}
+
+func (s *grpcServer) ListIndexes(_ context.Context, _ *lrpb.ListIndexesRequest) (*lrpb.ListIndexesResponse, error) {
+	files, err := listIndexFiles(getDefaultIndexDir())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	currentModel := getCurrentEmbeddingModel()
+	resp := &lrpb.ListIndexesResponse{}
+	for _, file := range files {
+		info := describeIndex(file, currentModel)
+		resp.Indexes = append(resp.Indexes, &lrpb.IndexInfo{
+			Name:           info.Name,
+			File:           info.File,
+			Path:           info.Path,
+			SourcePath:     info.SourcePath,
+			IndexedAt:      info.IndexedAt,
+			Chunks:         int32(info.Chunks),
+			FilesIndexed:   int32(info.FilesIndexed),
+			EmbeddingModel: info.EmbeddingModel,
+			Dimensions:     int32(info.Dimensions),
+			SizeBytes:      info.SizeBytes,
+			Format:         info.Format,
+			LastCommit:     info.LastCommit,
+			Stale:          info.Stale,
+			ChangedFiles:   int32(info.ChangedFiles),
+			Error:          info.Error,
+		})
+	}
+	return resp, nil
+}
+
+// Index runs lr index in a child process (so it takes the usual index lock and
+// safety caps) and streams its output
+func (s *grpcServer) Index(req *lrpb.IndexRequest, stream lrpb.LocalRag_IndexServer) error {
+	if req.Src == "" || req.Name == "" {
+		return status.Error(codes.InvalidArgument, "src and name are required")
+	}
+
+	execPath, err := os.Executable()
+	if err != nil {
+		return grpcError(err)
+	}
+	args := []string{"index", "--src", req.Src, "--out-name", req.Name, "--wait", "--no-progress", "--no-color"}
+	if req.Update {
+		args = append(args, "--update")
+	}
+	if req.Force {
+		args = append(args, "--force")
+	}
+	if embeddingModel != "" {
+		args = append(args, "--embedding-model", embeddingModel)
+	}
+	if mockLLM {
+		args = append(args, "--mock-llm")
+	}
+
+	cmd := exec.CommandContext(stream.Context(), execPath, args...)
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return grpcError(err)
+	}
+	cmd.Stderr = cmd.Stdout
+	if err := cmd.Start(); err != nil {
+		return grpcError(err)
+	}
+
+	var lastLine string
+	scanner := bufio.NewScanner(out)
+	for scanner.Scan() {
+		lastLine = scanner.Text()
+		if err := stream.Send(&lrpb.IndexEvent{Event: &lrpb.IndexEvent_Log{Log: lastLine}}); err != nil {
+			cmd.Process.Kill()
+			cmd.Wait()
+			return err
+		}
+	}
+
+	result := &lrpb.IndexResult{}
+	if err := cmd.Wait(); err != nil {
+		result.ExitCode = exitGeneric
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			result.ExitCode = int32(exitErr.ExitCode())
+		}
+		result.Error = lastLine
+	}
+	return stream.Send(&lrpb.IndexEvent{Event: &lrpb.IndexEvent_Result{Result: result}})
+}
+
+// toProtoResults converts search results to their grpc form
+func toProtoResults(results []SearchResult) []*lrpb.SearchResult {
+	out := make([]*lrpb.SearchResult, 0, len(results))
+	for _, r := range results {
+		out = append(out, &lrpb.SearchResult{
+			Source:     r.Chunk.Source,
+			Index:      r.Chunk.Metadata["vector_source"],
+			Similarity: r.Similarity,
+			Text:       r.Chunk.Text,
+			Metadata:   r.Chunk.Metadata,
+			AlsoIn:     r.AlsoIn,
+		})
+	}
+	return out
+}
+
+func runServe(_ *cobra.Command, _ []string) error {
+	if serveRemote {
+		return runRemoteServe()
+	}
+	lis, err := net.Listen("tcp", serveAddr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", serveAddr, err)
+	}
+
+	srv := grpc.NewServer()
+	lrpb.RegisterLocalRagServer(srv, &grpcServer{})
+
+	// stop gracefully on Ctrl+C / kill
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-sigChan
+		fmt.Println("\nstopping grpc server...")
+		srv.GracefulStop()
+	}()
+
+	fmt.Printf("lr grpc api listening on %s\n", lis.Addr())
+	return srv.Serve(lis)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"sync"
+
+	"lr/pkg/rag"
+)
+
+// sourceEmbedders caches the embedders of sourceEmbedder, one per model
+var (
+	sourceEmbedders   = make(map[string]Embedder)
+	sourceEmbeddersMu sync.Mutex
+)
+
+// sourceEmbedder returns the embedder for indexes built with model, so a
+// question can be searched in them alongside indexes built with another one
+func sourceEmbedder(model string) (Embedder, error) {
+	sourceEmbeddersMu.Lock()
+	defer sourceEmbeddersMu.Unlock()
+	if e, ok := sourceEmbedders[model]; ok {
+		return e, nil
+	}
+	e, err := embedderForModel(model)
+	if err != nil {
+		return nil, err
+	}
+	meter := newUsageMeter(embedderClient{e}, model, "")
+	sourceEmbedders[model] = meter
+	return meter, nil
+}
+
+// NewRAGMultiSource creates a rag over mss that embeds the question with
+// client, and with the embedding model of each source built with another
+func NewRAGMultiSource(mss *MultiSourceStore, client LLMClient) *RAG {
+	r := rag.NewRAGMultiSource(mss, client)
+	r.EmbeddingModel = getCurrentEmbeddingModel()
+	r.Embedder = sourceEmbedder
+	return r
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// server lifetime, for server_status. lastLoadAt and loads are guarded by preloadMutex
+var (
+	serverStartedAt time.Time
+	lastLoadAt      time.Time
+	loads           int
+)
+
+func handleServerStatus(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	preloadMutex.RLock()
+	mss, llm, loadedAt, loadCount, progress := preloadedMSS, preloadedLLM, lastLoadAt, loads, preload
+	preloadMutex.RUnlock()
+
+	var warnings []string
+	response := fmt.Sprintf("pid: %d\n", os.Getpid())
+	if !serverStartedAt.IsZero() {
+		response += fmt.Sprintf("uptime: %s (started %s)\n", time.Since(serverStartedAt).Round(time.Second), serverStartedAt.Format(time.RFC3339))
+	}
+
+	// preload state
+	switch {
+	case noPreload:
+		response += "preload: off (--no-preload), indexes are read from disk when they change\n"
+		stats := onDemandCache.Stats()
+		response += fmt.Sprintf("index cache: %d cached, %d hits, %d reads, %d shared reads, %d evicted",
+			stats.Entries, stats.Hits, stats.Loads, stats.Shared, stats.Evictions)
+		if onDemandCache.TTL > 0 {
+			response += fmt.Sprintf(" (ttl %s)", onDemandCache.TTL)
+		}
+		response += "\n"
+	case mss == nil && progress.running:
+		response += fmt.Sprintf("preload: loading in background, %d of %d indexes ready (%s); tools load the rest on demand\n",
+			progress.ready, progress.total, time.Since(progress.startedAt).Round(time.Second))
+		warnings = append(warnings, progress.failed...)
+	case mss == nil && progress.err != nil:
+		response += "preload: failed, indexes are read from disk on each request\n"
+		warnings = append(warnings, progress.err.Error())
+	case mss == nil:
+		response += "preload: starting\n"
+	default:
+		response += fmt.Sprintf("preload: on, indexes loaded %s ago in %s", time.Since(loadedAt).Round(time.Second),
+			progress.finishedAt.Sub(progress.startedAt).Round(time.Millisecond))
+		if loadCount > 1 {
+			response += fmt.Sprintf(" (reloaded %d times)", loadCount-1)
+		}
+		response += fmt.Sprintf("; to pick up new indexes: lr mcp --reload %d\n", os.Getpid())
+		switch {
+		case progress.running:
+			response += fmt.Sprintf("reload: in progress, %d of %d indexes read\n", progress.ready, progress.total)
+		case progress.err != nil:
+			response += "reload: failed, still serving the previous indexes\n"
+			warnings = append(warnings, progress.err.Error())
+		}
+	}
+	response += fmt.Sprintf("index dir: %s\n", getDefaultIndexDir())
+	if mcpToolTimeout > 0 {
+		response += fmt.Sprintf("tool timeout: %s\n", mcpToolTimeout)
+	}
+
+	// model configuration
+	chat := resolveChatModel(chatModel)
+	embedding := getCurrentEmbeddingModel()
+	response += "\nmodels:\n"
+	response += fmt.Sprintf("  chat: %s\n", chat)
+	if len(fallbackModels) > 0 {
+		response += fmt.Sprintf("  fallbacks: %s\n", strings.Join(fallbackModels, ", "))
+	}
+	if embedding == "" {
+		response += "  embeddings: none available\n"
+		warnings = append(warnings, "no embedding model is configured (set VOYAGE_API_KEY or OPENAI_API_KEY, or use --embedding-model)")
+	} else {
+		response += fmt.Sprintf("  embeddings: %s\n", embedding)
+	}
+	if !noPreload {
+		state := "preloaded"
+		if llm == nil {
+			state = "not created"
+		}
+		response += fmt.Sprintf("  model client: %s\n", state)
+	}
+	warmup, err := formatWarmup()
+	response += warmup
+	if err != nil {
+		warnings = append(warnings, err.Error())
+	}
+
+	// loaded sources. while a preload is running they are only counted above, so a
+	// status check doesn't wait on the load it reports
+	if mss == nil && (noPreload || (!progress.running && progress.err != nil)) {
+		var err error
+		if mss, err = currentStores(); err != nil {
+			warnings = append(warnings, fmt.Sprintf("failed to load indexes: %v", err))
+		}
+	}
+	if mss != nil {
+		names := mss.ListSources()
+		sort.Strings(names)
+		total := 0
+		var lines string
+		for _, name := range names {
+			vs := mss.Sources[name]
+			total += len(vs.Chunks)
+			lines += fmt.Sprintf("  • %s: %d chunks", name, len(vs.Chunks))
+			if len(vs.Chunks) == 0 {
+				warnings = append(warnings, fmt.Sprintf("%s has no chunks", name))
+			}
+			if dims := vs.Dimensions(); dims > 0 {
+				lines += fmt.Sprintf(", %d dims", dims)
+			}
+			if model := vs.Metadata.EmbeddingModel; model != "" {
+				lines += ", " + model
+				if embedding != "" && model != embedding {
+					lines += " (differs from the query model)"
+					warnings = append(warnings, fmt.Sprintf("%s was indexed with %s but queries embed with %s", name, model, embedding))
+				}
+			}
+			if vs.Metadata.IndexedAt != "" {
+				lines += ", indexed " + vs.Metadata.IndexedAt
+			}
+			lines += "\n"
+		}
+		response += fmt.Sprintf("\nsources (%d, %d chunks):\n%s", len(names), total, lines)
+		if len(names) == 0 {
+			warnings = append(warnings, "no indexes found - run 'lr index' first")
+		}
+	}
+
+	// memory
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	response += "\nmemory:\n"
+	response += fmt.Sprintf("  heap in use: %s\n", formatBytes(int64(mem.HeapInuse)))
+	response += fmt.Sprintf("  from os: %s\n", formatBytes(int64(mem.Sys)))
+	response += fmt.Sprintf("  goroutines: %d\n", runtime.NumGoroutine())
+	response += formatMemoryUsage()
+
+	status := "status: healthy\n\n"
+	if len(warnings) > 0 {
+		status = fmt.Sprintf("status: %d warning(s)\n", len(warnings))
+		for _, w := range warnings {
+			status += "  - " + w + "\n"
+		}
+		status += "\n"
+	}
+	return mcp.NewToolResultText(status + response), nil
+}
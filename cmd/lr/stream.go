@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// answerPrinter prints the question header, then the answer as it is generated
+type answerPrinter struct {
+	question string
+	streamed strings.Builder
+}
+
+// token prints the next piece of a streamed answer
+func (p *answerPrinter) token(s string) {
+	if p.streamed.Len() == 0 {
+		printQuestion(p.question)
+		fmt.Printf("\n%s\n", bold("answer:"))
+	}
+	p.streamed.WriteString(s)
+	fmt.Print(s)
+}
+
+// printQuestionKind notes how many chunks the kind of question picked, when it
+// differs from a general question's
+func printQuestionKind(rag *RAG) {
+	switch rag.QuestionKind {
+	case questionSymbol:
+		fmt.Println(dim(fmt.Sprintf("symbol lookup: retrieving %d chunks, those naming it first (set --top-k to choose)", rag.QuestionKind.TopK())))
+	case questionArchitecture:
+		fmt.Println(dim(fmt.Sprintf("architecture question: retrieving %d chunks from different files (set --top-k to choose)", rag.QuestionKind.TopK())))
+	}
+}
+
+// answerQuestion retrieves the chunks for question and synthesizes an answer from
+// them, streaming it to stdout as it is generated (unless --no-stream), then
+// prints the sources, and the model that answered after a failover. answers from
+// clients that can't stream are printed whole.
+// it returns the answer and the results it was based on
+func answerQuestion(rag *RAG, question string, topK int, sources []string) (string, []SearchResult, error) {
+	ctx := context.Background()
+	var out *answerPrinter
+	// the grounding guard checks the answer before it's shown, so it can't stream
+	if !noStream && rag.MinSimilarity == 0 {
+		out = &answerPrinter{question: question}
+		ctx = withTokenHandler(ctx, out.token)
+	}
+
+	results, queryEmbedding, err := rag.RetrieveDeep(ctx, question, topK, sources, rag.Deep)
+	printQuestionKind(rag)
+	if len(rag.Routed) > 0 {
+		fmt.Println(dim(fmt.Sprintf("routed to %d of %d sources: %v", len(rag.Routed), len(rag.MultiSourceStore.Sources), rag.Routed)))
+	}
+	if len(rag.FollowUps) > 0 {
+		fmt.Println(dim(fmt.Sprintf("also searched for: %s", strings.Join(rag.FollowUps, "; "))))
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	printSkippedSources(rag.Skipped)
+
+	var failover FailoverResult
+	answer, err := rag.Synthesize(withFailoverResult(ctx, &failover), question, queryEmbedding, results)
+	if out != nil && out.streamed.Len() > 0 {
+		// end the partial or complete streamed answer
+		fmt.Println()
+	}
+	if err != nil {
+		return "", nil, err
+	}
+
+	// the guard's reply lists the nearest matches itself
+	if rag.Abstained {
+		printQuestion(question)
+		fmt.Printf("\n%s\n%s\n\n", bold("answer:"), yellow(answer))
+		return answer, results, nil
+	}
+
+	// a failover retry or a client that doesn't stream: print the answer it returned
+	if out == nil || out.streamed.String() != answer {
+		printResults(question, answer, results)
+	} else {
+		printSources(results)
+	}
+	printCitationProblems(rag.Citations)
+	printConfidence(rag.Confidence)
+	printFailoverNote(&failover)
+	return answer, results, nil
+}
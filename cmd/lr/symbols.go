@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// review context is targeted at what a change touches: the hunks of the diff
+// are mapped to the chunks (functions, types, sections) whose lines they
+// change, and the code elsewhere that references those symbols is added
+
+// symbolChunk is a chunk of a changed file that a hunk changes
+type symbolChunk struct {
+	chunk      Chunk
+	name       string // the function, type or heading it defines, if recognized
+	start, end int
+}
+
+// symbolPattern matches the definition a chunk starts with: go, js/ts,
+// python, java, rust, c++ and c# functions, methods and types, templ and
+// react components, sql tables, views and functions, and markdown headings
+var symbolPattern = regexp.MustCompile(`(?m)^\s*(?:func\s+(?:\([^)]*\)\s*)?|(?:export\s+)?(?:async\s+)?function\s*\*?\s*|def\s+|templ\s+(?:\([^)]*\)\s*)?|(?:pub(?:\([^)]*\))?\s+)?(?:(?:async|unsafe|const)\s+)*(?:fn|struct|enum|trait|mod|impl(?:<[^>]*>)?)\s+|namespace\s+|(?i:create\s+(?:or\s+replace\s+)?(?:unique\s+)?(?:table|view|index|function|procedure|trigger)\s+(?:if\s+not\s+exists\s+)?)|(?:export\s+(?:default\s+)?)?const\s+|(?:export\s+)?(?:abstract\s+)?class\s+|interface\s+|type\s+|(?:public|private|protected)\s+(?:static\s+)?(?:[\w<>\[\],]+\s+)?|#+\s+)([A-Za-z_]\w*)`)
+
+// chunkSymbol returns the name of the first definition in a chunk, or ""
+func chunkSymbol(text string) string {
+	if m := symbolPattern.FindStringSubmatch(text); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// changedSymbols returns the chunks of file that hunks change, in file order.
+// line ranges come from the chunk metadata; chunks indexed before it was
+// recorded are located in the file on disk
+func changedSymbols(store *VectorStore, file string, hunks []diffHunk) []symbolChunk {
+	var fileHunks []diffHunk
+	for _, h := range hunks {
+		if h.file == file {
+			fileHunks = append(fileHunks, h)
+		}
+	}
+	if len(fileHunks) == 0 {
+		return nil
+	}
+
+	var content *string // read on first use
+	var symbols []symbolChunk
+	for _, c := range fileChunksOf(store, file) {
+		start, end, ok := chunkLineRange(c)
+		if !ok {
+			if content == nil {
+				data, _ := os.ReadFile(filepath.Join(store.Metadata.SourcePath, file))
+				text := string(data)
+				content = &text
+			}
+			if start, end, ok = locateLines(*content, c.Text); !ok {
+				continue
+			}
+		}
+		for _, h := range fileHunks {
+			if h.start <= end && start <= h.end {
+				symbols = append(symbols, symbolChunk{c, chunkSymbol(c.Text), start, end})
+				break
+			}
+		}
+	}
+	return symbols
+}
+
+// locateLines returns the lines text spans in content
+func locateLines(content, text string) (start, end int, ok bool) {
+	text = strings.TrimSpace(text)
+	i := strings.Index(content, text)
+	if text == "" || i < 0 {
+		return 0, 0, false
+	}
+	start = 1 + strings.Count(content[:i], "\n")
+	return start, start + strings.Count(text, "\n"), true
+}
+
+// formatSymbolContext formats up to topK changed chunks of file for a review prompt
+func formatSymbolContext(file string, symbols []symbolChunk, topK int) string {
+	response := ""
+	for i, s := range symbols {
+		if i >= topK {
+			break
+		}
+		what := ""
+		if s.name != "" {
+			what = ": " + s.name
+		}
+		response += fmt.Sprintf("--- context from %s%s (lines %d-%d, changed) ---\n%s\n\n", file, what, s.start, s.end, s.chunk.Text)
+	}
+	return response
+}
+
+// referencesContext returns the review prompt section with up to topK chunks
+// per changed symbol that mention it, e.g. the callers of a changed function,
+// or "" if there are none. chunks already shown are left out, and shown is
+// updated with the ones added
+func referencesContext(store *VectorStore, symbols []symbolChunk, shown map[string]bool, topK int) string {
+	response := ""
+	seen := make(map[string]bool)
+	for _, s := range symbols {
+		// short names (e.g. "x", "ok"), entry points and doc headings match too much
+		if len(s.name) < 3 || s.name == "main" || s.name == "init" || strings.HasSuffix(s.chunk.Source, ".md") || seen[s.name] {
+			continue
+		}
+		seen[s.name] = true
+		word := regexp.MustCompile(`\b` + regexp.QuoteMeta(s.name) + `\b`)
+		found := 0
+		for _, c := range store.Chunks {
+			if found >= topK {
+				break
+			}
+			key := chunkKey(c)
+			if shown[key] || isConventionFile(c.Source) || !word.MatchString(c.Text) {
+				continue
+			}
+			shown[key] = true
+			found++
+			response += fmt.Sprintf("--- %s referenced in %s ---\n%s\n\n", s.name, c.Source, c.Text)
+		}
+	}
+	if response == "" {
+		return ""
+	}
+	return "=== REFERENCES TO CHANGED SYMBOLS ===\n\n" + response
+}
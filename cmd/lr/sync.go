@@ -0,0 +1,426 @@
+package main
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"lr/pkg/store"
+)
+
+// syncManifestFile lists the indexes a sync source offers. lr sync
+// --write-manifest creates it in the index directory for ci to publish
+const syncManifestFile = "manifest.lrmeta"
+
+// syncManifest is what a sync source offers, newest version of each index
+type syncManifest struct {
+	GeneratedAt string      `json:"generated_at"`
+	Indexes     []syncEntry `json:"indexes"`
+}
+
+// syncEntry is one index of a syncManifest
+type syncEntry struct {
+	Name           string `json:"name"`
+	File           string `json:"file"`          // relative to the manifest
+	URL            string `json:"url,omitempty"` // overrides File for http sources
+	SHA256         string `json:"sha256,omitempty"`
+	MD5            string `json:"md5,omitempty"` // from an s3 etag, when there is no manifest
+	Size           int64  `json:"size"`
+	EmbeddingModel string `json:"embedding_model,omitempty"`
+	IndexedAt      string `json:"indexed_at,omitempty"`
+}
+
+// syncSource fetches a manifest's files
+type syncSource struct {
+	manifest syncManifest
+	fetch    func(ctx context.Context, e syncEntry, w io.Writer) error
+}
+
+// runSync downloads newer index versions from --from (or LR_SYNC_FROM), or
+// writes the manifest of the local indexes with --write-manifest
+func runSync(_ *cobra.Command, _ []string) error {
+	indexDir := getDefaultIndexDir()
+	if syncWrite {
+		m, err := writeSyncManifest(indexDir)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("wrote %s (%d indexes)\n", filepath.Join(indexDir, syncManifestFile), len(m.Indexes))
+		return nil
+	}
+
+	from := syncFrom
+	if from == "" {
+		from = os.Getenv("LR_SYNC_FROM")
+	}
+	if from == "" {
+		return fmt.Errorf("nothing to sync from: use --from s3://bucket/prefix, gs://bucket/prefix or an https manifest url (or set LR_SYNC_FROM)")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), objectSyncTimeout)
+	defer cancel()
+	src, err := openSyncSource(ctx, from)
+	if err != nil {
+		return err
+	}
+	if len(src.manifest.Indexes) == 0 {
+		fmt.Printf("%s offers no indexes\n", from)
+		return nil
+	}
+
+	currentModel := getCurrentEmbeddingModel()
+	fmt.Printf("=== SYNC FROM %s ===\n", from)
+	var installed, failed int
+	for _, e := range src.manifest.Indexes {
+		ok, err := syncIndex(ctx, src, e, indexDir, currentModel)
+		if err != nil {
+			fmt.Printf("  ✗ %s: %v\n", e.Name, err)
+			failed++
+		} else if ok {
+			installed++
+		}
+	}
+
+	if dryRun {
+		fmt.Printf("\ndry run: %d index(es) would be downloaded\n", installed)
+		return nil
+	}
+	fmt.Printf("\nsynced %d index(es)\n", installed)
+	if installed > 0 && !syncNoReload {
+		if err := reloadAllProcesses(); err != nil {
+			fmt.Printf("warning: %v\n", err)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d index(es) failed to sync", failed)
+	}
+	return nil
+}
+
+// syncIndex downloads one index if it's newer than the local version, then
+// verifies and installs it. returns whether it was (or, with --dry-run, would
+// be) installed
+func syncIndex(ctx context.Context, src *syncSource, e syncEntry, indexDir, currentModel string) (bool, error) {
+	if e.Name == "" || e.File == "" || filepath.Base(e.File) != path.Base(e.File) || isPartialIndexFile(e.File) {
+		return false, fmt.Errorf("invalid manifest entry %q", e.File)
+	}
+	target := filepath.Join(indexDir, path.Base(e.File))
+
+	// skip what we already have, and local builds newer than the shared one
+	versions, err := indexVersions(indexDir, e.Name)
+	if err != nil {
+		return false, err
+	}
+	if len(versions) > 0 {
+		latest := versions[len(versions)-1]
+		if sameDigest(latest, e) {
+			fmt.Printf("  = %s: up to date\n", e.Name)
+			return false, nil
+		}
+		if local := NewVectorStore(); local.Load(latest) == nil && !newerIndex(e.IndexedAt, local.Metadata.IndexedAt) {
+			fmt.Printf("  = %s: local %s is as new (indexed %s)\n", e.Name, filepath.Base(latest), local.Metadata.IndexedAt)
+			return false, nil
+		}
+	}
+
+	// don't download what queries couldn't use
+	if !syncForce && e.EmbeddingModel != "" && currentModel != "" && e.EmbeddingModel != currentModel {
+		return false, fmt.Errorf("embedded with %s but queries use %s (--force to sync anyway)", e.EmbeddingModel, currentModel)
+	}
+	if dryRun {
+		fmt.Printf("  + %s: would download %s (%s)\n", e.Name, path.Base(e.File), formatBytes(e.Size))
+		return true, nil
+	}
+
+	lock, err := acquireIndexLock(indexDir, e.Name, waitLock)
+	if err != nil {
+		return false, err
+	}
+	defer lock.Release()
+
+	tempPath := strings.TrimSuffix(target, ".lrindex") + ".tmp.lrindex"
+	if err := downloadIndex(ctx, src, e, tempPath); err != nil {
+		os.Remove(tempPath)
+		return false, err
+	}
+
+	// the manifest's model may be missing or wrong; the index's own is what counts
+	vs := NewVectorStore()
+	if err := vs.Load(tempPath); err != nil {
+		os.Remove(tempPath)
+		return false, fmt.Errorf("downloaded index is corrupt: %w", err)
+	}
+	if model := inferEmbeddingModel(vs); !syncForce && model != "" && currentModel != "" && model != currentModel {
+		os.Remove(tempPath)
+		return false, fmt.Errorf("embedded with %s but queries use %s (--force to sync anyway)", model, currentModel)
+	}
+
+	if err := os.Rename(tempPath, target); err != nil {
+		os.Remove(tempPath)
+		return false, fmt.Errorf("failed to install %s: %w", path.Base(e.File), err)
+	}
+	fmt.Printf("  ✓ %s: %s (%d chunks)\n", e.Name, filepath.Base(target), len(vs.Chunks))
+	cleanupSupersededIndexes(indexDir, e.Name, target)
+	return true, nil
+}
+
+// sameDigest reports whether the file at path has e's checksum
+func sameDigest(path string, e syncEntry) bool {
+	h, want := entryHash(e)
+	if h == nil {
+		return false
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	if _, err := io.Copy(h, f); err != nil {
+		return false
+	}
+	return hex.EncodeToString(h.Sum(nil)) == want
+}
+
+// entryHash returns a hash to check e's content with and the digest expected,
+// nil if e has no checksum
+func entryHash(e syncEntry) (hash.Hash, string) {
+	switch {
+	case e.SHA256 != "":
+		return sha256.New(), e.SHA256
+	case e.MD5 != "":
+		return md5.New(), e.MD5
+	}
+	return nil, ""
+}
+
+// downloadIndex fetches e into path and checks its size and checksum
+func downloadIndex(ctx context.Context, src *syncSource, e syncEntry, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	h, want := entryHash(e)
+	w := io.Writer(f)
+	if h != nil {
+		w = io.MultiWriter(f, h)
+	}
+	counter := &countingWriter{w: w}
+	err = src.fetch(ctx, e, counter)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+	if e.Size > 0 && counter.n != e.Size {
+		return fmt.Errorf("size mismatch: got %d bytes, expected %d", counter.n, e.Size)
+	}
+	if h != nil {
+		if got := hex.EncodeToString(h.Sum(nil)); got != want {
+			return fmt.Errorf("checksum mismatch: got %s, expected %s", got, want)
+		}
+	}
+	return nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// newerIndex reports whether an index built at remote is newer than one built
+// at local (RFC3339 timestamps). an unknown time counts as newer, so checksums
+// decide
+func newerIndex(remote, local string) bool {
+	r, err1 := time.Parse(time.RFC3339, remote)
+	l, err2 := time.Parse(time.RFC3339, local)
+	if err1 != nil || err2 != nil {
+		return true
+	}
+	return r.After(l)
+}
+
+// openSyncSource reads the manifest of an object store prefix or an http url
+func openSyncSource(ctx context.Context, from string) (*syncSource, error) {
+	if store.IsObjectStoreURL(from) {
+		return openObjectSyncSource(ctx, from)
+	}
+	if strings.HasPrefix(from, "http://") || strings.HasPrefix(from, "https://") {
+		return openHTTPSyncSource(ctx, from)
+	}
+	return nil, fmt.Errorf("unsupported sync source %q (use s3://, gs://, http:// or https://)", from)
+}
+
+// openObjectSyncSource uses the bucket's manifest if it has one, otherwise
+// the newest version of each index in it, checked against its etag
+func openObjectSyncSource(ctx context.Context, from string) (*syncSource, error) {
+	o, err := store.OpenObjectStore(from)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := o.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	src := &syncSource{fetch: func(ctx context.Context, e syncEntry, w io.Writer) error {
+		_, err := o.Get(ctx, e.File, w)
+		return err
+	}}
+
+	latest := make(map[string]store.ObjectInfo)
+	for _, obj := range objects {
+		if obj.Key == syncManifestFile {
+			var buf strings.Builder
+			if _, err := o.Get(ctx, obj.Key, &buf); err != nil {
+				return nil, err
+			}
+			if err := json.Unmarshal([]byte(buf.String()), &src.manifest); err != nil {
+				return nil, fmt.Errorf("invalid manifest in %s: %w", from, err)
+			}
+			return src, nil
+		}
+		if strings.Contains(obj.Key, "/") || !strings.HasSuffix(obj.Key, ".lrindex") || isPartialIndexFile(obj.Key) {
+			continue
+		}
+		name := indexNameFromFile(obj.Key)
+		if prev, ok := latest[name]; !ok || obj.Key > prev.Key {
+			latest[name] = obj
+		}
+	}
+	for name, obj := range latest {
+		e := syncEntry{Name: name, File: obj.Key, Size: obj.Size}
+		// multipart uploads have etags that aren't the md5 of the content
+		if len(obj.ETag) == 32 && !strings.Contains(obj.ETag, "-") {
+			e.MD5 = obj.ETag
+		}
+		src.manifest.Indexes = append(src.manifest.Indexes, e)
+	}
+	sort.Slice(src.manifest.Indexes, func(i, j int) bool { return src.manifest.Indexes[i].Name < src.manifest.Indexes[j].Name })
+	return src, nil
+}
+
+// openHTTPSyncSource fetches a manifest from an http url (the manifest file
+// itself, or the directory holding it); LR_SYNC_TOKEN is sent as a bearer token
+func openHTTPSyncSource(ctx context.Context, from string) (*syncSource, error) {
+	manifestURL := from
+	if !strings.HasSuffix(from, ".lrmeta") && !strings.HasSuffix(from, ".json") {
+		manifestURL = strings.TrimRight(from, "/") + "/" + syncManifestFile
+	}
+	base, err := url.Parse(manifestURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid sync url: %w", err)
+	}
+	client := &http.Client{Timeout: objectSyncTimeout}
+	get := func(ctx context.Context, u string, w io.Writer) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return err
+		}
+		if token := os.Getenv("LR_SYNC_TOKEN"); token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("GET %s: %s", u, resp.Status)
+		}
+		_, err = io.Copy(w, resp.Body)
+		return err
+	}
+
+	var buf strings.Builder
+	if err := get(ctx, base.String(), &buf); err != nil {
+		return nil, fmt.Errorf("failed to fetch manifest: %w", err)
+	}
+	src := &syncSource{}
+	if err := json.Unmarshal([]byte(buf.String()), &src.manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest at %s: %w", manifestURL, err)
+	}
+	src.fetch = func(ctx context.Context, e syncEntry, w io.Writer) error {
+		ref := e.URL
+		if ref == "" {
+			ref = e.File
+		}
+		u, err := base.Parse(ref)
+		if err != nil {
+			return err
+		}
+		return get(ctx, u.String(), w)
+	}
+	return src, nil
+}
+
+// writeSyncManifest records the newest version of each index in indexDir
+func writeSyncManifest(indexDir string) (syncManifest, error) {
+	m := syncManifest{GeneratedAt: time.Now().UTC().Format(time.RFC3339)}
+	files, err := listIndexFiles(indexDir)
+	if err != nil {
+		return m, err
+	}
+	latest := make(map[string]string)
+	for _, file := range files {
+		if !strings.HasSuffix(file, ".lrindex") {
+			continue
+		}
+		name := indexNameFromFile(file)
+		if file > latest[name] {
+			latest[name] = file
+		}
+	}
+	for name, file := range latest {
+		vs := NewVectorStore()
+		if err := vs.Load(file); err != nil {
+			return m, fmt.Errorf("failed to read %s: %w", filepath.Base(file), err)
+		}
+		if vs.Metadata.ReviewIndex {
+			continue
+		}
+		sum, err := fileHash(file)
+		if err != nil {
+			return m, err
+		}
+		info, err := os.Stat(file)
+		if err != nil {
+			return m, err
+		}
+		m.Indexes = append(m.Indexes, syncEntry{
+			Name:           name,
+			File:           filepath.Base(file),
+			SHA256:         sum,
+			Size:           info.Size(),
+			EmbeddingModel: inferEmbeddingModel(vs),
+			IndexedAt:      vs.Metadata.IndexedAt,
+		})
+	}
+	sort.Slice(m.Indexes, func(i, j int) bool { return m.Indexes[i].Name < m.Indexes[j].Name })
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return m, err
+	}
+	return m, os.WriteFile(filepath.Join(indexDir, syncManifestFile), data, 0644)
+}
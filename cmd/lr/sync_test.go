@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSyncFromHTTPManifest(t *testing.T) {
+	// ci publishes two indexes and their manifest
+	published := t.TempDir()
+	for name, model := range map[string]string{"docs": "mock", "other": "text-embedding-3-small"} {
+		vs := NewVectorStore()
+		vs.Add(Chunk{Text: name, Source: name + ".md"}, []float64{1, 0})
+		vs.Metadata.EmbeddingModel = model
+		vs.Metadata.IndexedAt = "2026-01-02T00:00:00Z"
+		if err := atomicSave(vs, filepath.Join(published, name+"_20260102.lrindex")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := writeSyncManifest(published); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.FileServer(http.Dir(published)))
+	defer server.Close()
+
+	ctx := context.Background()
+	src, err := openSyncSource(ctx, server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(src.manifest.Indexes) != 2 {
+		t.Fatalf("expected 2 indexes in the manifest, got %+v", src.manifest.Indexes)
+	}
+	docs, other := src.manifest.Indexes[0], src.manifest.Indexes[1]
+
+	local := t.TempDir()
+	if ok, err := syncIndex(ctx, src, docs, local, "mock"); !ok || err != nil {
+		t.Fatalf("expected docs synced, got %v %v", ok, err)
+	}
+	if ok, err := syncIndex(ctx, src, docs, local, "mock"); ok || err != nil {
+		t.Errorf("expected docs up to date on the second sync, got %v %v", ok, err)
+	}
+	if _, err := syncIndex(ctx, src, other, local, "mock"); err == nil {
+		t.Errorf("expected an index embedded with another model rejected")
+	}
+
+	// a newer version that arrives corrupted doesn't replace anything
+	os.WriteFile(filepath.Join(published, docs.File), []byte("tampered"), 0644)
+	docs.IndexedAt = "2026-02-01T00:00:00Z"
+	docs.Size = int64(len("tampered"))
+	docs.SHA256 = "4f2bd3a7c1f0e5a8b9d6c3e2f1a0b9c8d7e6f5a4b3c2d1e0f9a8b7c6d5e4f3a2"
+	if _, err := syncIndex(ctx, src, docs, local, "mock"); err == nil {
+		t.Errorf("expected a checksum mismatch")
+	}
+	if vs := NewVectorStore(); vs.Load(filepath.Join(local, docs.File)) != nil {
+		t.Errorf("expected the synced index left intact")
+	}
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// weakMatchSimilarity is the best similarity below which lr top counts a
+// question as poorly covered by the indexes
+const weakMatchSimilarity = 0.5
+
+// RetrievalRecord is one line of the retrieval log: the chunks a question
+// retrieved
+type RetrievalRecord struct {
+	Time      time.Time        `json:"time"`
+	Operation string           `json:"operation"` // e.g. query, interactive, mcp, daemon
+	Question  string           `json:"question"`
+	Results   []RetrievedChunk `json:"results"`
+}
+
+// RetrievedChunk is a chunk a question retrieved
+type RetrievedChunk struct {
+	Index      string  `json:"index"`
+	Source     string  `json:"source"` // the chunk's source, e.g. server/server.go (part 2)
+	Similarity float64 `json:"similarity"`
+}
+
+// getRetrievalLogPath returns the path to the retrieval log (next to the usage ledger)
+func getRetrievalLogPath() string {
+	return filepath.Join(filepath.Dir(getDataDir()), "retrievals.jsonl")
+}
+
+// logRetrieval appends the chunks question retrieved to the retrieval log,
+// unless LR_NO_RETRIEVAL_LOG is set. failures are only warned about
+func logRetrieval(operation, question string, results []SearchResult) {
+	if os.Getenv("LR_NO_RETRIEVAL_LOG") != "" || len(results) == 0 {
+		return
+	}
+	r := RetrievalRecord{Time: time.Now(), Operation: operation, Question: question}
+	for _, result := range results {
+		r.Results = append(r.Results, RetrievedChunk{
+			Index:      result.Chunk.Metadata["vector_source"],
+			Source:     result.Chunk.Source,
+			Similarity: result.Similarity,
+		})
+	}
+	if err := appendRetrievalRecord(r); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to log retrieval: %v\n", err)
+	}
+}
+
+// appendRetrievalRecord appends r to the retrieval log
+func appendRetrievalRecord(r RetrievalRecord) error {
+	path := getRetrievalLogPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return json.NewEncoder(f).Encode(r)
+}
+
+// loadRetrievalRecords reads all retrieval log records at or after since
+func loadRetrievalRecords(since time.Time) ([]RetrievalRecord, error) {
+	f, err := os.Open(getRetrievalLogPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []RetrievalRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var r RetrievalRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue // skip damaged lines
+		}
+		if !r.Time.Before(since) {
+			records = append(records, r)
+		}
+	}
+	return records, scanner.Err()
+}
+
+// topReport is what lr top prints
+type topReport struct {
+	Since       time.Time     `json:"since,omitzero"`
+	Queries     int           `json:"queries"`
+	Files       []topEntry    `json:"files"`  // the most retrieved files
+	Chunks      []topEntry    `json:"chunks"` // the most retrieved chunks
+	Never       []topNever    `json:"never_retrieved"`
+	WeakMatches []topQuestion `json:"weak_matches"` // questions the indexes barely cover
+}
+
+// topEntry is a file or chunk and how often it was retrieved
+type topEntry struct {
+	Index         string  `json:"index"`
+	Source        string  `json:"source"`
+	Hits          int     `json:"hits"`
+	Queries       int     `json:"queries"` // distinct questions
+	AvgSimilarity float64 `json:"avg_similarity"`
+}
+
+// topNever lists the files of an index that no question retrieved
+type topNever struct {
+	Index      string   `json:"index"`
+	TotalFiles int      `json:"total_files"`
+	Files      []string `json:"files"`
+}
+
+// topQuestion is a question whose best match was weak
+type topQuestion struct {
+	Question   string  `json:"question"`
+	Similarity float64 `json:"best_similarity"`
+	Asked      int     `json:"asked"`
+}
+
+// buildTopReport tallies records by file and by chunk and finds the files of
+// indexes (index name to its files) that no record retrieved. lists are cut
+// to limit entries (0 for all)
+func buildTopReport(records []RetrievalRecord, indexes map[string][]string, limit int) *topReport {
+	type tally struct {
+		entry     topEntry
+		questions map[string]bool
+		total     float64
+	}
+	files, chunks := make(map[string]*tally), make(map[string]*tally)
+	add := func(m map[string]*tally, index, source, question string, similarity float64) {
+		key := index + "\x00" + source
+		t, ok := m[key]
+		if !ok {
+			t = &tally{entry: topEntry{Index: index, Source: source}, questions: make(map[string]bool)}
+			m[key] = t
+		}
+		t.entry.Hits++
+		t.total += similarity
+		t.questions[normalizeQuestion(question)] = true
+	}
+	weak := make(map[string]*topQuestion)
+	var weakOrder []string
+	for _, r := range records {
+		best := 0.0
+		for _, c := range r.Results {
+			add(files, c.Index, chunkFilePath(c.Source), r.Question, c.Similarity)
+			add(chunks, c.Index, c.Source, r.Question, c.Similarity)
+			best = max(best, c.Similarity)
+		}
+		if best < weakMatchSimilarity {
+			key := normalizeQuestion(r.Question)
+			if q, ok := weak[key]; ok {
+				q.Asked++
+				q.Similarity = max(q.Similarity, best)
+			} else {
+				weak[key] = &topQuestion{Question: r.Question, Similarity: best, Asked: 1}
+				weakOrder = append(weakOrder, key)
+			}
+		}
+	}
+
+	ranked := func(m map[string]*tally) []topEntry {
+		entries := make([]topEntry, 0, len(m))
+		for _, t := range m {
+			t.entry.Queries = len(t.questions)
+			t.entry.AvgSimilarity = t.total / float64(t.entry.Hits)
+			entries = append(entries, t.entry)
+		}
+		sort.Slice(entries, func(i, j int) bool {
+			a, b := entries[i], entries[j]
+			if a.Hits != b.Hits {
+				return a.Hits > b.Hits
+			}
+			if a.Index != b.Index {
+				return a.Index < b.Index
+			}
+			return a.Source < b.Source
+		})
+		return truncate(entries, limit)
+	}
+
+	report := &topReport{Queries: len(records), Files: ranked(files), Chunks: ranked(chunks), Never: []topNever{}, WeakMatches: []topQuestion{}}
+	names := make([]string, 0, len(indexes))
+	for name := range indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		never := topNever{Index: name, TotalFiles: len(indexes[name]), Files: []string{}}
+		for _, file := range indexes[name] {
+			if _, ok := files[name+"\x00"+file]; !ok {
+				never.Files = append(never.Files, file)
+			}
+		}
+		if len(never.Files) > 0 {
+			report.Never = append(report.Never, never)
+		}
+	}
+	for _, key := range weakOrder {
+		report.WeakMatches = append(report.WeakMatches, *weak[key])
+	}
+	sort.SliceStable(report.WeakMatches, func(i, j int) bool {
+		return report.WeakMatches[i].Asked > report.WeakMatches[j].Asked
+	})
+	report.WeakMatches = truncate(report.WeakMatches, limit)
+	return report
+}
+
+// truncate cuts s to limit entries, if limit is positive
+func truncate[T any](s []T, limit int) []T {
+	if limit > 0 && len(s) > limit {
+		return s[:limit]
+	}
+	return s
+}
+
+// runTop reports which files and chunks the logged questions retrieve most,
+// which files they never retrieve, and which questions found little
+func runTop(_ *cobra.Command, _ []string) error {
+	lookback, err := parseSince(topSince)
+	if err != nil {
+		return err
+	}
+	var since time.Time
+	if lookback > 0 {
+		since = time.Now().Add(-lookback)
+	}
+	records, err := loadRetrievalRecords(since)
+	if err != nil {
+		return fmt.Errorf("failed to read retrieval log: %w", err)
+	}
+	if len(querySources) > 0 {
+		wanted := make(map[string]bool)
+		for _, s := range querySources {
+			wanted[s] = true
+		}
+		// only the questions that retrieved from these indexes
+		var filtered []RetrievalRecord
+		for _, r := range records {
+			var kept []RetrievedChunk
+			for _, c := range r.Results {
+				if wanted[c.Index] {
+					kept = append(kept, c)
+				}
+			}
+			if len(kept) > 0 {
+				r.Results = kept
+				filtered = append(filtered, r)
+			}
+		}
+		records = filtered
+	}
+
+	mss, err := loadKeywordSources(querySources)
+	if err != nil {
+		return err
+	}
+	indexes := make(map[string][]string)
+	for name, vs := range mss.Sources {
+		indexes[name] = indexedFiles(vs)
+	}
+
+	report := buildTopReport(records, indexes, topLimit)
+	report.Since = since
+	if jsonOutput {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	printTopReport(report)
+	return nil
+}
+
+// printTopReport prints report for people
+func printTopReport(report *topReport) {
+	if report.Since.IsZero() {
+		fmt.Printf("=== RETRIEVALS (all time) ===\n")
+	} else {
+		fmt.Printf("=== RETRIEVALS (since %s) ===\n", report.Since.Format("2006-01-02 15:04"))
+	}
+	fmt.Printf("questions: %d\n", report.Queries)
+	if report.Queries == 0 {
+		fmt.Println(dim("no retrievals logged yet: questions asked with lr query, lr interactive, lr mcp, lr daemon and lr serve are logged to " + getRetrievalLogPath()))
+	}
+
+	printEntries := func(title string, entries []topEntry) {
+		if len(entries) == 0 {
+			return
+		}
+		fmt.Printf("\n%s:\n", bold(title))
+		for _, e := range entries {
+			fmt.Printf("  %5d hits %4d questions  avg %.2f  %s\n", e.Hits, e.Queries, e.AvgSimilarity, cyan(e.Index+":"+e.Source))
+		}
+	}
+	printEntries("most retrieved files", report.Files)
+	printEntries("most retrieved chunks", report.Chunks)
+	if len(report.Files) > 0 {
+		fmt.Println(dim("files retrieved for many unrelated questions may be noise (generated code, fixtures): consider --exclude when indexing"))
+	}
+
+	if len(report.Never) > 0 {
+		fmt.Printf("\n%s\n", bold("never retrieved:"))
+		for _, n := range report.Never {
+			fmt.Printf("  %s: %d of %d files\n", cyan(n.Index), len(n.Files), n.TotalFiles)
+			shown := truncate(n.Files, topLimit)
+			for _, f := range shown {
+				fmt.Printf("    %s\n", f)
+			}
+			if len(shown) < len(n.Files) {
+				fmt.Println(dim(fmt.Sprintf("    ... and %d more (--limit 0 for all)", len(n.Files)-len(shown))))
+			}
+		}
+		fmt.Println(dim("files nothing retrieves are candidates to exclude, or cover what nobody has asked about yet"))
+	}
+
+	if len(report.WeakMatches) > 0 {
+		fmt.Printf("\n%s\n", bold(fmt.Sprintf("questions with weak matches (best similarity below %.2f):", weakMatchSimilarity)))
+		for _, q := range report.WeakMatches {
+			fmt.Printf("  %s %s\n", yellow(fmt.Sprintf("%.2f", q.Similarity)), q.Question)
+			if q.Asked > 1 {
+				fmt.Println(dim(fmt.Sprintf("       asked %d times", q.Asked)))
+			}
+		}
+		fmt.Println(dim("the indexes barely cover these: they are the docs worth writing"))
+	}
+	fmt.Printf("\nlog: %s\n", getRetrievalLogPath())
+}
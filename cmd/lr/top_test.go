@@ -0,0 +1,39 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildTopReport(t *testing.T) {
+	records := []RetrievalRecord{
+		{Question: "how are streams stored?", Results: []RetrievedChunk{
+			{Index: "docs", Source: "streams.md (part 1)", Similarity: 0.8},
+			{Index: "docs", Source: "streams.md (part 2)", Similarity: 0.6},
+		}},
+		{Question: "How are streams stored", Results: []RetrievedChunk{
+			{Index: "docs", Source: "streams.md (part 1)", Similarity: 0.8},
+		}},
+		{Question: "what is a lease", Results: []RetrievedChunk{
+			{Index: "docs", Source: "kv.md", Similarity: 0.3},
+		}},
+	}
+	indexes := map[string][]string{"docs": {"kv.md", "old.md", "streams.md"}}
+
+	r := buildTopReport(records, indexes, 1)
+	if r.Queries != 3 {
+		t.Errorf("queries = %d, want 3", r.Queries)
+	}
+	if len(r.Files) != 1 || r.Files[0].Source != "streams.md" || r.Files[0].Hits != 3 || r.Files[0].Queries != 1 {
+		t.Errorf("files = %+v, want streams.md with 3 hits from 1 question", r.Files)
+	}
+	if len(r.Chunks) != 1 || r.Chunks[0].Source != "streams.md (part 1)" || r.Chunks[0].Hits != 2 {
+		t.Errorf("chunks = %+v, want streams.md (part 1) with 2 hits", r.Chunks)
+	}
+	if len(r.Never) != 1 || !reflect.DeepEqual(r.Never[0].Files, []string{"old.md"}) || r.Never[0].TotalFiles != 3 {
+		t.Errorf("never retrieved = %+v, want old.md of 3", r.Never)
+	}
+	if len(r.WeakMatches) != 1 || r.WeakMatches[0].Question != "what is a lease" {
+		t.Errorf("weak matches = %+v, want what is a lease", r.WeakMatches)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// transcript records an interactive session for export as markdown
+type transcript struct {
+	started time.Time
+	sources []string
+	entries []transcriptEntry
+}
+
+// transcriptEntry is one answered question
+type transcriptEntry struct {
+	question   string
+	answer     string
+	results    []SearchResult
+	cited      map[int]bool // source numbers the answer cites
+	followUps  []string
+	problems   []string
+	confidence *Confidence
+	abstained  bool
+}
+
+func newTranscript(sources []string) *transcript {
+	return &transcript{started: time.Now(), sources: sources}
+}
+
+// add records an answer to question
+func (t *transcript) add(question string, resp *remoteQueryResponse) {
+	e := transcriptEntry{
+		question:   question,
+		answer:     resp.Answer,
+		results:    resp.Results,
+		cited:      make(map[int]bool),
+		followUps:  resp.FollowUps,
+		problems:   citationProblems(resp.Citations),
+		confidence: resp.Confidence,
+		abstained:  resp.Abstained,
+	}
+	for _, c := range resp.Citations {
+		if c.Source != "" && c.Problem == "" {
+			e.cited[c.Number] = true
+		}
+	}
+	t.entries = append(t.entries, e)
+}
+
+// markdown renders the session: each question with its answer and the sources
+// it was based on, numbered as the answer cites them
+func (t *transcript) markdown() string {
+	var b strings.Builder
+	b.WriteString("# lr session\n\n")
+	fmt.Fprintf(&b, "- started: %s\n", t.started.Format(time.RFC3339))
+	fmt.Fprintf(&b, "- sources: %s\n", strings.Join(t.sources, ", "))
+	fmt.Fprintf(&b, "- questions: %d\n", len(t.entries))
+
+	for i, e := range t.entries {
+		fmt.Fprintf(&b, "\n## %d. %s\n\n", i+1, e.question)
+		if len(e.followUps) > 0 {
+			fmt.Fprintf(&b, "_also searched for: %s_\n\n", strings.Join(e.followUps, "; "))
+		}
+		b.WriteString(strings.TrimSpace(e.answer) + "\n")
+
+		// an abstained answer lists the nearest matches itself
+		if e.abstained || len(e.results) == 0 {
+			continue
+		}
+		b.WriteString("\n**sources:**\n\n")
+		for n, result := range e.results {
+			source := result.Chunk.Source
+			if index := result.Chunk.Metadata["vector_source"]; index != "" {
+				source = index + ":" + source
+			}
+			cited := ""
+			if e.cited[n+1] {
+				cited = ", cited"
+			}
+			fmt.Fprintf(&b, "%d. `%s` (similarity: %.3f%s)\n", n+1, source, result.Similarity, cited)
+		}
+		if len(e.problems) > 0 {
+			b.WriteString("\n**possibly fabricated citations:**\n\n")
+			for _, problem := range e.problems {
+				fmt.Fprintf(&b, "- %s\n", problem)
+			}
+		}
+		if e.confidence != nil {
+			fmt.Fprintf(&b, "\n**confidence:** %s\n", e.confidence)
+		}
+	}
+	return b.String()
+}
+
+// save writes the transcript to path, replacing any previous version
+func (t *transcript) save(path string) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := ensureDir(dir); err != nil {
+			return fmt.Errorf("failed to create %s: %w", dir, err)
+		}
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(t.markdown()), 0644); err != nil {
+		return fmt.Errorf("failed to write transcript: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
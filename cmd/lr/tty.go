@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/schollz/progressbar/v3"
+	"golang.org/x/term"
+)
+
+// isTerminal reports whether f is attached to a terminal
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
+
+// showProgress reports whether progress bars should be drawn: not with
+// --no-progress, and not when stdout is redirected (docker, ci, cron)
+func showProgress() bool {
+	return !noProgress && isTerminal(os.Stdout)
+}
+
+// newProgressBar creates the chunk progress bar used while embedding; it is
+// invisible when progress output is disabled
+func newProgressBar(max int, description string) *progressbar.ProgressBar {
+	return progressbar.NewOptions(max,
+		progressbar.OptionSetDescription(description),
+		progressbar.OptionShowCount(),
+		progressbar.OptionSetWidth(40),
+		progressbar.OptionThrottle(100*time.Millisecond),
+		progressbar.OptionShowIts(),
+		progressbar.OptionSetItsString("chunks"),
+		progressbar.OptionSetVisibility(showProgress()),
+	)
+}
+
+// confirm asks a yes/no question on the terminal. --yes answers yes; without a
+// terminal on stdin there is nobody to ask, so the answer is no
+func confirm(question string) bool {
+	if assumeYes {
+		return true
+	}
+	if !isTerminal(os.Stdin) {
+		return false
+	}
+	fmt.Printf("%s [y/N] ", question)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes"
+}
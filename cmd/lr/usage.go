@@ -0,0 +1,336 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"lr/pkg/llm"
+)
+
+// providerForModel guesses the provider from a model name
+func providerForModel(model string) string {
+	switch {
+	case strings.HasPrefix(model, "claude-"):
+		return "anthropic"
+	case strings.HasPrefix(model, "gpt-"), strings.HasPrefix(model, "text-embedding-"):
+		return "openai"
+	case strings.HasPrefix(model, "voyage-"):
+		return "voyage"
+	case isPluginModel(model):
+		return "plugin"
+	case strings.HasPrefix(model, "http://"), strings.HasPrefix(model, "https://"):
+		return "external"
+	default:
+		return "ollama"
+	}
+}
+
+// estimateTokens approximates token count (rough: 1 token ≈ 4 characters)
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// UsageRecord is one line of the usage ledger
+type UsageRecord struct {
+	Time         time.Time `json:"time"`
+	Operation    string    `json:"operation"` // e.g. index, update-all, query, mcp
+	Index        string    `json:"index,omitempty"`
+	Provider     string    `json:"provider"`
+	Model        string    `json:"model"`
+	Kind         string    `json:"kind"` // embedding or chat
+	InputTokens  int       `json:"input_tokens"`
+	OutputTokens int       `json:"output_tokens"`
+	Cost         float64   `json:"cost"`
+}
+
+// getUsageLedgerPath returns the path to the usage ledger (next to the indexes dir)
+func getUsageLedgerPath() string {
+	return filepath.Join(filepath.Dir(getDataDir()), "usage.jsonl")
+}
+
+// UsageMeter wraps an LLMClient and counts the (estimated) tokens sent through it
+type UsageMeter struct {
+	LLMClient
+	EmbeddingModel string
+	ChatModel      string
+
+	mu               sync.Mutex
+	embeddingTokens  int
+	chatInputTokens  int
+	chatOutputTokens int
+}
+
+var _ ContextLLMClient = (*UsageMeter)(nil)
+
+// activeMeters are flushed to the ledger when the command exits
+var (
+	activeMeters   []*UsageMeter
+	activeMetersMu sync.Mutex
+)
+
+// newUsageMeter wraps llm so its usage is recorded in the ledger
+func newUsageMeter(llm LLMClient, embeddingModel, chatModel string) *UsageMeter {
+	m := &UsageMeter{LLMClient: llm, EmbeddingModel: embeddingModel, ChatModel: chatModel}
+	activeMetersMu.Lock()
+	activeMeters = append(activeMeters, m)
+	activeMetersMu.Unlock()
+	return m
+}
+
+// GetEmbedding counts and forwards an embedding request
+func (m *UsageMeter) GetEmbedding(text string) ([]float64, error) {
+	return m.GetEmbeddingContext(context.Background(), text)
+}
+
+// GetEmbeddingContext counts and forwards an embedding request
+func (m *UsageMeter) GetEmbeddingContext(ctx context.Context, text string) ([]float64, error) {
+	embedding, err := getEmbeddingContext(ctx, m.LLMClient, text)
+	if err == nil {
+		m.mu.Lock()
+		m.embeddingTokens += estimateTokens(text)
+		m.mu.Unlock()
+	}
+	return embedding, err
+}
+
+// GetBatchEmbeddingsContext counts and forwards embedding requests for texts,
+// sent as one request when the wrapped client can
+func (m *UsageMeter) GetBatchEmbeddingsContext(ctx context.Context, texts []string) ([][]float64, error) {
+	embeddings, err := llm.GetBatchEmbeddingsContext(ctx, m.LLMClient, texts)
+	if err == nil {
+		m.mu.Lock()
+		for _, text := range texts {
+			m.embeddingTokens += estimateTokens(text)
+		}
+		m.mu.Unlock()
+	}
+	return embeddings, err
+}
+
+// Chat counts and forwards a chat request
+func (m *UsageMeter) Chat(messages []Message) (string, error) {
+	return m.ChatContext(context.Background(), messages)
+}
+
+// ChatContext counts and forwards a chat request
+func (m *UsageMeter) ChatContext(ctx context.Context, messages []Message) (string, error) {
+	answer, err := chatContext(ctx, m.LLMClient, messages)
+	if err == nil {
+		m.mu.Lock()
+		for _, msg := range messages {
+			m.chatInputTokens += estimateTokens(msg.Content)
+		}
+		m.chatOutputTokens += estimateTokens(answer)
+		m.mu.Unlock()
+	}
+	return answer, err
+}
+
+// Flush appends the usage counted so far to the ledger and resets the counters
+func (m *UsageMeter) Flush(operation, index string) {
+	m.mu.Lock()
+	var records []UsageRecord
+	now := time.Now()
+	if m.embeddingTokens > 0 {
+		price, _ := priceOf(m.EmbeddingModel)
+		records = append(records, UsageRecord{
+			Time: now, Operation: operation, Index: index,
+			Provider: providerForModel(m.EmbeddingModel), Model: m.EmbeddingModel, Kind: "embedding",
+			InputTokens: m.embeddingTokens,
+			Cost:        float64(m.embeddingTokens) / 1_000_000.0 * price.Input,
+		})
+	}
+	if m.chatInputTokens > 0 || m.chatOutputTokens > 0 {
+		price, _ := priceOf(m.ChatModel)
+		records = append(records, UsageRecord{
+			Time: now, Operation: operation, Index: index,
+			Provider: providerForModel(m.ChatModel), Model: m.ChatModel, Kind: "chat",
+			InputTokens: m.chatInputTokens, OutputTokens: m.chatOutputTokens,
+			Cost: float64(m.chatInputTokens)/1_000_000.0*price.Input +
+				float64(m.chatOutputTokens)/1_000_000.0*price.Output,
+		})
+	}
+	m.embeddingTokens, m.chatInputTokens, m.chatOutputTokens = 0, 0, 0
+	m.mu.Unlock()
+
+	if err := appendUsageRecords(records); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: failed to record usage: %v\n", err)
+	}
+}
+
+// flushUsage records any usage not yet flushed by the command itself
+func flushUsage(operation string) {
+	activeMetersMu.Lock()
+	meters := activeMeters
+	activeMetersMu.Unlock()
+	for _, m := range meters {
+		m.Flush(operation, "")
+	}
+}
+
+// flushUsageFor flushes llm's usage under the given operation and index, if it is metered
+func flushUsageFor(llm LLMClient, operation, index string) {
+	switch c := llm.(type) {
+	case *UsageMeter:
+		c.Flush(operation, index)
+	case *FailoverClient:
+		flushUsageFor(c.LLMClient, operation, index)
+		for _, b := range c.Fallbacks {
+			flushUsageFor(b.Client, operation, index)
+		}
+	}
+}
+
+// appendUsageRecords appends records to the ledger
+func appendUsageRecords(records []UsageRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	path := getUsageLedgerPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	for _, r := range records {
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadUsageRecords reads all ledger records at or after since
+func loadUsageRecords(since time.Time) ([]UsageRecord, error) {
+	f, err := os.Open(getUsageLedgerPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []UsageRecord
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var r UsageRecord
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			continue // skip damaged lines
+		}
+		if !r.Time.Before(since) {
+			records = append(records, r)
+		}
+	}
+	return records, scanner.Err()
+}
+
+// parseSince parses a lookback like "30d", "12h" or "2w"
+func parseSince(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if strings.HasSuffix(s, suffix) {
+			n, err := strconv.Atoi(strings.TrimSuffix(s, suffix))
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid --since value %q", s)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid --since value %q (examples: 30d, 2w, 12h)", s)
+	}
+	return d, nil
+}
+
+// runUsage summarizes the usage ledger
+func runUsage(_ *cobra.Command, _ []string) error {
+	lookback, err := parseSince(usageSince)
+	if err != nil {
+		return err
+	}
+	var since time.Time
+	if lookback > 0 {
+		since = time.Now().Add(-lookback)
+	}
+
+	records, err := loadUsageRecords(since)
+	if err != nil {
+		return fmt.Errorf("failed to read usage ledger: %w", err)
+	}
+	if len(records) == 0 {
+		fmt.Println("no usage recorded")
+		return nil
+	}
+
+	type total struct {
+		tokens int
+		cost   float64
+	}
+	var grand total
+	byProvider := make(map[string]*total)
+	byIndex := make(map[string]*total)
+	byOperation := make(map[string]*total)
+	add := func(m map[string]*total, key string, r UsageRecord) {
+		if m[key] == nil {
+			m[key] = &total{}
+		}
+		m[key].tokens += r.InputTokens + r.OutputTokens
+		m[key].cost += r.Cost
+	}
+	for _, r := range records {
+		grand.tokens += r.InputTokens + r.OutputTokens
+		grand.cost += r.Cost
+		add(byProvider, fmt.Sprintf("%s %s (%s)", r.Provider, r.Model, r.Kind), r)
+		index := r.Index
+		if index == "" {
+			index = "(none)"
+		}
+		add(byIndex, index, r)
+		add(byOperation, r.Operation, r)
+	}
+
+	printTotals := func(title string, m map[string]*total) {
+		fmt.Printf("\n%s:\n", title)
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool { return m[keys[i]].cost > m[keys[j]].cost })
+		for _, k := range keys {
+			fmt.Printf("  %-48s %12d tokens  $%.4f\n", k, m[k].tokens, m[k].cost)
+		}
+	}
+
+	if since.IsZero() {
+		fmt.Printf("=== USAGE (all time) ===\n")
+	} else {
+		fmt.Printf("=== USAGE (since %s) ===\n", since.Format("2006-01-02 15:04"))
+	}
+	fmt.Printf("records: %d\n", len(records))
+	fmt.Printf("total: %d tokens, $%.4f (estimated)\n", grand.tokens, grand.cost)
+	printTotals("by provider", byProvider)
+	printTotals("by index", byIndex)
+	printTotals("by operation", byOperation)
+	fmt.Printf("\nledger: %s\n", getUsageLedgerPath())
+	return nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"math"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestUsageMeterFlush(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	m := &UsageMeter{LLMClient: &MockLLMClient{}, EmbeddingModel: "text-embedding-3-small", ChatModel: "gpt-4o"}
+	text := "func Parse(s string) (Config, error)"
+	if _, err := m.GetEmbedding(text); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Chat([]Message{{Role: "user", Content: "how is the config parsed?"}}); err != nil {
+		t.Fatal(err)
+	}
+	m.Flush("query", "proj")
+	m.Flush("query", "proj") // nothing new counted: nothing recorded
+
+	records, err := loadUsageRecords(time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("expected an embedding and a chat record, got %+v", records)
+	}
+	embedding, chat := records[0], records[1]
+	embeddingPrice, _ := priceOf("text-embedding-3-small")
+	if embedding.Kind != "embedding" || embedding.Index != "proj" || embedding.Operation != "query" || embedding.InputTokens != estimateTokens(text) ||
+		math.Abs(embedding.Cost-float64(embedding.InputTokens)/1e6*embeddingPrice.Input) > 1e-12 {
+		t.Errorf("embedding record = %+v", embedding)
+	}
+	chatPrice, _ := priceOf("gpt-4o")
+	wantCost := float64(chat.InputTokens)/1e6*chatPrice.Input + float64(chat.OutputTokens)/1e6*chatPrice.Output
+	if chat.Kind != "chat" || chat.Model != "gpt-4o" || chat.OutputTokens != estimateTokens("mock response") || chat.Cost == 0 || math.Abs(chat.Cost-wantCost) > 1e-12 {
+		t.Errorf("chat record = %+v", chat)
+	}
+}
+
+func TestLoadUsageRecords(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	if records, err := loadUsageRecords(time.Time{}); err != nil || records != nil {
+		t.Fatalf("without a ledger: %v, %v", records, err)
+	}
+
+	now := time.Now()
+	if err := appendUsageRecords([]UsageRecord{
+		{Time: now.Add(-48 * time.Hour), Operation: "index", InputTokens: 1000},
+		{Time: now.Add(-time.Hour), Operation: "query", InputTokens: 10},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(getUsageLedgerPath(), os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("{\"time\": not json\n")
+	f.Close()
+
+	records, err := loadUsageRecords(now.Add(-24 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].Operation != "query" {
+		t.Errorf("records since a day ago = %+v, want the query only, skipping the damaged line", records)
+	}
+	if all, _ := loadUsageRecords(time.Time{}); len(all) != 2 {
+		t.Errorf("all records = %+v", all)
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	for s, want := range map[string]time.Duration{
+		"":    0,
+		"30d": 30 * 24 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+		"12h": 12 * time.Hour,
+		"90m": 90 * time.Minute,
+	} {
+		if got, err := parseSince(s); err != nil || got != want {
+			t.Errorf("parseSince(%q) = %s, %v, want %s", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"d", "-3d", "xw", "soon", "12"} {
+		if _, err := parseSince(s); err == nil {
+			t.Errorf("parseSince(%q) should fail", s)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// with --warmup, lr mcp embeds a few common questions as it starts, so the
+// first tool call doesn't also pay for connecting to the embedding provider.
+// the embeddings are cached (see queryEmbeddingCache), so asking one of the
+// questions doesn't embed it at all
+
+// warmupState is how the warmup went, for server_status. guarded by preloadMutex
+var warmupState struct {
+	running bool
+	done    int
+	took    time.Duration
+	err     error
+}
+
+// warmupQuestions returns the questions of --warmup, where values naming a
+// saved query (see lr query --save) stand for its question
+func warmupQuestions() ([]string, error) {
+	if len(mcpWarmup) == 0 {
+		return nil, nil
+	}
+	saved, err := loadSavedQueries(savedQueriesPath())
+	if err != nil {
+		return nil, err
+	}
+	questions := make([]string, 0, len(mcpWarmup))
+	for _, q := range mcpWarmup {
+		if s, ok := saved[q]; ok {
+			q = s.Question
+		}
+		questions = append(questions, q)
+	}
+	return questions, nil
+}
+
+// mcpEmbeddings is the client the mcp server's rag embeds questions with:
+// client, with the embeddings of questions asked before (and warmed up) cached
+func mcpEmbeddings(client LLMClient) *queryEmbeddingCache {
+	return &queryEmbeddingCache{LLMClient: client, model: getCurrentEmbeddingModel()}
+}
+
+// warmUp embeds questions with client, cached or not: embedding them again is
+// what opens the connection to the provider. warmupState.running must be set
+func warmUp(client LLMClient, questions []string) {
+	start := time.Now()
+	cache := newQueryEmbeddingCache(client, getCurrentEmbeddingModel())
+	var err error
+	done := 0
+	for _, q := range questions {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		_, err = cache.refresh(ctx, q)
+		cancel()
+		if err != nil {
+			err = fmt.Errorf("warmup failed embedding %q: %w", q, err)
+			break
+		}
+		done++
+	}
+	flushUsageFor(client, "mcp warmup", "")
+
+	preloadMutex.Lock()
+	warmupState.running, warmupState.done, warmupState.took, warmupState.err = false, done, time.Since(start), err
+	preloadMutex.Unlock()
+
+	if err != nil {
+		mcpLog.Print(err)
+	} else {
+		mcpLog.Printf("warmed up %d queries in %s", done, time.Since(start).Round(time.Millisecond))
+	}
+}
+
+// formatWarmup describes the warmup for server_status, "" without one
+func formatWarmup() (string, error) {
+	preloadMutex.RLock()
+	defer preloadMutex.RUnlock()
+	switch {
+	case len(mcpWarmup) == 0:
+		return "", nil
+	case warmupState.running:
+		return fmt.Sprintf("  warmup: embedding %d queries\n", len(mcpWarmup)), nil
+	case warmupState.err != nil:
+		return fmt.Sprintf("  warmup: failed after %d of %d queries\n", warmupState.done, len(mcpWarmup)), warmupState.err
+	}
+	return fmt.Sprintf("  warmup: %d queries embedded in %s\n", warmupState.done, warmupState.took.Round(time.Millisecond)), nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// watchHeartbeat is how often a running watcher refreshes its status file; a
+// status older than a few heartbeats means the watcher is stuck or suspended
+const watchHeartbeat = 15 * time.Second
+
+// WatchStatus is the state a review watcher publishes for `lr review status`
+// in a file next to the review index (name.watch)
+type WatchStatus struct {
+	PID         int       `json:"pid"`
+	StartedAt   time.Time `json:"started_at"`
+	Heartbeat   time.Time `json:"heartbeat"`
+	WatchedDirs int       `json:"watched_dirs"`
+	Pending     int       `json:"pending"`  // changed files waiting for the next update
+	Updating    bool      `json:"updating"` // an update is embedding right now
+}
+
+// watchStatusPath returns the watcher status file of the review index at indexPath
+func watchStatusPath(indexPath string) string {
+	return strings.TrimSuffix(indexPath, ".lrindex") + ".watch"
+}
+
+// writeWatchStatus replaces the watcher status file of the index at indexPath
+func writeWatchStatus(indexPath string, status WatchStatus) error {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	path := watchStatusPath(indexPath)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write watch status: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// readWatchStatus returns the status last written by a watcher of the index at
+// indexPath, or nil if no watcher has run since the session started
+func readWatchStatus(indexPath string) (*WatchStatus, error) {
+	data, err := os.ReadFile(watchStatusPath(indexPath))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var status WatchStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil, fmt.Errorf("corrupt watch status %s: %w", watchStatusPath(indexPath), err)
+	}
+	return &status, nil
+}
+
+// removeWatchStatus deletes the watcher status file of the index at indexPath
+func removeWatchStatus(indexPath string) {
+	os.Remove(watchStatusPath(indexPath))
+}
+
+// watcherHealth describes whether the watcher that wrote status is still
+// working: running, not responding (alive but no recent heartbeat, e.g.
+// suspended with Ctrl+Z) or not running (it exited or crashed). ok is true
+// only for a running watcher
+func watcherHealth(status *WatchStatus, now time.Time) (health string, ok bool) {
+	if status == nil {
+		return red("not running") + " (start it with 'lr review watch')", false
+	}
+	if err := syscall.Kill(status.PID, 0); err != nil && !errors.Is(err, syscall.EPERM) {
+		return fmt.Sprintf("%s (pid %d exited; restart it with 'lr review watch')", red("not running"), status.PID), false
+	}
+	if age := now.Sub(status.Heartbeat); age > 3*watchHeartbeat {
+		return fmt.Sprintf("%s (pid %d, last heartbeat %s ago)", yellow("not responding"), status.PID, age.Round(time.Second)), false
+	}
+	return fmt.Sprintf("%s (pid %d)", green("running"), status.PID), true
+}
+
+// reviewIndexFiles returns the size on disk of the review index at indexPath
+// with its segments and journal, the number of segments, and when the index
+// or a segment was last written
+func reviewIndexFiles(indexPath string) (size int64, segments int, lastUpdate time.Time) {
+	paths, _ := segmentPaths(indexPath)
+	files := append([]string{indexPath}, paths...)
+	for _, path := range files {
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		size += info.Size()
+		if info.ModTime().After(lastUpdate) {
+			lastUpdate = info.ModTime()
+		}
+	}
+	if info, err := os.Stat(journalPath(indexPath)); err == nil {
+		size += info.Size()
+	}
+	return size, len(paths), lastUpdate
+}
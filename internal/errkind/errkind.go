@@ -0,0 +1,20 @@
+// Package errkind tags errors with a sentinel kind that callers can test with
+// errors.Is, without changing the error message
+package errkind
+
+// kindError tags an error with a kind without changing its message
+type kindError struct {
+	err  error
+	kind error
+}
+
+func (e *kindError) Error() string   { return e.err.Error() }
+func (e *kindError) Unwrap() []error { return []error{e.err, e.kind} }
+
+// With tags err with kind (nil stays nil)
+func With(err, kind error) error {
+	if err == nil {
+		return nil
+	}
+	return &kindError{err: err, kind: kind}
+}
@@ -0,0 +1,125 @@
+package chunk
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// a giant file (a generated table, a bundled dependency, a long changelog)
+// can make up much of an index, crowding other files out of search results
+// and costing embeddings for little. capping the chunks per file keeps a
+// file's most useful chunks
+
+// CapStrategy picks which chunks of a file over the cap are kept
+type CapStrategy string
+
+const (
+	CapDensity CapStrategy = "density" // the most information-dense chunks (see Density)
+	CapSpread  CapStrategy = "spread"  // chunks spread evenly over the file
+	CapHead    CapStrategy = "head"    // the first chunks
+)
+
+// CapStrategies are all cap strategies, the default first
+var CapStrategies = []CapStrategy{CapDensity, CapSpread, CapHead}
+
+// CappedMetadataKey is the chunk metadata marking the chunks kept of a capped
+// file, as "kept/total" (e.g. "50/312")
+const CappedMetadataKey = "capped"
+
+// ParseCapStrategy parses a --cap-by value ("" is the default, density)
+func ParseCapStrategy(s string) (CapStrategy, error) {
+	if s == "" {
+		return CapDensity, nil
+	}
+	for _, c := range CapStrategies {
+		if string(c) == strings.ToLower(s) {
+			return c, nil
+		}
+	}
+	return "", fmt.Errorf("unknown cap strategy %q (use density, spread or head)", s)
+}
+
+var wordPattern = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]+`)
+
+// Density scores how much information text holds: its distinct words,
+// discounted by how often they repeat. prose and code score well; tables,
+// data and repetitive generated code score poorly
+func Density(text string) float64 {
+	words := wordPattern.FindAllString(text, -1)
+	if len(words) == 0 {
+		return 0
+	}
+	distinct := make(map[string]bool, len(words))
+	for _, w := range words {
+		distinct[strings.ToLower(w)] = true
+	}
+	n := float64(len(distinct))
+	return n * n / float64(len(words))
+}
+
+// Cap keeps at most max of a file's chunks (all when max <= 0), chosen by
+// strategy and in their order in the file. the kept chunks of a capped file
+// are marked with CappedMetadataKey
+func Cap(chunks []Chunk, max int, strategy CapStrategy) []Chunk {
+	if max <= 0 || len(chunks) <= max {
+		return chunks
+	}
+
+	var keep []int
+	switch strategy {
+	case CapHead:
+		for i := range max {
+			keep = append(keep, i)
+		}
+	case CapSpread:
+		step := float64(len(chunks)) / float64(max)
+		for i := range max {
+			keep = append(keep, int(math.Floor(float64(i)*step)))
+		}
+	default:
+		// the file's first chunk (its package doc, or a document's title and
+		// introduction) is kept whatever its density
+		order := make([]int, len(chunks)-1)
+		scores := make([]float64, len(chunks))
+		for i := range chunks {
+			scores[i] = Density(chunks[i].Text)
+			if i > 0 {
+				order[i-1] = i
+			}
+		}
+		sort.SliceStable(order, func(a, b int) bool {
+			return scores[order[a]] > scores[order[b]]
+		})
+		keep = append([]int{0}, order[:max-1]...)
+		sort.Ints(keep)
+	}
+
+	capped := strconv.Itoa(max) + "/" + strconv.Itoa(len(chunks))
+	kept := make([]Chunk, 0, max)
+	for _, i := range keep {
+		c := chunks[i]
+		metadata := make(map[string]string, len(c.Metadata)+1)
+		for k, v := range c.Metadata {
+			metadata[k] = v
+		}
+		metadata[CappedMetadataKey] = capped
+		c.Metadata = metadata
+		kept = append(kept, c)
+	}
+	return kept
+}
+
+// CappedTotal returns how many chunks the file of a chunk marked by Cap had,
+// or 0 if it wasn't capped
+func CappedTotal(c Chunk) int {
+	_, total, ok := strings.Cut(c.Metadata[CappedMetadataKey], "/")
+	if !ok {
+		return 0
+	}
+	n, _ := strconv.Atoi(total)
+	return n
+}
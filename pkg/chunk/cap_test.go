@@ -0,0 +1,51 @@
+package chunk
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCap(t *testing.T) {
+	var chunks []Chunk
+	for i := range 6 {
+		text := strings.Repeat("row row row ", 20) // repetitive data
+		if i == 2 || i == 4 {
+			text = fmt.Sprintf("func handle%d(conn net.Conn, opts Options) error { return dispatch(conn, opts.Timeout) }", i)
+		}
+		chunks = append(chunks, Chunk{Text: text, Source: fmt.Sprintf("big.go (part %d)", i+1), Metadata: map[string]string{}})
+	}
+	sources := func(chunks []Chunk) []string {
+		var s []string
+		for _, c := range chunks {
+			s = append(s, c.Source)
+		}
+		return s
+	}
+
+	if got := Cap(chunks, 0, CapDensity); len(got) != 6 {
+		t.Errorf("expected no cap with 0, got %d chunks", len(got))
+	}
+	// the first chunk is kept, then the densest, in file order
+	got := Cap(chunks, 3, CapDensity)
+	if want := []string{"big.go (part 1)", "big.go (part 3)", "big.go (part 5)"}; !reflect.DeepEqual(sources(got), want) {
+		t.Errorf("density kept %v, want %v", sources(got), want)
+	}
+	if got[0].Metadata[CappedMetadataKey] != "3/6" || CappedTotal(got[1]) != 6 {
+		t.Errorf("expected kept chunks marked 3/6, got %q", got[0].Metadata[CappedMetadataKey])
+	}
+	if chunks[0].Metadata[CappedMetadataKey] != "" {
+		t.Error("capping changed the metadata of the chunks passed in")
+	}
+	if got := sources(Cap(chunks, 2, CapSpread)); !reflect.DeepEqual(got, []string{"big.go (part 1)", "big.go (part 4)"}) {
+		t.Errorf("spread kept %v", got)
+	}
+	if got := sources(Cap(chunks, 2, CapHead)); !reflect.DeepEqual(got, []string{"big.go (part 1)", "big.go (part 2)"}) {
+		t.Errorf("head kept %v", got)
+	}
+
+	if _, err := ParseCapStrategy("biggest"); err == nil {
+		t.Error("expected an unknown cap strategy rejected")
+	}
+}
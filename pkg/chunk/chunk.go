@@ -0,0 +1,514 @@
+// Package chunk splits documents into embedding-sized chunks, by headers for
+// markdown and by functions for code
+package chunk
+
+import (
+	"strconv"
+	"strings"
+
+	"lr/pkg/loader"
+)
+
+// DefaultMaxSize is the chunk size lr indexes with, in characters
+const DefaultMaxSize = 1500
+
+// DefaultMinSize is the size, in characters, below which a section is merged
+// into a neighbor instead of becoming a chunk of its own
+const DefaultMinSize = 50
+
+// Chunk represents a text chunk with metadata. chunks made by ChunkDocument
+// record the lines of the file they span as "start_line" and "end_line"
+// (1-based, inclusive), their position among the document's sections as
+// "chunk_index" ("3", or "3.1" for the parts of a split section) and a stable
+// id (see IDMetadataKey)
+type Chunk struct {
+	Text     string
+	Source   string
+	Metadata map[string]string
+}
+
+// ChunkDocument splits a document into smaller chunks
+// uses different strategies based on document type
+func ChunkDocument(doc loader.Document, maxChunkSize int) []Chunk {
+	return ChunkDocumentSized(doc, maxChunkSize, DefaultMinSize)
+}
+
+// ChunkDocumentSized is ChunkDocument with sections shorter than minChunkSize
+// (short constants, one-line docs) merged into a neighboring section
+func ChunkDocumentSized(doc loader.Document, maxChunkSize, minChunkSize int) []Chunk {
+	var chunks []Chunk
+	docType := doc.Metadata["type"]
+
+	var sections []string
+	var blocks []namedBlock
+	var blocksKey string // the metadata recording the names of blocks
+
+	// choose chunking strategy based on document type
+	if docType == "markdown" {
+		// split by markdown headers
+		sections = splitByHeaders(doc.Content)
+	} else if docType == "go" || docType == "javascript" || docType == "typescript" ||
+		docType == "python" || docType == "java" || docType == "c" {
+		// split code by functions/methods
+		sections = splitByFunctions(doc.Content)
+	} else if _, ok := templateSplitters[docType]; ok {
+		// split view templates by component
+		sections, blocks = splitTemplates(doc.Content, docType)
+		blocksKey = ComponentMetadataKey
+	} else if docType == "sql" {
+		// split sql by statement, keeping a table's statements together
+		sections = splitSQL(doc.Content, maxChunkSize)
+	} else if _, ok := configTypes[docType]; ok {
+		// split config files by key path
+		sections, blocks = splitConfig(doc.Content, docType, maxChunkSize)
+		blocksKey = KeyPathMetadataKey
+	} else if rules, ok := definitionRulesByType[docType]; ok {
+		// split rust, c++ and c# by definitions, descending into containers
+		sections = splitByDefinitions(doc.Content, rules)
+	} else {
+		// fallback: split by paragraphs
+		sections = splitByParagraphs(doc.Content, maxChunkSize)
+	}
+
+	sections = mergeSmallSections(doc.Content, sections, minChunkSize, maxChunkSize)
+
+	for i, section := range sections {
+		if strings.TrimSpace(section) == "" {
+			continue
+		}
+
+		// estimate tokens (rough: 1 token ≈ 4 characters)
+		estimatedTokens := len(section) / 4
+
+		// if section is too large even for aggressive splitting, truncate it
+		// openai embedding limit is 8192 tokens, we use 5000 to be very safe
+		if estimatedTokens > 5000 {
+			// aggressively split by lines (~2000 tokens per chunk for safety)
+			subChunks := splitByLines(section, 8000)
+			for j, subChunk := range subChunks {
+				chunk := Chunk{
+					Text:   subChunk,
+					Source: doc.Source,
+					Metadata: map[string]string{
+						"source":      doc.Source,
+						"type":        docType,
+						"chunk_index": strconv.Itoa(i) + "." + strconv.Itoa(j),
+					},
+				}
+				chunks = append(chunks, chunk)
+			}
+		} else if len(section) <= maxChunkSize {
+			// section is small enough, use as is
+			chunk := Chunk{
+				Text:   section,
+				Source: doc.Source,
+				Metadata: map[string]string{
+					"source":      doc.Source,
+					"type":        docType,
+					"chunk_index": strconv.Itoa(i),
+				},
+			}
+			chunks = append(chunks, chunk)
+		} else {
+			// split large sections by paragraphs
+			subChunks := splitByParagraphs(section, maxChunkSize)
+			for j, subChunk := range subChunks {
+				chunk := Chunk{
+					Text:   subChunk,
+					Source: doc.Source,
+					Metadata: map[string]string{
+						"source":      doc.Source,
+						"type":        docType,
+						"chunk_index": strconv.Itoa(i) + "." + strconv.Itoa(j),
+					},
+				}
+				chunks = append(chunks, chunk)
+			}
+		}
+	}
+
+	if docType != "data" {
+		// a data file's chunk is its summary, not lines of the file
+		setLineRanges(chunks, doc)
+	}
+	if blocks != nil {
+		setBlockNames(chunks, blocks, doc, blocksKey)
+	}
+	if docType == "sql" {
+		setTables(chunks)
+	}
+	if docType == "go" {
+		copyMetadata(chunks, doc, loader.GoPackageMetadataKey, loader.GoImportsMetadataKey)
+	}
+	if refTypes[docType] {
+		setReferences(chunks)
+	}
+	setIDs(chunks, doc.Source)
+	return chunks
+}
+
+// setLineRanges records the lines each chunk spans in the document's file.
+// chunks are substrings of the content in order, so each is looked for after
+// the previous one; a document that is a part of a larger file gives the line
+// it starts at as "first_line"
+func setLineRanges(chunks []Chunk, doc loader.Document) {
+	firstLine := 1
+	if n, err := strconv.Atoi(doc.Metadata["first_line"]); err == nil {
+		firstLine = n
+	}
+	offset := 0
+	for _, c := range chunks {
+		text := strings.TrimSpace(c.Text)
+		i := strings.Index(doc.Content[offset:], text)
+		if text == "" || i < 0 {
+			continue
+		}
+		start := offset + i
+		startLine := firstLine + strings.Count(doc.Content[:start], "\n")
+		c.Metadata["start_line"] = strconv.Itoa(startLine)
+		c.Metadata["end_line"] = strconv.Itoa(startLine + strings.Count(text, "\n"))
+		offset = start + len(text)
+	}
+}
+
+// namedBlock is a block of a file, lines [start, end), and its name: the key
+// path of a config block, the name of a template component. header is the line
+// that names it
+type namedBlock struct {
+	path               string
+	header, start, end int
+}
+
+// setBlockNames records the names of the blocks each chunk holds under key:
+// those whose headers are in the chunk, or the block the chunk is part of
+func setBlockNames(chunks []Chunk, blocks []namedBlock, doc loader.Document, key string) {
+	firstLine := 1
+	if n, err := strconv.Atoi(doc.Metadata["first_line"]); err == nil {
+		firstLine = n
+	}
+	for _, c := range chunks {
+		start, end, ok := LineRange(c)
+		if !ok {
+			continue
+		}
+		start, end = start-firstLine, end-firstLine
+		var paths []string
+		for _, b := range blocks {
+			if b.path == "" || b.header < start || b.header > end {
+				continue
+			}
+			if len(paths) == 0 || paths[len(paths)-1] != b.path {
+				paths = append(paths, b.path)
+			}
+		}
+		if len(paths) == 0 {
+			for _, b := range blocks {
+				if b.start <= start && start < b.end && b.path != "" {
+					paths = append(paths, b.path)
+					break
+				}
+			}
+		}
+		if len(paths) > 0 {
+			c.Metadata[key] = strings.Join(paths, ", ")
+		}
+	}
+}
+
+// ImportLinkMetadataKey is the metadata of a search result added because its go
+// package is imported by, or imports, the file of the top result; it holds
+// that file
+const ImportLinkMetadataKey = "import_link"
+
+// CallerOfMetadataKey and CalleeOfMetadataKey are the metadata of a search
+// result added because it calls, or is called by, the functions of another
+// result; they hold those functions
+const (
+	CallerOfMetadataKey = "caller_of"
+	CalleeOfMetadataKey = "callee_of"
+)
+
+// copyMetadata copies the document metadata keys (those it has) to each chunk
+func copyMetadata(chunks []Chunk, doc loader.Document, keys ...string) {
+	for _, c := range chunks {
+		for _, key := range keys {
+			if v, ok := doc.Metadata[key]; ok {
+				c.Metadata[key] = v
+			}
+		}
+	}
+}
+
+// Structure describes where in its file's structure a chunk is, from its
+// metadata: "key path: server.tls", "tables: orders, users" or "component:
+// Cart", or why it was retrieved ("linked by imports to: server/auth.go",
+// "calls: Connect"); "" for chunks of other files
+func Structure(c Chunk) string {
+	for _, kv := range [][2]string{
+		{KeyPathMetadataKey, "key path"},
+		{TablesMetadataKey, "tables"},
+		{ComponentMetadataKey, "component"},
+		{ImportLinkMetadataKey, "linked by imports to"},
+		{CallerOfMetadataKey, "calls"},
+		{CalleeOfMetadataKey, "called by"},
+	} {
+		if v := c.Metadata[kv[0]]; v != "" {
+			return kv[1] + ": " + v
+		}
+	}
+	return ""
+}
+
+// LineRange returns the lines of its file a chunk spans, if recorded
+func LineRange(c Chunk) (start, end int, ok bool) {
+	start, err1 := strconv.Atoi(c.Metadata["start_line"])
+	end, err2 := strconv.Atoi(c.Metadata["end_line"])
+	return start, end, err1 == nil && err2 == nil
+}
+
+// section is a section of a document and the span of the document content it
+// was found at (start is -1 if it wasn't)
+type section struct {
+	text       string
+	start, end int
+}
+
+// join returns a and b, which follows it, as one section. sections found in
+// content are joined by the content between them, so the result can still be
+// located in the file
+func (a section) join(content string, b section) section {
+	if a.start >= 0 && b.start >= a.end {
+		return section{content[a.start:b.end], a.start, b.end}
+	}
+	return section{a.text + "\n\n" + b.text, -1, -1}
+}
+
+// mergeSmallSections merges sections shorter than minSize into the section
+// after them (headings, package clauses and doc comments precede what they
+// belong to), or the one before when that doesn't fit in maxSize. a small
+// section with no neighbor to take it is kept on its own
+func mergeSmallSections(content string, texts []string, minSize, maxSize int) []string {
+	var sections []section
+	offset := 0
+	for _, text := range texts {
+		trimmed := strings.TrimSpace(text)
+		if trimmed == "" {
+			continue
+		}
+		s := section{trimmed, -1, -1}
+		if i := strings.Index(content[offset:], trimmed); i >= 0 {
+			s.start, s.end = offset+i, offset+i+len(trimmed)
+			offset = s.end
+		}
+		sections = append(sections, s)
+	}
+
+	var merged []section
+	var pending *section
+	mergeBack := func(s section) bool {
+		n := len(merged)
+		if n == 0 {
+			return false
+		}
+		if joined := merged[n-1].join(content, s); len(joined.text) <= maxSize {
+			merged[n-1] = joined
+			return true
+		}
+		return false
+	}
+	for _, s := range sections {
+		if pending != nil {
+			if joined := pending.join(content, s); len(joined.text) <= maxSize {
+				s = joined
+			} else if !mergeBack(*pending) {
+				merged = append(merged, *pending)
+			}
+			pending = nil
+		}
+		if len(s.text) < minSize {
+			pending = &s
+			continue
+		}
+		merged = append(merged, s)
+	}
+	if pending != nil && !mergeBack(*pending) {
+		merged = append(merged, *pending)
+	}
+
+	result := make([]string, len(merged))
+	for i, s := range merged {
+		result[i] = s.text
+	}
+	return result
+}
+
+// splitByHeaders splits content by markdown headers
+func splitByHeaders(content string) []string {
+	var sections []string
+	lines := strings.Split(content, "\n")
+	var currentSection strings.Builder
+
+	for _, line := range lines {
+		// check if line is a header (starts with #)
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			// save current section if not empty
+			if currentSection.Len() > 0 {
+				sections = append(sections, strings.TrimSpace(currentSection.String()))
+				currentSection.Reset()
+			}
+		}
+		currentSection.WriteString(line)
+		currentSection.WriteString("\n")
+	}
+
+	// add last section
+	if currentSection.Len() > 0 {
+		sections = append(sections, strings.TrimSpace(currentSection.String()))
+	}
+
+	return sections
+}
+
+// splitByParagraphs splits content by paragraphs, keeping size under maxSize
+func splitByParagraphs(content string, maxSize int) []string {
+	var chunks []string
+	paragraphs := strings.Split(content, "\n\n")
+	var currentChunk strings.Builder
+
+	for _, para := range paragraphs {
+		// if single paragraph is too large, split it by lines first
+		if len(para) > maxSize {
+			// save current chunk first
+			if currentChunk.Len() > 0 {
+				chunks = append(chunks, strings.TrimSpace(currentChunk.String()))
+				currentChunk.Reset()
+			}
+			// split the large paragraph by lines
+			subChunks := splitByLines(para, maxSize)
+			chunks = append(chunks, subChunks...)
+			continue
+		}
+
+		// if adding this paragraph exceeds max size, save current chunk
+		if currentChunk.Len()+len(para)+2 > maxSize && currentChunk.Len() > 0 {
+			chunks = append(chunks, strings.TrimSpace(currentChunk.String()))
+			currentChunk.Reset()
+		}
+
+		currentChunk.WriteString(para)
+		currentChunk.WriteString("\n\n")
+	}
+
+	// add last chunk
+	if currentChunk.Len() > 0 {
+		chunks = append(chunks, strings.TrimSpace(currentChunk.String()))
+	}
+
+	return chunks
+}
+
+// splitByLines splits content by lines when other methods fail
+func splitByLines(content string, maxSize int) []string {
+	var chunks []string
+	lines := strings.Split(content, "\n")
+	var currentChunk strings.Builder
+
+	for _, line := range lines {
+		// if single line is too large, split it by characters
+		if len(line) > maxSize {
+			// save current chunk first
+			if currentChunk.Len() > 0 {
+				chunks = append(chunks, currentChunk.String())
+				currentChunk.Reset()
+			}
+			// split the long line into smaller pieces
+			for i := 0; i < len(line); i += maxSize {
+				end := i + maxSize
+				if end > len(line) {
+					end = len(line)
+				}
+				chunks = append(chunks, line[i:end])
+			}
+			continue
+		}
+
+		// if adding this line exceeds max size, save current chunk
+		if currentChunk.Len()+len(line)+1 > maxSize && currentChunk.Len() > 0 {
+			chunks = append(chunks, currentChunk.String())
+			currentChunk.Reset()
+		}
+
+		currentChunk.WriteString(line)
+		currentChunk.WriteString("\n")
+	}
+
+	// add last chunk
+	if currentChunk.Len() > 0 {
+		chunks = append(chunks, currentChunk.String())
+	}
+
+	return chunks
+}
+
+// splitByFunctions attempts to split code by function/method definitions
+func splitByFunctions(content string) []string {
+	var sections []string
+	lines := strings.Split(content, "\n")
+	var currentSection strings.Builder
+	var braceCount int
+	inFunction := false
+
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		// detect function start (simple heuristic)
+		// go: func keyword
+		// js/ts: function keyword, arrow functions, method definitions
+		// python: def keyword
+		// java: public/private/protected methods, class definitions
+		// c: function definitions with return type
+		isFunctionStart := strings.HasPrefix(trimmed, "func ") ||
+			strings.HasPrefix(trimmed, "function ") ||
+			strings.HasPrefix(trimmed, "def ") ||
+			strings.HasPrefix(trimmed, "class ") ||
+			strings.HasPrefix(trimmed, "public ") ||
+			strings.HasPrefix(trimmed, "private ") ||
+			strings.HasPrefix(trimmed, "protected ") ||
+			(strings.Contains(trimmed, "=> {") || strings.Contains(trimmed, "=>")) ||
+			(i > 0 && strings.Contains(trimmed, "(") && strings.Contains(trimmed, ")") && strings.Contains(trimmed, "{"))
+
+		if isFunctionStart && !inFunction && braceCount == 0 {
+			// save previous section if not empty
+			if currentSection.Len() > 0 {
+				sections = append(sections, strings.TrimSpace(currentSection.String()))
+				currentSection.Reset()
+			}
+			inFunction = true
+		}
+
+		currentSection.WriteString(line)
+		currentSection.WriteString("\n")
+
+		// track braces to know when function ends
+		braceCount += strings.Count(line, "{") - strings.Count(line, "}")
+
+		// function ended
+		if inFunction && braceCount == 0 && strings.Contains(line, "}") {
+			sections = append(sections, strings.TrimSpace(currentSection.String()))
+			currentSection.Reset()
+			inFunction = false
+		}
+	}
+
+	// add remaining content
+	if currentSection.Len() > 0 {
+		sections = append(sections, strings.TrimSpace(currentSection.String()))
+	}
+
+	// fallback: if we didn't find functions, split by blank lines
+	if len(sections) <= 1 {
+		return splitByParagraphs(content, 2000)
+	}
+
+	return sections
+}
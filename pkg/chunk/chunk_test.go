@@ -0,0 +1,83 @@
+package chunk
+
+import (
+	"strings"
+	"testing"
+
+	"lr/pkg/loader"
+)
+
+func TestChunkLineRanges(t *testing.T) {
+	content := `package p
+
+func First(a int) int {
+	// first adds one to its argument and returns it
+	return a + 1
+}
+
+func Second(b int) int {
+	// second doubles its argument and returns the result
+	return b * 2
+}
+`
+	doc := loader.Document{Content: content, Source: "p.go", Metadata: map[string]string{"type": "go", "first_line": "101"}}
+	chunks := ChunkDocument(doc, 1000)
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 chunks, got %d", len(chunks))
+	}
+	// the package clause is too small for a chunk of its own, so it is merged into First
+	for i, want := range [][2]int{{101, 106}, {108, 111}} {
+		start, end, ok := LineRange(chunks[i])
+		if !ok || start != want[0] || end != want[1] {
+			t.Errorf("chunk %d spans %d-%d (%v), want %d-%d", i, start, end, ok, want[0], want[1])
+		}
+	}
+}
+
+func TestSmallSectionsMerged(t *testing.T) {
+	content := `# Limits
+
+MaxConns = 5
+
+## Timeouts
+
+requests time out after 30 seconds unless the client overrides the deadline
+`
+	doc := loader.Document{Content: content, Source: "limits.md", Metadata: map[string]string{"type": "markdown"}}
+	chunks := ChunkDocument(doc, 1000)
+	if len(chunks) != 1 || !strings.Contains(chunks[0].Text, "MaxConns = 5") {
+		t.Fatalf("expected the short section merged into the next one, got %+v", chunks)
+	}
+	if start, _, _ := LineRange(chunks[0]); start != 1 {
+		t.Errorf("expected the merged chunk to start at line 1, got %d", start)
+	}
+
+	if chunks := ChunkDocumentSized(doc, 1000, 10); len(chunks) != 2 {
+		t.Errorf("expected 2 chunks with a 10 character minimum, got %d", len(chunks))
+	}
+}
+
+func TestChunkIDsStable(t *testing.T) {
+	first := "func First(a int) int {\n\t// first adds one to its argument and returns it\n\treturn a + 1\n}"
+	second := "func Second(b int) int {\n\t// second doubles its argument and returns the result\n\treturn b * 2\n}"
+	chunkIDs := func(content string) []string {
+		doc := loader.Document{Content: content, Source: "p.go (part 2)", Metadata: map[string]string{"type": "go"}}
+		var ids []string
+		for _, c := range ChunkDocument(doc, 1000) {
+			ids = append(ids, c.Metadata[IDMetadataKey])
+		}
+		return ids
+	}
+
+	before := chunkIDs(first + "\n\n" + second + "\n")
+	after := chunkIDs(first + "\n\n" + strings.Replace(second, "* 2", "* 3", 1) + "\n\n" + first + "\n")
+	if len(before) != 2 || len(after) != 3 || !strings.HasPrefix(before[0], "p.go#") {
+		t.Fatalf("unexpected ids: %v %v", before, after)
+	}
+	if before[0] != after[0] || before[1] == after[1] {
+		t.Errorf("only the changed chunk should get a new id: %v %v", before, after)
+	}
+	if after[2] != after[0]+"-2" {
+		t.Errorf("expected the repeated chunk to get %s-2, got %s", after[0], after[2])
+	}
+}
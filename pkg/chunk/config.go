@@ -0,0 +1,262 @@
+package chunk
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// config files (yaml, toml, json, hcl, dockerfiles) are split by key path:
+// each top-level section or block is a chunk, and one too large for a chunk is
+// split into its children. the key paths a chunk holds are recorded, so a
+// question about "the retry timeout" can be answered with client.retry.timeout
+// and where it is set
+
+// KeyPathMetadataKey is the chunk metadata holding the key paths of the config
+// blocks a chunk holds, comma-separated, e.g. "server.tls" or
+// "resource.aws_s3_bucket.logs"
+const KeyPathMetadataKey = "key_path"
+
+// configLine is a line of a config file as the block splitting sees it
+type configLine struct {
+	level    int    // indentation (yaml) or nesting depth of the line
+	key      string // the key the line starts a block for, "" if none; "[]" for list items
+	attached bool   // a comment, which belongs to the block after it
+}
+
+var (
+	yamlKeyPattern    = regexp.MustCompile(`^(\s*)(-\s+)?("[^"]*"|'[^']*'|[^\s#'"-][^:#]*?)?:(?:\s|$)`)
+	yamlItemPattern   = regexp.MustCompile(`^(\s*)-(?:\s|$)`)
+	jsonKeyPattern    = regexp.MustCompile(`^\s*"((?:[^"\\]|\\.)*)"\s*:`)
+	hclBlockPattern   = regexp.MustCompile(`^\s*([\w-]+)((?:\s+(?:"[^"]*"|[\w-]+))*)\s*\{`)
+	hclAttrPattern    = regexp.MustCompile(`^\s*([\w-]+)\s*=`)
+	tomlTablePattern  = regexp.MustCompile(`^\s*\[(\[)?\s*([^\]]+?)\s*\]`)
+	tomlKeyPattern    = regexp.MustCompile(`^\s*("[^"]*"|[\w.-]+)\s*=`)
+	dockerFromPattern = regexp.MustCompile(`(?i)^\s*FROM\s+(?:--\S+\s+)*(\S+)(?:\s+AS\s+(\S+))?`)
+)
+
+// configTypes are the document types split by key path
+var configTypes = map[string]func([]string) []configLine{
+	"yaml":       yamlLines,
+	"json":       jsonLines,
+	"hcl":        hclLines,
+	"toml":       tomlLines,
+	"dockerfile": dockerfileLines,
+}
+
+// yamlLines classifies yaml lines by indentation; keys and list items start blocks
+func yamlLines(lines []string) []configLine {
+	out := make([]configLine, len(lines))
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		out[i].level = indent
+		switch {
+		case trimmed == "":
+		case strings.HasPrefix(trimmed, "#") || trimmed == "---":
+			out[i].attached = true
+		case yamlItemPattern.MatchString(line):
+			out[i].key = "[]"
+		default:
+			if m := yamlKeyPattern.FindStringSubmatch(line); m != nil && m[3] != "" {
+				out[i].key = strings.Trim(m[3], `"'`)
+			}
+		}
+	}
+	return out
+}
+
+// jsonLines classifies json lines by nesting depth; keys and the objects and
+// arrays of arrays start blocks
+func jsonLines(lines []string) []configLine {
+	out := make([]configLine, len(lines))
+	depth := 0
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		out[i].level = depth
+		if m := jsonKeyPattern.FindStringSubmatch(line); m != nil && depth > 0 {
+			out[i].key = m[1]
+		} else if depth > 0 && (strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[")) {
+			out[i].key = "[]"
+		}
+		depth = max(depth+bracketDelta(line, "{[", "}]"), 0)
+	}
+	return out
+}
+
+// hclLines classifies hcl (and terraform) lines by nesting depth; blocks and
+// attributes start blocks, a block's path being its type and labels
+func hclLines(lines []string) []configLine {
+	out := make([]configLine, len(lines))
+	depth := 0
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		out[i].level = depth
+		switch {
+		case strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "//") || strings.HasPrefix(trimmed, "/*"):
+			out[i].attached = true
+		case hclBlockPattern.MatchString(line) && !hclAttrPattern.MatchString(line):
+			m := hclBlockPattern.FindStringSubmatch(line)
+			key := m[1]
+			for _, label := range strings.Fields(m[2]) {
+				key += "." + strings.Trim(label, `"`)
+			}
+			out[i].key = key
+		default:
+			if m := hclAttrPattern.FindStringSubmatch(line); m != nil {
+				out[i].key = m[1]
+			}
+		}
+		depth = max(depth+bracketDelta(line, "{", "}"), 0)
+	}
+	return out
+}
+
+// tomlLines classifies toml lines: tables start top-level blocks, and keys the
+// blocks of the table they are in
+func tomlLines(lines []string) []configLine {
+	out := make([]configLine, len(lines))
+	inTable := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "#"):
+			out[i].attached = true
+		case tomlTablePattern.MatchString(line):
+			m := tomlTablePattern.FindStringSubmatch(line)
+			out[i].key = m[2]
+			if m[1] != "" {
+				out[i].key += "[]" // an array of tables
+			}
+			inTable = true
+		default:
+			if m := tomlKeyPattern.FindStringSubmatch(line); m != nil {
+				out[i].key = strings.Trim(m[1], `"`)
+				if inTable {
+					out[i].level = 1
+				}
+			}
+		}
+	}
+	return out
+}
+
+// dockerfileLines classifies dockerfile lines: each build stage is a block,
+// named by its stage name or base image
+func dockerfileLines(lines []string) []configLine {
+	out := make([]configLine, len(lines))
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			out[i].attached = true
+		} else if m := dockerFromPattern.FindStringSubmatch(line); m != nil {
+			out[i].key = m[1]
+			if m[2] != "" {
+				out[i].key = m[2]
+			}
+		}
+	}
+	return out
+}
+
+// bracketDelta returns the brackets line opens minus those it closes, outside
+// of strings
+func bracketDelta(line, open, close string) int {
+	delta := 0
+	inString := false
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case inString && c == '\\':
+			i++
+		case c == '"':
+			inString = !inString
+		case inString:
+		case strings.IndexByte(open, c) >= 0:
+			delta++
+		case strings.IndexByte(close, c) >= 0:
+			delta--
+		}
+	}
+	return delta
+}
+
+// splitConfig splits a config file of docType into sections by key path, and
+// returns the blocks the sections are. a block longer than maxSize is split
+// into its children
+func splitConfig(content, docType string, maxSize int) ([]string, []namedBlock) {
+	lines := strings.Split(content, "\n")
+	info := configTypes[docType](lines)
+	blocks := configSections(lines, info, 0, len(lines), "", maxSize)
+
+	return blockTexts(lines, blocks), blocks
+}
+
+// configSections returns the blocks of lines [lo, hi) under parent: the lines
+// before the first block, then each block with the comments above it, split
+// into its children when longer than maxSize
+func configSections(lines []string, info []configLine, lo, hi int, parent string, maxSize int) []namedBlock {
+	// blocks start at the keys of the outermost level in the range
+	level := -1
+	for i := lo; i < hi; i++ {
+		if info[i].key != "" && (level < 0 || info[i].level < level) {
+			level = info[i].level
+		}
+	}
+	var headers []int
+	for i := lo; i < hi; i++ {
+		if info[i].key != "" && info[i].level == level {
+			headers = append(headers, i)
+		}
+	}
+	if len(headers) == 0 {
+		return []namedBlock{{path: parent, header: lo, start: lo, end: hi}}
+	}
+
+	starts := make([]int, len(headers))
+	for j, h := range headers {
+		starts[j] = h
+		for starts[j] > lo && info[starts[j]-1].attached {
+			starts[j]--
+		}
+	}
+
+	var blocks []namedBlock
+	if starts[0] > lo {
+		blocks = append(blocks, namedBlock{path: parent, header: lo, start: lo, end: starts[0]})
+	}
+	items := make(map[string]int)
+	for j, h := range headers {
+		end := hi
+		if j+1 < len(starts) {
+			end = starts[j+1]
+		}
+		b := namedBlock{path: keyPath(parent, info[h].key, items), header: h, start: starts[j], end: end}
+
+		if size := len(strings.Join(lines[b.start:b.end], "\n")); size <= maxSize || h+1 >= end {
+			blocks = append(blocks, b)
+			continue
+		}
+		// too large: the header, then the block's children
+		children := configSections(lines, info, h+1, end, b.path, maxSize)
+		blocks = append(blocks, namedBlock{path: b.path, header: h, start: b.start, end: h + 1})
+		blocks = append(blocks, children...)
+	}
+	return blocks
+}
+
+// keyPath returns the path of key under parent. list items ("[]", or a
+// "name[]" array of tables) are numbered by their position among their
+// siblings, counted in items
+func keyPath(parent, key string, items map[string]int) string {
+	if name, ok := strings.CutSuffix(key, "[]"); ok {
+		n := items[key]
+		items[key]++
+		key = name + "[" + strconv.Itoa(n) + "]"
+		if name == "" {
+			return parent + key
+		}
+	}
+	if parent == "" {
+		return key
+	}
+	return parent + "." + key
+}
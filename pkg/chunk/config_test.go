@@ -0,0 +1,88 @@
+package chunk
+
+import (
+	"strings"
+	"testing"
+
+	"lr/pkg/loader"
+)
+
+func TestConfigKeyPaths(t *testing.T) {
+	tests := []struct {
+		docType, content string
+		maxSize          int
+		want             []string // the key paths of the chunks
+	}{
+		{"yaml", `# the client settings
+client:
+  retry:
+    attempts: 3
+    timeout: 5s
+  endpoints:
+    - name: primary
+      url: https://a.example.com
+    - name: backup
+      url: https://b.example.com
+server:
+  port: 8080
+  tls:
+    cert: /etc/tls/cert.pem
+`, 120, []string{"client", "client.retry", "client.endpoints", "server"}},
+		{"json", `{
+  "name": "service",
+  "retry": {
+    "attempts": 3,
+    "timeout": "5s"
+  },
+  "servers": [
+    {"host": "a.example.com", "port": 4222}
+  ]
+}
+`, 1000, []string{"", "name", "retry", "servers"}},
+		{"hcl", `# the log bucket
+resource "aws_s3_bucket" "logs" {
+  bucket = "my-logs"
+  versioning {
+    enabled = true
+  }
+}
+
+variable "region" {
+  default = "us-east-1"
+}
+`, 1000, []string{"resource.aws_s3_bucket.logs", "variable.region"}},
+		{"toml", `title = "service configuration file"
+
+[client.retry]
+attempts = 3
+timeout = "5s"
+
+[[servers]]
+host = "a.example.com"
+
+[[servers]]
+host = "b.example.com"
+`, 1000, []string{"title", "client.retry", "servers[0]", "servers[1]"}},
+		{"dockerfile", `FROM golang:1.24 AS build
+WORKDIR /src
+COPY . .
+RUN go build -o /bin/app ./cmd/app
+
+FROM gcr.io/distroless/static
+COPY --from=build /bin/app /app
+ENTRYPOINT ["/app"]
+`, 1000, []string{"build", "gcr.io/distroless/static"}},
+	}
+	for _, tt := range tests {
+		doc := loader.Document{Content: tt.content, Source: "conf", Metadata: map[string]string{"type": tt.docType}}
+		// no merging, to see every block
+		chunks := ChunkDocumentSized(doc, tt.maxSize, 1)
+		var got []string
+		for _, c := range chunks {
+			got = append(got, c.Metadata[KeyPathMetadataKey])
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("%s: chunk key paths %q, want %q", tt.docType, got, tt.want)
+		}
+	}
+}
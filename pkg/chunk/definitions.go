@@ -0,0 +1,157 @@
+package chunk
+
+import (
+	"regexp"
+	"strings"
+)
+
+// rust, c++ and c# are split by definitions: functions, methods, types and
+// impls each become a section. containers (rust impl/mod/trait, c++
+// namespaces and classes, c# namespaces and types) are descended into, so
+// their methods are sections of their own rather than one large block, and
+// the comments and attributes before a definition stay with it
+
+// definitionRules recognize the lines of a language that start a definition
+// or a container, and the lines (comments, attributes) that belong to the
+// definition after them
+type definitionRules struct {
+	container  *regexp.Regexp
+	definition *regexp.Regexp
+	attached   *regexp.Regexp
+}
+
+// controlPattern matches statements that look like definitions to the loose
+// c++ and c# rules (a name followed by parentheses)
+var controlPattern = regexp.MustCompile(`^(?:if|else|for|foreach|while|switch|return|do|case|catch|try|throw|lock|using\s*\(|sizeof|delete|new)\b`)
+
+var definitionRulesByType = map[string]definitionRules{
+	"rust": {
+		container:  regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?(?:unsafe\s+)?(?:impl\b|trait\s+\w+|mod\s+\w+\s*\{)`),
+		definition: regexp.MustCompile(`^(?:pub(?:\([^)]*\))?\s+)?(?:(?:const|async|unsafe|default|extern\s+"[^"]*")\s+)*(?:fn|struct|enum|union|type|const|static|macro_rules!)\s*\w`),
+		attached:   regexp.MustCompile(`^(?:#!?\[|//|/\*|\*)`),
+	},
+	"cpp": {
+		container:  regexp.MustCompile(`^(?:(?:inline\s+)?namespace\b[^;]*$|extern\s+"C"\s*\{|(?:template\s*<.*>\s*)?(?:class|struct)\s+\w+[^;()]*$)`),
+		definition: regexp.MustCompile(`^(?:[\w:<>,\s\*&~\[\]]+\(|(?:enum|union|typedef|using)\b)`),
+		attached:   regexp.MustCompile(`^(?://|/\*|\*|template\s*<.*>\s*$|\[\[)`),
+	},
+	"csharp": {
+		container:  regexp.MustCompile(`^(?:(?:public|private|protected|internal|static|abstract|sealed|partial|readonly|unsafe|new|file)\s+)*(?:namespace|class|struct|interface|record(?:\s+class|\s+struct)?)\s+[\w.<>]+[^;(]*$`),
+		definition: regexp.MustCompile(`^(?:(?:public|private|protected|internal|static|virtual|override|abstract|async|sealed|extern|unsafe|new|partial|readonly|const|event)\s+|(?:enum|delegate|record)\b|[\w<>\[\],.?]+\s+\w+\s*(?:<[^>]*>)?\s*\(|\w+\s*\()`),
+		attached:   regexp.MustCompile(`^(?://|/\*|\*|\[)`),
+	},
+}
+
+// splitByDefinitions splits code of a language with definitionRules into its
+// definitions. lines between definitions (imports, declarations) form sections
+// of their own
+func splitByDefinitions(content string, rules definitionRules) []string {
+	var (
+		sections         []string
+		current, pending []string // pending holds attached lines awaiting their definition
+		depth            int      // brace depth at the start of the line
+		levels           []int    // brace depths of the open container bodies
+		inDefinition     bool
+		opened           bool // the current definition's body was entered
+		awaitingBody     bool // a container header whose "{" is on a later line
+	)
+	level := func() int {
+		if len(levels) == 0 {
+			return 0
+		}
+		return levels[len(levels)-1]
+	}
+	flush := func() {
+		if text := strings.TrimSpace(strings.Join(current, "\n")); text != "" {
+			sections = append(sections, text)
+		}
+		current = nil
+	}
+	start := func(line string) {
+		flush()
+		current = append(pending, line)
+		pending = nil
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		delta := strings.Count(line, "{") - strings.Count(line, "}")
+		next := max(depth+delta, 0)
+
+		switch {
+		case inDefinition:
+			current = append(current, line)
+			if next > level() {
+				opened = true
+			}
+			if (opened && next <= level()) || (!opened && strings.HasSuffix(trimmed, ";")) {
+				inDefinition = false
+				flush()
+			}
+
+		case awaitingBody:
+			current = append(current, line)
+			if next > level() {
+				awaitingBody = false
+				levels = append(levels, next)
+				flush()
+			}
+
+		case depth != level():
+			// inside something that isn't a recognized definition
+			current = append(current, line)
+
+		case trimmed == "":
+			if len(pending) > 0 {
+				pending = append(pending, line)
+			} else {
+				current = append(current, line)
+			}
+
+		case rules.attached.MatchString(trimmed):
+			pending = append(pending, line)
+
+		case next < level():
+			// the end of the enclosing container
+			current = append(append(current, pending...), line)
+			pending = nil
+			for len(levels) > 0 && next < level() {
+				levels = levels[:len(levels)-1]
+			}
+			flush()
+
+		case rules.container.MatchString(trimmed):
+			start(line)
+			if next > level() {
+				levels = append(levels, next)
+				flush()
+			} else if !strings.HasSuffix(trimmed, ";") {
+				awaitingBody = true
+			}
+
+		case rules.definition.MatchString(trimmed) && !controlPattern.MatchString(trimmed):
+			start(line)
+			switch {
+			case next > level():
+				inDefinition, opened = true, true
+			case strings.HasSuffix(trimmed, ";") || strings.HasSuffix(trimmed, "}"):
+				flush() // a declaration, or a definition on one line
+			default:
+				inDefinition, opened = true, false
+			}
+
+		default:
+			current = append(append(current, pending...), line)
+			pending = nil
+		}
+		depth = next
+	}
+	current = append(current, pending...)
+	flush()
+
+	// fallback: if we didn't find definitions, split by blank lines
+	if len(sections) <= 1 {
+		return splitByParagraphs(content, 2000)
+	}
+	return sections
+}
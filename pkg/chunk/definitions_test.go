@@ -0,0 +1,80 @@
+package chunk
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSplitByDefinitions(t *testing.T) {
+	tests := []struct {
+		docType, content string
+		want             []string // the first line of each section
+	}{
+		{"rust", `use std::fmt;
+
+/// a point in the plane
+#[derive(Debug)]
+pub struct Point {
+    x: i32,
+}
+
+impl Point {
+    pub fn new(x: i32) -> Self {
+        Point { x }
+    }
+
+    fn x(&self) -> i32 {
+        self.x
+    }
+}
+`, []string{"use std::fmt;", "/// a point in the plane", "impl Point {", "pub fn new(x: i32) -> Self {", "fn x(&self) -> i32 {", "}"}},
+		{"cpp", `#include <string>
+
+namespace geo {
+
+// area of a circle
+double area(double r) {
+    return 3.14 * r * r;
+}
+
+class Shape
+{
+public:
+    virtual ~Shape();
+    int sides() const {
+        return n;
+    }
+};
+
+}
+`, []string{"#include <string>", "namespace geo {", "// area of a circle", "class Shape", "public:", "virtual ~Shape();", "int sides() const {", "};", "}"}},
+		{"csharp", `using System;
+
+namespace Geo
+{
+    public class Circle
+    {
+        /// <summary>the radius</summary>
+        public double Radius { get; set; }
+
+        [Obsolete]
+        public double Area()
+        {
+            if (Radius > 0) { return Math.PI * Radius * Radius; }
+            return 0;
+        }
+    }
+}
+`, []string{"using System;", "namespace Geo", "public class Circle", "/// <summary>the radius</summary>", "[Obsolete]", "}", "}"}},
+	}
+	for _, tt := range tests {
+		sections := splitByDefinitions(tt.content, definitionRulesByType[tt.docType])
+		var got []string
+		for _, s := range sections {
+			got = append(got, strings.TrimSpace(strings.SplitN(s, "\n", 2)[0]))
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("%s: sections start with\n%q\nwant\n%q", tt.docType, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package chunk
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// IDMetadataKey is the chunk metadata holding the chunk's id. ids are
+// "path#hash", hash being the first 12 hex digits of ContentHash; a chunk whose
+// text occurs again in the same file gets "-n" appended for its nth occurrence.
+// they only depend on the file and the chunk's text, so they survive re-indexing
+// and edits elsewhere in the file
+const IDMetadataKey = "chunk_id"
+
+// idHashLen is the number of hex digits of the content hash in an id
+const idHashLen = 12
+
+// idHashPattern matches the part of an id after the "#"
+var idHashPattern = regexp.MustCompile(`^[0-9a-f]{12}(?:-[0-9]+)?$`)
+
+// ContentHash hashes chunk text with whitespace normalized, so re-indented
+// copies of a chunk hash the same
+func ContentHash(text string) string {
+	sum := sha256.Sum256([]byte(strings.Join(strings.Fields(text), " ")))
+	return hex.EncodeToString(sum[:])
+}
+
+// FilePath returns the file a chunk source names: the parts of a split large
+// file ("path (part n)") belong to path
+func FilePath(source string) string {
+	if i := strings.Index(source, " (part "); i >= 0 {
+		return source[:i]
+	}
+	return source
+}
+
+// IsIDHash reports whether s is the part of a chunk id after the "#"
+func IsIDHash(s string) bool {
+	return idHashPattern.MatchString(s)
+}
+
+// FileIDs returns the ids of the chunks of one file, in file order: the
+// recorded ones, computed for chunks indexed before ids were recorded
+func FileIDs(file string, chunks []Chunk) []string {
+	ids := make([]string, len(chunks))
+	seen := make(map[string]int)
+	for i, c := range chunks {
+		hash := ContentHash(c.Text)[:idHashLen]
+		seen[hash]++
+		if id := c.Metadata[IDMetadataKey]; id != "" {
+			ids[i] = id
+			continue
+		}
+		ids[i] = file + "#" + hash
+		if n := seen[hash]; n > 1 {
+			ids[i] += "-" + strconv.Itoa(n)
+		}
+	}
+	return ids
+}
+
+// HashOf returns the content hash part of c's id, computed for chunks indexed
+// before ids were recorded. chunks with the same text have the same hash
+func HashOf(c Chunk) string {
+	id := c.Metadata[IDMetadataKey]
+	if i := strings.LastIndex(id, "#"); i >= 0 && len(id)-i > idHashLen {
+		return id[i+1 : i+1+idHashLen]
+	}
+	return ContentHash(c.Text)[:idHashLen]
+}
+
+// setIDs records the ids of the chunks of one document
+func setIDs(chunks []Chunk, source string) {
+	for i, id := range FileIDs(FilePath(source), chunks) {
+		chunks[i].Metadata[IDMetadataKey] = id
+	}
+}
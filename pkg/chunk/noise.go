@@ -0,0 +1,170 @@
+package chunk
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"lr/pkg/loader"
+)
+
+// noise chunks cost embedding spend without helping retrieval: license
+// headers, import blocks and generated code match many questions loosely and
+// crowd out the code that answers them
+
+// NoiseKind is a kind of noise chunk
+type NoiseKind string
+
+const (
+	NoiseLicense   NoiseKind = "license"   // mostly a license or copyright header
+	NoiseImports   NoiseKind = "imports"   // mostly package, import or include lines
+	NoiseGenerated NoiseKind = "generated" // from a file marked as generated
+)
+
+// NoiseKinds are all kinds of noise chunks
+var NoiseKinds = []NoiseKind{NoiseLicense, NoiseImports, NoiseGenerated}
+
+// NoiseMetadataKey is the chunk metadata holding the kind of a down-weighted
+// noise chunk; VectorStore.Search ranks such chunks lower
+const NoiseMetadataKey = "noise"
+
+// noiseShare is the share of a chunk's non-blank lines that must be
+// boilerplate for it to count as license or imports noise
+const noiseShare = 0.8
+
+var (
+	licensePattern   = regexp.MustCompile(`(?i)copyright|spdx-license-identifier|licensed under|permission is hereby granted|without warranties|all rights reserved|general public license`)
+	generatedPattern = regexp.MustCompile(`(?i)code generated .*do not edit|@generated|<auto-generated|this file (?:is|was) (?:automatically|auto-?) ?generated`)
+	importPattern    = regexp.MustCompile(`^(?:package\s+[\w.]+;?|import\b.*|from\s+\S+\s+import\b.*|#include\b.*|using\s+[\w.]+;|use\s+[\w:{}, ]+;|(?:const|let|var)\s+[\w{}, ]+\s*=\s*require\(.*\);?|\w*\s*"[^"]+"|[)}\]];?|\}?\s*from\s+['"][^'"]+['"];?)$`)
+	commentPattern   = regexp.MustCompile(`^(?://|/\*|\*|#|--|<!--)`)
+)
+
+// ParseNoiseKinds parses the --noise-filter values: kinds, "all" or "none"
+func ParseNoiseKinds(values []string) ([]NoiseKind, error) {
+	var kinds []NoiseKind
+	for _, v := range values {
+		switch v = strings.TrimSpace(strings.ToLower(v)); v {
+		case "none":
+			return nil, nil
+		case "all":
+			return NoiseKinds, nil
+		case string(NoiseLicense), string(NoiseImports), string(NoiseGenerated):
+			kinds = append(kinds, NoiseKind(v))
+		default:
+			return nil, fmt.Errorf("unknown noise filter %q (use license, imports, generated, all or none)", v)
+		}
+	}
+	return kinds, nil
+}
+
+// ClassifyNoise returns the kind of noise chunk c of doc is, or "". license
+// and imports only apply to code, so a LICENSE.md stays searchable
+func ClassifyNoise(doc loader.Document, c Chunk) NoiseKind {
+	if isGenerated(doc) {
+		return NoiseGenerated
+	}
+	if doc.Metadata["type"] == "markdown" {
+		return ""
+	}
+
+	var lines, comments, imports int
+	for _, line := range strings.Split(c.Text, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			continue
+		case importPattern.MatchString(line):
+			imports++
+		case commentPattern.MatchString(line):
+			comments++
+		}
+		lines++
+	}
+	if lines == 0 || float64(comments+imports) < noiseShare*float64(lines) {
+		return ""
+	}
+	// a license header usually shares its chunk with the package and imports
+	if comments >= 3 && licensePattern.MatchString(c.Text) {
+		return NoiseLicense
+	}
+	if imports >= 3 && imports > comments {
+		return NoiseImports
+	}
+	return ""
+}
+
+// isGenerated reports whether doc is marked as generated in its first lines
+func isGenerated(doc loader.Document) bool {
+	head := doc.Content
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	return generatedPattern.MatchString(head)
+}
+
+// NoiseFilter drops, or with DownWeight marks, the noise chunks of Kinds
+type NoiseFilter struct {
+	Kinds      []NoiseKind
+	DownWeight bool
+}
+
+// NoiseReport counts the noise chunks a NoiseFilter dropped or marked
+type NoiseReport struct {
+	Chunks map[NoiseKind]int
+	Bytes  int
+}
+
+// Total returns the number of noise chunks found
+func (r NoiseReport) Total() int {
+	n := 0
+	for _, c := range r.Chunks {
+		n += c
+	}
+	return n
+}
+
+// String summarizes the report, e.g. "12 license, 3 imports"
+func (r NoiseReport) String() string {
+	var parts []string
+	for _, kind := range NoiseKinds {
+		if n := r.Chunks[kind]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, kind))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Apply returns chunks of doc without their noise chunks (or with them
+// marked, when down-weighting), counting them in report
+func (f NoiseFilter) Apply(doc loader.Document, chunks []Chunk, report *NoiseReport) []Chunk {
+	if len(f.Kinds) == 0 {
+		return chunks
+	}
+	kept := chunks[:0]
+	for _, c := range chunks {
+		kind := ClassifyNoise(doc, c)
+		if kind == "" || !f.has(kind) {
+			kept = append(kept, c)
+			continue
+		}
+		if report.Chunks == nil {
+			report.Chunks = make(map[NoiseKind]int)
+		}
+		report.Chunks[kind]++
+		report.Bytes += len(c.Text)
+		if f.DownWeight {
+			c.Metadata[NoiseMetadataKey] = string(kind)
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+func (f NoiseFilter) has(kind NoiseKind) bool {
+	for _, k := range f.Kinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package chunk
+
+import (
+	"testing"
+
+	"lr/pkg/loader"
+)
+
+func TestClassifyNoise(t *testing.T) {
+	goDoc := loader.Document{Metadata: map[string]string{"type": "go"}}
+	for _, tc := range []struct {
+		text string
+		want NoiseKind
+	}{
+		{"// Copyright 2024 The Authors\n// Licensed under the Apache License, Version 2.0\n// you may not use this file except in compliance\n\npackage foo", NoiseLicense},
+		{"package foo\n\nimport (\n\t\"fmt\"\n\t\"os\"\n\tlog \"github.com/x/log\"\n)", NoiseImports},
+		{"// Package foo parses tokens\npackage foo\n\nfunc Parse(s string) string {\n\treturn s\n}", ""},
+		{"func Copyright() string {\n\treturn \"copyright 2024\"\n}", ""},
+	} {
+		if got := ClassifyNoise(goDoc, Chunk{Text: tc.text}); got != tc.want {
+			t.Errorf("%q: expected %q, got %q", tc.text, tc.want, got)
+		}
+	}
+
+	generated := loader.Document{Content: "// Code generated by protoc-gen-go. DO NOT EDIT.\npackage pb", Metadata: map[string]string{"type": "go"}}
+	if got := ClassifyNoise(generated, Chunk{Text: "func (x *Msg) Reset() {}"}); got != NoiseGenerated {
+		t.Errorf("expected chunks of a generated file to be generated noise, got %q", got)
+	}
+	license := loader.Document{Metadata: map[string]string{"type": "markdown"}}
+	if got := ClassifyNoise(license, Chunk{Text: "# License\nCopyright 2024. Licensed under MIT"}); got != "" {
+		t.Errorf("markdown should never be license noise, got %q", got)
+	}
+
+	chunks := []Chunk{{Text: "// Copyright 2024\n// All rights reserved.\n// SPDX-License-Identifier: MIT", Metadata: map[string]string{}}, {Text: "func A() {}", Metadata: map[string]string{}}}
+	var report NoiseReport
+	kept := NoiseFilter{Kinds: NoiseKinds, DownWeight: true}.Apply(goDoc, chunks, &report)
+	if len(kept) != 2 || kept[0].Metadata[NoiseMetadataKey] != "license" || report.Total() != 1 {
+		t.Errorf("expected the license chunk kept and marked, got %+v (%s)", kept, report)
+	}
+}
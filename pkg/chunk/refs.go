@@ -0,0 +1,126 @@
+package chunk
+
+import (
+	"regexp"
+	"strings"
+)
+
+// code chunks record the functions they define and the names they call, a
+// lightweight call graph (by identifier, not by type) that lets a query add
+// the callers or callees of the functions it retrieved
+
+const (
+	// DefinesMetadataKey is the chunk metadata holding the functions and
+	// methods a code chunk defines, comma-separated
+	DefinesMetadataKey = "defines"
+	// CallsMetadataKey is the chunk metadata holding the names a code chunk
+	// calls, comma-separated
+	CallsMetadataKey = "calls"
+)
+
+// maxCalls bounds the names recorded as called by one chunk
+const maxCalls = 60
+
+var (
+	// definitions: go, js/ts, python and rust functions and methods, js arrow
+	// functions, and java, c, c++ and c# methods (a return type, a name and
+	// parameters at the start of a line ending with a brace)
+	definePattern = regexp.MustCompile(`(?m)^\s*(?:func\s+(?:\([^)]*\)\s*)?([A-Za-z_]\w*)|(?:export\s+)?(?:default\s+)?(?:async\s+)?function\s*\*?\s*([A-Za-z_$][\w$]*)|(?:async\s+)?def\s+([A-Za-z_]\w*)|(?:pub(?:\([^)]*\))?\s+)?(?:(?:async|unsafe|const|extern)\s+)*fn\s+([A-Za-z_]\w*)|(?:export\s+)?(?:const|let|var)\s+([A-Za-z_$][\w$]*)\s*=\s*(?:async\s*)?(?:\([^)]*\)|[A-Za-z_$][\w$]*)\s*(?::\s*[^=]+)?=>|(?:[\w<>\[\],.*&:]+[ \t]+)+(?:[\w:]+::)?([A-Za-z_~]\w*)[ \t]*\([^;\n]*\)[ \t]*(?:const[ \t]*)?(?:throws[ \t]+[\w., \t]+)?\{?[ \t]*$)`)
+	// lines that look like a method definition but are statements
+	statementPattern = regexp.MustCompile(`^\s*(?:return|else|if|go|defer|throw|new|await|case|yield|delete|print|echo|raise|assert)\b`)
+	callPattern      = regexp.MustCompile(`([A-Za-z_$][\w$]*)\s*\(`)
+	lineComment      = regexp.MustCompile(`(?m)(?://|#).*$`)
+	quotedString     = regexp.MustCompile(`"(?:[^"\\\n]|\\.)*"|'(?:[^'\\\n]|\\.)*'|` + "`[^`]*`")
+)
+
+// callKeywords are the words followed by "(" that aren't calls: control flow,
+// declarations and the builtins of the languages lr chunks by function
+var callKeywords = map[string]bool{
+	"if": true, "for": true, "while": true, "switch": true, "case": true, "catch": true, "return": true,
+	"func": true, "function": true, "def": true, "fn": true, "new": true, "delete": true, "typeof": true,
+	"sizeof": true, "await": true, "async": true, "yield": true, "with": true, "elif": true, "else": true, "match": true,
+	"using": true, "lock": true, "foreach": true, "defer": true, "go": true, "select": true, "import": true,
+	"make": true, "len": true, "cap": true, "append": true, "copy": true, "panic": true, "recover": true,
+	"print": true, "println": true, "string": true, "int": true, "byte": true, "rune": true, "float64": true,
+	"int64": true, "uint64": true, "bool": true, "error": true, "min": true, "max": true, "clear": true,
+	"super": true, "this": true, "self": true, "isinstance": true, "range": true, "str": true, "list": true,
+	"dict": true, "set": true, "tuple": true, "type": true, "var": true, "const": true, "let": true,
+}
+
+// refTypes are the document types whose chunks record definitions and calls
+var refTypes = map[string]bool{
+	"go": true, "javascript": true, "typescript": true, "jsx": true, "tsx": true, "python": true,
+	"java": true, "c": true, "rust": true, "cpp": true, "csharp": true,
+}
+
+// chunkDefinitions returns the functions and methods defined in code, in order
+func chunkDefinitions(code string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, m := range definePattern.FindAllStringSubmatch(code, -1) {
+		if statementPattern.MatchString(m[0]) {
+			continue
+		}
+		for _, name := range m[1:] {
+			if name != "" && !callKeywords[name] && !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// chunkCalls returns the names code calls, in order, leaving out comments,
+// strings, keywords and the functions it defines
+func chunkCalls(code string, defined []string) []string {
+	code = lineComment.ReplaceAllString(quotedString.ReplaceAllString(code, `""`), "")
+	seen := make(map[string]bool)
+	for _, name := range defined {
+		seen[name] = true
+	}
+	var names []string
+	for _, m := range callPattern.FindAllStringSubmatch(code, -1) {
+		name := m[1]
+		if callKeywords[name] || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+		if len(names) == maxCalls {
+			break
+		}
+	}
+	return names
+}
+
+// setReferences records the functions each code chunk defines and calls
+func setReferences(chunks []Chunk) {
+	for _, c := range chunks {
+		defined := chunkDefinitions(c.Text)
+		if len(defined) > 0 {
+			c.Metadata[DefinesMetadataKey] = strings.Join(defined, ",")
+		}
+		if calls := chunkCalls(c.Text, defined); len(calls) > 0 {
+			c.Metadata[CallsMetadataKey] = strings.Join(calls, ",")
+		}
+	}
+}
+
+// Defines returns the functions a chunk defines, if recorded
+func Defines(c Chunk) []string {
+	return splitList(c.Metadata[DefinesMetadataKey])
+}
+
+// Calls returns the names a chunk calls, if recorded
+func Calls(c Chunk) []string {
+	return splitList(c.Metadata[CallsMetadataKey])
+}
+
+// splitList splits a comma-separated metadata value
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
@@ -0,0 +1,46 @@
+package chunk
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReferences(t *testing.T) {
+	tests := []struct {
+		name, code     string
+		defines, calls string
+	}{
+		{"go", `// Connect dials the server
+func (c *Client) Connect(url string) error {
+	conn, err := net.Dial("tcp", url) // dial(
+	if err != nil {
+		return fmt.Errorf("connect(%s): %w", url, err)
+	}
+	c.conn = conn
+	return c.handshake()
+}`, "Connect", "Dial,Errorf,handshake"},
+		{"java", `    public List<Order> findOrders(String customer) throws SQLException {
+        if (customer == null) {
+            return Collections.emptyList();
+        }
+        return repository.query(customer);
+    }`, "findOrders", "emptyList,query"},
+		{"python", `def load(path):
+    # parse(path) is slow
+    with open(path) as f:
+        return parse(f.read())`, "load", "open,parse,read"},
+		{"typescript", `export const fetchCart = async (id: string) => {
+  const res = await fetch(url(id));
+  return res.json();
+};`, "fetchCart", "fetch,url,json"},
+	}
+	for _, tt := range tests {
+		defined := chunkDefinitions(tt.code)
+		if got := strings.Join(defined, ","); got != tt.defines {
+			t.Errorf("%s: defines %q, want %q", tt.name, got, tt.defines)
+		}
+		if got := strings.Join(chunkCalls(tt.code, defined), ","); got != tt.calls {
+			t.Errorf("%s: calls %q, want %q", tt.name, got, tt.calls)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package chunk
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"lr/pkg/loader"
+)
+
+// StrategyVersion identifies how ChunkDocumentSized splits documents. it is
+// bumped when a change to the splitting would give an existing index's files
+// different chunks, so updates know to re-chunk them
+const StrategyVersion = 1
+
+// Settings are the parameters an index was chunked with; chunks made with
+// different settings have different shapes and shouldn't share an index
+type Settings struct {
+	Strategy  int         `json:"strategy"`
+	MaxSize   int         `json:"max_size"`
+	MinSize   int         `json:"min_size"`
+	Noise     []NoiseKind `json:"noise,omitempty"` // filtered noise kinds, sorted
+	NoiseMode string      `json:"noise_mode"`      // drop or downweight
+	// MaxPerFile caps the chunks kept of a file, picked by CapBy (0: no cap)
+	MaxPerFile int         `json:"max_per_file,omitempty"`
+	CapBy      CapStrategy `json:"cap_by,omitempty"`
+}
+
+// NewSettings returns the settings of chunking with the current strategy
+func NewSettings(maxSize, minSize int, filter NoiseFilter) Settings {
+	s := Settings{Strategy: StrategyVersion, MaxSize: maxSize, MinSize: minSize, NoiseMode: "drop"}
+	s.Noise = slices.Clone(filter.Kinds)
+	slices.Sort(s.Noise)
+	s.Noise = slices.Compact(s.Noise)
+	if filter.DownWeight {
+		s.NoiseMode = "downweight"
+	}
+	return s
+}
+
+// Filter returns the noise filter of s
+func (s Settings) Filter() NoiseFilter {
+	return NoiseFilter{Kinds: s.Noise, DownWeight: s.NoiseMode == "downweight"}
+}
+
+// Chunk chunks doc with s, filtering its noise chunks into report and
+// capping what is left
+func (s Settings) Chunk(doc loader.Document, report *NoiseReport) []Chunk {
+	chunks := s.Filter().Apply(doc, ChunkDocumentSized(doc, s.MaxSize, s.MinSize), report)
+	return Cap(chunks, s.MaxPerFile, s.CapBy)
+}
+
+// Equal reports whether s and o chunk alike
+func (s Settings) Equal(o Settings) bool {
+	return len(s.Changes(o)) == 0
+}
+
+// Changes describes how o differs from s, e.g. "min size 50 → 100"
+func (s Settings) Changes(o Settings) []string {
+	var changes []string
+	add := func(what string, from, to any) {
+		changes = append(changes, fmt.Sprintf("%s %v → %v", what, from, to))
+	}
+	if s.Strategy != o.Strategy {
+		add("chunking strategy", s.Strategy, o.Strategy)
+	}
+	if s.MaxSize != o.MaxSize {
+		add("max size", s.MaxSize, o.MaxSize)
+	}
+	if s.MinSize != o.MinSize {
+		add("min size", s.MinSize, o.MinSize)
+	}
+	if !slices.Equal(s.Noise, o.Noise) {
+		add("noise filter", noiseList(s.Noise), noiseList(o.Noise))
+	}
+	if s.NoiseMode != o.NoiseMode {
+		add("noise mode", s.NoiseMode, o.NoiseMode)
+	}
+	if s.MaxPerFile != o.MaxPerFile {
+		add("max chunks per file", capList(s.MaxPerFile), capList(o.MaxPerFile))
+	} else if s.MaxPerFile > 0 && s.CapBy != o.CapBy {
+		add("cap strategy", s.CapBy, o.CapBy)
+	}
+	return changes
+}
+
+// capList renders a chunks per file cap as --max-chunks-per-file takes it
+func capList(max int) string {
+	if max <= 0 {
+		return "none"
+	}
+	return fmt.Sprint(max)
+}
+
+// noiseList renders kinds as --noise-filter takes them
+func noiseList(kinds []NoiseKind) string {
+	if len(kinds) == 0 {
+		return "none"
+	}
+	names := make([]string, len(kinds))
+	for i, k := range kinds {
+		names[i] = string(k)
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,24 @@
+package chunk
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestSettingsChanges(t *testing.T) {
+	built := NewSettings(DefaultMaxSize, DefaultMinSize, NoiseFilter{Kinds: []NoiseKind{NoiseLicense, NoiseImports, NoiseLicense}})
+	if want := []NoiseKind{NoiseImports, NoiseLicense}; !reflect.DeepEqual(built.Noise, want) {
+		t.Errorf("noise = %v, want %v", built.Noise, want)
+	}
+	if same := NewSettings(DefaultMaxSize, DefaultMinSize, NoiseFilter{Kinds: []NoiseKind{NoiseImports, NoiseLicense}}); !built.Equal(same) {
+		t.Errorf("settings with the noise kinds in another order differ: %v", built.Changes(same))
+	}
+
+	now := NewSettings(DefaultMaxSize, 100, NoiseFilter{DownWeight: true})
+	now.Strategy++
+	want := []string{fmt.Sprintf("chunking strategy %d → %d", StrategyVersion, StrategyVersion+1), "min size 50 → 100", "noise filter imports,license → none", "noise mode drop → downweight"}
+	if got := built.Changes(now); !reflect.DeepEqual(got, want) {
+		t.Errorf("changes = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,176 @@
+package chunk
+
+import (
+	"regexp"
+	"strings"
+)
+
+// sql files (schemas, migrations) are split by statement, the comments above a
+// statement staying with it. consecutive statements on the same table (a
+// create table, its indexes and comments) are kept together, so a question
+// about the data model retrieves the ddl that defines it
+
+// TablesMetadataKey is the chunk metadata holding the tables the statements
+// of a sql chunk define, alter or reference, comma-separated
+const TablesMetadataKey = "tables"
+
+var (
+	sqlTablePattern   = regexp.MustCompile(`(?i)\b(?:create\s+(?:or\s+replace\s+)?(?:(?:global\s+|local\s+)?temp(?:orary)?\s+|unlogged\s+|materialized\s+)?(?:table|view)\s+(?:if\s+not\s+exists\s+)?|alter\s+table\s+(?:if\s+exists\s+)?(?:only\s+)?|drop\s+(?:table|view)\s+(?:if\s+exists\s+)?|create\s+(?:unique\s+)?index\s+(?:concurrently\s+)?(?:if\s+not\s+exists\s+)?(?:[\w"` + "`" + `]+\s+)?on\s+(?:only\s+)?|insert\s+into\s+|comment\s+on\s+table\s+|references\s+)([\w."` + "`" + `\[\]]+)`)
+	sqlRoutinePattern = regexp.MustCompile(`(?i)^\s*create\s+(?:or\s+replace\s+)?(?:definer\s*=\s*\S+\s+)?(?:function|procedure|trigger)\b`)
+	sqlBeginPattern   = regexp.MustCompile(`(?i)\bbegin\b(\s*(?:;|transaction\b|work\b))?`)
+	sqlEndPattern     = regexp.MustCompile(`(?i)\bend\b\s*(\w*)`)
+	sqlCasePattern    = regexp.MustCompile(`(?i)\bcase\b`)
+	sqlEndCasePattern = regexp.MustCompile(`(?i)\bend\s+case\b`)
+)
+
+// sqlTables returns the tables the statements in text define, alter or
+// reference, in the order they appear
+func sqlTables(text string) []string {
+	var tables []string
+	seen := make(map[string]bool)
+	for _, m := range sqlTablePattern.FindAllStringSubmatch(text, -1) {
+		name := strings.Trim(m[1], "\"`[]")
+		name = strings.NewReplacer(`"`, "", "`", "", "[", "", "]", "").Replace(name)
+		if name != "" && !seen[strings.ToLower(name)] {
+			seen[strings.ToLower(name)] = true
+			tables = append(tables, name)
+		}
+	}
+	return tables
+}
+
+// splitSQL splits sql into statements, grouping consecutive statements on the
+// same table while they fit in maxSize
+func splitSQL(content string, maxSize int) []string {
+	var sections []string
+	var table string
+	for _, stmt := range sqlStatements(content) {
+		tables := sqlTables(stmt)
+		first := ""
+		if len(tables) > 0 {
+			first = strings.ToLower(tables[0])
+		}
+		if n := len(sections); n > 0 && first != "" && first == table && len(sections[n-1])+len(stmt)+1 <= maxSize {
+			sections[n-1] += "\n" + stmt
+			continue
+		}
+		sections = append(sections, stmt)
+		table = first
+	}
+	return sections
+}
+
+// sqlStatements splits sql into statements at the lines a statement ends on,
+// outside strings, comments, dollar-quoted bodies and the begin ... end of a
+// function or procedure
+func sqlStatements(content string) []string {
+	var statements, current []string
+	var (
+		inBlockComment bool
+		quote          byte   // the quote of the string the line ends in, if any
+		dollarTag      string // the tag of the dollar-quoted body the line ends in, if any
+		started        bool   // the statement's first line (after its comments) was seen
+		routine        bool   // the statement creates a function, procedure or trigger
+		depth          int    // begin ... end nesting of a routine body
+	)
+	for _, line := range strings.Split(content, "\n") {
+		if trimmed := strings.TrimSpace(line); !started && trimmed != "" && !strings.HasPrefix(trimmed, "--") && !inBlockComment {
+			started, routine = true, sqlRoutinePattern.MatchString(line)
+		}
+		current = append(current, line)
+
+		semicolon := false
+		for i := 0; i < len(line); i++ {
+			c := line[i]
+			switch {
+			case inBlockComment:
+				if strings.HasPrefix(line[i:], "*/") {
+					inBlockComment = false
+					i++
+				}
+			case quote != 0:
+				if c == quote {
+					quote = 0
+				}
+			case dollarTag != "":
+				if strings.HasPrefix(line[i:], dollarTag) {
+					i += len(dollarTag) - 1
+					dollarTag = ""
+				}
+			case strings.HasPrefix(line[i:], "--"):
+				i = len(line)
+			case strings.HasPrefix(line[i:], "/*"):
+				inBlockComment = true
+				i++
+			case c == '\'' || c == '"' || c == '`':
+				quote = c
+			case c == '$':
+				if j := strings.IndexByte(line[i+1:], '$'); j >= 0 && isDollarTag(line[i+1:i+1+j]) {
+					dollarTag = line[i : i+j+2]
+					i += j + 1
+				}
+			case c == ';':
+				semicolon = true
+			}
+		}
+		if quote != 0 || dollarTag != "" || inBlockComment {
+			continue
+		}
+		if routine {
+			depth = max(depth+routineDepthDelta(line), 0)
+		}
+		if semicolon && depth == 0 {
+			if text := strings.TrimSpace(strings.Join(current, "\n")); text != "" {
+				statements = append(statements, text)
+			}
+			current, started, routine = nil, false, false
+		}
+	}
+	if text := strings.TrimSpace(strings.Join(current, "\n")); text != "" {
+		statements = append(statements, text)
+	}
+	return statements
+}
+
+// routineDepthDelta returns the begin ... end and case ... end blocks a line
+// of a function or procedure body opens minus those it closes. "begin;" and
+// "begin transaction" start transactions, and "end if", "end loop" and the
+// like close statements that didn't open a block
+func routineDepthDelta(line string) int {
+	if i := strings.Index(line, "--"); i >= 0 {
+		line = line[:i]
+	}
+	delta := len(sqlCasePattern.FindAllString(line, -1)) - len(sqlEndCasePattern.FindAllString(line, -1))
+	for _, m := range sqlBeginPattern.FindAllStringSubmatch(line, -1) {
+		if m[1] == "" {
+			delta++
+		}
+	}
+	for _, m := range sqlEndPattern.FindAllStringSubmatch(line, -1) {
+		switch strings.ToLower(m[1]) {
+		case "if", "loop", "while", "repeat", "for":
+		default:
+			delta--
+		}
+	}
+	return delta
+}
+
+// isDollarTag reports whether s can be the tag of a postgres dollar quote ($$ or $name$)
+func isDollarTag(s string) bool {
+	for _, r := range s {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return s == "" || !(s[0] >= '0' && s[0] <= '9')
+}
+
+// setTables records the tables each chunk of a sql file is about
+func setTables(chunks []Chunk) {
+	for _, c := range chunks {
+		if tables := sqlTables(c.Text); len(tables) > 0 {
+			c.Metadata[TablesMetadataKey] = strings.Join(tables, ", ")
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package chunk
+
+import (
+	"strings"
+	"testing"
+
+	"lr/pkg/loader"
+)
+
+func TestSQLChunks(t *testing.T) {
+	content := `-- +goose Up
+-- users of the service
+CREATE TABLE users (
+    id    bigserial PRIMARY KEY,
+    email text NOT NULL UNIQUE -- login; unique per tenant
+);
+CREATE INDEX users_email_idx ON users (email);
+
+CREATE TABLE orders (
+    id      bigserial PRIMARY KEY,
+    user_id bigint NOT NULL REFERENCES users (id),
+    note    text DEFAULT 'none; yet'
+);
+
+CREATE FUNCTION order_count(uid bigint) RETURNS bigint AS $$
+BEGIN
+    RETURN (SELECT count(*) FROM orders WHERE user_id = uid);
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE PROCEDURE archive_orders()
+BEGIN
+    IF (SELECT count(*) FROM orders) > 0 THEN
+        DELETE FROM orders;
+    END IF;
+END;
+`
+	doc := loader.Document{Content: content, Source: "001_init.sql", Metadata: map[string]string{"type": "sql"}}
+	chunks := ChunkDocumentSized(doc, 1000, 1)
+	want := []struct{ first, tables string }{
+		{"-- +goose Up", "users"},
+		{"CREATE TABLE orders (", "orders, users"},
+		{"CREATE FUNCTION order_count(uid bigint) RETURNS bigint AS $$", ""},
+		{"CREATE PROCEDURE archive_orders()", ""},
+	}
+	if len(chunks) != len(want) {
+		for _, c := range chunks {
+			t.Logf("chunk:\n%s", c.Text)
+		}
+		t.Fatalf("expected %d chunks, got %d", len(want), len(chunks))
+	}
+	for i, w := range want {
+		if first := strings.SplitN(chunks[i].Text, "\n", 2)[0]; first != w.first {
+			t.Errorf("chunk %d starts with %q, want %q", i, first, w.first)
+		}
+		if tables := chunks[i].Metadata[TablesMetadataKey]; tables != w.tables {
+			t.Errorf("chunk %d tables %q, want %q", i, tables, w.tables)
+		}
+	}
+	if !strings.Contains(chunks[0].Text, "users_email_idx") {
+		t.Errorf("expected the users index with the users table, got:\n%s", chunks[0].Text)
+	}
+}
@@ -0,0 +1,180 @@
+package chunk
+
+import (
+	"regexp"
+	"strings"
+)
+
+// view templates (templ, html, jsx/tsx) are split by component: each templ
+// component, go template block, html landmark element or react component is a
+// chunk with its markup, rather than being cut wherever a brace in the markup
+// happens to close
+
+// ComponentMetadataKey is the chunk metadata holding the names of the
+// components (templ components, go template blocks, react components, html
+// elements such as "section#pricing") a chunk holds, comma-separated
+const ComponentMetadataKey = "component"
+
+var (
+	templStartPattern = regexp.MustCompile(`^(?:templ|css|script|func|type|var|const)\b`)
+	templNamePattern  = regexp.MustCompile(`^(?:templ|css|script)\s+(?:\([^)]*\)\s*)?(\w+)`)
+	jsxStartPattern   = regexp.MustCompile(`^(?:export\s+default\b|export\s*\{|(?:export\s+)?(?:declare\s+)?(?:async\s+)?(?:function|const|let|var|class|interface|type|enum|abstract\s+class)\b)`)
+	jsxNamePattern    = regexp.MustCompile(`^(?:export\s+(?:default\s+)?)?(?:async\s+)?(?:function\s*\*?\s*|const\s+|let\s+|var\s+|class\s+)([A-Z]\w*)`)
+	commentLine       = regexp.MustCompile(`^(?://|/\*|\*|@)`)
+
+	goTemplateStart   = regexp.MustCompile(`\{\{-?\s*(?:define|block)\s+"([^"]+)"`)
+	goTemplateOpen    = regexp.MustCompile(`\{\{-?\s*(?:if|range|with|define|block)\b`)
+	goTemplateEnd     = regexp.MustCompile(`\{\{-?\s*end\b`)
+	htmlLandmark      = regexp.MustCompile(`^<(header|nav|main|section|article|aside|footer|form|template|script|style|head|dialog)\b`)
+	htmlIDPattern     = regexp.MustCompile(`\bid\s*=\s*["']([^"']+)["']`)
+	htmlCommentPrefix = "<!--"
+)
+
+// templateSplitters are the document types split by component
+var templateSplitters = map[string]func([]string) []namedBlock{
+	"templ": templBlocks,
+	"jsx":   jsxBlocks,
+	"tsx":   jsxBlocks,
+	"html":  htmlBlocks,
+}
+
+// splitTemplates splits a view template of docType into its components, and
+// returns the blocks the sections are. nil blocks mean no component was found
+// and content was split by paragraphs
+func splitTemplates(content, docType string) ([]string, []namedBlock) {
+	lines := strings.Split(content, "\n")
+	blocks := templateSplitters[docType](lines)
+	if len(blocks) <= 1 {
+		return splitByParagraphs(content, 2000), nil
+	}
+	return blockTexts(lines, blocks), blocks
+}
+
+// templBlocks splits templ files at their top-level declarations, naming
+// components, css classes and scripts
+func templBlocks(lines []string) []namedBlock {
+	return topLevelBlocks(lines, templStartPattern, func(line string) string {
+		if m := templNamePattern.FindStringSubmatch(line); m != nil {
+			return m[1]
+		}
+		return ""
+	})
+}
+
+// jsxBlocks splits jsx and tsx at their top-level declarations, naming
+// components (capitalized functions, consts and classes; not CONSTANTS)
+func jsxBlocks(lines []string) []namedBlock {
+	return topLevelBlocks(lines, jsxStartPattern, func(line string) string {
+		if m := jsxNamePattern.FindStringSubmatch(line); m != nil && strings.ToUpper(m[1]) != m[1] {
+			return m[1]
+		}
+		return ""
+	})
+}
+
+// topLevelBlocks splits lines into blocks at the unindented lines start
+// matches, each with the comments right above it. the lines before the first
+// are a block of their own. name names a block by its first line
+func topLevelBlocks(lines []string, start *regexp.Regexp, name func(string) string) []namedBlock {
+	var blocks []namedBlock
+	for i, line := range lines {
+		if line == "" || line[0] == ' ' || line[0] == '\t' || !start.MatchString(line) {
+			continue
+		}
+		lo := 0
+		if n := len(blocks); n > 0 {
+			lo = blocks[n-1].header + 1
+		}
+		s := i
+		for s > lo && commentLine.MatchString(strings.TrimSpace(lines[s-1])) {
+			s--
+		}
+		if n := len(blocks); n > 0 {
+			blocks[n-1].end = s
+		} else if s > 0 {
+			blocks = append(blocks, namedBlock{header: 0, start: 0, end: s})
+		}
+		blocks = append(blocks, namedBlock{path: name(line), header: i, start: s, end: len(lines)})
+	}
+	return blocks
+}
+
+// htmlBlocks splits html at go template define/block actions and landmark
+// elements (header, nav, section, form, ...), naming them by the template
+// name or the element and its id
+func htmlBlocks(lines []string) []namedBlock {
+	var blocks []namedBlock
+	glue := -1 // the start of the lines outside any block, -1 if none
+	closeGlue := func(end int) {
+		if glue >= 0 && end > glue {
+			blocks = append(blocks, namedBlock{header: glue, start: glue, end: end})
+		}
+		glue = -1
+	}
+
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		var name string
+		var end int
+		if m := goTemplateStart.FindStringSubmatch(trimmed); m != nil && strings.HasPrefix(trimmed, "{{") {
+			name, end = m[1], goTemplateBlockEnd(lines, i)
+		} else if m := htmlLandmark.FindStringSubmatch(trimmed); m != nil {
+			name, end = m[1], htmlElementEnd(lines, i, m[1])
+			if id := htmlIDPattern.FindStringSubmatch(trimmed); id != nil {
+				name += "#" + id[1]
+			}
+		} else {
+			if glue < 0 {
+				glue = i
+			}
+			continue
+		}
+
+		// an html comment right above belongs to the block
+		start := i
+		if glue >= 0 && start > glue && strings.HasPrefix(strings.TrimSpace(lines[start-1]), htmlCommentPrefix) {
+			start--
+		}
+		closeGlue(start)
+		blocks = append(blocks, namedBlock{path: name, header: i, start: start, end: end})
+		i = end - 1
+	}
+	closeGlue(len(lines))
+	return blocks
+}
+
+// goTemplateBlockEnd returns the line after the {{ end }} closing the define or
+// block action on line start
+func goTemplateBlockEnd(lines []string, start int) int {
+	depth := 0
+	for i := start; i < len(lines); i++ {
+		depth += len(goTemplateOpen.FindAllString(lines[i], -1)) - len(goTemplateEnd.FindAllString(lines[i], -1))
+		if depth <= 0 {
+			return i + 1
+		}
+	}
+	return len(lines)
+}
+
+// htmlElementEnd returns the line after the one closing the tag element opened
+// on line start
+func htmlElementEnd(lines []string, start int, tag string) int {
+	open := regexp.MustCompile(`<` + tag + `\b`)
+	depth := 0
+	for i := start; i < len(lines); i++ {
+		depth += len(open.FindAllString(lines[i], -1)) - strings.Count(lines[i], "</"+tag+">")
+		if depth <= 0 {
+			return i + 1
+		}
+	}
+	return len(lines)
+}
+
+// blockTexts returns the text of each block of lines
+func blockTexts(lines []string, blocks []namedBlock) []string {
+	texts := make([]string, len(blocks))
+	for i, b := range blocks {
+		texts[i] = strings.Join(lines[b.start:b.end], "\n")
+	}
+	return texts
+}
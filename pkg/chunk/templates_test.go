@@ -0,0 +1,74 @@
+package chunk
+
+import (
+	"strings"
+	"testing"
+
+	"lr/pkg/loader"
+)
+
+func TestTemplateComponents(t *testing.T) {
+	tests := []struct {
+		docType, content string
+		want             []string // the component names of the chunks
+	}{
+		{"tsx", `import React from "react";
+
+const API_URL = "/api/cart";
+
+// Cart lists the items in the cart
+export function Cart({ items }: CartProps) {
+  return (
+    <ul className={styles.cart}>
+      {items.map((item) => (
+        <li key={item.id}>{item.name}</li>
+      ))}
+    </ul>
+  );
+}
+
+export const CheckoutButton = ({ onClick }: Props) => (
+  <button onClick={() => onClick()}>checkout</button>
+);
+`, []string{"", "", "Cart", "CheckoutButton"}},
+		{"templ", `package views
+
+// Page wraps a page's content in the site layout
+templ Page(title string) {
+	<html>
+		<head><title>{ title }</title></head>
+		<body>{ children... }</body>
+	</html>
+}
+
+css primary() {
+	color: blue;
+}
+`, []string{"", "Page", "primary"}},
+		{"html", `<html>
+<body>
+  <!-- the pricing table -->
+  <section id="pricing">
+    <section class="plan">free</section>
+    <section class="plan">pro</section>
+  </section>
+  {{ define "footer" }}
+    {{ if .Year }}<p>{{ .Year }}</p>{{ end }}
+  {{ end }}
+</body>
+</html>
+`, []string{"", "section#pricing", "footer", ""}},
+	}
+	for _, tt := range tests {
+		doc := loader.Document{Content: tt.content, Source: "view", Metadata: map[string]string{"type": tt.docType}}
+		// no merging, to see every component
+		chunks := ChunkDocumentSized(doc, 2000, 1)
+		var got []string
+		for _, c := range chunks {
+			got = append(got, c.Metadata[ComponentMetadataKey])
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("%s: chunk components %q, want %q", tt.docType, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"lr/internal/errkind"
+)
+
+// AnthropicClient handles Anthropic API requests
+type AnthropicClient struct {
+	APIKey string
+	Model  string
+	Client *http.Client
+}
+
+// NewAnthropicClient creates a new Anthropic client
+func NewAnthropicClient(apiKey, model string) *AnthropicClient {
+	if model == "" {
+		model = "claude-sonnet-4-5-20250929"
+	}
+	return &AnthropicClient{
+		APIKey: apiKey,
+		Model:  model,
+		Client: newHTTPClient(0),
+	}
+}
+
+// GetEmbedding gets an embedding using Voyage AI (Anthropic's recommended provider)
+// Note: Anthropic doesn't provide embeddings directly, so we still need OpenAI or Voyage
+// For simplicity, we'll use a wrapper that falls back to OpenAI embeddings
+func (c *AnthropicClient) GetEmbedding(_ string) ([]float64, error) {
+	// anthropic doesn't provide embeddings, so we need to use openai for this part
+	// you could also use voyage ai or other embedding providers
+	return nil, fmt.Errorf("embeddings not supported directly by anthropic - use openai for embeddings")
+}
+
+// ChatRequest represents an Anthropic messages API request
+type AnthropicChatRequest struct {
+	Model         string             `json:"model"`
+	MaxTokens     int                `json:"max_tokens"`
+	Messages      []AnthropicMessage `json:"messages"`
+	System        string             `json:"system,omitempty"`
+	Temperature   *float64           `json:"temperature,omitempty"`
+	StopSequences []string           `json:"stop_sequences,omitempty"`
+	Stream        bool               `json:"stream,omitempty"`
+}
+
+// AnthropicMessage represents a message in the chat
+type AnthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// AnthropicChatResponse represents the response
+type AnthropicChatResponse struct {
+	Content []struct {
+		Text string `json:"text"`
+		Type string `json:"type"`
+	} `json:"content"`
+}
+
+// Chat sends a chat completion request to Claude
+func (c *AnthropicClient) Chat(messages []Message) (string, error) {
+	return c.ChatContext(context.Background(), messages)
+}
+
+// anthropicStreamEvent is one server-sent event of a streamed messages response
+type anthropicStreamEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+	Error struct {
+		Type    string `json:"type"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// ChatContext sends a chat completion request to Claude, aborting if ctx is done.
+// ChatOptions carried by ctx set max tokens, temperature and stop sequences, and
+// the answer is streamed to a token handler if ctx carries one
+func (c *AnthropicClient) ChatContext(ctx context.Context, messages []Message) (string, error) {
+	// separate system message from user messages
+	var systemPrompt string
+	var userMessages []AnthropicMessage
+
+	for _, msg := range messages {
+		if msg.Role == "system" {
+			systemPrompt = msg.Content
+		} else {
+			userMessages = append(userMessages, AnthropicMessage{
+				Role:    msg.Role,
+				Content: msg.Content,
+			})
+		}
+	}
+
+	opts := ChatOptionsFrom(ctx)
+	reqBody := AnthropicChatRequest{
+		Model:         c.Model,
+		MaxTokens:     opts.maxTokensOr(DefaultMaxTokens),
+		Messages:      userMessages,
+		System:        systemPrompt,
+		Temperature:   opts.Temperature,
+		StopSequences: opts.Stop,
+	}
+	onToken := tokenHandler(ctx)
+	reqBody.Stream = onToken != nil
+
+	body, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.anthropic.com/v1/messages", bytes.NewBuffer(body))
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", c.APIKey)
+	req.Header.Set("anthropic-version", "2023-06-01")
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return "", unreachable(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return "", newAPIError("anthropic api", resp, bodyBytes)
+	}
+
+	if onToken != nil {
+		return readAnthropicStream(resp.Body, onToken)
+	}
+
+	var chatResp AnthropicChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+		return "", err
+	}
+
+	if len(chatResp.Content) == 0 {
+		return "", fmt.Errorf("no response from claude")
+	}
+
+	return chatResp.Content[0].Text, nil
+}
+
+// readAnthropicStream collects a streamed messages response, passing each text
+// delta to onToken
+func readAnthropicStream(body io.Reader, onToken func(string)) (string, error) {
+	var answer strings.Builder
+	err := readSSE(body, func(data string) error {
+		var event anthropicStreamEvent
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return fmt.Errorf("anthropic api returned an invalid stream event: %w", err)
+		}
+		switch event.Type {
+		case "content_block_delta":
+			if event.Delta.Type == "text_delta" {
+				answer.WriteString(event.Delta.Text)
+				onToken(event.Delta.Text)
+			}
+		case "message_stop":
+			return errStreamDone
+		case "error":
+			err := fmt.Errorf("anthropic api stream error: %s - %s", event.Error.Type, event.Error.Message)
+			switch event.Error.Type {
+			case "overloaded_error":
+				// still rate limited to scripts, as before it could fail over
+				return errkind.With(errkind.With(err, ErrRateLimited), ErrOverloaded)
+			case "rate_limit_error":
+				return errkind.With(err, ErrRateLimited)
+			}
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if answer.Len() == 0 {
+		return "", fmt.Errorf("no response from claude")
+	}
+	return answer.String(), nil
+}
@@ -0,0 +1,140 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// IsExternalChatModel reports whether the chat model delegates synthesis to an
+// external command ("plugin:<command>") or http endpoint ("http(s)://...")
+func IsExternalChatModel(model string) bool {
+	return IsPluginModel(model) || strings.HasPrefix(model, "http://") || strings.HasPrefix(model, "https://")
+}
+
+// externalChatRequest is sent to the external chat command on stdin, or as the http request body
+type externalChatRequest struct {
+	Messages    []Message `json:"messages"`
+	MaxTokens   int       `json:"max_tokens,omitempty"`
+	Temperature *float64  `json:"temperature,omitempty"`
+	Stop        []string  `json:"stop,omitempty"`
+}
+
+// externalChatResponse is accepted from http endpoints answering with application/json
+type externalChatResponse struct {
+	Text  string `json:"text"`
+	Error string `json:"error,omitempty"`
+}
+
+// ExternalChatClient delegates chat synthesis to an external command or http endpoint
+// and uses a regular embedding client for retrieval
+type ExternalChatClient struct {
+	Embedder Embedder
+	target   string
+}
+
+// Embedder is the embedding half of an LLMClient
+type Embedder interface {
+	GetEmbedding(text string) ([]float64, error)
+	GetEmbeddingContext(ctx context.Context, text string) ([]float64, error)
+}
+
+// NewExternalChatClient creates a client that embeds with embedder and chats via target
+func NewExternalChatClient(embedder Embedder, target string) *ExternalChatClient {
+	return &ExternalChatClient{Embedder: embedder, target: target}
+}
+
+// GetEmbedding uses the configured embedder
+func (e *ExternalChatClient) GetEmbedding(text string) ([]float64, error) {
+	return e.Embedder.GetEmbedding(text)
+}
+
+// GetEmbeddingContext uses the configured embedder, aborting if ctx is done
+func (e *ExternalChatClient) GetEmbeddingContext(ctx context.Context, text string) ([]float64, error) {
+	return e.Embedder.GetEmbeddingContext(ctx, text)
+}
+
+// Chat sends the conversation to the external chat target
+func (e *ExternalChatClient) Chat(messages []Message) (string, error) {
+	return e.ChatContext(context.Background(), messages)
+}
+
+// ChatContext sends the conversation (and any ChatOptions carried by ctx) to the
+// external chat target, aborting if ctx is done
+func (e *ExternalChatClient) ChatContext(ctx context.Context, messages []Message) (string, error) {
+	opts := ChatOptionsFrom(ctx)
+	body, err := json.Marshal(externalChatRequest{
+		Messages:    messages,
+		MaxTokens:   opts.MaxTokens,
+		Temperature: opts.Temperature,
+		Stop:        opts.Stop,
+	})
+	if err != nil {
+		return "", err
+	}
+	if IsPluginModel(e.target) {
+		return e.chatCommand(ctx, body)
+	}
+	return e.chatHTTP(ctx, body)
+}
+
+// chatCommand runs the chat command once per request: the request json on stdin, the answer text on stdout
+func (e *ExternalChatClient) chatCommand(ctx context.Context, body []byte) (string, error) {
+	command := strings.TrimSpace(strings.TrimPrefix(e.target, PluginPrefix))
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Stderr = os.Stderr
+	out, err := cmd.Output()
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", ctx.Err()
+		}
+		return "", fmt.Errorf("chat plugin %q failed: %w", command, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// chatHTTP posts the request json to the endpoint. the answer is the response body, or
+// its "text" field for json responses. LR_CHAT_TOKEN is sent as a bearer token if set
+func (e *ExternalChatClient) chatHTTP(ctx context.Context, body []byte) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", e.target, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token := os.Getenv("LR_CHAT_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := newHTTPClient(0).Do(req)
+	if err != nil {
+		return "", unreachable(err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", newAPIError("chat endpoint", resp, bodyBytes)
+	}
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		var r externalChatResponse
+		if err := json.Unmarshal(bodyBytes, &r); err != nil {
+			return "", fmt.Errorf("chat endpoint returned invalid json: %w", err)
+		}
+		if r.Error != "" {
+			return "", fmt.Errorf("chat endpoint error: %s", r.Error)
+		}
+		return r.Text, nil
+	}
+	return strings.TrimSpace(string(bodyBytes)), nil
+}
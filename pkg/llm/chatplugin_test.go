@@ -0,0 +1,91 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExternalChatCommand(t *testing.T) {
+	messages := []Message{{Role: "user", Content: "how does auth work?"}}
+	ctx := WithChatOptions(context.Background(), ChatOptions{MaxTokens: 200, Stop: []string{"END"}})
+
+	// the command gets the request json on stdin and answers on stdout
+	answer, err := NewExternalChatClient(nil, "plugin:cat").ChatContext(ctx, messages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req externalChatRequest
+	if err := json.Unmarshal([]byte(answer), &req); err != nil {
+		t.Fatalf("the command didn't get the request json: %q", answer)
+	}
+	if len(req.Messages) != 1 || req.Messages[0].Content != "how does auth work?" || req.MaxTokens != 200 || len(req.Stop) != 1 {
+		t.Errorf("request = %+v", req)
+	}
+
+	if answer, err := NewExternalChatClient(nil, "plugin:echo '  the answer  '").Chat(messages); err != nil || answer != "the answer" {
+		t.Errorf("answer = %q, %v, want it trimmed", answer, err)
+	}
+	if _, err := NewExternalChatClient(nil, "plugin:exit 3").Chat(messages); err == nil || !strings.Contains(err.Error(), "chat plugin") {
+		t.Errorf("expected the failed command reported, got %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := NewExternalChatClient(nil, "plugin:exec sleep 30").ChatContext(ctx, messages); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the deadline error, got %v", err)
+	}
+}
+
+func TestExternalChatHTTP(t *testing.T) {
+	t.Setenv("LR_CHAT_TOKEN", "secret")
+	var got externalChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.Header.Get("Content-Type") != "application/json" || r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, &got)
+		switch got.Messages[0].Content {
+		case "json":
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `{"text":"from json"}`)
+		case "json error":
+			w.Header().Set("Content-Type", "application/json")
+			io.WriteString(w, `{"error":"no model loaded"}`)
+		case "fail":
+			w.WriteHeader(http.StatusInternalServerError)
+			io.WriteString(w, "internal error")
+		default:
+			io.WriteString(w, "plain answer\n")
+		}
+	}))
+	defer server.Close()
+	client := NewExternalChatClient(nil, server.URL)
+	ask := func(question string) (string, error) {
+		return client.ChatContext(WithChatOptions(context.Background(), ChatOptions{MaxTokens: 100}), []Message{{Role: "user", Content: question}})
+	}
+
+	if answer, err := ask("plain"); err != nil || answer != "plain answer" {
+		t.Errorf("plain text answer = %q, %v", answer, err)
+	}
+	if got.MaxTokens != 100 {
+		t.Errorf("request = %+v, want the chat options sent", got)
+	}
+	if answer, err := ask("json"); err != nil || answer != "from json" {
+		t.Errorf("json answer = %q, %v", answer, err)
+	}
+	if _, err := ask("json error"); err == nil || !strings.Contains(err.Error(), "no model loaded") {
+		t.Errorf("expected the endpoint's error, got %v", err)
+	}
+	if _, err := ask("fail"); err == nil || !strings.Contains(err.Error(), "internal error") {
+		t.Errorf("expected the 500 reported, got %v", err)
+	}
+}
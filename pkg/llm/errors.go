@@ -0,0 +1,44 @@
+package llm
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"lr/internal/errkind"
+)
+
+// error kinds for failed provider requests; check with errors.Is
+var (
+	ErrAuth        = errors.New("authentication failed")
+	ErrRateLimited = errors.New("rate limited")
+	ErrOverloaded  = errors.New("provider overloaded")
+	ErrUnreachable = errors.New("provider unreachable")
+)
+
+// newAPIError builds the error for a failed provider response, classifying
+// auth failures, rate limits and overloaded or unavailable providers. prefix
+// is e.g. "openai api"
+func newAPIError(prefix string, resp *http.Response, body []byte) error {
+	err := fmt.Errorf("%s error: %s - %s", prefix, resp.Status, string(body))
+	switch resp.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return errkind.With(err, ErrAuth)
+	case http.StatusTooManyRequests:
+		return errkind.With(err, ErrRateLimited)
+	case statusOverloaded, http.StatusServiceUnavailable:
+		return errkind.With(err, ErrOverloaded)
+	case http.StatusBadGateway, http.StatusGatewayTimeout:
+		return errkind.With(err, ErrUnreachable)
+	}
+	return err
+}
+
+// statusOverloaded is anthropic's "overloaded" status
+const statusOverloaded = 529
+
+// unreachable tags the error of a request that got no response (connection
+// refused, dns, a timeout) as ErrUnreachable
+func unreachable(err error) error {
+	return errkind.With(err, ErrUnreachable)
+}
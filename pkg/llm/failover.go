@@ -0,0 +1,126 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// ChatBackend is one model in a failover chain
+type ChatBackend struct {
+	Model  string
+	Client LLMClient
+}
+
+// FailoverClient embeds with its primary client and tries each chat backend in
+// order until one answers, so an overloaded or unreachable provider doesn't fail
+// the query. other errors (a bad request, a prompt too long for the model) fail
+// the query as they would without a chain: another model wouldn't fix them
+type FailoverClient struct {
+	LLMClient // primary: all embeddings and the first chat attempt
+	Model     string
+	Fallbacks []ChatBackend
+}
+
+// NewFailoverClient creates a client that falls back to the given chat backends
+func NewFailoverClient(primary LLMClient, model string, fallbacks []ChatBackend) *FailoverClient {
+	return &FailoverClient{LLMClient: primary, Model: model, Fallbacks: fallbacks}
+}
+
+// GetEmbeddingContext uses the primary client, aborting if ctx is done
+func (f *FailoverClient) GetEmbeddingContext(ctx context.Context, text string) ([]float64, error) {
+	return GetEmbeddingContext(ctx, f.LLMClient, text)
+}
+
+// GetBatchEmbeddingsContext uses the primary client, aborting if ctx is done
+func (f *FailoverClient) GetBatchEmbeddingsContext(ctx context.Context, texts []string) ([][]float64, error) {
+	return GetBatchEmbeddingsContext(ctx, f.LLMClient, texts)
+}
+
+// Chat tries each backend in order
+func (f *FailoverClient) Chat(messages []Message) (string, error) {
+	return f.ChatContext(context.Background(), messages)
+}
+
+// ChatContext tries each backend in order, stopping early if ctx is done or
+// on an error failing over won't help with. a streamed answer fails over only
+// before its first token: the next model's answer would follow a partial one.
+// which model answered is recorded in the FailoverResult carried by ctx, if any
+func (f *FailoverClient) ChatContext(ctx context.Context, messages []Message) (string, error) {
+	backends := append([]ChatBackend{{Model: f.Model, Client: f.LLMClient}}, f.Fallbacks...)
+	result, _ := ctx.Value(failoverResultKey{}).(*FailoverResult)
+
+	var streamed atomic.Bool
+	if onToken := tokenHandler(ctx); onToken != nil {
+		ctx = WithTokenHandler(ctx, func(token string) {
+			streamed.Store(true)
+			onToken(token)
+		})
+	}
+
+	var failures []error
+	var lastErr error
+	for _, b := range backends {
+		answer, err := ChatContext(ctx, b.Client, messages)
+		if err == nil {
+			result.record(b.Model, failures)
+			return answer, nil
+		}
+		failures = append(failures, fmt.Errorf("%s: %w", b.Model, err))
+		lastErr = err
+		if ctx.Err() != nil || !failsOver(err) || streamed.Load() {
+			break
+		}
+	}
+
+	result.record("", failures)
+	switch {
+	case len(failures) == 1:
+		return "", lastErr // as without a chain: nothing was tried instead
+	case len(failures) == len(backends):
+		return "", fmt.Errorf("all chat models failed: %w", errors.Join(failures...))
+	}
+	return "", fmt.Errorf("chat failed after failing over: %w", errors.Join(failures...))
+}
+
+// failsOver reports whether a chat error is one the next model in the chain
+// may not have: the provider is overloaded or can't be reached
+func failsOver(err error) bool {
+	return errors.Is(err, ErrOverloaded) || errors.Is(err, ErrUnreachable)
+}
+
+type failoverResultKey struct{}
+
+// FailoverResult is which model of a FailoverClient answered a request, and
+// why the models tried before it failed
+type FailoverResult struct {
+	mu       sync.Mutex
+	model    string
+	failures []error
+}
+
+// WithFailoverResult returns a context on which FailoverClients record which
+// model answered in result. each request has its own, so requests sharing a
+// client (an mcp or http server's) don't see each other's
+func WithFailoverResult(ctx context.Context, result *FailoverResult) context.Context {
+	return context.WithValue(ctx, failoverResultKey{}, result)
+}
+
+func (r *FailoverResult) record(model string, failures []error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.model, r.failures = model, failures
+}
+
+// AnsweredBy returns the model that produced the last answer and the errors from
+// the models tried before it
+func (r *FailoverResult) AnsweredBy() (string, []error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.model, r.failures
+}
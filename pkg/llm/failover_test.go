@@ -0,0 +1,155 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// chatEndpoint is an external chat endpoint answering with status and body,
+// counting its requests
+func chatEndpoint(t *testing.T, status int, body string, hits *atomic.Int32) *ExternalChatClient {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if hits != nil {
+			hits.Add(1)
+		}
+		w.WriteHeader(status)
+		io.WriteString(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return NewExternalChatClient(nil, server.URL)
+}
+
+func TestFailoverChain(t *testing.T) {
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+	unreachableClient := NewExternalChatClient(nil, down.URL)
+	overloaded := chatEndpoint(t, statusOverloaded, "overloaded", nil)
+	var answeredHits atomic.Int32
+	answers := chatEndpoint(t, http.StatusOK, "the answer", &answeredHits)
+	tooLong := chatEndpoint(t, http.StatusBadRequest, "prompt is too long", nil)
+	messages := []Message{{Role: "user", Content: "how does auth work?"}}
+
+	// overloaded and unreachable models fail over to the next
+	client := NewFailoverClient(overloaded, "primary", []ChatBackend{{Model: "down", Client: unreachableClient}, {Model: "last", Client: answers}})
+	var result FailoverResult
+	answer, err := client.ChatContext(WithFailoverResult(context.Background(), &result), messages)
+	if err != nil || answer != "the answer" {
+		t.Fatalf("expected the last model's answer, got %q, %v", answer, err)
+	}
+	model, failures := result.AnsweredBy()
+	if model != "last" || len(failures) != 2 || !errors.Is(failures[0], ErrOverloaded) || !errors.Is(failures[1], ErrUnreachable) {
+		t.Fatalf("answered by %q after %v", model, failures)
+	}
+
+	// a bad request would fail on every model: it isn't failed over
+	answeredHits.Store(0)
+	client = NewFailoverClient(tooLong, "primary", []ChatBackend{{Model: "last", Client: answers}})
+	result = FailoverResult{}
+	if _, err := client.ChatContext(WithFailoverResult(context.Background(), &result), messages); err == nil || !strings.Contains(err.Error(), "prompt is too long") {
+		t.Fatalf("expected the primary's error, got %v", err)
+	}
+	if answeredHits.Load() != 0 {
+		t.Errorf("a 400 failed over to the next model")
+	}
+	if model, _ := result.AnsweredBy(); model != "" {
+		t.Errorf("expected no model recorded as answering, got %q", model)
+	}
+
+	// a chain that runs out reports every model's error
+	client = NewFailoverClient(overloaded, "primary", []ChatBackend{{Model: "down", Client: unreachableClient}})
+	if _, err := client.Chat(messages); err == nil || !strings.Contains(err.Error(), "all chat models failed") || !errors.Is(err, ErrUnreachable) {
+		t.Errorf("expected all models failed, got %v", err)
+	}
+}
+
+func TestFailoverResultPerRequest(t *testing.T) {
+	// the primary is overloaded for some requests only
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "busy") {
+			w.WriteHeader(statusOverloaded)
+			return
+		}
+		io.WriteString(w, "primary")
+	}))
+	defer primary.Close()
+	client := NewFailoverClient(NewExternalChatClient(nil, primary.URL), "primary",
+		[]ChatBackend{{Model: "fallback", Client: chatEndpoint(t, http.StatusOK, "fallback", nil)}})
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			question, want := "quiet", "primary"
+			if i%2 == 0 {
+				question, want = "busy", "fallback"
+			}
+			var result FailoverResult
+			answer, err := client.ChatContext(WithFailoverResult(context.Background(), &result), []Message{{Role: "user", Content: question}})
+			if model, _ := result.AnsweredBy(); err != nil || answer != want || model != want {
+				errs <- fmt.Errorf("%s: answered %q by %q (%v), want %s", question, answer, model, err, want)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// streamingClient streams tokens to the token handler, then fails with err
+type streamingClient struct {
+	MockClient
+	tokens []string
+	err    error
+	calls  atomic.Int32
+}
+
+func (c *streamingClient) ChatContext(ctx context.Context, messages []Message) (string, error) {
+	c.calls.Add(1)
+	for _, token := range c.tokens {
+		tokenHandler(ctx)(token)
+	}
+	if c.err != nil {
+		return "", c.err
+	}
+	return strings.Join(c.tokens, ""), nil
+}
+
+func TestFailoverStreaming(t *testing.T) {
+	messages := []Message{{Role: "user", Content: "how does auth work?"}}
+	overloaded := fmt.Errorf("anthropic api stream error: overloaded_error: %w", ErrOverloaded)
+	ask := func(client *FailoverClient) (string, string, error) {
+		var streamed strings.Builder
+		ctx := WithTokenHandler(context.Background(), func(token string) { streamed.WriteString(token) })
+		answer, err := client.ChatContext(ctx, messages)
+		return answer, streamed.String(), err
+	}
+
+	// overloaded before the first token: the fallback streams the answer
+	fallback := &streamingClient{tokens: []string{"the ", "fallback"}}
+	answer, streamed, err := ask(NewFailoverClient(&streamingClient{err: overloaded}, "primary", []ChatBackend{{Model: "fallback", Client: fallback}}))
+	if err != nil || answer != "the fallback" || streamed != "the fallback" {
+		t.Fatalf("answer %q, streamed %q, %v", answer, streamed, err)
+	}
+
+	// overloaded mid-stream: the partial answer isn't followed by another
+	fallback = &streamingClient{tokens: []string{"the ", "fallback"}}
+	primary := &streamingClient{tokens: []string{"auth is "}, err: overloaded}
+	_, streamed, err = ask(NewFailoverClient(primary, "primary", []ChatBackend{{Model: "fallback", Client: fallback}}))
+	if !errors.Is(err, ErrOverloaded) || streamed != "auth is " || fallback.calls.Load() != 0 {
+		t.Fatalf("streamed %q, fallback called %d times, %v; want the primary's error", streamed, fallback.calls.Load(), err)
+	}
+}
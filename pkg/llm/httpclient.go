@@ -0,0 +1,90 @@
+package llm
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// defaultHTTPTimeout bounds a single api request (long chat completions included)
+const defaultHTTPTimeout = 5 * time.Minute
+
+var (
+	sharedTransport     *http.Transport
+	sharedTransportOnce sync.Once
+)
+
+// newHTTPClient returns an http client for provider apis with the given timeout
+// a zero timeout uses LR_HTTP_TIMEOUT (e.g. "90s") or defaultHTTPTimeout
+func newHTTPClient(timeout time.Duration) *http.Client {
+	if timeout == 0 {
+		timeout = httpTimeoutFromEnv()
+	}
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: apiTransport(),
+	}
+}
+
+// httpTimeoutFromEnv reads LR_HTTP_TIMEOUT, falling back to defaultHTTPTimeout
+func httpTimeoutFromEnv() time.Duration {
+	if v := os.Getenv("LR_HTTP_TIMEOUT"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+		fmt.Fprintf(os.Stderr, "warning: invalid LR_HTTP_TIMEOUT %q, using %s\n", v, defaultHTTPTimeout)
+	}
+	return defaultHTTPTimeout
+}
+
+// apiTransport builds the transport shared by all api clients:
+// proxies come from HTTPS_PROXY/HTTP_PROXY/NO_PROXY and LR_CA_BUNDLE adds a PEM
+// bundle of extra trusted roots (for tls-intercepting corporate proxies)
+func apiTransport() *http.Transport {
+	sharedTransportOnce.Do(func() {
+		transport := &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout:   30 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			TLSHandshakeTimeout: 15 * time.Second,
+			IdleConnTimeout:     90 * time.Second,
+			MaxIdleConns:        10,
+		}
+
+		if bundle := os.Getenv("LR_CA_BUNDLE"); bundle != "" {
+			pool, err := loadCABundle(bundle)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "warning: %v (using system roots)\n", err)
+			} else {
+				transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+			}
+		}
+
+		sharedTransport = transport
+	})
+	return sharedTransport
+}
+
+// loadCABundle returns the system roots plus the certificates in the PEM file at path
+func loadCABundle(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read LR_CA_BUNDLE: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("LR_CA_BUNDLE %s contains no valid certificates", path)
+	}
+	return pool, nil
+}
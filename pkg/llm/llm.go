@@ -0,0 +1,167 @@
+// Package llm defines the embedding and chat client interface lr uses, with
+// implementations for openai, voyage, ollama, anthropic and external plugins
+package llm
+
+import "context"
+
+// LLMClient is an interface for different LLM providers
+type LLMClient interface {
+	GetEmbedding(text string) ([]float64, error)
+	Chat(messages []Message) (string, error)
+}
+
+// ContextLLMClient is implemented by clients whose requests can be cancelled
+type ContextLLMClient interface {
+	GetEmbeddingContext(ctx context.Context, text string) ([]float64, error)
+	ChatContext(ctx context.Context, messages []Message) (string, error)
+}
+
+// ensure all clients implement the interface
+var _ LLMClient = (*OpenAIClient)(nil)
+var _ LLMClient = (*HybridClient)(nil)
+var _ LLMClient = (*VoyageClaudeClient)(nil)
+var _ LLMClient = (*OllamaClaudeClient)(nil)
+var _ LLMClient = (*PluginClaudeClient)(nil)
+var _ LLMClient = (*ExternalChatClient)(nil)
+var _ LLMClient = (*MockClient)(nil)
+var _ LLMClient = (*OllamaChatClient)(nil)
+var _ LLMClient = (*FailoverClient)(nil)
+
+var _ ContextLLMClient = (*OpenAIClient)(nil)
+var _ ContextLLMClient = (*HybridClient)(nil)
+var _ ContextLLMClient = (*VoyageClaudeClient)(nil)
+var _ ContextLLMClient = (*OllamaClaudeClient)(nil)
+var _ ContextLLMClient = (*PluginClaudeClient)(nil)
+var _ ContextLLMClient = (*ExternalChatClient)(nil)
+var _ ContextLLMClient = (*MockClient)(nil)
+var _ ContextLLMClient = (*OllamaChatClient)(nil)
+var _ ContextLLMClient = (*FailoverClient)(nil)
+
+// GetEmbeddingContext gets an embedding, returning as soon as ctx is done
+// even if the client doesn't support cancellation
+func GetEmbeddingContext(ctx context.Context, llm LLMClient, text string) ([]float64, error) {
+	if c, ok := llm.(ContextLLMClient); ok {
+		return c.GetEmbeddingContext(ctx, text)
+	}
+
+	type result struct {
+		embedding []float64
+		err       error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		embedding, err := llm.GetEmbedding(text)
+		ch <- result{embedding, err}
+	}()
+
+	select {
+	case r := <-ch:
+		return r.embedding, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// BatchEmbedder is implemented by clients that embed several texts per request
+type BatchEmbedder interface {
+	GetBatchEmbeddings(texts []string) ([][]float64, error)
+}
+
+// contextBatchEmbedder is a BatchEmbedder whose requests can be cancelled
+type contextBatchEmbedder interface {
+	GetBatchEmbeddingsContext(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+// GetBatchEmbeddingsContext embeds texts in one request when llm can, one at a
+// time otherwise, returning as soon as ctx is done
+func GetBatchEmbeddingsContext(ctx context.Context, llm LLMClient, texts []string) ([][]float64, error) {
+	if c, ok := llm.(contextBatchEmbedder); ok {
+		return c.GetBatchEmbeddingsContext(ctx, texts)
+	}
+	if b, ok := llm.(BatchEmbedder); ok {
+		type result struct {
+			embeddings [][]float64
+			err        error
+		}
+		ch := make(chan result, 1)
+		go func() {
+			embeddings, err := b.GetBatchEmbeddings(texts)
+			ch <- result{embeddings, err}
+		}()
+		select {
+		case r := <-ch:
+			return r.embeddings, r.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	embeddings := make([][]float64, len(texts))
+	for i, text := range texts {
+		embedding, err := GetEmbeddingContext(ctx, llm, text)
+		if err != nil {
+			return nil, err
+		}
+		embeddings[i] = embedding
+	}
+	return embeddings, nil
+}
+
+// ChatContext sends a chat request, returning as soon as ctx is done
+// even if the client doesn't support cancellation
+func ChatContext(ctx context.Context, llm LLMClient, messages []Message) (string, error) {
+	if c, ok := llm.(ContextLLMClient); ok {
+		return c.ChatContext(ctx, messages)
+	}
+
+	type result struct {
+		answer string
+		err    error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		answer, err := llm.Chat(messages)
+		ch <- result{answer, err}
+	}()
+
+	select {
+	case r := <-ch:
+		return r.answer, r.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// HybridClient uses OpenAI for embeddings and Claude for chat
+type HybridClient struct {
+	OpenAI *OpenAIClient
+	Claude *AnthropicClient
+}
+
+// NewHybridClient creates a client that uses OpenAI for embeddings and Claude for chat
+func NewHybridClient(openaiKey, claudeKey, embeddingModel, chatModel string) *HybridClient {
+	return &HybridClient{
+		OpenAI: NewOpenAIClient(openaiKey, "", embeddingModel), // empty chat model since we use Claude for chat
+		Claude: NewAnthropicClient(claudeKey, chatModel),
+	}
+}
+
+// GetEmbedding uses OpenAI for embeddings
+func (h *HybridClient) GetEmbedding(text string) ([]float64, error) {
+	return h.OpenAI.GetEmbedding(text)
+}
+
+// Chat uses Claude for chat completions
+func (h *HybridClient) Chat(messages []Message) (string, error) {
+	return h.Claude.Chat(messages)
+}
+
+// GetEmbeddingContext uses OpenAI for embeddings, aborting if ctx is done
+func (h *HybridClient) GetEmbeddingContext(ctx context.Context, text string) ([]float64, error) {
+	return h.OpenAI.GetEmbeddingContext(ctx, text)
+}
+
+// ChatContext uses Claude for chat completions, aborting if ctx is done
+func (h *HybridClient) ChatContext(ctx context.Context, messages []Message) (string, error) {
+	return h.Claude.ChatContext(ctx, messages)
+}
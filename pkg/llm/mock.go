@@ -0,0 +1,105 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strings"
+	"unicode"
+)
+
+// MockModel is the embedding and chat model name recorded for the mock client
+const MockModel = "mock"
+
+// mockDimensions is the size of mock embeddings
+const mockDimensions = 256
+
+// MockClient is an offline LLMClient for exercising indexing, search and the mcp
+// server without api keys or network. embeddings are deterministic hashes of the
+// text's words, so texts sharing words are similar, and chat returns a canned answer
+type MockClient struct{}
+
+// NewMockClient creates a mock client
+func NewMockClient() *MockClient {
+	return &MockClient{}
+}
+
+// GetEmbedding returns a deterministic embedding built by hashing the text's words
+func (m *MockClient) GetEmbedding(text string) ([]float64, error) {
+	embedding := make([]float64, mockDimensions)
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		h := fnv.New64a()
+		h.Write([]byte(w))
+		sum := h.Sum64()
+		sign := 1.0
+		if sum&(1<<63) != 0 {
+			sign = -1
+		}
+		embedding[sum%mockDimensions] += sign
+	}
+
+	var norm float64
+	for _, v := range embedding {
+		norm += v * v
+	}
+	if norm == 0 {
+		// no words: any fixed unit vector keeps similarity well defined
+		embedding[0] = 1
+		return embedding, nil
+	}
+	norm = math.Sqrt(norm)
+	for i := range embedding {
+		embedding[i] /= norm
+	}
+	return embedding, nil
+}
+
+// GetEmbeddingContext returns the mock embedding (it never blocks)
+func (m *MockClient) GetEmbeddingContext(ctx context.Context, text string) ([]float64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return m.GetEmbedding(text)
+}
+
+// GetBatchEmbeddings returns mock embeddings for several texts
+func (m *MockClient) GetBatchEmbeddings(texts []string) ([][]float64, error) {
+	embeddings := make([][]float64, len(texts))
+	for i, text := range texts {
+		embeddings[i], _ = m.GetEmbedding(text)
+	}
+	return embeddings, nil
+}
+
+// Chat returns a canned answer describing what it was given
+func (m *MockClient) Chat(messages []Message) (string, error) {
+	if len(messages) == 0 {
+		return "", fmt.Errorf("mock chat: no messages")
+	}
+	last := messages[len(messages)-1].Content
+	documents := strings.Count(last, "--- document ")
+	return fmt.Sprintf("mock answer (--mock-llm, no model was called): received %d message(s) with %d context document(s) and a %d character prompt",
+		len(messages), documents, len(last)), nil
+}
+
+// ChatContext returns the canned answer (it never blocks), streaming it word by
+// word to a token handler if ctx carries one
+func (m *MockClient) ChatContext(ctx context.Context, messages []Message) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	answer, err := m.Chat(messages)
+	if onToken := tokenHandler(ctx); onToken != nil && err == nil {
+		for i, word := range strings.Fields(answer) {
+			if i > 0 {
+				word = " " + word
+			}
+			onToken(word)
+		}
+	}
+	return answer, err
+}
@@ -0,0 +1,329 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// OllamaClient handles Ollama local API requests for embeddings
+type OllamaClient struct {
+	BaseURL string
+	Model   string
+	Client  *http.Client
+}
+
+// NewOllamaClient creates a new Ollama client
+func NewOllamaClient(model string) *OllamaClient {
+	if model == "" {
+		model = "nomic-embed-text"
+	}
+	return &OllamaClient{
+		BaseURL: "http://localhost:11434",
+		Model:   model,
+		Client:  newHTTPClient(30 * time.Second),
+	}
+}
+
+// OllamaEmbedRequest represents an Ollama embedding request (single text)
+type OllamaEmbedRequest struct {
+	Model string `json:"model"`
+	Input string `json:"input"`
+}
+
+// OllamaBatchEmbedRequest represents an Ollama batch embedding request
+type OllamaBatchEmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+// OllamaEmbedResponse represents an Ollama embedding response
+type OllamaEmbedResponse struct {
+	Embeddings [][]float64 `json:"embeddings"`
+}
+
+// GetEmbedding gets an embedding for the given text using Ollama
+func (o *OllamaClient) GetEmbedding(text string) ([]float64, error) {
+	return o.GetEmbeddingContext(context.Background(), text)
+}
+
+// GetEmbeddingContext gets an embedding using Ollama, aborting if ctx is done
+func (o *OllamaClient) GetEmbeddingContext(ctx context.Context, text string) ([]float64, error) {
+	reqBody := OllamaEmbedRequest{
+		Model: o.Model,
+		Input: text,
+	}
+
+	body, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", o.BaseURL+"/api/embed", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.Client.Do(req)
+	if err != nil {
+		return nil, unreachable(fmt.Errorf("ollama not running? %w (start with: ollama serve)", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, newAPIError("ollama", resp, bodyBytes)
+	}
+
+	var embResp OllamaEmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embResp); err != nil {
+		return nil, err
+	}
+
+	if len(embResp.Embeddings) == 0 {
+		return nil, fmt.Errorf("no embeddings returned from ollama")
+	}
+
+	return embResp.Embeddings[0], nil
+}
+
+// GetBatchEmbeddings gets embeddings for multiple texts in a single API call
+func (o *OllamaClient) GetBatchEmbeddings(texts []string) ([][]float64, error) {
+	ctx := context.Background()
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	reqBody := OllamaBatchEmbedRequest{
+		Model: o.Model,
+		Input: texts,
+	}
+
+	body, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", o.BaseURL+"/api/embed", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.Client.Do(req)
+	if err != nil {
+		return nil, unreachable(fmt.Errorf("ollama not running? %w (start with: ollama serve)", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, newAPIError("ollama", resp, bodyBytes)
+	}
+
+	var embResp OllamaEmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embResp); err != nil {
+		return nil, err
+	}
+
+	if len(embResp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("expected %d embeddings, got %d", len(texts), len(embResp.Embeddings))
+	}
+
+	return embResp.Embeddings, nil
+}
+
+// Chat is not supported by Ollama embeddings client
+func (o *OllamaClient) Chat(_ []Message) (string, error) {
+	return "", fmt.Errorf("ollama embeddings client does not support chat - use with claude")
+}
+
+// OllamaClaudeClient uses Ollama for embeddings and Claude for chat
+type OllamaClaudeClient struct {
+	Ollama    *OllamaClient
+	Claude    *AnthropicClient
+	chatModel string
+}
+
+// NewOllamaClaudeClient creates a client using Ollama embeddings + Claude chat
+// Claude client is created lazily when Chat is called (allows indexing without API key)
+func NewOllamaClaudeClient(embeddingModel, chatModel string) *OllamaClaudeClient {
+	return &OllamaClaudeClient{
+		Ollama:    NewOllamaClient(embeddingModel),
+		chatModel: chatModel,
+	}
+}
+
+// GetEmbedding uses Ollama for embeddings
+func (oc *OllamaClaudeClient) GetEmbedding(text string) ([]float64, error) {
+	return oc.Ollama.GetEmbedding(text)
+}
+
+// GetBatchEmbeddings uses Ollama for batch embeddings
+func (oc *OllamaClaudeClient) GetBatchEmbeddings(texts []string) ([][]float64, error) {
+	return oc.Ollama.GetBatchEmbeddings(texts)
+}
+
+// GetEmbeddingContext uses Ollama for embeddings, aborting if ctx is done
+func (oc *OllamaClaudeClient) GetEmbeddingContext(ctx context.Context, text string) ([]float64, error) {
+	return oc.Ollama.GetEmbeddingContext(ctx, text)
+}
+
+// Chat uses Claude for chat (lazily initializes Claude client)
+func (oc *OllamaClaudeClient) Chat(messages []Message) (string, error) {
+	return oc.ChatContext(context.Background(), messages)
+}
+
+// ChatContext uses Claude for chat, aborting if ctx is done
+func (oc *OllamaClaudeClient) ChatContext(ctx context.Context, messages []Message) (string, error) {
+	if oc.Claude == nil {
+		claudeKey := os.Getenv("ANTHROPIC_API_KEY")
+		if claudeKey == "" {
+			return "", fmt.Errorf("ANTHROPIC_API_KEY is required for chat synthesis")
+		}
+		oc.Claude = NewAnthropicClient(claudeKey, oc.chatModel)
+	}
+	return oc.Claude.ChatContext(ctx, messages)
+}
+
+// OllamaChatClient sends chat requests to a local Ollama model (e.g. as a failover
+// when hosted models are unavailable)
+type OllamaChatClient struct {
+	BaseURL string
+	Model   string
+	Client  *http.Client
+}
+
+// OllamaChatRequest represents an Ollama chat request
+type OllamaChatRequest struct {
+	Model    string             `json:"model"`
+	Messages []Message          `json:"messages"`
+	Stream   bool               `json:"stream"`
+	Options  *OllamaChatOptions `json:"options,omitempty"`
+}
+
+// OllamaChatOptions are the generation parameters of an Ollama chat request
+type OllamaChatOptions struct {
+	NumPredict  int      `json:"num_predict,omitempty"`
+	Temperature *float64 `json:"temperature,omitempty"`
+	Stop        []string `json:"stop,omitempty"`
+}
+
+// OllamaChatResponse represents an Ollama chat response, or one line of a
+// streamed response
+type OllamaChatResponse struct {
+	Message Message `json:"message"`
+	Done    bool    `json:"done"`
+	Error   string  `json:"error,omitempty"`
+}
+
+// NewOllamaChatClient creates a chat client for a local Ollama model
+func NewOllamaChatClient(model string) *OllamaChatClient {
+	if model == "" {
+		model = "llama3.2"
+	}
+	return &OllamaChatClient{
+		BaseURL: "http://localhost:11434",
+		Model:   model,
+		Client:  newHTTPClient(0),
+	}
+}
+
+// GetEmbedding is not supported by the Ollama chat client
+func (o *OllamaChatClient) GetEmbedding(_ string) ([]float64, error) {
+	return nil, fmt.Errorf("ollama chat client does not support embeddings")
+}
+
+// GetEmbeddingContext is not supported by the Ollama chat client
+func (o *OllamaChatClient) GetEmbeddingContext(_ context.Context, text string) ([]float64, error) {
+	return o.GetEmbedding(text)
+}
+
+// Chat sends a chat request to Ollama
+func (o *OllamaChatClient) Chat(messages []Message) (string, error) {
+	return o.ChatContext(context.Background(), messages)
+}
+
+// ChatContext sends a chat request to Ollama, aborting if ctx is done.
+// ChatOptions carried by ctx set max tokens, temperature and stop sequences, and
+// the answer is streamed to a token handler if ctx carries one
+func (o *OllamaChatClient) ChatContext(ctx context.Context, messages []Message) (string, error) {
+	onToken := tokenHandler(ctx)
+	chatReq := OllamaChatRequest{Model: o.Model, Messages: messages, Stream: onToken != nil}
+	if opts := ChatOptionsFrom(ctx); opts.MaxTokens > 0 || opts.Temperature != nil || len(opts.Stop) > 0 {
+		chatReq.Options = &OllamaChatOptions{NumPredict: opts.MaxTokens, Temperature: opts.Temperature, Stop: opts.Stop}
+	}
+	body, err := json.Marshal(chatReq)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", o.BaseURL+"/api/chat", bytes.NewBuffer(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := o.Client.Do(req)
+	if err != nil {
+		return "", unreachable(fmt.Errorf("ollama not running? %w (start with: ollama serve)", err))
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return "", newAPIError("ollama", resp, bodyBytes)
+	}
+
+	if onToken != nil {
+		return readOllamaStream(resp.Body, onToken)
+	}
+
+	var chatResp OllamaChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+		return "", err
+	}
+	if chatResp.Message.Content == "" {
+		return "", fmt.Errorf("no response from ollama")
+	}
+	return chatResp.Message.Content, nil
+}
+
+// readOllamaStream collects a streamed chat response (one json object per line),
+// passing each piece of the message to onToken
+func readOllamaStream(body io.Reader, onToken func(string)) (string, error) {
+	var answer strings.Builder
+	decoder := json.NewDecoder(body)
+	for {
+		var chunk OllamaChatResponse
+		if err := decoder.Decode(&chunk); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return "", err
+		}
+		if chunk.Error != "" {
+			return "", fmt.Errorf("ollama error: %s", chunk.Error)
+		}
+		if chunk.Message.Content != "" {
+			answer.WriteString(chunk.Message.Content)
+			onToken(chunk.Message.Content)
+		}
+		if chunk.Done {
+			break
+		}
+	}
+	if answer.Len() == 0 {
+		return "", fmt.Errorf("no response from ollama")
+	}
+	return answer.String(), nil
+}
@@ -1,11 +1,13 @@
-package main
+package llm
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 )
 
 // OpenAIClient handles OpenAI API requests
@@ -28,7 +30,7 @@ func NewOpenAIClient(apiKey, chatModel, embeddingModel string) *OpenAIClient {
 		APIKey:         apiKey,
 		ChatModel:      chatModel,
 		EmbeddingModel: embeddingModel,
-		Client:         &http.Client{},
+		Client:         newHTTPClient(0),
 	}
 }
 
@@ -47,6 +49,11 @@ type EmbeddingResponse struct {
 
 // GetEmbedding gets an embedding for the given text
 func (c *OpenAIClient) GetEmbedding(text string) ([]float64, error) {
+	return c.GetEmbeddingContext(context.Background(), text)
+}
+
+// GetEmbeddingContext gets an embedding for the given text, aborting if ctx is done
+func (c *OpenAIClient) GetEmbeddingContext(ctx context.Context, text string) ([]float64, error) {
 	reqBody := EmbeddingRequest{
 		Input: text,
 		Model: c.EmbeddingModel,
@@ -57,7 +64,7 @@ func (c *OpenAIClient) GetEmbedding(text string) ([]float64, error) {
 		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", "https://api.openai.com/v1/embeddings", bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/embeddings", bytes.NewBuffer(body))
 	if err != nil {
 		return nil, err
 	}
@@ -67,13 +74,13 @@ func (c *OpenAIClient) GetEmbedding(text string) ([]float64, error) {
 
 	resp, err := c.Client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, unreachable(err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("openai api error: %s - %s", resp.Status, string(bodyBytes))
+		return nil, newAPIError("openai api", resp, bodyBytes)
 	}
 
 	var embResp EmbeddingResponse
@@ -90,8 +97,12 @@ func (c *OpenAIClient) GetEmbedding(text string) ([]float64, error) {
 
 // ChatRequest represents an OpenAI chat completion request
 type ChatRequest struct {
-	Model    string    `json:"model"`
-	Messages []Message `json:"messages"`
+	Model       string    `json:"model"`
+	Messages    []Message `json:"messages"`
+	MaxTokens   int       `json:"max_completion_tokens,omitempty"`
+	Temperature *float64  `json:"temperature,omitempty"`
+	Stop        []string  `json:"stop,omitempty"`
+	Stream      bool      `json:"stream,omitempty"`
 }
 
 // Message represents a chat message
@@ -109,17 +120,37 @@ type ChatResponse struct {
 
 // Chat sends a chat completion request
 func (c *OpenAIClient) Chat(messages []Message) (string, error) {
+	return c.ChatContext(context.Background(), messages)
+}
+
+// chatStreamChunk is one server-sent event of a streamed chat completion
+type chatStreamChunk struct {
+	Choices []struct {
+		Delta Message `json:"delta"`
+	} `json:"choices"`
+}
+
+// ChatContext sends a chat completion request, aborting if ctx is done.
+// ChatOptions carried by ctx set max tokens, temperature and stop sequences, and
+// the answer is streamed to a token handler if ctx carries one
+func (c *OpenAIClient) ChatContext(ctx context.Context, messages []Message) (string, error) {
+	opts := ChatOptionsFrom(ctx)
 	reqBody := ChatRequest{
-		Model:    c.ChatModel,
-		Messages: messages,
+		Model:       c.ChatModel,
+		Messages:    messages,
+		MaxTokens:   opts.MaxTokens,
+		Temperature: opts.Temperature,
+		Stop:        opts.Stop,
 	}
+	onToken := tokenHandler(ctx)
+	reqBody.Stream = onToken != nil
 
 	body, err := json.Marshal(reqBody)
 	if err != nil {
 		return "", err
 	}
 
-	req, err := http.NewRequest("POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.openai.com/v1/chat/completions", bytes.NewBuffer(body))
 	if err != nil {
 		return "", err
 	}
@@ -129,13 +160,17 @@ func (c *OpenAIClient) Chat(messages []Message) (string, error) {
 
 	resp, err := c.Client.Do(req)
 	if err != nil {
-		return "", err
+		return "", unreachable(err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("openai api error: %s - %s", resp.Status, string(bodyBytes))
+		return "", newAPIError("openai api", resp, bodyBytes)
+	}
+
+	if onToken != nil {
+		return readOpenAIStream(resp.Body, onToken)
 	}
 
 	var chatResp ChatResponse
@@ -149,3 +184,32 @@ func (c *OpenAIClient) Chat(messages []Message) (string, error) {
 
 	return chatResp.Choices[0].Message.Content, nil
 }
+
+// readOpenAIStream collects a streamed chat completion, passing each content
+// delta to onToken
+func readOpenAIStream(body io.Reader, onToken func(string)) (string, error) {
+	var answer strings.Builder
+	err := readSSE(body, func(data string) error {
+		if data == "[DONE]" {
+			return errStreamDone
+		}
+		var chunk chatStreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("openai api returned an invalid stream chunk: %w", err)
+		}
+		for _, choice := range chunk.Choices {
+			if choice.Delta.Content != "" {
+				answer.WriteString(choice.Delta.Content)
+				onToken(choice.Delta.Content)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if answer.Len() == 0 {
+		return "", fmt.Errorf("no response from openai")
+	}
+	return answer.String(), nil
+}
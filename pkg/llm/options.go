@@ -0,0 +1,37 @@
+package llm
+
+import "context"
+
+// DefaultMaxTokens is the answer length limit used when ChatOptions.MaxTokens is 0
+// for providers that require one (anthropic)
+const DefaultMaxTokens = 8192
+
+// ChatOptions are per-request generation parameters. zero values leave the
+// provider's defaults in place
+type ChatOptions struct {
+	MaxTokens   int      // maximum answer length in tokens
+	Temperature *float64 // sampling temperature, nil for the provider default
+	Stop        []string // stop sequences
+}
+
+type chatOptionsKey struct{}
+
+// WithChatOptions returns a context carrying opts, so they reach the chat client
+// through any wrappers (usage metering, failover, embedding+chat pairs)
+func WithChatOptions(ctx context.Context, opts ChatOptions) context.Context {
+	return context.WithValue(ctx, chatOptionsKey{}, opts)
+}
+
+// ChatOptionsFrom returns the chat options carried by ctx, if any
+func ChatOptionsFrom(ctx context.Context) ChatOptions {
+	opts, _ := ctx.Value(chatOptionsKey{}).(ChatOptions)
+	return opts
+}
+
+// maxTokensOr returns the requested max tokens, or def if none was requested
+func (o ChatOptions) maxTokensOr(def int) int {
+	if o.MaxTokens > 0 {
+		return o.MaxTokens
+	}
+	return def
+}
@@ -0,0 +1,212 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"lr/internal/errkind"
+)
+
+// PluginPrefix marks an embedding model served by an external command,
+// e.g. --embedding-model "plugin:./my-embedder --dims 768"
+const PluginPrefix = "plugin:"
+
+// IsPluginModel reports whether model names an embedding plugin
+func IsPluginModel(model string) bool {
+	return strings.HasPrefix(model, PluginPrefix)
+}
+
+// pluginRequest is written to the plugin's stdin, one json object per line
+type pluginRequest struct {
+	Texts []string `json:"texts"`
+}
+
+// pluginResponse is read from the plugin's stdout, one json object per line
+type pluginResponse struct {
+	Embeddings [][]float64 `json:"embeddings"`
+	Error      string      `json:"error,omitempty"`
+}
+
+// PluginEmbedder gets embeddings from an external command speaking json lines over
+// stdin/stdout. the command is started on first use and kept running for later requests
+type PluginEmbedder struct {
+	command string
+
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+}
+
+// NewPluginEmbedder creates an embedder for a "plugin:<command>" model string
+func NewPluginEmbedder(model string) *PluginEmbedder {
+	return &PluginEmbedder{command: strings.TrimSpace(strings.TrimPrefix(model, PluginPrefix))}
+}
+
+// start launches the plugin process; callers hold p.mu
+func (p *PluginEmbedder) start() error {
+	if p.command == "" {
+		return fmt.Errorf("embedding plugin has no command (use plugin:<command>)")
+	}
+	cmd := exec.Command("sh", "-c", p.command)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return fmt.Errorf("embedding plugin: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("embedding plugin: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start embedding plugin %q: %w", p.command, err)
+	}
+	p.cmd = cmd
+	p.stdin = stdin
+	p.stdout = bufio.NewReaderSize(stdout, 1024*1024)
+	return nil
+}
+
+// stop kills the plugin process so the next request starts a fresh one; callers hold p.mu
+func (p *PluginEmbedder) stop() {
+	if p.cmd == nil {
+		return
+	}
+	p.stdin.Close()
+	p.cmd.Process.Kill()
+	p.cmd.Wait()
+	p.cmd = nil
+}
+
+// Close stops the plugin process
+func (p *PluginEmbedder) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stop()
+}
+
+// GetBatchEmbeddingsContext sends texts to the plugin and waits for their embeddings,
+// killing the plugin if ctx is done first
+func (p *PluginEmbedder) GetBatchEmbeddingsContext(ctx context.Context, texts []string) ([][]float64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cmd == nil {
+		if err := p.start(); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := json.Marshal(pluginRequest{Texts: texts})
+	if err != nil {
+		return nil, err
+	}
+
+	type result struct {
+		line []byte
+		err  error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		if _, err := p.stdin.Write(append(req, '\n')); err != nil {
+			ch <- result{nil, err}
+			return
+		}
+		line, err := p.stdout.ReadBytes('\n')
+		ch <- result{line, err}
+	}()
+
+	var r result
+	select {
+	case r = <-ch:
+	case <-ctx.Done():
+		p.stop()
+		return nil, ctx.Err()
+	}
+	if r.err != nil {
+		p.stop()
+		return nil, fmt.Errorf("embedding plugin %q failed: %w", p.command, r.err)
+	}
+
+	var resp pluginResponse
+	if err := json.Unmarshal(r.line, &resp); err != nil {
+		p.stop()
+		return nil, fmt.Errorf("embedding plugin returned invalid json: %w", err)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("embedding plugin error: %s", resp.Error)
+	}
+	if len(resp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("embedding plugin returned %d embeddings for %d texts", len(resp.Embeddings), len(texts))
+	}
+	return resp.Embeddings, nil
+}
+
+// GetBatchEmbeddings gets embeddings for several texts in one plugin request
+func (p *PluginEmbedder) GetBatchEmbeddings(texts []string) ([][]float64, error) {
+	return p.GetBatchEmbeddingsContext(context.Background(), texts)
+}
+
+// GetEmbeddingContext gets the embedding for a single text
+func (p *PluginEmbedder) GetEmbeddingContext(ctx context.Context, text string) ([]float64, error) {
+	embeddings, err := p.GetBatchEmbeddingsContext(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// GetEmbedding gets the embedding for a single text
+func (p *PluginEmbedder) GetEmbedding(text string) ([]float64, error) {
+	return p.GetEmbeddingContext(context.Background(), text)
+}
+
+// PluginClaudeClient uses an embedding plugin for embeddings and Claude for chat
+type PluginClaudeClient struct {
+	Plugin    *PluginEmbedder
+	Claude    *AnthropicClient
+	chatModel string
+}
+
+// NewPluginClaudeClient creates a client using plugin embeddings + Claude chat
+// Claude client is created lazily when Chat is called (allows indexing without API key)
+func NewPluginClaudeClient(embeddingModel, chatModel string) *PluginClaudeClient {
+	return &PluginClaudeClient{
+		Plugin:    NewPluginEmbedder(embeddingModel),
+		chatModel: chatModel,
+	}
+}
+
+// GetEmbedding uses the plugin for embeddings
+func (pc *PluginClaudeClient) GetEmbedding(text string) ([]float64, error) {
+	return pc.Plugin.GetEmbedding(text)
+}
+
+// GetEmbeddingContext uses the plugin for embeddings, aborting if ctx is done
+func (pc *PluginClaudeClient) GetEmbeddingContext(ctx context.Context, text string) ([]float64, error) {
+	return pc.Plugin.GetEmbeddingContext(ctx, text)
+}
+
+// Chat uses Claude for chat (lazily initializes Claude client)
+func (pc *PluginClaudeClient) Chat(messages []Message) (string, error) {
+	return pc.ChatContext(context.Background(), messages)
+}
+
+// ChatContext uses Claude for chat, aborting if ctx is done
+func (pc *PluginClaudeClient) ChatContext(ctx context.Context, messages []Message) (string, error) {
+	if pc.Claude == nil {
+		claudeKey := os.Getenv("ANTHROPIC_API_KEY")
+		if claudeKey == "" {
+			return "", errkind.With(fmt.Errorf("ANTHROPIC_API_KEY is required for chat synthesis"), ErrAuth)
+		}
+		pc.Claude = NewAnthropicClient(claudeKey, pc.chatModel)
+	}
+	return pc.Claude.ChatContext(ctx, messages)
+}
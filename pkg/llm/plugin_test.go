@@ -0,0 +1,82 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPluginEmbedder(t *testing.T) {
+	starts := filepath.Join(t.TempDir(), "starts")
+	p := NewPluginEmbedder("plugin: echo started >> " + starts + "; while read line; do echo '{\"embeddings\":[[0.5,0.25]]}'; done")
+	defer p.Close()
+
+	for range 3 {
+		embedding, err := p.GetEmbedding("func main() {}")
+		if err != nil || len(embedding) != 2 || embedding[0] != 0.5 {
+			t.Fatalf("embedding = %v, %v", embedding, err)
+		}
+	}
+	if data, _ := os.ReadFile(starts); strings.Count(string(data), "started") != 1 {
+		t.Errorf("the plugin started %d times for 3 requests, want once", strings.Count(string(data), "started"))
+	}
+
+	if _, err := NewPluginEmbedder("plugin:").GetEmbedding("x"); err == nil || !strings.Contains(err.Error(), "no command") {
+		t.Errorf("expected an error for a plugin without a command, got %v", err)
+	}
+}
+
+func TestPluginEmbedderRestart(t *testing.T) {
+	// the plugin answers one request and exits
+	p := NewPluginEmbedder(`plugin:read line; echo '{"embeddings":[[1,0]]}'`)
+	defer p.Close()
+
+	if _, err := p.GetEmbedding("first"); err != nil {
+		t.Fatal(err)
+	}
+	// the process is gone: the request fails, and the next starts a new one
+	if _, err := p.GetEmbedding("second"); err == nil {
+		t.Fatal("expected the request to the exited plugin to fail")
+	}
+	if embedding, err := p.GetEmbedding("third"); err != nil || len(embedding) != 2 {
+		t.Fatalf("expected the restarted plugin to answer, got %v, %v", embedding, err)
+	}
+}
+
+func TestPluginEmbedderCancel(t *testing.T) {
+	p := NewPluginEmbedder("plugin:read line; exec sleep 30")
+	defer p.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := p.GetEmbeddingContext(ctx, "slow"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected the deadline error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("cancelled request took %s", elapsed)
+	}
+	if p.cmd != nil {
+		t.Errorf("the plugin was left running after the cancelled read")
+	}
+}
+
+func TestPluginEmbedderMalformedReplies(t *testing.T) {
+	for _, tc := range []struct {
+		reply, want string
+	}{
+		{"not json", "invalid json"},
+		{`{"embeddings":[]}`, "0 embeddings for 1 texts"},
+		{`{"error":"model not loaded"}`, "model not loaded"},
+	} {
+		p := NewPluginEmbedder("plugin:while read line; do echo '" + tc.reply + "'; done")
+		if _, err := p.GetEmbedding("x"); err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("reply %s: got %v, want %q", tc.reply, err, tc.want)
+		}
+		p.Close()
+	}
+}
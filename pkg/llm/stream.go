@@ -0,0 +1,48 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+type tokenHandlerKey struct{}
+
+// WithTokenHandler returns a context asking chat clients that can stream to pass
+// each piece of the answer to fn as it is generated. the full answer is still
+// returned; clients that can't stream just return it
+func WithTokenHandler(ctx context.Context, fn func(token string)) context.Context {
+	return context.WithValue(ctx, tokenHandlerKey{}, fn)
+}
+
+// tokenHandler returns the token handler carried by ctx, or nil
+func tokenHandler(ctx context.Context) func(string) {
+	fn, _ := ctx.Value(tokenHandlerKey{}).(func(string))
+	return fn
+}
+
+// errStreamDone stops readSSE at the end-of-stream marker
+var errStreamDone = errors.New("stream done")
+
+// readSSE calls fn with the data of each server-sent event until the body ends
+// or fn returns an error (errStreamDone ends the stream cleanly)
+func readSSE(body io.Reader, fn func(data string) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data:") {
+			// event names, comments and blank separators
+			continue
+		}
+		if err := fn(strings.TrimSpace(strings.TrimPrefix(line, "data:"))); err != nil {
+			if errors.Is(err, errStreamDone) {
+				return nil
+			}
+			return err
+		}
+	}
+	return scanner.Err()
+}
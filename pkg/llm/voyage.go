@@ -1,7 +1,8 @@
-package main
+package llm
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -23,7 +24,7 @@ func NewVoyageClient(apiKey, model string) *VoyageClient {
 	return &VoyageClient{
 		APIKey: apiKey,
 		Model:  model,
-		Client: &http.Client{},
+		Client: newHTTPClient(0),
 	}
 }
 
@@ -42,6 +43,11 @@ type VoyageEmbeddingResponse struct {
 
 // GetEmbedding gets an embedding for the given text using Voyage AI
 func (v *VoyageClient) GetEmbedding(text string) ([]float64, error) {
+	return v.GetEmbeddingContext(context.Background(), text)
+}
+
+// GetEmbeddingContext gets an embedding using Voyage AI, aborting if ctx is done
+func (v *VoyageClient) GetEmbeddingContext(ctx context.Context, text string) ([]float64, error) {
 	reqBody := VoyageEmbeddingRequest{
 		Input: []string{text},
 		Model: v.Model,
@@ -52,7 +58,7 @@ func (v *VoyageClient) GetEmbedding(text string) ([]float64, error) {
 		return nil, err
 	}
 
-	req, err := http.NewRequest("POST", "https://api.voyageai.com/v1/embeddings", bytes.NewBuffer(body))
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://api.voyageai.com/v1/embeddings", bytes.NewBuffer(body))
 	if err != nil {
 		return nil, err
 	}
@@ -62,13 +68,13 @@ func (v *VoyageClient) GetEmbedding(text string) ([]float64, error) {
 
 	resp, err := v.Client.Do(req)
 	if err != nil {
-		return nil, err
+		return nil, unreachable(err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("voyage ai error: %s - %s", resp.Status, string(bodyBytes))
+		return nil, newAPIError("voyage ai", resp, bodyBytes)
 	}
 
 	var embResp VoyageEmbeddingResponse
@@ -111,3 +117,13 @@ func (vc *VoyageClaudeClient) GetEmbedding(text string) ([]float64, error) {
 func (vc *VoyageClaudeClient) Chat(messages []Message) (string, error) {
 	return vc.Claude.Chat(messages)
 }
+
+// GetEmbeddingContext uses Voyage for embeddings, aborting if ctx is done
+func (vc *VoyageClaudeClient) GetEmbeddingContext(ctx context.Context, text string) ([]float64, error) {
+	return vc.Voyage.GetEmbeddingContext(ctx, text)
+}
+
+// ChatContext uses Claude for chat, aborting if ctx is done
+func (vc *VoyageClaudeClient) ChatContext(ctx context.Context, messages []Message) (string, error) {
+	return vc.Claude.ChatContext(ctx, messages)
+}
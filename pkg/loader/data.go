@@ -0,0 +1,299 @@
+package loader
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// data files (csv, tsv, jsonl) are indexed as a summary of their schema (the
+// columns and their types, a few sample rows and the row count) rather than
+// their rows, so questions about the datasets and fixtures a repo has can be
+// answered without embedding megabytes of data. they are summarized whatever
+// their size
+
+// DataExtensions are the extensions of the data files lr summarizes
+var DataExtensions = []string{".csv", ".tsv", ".jsonl", ".ndjson"}
+
+const (
+	dataSampleRows  = 3   // sample rows shown in a summary
+	dataTypeRows    = 100 // rows column types are inferred from
+	dataValueLength = 60  // longest sample value shown before it is cut
+	dataMaxColumns  = 50  // most columns listed
+)
+
+var datePattern = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}(?:[T ]\d{2}:\d{2}(?::\d{2}(?:\.\d+)?)?(?:Z|[+-]\d{2}:?\d{2})?)?$`)
+
+// IsDataFile reports whether path is a data file lr summarizes
+func IsDataFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, e := range DataExtensions {
+		if ext == e {
+			return true
+		}
+	}
+	return false
+}
+
+// dataColumn is a column of a data file and the types of values seen in it
+type dataColumn struct {
+	name  string
+	types map[string]int
+}
+
+// SummarizeDataFile returns a document holding the summary of the data file
+// at path, relPath being its path in the source. the document's type is "data"
+func SummarizeDataFile(path, relPath string) (Document, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Document{}, err
+	}
+	defer f.Close()
+
+	var format string
+	var columns []*dataColumn
+	var samples [][]string
+	var rows int
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv", ".tsv":
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(path)), ".")
+		columns, samples, rows, err = summarizeCSV(f, format == "tsv")
+	default:
+		format = "jsonl"
+		columns, samples, rows, err = summarizeJSONL(f)
+	}
+	if err != nil {
+		return Document{}, fmt.Errorf("summarize %s: %w", relPath, err)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "data file: %s (%s)\n", relPath, format)
+	fmt.Fprintf(&b, "rows: %d\n", rows)
+	fmt.Fprintf(&b, "columns (%d):\n", len(columns))
+	for i, c := range columns {
+		if i == dataMaxColumns {
+			fmt.Fprintf(&b, "  ... %d more\n", len(columns)-dataMaxColumns)
+			break
+		}
+		fmt.Fprintf(&b, "  %s: %s\n", c.name, c.typeName())
+	}
+	if len(samples) > 0 {
+		b.WriteString("sample rows:\n")
+		for _, row := range samples {
+			var fields []string
+			for i, v := range row {
+				if i < len(columns) && i < dataMaxColumns {
+					fields = append(fields, columns[i].name+"="+cutValue(v))
+				}
+			}
+			fmt.Fprintf(&b, "  %s\n", strings.Join(fields, ", "))
+		}
+	}
+
+	return Document{
+		Content: b.String(),
+		Source:  relPath,
+		Metadata: map[string]string{
+			"path": relPath,
+			"type": "data",
+		},
+	}, nil
+}
+
+// summarizeCSV reads the header, sample rows and row count of a csv (or tsv)
+func summarizeCSV(r io.Reader, tabs bool) ([]*dataColumn, [][]string, int, error) {
+	cr := csv.NewReader(bufio.NewReader(r))
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	cr.ReuseRecord = true
+	if tabs {
+		cr.Comma = '\t'
+	}
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil, nil, 0, nil
+	}
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	var columns []*dataColumn
+	for _, name := range header {
+		columns = append(columns, &dataColumn{name: strings.TrimSpace(name), types: make(map[string]int)})
+	}
+
+	var samples [][]string
+	rows := 0
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, 0, fmt.Errorf("row %d: %w", rows+1, err)
+		}
+		if rows < dataTypeRows {
+			for i, v := range record {
+				if i < len(columns) {
+					columns[i].types[valueType(v)]++
+				}
+			}
+		}
+		if rows < dataSampleRows {
+			samples = append(samples, append([]string(nil), record...))
+		}
+		rows++
+	}
+	return columns, samples, rows, nil
+}
+
+// summarizeJSONL reads the keys, sample rows and row count of json lines. the
+// columns are the keys of the objects, in the order they are first seen
+func summarizeJSONL(r io.Reader) ([]*dataColumn, [][]string, int, error) {
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var columns []*dataColumn
+	index := make(map[string]int)
+	var samples [][]string
+	rows := 0
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		if rows < dataTypeRows {
+			keys, values, err := jsonObject(line)
+			if err != nil {
+				return nil, nil, 0, fmt.Errorf("line %d: %w", rows+1, err)
+			}
+			row := make([]string, len(columns))
+			for i, key := range keys {
+				j, ok := index[key]
+				if !ok {
+					j = len(columns)
+					index[key] = j
+					columns = append(columns, &dataColumn{name: key, types: make(map[string]int)})
+					row = append(row, "")
+				}
+				columns[j].types[jsonType(values[i])]++
+				row[j] = string(values[i])
+			}
+			if rows < dataSampleRows {
+				samples = append(samples, row)
+			}
+		}
+		rows++
+	}
+	return columns, samples, rows, sc.Err()
+}
+
+// jsonObject decodes a json object, returning its keys in order and their raw values
+func jsonObject(line string) ([]string, []json.RawMessage, error) {
+	dec := json.NewDecoder(strings.NewReader(line))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return nil, nil, fmt.Errorf("not a json object")
+	}
+	var keys []string
+	var values []json.RawMessage
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, tok.(string))
+		values = append(values, v)
+	}
+	return keys, values, nil
+}
+
+// jsonType names the type of a raw json value
+func jsonType(v json.RawMessage) string {
+	s := strings.TrimSpace(string(v))
+	switch {
+	case s == "null":
+		return "null"
+	case s == "true" || s == "false":
+		return "boolean"
+	case strings.HasPrefix(s, `"`):
+		var str string
+		if json.Unmarshal(v, &str) == nil && datePattern.MatchString(str) {
+			return "date"
+		}
+		return "string"
+	case strings.HasPrefix(s, "{"):
+		return "object"
+	case strings.HasPrefix(s, "["):
+		return "array"
+	case strings.ContainsAny(s, ".eE"):
+		return "float"
+	}
+	return "integer"
+}
+
+// valueType names the type a csv value looks like
+func valueType(v string) string {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return "null"
+	}
+	if _, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return "integer"
+	}
+	if _, err := strconv.ParseFloat(v, 64); err == nil {
+		return "float"
+	}
+	if b := strings.ToLower(v); b == "true" || b == "false" {
+		return "boolean"
+	}
+	if datePattern.MatchString(v) {
+		return "date"
+	}
+	return "string"
+}
+
+// typeName summarizes the types seen in a column: one type, integers widened
+// to floats, several types as "a|b", and "(nullable)" when values were missing
+func (c *dataColumn) typeName() string {
+	types := make(map[string]int)
+	for t, n := range c.types {
+		types[t] = n
+	}
+	nullable := types["null"] > 0
+	delete(types, "null")
+	if types["integer"] > 0 && types["float"] > 0 {
+		types["float"] += types["integer"]
+		delete(types, "integer")
+	}
+	var names []string
+	for t := range types {
+		names = append(names, t)
+	}
+	sort.Strings(names)
+	name := strings.Join(names, "|")
+	if name == "" {
+		name = "null"
+	} else if nullable {
+		name += " (nullable)"
+	}
+	return name
+}
+
+// cutValue shortens a sample value for the summary
+func cutValue(v string) string {
+	v = strings.Join(strings.Fields(v), " ")
+	if r := []rune(v); len(r) > dataValueLength {
+		return string(r[:dataValueLength]) + "..."
+	}
+	return v
+}
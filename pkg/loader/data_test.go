@@ -0,0 +1,37 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSummarizeDataFile(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "users.csv"), []byte("id,email,score,joined\n1,a@example.com,3.5,2024-01-02\n2,b@example.com,4,\n3,c@example.com,5,2024-03-04\n"), 0644)
+	os.WriteFile(filepath.Join(dir, "events.jsonl"), []byte(`{"id":1,"kind":"login","at":"2024-01-02T10:00:00Z"}`+"\n"+`{"id":2,"kind":"logout","meta":{"ip":"10.0.0.1"}}`+"\n"), 0644)
+
+	result, err := LoadFilesByExtensionsWithStatsAndSplit(dir, DataExtensions, "data", 10, false, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	summaries := make(map[string]string)
+	for _, doc := range result.Documents {
+		summaries[doc.Source] = doc.Content
+		if doc.Metadata["type"] != "data" {
+			t.Errorf("%s: type %q, want data", doc.Source, doc.Metadata["type"])
+		}
+	}
+	// both are summarized though larger than the 10 byte limit
+	for file, want := range map[string][]string{
+		"users.csv":    {"rows: 3", "id: integer", "score: float", "joined: date (nullable)", "id=1, email=a@example.com"},
+		"events.jsonl": {"rows: 2", "kind: string", "at: date", "meta: object", `kind="login"`},
+	} {
+		for _, w := range want {
+			if !strings.Contains(summaries[file], w) {
+				t.Errorf("%s summary lacks %q:\n%s", file, w, summaries[file])
+			}
+		}
+	}
+}
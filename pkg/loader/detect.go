@@ -0,0 +1,94 @@
+package loader
+
+import (
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// DetectedFiles, in a list of extensions, also selects the files
+// DetectFileType recognizes. CodeExtensions includes it, so build tooling is
+// indexed with the code it builds
+const DetectedFiles = "#!"
+
+// SpecialFiles maps the lower-case names of files without a telling extension,
+// mostly build tooling, to their document type
+var SpecialFiles = map[string]string{
+	"makefile": "makefile", "gnumakefile": "makefile", "justfile": "just",
+	"dockerfile": "dockerfile", "containerfile": "dockerfile",
+	"jenkinsfile": "groovy", "rakefile": "ruby", "gemfile": "ruby", "vagrantfile": "ruby", "brewfile": "ruby",
+	"build": "starlark", "build.bazel": "starlark", "workspace": "starlark", "tiltfile": "starlark",
+	"procfile": "procfile",
+}
+
+// Shebangs maps the interpreters named by scripts' #! lines to the document
+// type of the scripts. versioned names (python3.12) are looked up without
+// their version too
+var Shebangs = map[string]string{
+	"sh": "shell", "bash": "shell", "zsh": "shell", "dash": "shell", "ksh": "shell", "fish": "shell",
+	"python": "python", "node": "javascript", "deno": "typescript", "bun": "javascript",
+	"ruby": "ruby", "perl": "perl", "php": "php", "lua": "lua",
+}
+
+// maxShebangLine bounds what is read of a file looking for its #! line
+const maxShebangLine = 256
+
+// DetectFileType returns the document type of a file known by name (see
+// SpecialFiles) or of an extensionless script with a known interpreter (see
+// Shebangs), or "" for anything else
+func DetectFileType(filePath string) string {
+	if t, ok := SpecialFiles[strings.ToLower(filepath.Base(filePath))]; ok {
+		return t
+	}
+	if filepath.Ext(filePath) != "" {
+		return ""
+	}
+	f, err := os.Open(filePath)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	head := make([]byte, maxShebangLine)
+	n, _ := io.ReadFull(f, head)
+	return shebangType(string(head[:n]))
+}
+
+// shebangType returns the document type of a script starting with head, from
+// its interpreter: /bin/bash, or python3 in "/usr/bin/env -S python3 -u"
+func shebangType(head string) string {
+	line, ok := strings.CutPrefix(head, "#!")
+	if !ok {
+		return ""
+	}
+	line, _, _ = strings.Cut(line, "\n")
+	for i, field := range strings.Fields(line) {
+		name := path.Base(field)
+		// env runs the interpreter after its options and variables
+		if (i == 0 && name == "env") || (i > 0 && (strings.HasPrefix(field, "-") || strings.Contains(field, "="))) {
+			continue
+		}
+		if t, ok := Shebangs[name]; ok {
+			return t
+		}
+		return Shebangs[strings.TrimRight(name, "0123456789.")]
+	}
+	return ""
+}
+
+// Selects reports whether extensions select the file at filePath: by its
+// extension (or name suffix), or by DetectFileType when they include
+// DetectedFiles, which reads the file
+func Selects(filePath string, extensions []string) bool {
+	lower := strings.ToLower(filePath)
+	detect := false
+	for _, ext := range extensions {
+		if ext == DetectedFiles {
+			detect = true
+		} else if strings.HasSuffix(lower, ext) {
+			return true
+		}
+	}
+	return detect && DetectFileType(filePath) != ""
+}
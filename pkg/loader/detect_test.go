@@ -0,0 +1,52 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestShebangType(t *testing.T) {
+	tests := map[string]string{
+		"#!/bin/bash\nset -e\n":               "shell",
+		"#!/usr/bin/env python3\n":            "python",
+		"#!/usr/bin/env -S python3.12 -u\n":   "python",
+		"#!/usr/bin/env NODE_ENV=prod node\n": "javascript",
+		"#! /usr/bin/perl -w\n":               "perl",
+		"#!/usr/bin/awk -f\n":                 "",
+		"echo no shebang\n":                   "",
+		"#!/usr/bin/env\n":                    "",
+	}
+	for head, want := range tests {
+		if got := shebangType(head); got != want {
+			t.Errorf("shebangType(%q) = %q, want %q", head, got, want)
+		}
+	}
+}
+
+func TestSelectsDetectedFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	makefile := write("Makefile", "build:\n\tgo build\n")
+	script := write("deploy", "#!/bin/sh\necho deploy\n")
+	notes := write("NOTES", "just text\n")
+	shellScript := write("run.sh", "#!/bin/sh\n")
+
+	for path, want := range map[string]bool{makefile: true, script: true, notes: false, shellScript: false} {
+		if got := Selects(path, CodeExtensions); got != want {
+			t.Errorf("Selects(%s, code) = %v, want %v", filepath.Base(path), got, want)
+		}
+	}
+	if Selects(makefile, []string{".md"}) {
+		t.Error("a docs-only selection selected the Makefile")
+	}
+	if got := DetectFileType(makefile); got != "makefile" {
+		t.Errorf("DetectFileType(Makefile) = %q, want makefile", got)
+	}
+}
@@ -0,0 +1,38 @@
+package loader
+
+import (
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// go files record their package and imports, so a query can follow the import
+// graph from a result to the packages it uses and those that use it
+
+const (
+	// GoPackageMetadataKey is the document metadata holding a go file's package name
+	GoPackageMetadataKey = "go_package"
+	// GoImportsMetadataKey is the document metadata holding the import paths
+	// of a go file, comma-separated
+	GoImportsMetadataKey = "go_imports"
+)
+
+// setGoImports records the package and imports of the go source content in
+// metadata. a file that doesn't parse records what was read before the error
+func setGoImports(content string, metadata map[string]string) {
+	f, _ := parser.ParseFile(token.NewFileSet(), "", content, parser.ImportsOnly)
+	if f == nil || f.Name == nil {
+		return
+	}
+	metadata[GoPackageMetadataKey] = f.Name.Name
+	var imports []string
+	for _, imp := range f.Imports {
+		if path, err := strconv.Unquote(imp.Path.Value); err == nil {
+			imports = append(imports, path)
+		}
+	}
+	if len(imports) > 0 {
+		metadata[GoImportsMetadataKey] = strings.Join(imports, ",")
+	}
+}
@@ -0,0 +1,67 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	ignore "github.com/sabhiram/go-gitignore"
+)
+
+// ignoreFiles are the gitignore-style files honored at the root of a source
+// tree: .gitignore, and .lrignore for exclusions that only apply to lr
+var ignoreFiles = []string{".gitignore", ".lrignore"}
+
+// IgnoreRules are the compiled ignore files of a source tree
+type IgnoreRules struct {
+	files       []string // the ignore files found, parallel to matchers
+	matchers    []*ignore.GitIgnore
+	hasNegation bool // some pattern re-includes paths ("!*.go")
+}
+
+// LoadIgnoreRules compiles the ignore files at the root of rootDir. missing or
+// unreadable files are skipped
+func LoadIgnoreRules(rootDir string) *IgnoreRules {
+	rules := &IgnoreRules{}
+	for _, name := range ignoreFiles {
+		data, err := os.ReadFile(filepath.Join(rootDir, name))
+		if err != nil {
+			continue
+		}
+		lines := strings.Split(string(data), "\n")
+		for _, line := range lines {
+			if strings.HasPrefix(strings.TrimSpace(line), "!") {
+				rules.hasNegation = true
+			}
+		}
+		rules.files = append(rules.files, name)
+		rules.matchers = append(rules.matchers, ignore.CompileIgnoreLines(lines...))
+	}
+	return rules
+}
+
+// IgnoredBy returns the ignore file ("gitignore" or "lrignore") that excludes the
+// file at relPath, relative to the root, or "" if none does
+func (r *IgnoreRules) IgnoredBy(relPath string) string {
+	for i, m := range r.matchers {
+		if m.MatchesPath(relPath) {
+			return strings.TrimPrefix(r.files[i], ".")
+		}
+	}
+	return ""
+}
+
+// Ignored reports whether the file at relPath, relative to the root, is excluded
+func (r *IgnoreRules) Ignored(relPath string) bool {
+	return r.IgnoredBy(relPath) != ""
+}
+
+// SkipDir reports whether the directory at relPath, relative to the root, is
+// excluded as a whole and needn't be walked or watched. with negated patterns
+// (e.g. "*" then "!*.go") files can be re-included anywhere, so no directory is
+func (r *IgnoreRules) SkipDir(relPath string) bool {
+	if r.hasNegation || relPath == "." {
+		return false
+	}
+	return r.Ignored(filepath.ToSlash(relPath) + "/")
+}
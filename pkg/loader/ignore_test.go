@@ -0,0 +1,34 @@
+package loader
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIgnoreRules(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, ".gitignore"), []byte(".venv/\n*.out\n"), 0644)
+	os.WriteFile(filepath.Join(dir, ".lrignore"), []byte("coverage/\n"), 0644)
+	rules := LoadIgnoreRules(dir)
+
+	for path, want := range map[string]string{
+		"main.go":             "",
+		"cover.out":           "gitignore",
+		"coverage/index.html": "lrignore",
+		".venv/lib/site.py":   "gitignore",
+	} {
+		if got := rules.IgnoredBy(path); got != want {
+			t.Errorf("IgnoredBy(%q) = %q, want %q", path, got, want)
+		}
+	}
+	if !rules.SkipDir(".venv") || !rules.SkipDir("a/coverage") || rules.SkipDir("src") || rules.SkipDir(".") {
+		t.Error("SkipDir should skip exactly the ignored directories")
+	}
+
+	// with an allowlist any directory may hold re-included files
+	os.WriteFile(filepath.Join(dir, ".gitignore"), []byte("*\n!*.go\n"), 0644)
+	if rules := LoadIgnoreRules(dir); rules.SkipDir("src") || rules.Ignored("src/main.go") {
+		t.Error("negated patterns should keep directories and re-included files")
+	}
+}
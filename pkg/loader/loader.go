@@ -1,4 +1,6 @@
-package main
+// Package loader walks a source tree and loads the files lr indexes as documents,
+// honoring .gitignore (and .lrignore) and skipping generated, binary and oversized files
+package loader
 
 import (
 	"fmt"
@@ -6,8 +8,6 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
-
-	ignore "github.com/sabhiram/go-gitignore"
 )
 
 // Document represents a loaded document with metadata
@@ -17,6 +17,13 @@ type Document struct {
 	Metadata map[string]string
 }
 
+// SkippedFile represents a file that was skipped during indexing
+type SkippedFile struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"` // e.g., "too large (150KB)", "test file", "binary file"
+	Size   int64  `json:"size"`   // file size in bytes
+}
+
 // ShouldExcludeFile returns true if the file should be excluded from indexing
 func ShouldExcludeFile(path string) bool {
 	baseName := filepath.Base(path)
@@ -29,7 +36,8 @@ func ShouldExcludeFile(path string) bool {
 		lowerName == "bundle.js" || lowerName == "vendor.js" ||
 		strings.HasSuffix(lowerName, ".chunk.js") ||
 		lowerName == "package-lock.json" || lowerName == "yarn.lock" ||
-		lowerName == "pnpm-lock.yaml" || lowerName == "go.sum" {
+		lowerName == "pnpm-lock.yaml" || lowerName == "go.sum" ||
+		lowerName == ".terraform.lock.hcl" {
 		return true
 	}
 
@@ -58,9 +66,50 @@ func LoadMarkdownFiles(rootDir string) ([]Document, error) {
 	return LoadFilesByExtensions(rootDir, []string{".md"}, "markdown")
 }
 
-// LoadCodeFiles loads code files (Go, JavaScript, TypeScript, Python, Java, C) from the given directory
+// CodeExtensions are the extensions of the code files lr indexes, and
+// DetectedFiles for build files and scripts without one
+var CodeExtensions = []string{
+	".go", ".js", ".ts", ".jsx", ".tsx", ".templ", ".py", ".java", ".c", ".h",
+	".rs", ".cpp", ".cc", ".cxx", ".hpp", ".hh", ".hxx", ".cs", ".sql", ".html",
+	DetectedFiles,
+}
+
+// codeFileTypes maps code file extensions to the document type the chunker
+// splits them by
+var codeFileTypes = map[string]string{
+	".go": "go", ".js": "javascript", ".jsx": "jsx", ".ts": "typescript", ".tsx": "tsx",
+	".templ": "templ", ".py": "python", ".java": "java", ".c": "c", ".h": "c", ".rs": "rust",
+	".cpp": "cpp", ".cc": "cpp", ".cxx": "cpp", ".hpp": "cpp", ".hh": "cpp", ".hxx": "cpp", ".cs": "csharp",
+	".sql": "sql", ".html": "html",
+}
+
+// CodeFileType returns the document type of a code file ("go", "rust", ...), or "" if path isn't code
+func CodeFileType(path string) string {
+	return codeFileTypes[filepath.Ext(path)]
+}
+
+// ConfigExtensions are the extensions of the config files lr indexes.
+// dockerfiles have none, so they are matched by name ("Dockerfile",
+// "api.Dockerfile")
+var ConfigExtensions = []string{".yaml", ".yml", ".toml", ".json", ".hcl", ".tf", ".tfvars", "dockerfile"}
+
+// configFileTypes maps config file extensions to the document type the chunker
+// splits them by
+var configFileTypes = map[string]string{
+	".yaml": "yaml", ".yml": "yaml", ".toml": "toml", ".json": "json", ".hcl": "hcl", ".tf": "hcl", ".tfvars": "hcl",
+}
+
+// ConfigFileType returns the document type of a config file ("yaml", "dockerfile", ...), or "" if path isn't config
+func ConfigFileType(path string) string {
+	if strings.HasSuffix(strings.ToLower(path), "dockerfile") {
+		return "dockerfile"
+	}
+	return configFileTypes[strings.ToLower(filepath.Ext(path))]
+}
+
+// LoadCodeFiles loads code files (Go, JavaScript, TypeScript, Python, Java, C, Rust, C++, C#, SQL) from the given directory
 func LoadCodeFiles(rootDir string) ([]Document, error) {
-	return LoadFilesByExtensions(rootDir, []string{".go", ".js", ".ts", ".jsx", ".tsx", ".py", ".java", ".c", ".h"}, "code")
+	return LoadFilesByExtensions(rootDir, CodeExtensions, "code")
 }
 
 // LoadFilesByExtensions loads files with specific extensions from the given directory
@@ -81,12 +130,8 @@ func LoadFilesByExtensionsWithStatsAndSplit(rootDir string, extensions []string,
 		SkippedFiles: []SkippedFile{},
 	}
 
-	// try to load .gitignore if it exists
-	var gitignore *ignore.GitIgnore
-	gitignorePath := filepath.Join(rootDir, ".gitignore")
-	if _, err := os.Stat(gitignorePath); err == nil {
-		gitignore, _ = ignore.CompileIgnoreFile(gitignorePath)
-	}
+	// .gitignore and .lrignore at the root, if present
+	rules := LoadIgnoreRules(rootDir)
 
 	err := filepath.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
@@ -96,9 +141,9 @@ func LoadFilesByExtensionsWithStatsAndSplit(rootDir string, extensions []string,
 		// get relative path for gitignore checking
 		relPath, _ := filepath.Rel(rootDir, path)
 
-		// check gitignore for files only - don't skip directories based on gitignore
+		// check ignore rules for files only - don't skip directories based on them
 		// because allowlist patterns (like "* then !*.go") need to check actual files
-		if gitignore != nil && !d.IsDir() && gitignore.MatchesPath(relPath) {
+		if ignoredBy := rules.IgnoredBy(relPath); ignoredBy != "" && !d.IsDir() {
 			info, _ := d.Info()
 			size := int64(0)
 			if info != nil {
@@ -106,7 +151,7 @@ func LoadFilesByExtensionsWithStatsAndSplit(rootDir string, extensions []string,
 			}
 			result.SkippedFiles = append(result.SkippedFiles, SkippedFile{
 				Path:   relPath,
-				Reason: "gitignore",
+				Reason: ignoredBy,
 				Size:   size,
 			})
 			return nil
@@ -126,16 +171,8 @@ func LoadFilesByExtensionsWithStatsAndSplit(rootDir string, extensions []string,
 
 		result.TotalFiles++
 
-		// check if file has one of the desired extensions
-		hasExtension := false
-		for _, ext := range extensions {
-			if strings.HasSuffix(strings.ToLower(path), ext) {
-				hasExtension = true
-				break
-			}
-		}
-
-		if !hasExtension {
+		// check if file has one of the desired extensions (or is detected)
+		if !Selects(path, extensions) {
 			// track as skipped with extension reason
 			ext := filepath.Ext(path)
 			if ext != "" {
@@ -159,6 +196,8 @@ func LoadFilesByExtensionsWithStatsAndSplit(rootDir string, extensions []string,
 			strings.HasSuffix(baseName, "_test.ts") || strings.HasSuffix(baseName, "_test.js") ||
 			strings.HasSuffix(baseName, ".test.ts") || strings.HasSuffix(baseName, ".test.js") ||
 			strings.HasSuffix(baseName, "_test.py") || strings.HasSuffix(baseName, "Test.java") ||
+			strings.HasSuffix(baseName, "_test.cc") || strings.HasSuffix(baseName, "_test.cpp") ||
+			strings.HasSuffix(baseName, "Tests.cs") || strings.HasSuffix(baseName, "Test.cs") ||
 			strings.Contains(baseName, "test_")) {
 			result.SkippedFiles = append(result.SkippedFiles, SkippedFile{
 				Path:   relPath,
@@ -178,6 +217,21 @@ func LoadFilesByExtensionsWithStatsAndSplit(rootDir string, extensions []string,
 			return nil
 		}
 
+		// data files are indexed as a summary, whatever their size
+		if IsDataFile(path) {
+			doc, err := SummarizeDataFile(path, relPath)
+			if err != nil {
+				result.SkippedFiles = append(result.SkippedFiles, SkippedFile{
+					Path:   relPath,
+					Reason: fmt.Sprintf("unreadable data file: %v", err),
+					Size:   info.Size(),
+				})
+				return nil
+			}
+			result.Documents = append(result.Documents, doc)
+			return nil
+		}
+
 		content, err := os.ReadFile(path)
 		if err != nil {
 			return err
@@ -185,20 +239,12 @@ func LoadFilesByExtensionsWithStatsAndSplit(rootDir string, extensions []string,
 
 		// determine file type
 		fileType := docType
-		if strings.HasSuffix(path, ".go") {
-			fileType = "go"
-		} else if strings.HasSuffix(path, ".js") || strings.HasSuffix(path, ".jsx") {
-			fileType = "javascript"
-		} else if strings.HasSuffix(path, ".ts") || strings.HasSuffix(path, ".tsx") {
-			fileType = "typescript"
-		} else if strings.HasSuffix(path, ".templ") {
-			fileType = "templ"
-		} else if strings.HasSuffix(path, ".py") {
-			fileType = "python"
-		} else if strings.HasSuffix(path, ".java") {
-			fileType = "java"
-		} else if strings.HasSuffix(path, ".c") || strings.HasSuffix(path, ".h") {
-			fileType = "c"
+		if t := CodeFileType(path); t != "" {
+			fileType = t
+		} else if t := ConfigFileType(path); t != "" {
+			fileType = t
+		} else if t := DetectFileType(path); t != "" {
+			fileType = t
 		}
 
 		// handle large files
@@ -227,6 +273,9 @@ func LoadFilesByExtensionsWithStatsAndSplit(rootDir string, extensions []string,
 				"type": fileType,
 			},
 		}
+		if fileType == "go" {
+			setGoImports(doc.Content, doc.Metadata)
+		}
 
 		result.Documents = append(result.Documents, doc)
 		return nil
@@ -246,6 +295,19 @@ func LoadSpecificFiles(rootDir string, files []string, docType string, maxFileSi
 	for _, relPath := range files {
 		path := filepath.Join(rootDir, relPath)
 
+		if IsDataFile(path) {
+			doc, err := SummarizeDataFile(path, relPath)
+			if err != nil {
+				result.SkippedFiles = append(result.SkippedFiles, SkippedFile{
+					Path:   relPath,
+					Reason: fmt.Sprintf("unreadable data file: %v", err),
+				})
+				continue
+			}
+			result.Documents = append(result.Documents, doc)
+			continue
+		}
+
 		content, err := os.ReadFile(path)
 		if err != nil {
 			result.SkippedFiles = append(result.SkippedFiles, SkippedFile{
@@ -257,22 +319,14 @@ func LoadSpecificFiles(rootDir string, files []string, docType string, maxFileSi
 
 		// determine file type
 		fileType := docType
-		if strings.HasSuffix(path, ".go") {
-			fileType = "go"
-		} else if strings.HasSuffix(path, ".js") || strings.HasSuffix(path, ".jsx") {
-			fileType = "javascript"
-		} else if strings.HasSuffix(path, ".ts") || strings.HasSuffix(path, ".tsx") {
-			fileType = "typescript"
-		} else if strings.HasSuffix(path, ".templ") {
-			fileType = "templ"
-		} else if strings.HasSuffix(path, ".py") {
-			fileType = "python"
-		} else if strings.HasSuffix(path, ".java") {
-			fileType = "java"
-		} else if strings.HasSuffix(path, ".c") || strings.HasSuffix(path, ".h") {
-			fileType = "c"
+		if t := CodeFileType(path); t != "" {
+			fileType = t
+		} else if t := ConfigFileType(path); t != "" {
+			fileType = t
 		} else if strings.HasSuffix(path, ".md") {
 			fileType = "markdown"
+		} else if t := DetectFileType(path); t != "" {
+			fileType = t
 		}
 
 		// handle large files
@@ -298,6 +352,9 @@ func LoadSpecificFiles(rootDir string, files []string, docType string, maxFileSi
 				"type": fileType,
 			},
 		}
+		if fileType == "go" {
+			setGoImports(doc.Content, doc.Metadata)
+		}
 
 		result.Documents = append(result.Documents, doc)
 	}
@@ -310,24 +367,28 @@ func splitLargeFile(content, path, fileType string, maxSize int) []Document {
 	var docs []Document
 
 	// simple strategy: split by lines, keeping chunks under maxSize
+	// each part records the line of the file it starts at (first_line)
 	lines := strings.Split(content, "\n")
 	var currentChunk strings.Builder
 	partNum := 1
+	firstLine := 1
 
-	for _, line := range lines {
+	for i, line := range lines {
 		// if adding this line would exceed max size, save current chunk
 		if currentChunk.Len()+len(line)+1 > maxSize && currentChunk.Len() > 0 {
 			docs = append(docs, Document{
 				Content: currentChunk.String(),
 				Source:  fmt.Sprintf("%s (part %d)", path, partNum),
 				Metadata: map[string]string{
-					"path": path,
-					"type": fileType,
-					"part": fmt.Sprintf("%d", partNum),
+					"path":       path,
+					"type":       fileType,
+					"part":       fmt.Sprintf("%d", partNum),
+					"first_line": fmt.Sprintf("%d", firstLine),
 				},
 			})
 			currentChunk.Reset()
 			partNum++
+			firstLine = i + 1
 		}
 
 		currentChunk.WriteString(line)
@@ -340,12 +401,20 @@ func splitLargeFile(content, path, fileType string, maxSize int) []Document {
 			Content: currentChunk.String(),
 			Source:  fmt.Sprintf("%s (part %d)", path, partNum),
 			Metadata: map[string]string{
-				"path": path,
-				"type": fileType,
-				"part": fmt.Sprintf("%d", partNum),
+				"path":       path,
+				"type":       fileType,
+				"part":       fmt.Sprintf("%d", partNum),
+				"first_line": fmt.Sprintf("%d", firstLine),
 			},
 		})
 	}
 
+	// every part records the imports, which only the first holds
+	if fileType == "go" {
+		for _, doc := range docs {
+			setGoImports(content, doc.Metadata)
+		}
+	}
+
 	return docs
 }
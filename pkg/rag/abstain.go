@@ -0,0 +1,60 @@
+package rag
+
+import (
+	"fmt"
+	"strings"
+
+	"lr/pkg/store"
+)
+
+// NotCovered begins the answer returned instead of a guess when the grounding
+// guard abstains
+const NotCovered = "not covered by indexed sources"
+
+// guarded reports whether the grounding guard applies. questions with attachments
+// are exempt, since the answer may rightly come from the attached content
+func (r *RAG) guarded() bool {
+	return r.MinSimilarity > 0 && len(r.Attachments) == 0
+}
+
+// citesRetrieved reports whether any citation names a retrieved chunk
+func citesRetrieved(citations []Citation) bool {
+	for _, c := range citations {
+		if c.Source != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// bestSimilarity returns the highest raw similarity among results
+func bestSimilarity(results []store.SearchResult) float64 {
+	best := 0.0
+	for i, r := range results {
+		if i == 0 || r.Similarity > best {
+			best = r.Similarity
+		}
+	}
+	return best
+}
+
+// abstain records that the guard withheld an answer and returns the reply that
+// replaces it: the reason and the nearest matches
+func (r *RAG) abstain(reason string, results []store.SearchResult) string {
+	r.Abstained = true
+	r.Citations, r.Confidence = nil, nil
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: %s.", NotCovered, reason)
+	if len(results) > 0 {
+		b.WriteString("\n\nnearest matches:")
+		for i, result := range results {
+			source := result.Chunk.Source
+			if index := result.Chunk.Metadata["vector_source"]; index != "" {
+				source = index + ":" + source
+			}
+			fmt.Fprintf(&b, "\n  [%d] %s (similarity: %.3f)", i+1, source, result.Similarity)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,38 @@
+package rag
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"lr/pkg/chunk"
+	"lr/pkg/llm"
+	"lr/pkg/store"
+)
+
+func TestGroundingGuard(t *testing.T) {
+	results := []store.SearchResult{
+		{Chunk: chunk.Chunk{Source: "a.go", Text: "package a", Metadata: map[string]string{"vector_source": "repo"}}, Similarity: 0.4},
+	}
+	r := &RAG{LLM: llm.NewMockClient()}
+
+	// guard off: the (uncited) mock answer is returned as is
+	answer, err := r.Synthesize(context.Background(), "q", nil, results)
+	if err != nil || r.Abstained || strings.HasPrefix(answer, NotCovered) {
+		t.Fatalf("guard off: expected an answer, got %q (abstained %v, err %v)", answer, r.Abstained, err)
+	}
+
+	// best match below the threshold
+	r.MinSimilarity = 0.5
+	answer, _ = r.Synthesize(context.Background(), "q", nil, results)
+	if !r.Abstained || !strings.Contains(answer, "below the 0.50 threshold") || !strings.Contains(answer, "repo:a.go") {
+		t.Errorf("expected abstention for a weak match listing the nearest matches, got %q", answer)
+	}
+
+	// good match, but the answer cites nothing
+	r.MinSimilarity = 0.3
+	answer, _ = r.Synthesize(context.Background(), "q", nil, results)
+	if !r.Abstained || !strings.Contains(answer, "cited none") {
+		t.Errorf("expected abstention for an uncited answer, got %q", answer)
+	}
+}
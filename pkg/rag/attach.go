@@ -0,0 +1,123 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"lr/pkg/chunk"
+	"lr/pkg/llm"
+	"lr/pkg/loader"
+	"lr/pkg/store"
+)
+
+// maxAttachmentChars caps how much attached content goes into the prompt (~12k tokens).
+// larger attachments are chunked and only the parts most relevant to the question are kept
+const maxAttachmentChars = 48000
+
+// MaxAttachmentChunks caps how many chunks of large attachments are embedded to
+// find those parts (~125k tokens); attachments with more are refused
+const MaxAttachmentChunks = 500
+
+// attachmentBatchSize is how many attachment chunks are embedded per request
+const attachmentBatchSize = 64
+
+// AttachmentChunksToEmbed returns the chunks of attachments that have to be
+// embedded to pick the parts relevant to a question: none if they fit in the
+// prompt whole
+func AttachmentChunksToEmbed(attachments []loader.Document) []chunk.Chunk {
+	total := 0
+	for _, doc := range attachments {
+		total += len(doc.Content)
+	}
+	if total <= maxAttachmentChars {
+		return nil
+	}
+	var chunks []chunk.Chunk
+	for _, doc := range attachments {
+		chunks = append(chunks, chunk.ChunkDocument(doc, chunk.DefaultMaxSize)...)
+	}
+	return chunks
+}
+
+// CheckAttachments returns an error if attachments are too large to embed
+func CheckAttachments(attachments []loader.Document) error {
+	if n := len(AttachmentChunksToEmbed(attachments)); n > MaxAttachmentChunks {
+		return tooManyAttachmentChunks(n)
+	}
+	return nil
+}
+
+// tooManyAttachmentChunks is the error for attachments of n chunks
+func tooManyAttachmentChunks(n int) error {
+	return fmt.Errorf("attachments are too large: %d chunks to embed, at most %d (attach only the relevant files, or a smaller diff)", n, MaxAttachmentChunks)
+}
+
+// attachmentChunks returns the attached content to put in the prompt. if everything
+// fits in maxAttachmentChars it is included whole, otherwise the attachments are
+// chunked and the chunks most similar to the question are kept, in file order
+func (r *RAG) attachmentChunks(ctx context.Context, queryEmbedding []float64) ([]chunk.Chunk, error) {
+	chunks := AttachmentChunksToEmbed(r.Attachments)
+	if chunks == nil {
+		chunks = make([]chunk.Chunk, 0, len(r.Attachments))
+		for _, doc := range r.Attachments {
+			chunks = append(chunks, chunk.Chunk{Text: doc.Content, Source: doc.Source, Metadata: doc.Metadata})
+		}
+		return chunks, nil
+	}
+	if len(chunks) > MaxAttachmentChunks {
+		return nil, tooManyAttachmentChunks(len(chunks))
+	}
+
+	type scored struct {
+		index int
+		score float64
+	}
+	ranked := make([]scored, 0, len(chunks))
+	for start := 0; start < len(chunks); start += attachmentBatchSize {
+		batch := chunks[start:min(start+attachmentBatchSize, len(chunks))]
+		texts := make([]string, len(batch))
+		for i, c := range batch {
+			texts[i] = c.Text
+		}
+		embeddings, err := llm.GetBatchEmbeddingsContext(ctx, r.LLM, texts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to embed attachment %s: %w", batch[0].Source, err)
+		}
+		for i, embedding := range embeddings {
+			ranked = append(ranked, scored{start + i, store.CosineSimilarity(queryEmbedding, embedding)})
+		}
+	}
+	sort.Slice(ranked, func(i, j int) bool { return ranked[i].score > ranked[j].score })
+
+	var keep []int
+	size := 0
+	for _, s := range ranked {
+		if size+len(chunks[s.index].Text) > maxAttachmentChars {
+			continue
+		}
+		size += len(chunks[s.index].Text)
+		keep = append(keep, s.index)
+	}
+	sort.Ints(keep)
+
+	selected := make([]chunk.Chunk, 0, len(keep))
+	for _, i := range keep {
+		selected = append(selected, chunks[i])
+	}
+	return selected, nil
+}
+
+// writeAttachmentContext appends attached content to the prompt context
+func writeAttachmentContext(b *strings.Builder, chunks []chunk.Chunk) {
+	if len(chunks) == 0 {
+		return
+	}
+	b.WriteString("here is content the user attached to the question:\n\n")
+	for _, c := range chunks {
+		b.WriteString(fmt.Sprintf("--- attached: %s ---\n", c.Source))
+		b.WriteString(c.Text)
+		b.WriteString("\n\n")
+	}
+}
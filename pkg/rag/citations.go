@@ -0,0 +1,187 @@
+package rag
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"lr/pkg/store"
+)
+
+// minQuoteChars is the shortest quote worth verifying; shorter ones are usually
+// identifiers or emphasis rather than quotations
+const minQuoteChars = 8
+
+// maxQuoteDisplay bounds a quote repeated in a warning
+const maxQuoteDisplay = 60
+
+var (
+	// citationPattern matches numbered citations like [2] or [1, 3]
+	citationPattern = regexp.MustCompile(`\[(\d+(?:\s*,\s*\d+)*)\]`)
+	fencePattern    = regexp.MustCompile("(?s)```[^\n]*\n(.*?)```")
+	// quotePattern matches inline code and double-quoted text on one line
+	quotePattern = regexp.MustCompile("`([^`\n]+)`|\"([^\"\n]+)\"|“([^”\n]+)”")
+)
+
+// Citation is a numbered reference in an answer to one of the retrieved documents
+type Citation struct {
+	Number int    // document number, as in the prompt and the printed sources
+	Source string // the cited document's source, empty if there is no such document
+	Quote  string // text quoted right before the citation, if any
+	// Problem explains why the citation looks fabricated; empty if it checks out
+	Problem string
+}
+
+// span is a quoted or code region of an answer
+type span struct {
+	start, end int // byte offsets of the whole region, delimiters included
+	text       string
+	code       bool // citations inside code aren't citations (e.g. arr[0])
+}
+
+// CheckCitations finds the numbered citations in answer and checks each against
+// the retrieved results: the number must name a retrieved document, and text
+// quoted right before the citation must appear in that document
+func CheckCitations(answer string, results []store.SearchResult) []Citation {
+	spans := quotedSpans(answer)
+
+	var citations []Citation
+	seen := make(map[string]bool)
+	for _, m := range citationPattern.FindAllStringSubmatchIndex(answer, -1) {
+		start := m[0]
+		if insideCode(spans, start) {
+			continue
+		}
+
+		var numbers []int
+		for _, s := range strings.Split(answer[m[2]:m[3]], ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(s))
+			if err == nil {
+				numbers = append(numbers, n)
+			}
+		}
+
+		quote := quoteBefore(answer, spans, start)
+		quoteFound := quote == ""
+		for _, n := range numbers {
+			if n >= 1 && n <= len(results) && containsQuote(results[n-1].Chunk.Text, quote) {
+				quoteFound = true
+			}
+		}
+
+		for _, n := range numbers {
+			key := fmt.Sprintf("%d\x00%s", n, quote)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			c := Citation{Number: n, Quote: quote}
+			switch {
+			case n < 1 || n > len(results):
+				c.Problem = fmt.Sprintf("[%d] cites document %d, but only %d were retrieved", n, n, len(results))
+			default:
+				c.Source = results[n-1].Chunk.Source
+				if !quoteFound {
+					c.Problem = fmt.Sprintf("[%d] quotes %q, which %s doesn't contain", n, shortQuote(quote), c.Source)
+				}
+			}
+			citations = append(citations, c)
+		}
+	}
+	return citations
+}
+
+// quotedSpans returns the fenced code blocks, inline code and double-quoted text
+// of an answer, in order
+func quotedSpans(answer string) []span {
+	var spans []span
+	for _, m := range fencePattern.FindAllStringSubmatchIndex(answer, -1) {
+		spans = append(spans, span{start: m[0], end: m[1], text: answer[m[2]:m[3]], code: true})
+	}
+	for _, m := range quotePattern.FindAllStringSubmatchIndex(answer, -1) {
+		if insideCode(spans, m[0]) {
+			continue
+		}
+		s := span{start: m[0], end: m[1]}
+		switch {
+		case m[2] >= 0:
+			s.text, s.code = answer[m[2]:m[3]], true
+		case m[4] >= 0:
+			s.text = answer[m[4]:m[5]]
+		default:
+			s.text = answer[m[6]:m[7]]
+		}
+		spans = append(spans, s)
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	return spans
+}
+
+// insideCode reports whether offset falls within a code span
+func insideCode(spans []span, offset int) bool {
+	for _, s := range spans {
+		if s.code && offset >= s.start && offset < s.end {
+			return true
+		}
+	}
+	return false
+}
+
+// quoteBefore returns the quote that ends right before a citation (only spaces
+// or a colon between them), or "" if there is none or it's too short to check
+func quoteBefore(answer string, spans []span, citationStart int) string {
+	for i := len(spans) - 1; i >= 0; i-- {
+		s := spans[i]
+		if s.end > citationStart {
+			continue
+		}
+		if strings.Trim(answer[s.end:citationStart], " \t\n:") != "" {
+			return ""
+		}
+		if len(normalizeQuote(s.text)) < minQuoteChars {
+			return ""
+		}
+		return strings.TrimSpace(s.text)
+	}
+	return ""
+}
+
+// containsQuote reports whether text contains quote, ignoring case and
+// whitespace differences. an ellipsis in the quote matches any elided text
+func containsQuote(text, quote string) bool {
+	if quote == "" {
+		return true
+	}
+	text = normalizeQuote(text)
+	quote = strings.ReplaceAll(quote, "…", "...")
+	pos := 0
+	for _, part := range strings.Split(quote, "...") {
+		part = normalizeQuote(part)
+		if part == "" {
+			continue
+		}
+		i := strings.Index(text[pos:], part)
+		if i < 0 {
+			return false
+		}
+		pos += i + len(part)
+	}
+	return true
+}
+
+// normalizeQuote lowercases s and collapses its whitespace
+func normalizeQuote(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// shortQuote trims a quote for display
+func shortQuote(quote string) string {
+	quote = strings.Join(strings.Fields(quote), " ")
+	if r := []rune(quote); len(r) > maxQuoteDisplay {
+		return string(r[:maxQuoteDisplay]) + "..."
+	}
+	return quote
+}
@@ -0,0 +1,36 @@
+package rag
+
+import (
+	"testing"
+
+	"lr/pkg/chunk"
+	"lr/pkg/store"
+)
+
+func TestCheckCitations(t *testing.T) {
+	results := []store.SearchResult{
+		{Chunk: chunk.Chunk{Source: "server.go", Text: "func (s *Server) Start() error {\n\treturn s.listen()\n}"}},
+		{Chunk: chunk.Chunk{Source: "README.md", Text: "The server listens on port 4222 by default."}},
+	}
+	answer := "the server starts with `func (s *Server) Start() error` [1] and \"listens on port 4222\" [2].\n" +
+		"it also \"retries forever on failure\" [2], see [3].\n" +
+		"```go\nx := arr[1]\n```\n" +
+		"the docs say \"The server ... by default\" [1, 2]."
+
+	problems := map[int][]string{}
+	for _, c := range CheckCitations(answer, results) {
+		if c.Problem != "" {
+			problems[c.Number] = append(problems[c.Number], c.Quote)
+		}
+	}
+
+	if len(problems[1]) != 0 {
+		t.Errorf("citation [1] should check out, got problems for quotes %q", problems[1])
+	}
+	if len(problems[2]) != 1 || problems[2][0] != "retries forever on failure" {
+		t.Errorf("expected only the fabricated quote flagged for [2], got %q", problems[2])
+	}
+	if len(problems[3]) != 1 {
+		t.Errorf("expected [3] flagged as a missing document, got %q", problems[3])
+	}
+}
@@ -0,0 +1,168 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+
+	"lr/pkg/llm"
+	"lr/pkg/store"
+)
+
+// confidence levels, from the combined score
+const (
+	ConfidenceHigh   = "high"
+	ConfidenceMedium = "medium"
+	ConfidenceLow    = "low"
+)
+
+// self-check verdicts
+const (
+	SelfCheckSupported   = "supported"
+	SelfCheckPartial     = "partial"
+	SelfCheckUnsupported = "unsupported"
+)
+
+const (
+	// best-match similarities at or below weakSimilarity count as no match, at or
+	// above strongSimilarity as a full match
+	weakSimilarity   = 0.2
+	strongSimilarity = 0.6
+
+	// results within agreementMargin of the best match agree with it
+	agreementMargin = 0.1
+
+	highConfidence   = 0.7
+	mediumConfidence = 0.4
+)
+
+// Confidence indicates how far an answer can be trusted, from how well the
+// question matched the index, whether the retrieved chunks agree, whether the
+// answer's citations check out and, optionally, the chat model's own check
+type Confidence struct {
+	Level     string   // high, medium or low
+	Score     float64  // 0-1
+	Reasons   []string // what the level is based on
+	SelfCheck string   // supported, partial or unsupported; empty if not run
+}
+
+// String renders the confidence for display, e.g. "medium (0.52): best match 0.41, ..."
+func (c Confidence) String() string {
+	return fmt.Sprintf("%s (%.2f): %s", c.Level, c.Score, strings.Join(c.Reasons, ", "))
+}
+
+// AssessConfidence scores an answer from its retrieval results and citations
+func AssessConfidence(results []store.SearchResult, citations []Citation) Confidence {
+	if len(results) == 0 {
+		return Confidence{Level: ConfidenceLow, Reasons: []string{"nothing was retrieved"}}
+	}
+
+	best := bestSimilarity(results)
+	match := clamp((best - weakSimilarity) / (strongSimilarity - weakSimilarity))
+
+	agreeing := 0
+	for _, r := range results {
+		if r.Similarity >= best-agreementMargin {
+			agreeing++
+		}
+	}
+	agreement := float64(agreeing) / float64(len(results))
+
+	var reasons []string
+	reasons = append(reasons, fmt.Sprintf("best match %.2f (%s)", best, matchStrength(match)))
+	reasons = append(reasons, fmt.Sprintf("%d of %d chunks close to it", agreeing, len(results)))
+
+	cited, fabricated := 0, 0
+	for _, c := range citations {
+		if c.Problem != "" {
+			fabricated++
+		} else {
+			cited++
+		}
+	}
+	var grounding float64
+	switch {
+	case fabricated > 0:
+		reasons = append(reasons, fmt.Sprintf("%d suspect citation(s)", fabricated))
+	case cited == 0:
+		grounding = 0.5
+		reasons = append(reasons, "no citations")
+	default:
+		grounding = 1
+		reasons = append(reasons, fmt.Sprintf("%d citation(s) check out", cited))
+	}
+
+	score := 0.6*match + 0.2*agreement + 0.2*grounding
+	if fabricated > 0 {
+		// part of the answer is made up, however good the retrieval was
+		score = math.Min(score, highConfidence-0.01)
+	}
+	return Confidence{Level: confidenceLevel(score), Score: score, Reasons: reasons}
+}
+
+// applySelfCheck lowers the confidence when the chat model finds the answer isn't
+// (fully) supported by the context
+func (c *Confidence) applySelfCheck(verdict string) {
+	c.SelfCheck = verdict
+	c.Reasons = append(c.Reasons, "self-check: "+verdict)
+	switch verdict {
+	case SelfCheckUnsupported:
+		c.Score = math.Min(c.Score, mediumConfidence-0.01)
+	case SelfCheckPartial:
+		c.Score = math.Min(c.Score, highConfidence-0.01)
+	}
+	c.Level = confidenceLevel(c.Score)
+}
+
+// selfCheck asks the chat model whether the retrieved context supports the answer
+func (r *RAG) selfCheck(ctx context.Context, question, answer string, results []store.SearchResult) (string, error) {
+	var docs strings.Builder
+	for i, result := range results {
+		docs.WriteString(fmt.Sprintf("--- document %d (source: %s) ---\n%s\n\n", i+1, result.Chunk.Source, result.Chunk.Text))
+	}
+
+	messages := []llm.Message{
+		{Role: "system", Content: `you check answers against the documents they were based on.
+reply with exactly one word: supported if every claim in the answer is backed by the documents,
+partial if some claims are, or unsupported if the documents don't back the answer.`},
+		{Role: "user", Content: fmt.Sprintf("%s\nquestion: %s\n\nanswer:\n%s", docs.String(), question, answer)},
+	}
+	// the verdict isn't part of the answer, so it's never streamed
+	verdict, err := llm.ChatContext(llm.WithTokenHandler(ctx, nil), r.LLM, messages)
+	if err != nil {
+		return "", fmt.Errorf("self-check failed: %w", err)
+	}
+
+	verdict = strings.ToLower(verdict)
+	for _, v := range []string{SelfCheckUnsupported, SelfCheckPartial, SelfCheckSupported} {
+		if strings.Contains(verdict, v) {
+			return v, nil
+		}
+	}
+	return "", fmt.Errorf("self-check returned an unexpected verdict: %q", strings.TrimSpace(verdict))
+}
+
+func confidenceLevel(score float64) string {
+	switch {
+	case score >= highConfidence:
+		return ConfidenceHigh
+	case score >= mediumConfidence:
+		return ConfidenceMedium
+	}
+	return ConfidenceLow
+}
+
+func matchStrength(match float64) string {
+	switch {
+	case match >= 0.75:
+		return "strong"
+	case match >= 0.35:
+		return "fair"
+	}
+	return "weak"
+}
+
+func clamp(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
@@ -0,0 +1,33 @@
+package rag
+
+import (
+	"testing"
+
+	"lr/pkg/store"
+)
+
+func TestAssessConfidence(t *testing.T) {
+	strong := []store.SearchResult{{Similarity: 0.72}, {Similarity: 0.68}, {Similarity: 0.66}}
+	weak := []store.SearchResult{{Similarity: 0.21}, {Similarity: 0.12}, {Similarity: 0.05}}
+	good := []Citation{{Number: 1, Source: "a.go"}}
+	bad := []Citation{{Number: 4, Problem: "[4] cites document 4, but only 3 were retrieved"}}
+
+	if c := AssessConfidence(strong, good); c.Level != ConfidenceHigh {
+		t.Errorf("strong, agreeing, cited results: expected high confidence, got %s", c)
+	}
+	if c := AssessConfidence(weak, good); c.Level != ConfidenceLow {
+		t.Errorf("weak results: expected low confidence, got %s", c)
+	}
+	if c := AssessConfidence(strong, bad); c.Level == ConfidenceHigh {
+		t.Errorf("fabricated citation: expected less than high confidence, got %s", c)
+	}
+	if c := AssessConfidence(nil, nil); c.Level != ConfidenceLow {
+		t.Errorf("no results: expected low confidence, got %s", c)
+	}
+
+	c := AssessConfidence(strong, good)
+	c.applySelfCheck(SelfCheckUnsupported)
+	if c.Level != ConfidenceLow {
+		t.Errorf("unsupported self-check: expected low confidence, got %s", c)
+	}
+}
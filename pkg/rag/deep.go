@@ -0,0 +1,126 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"lr/pkg/llm"
+	"lr/pkg/store"
+)
+
+// DefaultDeepRounds bounds the follow-up retrievals of a deep query
+const DefaultDeepRounds = 3
+
+const (
+	// maxFollowUps bounds the searches the chat model may ask for per round
+	maxFollowUps = 3
+	// deepPreviewChars bounds each chunk shown to the chat model while planning
+	deepPreviewChars = 800
+	// searchPrefix marks a follow-up search in the planning reply
+	searchPrefix = "SEARCH:"
+)
+
+// RetrieveDeep retrieves the chunks for question like Retrieve, then lets the chat
+// model ask for follow-up searches (up to rounds of them) until it has what it
+// needs, for questions that span several places in the code. the follow-up
+// queries are recorded in FollowUps. with rounds <= 0 it is just Retrieve
+func (r *RAG) RetrieveDeep(ctx context.Context, question string, topK int, sources []string, rounds int) ([]store.SearchResult, []float64, error) {
+	r.FollowUps = nil
+	results, queryEmbedding, err := r.Retrieve(ctx, question, topK, sources)
+	if err != nil || rounds <= 0 {
+		return results, queryEmbedding, err
+	}
+
+	// follow-up searches overwrite these; report the ones for the question itself
+	routed, skipped, kind := r.Routed, r.Skipped, r.QuestionKind
+	defer func() { r.Routed, r.Skipped, r.QuestionKind = routed, skipped, kind }()
+
+	seen := make(map[string]bool)
+	for _, result := range results {
+		seen[resultKey(result)] = true
+	}
+	searched := map[string]bool{strings.ToLower(question): true}
+
+	for round := 0; round < rounds; round++ {
+		queries, err := r.planFollowUps(ctx, question, results)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		added := 0
+		for _, q := range queries {
+			if searched[strings.ToLower(q)] {
+				continue
+			}
+			searched[strings.ToLower(q)] = true
+			r.FollowUps = append(r.FollowUps, q)
+
+			more, _, err := r.Retrieve(ctx, q, topK, sources)
+			if err != nil {
+				return nil, nil, fmt.Errorf("follow-up search %q failed: %w", q, err)
+			}
+			for _, result := range more {
+				if key := resultKey(result); !seen[key] {
+					seen[key] = true
+					results = append(results, result)
+					added++
+				}
+			}
+		}
+		if added == 0 {
+			break
+		}
+	}
+	return results, queryEmbedding, nil
+}
+
+// planFollowUps shows the chat model what has been retrieved so far and returns
+// the searches it asks for, none if it has enough to answer
+func (r *RAG) planFollowUps(ctx context.Context, question string, results []store.SearchResult) ([]string, error) {
+	var docs strings.Builder
+	for i, result := range results {
+		text := result.Chunk.Text
+		if len(text) > deepPreviewChars {
+			text = text[:deepPreviewChars] + "..."
+		}
+		docs.WriteString(fmt.Sprintf("--- document %d (source: %s) ---\n%s\n\n", i+1, result.Chunk.Source, text))
+	}
+
+	messages := []llm.Message{
+		{Role: "system", Content: fmt.Sprintf(`you plan searches over indexed code and documentation to answer a question.
+the question may need information from several places (e.g. where a setting is defined and where it is used).
+if the documents below are enough to answer it, reply with just ENOUGH.
+otherwise reply with up to %d short search queries for what is missing, one per line, each starting with %s`, maxFollowUps, searchPrefix)},
+		{Role: "user", Content: fmt.Sprintf("%squestion: %s", docs.String(), question)},
+	}
+	// planning replies aren't part of the answer, so they're never streamed
+	reply, err := llm.ChatContext(llm.WithTokenHandler(ctx, nil), r.LLM, messages)
+	if err != nil {
+		return nil, fmt.Errorf("failed to plan follow-up searches: %w", err)
+	}
+	return parseFollowUps(reply), nil
+}
+
+// parseFollowUps extracts the SEARCH: lines of a planning reply
+func parseFollowUps(reply string) []string {
+	var queries []string
+	for _, line := range strings.Split(reply, "\n") {
+		line = strings.TrimLeft(strings.TrimSpace(line), "-*0123456789. ")
+		if len(line) < len(searchPrefix) || !strings.EqualFold(line[:len(searchPrefix)], searchPrefix) {
+			continue
+		}
+		if q := strings.Trim(strings.TrimSpace(line[len(searchPrefix):]), `"`); q != "" {
+			queries = append(queries, q)
+		}
+		if len(queries) == maxFollowUps {
+			break
+		}
+	}
+	return queries
+}
+
+// resultKey identifies a chunk across searches
+func resultKey(r store.SearchResult) string {
+	return r.Chunk.Metadata["vector_source"] + "\x00" + r.Chunk.Source + "\x00" + r.Chunk.Text
+}
@@ -0,0 +1,23 @@
+package rag
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFollowUps(t *testing.T) {
+	tests := []struct {
+		reply string
+		want  []string
+	}{
+		{"ENOUGH", nil},
+		{"SEARCH: where is MaxPayload set", []string{"where is MaxPayload set"}},
+		{"need more:\n- search: config loading\n2. SEARCH: \"lexer options\"\nSEARCH:   ", []string{"config loading", "lexer options"}},
+		{"SEARCH: a\nSEARCH: b\nSEARCH: c\nSEARCH: d", []string{"a", "b", "c"}},
+	}
+	for _, tt := range tests {
+		if got := parseFollowUps(tt.reply); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseFollowUps(%q) = %q, want %q", tt.reply, got, tt.want)
+		}
+	}
+}
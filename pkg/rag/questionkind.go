@@ -0,0 +1,172 @@
+package rag
+
+import (
+	"regexp"
+	"strings"
+
+	"lr/pkg/chunk"
+	"lr/pkg/store"
+)
+
+// QuestionKind is what a question asks for, as far as how many chunks answer
+// it: a symbol lookup needs the few that define or use the symbol, an
+// architecture question needs many, from different files
+type QuestionKind string
+
+const (
+	QuestionSymbol       QuestionKind = "symbol"
+	QuestionArchitecture QuestionKind = "architecture"
+	QuestionGeneral      QuestionKind = "general"
+)
+
+const (
+	// maxSymbolQuestionWords bounds the questions taken for symbol lookups;
+	// longer ones that name a symbol usually ask about more than it
+	maxSymbolQuestionWords = 12
+	// maxChunksPerFile bounds the chunks of one file an architecture
+	// question gets, so its results span the codebase
+	maxChunksPerFile = 2
+	// candidateFactor is how many more chunks than kept are searched for when
+	// the kept ones are filtered or diversified
+	candidateFactor = 3
+)
+
+// TopK returns the number of chunks retrieved for questions of kind
+func (k QuestionKind) TopK() int {
+	switch k {
+	case QuestionSymbol:
+		return 2
+	case QuestionArchitecture:
+		return 8
+	default:
+		return 3
+	}
+}
+
+var (
+	// backticked text, flags and words (with selectors and call parens), which
+	// questionSymbols narrows to those that look like code
+	symbolPattern = regexp.MustCompile("`[^`]+`|(?:^|\\s)--?[a-z][\\w-]+|[A-Za-z_][\\w.]*(?:\\(\\))?")
+	// phrases of questions about how parts fit together
+	architecturePattern = regexp.MustCompile(`\b(architecture|overview|design|high[- ]level|big picture|end[- ]to[- ]end|lifecycle|pipeline|components?|interact(s|ion)?|relationship|fit together|work together|data flow|control flow|structured?|organized)\b`)
+	// "how does x work" is about architecture unless x is a symbol
+	howWorksPattern = regexp.MustCompile(`\bhow (does|do|is|are)\b.*\bwork`)
+)
+
+// ClassifyQuestion guesses the kind of question from its wording, returning
+// the symbols a symbol lookup names. it is a cheap heuristic: no model is asked
+func ClassifyQuestion(question string) (QuestionKind, []string) {
+	lower := strings.ToLower(question)
+	symbols := questionSymbols(question)
+	if architecturePattern.MatchString(lower) || (len(symbols) == 0 && howWorksPattern.MatchString(lower)) {
+		return QuestionArchitecture, nil
+	}
+	if len(symbols) > 0 && len(strings.Fields(question)) <= maxSymbolQuestionWords {
+		return QuestionSymbol, symbols
+	}
+	return QuestionGeneral, nil
+}
+
+// questionSymbols returns the words of question that look like code rather
+// than english
+func questionSymbols(question string) []string {
+	var symbols []string
+	for _, m := range symbolPattern.FindAllString(question, -1) {
+		m = strings.TrimSpace(m)
+		quoted, call := strings.HasPrefix(m, "`"), strings.HasSuffix(m, "()")
+		m = strings.TrimRight(strings.Trim(m, "`"), "().")
+		if m == "" {
+			continue
+		}
+		if quoted || call || strings.HasPrefix(m, "-") || strings.Contains(m, "_") || isSelector(m) || isCamelCase(m) {
+			symbols = append(symbols, m)
+		}
+	}
+	return symbols
+}
+
+// isSelector reports whether word is like pkg.Func or a.b.c, and not an
+// abbreviation like e.g
+func isSelector(word string) bool {
+	parts := strings.Split(word, ".")
+	if len(parts) < 2 {
+		return false
+	}
+	for _, part := range parts {
+		if len(part) > 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// isCamelCase reports whether word has an upper case letter after its first,
+// like NewVectorStore or maxPayload (but not an acronym like JSON)
+func isCamelCase(word string) bool {
+	upper, lower := false, false
+	for i, r := range word {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z' && i > 0:
+			upper = true
+		}
+	}
+	return upper && lower
+}
+
+// candidates returns how many chunks to search for to keep topK of kind
+func (k QuestionKind) candidates(topK int) int {
+	if k == QuestionSymbol || k == QuestionArchitecture {
+		return topK * candidateFactor
+	}
+	return topK
+}
+
+// shape keeps topK of the results searched for a question of kind: those
+// mentioning a symbol first for a symbol lookup, at most maxChunksPerFile per
+// file (while others are left) for an architecture question. results keep
+// their order
+func (k QuestionKind) shape(results []store.SearchResult, symbols []string, topK int) []store.SearchResult {
+	if len(results) <= topK {
+		return results
+	}
+	keep := make([]bool, len(results))
+	kept := 0
+	pick := func(ok func(store.SearchResult) bool) {
+		for i, result := range results {
+			if kept < topK && !keep[i] && ok(result) {
+				keep[i] = true
+				kept++
+			}
+		}
+	}
+	switch k {
+	case QuestionSymbol:
+		pick(func(result store.SearchResult) bool {
+			text := strings.ToLower(result.Chunk.Text)
+			for _, s := range symbols {
+				if strings.Contains(text, strings.ToLower(s)) {
+					return true
+				}
+			}
+			return false
+		})
+	case QuestionArchitecture:
+		perFile := make(map[string]int)
+		pick(func(result store.SearchResult) bool {
+			file := result.Chunk.Metadata["vector_source"] + ":" + chunk.FilePath(result.Chunk.Source)
+			perFile[file]++
+			return perFile[file] <= maxChunksPerFile
+		})
+	}
+	pick(func(store.SearchResult) bool { return true })
+
+	shaped := make([]store.SearchResult, 0, topK)
+	for i, result := range results {
+		if keep[i] {
+			shaped = append(shaped, result)
+		}
+	}
+	return shaped
+}
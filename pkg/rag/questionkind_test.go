@@ -0,0 +1,66 @@
+package rag
+
+import (
+	"reflect"
+	"testing"
+
+	"lr/pkg/chunk"
+	"lr/pkg/store"
+)
+
+func TestClassifyQuestion(t *testing.T) {
+	tests := []struct {
+		question string
+		kind     QuestionKind
+		symbols  []string
+	}{
+		{"where is NewVectorStore defined?", QuestionSymbol, []string{"NewVectorStore"}},
+		{"what does `max_payload` limit", QuestionSymbol, []string{"max_payload"}},
+		{"what calls store.SearchEach()", QuestionSymbol, []string{"store.SearchEach"}},
+		{"what does --top-k do", QuestionSymbol, []string{"--top-k"}},
+		{"how does NewVectorStore work?", QuestionSymbol, []string{"NewVectorStore"}},
+		{"give me an overview of the architecture", QuestionArchitecture, nil},
+		{"how does the indexing pipeline work?", QuestionArchitecture, nil},
+		{"how do consumers work", QuestionArchitecture, nil},
+		{"how do i create a consumer, e.g. a pull consumer?", QuestionGeneral, nil},
+		{"what is a stream", QuestionGeneral, nil},
+	}
+	for _, tt := range tests {
+		kind, symbols := ClassifyQuestion(tt.question)
+		if kind != tt.kind || !reflect.DeepEqual(symbols, tt.symbols) {
+			t.Errorf("ClassifyQuestion(%q) = %s %q, want %s %q", tt.question, kind, symbols, tt.kind, tt.symbols)
+		}
+	}
+}
+
+func TestShapeResults(t *testing.T) {
+	result := func(source, text string) store.SearchResult {
+		return store.SearchResult{Chunk: chunk.Chunk{Source: source, Text: text}}
+	}
+	results := []store.SearchResult{
+		result("a.go (part 1)", "x"),
+		result("a.go (part 2)", "y"),
+		result("a.go (part 3)", "calls Open()"),
+		result("b.go", "z"),
+	}
+	sources := func(results []store.SearchResult) []string {
+		var s []string
+		for _, r := range results {
+			s = append(s, r.Chunk.Source)
+		}
+		return s
+	}
+
+	got := sources(QuestionSymbol.shape(results, []string{"Open"}, 2))
+	if want := []string{"a.go (part 1)", "a.go (part 3)"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("symbol lookup kept %q, want %q", got, want)
+	}
+	got = sources(QuestionArchitecture.shape(results, nil, 3))
+	if want := []string{"a.go (part 1)", "a.go (part 2)", "b.go"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("architecture question kept %q, want %q", got, want)
+	}
+	got = sources(QuestionGeneral.shape(results, nil, 2))
+	if want := []string{"a.go (part 1)", "a.go (part 2)"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("general question kept %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,355 @@
+// Package rag answers questions from indexed sources: it retrieves the chunks most
+// similar to the question and asks the chat model to answer from them
+package rag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"lr/pkg/chunk"
+	"lr/pkg/llm"
+	"lr/pkg/loader"
+	"lr/pkg/store"
+)
+
+// RAG handles retrieval-augmented generation
+type RAG struct {
+	VectorStore      *store.VectorStore
+	MultiSourceStore *store.MultiSourceStore
+	Backend          Backend // indexes kept outside the process, searched instead of the stores above
+	LLM              llm.LLMClient
+	Attachments      []loader.Document // ad-hoc content included with every question (lr query --file)
+	ChatOptions      *llm.ChatOptions  // generation parameters for synthesis, nil for the provider defaults
+
+	// Route narrows searches without explicit sources to the sources whose
+	// descriptions match the question; Routed records the last such choice
+	Route  bool
+	Routed []string
+
+	// EmbeddingModel is the model LLM embeds with. sources built with another
+	// model are searched with the question embedded by the client Embedder
+	// returns for that model, when Embedder is set
+	EmbeddingModel string
+	Embedder       func(model string) (llm.Embedder, error)
+
+	// Skipped lists the sources the last Retrieve couldn't search because they were
+	// built with a different embedding model
+	Skipped []*store.DimensionError
+
+	// Citations are the numbered citations in the last synthesized answer, checked
+	// against the documents it was given
+	Citations []Citation
+
+	// Confidence rates the last synthesized answer. with SelfCheck the chat model is
+	// also asked whether the documents support it (an extra chat request)
+	Confidence *Confidence
+	SelfCheck  bool
+
+	// MinSimilarity enables the grounding guard: when the best match is below it,
+	// or the answer cites none of the retrieved chunks, Synthesize returns a
+	// NotCovered reply listing the nearest matches instead of a guess, and sets
+	// Abstained. 0 disables the guard
+	MinSimilarity float64
+	Abstained     bool
+
+	// Deep lets the chat model ask for up to this many rounds of follow-up
+	// searches before answering (see RetrieveDeep); FollowUps records them
+	Deep      int
+	FollowUps []string
+
+	// ExpandImports adds up to this many chunks from the go packages the file
+	// of the top go result imports or that import it (see
+	// store.VectorStore.ExpandImports). 0 disables it
+	ExpandImports int
+
+	// Refs adds the callers or callees (or both) of the functions retrieved,
+	// following the call graph recorded at index time (see
+	// store.VectorStore.ExpandRefs)
+	Refs store.RefDirection
+
+	// ExcludePaths leaves the chunks of files matching these gitignore-style
+	// patterns ("vendor/**") out of the results, on top of each source's own
+	// (see store.MultiSourceStore.Excludes). a Backend is searched unfiltered,
+	// so its results are filtered after and may be fewer than asked for
+	ExcludePaths []string
+
+	// QuestionKind records the kind of question the last Retrieve without a
+	// top-k (topK <= 0) took it for, which picked the top-k and how results
+	// were kept (see ClassifyQuestion); "" when a top-k was given
+	QuestionKind QuestionKind
+}
+
+// Backend searches indexes that aren't loaded into memory, e.g. a
+// store.PGVectorStore. expansions that need a whole index (ExpandImports,
+// Refs) and source routing aren't available with a backend
+type Backend interface {
+	Search(ctx context.Context, queryEmbedding []float64, topK int, sources []string) ([]store.SearchResult, error)
+}
+
+// NewRAGBackend creates a new RAG system that searches a backend
+func NewRAGBackend(backend Backend, client llm.LLMClient) *RAG {
+	return &RAG{
+		Backend: backend,
+		LLM:     client,
+	}
+}
+
+// NewRAG creates a new RAG system with a single vector store
+func NewRAG(vs *store.VectorStore, client llm.LLMClient) *RAG {
+	return &RAG{
+		VectorStore: vs,
+		LLM:         client,
+	}
+}
+
+// NewRAGMultiSource creates a new RAG system with multi-source support
+func NewRAGMultiSource(mss *store.MultiSourceStore, client llm.LLMClient) *RAG {
+	return &RAG{
+		MultiSourceStore: mss,
+		LLM:              client,
+		Route:            true,
+	}
+}
+
+// Query performs a RAG query across all sources
+func (r *RAG) Query(question string, topK int) (string, []store.SearchResult, error) {
+	return r.QueryWithSources(question, topK, []string{})
+}
+
+// QueryWithSources performs a RAG query on specific sources
+func (r *RAG) QueryWithSources(question string, topK int, sources []string) (string, []store.SearchResult, error) {
+	return r.QueryWithSourcesContext(context.Background(), question, topK, sources)
+}
+
+// QueryWithSourcesContext performs a RAG query on specific sources, aborting when ctx is done
+// if synthesis fails the retrieved results are still returned alongside the error
+func (r *RAG) QueryWithSourcesContext(ctx context.Context, question string, topK int, sources []string) (string, []store.SearchResult, error) {
+	results, queryEmbedding, err := r.RetrieveDeep(ctx, question, topK, sources, r.Deep)
+	if err != nil {
+		return "", nil, err
+	}
+
+	answer, err := r.Synthesize(ctx, question, queryEmbedding, results)
+	if err != nil {
+		return "", results, err
+	}
+	return answer, results, nil
+}
+
+// Retrieve embeds the question and returns the most similar chunks, along with the
+// question embedding so Synthesize doesn't need to embed it again. with topK <= 0
+// the kind of question picks how many (see QuestionKind)
+func (r *RAG) Retrieve(ctx context.Context, question string, topK int, sources []string) ([]store.SearchResult, []float64, error) {
+	r.QuestionKind = ""
+	kind, symbols := QuestionGeneral, []string(nil)
+	if topK <= 0 {
+		kind, symbols = ClassifyQuestion(question)
+		topK = kind.TopK()
+		r.QuestionKind = kind
+	}
+	candidates := kind.candidates(topK)
+
+	// get embedding for the question
+	queryEmbedding, err := llm.GetEmbeddingContext(ctx, r.LLM, question)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get query embedding: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	// search for relevant chunks (use multi-source if available)
+	var results []store.SearchResult
+	if r.Backend != nil {
+		results, err = r.Backend.Search(ctx, queryEmbedding, candidates, sources)
+		if err != nil {
+			return nil, nil, err
+		}
+		results = kind.shape(store.NewPathFilter(r.ExcludePaths).Drop(results), symbols, topK)
+	} else if r.MultiSourceStore != nil {
+		r.Routed = nil
+		if r.Route && len(sources) == 0 {
+			r.Routed = r.MultiSourceStore.RouteSources(queryEmbedding)
+			sources = r.Routed
+		}
+
+		// sources built with another embedding model can't be searched unless the
+		// question is embedded with it too; that's only an error when it leaves
+		// nothing to search
+		q, err := r.queryEmbeddings(ctx, question, queryEmbedding, sources)
+		if err != nil {
+			return nil, nil, err
+		}
+		r.Skipped = r.MultiSourceStore.CheckDimensions(q, sources)
+		searchable := len(sources)
+		if searchable == 0 {
+			searchable = len(r.MultiSourceStore.Sources)
+		}
+		if len(r.Skipped) > 0 && len(r.Skipped) >= searchable {
+			errs := make([]error, len(r.Skipped))
+			for i, derr := range r.Skipped {
+				errs[i] = derr
+			}
+			return nil, nil, errors.Join(errs...)
+		}
+		results = kind.shape(r.MultiSourceStore.SearchExcluding(q, candidates, sources, r.ExcludePaths), symbols, topK)
+		results = r.MultiSourceStore.ExpandImports(q, results, r.ExpandImports)
+		results = r.MultiSourceStore.ExpandRefs(q, results, r.Refs)
+		results = r.MultiSourceStore.DropExcluded(results, r.ExcludePaths)
+	} else {
+		if err := r.VectorStore.CheckDimensions(queryEmbedding); err != nil {
+			return nil, nil, err
+		}
+		exclude := store.NewPathFilter(r.ExcludePaths)
+		results = kind.shape(r.VectorStore.SearchExcluding(queryEmbedding, candidates, exclude), symbols, topK)
+		results = r.VectorStore.ExpandImports(queryEmbedding, results, r.ExpandImports)
+		results = r.VectorStore.ExpandRefs(queryEmbedding, results, r.Refs)
+		results = exclude.Drop(results)
+	}
+	return results, queryEmbedding, nil
+}
+
+// queryEmbeddings embeds the question once per other embedding model among the
+// sources. a model without a working client leaves its sources to the question
+// embedding, which skips them when the dimensions differ
+func (r *RAG) queryEmbeddings(ctx context.Context, question string, queryEmbedding []float64, sources []string) (store.QueryEmbeddings, error) {
+	q := store.QueryEmbeddings{Default: queryEmbedding}
+	if r.Embedder == nil {
+		return q, nil
+	}
+	for _, model := range r.MultiSourceStore.EmbeddingModels(sources) {
+		if model == r.EmbeddingModel {
+			continue
+		}
+		embedder, err := r.Embedder(model)
+		if err != nil {
+			continue
+		}
+		embedding, err := embedder.GetEmbeddingContext(ctx, question)
+		if err != nil {
+			if ctx.Err() != nil {
+				return q, ctx.Err()
+			}
+			continue
+		}
+		if q.ByModel == nil {
+			q.ByModel = make(map[string][]float64)
+		}
+		q.ByModel[model] = embedding
+	}
+	return q, nil
+}
+
+// Synthesize asks the chat model to answer the question from the retrieved results
+// (and any attachments)
+func (r *RAG) Synthesize(ctx context.Context, question string, queryEmbedding []float64, results []store.SearchResult) (string, error) {
+	r.Citations, r.Confidence, r.Abstained = nil, nil, false
+	if r.guarded() {
+		if len(results) == 0 {
+			return r.abstain("nothing was retrieved", results), nil
+		}
+		if best := bestSimilarity(results); best < r.MinSimilarity {
+			return r.abstain(fmt.Sprintf("the best match (%.2f) is below the %.2f threshold", best, r.MinSimilarity), results), nil
+		}
+	}
+
+	messages, err := r.Prompt(ctx, question, queryEmbedding, results)
+	if err != nil {
+		return "", err
+	}
+
+	// get response from llm
+	if r.ChatOptions != nil {
+		ctx = llm.WithChatOptions(ctx, *r.ChatOptions)
+	}
+	answer, err := llm.ChatContext(ctx, r.LLM, messages)
+	if err != nil {
+		return "", fmt.Errorf("failed to get chat response: %w", err)
+	}
+
+	r.Citations = CheckCitations(answer, results)
+	if r.guarded() && !citesRetrieved(r.Citations) {
+		return r.abstain("the answer cited none of the retrieved chunks", results), nil
+	}
+	confidence := AssessConfidence(results, r.Citations)
+	if r.SelfCheck {
+		if verdict, err := r.selfCheck(ctx, question, answer, results); err != nil {
+			confidence.Reasons = append(confidence.Reasons, "self-check unavailable")
+		} else {
+			confidence.applySelfCheck(verdict)
+		}
+	}
+	r.Confidence = &confidence
+	return answer, nil
+}
+
+// Prompt returns the messages Synthesize sends the chat model: the results
+// (and any attachments) as numbered documents, and the question
+func (r *RAG) Prompt(ctx context.Context, question string, queryEmbedding []float64, results []store.SearchResult) ([]llm.Message, error) {
+	// build context from top results
+	var contextBuilder strings.Builder
+	contextBuilder.WriteString("here is the relevant context from the indexed documentation and source code:\n\n")
+	r.writeSourceHints(&contextBuilder, results)
+
+	for i, result := range results {
+		source := result.Chunk.Source
+		if len(result.AlsoIn) > 0 {
+			source += ", also in: " + strings.Join(result.AlsoIn, ", ")
+		}
+		if structure := chunk.Structure(result.Chunk); structure != "" {
+			source += ", " + structure
+		}
+		contextBuilder.WriteString(fmt.Sprintf("--- document %d (source: %s, type: %s, similarity: %.3f) ---\n",
+			i+1, source, result.Chunk.Metadata["type"], result.Similarity))
+		contextBuilder.WriteString(result.Chunk.Text)
+		contextBuilder.WriteString("\n\n")
+	}
+
+	// add attached content that isn't in any index
+	if len(r.Attachments) > 0 {
+		attached, err := r.attachmentChunks(ctx, queryEmbedding)
+		if err != nil {
+			return nil, err
+		}
+		writeAttachmentContext(&contextBuilder, attached)
+	}
+
+	// build prompt
+	systemPrompt := `you are a helpful assistant that answers questions based on indexed documentation and source code.
+answer based solely on the provided context from the indexed repositories and any content the user attached.
+if the context doesn't contain enough information to answer the question, say so.
+cite the documents you use by number in square brackets, e.g. [2] or [1, 3].
+when quoting text or code from a document, copy it exactly and put its citation right after the quote.
+when showing code examples, preserve the formatting and explain what the code does.`
+
+	userPrompt := fmt.Sprintf("%s\n\nquestion: %s", contextBuilder.String(), question)
+
+	return []llm.Message{
+		{Role: "system", Content: systemPrompt},
+		{Role: "user", Content: userPrompt},
+	}, nil
+}
+
+// writeSourceHints adds the prompt hints (about blurb and glossary) of each source
+// that contributed results, so the model knows the codebase's naming
+func (r *RAG) writeSourceHints(b *strings.Builder, results []store.SearchResult) {
+	if r.MultiSourceStore == nil || len(r.MultiSourceStore.Hints) == 0 {
+		return
+	}
+	seen := make(map[string]bool)
+	for _, result := range results {
+		name := result.Chunk.Metadata["vector_source"]
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		hints, ok := r.MultiSourceStore.Hints[name]
+		if !ok || hints.Empty() {
+			continue
+		}
+		b.WriteString(hints.Format(name))
+		b.WriteString("\n")
+	}
+}
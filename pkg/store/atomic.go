@@ -0,0 +1,61 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"lr/internal/errkind"
+)
+
+// AtomicSave saves vs to a temp file, validates, then renames to final path
+func AtomicSave(vs *VectorStore, finalPath string) error {
+	// write to temp file (keep .lrindex extension for proper gzip compression)
+	var tempPath string
+	if strings.HasSuffix(finalPath, ".lrindex") {
+		tempPath = strings.TrimSuffix(finalPath, ".lrindex") + ".tmp.lrindex"
+	} else {
+		tempPath = finalPath + ".tmp"
+	}
+	if err := vs.Save(tempPath); err != nil {
+		// don't leave a truncated temp file behind (e.g. disk full)
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to save temp file: %w", err)
+	}
+
+	// validate by loading
+	testVs := NewVectorStore()
+	if err := testVs.Load(tempPath); err != nil {
+		os.Remove(tempPath)
+		return errkind.With(fmt.Errorf("validation failed - temp file corrupt: %w", err), ErrCorruptIndex)
+	}
+
+	// verify chunk count matches
+	if len(testVs.Chunks) != len(vs.Chunks) {
+		os.Remove(tempPath)
+		return errkind.With(fmt.Errorf("validation failed - chunk count mismatch: got %d, expected %d",
+			len(testVs.Chunks), len(vs.Chunks)), ErrCorruptIndex)
+	}
+
+	// atomic rename
+	if err := os.Rename(tempPath, finalPath); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to rename temp file: %w", err)
+	}
+
+	// sync the directory so the rename itself survives a crash (best effort)
+	if dir, err := os.Open(filepath.Dir(finalPath)); err == nil {
+		dir.Sync()
+		dir.Close()
+	}
+
+	return nil
+}
+
+// IsPartialIndexFile reports whether an index filename belongs to an in-progress
+// write (checkpoint or AtomicSave temp file) rather than a finished index
+func IsPartialIndexFile(path string) bool {
+	base := filepath.Base(path)
+	return strings.Contains(base, "checkpoint") || strings.Contains(base, ".tmp.") || strings.HasSuffix(base, ".tmp")
+}
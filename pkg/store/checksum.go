@@ -0,0 +1,100 @@
+package store
+
+import (
+	"compress/gzip"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"lr/internal/errkind"
+)
+
+// gzipped indexes and segments record the length and crc-32c checksum of
+// their json in an extra field of the gzip header, so a load can tell disk
+// corruption or a truncated write from an index it can't parse. older lr
+// versions ignore the field, and files without one load unchecked
+
+// checksumID identifies lr's subfield of the gzip extra field (rfc 1952)
+var checksumID = [2]byte{'l', 'r'}
+
+// checksumVersion is the layout of the subfield: version, length, checksum
+const checksumVersion = 1
+
+const checksumFieldLen = 1 + 8 + 4
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// checksumExtra returns the gzip extra field recording data's length and checksum
+func checksumExtra(data []byte) []byte {
+	extra := make([]byte, 4+checksumFieldLen)
+	extra[0], extra[1] = checksumID[0], checksumID[1]
+	binary.LittleEndian.PutUint16(extra[2:], checksumFieldLen)
+	extra[4] = checksumVersion
+	binary.LittleEndian.PutUint64(extra[5:], uint64(len(data)))
+	binary.LittleEndian.PutUint32(extra[13:], crc32.Checksum(data, castagnoli))
+	return extra
+}
+
+// recordedChecksum finds the length and checksum checksumExtra recorded in a
+// gzip extra field
+func recordedChecksum(extra []byte) (length uint64, sum uint32, ok bool) {
+	for len(extra) >= 4 {
+		id := [2]byte{extra[0], extra[1]}
+		n := int(binary.LittleEndian.Uint16(extra[2:]))
+		if len(extra) < 4+n {
+			return 0, 0, false
+		}
+		field := extra[4 : 4+n]
+		if id == checksumID && n == checksumFieldLen && field[0] == checksumVersion {
+			return binary.LittleEndian.Uint64(field[1:]), binary.LittleEndian.Uint32(field[9:]), true
+		}
+		extra = extra[4+n:]
+	}
+	return 0, 0, false
+}
+
+// writeChecked writes data gzipped to w, its checksum in the header
+func writeChecked(w io.Writer, data []byte) error {
+	gw := gzip.NewWriter(w)
+	gw.Header.Extra = checksumExtra(data)
+	if _, err := gw.Write(data); err != nil {
+		gw.Close()
+		return err
+	}
+	// must close gzip writer to flush all data before file closes
+	return gw.Close()
+}
+
+// readChecked reads the gzipped data at r, verified against the checksum in
+// its header when it has one. failures are ErrCorruptIndex, naming what is
+// wrong with path
+func readChecked(r io.Reader, path string) ([]byte, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, corrupt(path, fmt.Errorf("not a valid index file: %w", err))
+	}
+	defer gr.Close()
+	length, sum, checked := recordedChecksum(gr.Header.Extra)
+
+	data, err := io.ReadAll(gr)
+	switch {
+	case err != nil && checked && uint64(len(data)) < length:
+		return nil, corrupt(path, fmt.Errorf("truncated: %d of %d bytes readable (%w)", len(data), length, err))
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return nil, corrupt(path, fmt.Errorf("truncated: %w", err))
+	case err != nil:
+		return nil, corrupt(path, err)
+	case checked && uint64(len(data)) != length:
+		return nil, corrupt(path, fmt.Errorf("length mismatch: %d bytes, %d recorded", len(data), length))
+	case checked && crc32.Checksum(data, castagnoli) != sum:
+		return nil, corrupt(path, errors.New("checksum mismatch"))
+	}
+	return data, nil
+}
+
+// corrupt reports that the index at path is corrupt, and why
+func corrupt(path string, err error) error {
+	return errkind.With(fmt.Errorf("index %s is corrupt (%w) - restore it from a backup or rebuild it", path, err), ErrCorruptIndex)
+}
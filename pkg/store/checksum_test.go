@@ -0,0 +1,60 @@
+package store
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"lr/pkg/chunk"
+)
+
+func TestLoadDetectsCorruption(t *testing.T) {
+	vs := NewVectorStore()
+	for i := range 50 {
+		vs.Add(chunk.Chunk{Text: strings.Repeat("chunk text ", i), Source: "a.go"}, []float64{float64(i), 0.5, 0.25})
+	}
+	path := filepath.Join(t.TempDir(), "test.lrindex")
+	if err := vs.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := NewVectorStore().Load(path); err != nil {
+		t.Fatalf("load of a checksummed index failed: %v", err)
+	}
+	saved, _ := os.ReadFile(path)
+
+	loadErr := func(data []byte) error {
+		t.Helper()
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return NewVectorStore().Load(path)
+	}
+	if err := loadErr(saved[:len(saved)/2]); !errors.Is(err, ErrCorruptIndex) || !strings.Contains(err.Error(), "truncated") {
+		t.Errorf("truncated index: %v", err)
+	}
+
+	// the json changed without the gzip noticing: only the checksum tells
+	data, _ := json.Marshal(vs)
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	gw.Header.Extra = checksumExtra(bytes.Replace(data, []byte("a.go"), []byte("b.go"), 1))
+	gw.Write(data)
+	gw.Close()
+	if err := loadErr(buf.Bytes()); !errors.Is(err, ErrCorruptIndex) || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("mismatched checksum: %v", err)
+	}
+
+	// indexes written before checksums load unchecked
+	buf.Reset()
+	gw = gzip.NewWriter(&buf)
+	gw.Write(data)
+	gw.Close()
+	if err := loadErr(buf.Bytes()); err != nil {
+		t.Errorf("index without a checksum: %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package store
+
+import (
+	"lr/pkg/chunk"
+)
+
+// duplicateSimilarity is how similar two chunks from different sources must be
+// to be treated as the same content
+const duplicateSimilarity = 0.98
+
+// collapseDuplicates merges results (sorted best first) whose chunks are the same
+// content indexed in different sources, keeping the best-scoring copy and listing
+// the others in its AlsoIn. chunks match on the content hash of their ids
+// (whitespace-normalized text) or near-identical embeddings
+func collapseDuplicates(results []SearchResult) []SearchResult {
+	kept := make([]SearchResult, 0, len(results))
+	byHash := make(map[string]int)
+
+	for _, r := range results {
+		hash := chunk.HashOf(r.Chunk)
+		dup, ok := byHash[hash]
+		if !ok || indexName(kept[dup]) == indexName(r) {
+			dup = nearDuplicate(kept, r)
+		}
+		if dup >= 0 {
+			kept[dup].AlsoIn = append(kept[dup].AlsoIn, origin(r))
+			continue
+		}
+
+		if _, ok := byHash[hash]; !ok {
+			byHash[hash] = len(kept)
+		}
+		kept = append(kept, r)
+	}
+	return kept
+}
+
+// nearDuplicate returns the index of a kept result from another source whose
+// embedding is nearly identical to r's, or -1
+func nearDuplicate(kept []SearchResult, r SearchResult) int {
+	if r.embedding == nil {
+		return -1
+	}
+	for i, k := range kept {
+		if k.embedding == nil || indexName(k) == indexName(r) {
+			continue
+		}
+		if CosineSimilarity(k.embedding, r.embedding) >= duplicateSimilarity {
+			return i
+		}
+	}
+	return -1
+}
+
+// indexName is the source (index) a multi-source result came from
+func indexName(r SearchResult) string {
+	return r.Chunk.Metadata["vector_source"]
+}
+
+// origin identifies where a result came from, as "index:path"
+func origin(r SearchResult) string {
+	return indexName(r) + ":" + r.Chunk.Source
+}
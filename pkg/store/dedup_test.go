@@ -0,0 +1,35 @@
+package store
+
+import (
+	"testing"
+
+	"lr/pkg/chunk"
+)
+
+func TestCollapseDuplicates(t *testing.T) {
+	result := func(index, path, text string, similarity float64, embedding []float64) SearchResult {
+		return SearchResult{
+			Chunk:      chunk.Chunk{Text: text, Source: path, Metadata: map[string]string{"vector_source": index}},
+			Similarity: similarity,
+			embedding:  embedding,
+		}
+	}
+
+	results := []SearchResult{
+		result("app", "vendor/lib/a.go", "func A() {}", 0.9, []float64{1, 0}),
+		result("lib", "a.go", "func  A()   {}", 0.89, []float64{0, 1}),        // same text, re-indented
+		result("lib", "b.go", "func B() {}", 0.8, []float64{1, 0.01}),         // near-identical embedding
+		result("app", "vendor/lib/c.go", "func A() {}", 0.7, []float64{0, 1}), // same source as the kept copy
+	}
+
+	got := collapseDuplicates(results)
+	if len(got) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(got))
+	}
+	if len(got[0].AlsoIn) != 2 || got[0].AlsoIn[0] != "lib:a.go" || got[0].AlsoIn[1] != "lib:b.go" {
+		t.Errorf("unexpected origins: %v", got[0].AlsoIn)
+	}
+	if got[1].Chunk.Source != "vendor/lib/c.go" {
+		t.Errorf("duplicates within one source should be kept, got %s", got[1].Chunk.Source)
+	}
+}
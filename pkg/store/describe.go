@@ -0,0 +1,106 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// descriptionsFile holds the source descriptions for an index directory. it
+// deliberately isn't .json so LoadAll doesn't mistake it for a legacy index
+const descriptionsFile = "descriptions.lrmeta"
+
+// routeMargin keeps every described source scoring within this much of the best
+// match, so questions spanning a couple of sources still reach all of them
+const routeMargin = 0.05
+
+// SourceDescription summarizes what a source contains. its embedding is compared
+// with the question to decide which sources are worth searching
+type SourceDescription struct {
+	Text           string    `json:"text"`
+	Embedding      []float64 `json:"embedding"`
+	EmbeddingModel string    `json:"embedding_model,omitempty"`
+	UpdatedAt      string    `json:"updated_at"`
+}
+
+// LoadDescriptions reads the source descriptions stored in baseDir (none is not an error)
+func LoadDescriptions(baseDir string) (map[string]SourceDescription, error) {
+	descriptions := make(map[string]SourceDescription)
+	data, err := os.ReadFile(filepath.Join(baseDir, descriptionsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return descriptions, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &descriptions); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", descriptionsFile, err)
+	}
+	return descriptions, nil
+}
+
+// SaveDescription stores (or, with a nil description, removes) the description of a source
+func SaveDescription(baseDir, name string, d *SourceDescription) error {
+	descriptions, err := LoadDescriptions(baseDir)
+	if err != nil {
+		return err
+	}
+	if d == nil {
+		delete(descriptions, name)
+	} else {
+		descriptions[name] = *d
+	}
+
+	data, err := json.MarshalIndent(descriptions, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(baseDir, descriptionsFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to save descriptions: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to save descriptions: %w", err)
+	}
+	return nil
+}
+
+// RouteSources picks the loaded sources worth searching for a question, by comparing
+// the question embedding with each source's description. sources without a usable
+// description are always kept. returns nil (search everything) when fewer than two
+// sources are described, since there is nothing to choose between
+func (m *MultiSourceStore) RouteSources(queryEmbedding []float64) []string {
+	type scored struct {
+		name  string
+		score float64
+	}
+	var described []scored
+	var undescribed []string
+	for name := range m.Sources {
+		d, ok := m.Descriptions[name]
+		if !ok || len(d.Embedding) != len(queryEmbedding) {
+			undescribed = append(undescribed, name)
+			continue
+		}
+		described = append(described, scored{name, CosineSimilarity(queryEmbedding, d.Embedding)})
+	}
+	if len(described) < 2 {
+		return nil
+	}
+
+	sort.Slice(described, func(i, j int) bool { return described[i].score > described[j].score })
+	routed := undescribed
+	for _, s := range described {
+		if s.score < described[0].score-routeMargin {
+			break
+		}
+		routed = append(routed, s.name)
+	}
+	sort.Strings(routed)
+	return routed
+}
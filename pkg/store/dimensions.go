@@ -0,0 +1,88 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrDimensionMismatch is the kind of a DimensionError; check with errors.Is
+var ErrDimensionMismatch = errors.New("embedding dimension mismatch")
+
+// DimensionError reports an index whose embeddings have a different size than the
+// query embedding, which means it was built with another embedding model
+type DimensionError struct {
+	Source         string // index name, empty for a standalone store
+	SourcePath     string // indexed directory, if recorded
+	EmbeddingModel string // model the index was built with, if recorded
+	IndexDims      int
+	QueryDims      int
+}
+
+func (e *DimensionError) Error() string {
+	name := "index"
+	if e.Source != "" {
+		name = "index " + e.Source
+	}
+	model := "an unrecorded embedding model"
+	if e.EmbeddingModel != "" {
+		model = e.EmbeddingModel
+	}
+	return fmt.Sprintf("%s was built with %s (%d dims) but the query embedding has %d dims",
+		name, model, e.IndexDims, e.QueryDims)
+}
+
+// Is makes errors.Is(err, ErrDimensionMismatch) match
+func (e *DimensionError) Is(target error) bool {
+	return target == ErrDimensionMismatch
+}
+
+// Dimensions returns the size of the store's embeddings, or 0 if it's empty
+func (vs *VectorStore) Dimensions() int {
+	if len(vs.Embeddings) == 0 {
+		return 0
+	}
+	return len(vs.Embeddings[0])
+}
+
+// CheckDimensions returns a *DimensionError if the query embedding can't be
+// compared with the store's embeddings. an empty store matches anything
+func (vs *VectorStore) CheckDimensions(queryEmbedding []float64) error {
+	if derr := vs.dimensionError(queryEmbedding); derr != nil {
+		return derr
+	}
+	return nil
+}
+
+func (vs *VectorStore) dimensionError(queryEmbedding []float64) *DimensionError {
+	dims := vs.Dimensions()
+	if dims == 0 || dims == len(queryEmbedding) {
+		return nil
+	}
+	return &DimensionError{
+		SourcePath:     vs.Metadata.SourcePath,
+		EmbeddingModel: vs.Metadata.EmbeddingModel,
+		IndexDims:      dims,
+		QueryDims:      len(queryEmbedding),
+	}
+}
+
+// CheckDimensions returns a *DimensionError for each of the given sources (or all
+// loaded sources if none are given) whose embeddings don't match the query.
+// Search skips these sources
+func (m *MultiSourceStore) CheckDimensions(q QueryEmbeddings, sources []string) []*DimensionError {
+	if len(sources) == 0 {
+		sources = m.ListSources()
+	}
+	var mismatched []*DimensionError
+	for _, name := range sources {
+		vs, ok := m.Sources[name]
+		if !ok {
+			continue
+		}
+		if derr := vs.dimensionError(q.For(vs)); derr != nil {
+			derr.Source = name
+			mismatched = append(mismatched, derr)
+		}
+	}
+	return mismatched
+}
@@ -0,0 +1,423 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"lr/pkg/chunk"
+)
+
+// corpora embedded by other tools can be imported as indexes, so lr queries
+// them like any other. ImportDump reads:
+//
+//   - langchain: an InMemoryVectorStore dump (a json object of
+//     {id, vector, text, metadata} records)
+//   - llamaindex: a persist directory (default__vector_store.json with the
+//     embeddings, docstore.json with the text)
+//   - records: a json array, or jsonl, of records with the text under text,
+//     page_content, document or content, the embedding under embedding,
+//     vector or values, and metadata (the remaining fields when there is no
+//     metadata object); or chroma-style columns (ids, embeddings, documents,
+//     metadatas)
+//   - parquet: a table of such records, read through the duckdb cli
+
+// ImportFormats lists the formats ImportDump reads ("auto" detects them)
+var ImportFormats = []string{"auto", "langchain", "llamaindex", "records", "parquet"}
+
+var (
+	importTextKeys   = []string{"text", "page_content", "document", "content", "chunk"}
+	importVectorKeys = []string{"embedding", "vector", "values", "embeddings"}
+	importIDKeys     = []string{"id", "_id", "node_id", "uuid"}
+	// metadata naming the document a chunk came from, in order of preference
+	importSourceKeys = []string{"source", "file_path", "path", "url", "file_name", "filename", "title", "doc_id", "ref_doc_id"}
+)
+
+// importRecord is a chunk of a dump before it joins the store
+type importRecord struct {
+	id       string
+	text     string
+	vector   []float64
+	metadata map[string]any
+}
+
+// ImportDump reads a vector dump in format (see ImportFormats) into a store.
+// the store's embedding model is left for the caller to record
+func ImportDump(path, format string) (*VectorStore, error) {
+	if format == "" || format == "auto" {
+		var err error
+		if format, err = detectDumpFormat(path); err != nil {
+			return nil, err
+		}
+	}
+	var records []importRecord
+	var err error
+	switch format {
+	case "langchain", "records":
+		records, err = readRecordDump(path)
+	case "llamaindex":
+		records, err = readLlamaIndexDump(path)
+	case "parquet":
+		records, err = readParquetDump(path)
+	default:
+		return nil, fmt.Errorf("unknown import format %q (use %s)", format, strings.Join(ImportFormats, ", "))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s holds no records", path)
+	}
+
+	vs := NewVectorStore()
+	sources := make(map[string]bool)
+	for i, r := range records {
+		name := r.id
+		if name == "" {
+			name = fmt.Sprint(i)
+		}
+		if strings.TrimSpace(r.text) == "" {
+			return nil, fmt.Errorf("record %s has no text", name)
+		}
+		if len(r.vector) == 0 {
+			return nil, fmt.Errorf("record %s has no embedding", name)
+		}
+		if dims := vs.Dimensions(); dims != 0 && len(r.vector) != dims {
+			return nil, fmt.Errorf("record %s has a %d-dimensional embedding, earlier records %d", name, len(r.vector), dims)
+		}
+		meta := flattenImportMetadata(r.metadata)
+		source := ""
+		for _, key := range importSourceKeys {
+			if meta[key] != "" {
+				source = meta[key]
+				break
+			}
+		}
+		if source == "" {
+			source = defaultString(r.id, "imported")
+		}
+		meta["source"] = source
+		if r.id != "" {
+			meta["import_id"] = r.id
+		}
+		vs.Add(chunk.Chunk{Text: r.text, Source: source, Metadata: meta}, r.vector)
+		sources[source] = true
+	}
+
+	abs, _ := filepath.Abs(path)
+	vs.Metadata.ImportedFrom = abs
+	vs.Metadata.IndexedAt = time.Now().Format(time.RFC3339)
+	vs.Metadata.ChunkCount = len(vs.Chunks)
+	vs.Metadata.FileCount = len(sources)
+	for source := range sources {
+		vs.Metadata.IndexedFiles = append(vs.Metadata.IndexedFiles, source)
+	}
+	sort.Strings(vs.Metadata.IndexedFiles)
+	return vs, nil
+}
+
+// detectDumpFormat guesses a dump's format from its name and, for json, its shape
+func detectDumpFormat(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if info.IsDir() {
+		return "llamaindex", nil
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".parquet":
+		return "parquet", nil
+	case ".jsonl", ".ndjson":
+		return "records", nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	var object map[string]json.RawMessage
+	if json.Unmarshal(data, &object) == nil {
+		if _, ok := object["embedding_dict"]; ok {
+			return "llamaindex", nil
+		}
+		if _, ok := object["ids"]; ok {
+			return "records", nil
+		}
+		return "langchain", nil
+	}
+	return "records", nil
+}
+
+// readRecordDump reads a json array, jsonl, a json object of records keyed by
+// id (langchain) or chroma-style columns
+func readRecordDump(path string) ([]importRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimSpace(data)
+
+	var raw []map[string]any
+	switch {
+	case len(data) > 0 && data[0] == '[':
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+	case len(data) > 0 && data[0] == '{' && json.Valid(data):
+		var object map[string]any
+		if err := json.Unmarshal(data, &object); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		if _, ok := object["ids"]; ok {
+			return columnRecords(object)
+		}
+		// a jsonl file of one record
+		for _, key := range importVectorKeys {
+			if _, ok := object[key]; ok {
+				return []importRecord{parseImportRecord(object)}, nil
+			}
+		}
+		ids := make([]string, 0, len(object))
+		for id := range object {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			r, ok := object[id].(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("%s: %s is not a record", path, id)
+			}
+			if _, ok := r["id"]; !ok {
+				r["id"] = id
+			}
+			raw = append(raw, r)
+		}
+	default:
+		// jsonl
+		for n, line := range bytes.Split(data, []byte("\n")) {
+			if line = bytes.TrimSpace(line); len(line) == 0 {
+				continue
+			}
+			var r map[string]any
+			if err := json.Unmarshal(line, &r); err != nil {
+				return nil, fmt.Errorf("%s line %d: %w", path, n+1, err)
+			}
+			raw = append(raw, r)
+		}
+	}
+
+	records := make([]importRecord, 0, len(raw))
+	for _, r := range raw {
+		records = append(records, parseImportRecord(r))
+	}
+	return records, nil
+}
+
+// parseImportRecord picks a record's id, text, embedding and metadata by
+// their usual field names
+func parseImportRecord(r map[string]any) importRecord {
+	var rec importRecord
+	used := make(map[string]bool)
+	take := func(keys []string) (any, bool) {
+		for _, key := range keys {
+			if v, ok := r[key]; ok && v != nil {
+				used[key] = true
+				return v, true
+			}
+		}
+		return nil, false
+	}
+	if v, ok := take(importIDKeys); ok {
+		rec.id = importString(v)
+	}
+	if v, ok := take(importTextKeys); ok {
+		rec.text, _ = v.(string)
+	}
+	if v, ok := take(importVectorKeys); ok {
+		rec.vector = importVector(v)
+	}
+	if v, ok := take([]string{"metadata"}); ok {
+		rec.metadata, _ = v.(map[string]any)
+	} else {
+		rec.metadata = make(map[string]any)
+		for k, v := range r {
+			if !used[k] {
+				rec.metadata[k] = v
+			}
+		}
+	}
+	return rec
+}
+
+// columnRecords reads chroma's get() shape: parallel ids, embeddings,
+// documents and metadatas
+func columnRecords(object map[string]any) ([]importRecord, error) {
+	ids, _ := object["ids"].([]any)
+	embeddings, _ := object["embeddings"].([]any)
+	documents, _ := object["documents"].([]any)
+	metadatas, _ := object["metadatas"].([]any)
+	if len(embeddings) != len(ids) || len(documents) != len(ids) {
+		return nil, fmt.Errorf("ids, embeddings and documents must be the same length (%d, %d, %d)", len(ids), len(embeddings), len(documents))
+	}
+	records := make([]importRecord, len(ids))
+	for i := range ids {
+		records[i].id = importString(ids[i])
+		records[i].vector = importVector(embeddings[i])
+		records[i].text, _ = documents[i].(string)
+		if i < len(metadatas) {
+			records[i].metadata, _ = metadatas[i].(map[string]any)
+		}
+	}
+	return records, nil
+}
+
+// readLlamaIndexDump reads a llamaindex SimpleVectorStore persist directory
+// (or its vector store file): the embeddings come from the vector store, the
+// text and metadata from the docstore next to it
+func readLlamaIndexDump(path string) ([]importRecord, error) {
+	dir, vectorFile := path, ""
+	if info, err := os.Stat(path); err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		dir, vectorFile = filepath.Dir(path), path
+	} else {
+		for _, name := range []string{"default__vector_store.json", "vector_store.json"} {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				vectorFile = filepath.Join(dir, name)
+				break
+			}
+		}
+		if vectorFile == "" {
+			return nil, fmt.Errorf("%s has no default__vector_store.json (is it a llamaindex persist directory?)", dir)
+		}
+	}
+
+	var vectors struct {
+		EmbeddingDict  map[string][]float64      `json:"embedding_dict"`
+		TextIDToRefDoc map[string]string         `json:"text_id_to_ref_doc_id"`
+		MetadataDict   map[string]map[string]any `json:"metadata_dict"`
+	}
+	if err := readJSONFile(vectorFile, &vectors); err != nil {
+		return nil, err
+	}
+	var docstore struct {
+		Data map[string]struct {
+			Data json.RawMessage `json:"__data__"`
+		} `json:"docstore/data"`
+	}
+	docstoreFile := filepath.Join(dir, "docstore.json")
+	if err := readJSONFile(docstoreFile, &docstore); err != nil {
+		return nil, fmt.Errorf("the embeddings' text is in docstore.json: %w", err)
+	}
+
+	ids := make([]string, 0, len(vectors.EmbeddingDict))
+	for id := range vectors.EmbeddingDict {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	records := make([]importRecord, 0, len(ids))
+	for _, id := range ids {
+		node, ok := docstore.Data[id]
+		if !ok {
+			return nil, fmt.Errorf("node %s isn't in %s", id, docstoreFile)
+		}
+		// older versions store the node as a json string
+		data := node.Data
+		var encoded string
+		if json.Unmarshal(data, &encoded) == nil {
+			data = []byte(encoded)
+		}
+		var n struct {
+			Text     string         `json:"text"`
+			Metadata map[string]any `json:"metadata"`
+		}
+		if err := json.Unmarshal(data, &n); err != nil {
+			return nil, fmt.Errorf("node %s in %s: %w", id, docstoreFile, err)
+		}
+		meta := n.Metadata
+		if meta == nil {
+			meta = vectors.MetadataDict[id]
+		}
+		if meta == nil {
+			meta = make(map[string]any)
+		}
+		if ref := vectors.TextIDToRefDoc[id]; ref != "" {
+			if _, ok := meta["ref_doc_id"]; !ok {
+				meta["ref_doc_id"] = ref
+			}
+		}
+		records = append(records, importRecord{id: id, text: n.Text, vector: vectors.EmbeddingDict[id], metadata: meta})
+	}
+	return records, nil
+}
+
+// readParquetDump reads a parquet table of records by having the duckdb cli
+// convert it to jsonl
+func readParquetDump(path string) ([]importRecord, error) {
+	if _, err := exec.LookPath("duckdb"); err != nil {
+		return nil, fmt.Errorf("importing parquet needs the duckdb cli on PATH (or convert %s to jsonl)", filepath.Base(path))
+	}
+	tmp, err := os.CreateTemp("", "lr-import-*.jsonl")
+	if err != nil {
+		return nil, err
+	}
+	tmp.Close()
+	defer os.Remove(tmp.Name())
+	quote := func(s string) string { return "'" + strings.ReplaceAll(s, "'", "''") + "'" }
+	sql := fmt.Sprintf("COPY (SELECT * FROM read_parquet(%s)) TO %s (FORMAT JSON)", quote(path), quote(tmp.Name()))
+	if out, err := exec.Command("duckdb", ":memory:", "-c", sql).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("duckdb failed to read %s: %w: %s", path, err, strings.TrimSpace(string(out)))
+	}
+	return readRecordDump(tmp.Name())
+}
+
+func readJSONFile(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return nil
+}
+
+// importVector converts a decoded json array of numbers
+func importVector(v any) []float64 {
+	values, _ := v.([]any)
+	vector := make([]float64, 0, len(values))
+	for _, x := range values {
+		f, ok := x.(float64)
+		if !ok {
+			return nil
+		}
+		vector = append(vector, f)
+	}
+	return vector
+}
+
+// importString renders a decoded json value as chunk metadata: strings as they
+// are, anything else as json
+func importString(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
+
+func flattenImportMetadata(metadata map[string]any) map[string]string {
+	meta := make(map[string]string, len(metadata)+2)
+	for k, v := range metadata {
+		if v != nil {
+			meta[k] = importString(v)
+		}
+	}
+	return meta
+}
@@ -0,0 +1,77 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestImportDump(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	langchain := write("langchain.json", `{
+		"b": {"id": "b", "vector": [0, 1], "text": "second", "metadata": {"source": "wiki/b.md", "page": 2}},
+		"a": {"id": "a", "vector": [1, 0], "text": "first", "metadata": {"source": "wiki/a.md"}}
+	}`)
+	jsonl := write("records.jsonl", `{"id": "1", "page_content": "first", "embedding": [1, 0], "url": "https://wiki/a"}
+{"id": "2", "page_content": "second", "embedding": [0, 1], "url": "https://wiki/b"}
+`)
+	columns := write("chroma.json", `{"ids": ["x", "y"], "embeddings": [[1, 0], [0, 1]], "documents": ["first", "second"], "metadatas": [{"source": "a"}, null]}`)
+	llama := filepath.Dir(write("persist/default__vector_store.json", `{
+		"embedding_dict": {"n1": [1, 0], "n2": [0, 1]},
+		"text_id_to_ref_doc_id": {"n1": "doc-a", "n2": "doc-b"},
+		"metadata_dict": {}
+	}`))
+	write("persist/docstore.json", `{"docstore/data": {
+		"n1": {"__data__": {"text": "first", "metadata": {"file_path": "/wiki/a.md"}}, "__type__": "1"},
+		"n2": {"__data__": "{\"text\": \"second\", \"metadata\": {}}", "__type__": "1"}
+	}}`)
+
+	tests := []struct {
+		path    string
+		sources []string
+	}{
+		{langchain, []string{"wiki/a.md", "wiki/b.md"}},
+		{jsonl, []string{"https://wiki/a", "https://wiki/b"}},
+		{columns, []string{"a", "y"}},
+		{llama, []string{"/wiki/a.md", "doc-b"}},
+	}
+	for _, tt := range tests {
+		vs, err := ImportDump(tt.path, "auto")
+		if err != nil {
+			t.Fatalf("%s: %v", tt.path, err)
+		}
+		if len(vs.Chunks) != 2 || vs.Dimensions() != 2 {
+			t.Fatalf("%s: got %d chunks of %d dims", tt.path, len(vs.Chunks), vs.Dimensions())
+		}
+		for i, c := range vs.Chunks {
+			if c.Text != []string{"first", "second"}[i] || c.Source != tt.sources[i] {
+				t.Errorf("%s: chunk %d is %q from %q", tt.path, i, c.Text, c.Source)
+			}
+			if c.Metadata["import_id"] == "" {
+				t.Errorf("%s: chunk %d has no import_id", tt.path, i)
+			}
+		}
+		if vs.Metadata.ImportedFrom == "" || vs.Metadata.FileCount != 2 {
+			t.Errorf("%s: unexpected metadata %+v", tt.path, vs.Metadata)
+		}
+	}
+	if vs, _ := ImportDump(langchain, "auto"); vs.Chunks[1].Metadata["page"] != "2" {
+		t.Errorf("metadata not flattened: %v", vs.Chunks[1].Metadata)
+	}
+
+	if _, err := ImportDump(write("mixed.jsonl", `{"text": "a", "vector": [1, 0]}
+{"text": "b", "vector": [1, 0, 0]}`), "auto"); err == nil {
+		t.Error("expected an error for mixed dimensions")
+	}
+}
@@ -0,0 +1,9 @@
+package store
+
+import "errors"
+
+// error kinds for missing and unreadable indexes; check with errors.Is
+var (
+	ErrNoIndex      = errors.New("no index found")
+	ErrCorruptIndex = errors.New("corrupt index")
+)
@@ -0,0 +1,124 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	ignore "github.com/sabhiram/go-gitignore"
+
+	"lr/pkg/chunk"
+)
+
+// excludesFile holds the paths each index's search results leave out. like
+// hintsFile it lives beside the indexes, so exclusions survive a rebuild
+const excludesFile = "excludes.lrmeta"
+
+// LoadExcludes reads the excluded path patterns stored in baseDir, by index
+// name (none is not an error)
+func LoadExcludes(baseDir string) (map[string][]string, error) {
+	excludes := make(map[string][]string)
+	data, err := os.ReadFile(filepath.Join(baseDir, excludesFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return excludes, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &excludes); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", excludesFile, err)
+	}
+	return excludes, nil
+}
+
+// SaveExcludes stores (or, with none, removes) the excluded path patterns of a source
+func SaveExcludes(baseDir, name string, patterns []string) error {
+	excludes, err := LoadExcludes(baseDir)
+	if err != nil {
+		return err
+	}
+	if len(patterns) == 0 {
+		delete(excludes, name)
+	} else {
+		excludes[name] = patterns
+	}
+
+	data, err := json.MarshalIndent(excludes, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(baseDir, excludesFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to save excludes: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to save excludes: %w", err)
+	}
+	return nil
+}
+
+// PathFilter matches the files of chunks against gitignore-style patterns
+// ("vendor/**", "*.pb.go", "!keep.go")
+type PathFilter struct {
+	matcher *ignore.GitIgnore
+}
+
+// NewPathFilter compiles the patterns of each list into one filter, nil when
+// there are none
+func NewPathFilter(lists ...[]string) *PathFilter {
+	patterns := slices.Concat(lists...)
+	if len(patterns) == 0 {
+		return nil
+	}
+	return &PathFilter{matcher: ignore.CompileIgnoreLines(patterns...)}
+}
+
+// Excludes reports whether the file of the chunk with source (relative to
+// the indexed directory, " (part n)" and all) matches the filter. a nil
+// filter excludes nothing
+func (f *PathFilter) Excludes(source string) bool {
+	return f != nil && f.matcher.MatchesPath(chunk.FilePath(source))
+}
+
+// Drop removes the results from files the filter matches
+func (f *PathFilter) Drop(results []SearchResult) []SearchResult {
+	if f == nil {
+		return results
+	}
+	kept := results[:0]
+	for _, r := range results {
+		if !f.Excludes(r.Chunk.Source) {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
+
+// exclusion returns the filter of the source's own excludes and exclude
+func (m *MultiSourceStore) exclusion(source string, exclude []string) *PathFilter {
+	return NewPathFilter(m.Excludes[source], exclude)
+}
+
+// DropExcluded removes the results from files excluded in their source, e.g.
+// chunks an expansion added after the search
+func (m *MultiSourceStore) DropExcluded(results []SearchResult, exclude []string) []SearchResult {
+	filters := make(map[string]*PathFilter)
+	kept := results[:0]
+	for _, r := range results {
+		source := r.Chunk.Metadata["vector_source"]
+		f, ok := filters[source]
+		if !ok {
+			f = m.exclusion(source, exclude)
+			filters[source] = f
+		}
+		if !f.Excludes(r.Chunk.Source) {
+			kept = append(kept, r)
+		}
+	}
+	return kept
+}
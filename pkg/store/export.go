@@ -0,0 +1,306 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// indexes can be exported to the collections of other vector databases, with
+// their embeddings, so other rag stacks can use them without re-embedding.
+// both targets are spoken to over their http apis
+
+// ExportFormats lists the databases Export can write to
+var ExportFormats = []string{"qdrant", "chroma"}
+
+// DefaultExportBatch is how many chunks an export request carries
+const DefaultExportBatch = 256
+
+// ExportOptions configures an Export
+type ExportOptions struct {
+	Format     string // qdrant or chroma
+	URL        string // the database's base url (default: its local default)
+	Collection string
+	APIKey     string // qdrant api-key, or chroma token
+	Recreate   bool   // delete the collection first, rather than adding to it
+	BatchSize  int    // 0: DefaultExportBatch
+	// Progress, if set, is called after each batch with the chunks sent so far
+	Progress func(done, total int)
+}
+
+// exporter writes batches of chunks to a collection
+type exporter interface {
+	prepare(ctx context.Context, dims int, recreate bool) error
+	upsert(ctx context.Context, ids []string, vs *VectorStore, from, to int, index string) error
+}
+
+// Export writes every chunk of vs (the index called name), its embedding and
+// metadata to a collection. each point's id derives from the index name and the
+// chunk's position, so exporting again overwrites rather than duplicates
+func Export(ctx context.Context, vs *VectorStore, name string, opts ExportOptions) error {
+	if len(vs.Chunks) == 0 {
+		return fmt.Errorf("index %s is empty", name)
+	}
+	if opts.Collection == "" {
+		opts.Collection = name
+	}
+	batch := opts.BatchSize
+	if batch <= 0 {
+		batch = DefaultExportBatch
+	}
+
+	h := &exportHTTP{client: &http.Client{Timeout: 5 * time.Minute}}
+	var e exporter
+	switch opts.Format {
+	case "qdrant":
+		h.base = defaultString(opts.URL, "http://localhost:6333")
+		if opts.APIKey != "" {
+			h.header = http.Header{"api-key": {opts.APIKey}}
+		}
+		e = &qdrantExporter{h: h, collection: opts.Collection}
+	case "chroma":
+		h.base = defaultString(opts.URL, "http://localhost:8000")
+		if opts.APIKey != "" {
+			h.header = http.Header{"X-Chroma-Token": {opts.APIKey}}
+		}
+		e = &chromaExporter{h: h, collection: opts.Collection}
+	default:
+		return fmt.Errorf("unknown export format %q (use %s)", opts.Format, strings.Join(ExportFormats, " or "))
+	}
+	h.base = strings.TrimRight(h.base, "/")
+
+	if err := e.prepare(ctx, vs.Dimensions(), opts.Recreate); err != nil {
+		return err
+	}
+	ids := exportIDs(name, len(vs.Chunks))
+	for from := 0; from < len(vs.Chunks); from += batch {
+		to := min(from+batch, len(vs.Chunks))
+		if err := e.upsert(ctx, ids, vs, from, to, name); err != nil {
+			return fmt.Errorf("export of chunks %d-%d failed: %w", from, to-1, err)
+		}
+		if opts.Progress != nil {
+			opts.Progress(to, len(vs.Chunks))
+		}
+	}
+	return nil
+}
+
+// exportIDs returns a uuid-formatted id per chunk position (qdrant only takes
+// integers and uuids)
+func exportIDs(name string, n int) []string {
+	ids := make([]string, n)
+	for i := range ids {
+		sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%d", name, i)))
+		sum[6] = sum[6]&0x0f | 0x50 // version 5 style
+		sum[8] = sum[8]&0x3f | 0x80 // rfc 4122 variant
+		ids[i] = fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+	}
+	return ids
+}
+
+// exportMetadata is what a point carries besides its text
+func exportMetadata(c map[string]string, source, index string) map[string]any {
+	meta := make(map[string]any, len(c)+2)
+	for k, v := range c {
+		meta[k] = v
+	}
+	meta["source"] = source
+	meta["lr_index"] = index
+	return meta
+}
+
+type qdrantExporter struct {
+	h          *exportHTTP
+	collection string
+}
+
+func (q *qdrantExporter) prepare(ctx context.Context, dims int, recreate bool) error {
+	path := "/collections/" + url.PathEscape(q.collection)
+	status, body, err := q.h.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return err
+	}
+	exists := status == http.StatusOK
+	if exists && !recreate {
+		// adding to a collection: its vectors must be the same size
+		var info struct {
+			Result struct {
+				Config struct {
+					Params struct {
+						Vectors json.RawMessage `json:"vectors"`
+					} `json:"params"`
+				} `json:"config"`
+			} `json:"result"`
+		}
+		json.Unmarshal(body, &info)
+		var vectors struct {
+			Size int `json:"size"`
+		}
+		if json.Unmarshal(info.Result.Config.Params.Vectors, &vectors) == nil && vectors.Size != 0 && vectors.Size != dims {
+			return fmt.Errorf("qdrant collection %s holds %d-dimensional vectors, the index has %d (use --recreate)", q.collection, vectors.Size, dims)
+		}
+		return nil
+	}
+	if exists {
+		if err := q.h.expect(ctx, http.MethodDelete, path, nil); err != nil {
+			return err
+		}
+	}
+	return q.h.expect(ctx, http.MethodPut, path, map[string]any{
+		"vectors": map[string]any{"size": dims, "distance": "Cosine"},
+	})
+}
+
+func (q *qdrantExporter) upsert(ctx context.Context, ids []string, vs *VectorStore, from, to int, index string) error {
+	points := make([]map[string]any, 0, to-from)
+	for i := from; i < to; i++ {
+		c := vs.Chunks[i]
+		payload := exportMetadata(c.Metadata, c.Source, index)
+		payload["text"] = c.Text
+		points = append(points, map[string]any{"id": ids[i], "vector": vs.Embeddings[i], "payload": payload})
+	}
+	return q.h.expect(ctx, http.MethodPut, "/collections/"+url.PathEscape(q.collection)+"/points?wait=true", map[string]any{"points": points})
+}
+
+// chromaExporter talks to chroma's v2 api (chroma 1.x), or v1 for older servers
+type chromaExporter struct {
+	h          *exportHTTP
+	collection string
+	prefix     string // collections path
+	id         string // collection id
+}
+
+func (c *chromaExporter) prepare(ctx context.Context, dims int, recreate bool) error {
+	c.prefix = "/api/v2/tenants/default_tenant/databases/default_database/collections"
+	if status, _, err := c.h.do(ctx, http.MethodGet, "/api/v2/heartbeat", nil); err != nil {
+		return err
+	} else if status == http.StatusNotFound {
+		c.prefix = "/api/v1/collections"
+	}
+
+	if recreate {
+		status, body, err := c.h.do(ctx, http.MethodDelete, c.prefix+"/"+url.PathEscape(c.collection), nil)
+		if err != nil {
+			return err
+		}
+		// a missing collection is fine; chroma versions differ in how they say so
+		if status/100 != 2 && status != http.StatusNotFound && !strings.Contains(string(body), "does not exist") {
+			return fmt.Errorf("chroma: failed to delete collection %s: %s", c.collection, exportErrorText(status, body))
+		}
+	}
+
+	status, body, err := c.h.do(ctx, http.MethodPost, c.prefix, map[string]any{
+		"name":          c.collection,
+		"metadata":      map[string]any{"hnsw:space": "cosine"},
+		"get_or_create": true,
+	})
+	if err != nil {
+		return err
+	}
+	if status/100 != 2 {
+		return fmt.Errorf("chroma: failed to create collection %s: %s", c.collection, exportErrorText(status, body))
+	}
+	var created struct {
+		ID        string `json:"id"`
+		Dimension *int   `json:"dimension"`
+	}
+	if err := json.Unmarshal(body, &created); err != nil || created.ID == "" {
+		return fmt.Errorf("chroma: unexpected create collection reply: %s", exportErrorText(status, body))
+	}
+	if created.Dimension != nil && *created.Dimension != dims {
+		return fmt.Errorf("chroma collection %s holds %d-dimensional vectors, the index has %d (use --recreate)", c.collection, *created.Dimension, dims)
+	}
+	c.id = created.ID
+	return nil
+}
+
+func (c *chromaExporter) upsert(ctx context.Context, ids []string, vs *VectorStore, from, to int, index string) error {
+	metadatas := make([]map[string]any, 0, to-from)
+	documents := make([]string, 0, to-from)
+	for i := from; i < to; i++ {
+		metadatas = append(metadatas, exportMetadata(vs.Chunks[i].Metadata, vs.Chunks[i].Source, index))
+		documents = append(documents, vs.Chunks[i].Text)
+	}
+	return c.h.expect(ctx, http.MethodPost, c.prefix+"/"+url.PathEscape(c.id)+"/upsert", map[string]any{
+		"ids":        ids[from:to],
+		"embeddings": vs.Embeddings[from:to],
+		"metadatas":  metadatas,
+		"documents":  documents,
+	})
+}
+
+// exportHTTP sends json requests to a database's api
+type exportHTTP struct {
+	client *http.Client
+	base   string
+	header http.Header
+}
+
+// do sends a request and returns the status and body of the reply
+func (h *exportHTTP) do(ctx context.Context, method, path string, body any) (int, []byte, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return 0, nil, err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, h.base+path, reader)
+	if err != nil {
+		return 0, nil, err
+	}
+	for k, v := range h.header {
+		req.Header[k] = v
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s %s: %w", method, h.base+path, err)
+	}
+	defer resp.Body.Close()
+	reply, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%s %s: %w", method, h.base+path, err)
+	}
+	return resp.StatusCode, reply, nil
+}
+
+// expect sends a request that must succeed
+func (h *exportHTTP) expect(ctx context.Context, method, path string, body any) error {
+	status, reply, err := h.do(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	if status/100 != 2 {
+		return fmt.Errorf("%s %s: %s", method, path, exportErrorText(status, reply))
+	}
+	return nil
+}
+
+func exportErrorText(status int, body []byte) string {
+	text := strings.TrimSpace(string(body))
+	if len(text) > 300 {
+		text = text[:300] + "..."
+	}
+	if text == "" {
+		return http.StatusText(status)
+	}
+	return fmt.Sprintf("%d %s", status, text)
+}
+
+func defaultString(s, fallback string) string {
+	if s == "" {
+		return fallback
+	}
+	return s
+}
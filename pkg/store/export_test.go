@@ -0,0 +1,116 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"lr/pkg/chunk"
+)
+
+// fakeQdrant keeps the points upserted into its collections
+type fakeQdrant struct {
+	mu     sync.Mutex
+	sizes  map[string]int
+	points map[string]map[string]map[string]any // collection -> id -> payload
+}
+
+func (f *fakeQdrant) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("api-key") != "secret" {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	name := parts[1]
+	switch {
+	case len(parts) == 2 && r.Method == http.MethodGet:
+		size, ok := f.sizes[name]
+		if !ok {
+			http.Error(w, `{"status":{"error":"Not found"}}`, http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"result": map[string]any{"config": map[string]any{"params": map[string]any{"vectors": map[string]any{"size": size}}}}})
+		return
+	case len(parts) == 2 && r.Method == http.MethodPut:
+		var body struct {
+			Vectors struct {
+				Size int `json:"size"`
+			} `json:"vectors"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		f.sizes[name] = body.Vectors.Size
+		f.points[name] = map[string]map[string]any{}
+	case len(parts) == 2 && r.Method == http.MethodDelete:
+		delete(f.sizes, name)
+		delete(f.points, name)
+	case len(parts) == 3 && r.Method == http.MethodPut:
+		var body struct {
+			Points []struct {
+				ID      string         `json:"id"`
+				Vector  []float64      `json:"vector"`
+				Payload map[string]any `json:"payload"`
+			} `json:"points"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		for _, p := range body.Points {
+			if len(p.Vector) != f.sizes[name] {
+				http.Error(w, "wrong vector size", http.StatusBadRequest)
+				return
+			}
+			f.points[name][p.ID] = p.Payload
+		}
+	default:
+		http.Error(w, "unexpected request", http.StatusBadRequest)
+		return
+	}
+	w.Write([]byte(`{"status":"ok"}`))
+}
+
+func TestExportQdrant(t *testing.T) {
+	fake := &fakeQdrant{sizes: map[string]int{}, points: map[string]map[string]map[string]any{}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	vs := NewVectorStore()
+	for i, text := range []string{"alpha", "beta", "gamma"} {
+		vs.Add(chunk.Chunk{Text: text, Source: "a.go", Metadata: map[string]string{"lang": "go"}}, []float64{float64(i), 1})
+	}
+	opts := ExportOptions{Format: "qdrant", URL: srv.URL, APIKey: "secret", BatchSize: 2}
+	ctx := context.Background()
+
+	// twice: the second export overwrites the first's points
+	for range 2 {
+		if err := Export(ctx, vs, "demo", opts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	points := fake.points["demo"]
+	if len(points) != 3 || fake.sizes["demo"] != 2 {
+		t.Fatalf("got %d points of size %d, want 3 of size 2", len(points), fake.sizes["demo"])
+	}
+	for _, p := range points {
+		if p["source"] != "a.go" || p["lang"] != "go" || p["lr_index"] != "demo" || p["text"] == "" {
+			t.Errorf("unexpected payload %v", p)
+		}
+	}
+
+	// a collection of another vector size isn't added to
+	fake.sizes["other"] = 3
+	opts.Collection = "other"
+	if err := Export(ctx, vs, "demo", opts); err == nil || !strings.Contains(err.Error(), "--recreate") {
+		t.Fatalf("expected a dimension error, got %v", err)
+	}
+	opts.Recreate = true
+	if err := Export(ctx, vs, "demo", opts); err != nil {
+		t.Fatal(err)
+	}
+	if fake.sizes["other"] != 2 || len(fake.points["other"]) != 3 {
+		t.Fatalf("recreate: got %d points of size %d", len(fake.points["other"]), fake.sizes["other"])
+	}
+}
@@ -0,0 +1,95 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// hintsFile holds the prompt hints for an index directory. like descriptionsFile
+// it isn't .json, and it lives beside the indexes so hints survive a rebuild
+const hintsFile = "hints.lrmeta"
+
+// SourceHints tell the chat model about a codebase's domain: a short blurb and a
+// glossary of its terminology. they are added to the synthesis prompt whenever
+// the source contributes chunks
+type SourceHints struct {
+	About     string            `json:"about,omitempty"`
+	Glossary  map[string]string `json:"glossary,omitempty"`
+	UpdatedAt string            `json:"updated_at"`
+}
+
+// Empty reports whether the hints say nothing
+func (h SourceHints) Empty() bool {
+	return strings.TrimSpace(h.About) == "" && len(h.Glossary) == 0
+}
+
+// LoadHints reads the prompt hints stored in baseDir (none is not an error)
+func LoadHints(baseDir string) (map[string]SourceHints, error) {
+	hints := make(map[string]SourceHints)
+	data, err := os.ReadFile(filepath.Join(baseDir, hintsFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return hints, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &hints); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", hintsFile, err)
+	}
+	return hints, nil
+}
+
+// SaveHints stores (or, with nil or empty hints, removes) the prompt hints of a source
+func SaveHints(baseDir, name string, h *SourceHints) error {
+	hints, err := LoadHints(baseDir)
+	if err != nil {
+		return err
+	}
+	if h == nil || h.Empty() {
+		delete(hints, name)
+	} else {
+		hints[name] = *h
+	}
+
+	data, err := json.MarshalIndent(hints, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(baseDir, hintsFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to save hints: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to save hints: %w", err)
+	}
+	return nil
+}
+
+// Format renders the hints for a prompt, glossary terms sorted
+func (h SourceHints) Format(name string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "about %s:", name)
+	if about := strings.TrimSpace(h.About); about != "" {
+		b.WriteString(" " + about)
+	}
+	b.WriteString("\n")
+	if len(h.Glossary) > 0 {
+		terms := make([]string, 0, len(h.Glossary))
+		for term := range h.Glossary {
+			terms = append(terms, term)
+		}
+		sort.Strings(terms)
+		b.WriteString("glossary:\n")
+		for _, term := range terms {
+			fmt.Fprintf(&b, "- %s: %s\n", term, h.Glossary[term])
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,118 @@
+package store
+
+import (
+	"path"
+	"strings"
+
+	"lr/pkg/chunk"
+	"lr/pkg/loader"
+)
+
+// a go result rarely makes sense without the packages around it: the types its
+// file uses from the packages it imports, and the callers in the packages that
+// import it. ExpandImports follows the import graph recorded at index time (see
+// loader.GoImportsMetadataKey) from the top go result to those packages. an
+// import path refers to a directory of the source when it ends with it, so
+// "example.com/app/pkg/store" refers to pkg/store; files in the root of the
+// source aren't linked
+
+// ExpandImports appends to results up to n chunks, the most similar to the
+// query, from the go packages the file of the top go result imports or that
+// import it. they are marked with ImportLinkMetadataKey
+func (vs *VectorStore) ExpandImports(queryEmbedding []float64, results []SearchResult, n int) []SearchResult {
+	top, ok := topGoResult(results)
+	if !ok || n <= 0 {
+		return results
+	}
+	linked := vs.importLinked(queryEmbedding, top.Chunk, results, n)
+	return appendLinked(results, linked, chunk.ImportLinkMetadataKey, chunk.FilePath(top.Chunk.Source), "")
+}
+
+// ExpandImports is VectorStore.ExpandImports on the source of the top go result
+func (m *MultiSourceStore) ExpandImports(q QueryEmbeddings, results []SearchResult, n int) []SearchResult {
+	top, ok := topGoResult(results)
+	if !ok || n <= 0 {
+		return results
+	}
+	source := top.Chunk.Metadata["vector_source"]
+	vs, ok := m.Sources[source]
+	if !ok {
+		return results
+	}
+	linked := vs.importLinked(q.For(vs), top.Chunk, results, n)
+	return appendLinked(results, linked, chunk.ImportLinkMetadataKey, chunk.FilePath(top.Chunk.Source), source)
+}
+
+// importLinked returns the n chunks most similar to the query from the
+// packages linked to c's by imports, leaving out those in results
+func (vs *VectorStore) importLinked(queryEmbedding []float64, c chunk.Chunk, results []SearchResult, n int) []SearchResult {
+	dir := path.Dir(chunk.FilePath(c.Source))
+	if dir == "." {
+		return nil
+	}
+	imports := strings.Split(c.Metadata[loader.GoImportsMetadataKey], ",")
+	seen := make(map[string]bool, len(results))
+	for _, r := range results {
+		seen[linkKey(r.Chunk)] = true
+	}
+
+	linked := vs.search(queryEmbedding, n+len(results), func(other chunk.Chunk) bool {
+		if other.Metadata["type"] != "go" || seen[linkKey(other)] {
+			return false
+		}
+		otherDir := path.Dir(chunk.FilePath(other.Source))
+		if otherDir == dir || otherDir == "." {
+			return false
+		}
+		return importsDir(imports, otherDir) ||
+			importsDir(strings.Split(other.Metadata[loader.GoImportsMetadataKey], ","), dir)
+	})
+	if len(linked) > n {
+		linked = linked[:n]
+	}
+	return linked
+}
+
+// appendLinked appends the linked results to results, with the metadata key set
+// to why they were added (and the source they were found in, if any)
+func appendLinked(results, linked []SearchResult, key, why, source string) []SearchResult {
+	for _, r := range linked {
+		// copy the map - stores may be shared between requests
+		metadata := make(map[string]string, len(r.Chunk.Metadata)+2)
+		for k, v := range r.Chunk.Metadata {
+			metadata[k] = v
+		}
+		metadata[key] = why
+		if source != "" {
+			metadata["vector_source"] = source
+		}
+		r.Chunk.Metadata = metadata
+		results = append(results, r)
+	}
+	return results
+}
+
+// linkKey identifies a chunk among the results of a search
+func linkKey(c chunk.Chunk) string {
+	return c.Source + "\x00" + c.Text
+}
+
+// topGoResult returns the best result from a go file
+func topGoResult(results []SearchResult) (SearchResult, bool) {
+	for _, r := range results {
+		if r.Chunk.Metadata["type"] == "go" {
+			return r, true
+		}
+	}
+	return SearchResult{}, false
+}
+
+// importsDir reports whether one of the import paths refers to dir
+func importsDir(imports []string, dir string) bool {
+	for _, imp := range imports {
+		if imp == dir || strings.HasSuffix(imp, "/"+dir) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package store
+
+import (
+	"testing"
+
+	"lr/pkg/chunk"
+	"lr/pkg/loader"
+)
+
+func TestExpandImports(t *testing.T) {
+	goChunk := func(source, imports string) chunk.Chunk {
+		return chunk.Chunk{Text: "func " + source, Source: source, Metadata: map[string]string{
+			"type":                      "go",
+			loader.GoImportsMetadataKey: imports,
+		}}
+	}
+	vs := NewVectorStore()
+	vs.Add(goChunk("cmd/app/main.go", "fmt,example.com/app/pkg/store"), []float64{1, 0})
+	vs.Add(goChunk("pkg/store/store.go", "example.com/app/pkg/chunk"), []float64{0, 1})
+	vs.Add(goChunk("pkg/chunk/chunk.go", "strings"), []float64{0.5, 0.5})
+	vs.Add(goChunk("pkg/other/other.go", "fmt"), []float64{0.9, 0.1})
+	vs.Add(goChunk("cmd/app/flags.go", "example.com/app/pkg/store"), []float64{0.9, 0.2})
+
+	// pkg/store is imported by the top result's file and by cmd/app (its own
+	// package, not a link); pkg/chunk and pkg/other aren't linked to cmd/app
+	results := vs.Search([]float64{0.8, 0.3}, 1)
+	results = vs.ExpandImports([]float64{0.8, 0.3}, results, 3)
+	if len(results) != 2 || results[1].Chunk.Source != "pkg/store/store.go" {
+		t.Fatalf("expected pkg/store/store.go added, got %v", sources(results))
+	}
+	if got := results[1].Chunk.Metadata[chunk.ImportLinkMetadataKey]; got != results[0].Chunk.Source {
+		t.Errorf("expected the added chunk linked to %s, got %q", results[0].Chunk.Source, got)
+	}
+
+	// from pkg/store: cmd/app imports it, and it imports pkg/chunk
+	results = vs.ExpandImports([]float64{0, 1}, vs.Search([]float64{0, 1}, 1), 10)
+	if len(results) != 4 {
+		t.Errorf("expected pkg/store's importers and imports added, got %v", sources(results))
+	}
+}
+
+func sources(results []SearchResult) []string {
+	var s []string
+	for _, r := range results {
+		s = append(s, r.Chunk.Source)
+	}
+	return s
+}
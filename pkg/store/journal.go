@@ -0,0 +1,124 @@
+package store
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"lr/pkg/chunk"
+)
+
+// JournalEntry is one file's update in a journal: the new chunks of Source, or
+// none if it was deleted. ModTime is the file's mtime when it was read
+type JournalEntry struct {
+	Source     string        `json:"source"`
+	ModTime    time.Time     `json:"mod_time"`
+	Chunks     []chunk.Chunk `json:"chunks"`
+	Embeddings [][]float64   `json:"embeddings"`
+}
+
+// Journal is a write-ahead log of per-file updates to an index (name.journal
+// next to it). each entry is synced to disk as soon as its file is embedded, so
+// work finished before a crash or sleep can be replayed with ReadJournal instead
+// of being embedded again
+type Journal struct {
+	f *os.File
+}
+
+// JournalPath returns the journal path of the index at indexPath
+func JournalPath(indexPath string) string {
+	return strings.TrimSuffix(indexPath, ".lrindex") + ".journal"
+}
+
+// OpenJournal opens the journal of the index at indexPath for appending
+func OpenJournal(indexPath string) (*Journal, error) {
+	f, err := os.OpenFile(JournalPath(indexPath), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open journal: %w", err)
+	}
+	return &Journal{f: f}, nil
+}
+
+// Append writes entry to the journal and syncs it to disk
+func (j *Journal) Append(entry JournalEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := j.f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write journal: %w", err)
+	}
+	return j.f.Sync()
+}
+
+// Reset empties the journal once its entries are saved in the index
+func (j *Journal) Reset() error {
+	if err := j.f.Truncate(0); err != nil {
+		return fmt.Errorf("failed to reset journal: %w", err)
+	}
+	return j.f.Sync()
+}
+
+// Close closes the journal, leaving its entries on disk
+func (j *Journal) Close() error {
+	return j.f.Close()
+}
+
+// ReadJournal returns the entries of the journal of the index at indexPath, only
+// the last one of each file. a missing journal has no entries, and an entry cut
+// short by a crash mid-write is ignored
+func ReadJournal(indexPath string) ([]JournalEntry, error) {
+	f, err := os.Open(JournalPath(indexPath))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []JournalEntry
+	latest := make(map[string]int)
+	r := bufio.NewReader(f)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			break // a final line without a newline was never fully written
+		}
+		if err != nil {
+			return nil, err
+		}
+		var entry JournalEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, fmt.Errorf("corrupt journal %s: %w", JournalPath(indexPath), err)
+		}
+		if len(entry.Chunks) != len(entry.Embeddings) {
+			return nil, fmt.Errorf("corrupt journal %s: %s has %d chunks but %d embeddings", JournalPath(indexPath), entry.Source, len(entry.Chunks), len(entry.Embeddings))
+		}
+		if i, ok := latest[entry.Source]; ok {
+			entries[i] = entry
+			continue
+		}
+		latest[entry.Source] = len(entries)
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// AddEntry adds a file update to the segment
+func (s *Segment) AddEntry(entry JournalEntry) {
+	s.Sources = append(s.Sources, entry.Source)
+	for i, c := range entry.Chunks {
+		s.Add(c, entry.Embeddings[i])
+	}
+}
+
+// RemoveJournal deletes the journal of the index at indexPath
+func RemoveJournal(indexPath string) {
+	os.Remove(JournalPath(indexPath))
+}
@@ -0,0 +1,51 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"lr/pkg/chunk"
+)
+
+func TestJournalReplay(t *testing.T) {
+	indexPath := filepath.Join(t.TempDir(), "review.lrindex")
+	journal, err := OpenJournal(indexPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer journal.Close()
+
+	for _, entry := range []JournalEntry{
+		{Source: "a.go", Chunks: []chunk.Chunk{{Text: "a v1", Source: "a.go"}}, Embeddings: [][]float64{{1, 0}}},
+		{Source: "b.go"},
+		{Source: "a.go", Chunks: []chunk.Chunk{{Text: "a v2", Source: "a.go"}}, Embeddings: [][]float64{{0, 1}}},
+	} {
+		if err := journal.Append(entry); err != nil {
+			t.Fatalf("append failed: %v", err)
+		}
+	}
+
+	// a crash mid-write leaves a partial last line
+	f, err := os.OpenFile(JournalPath(indexPath), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"source":"c.go","chu`)
+	f.Close()
+
+	entries, err := ReadJournal(indexPath)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	if len(entries) != 2 || entries[0].Source != "a.go" || entries[0].Chunks[0].Text != "a v2" || entries[1].Source != "b.go" {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+
+	if err := journal.Reset(); err != nil {
+		t.Fatalf("reset failed: %v", err)
+	}
+	if entries, err := ReadJournal(indexPath); err != nil || len(entries) != 0 {
+		t.Fatalf("expected an empty journal after reset, got %d entries (%v)", len(entries), err)
+	}
+}
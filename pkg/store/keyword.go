@@ -0,0 +1,66 @@
+package store
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"lr/pkg/chunk"
+)
+
+// KeywordMatch is a line of an indexed chunk that a keyword search matched
+type KeywordMatch struct {
+	Source  string `json:"source"`         // index name
+	File    string `json:"file"`           // path in the indexed source
+	Line    int    `json:"line,omitempty"` // 1-based, 0 if the chunk's lines weren't recorded
+	Text    string `json:"text"`
+	ChunkID string `json:"chunk_id,omitempty"`
+}
+
+// KeywordSearch returns the lines of the chunks in the given sources (or all
+// loaded sources if none are given) that re matches, by source and in index
+// order, up to limit (0 for all). no embedding is needed, so it finds exact
+// names and strings a similarity search can miss. lines that overlapping
+// chunks share are reported once
+func (m *MultiSourceStore) KeywordSearch(re *regexp.Regexp, sources []string, limit int) []KeywordMatch {
+	if len(sources) == 0 {
+		sources = m.ListSources()
+	}
+	var matches []KeywordMatch
+	for _, name := range sources {
+		vs, ok := m.Sources[name]
+		if !ok {
+			continue
+		}
+		seen := make(map[string]bool)
+		for _, c := range vs.Chunks {
+			text := strings.TrimSpace(c.Text)
+			file := chunk.FilePath(c.Source)
+			start, _, hasLines := chunk.LineRange(c)
+			id := ""
+			if c.Metadata[chunk.IDMetadataKey] != "" {
+				id = name + ":" + c.Metadata[chunk.IDMetadataKey]
+			}
+			lines := strings.Split(text, "\n")
+			for _, loc := range re.FindAllStringIndex(text, -1) {
+				// a match spanning lines is reported at the line it starts on
+				i := strings.Count(text[:loc[0]], "\n")
+				match := KeywordMatch{Source: name, File: file, Text: strings.TrimRight(lines[i], "\r"), ChunkID: id}
+				key := file + "\x00" + lines[i]
+				if hasLines {
+					match.Line = start + i
+					key = file + ":" + strconv.Itoa(match.Line)
+				}
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				matches = append(matches, match)
+				if limit > 0 && len(matches) >= limit {
+					return matches
+				}
+			}
+		}
+	}
+	return matches
+}
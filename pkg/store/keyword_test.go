@@ -0,0 +1,30 @@
+package store
+
+import (
+	"regexp"
+	"testing"
+
+	"lr/pkg/chunk"
+)
+
+func TestKeywordSearch(t *testing.T) {
+	vs := NewVectorStore()
+	// overlapping chunks share line 3
+	vs.Add(chunk.Chunk{Text: "package a\n\nfunc NewThing() {}", Source: "a.go",
+		Metadata: map[string]string{"start_line": "1", "end_line": "3"}}, []float64{1})
+	vs.Add(chunk.Chunk{Text: "func NewThing() {}\n\nvar x = NewThing()", Source: "a.go",
+		Metadata: map[string]string{"start_line": "3", "end_line": "5"}}, []float64{1})
+	m := NewMultiSourceStore(t.TempDir())
+	m.Sources["a"] = vs
+
+	got := m.KeywordSearch(regexp.MustCompile(`NewThing\(`), nil, 0)
+	if len(got) != 2 || got[0].Line != 3 || got[1].Line != 5 || got[1].Text != "var x = NewThing()" {
+		t.Fatalf("unexpected matches %+v", got)
+	}
+	if got := m.KeywordSearch(regexp.MustCompile(`NewThing`), nil, 1); len(got) != 1 {
+		t.Errorf("expected the limit to apply, got %d matches", len(got))
+	}
+	if got := m.KeywordSearch(regexp.MustCompile(`NewThing`), []string{"missing"}, 0); len(got) != 0 {
+		t.Errorf("expected no matches in a missing source, got %+v", got)
+	}
+}
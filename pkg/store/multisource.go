@@ -0,0 +1,446 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"lr/internal/errkind"
+)
+
+// read retry settings for loading indexes that may be mid-update
+const (
+	loadRetries    = 3
+	loadRetryDelay = 100 * time.Millisecond
+)
+
+// MultiSourceStore manages multiple independent vector stores
+type MultiSourceStore struct {
+	Sources      map[string]*VectorStore
+	BaseDir      string
+	Cache        *StoreCache                  // optional, reuses stores whose files haven't changed
+	Descriptions map[string]SourceDescription // loaded by LoadAll, used by RouteSources
+	Hints        map[string]SourceHints       // prompt hints, loaded with the sources
+	Excludes     map[string][]string          // paths left out of each source's results, loaded with the sources
+	Normalize    ScoreNormalization           // how scores are made comparable across sources (default zscore)
+	// CandidateFiles is how many files the summary stage of a hierarchical
+	// search keeps in indexes with file summaries (0: DefaultCandidateFiles,
+	// negative: search every chunk)
+	CandidateFiles int
+}
+
+// DefaultCandidateFiles is the number of files whose chunks a search of an
+// index with file summaries looks at
+const DefaultCandidateFiles = 20
+
+// NewMultiSourceStore creates a new multi-source store
+func NewMultiSourceStore(baseDir string) *MultiSourceStore {
+	return &MultiSourceStore{
+		Sources: make(map[string]*VectorStore),
+		BaseDir: baseDir,
+	}
+}
+
+// LoadSource loads a specific source's vector store (most recent version)
+// a failed read is retried with a fresh glob, since a concurrent update may
+// rename or prune the file between listing and reading it
+func (m *MultiSourceStore) LoadSource(name string) error {
+	var err error
+	for attempt := 0; attempt < loadRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(loadRetryDelay)
+		}
+		var retry bool
+		retry, err = m.loadSourceOnce(name)
+		if err == nil || !retry {
+			return err
+		}
+	}
+	return err
+}
+
+// loadSourceOnce makes a single attempt at loading a source
+// returns whether a failure is worth retrying
+func (m *MultiSourceStore) loadSourceOnce(name string) (bool, error) {
+	// try multiple filename patterns to find the source (.lrindex preferred, .json for backward compat)
+	patterns := []string{
+		filepath.Join(m.BaseDir, fmt.Sprintf("%s*.lrindex", name)),
+		filepath.Join(m.BaseDir, fmt.Sprintf("*_%s*.lrindex", name)),
+		filepath.Join(m.BaseDir, fmt.Sprintf("%s*.json", name)),
+		filepath.Join(m.BaseDir, fmt.Sprintf("*_%s*.json", name)),
+	}
+
+	var allFiles []string
+	for _, pattern := range patterns {
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return false, err
+		}
+		allFiles = append(allFiles, files...)
+	}
+
+	// filter out checkpoint and temp files
+	var validFiles []string
+	for _, file := range allFiles {
+		if !IsPartialIndexFile(file) {
+			validFiles = append(validFiles, file)
+		}
+	}
+
+	if len(validFiles) == 0 {
+		return false, errkind.With(fmt.Errorf("no vector store found for source %s", name), ErrNoIndex)
+	}
+
+	// sort by filename (newest timestamp last)
+	sort.Strings(validFiles)
+	mostRecent := validFiles[len(validFiles)-1]
+
+	var vs *VectorStore
+	var err error
+	if m.Cache != nil {
+		vs, err = m.Cache.Load(mostRecent)
+	} else {
+		vs = NewVectorStore()
+		err = vs.Load(mostRecent)
+	}
+	if err != nil {
+		return true, fmt.Errorf("failed to load source %s: %w", name, err)
+	}
+
+	m.Sources[name] = vs
+	if m.Hints == nil {
+		hints, err := LoadHints(m.BaseDir)
+		if err != nil {
+			return false, err
+		}
+		m.Hints = hints
+	}
+	if m.Excludes == nil {
+		excludes, err := LoadExcludes(m.BaseDir)
+		if err != nil {
+			return false, err
+		}
+		m.Excludes = excludes
+	}
+	return false, nil
+}
+
+// LoadSourceFile loads the index file at path as source name, e.g. an older
+// version of the source than LoadSource would pick
+func (m *MultiSourceStore) LoadSourceFile(name, path string) error {
+	var vs *VectorStore
+	var err error
+	if m.Cache != nil {
+		vs, err = m.Cache.Load(path)
+	} else {
+		vs = NewVectorStore()
+		err = vs.Load(path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load source %s: %w", name, err)
+	}
+	m.Sources[name] = vs
+	if m.Hints == nil {
+		hints, err := LoadHints(m.BaseDir)
+		if err != nil {
+			return err
+		}
+		m.Hints = hints
+	}
+	if m.Excludes == nil {
+		excludes, err := LoadExcludes(m.BaseDir)
+		if err != nil {
+			return err
+		}
+		m.Excludes = excludes
+	}
+	return nil
+}
+
+// WithSources returns a copy of m, sharing its stores and settings, holding
+// the sources of m that keep accepts. m is left as it is, so requests using it
+// aren't disturbed
+func (m *MultiSourceStore) WithSources(keep func(name string) bool) *MultiSourceStore {
+	c := *m
+	c.Sources = make(map[string]*VectorStore, len(m.Sources))
+	for name, vs := range m.Sources {
+		if keep(name) {
+			c.Sources[name] = vs
+		}
+	}
+	return &c
+}
+
+// SaveSource saves a specific source's vector store
+func (m *MultiSourceStore) SaveSource(name string, vs *VectorStore) error {
+	filepath := filepath.Join(m.BaseDir, fmt.Sprintf("%s.lrindex", name))
+
+	if err := AtomicSave(vs, filepath); err != nil {
+		return fmt.Errorf("failed to save source %s: %w", name, err)
+	}
+
+	m.Sources[name] = vs
+	return nil
+}
+
+// LoadAll loads all available source vector stores
+func (m *MultiSourceStore) LoadAll() error {
+	names, err := m.SourceNames()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := m.LoadSource(name); err != nil {
+			return err
+		}
+	}
+	return m.LoadSourceInfo()
+}
+
+// LoadSources loads the named sources, up to workers at a time, then their
+// descriptions and hints. ready, if set, is called as each source loads or fails.
+// sources that load are kept even if others fail; the failures are returned joined
+func (m *MultiSourceStore) LoadSources(names []string, workers int, ready func(name string, err error)) error {
+	var (
+		mu   sync.Mutex
+		errs []error
+		wg   sync.WaitGroup
+	)
+	sem := make(chan struct{}, max(workers, 1))
+	for _, name := range names {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(name string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			// each worker loads into its own store, so only the merge needs the lock
+			one := &MultiSourceStore{Sources: make(map[string]*VectorStore), BaseDir: m.BaseDir, Cache: m.Cache, Hints: map[string]SourceHints{}, Excludes: map[string][]string{}}
+			err := one.LoadSource(name)
+			mu.Lock()
+			if err != nil {
+				errs = append(errs, err)
+			} else {
+				m.Sources[name] = one.Sources[name]
+			}
+			mu.Unlock()
+			if ready != nil {
+				ready(name, err)
+			}
+		}(name)
+	}
+	wg.Wait()
+
+	if err := m.LoadSourceInfo(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// SourceNames lists the sources that have an index in BaseDir
+func (m *MultiSourceStore) SourceNames() ([]string, error) {
+	// list all index files (.lrindex and .json for backward compat)
+	patterns := []string{
+		filepath.Join(m.BaseDir, "*.lrindex"),
+		filepath.Join(m.BaseDir, "*.json"),
+	}
+	var files []string
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+
+	// group files by source name
+	sourceNames := make(map[string]bool)
+	for _, file := range files {
+		base := filepath.Base(file)
+
+		// skip checkpoint and temp files
+		if IsPartialIndexFile(base) {
+			continue
+		}
+
+		// extract source name (strip extension and timestamp if present)
+		name := strings.TrimSuffix(base, ".lrindex")
+		name = strings.TrimSuffix(name, ".json")
+
+		// strip common prefixes from filename
+		for _, prefix := range []string{"nats_", "lr_"} {
+			if strings.HasPrefix(name, prefix) {
+				name = strings.TrimPrefix(name, prefix)
+				break
+			}
+		}
+
+		// if it has a timestamp suffix, remove it
+		if parts := strings.Split(name, "_"); len(parts) > 1 {
+			// check if last part looks like a date (8 digits)
+			lastPart := parts[len(parts)-1]
+			if len(lastPart) == 8 {
+				name = strings.Join(parts[:len(parts)-1], "_")
+			}
+		}
+
+		sourceNames[name] = true
+	}
+
+	names := make([]string, 0, len(sourceNames))
+	for name := range sourceNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// LoadSourceInfo loads the descriptions, hints and excludes that go with the sources
+func (m *MultiSourceStore) LoadSourceInfo() error {
+	descriptions, err := LoadDescriptions(m.BaseDir)
+	if err != nil {
+		return err
+	}
+	m.Descriptions = descriptions
+
+	hints, err := LoadHints(m.BaseDir)
+	if err != nil {
+		return err
+	}
+	m.Hints = hints
+
+	excludes, err := LoadExcludes(m.BaseDir)
+	if err != nil {
+		return err
+	}
+	m.Excludes = excludes
+	return nil
+}
+
+// Search searches across specified sources (or all if empty)
+func (m *MultiSourceStore) Search(queryEmbedding []float64, topK int, sources []string) []SearchResult {
+	return m.SearchEach(QueryEmbeddings{Default: queryEmbedding}, topK, sources)
+}
+
+// SearchEach is Search with each source searched with the embedding of the
+// question by the model it was built with. scores are normalized per source
+// before merging, so they are comparable across models
+func (m *MultiSourceStore) SearchEach(q QueryEmbeddings, topK int, sources []string) []SearchResult {
+	return m.SearchExcluding(q, topK, sources, nil)
+}
+
+// SearchExcluding is SearchEach without the chunks of files matching a
+// source's Excludes or the gitignore-style patterns of exclude, so indexed
+// noise (vendored code, fixtures) can be left out without rebuilding
+func (m *MultiSourceStore) SearchExcluding(q QueryEmbeddings, topK int, sources, exclude []string) []SearchResult {
+	var allResults []SearchResult
+
+	// if no sources specified, search all
+	if len(sources) == 0 {
+		for name := range m.Sources {
+			sources = append(sources, name)
+		}
+	}
+
+	// search each specified source
+	for _, sourceName := range sources {
+		vs, ok := m.Sources[sourceName]
+		if !ok {
+			continue
+		}
+
+		// normalize over a wider pool than we keep, so the statistics mean something
+		pool := topK
+		if len(sources) > 1 && m.Normalize != NormalizeNone && pool < normalizationPool {
+			pool = normalizationPool
+		}
+		results := vs.searchHierarchical(q.For(vs), pool, m.candidateFiles(), m.exclusion(sourceName, exclude))
+		if len(sources) > 1 {
+			normalizeScores(results, m.Normalize)
+		}
+		if len(results) > topK {
+			results = results[:topK]
+		}
+
+		// add source name to metadata (copy the map - stores may be shared between requests)
+		for i := range results {
+			metadata := make(map[string]string, len(results[i].Chunk.Metadata)+1)
+			for k, v := range results[i].Chunk.Metadata {
+				metadata[k] = v
+			}
+			metadata["vector_source"] = sourceName
+			results[i].Chunk.Metadata = metadata
+		}
+
+		allResults = append(allResults, results...)
+	}
+
+	// sort by (normalized) score and take top k
+	sort.Slice(allResults, func(i, j int) bool {
+		return allResults[i].Score > allResults[j].Score
+	})
+
+	// the same content indexed twice (e.g. vendored and standalone) shouldn't crowd the top k
+	allResults = collapseDuplicates(allResults)
+
+	if topK > len(allResults) {
+		topK = len(allResults)
+	}
+
+	return allResults[:topK]
+}
+
+// candidateFiles returns the files a hierarchical search keeps, 0 to search every chunk
+func (m *MultiSourceStore) candidateFiles() int {
+	switch {
+	case m.CandidateFiles < 0:
+		return 0
+	case m.CandidateFiles == 0:
+		return DefaultCandidateFiles
+	}
+	return m.CandidateFiles
+}
+
+// ListSources returns all available source names
+func (m *MultiSourceStore) ListSources() []string {
+	var names []string
+	for name := range m.Sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetSourceStats returns statistics about each source
+func (m *MultiSourceStore) GetSourceStats() map[string]int {
+	stats := make(map[string]int)
+	for name, vs := range m.Sources {
+		stats[name] = len(vs.Chunks)
+	}
+	return stats
+}
+
+// SourceExists checks if a source vector store file exists
+func SourceExists(baseDir, name string) bool {
+	// check multiple possible filename patterns (.lrindex and .json)
+	patterns := []string{
+		filepath.Join(baseDir, fmt.Sprintf("%s.lrindex", name)),
+		filepath.Join(baseDir, fmt.Sprintf("%s_*.lrindex", name)),
+		filepath.Join(baseDir, fmt.Sprintf("*_%s.lrindex", name)),
+		filepath.Join(baseDir, fmt.Sprintf("%s.json", name)),
+		filepath.Join(baseDir, fmt.Sprintf("%s_*.json", name)),
+		filepath.Join(baseDir, fmt.Sprintf("*_%s.json", name)),
+	}
+
+	for _, pattern := range patterns {
+		matches, _ := filepath.Glob(pattern)
+		if len(matches) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+
+	"lr/pkg/chunk"
+)
+
+func TestLoadSourcesKeepsWhatLoads(t *testing.T) {
+	dir := t.TempDir()
+	saveTestStore(t, filepath.Join(dir, "a_20260101.lrindex"), "one")
+	saveTestStore(t, filepath.Join(dir, "b_20260101.lrindex"), "two")
+	if err := os.WriteFile(filepath.Join(dir, "zz_20260101.lrindex"), []byte("garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mss := NewMultiSourceStore(dir)
+	names, err := mss.SourceNames()
+	if err != nil {
+		t.Fatalf("listing sources failed: %v", err)
+	}
+	if len(names) != 3 {
+		t.Fatalf("expected 3 sources, got %v", names)
+	}
+
+	var mu sync.Mutex
+	var ready, failed []string
+	err = mss.LoadSources(names, 2, func(name string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			failed = append(failed, name)
+		} else {
+			ready = append(ready, name)
+		}
+	})
+	if err == nil {
+		t.Fatal("expected the corrupt source to fail")
+	}
+	sort.Strings(ready)
+	if len(ready) != 2 || ready[0] != "a" || ready[1] != "b" || len(failed) != 1 || failed[0] != "zz" {
+		t.Fatalf("unexpected progress: ready %v, failed %v", ready, failed)
+	}
+	if len(mss.Sources) != 2 {
+		t.Fatalf("expected the 2 good sources to be kept, got %v", mss.ListSources())
+	}
+}
+
+func TestSearchEachEmbeddingModel(t *testing.T) {
+	local := NewVectorStore()
+	local.Metadata.EmbeddingModel = "small"
+	local.Add(chunk.Chunk{Text: "local", Source: "local.go"}, []float64{1, 0})
+	local.Add(chunk.Chunk{Text: "other", Source: "other.go"}, []float64{0, 1})
+	remote := NewVectorStore()
+	remote.Metadata.EmbeddingModel = "large"
+	remote.Add(chunk.Chunk{Text: "remote", Source: "remote.go"}, []float64{0, 0, 1})
+
+	m := NewMultiSourceStore(t.TempDir())
+	m.Sources["local"] = local
+	m.Sources["remote"] = remote
+	if got := m.EmbeddingModels(nil); len(got) != 2 || got[0] != "large" || got[1] != "small" {
+		t.Fatalf("unexpected models %v", got)
+	}
+
+	// with one embedding the other model's source can't be searched
+	q := QueryEmbeddings{Default: []float64{1, 0}}
+	if skipped := m.CheckDimensions(q, nil); len(skipped) != 1 || skipped[0].Source != "remote" {
+		t.Fatalf("expected remote to be skipped, got %v", skipped)
+	}
+
+	q.ByModel = map[string][]float64{"large": {0, 0, 1}}
+	if skipped := m.CheckDimensions(q, nil); len(skipped) != 0 {
+		t.Fatalf("expected nothing skipped, got %v", skipped)
+	}
+	sources := make(map[string]bool)
+	for _, r := range m.SearchEach(q, 3, nil) {
+		sources[r.Chunk.Source] = true
+	}
+	if !sources["local.go"] || !sources["remote.go"] {
+		t.Errorf("expected both sources' matches, got %v", sources)
+	}
+}
+
+func TestSearchExcluding(t *testing.T) {
+	dir := t.TempDir()
+	if err := SaveExcludes(dir, "a", []string{"vendor/**", "!vendor/keep.go"}); err != nil {
+		t.Fatal(err)
+	}
+	excludes, err := LoadExcludes(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vs := NewVectorStore()
+	vs.Add(chunk.Chunk{Text: "vendored", Source: "vendor/lib/x.go (part 2)"}, []float64{1, 0})
+	vs.Add(chunk.Chunk{Text: "kept", Source: "vendor/keep.go"}, []float64{0.9, 0.1})
+	vs.Add(chunk.Chunk{Text: "server", Source: "server.go"}, []float64{0.8, 0.2})
+	vs.Add(chunk.Chunk{Text: "readme", Source: "README.md"}, []float64{0.7, 0.3})
+	m := NewMultiSourceStore(dir)
+	m.Sources["a"] = vs
+	m.Excludes = excludes
+
+	search := func(exclude []string) []string {
+		var got []string
+		for _, r := range m.SearchExcluding(QueryEmbeddings{Default: []float64{1, 0}}, 10, nil, exclude) {
+			got = append(got, r.Chunk.Source)
+		}
+		return got
+	}
+	if got := search(nil); len(got) != 3 || got[0] != "vendor/keep.go" {
+		t.Errorf("expected the index's excludes applied, got %v", got)
+	}
+	if got := search([]string{"*.md"}); len(got) != 2 || got[1] != "server.go" {
+		t.Errorf("expected query excludes added to the index's, got %v", got)
+	}
+
+	// an excluded summary doesn't take a candidate's place
+	vs.Summaries = []FileSummary{
+		{File: "vendor/lib/x.go", Embedding: []float64{1, 0}},
+		{File: "server.go", Embedding: []float64{0.9, 0.1}},
+		{File: "README.md", Embedding: []float64{0, 1}},
+	}
+	m.CandidateFiles = 1
+	if got := search([]string{"vendor/**"}); len(got) != 1 || got[0] != "server.go" {
+		t.Errorf("expected server.go as the only candidate, got %v", got)
+	}
+
+	if err := SaveExcludes(dir, "a", nil); err != nil {
+		t.Fatal(err)
+	}
+	if excludes, _ := LoadExcludes(dir); len(excludes) != 0 {
+		t.Errorf("expected excludes cleared, got %v", excludes)
+	}
+}
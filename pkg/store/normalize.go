@@ -0,0 +1,73 @@
+package store
+
+import (
+	"fmt"
+	"math"
+)
+
+// ScoreNormalization selects how similarities from different sources are made
+// comparable before merging. cosine distributions differ by embedding model and
+// content type, so ranking by raw similarity favors some sources
+type ScoreNormalization string
+
+const (
+	NormalizeZScore ScoreNormalization = "zscore" // standard deviations above the source's mean (default)
+	NormalizeMinMax ScoreNormalization = "minmax" // position between the source's worst and best candidate
+	NormalizeNone   ScoreNormalization = "none"   // raw cosine similarity
+)
+
+// normalizationPool is how many candidates per source the statistics are computed over
+const normalizationPool = 50
+
+// ParseScoreNormalization validates a normalization name ("" is the default)
+func ParseScoreNormalization(s string) (ScoreNormalization, error) {
+	switch n := ScoreNormalization(s); n {
+	case "", NormalizeZScore, NormalizeMinMax, NormalizeNone:
+		return n, nil
+	}
+	return "", fmt.Errorf("unknown score normalization %q (use zscore, minmax or none)", s)
+}
+
+// normalizeScores sets Score on one source's candidates from their scores
+// (the similarities, down-weighted for noise chunks)
+func normalizeScores(results []SearchResult, method ScoreNormalization) {
+	if len(results) == 0 {
+		return
+	}
+
+	switch method {
+	case NormalizeNone:
+		return
+	case NormalizeMinMax:
+		lo, hi := results[0].Score, results[0].Score
+		for _, r := range results {
+			lo = math.Min(lo, r.Score)
+			hi = math.Max(hi, r.Score)
+		}
+		for i := range results {
+			if hi > lo {
+				results[i].Score = (results[i].Score - lo) / (hi - lo)
+			} else {
+				results[i].Score = 1
+			}
+		}
+	default:
+		var mean float64
+		for _, r := range results {
+			mean += r.Score
+		}
+		mean /= float64(len(results))
+		var variance float64
+		for _, r := range results {
+			variance += (r.Score - mean) * (r.Score - mean)
+		}
+		std := math.Sqrt(variance / float64(len(results)))
+		for i := range results {
+			if std > 0 {
+				results[i].Score = (results[i].Score - mean) / std
+			} else {
+				results[i].Score = 0
+			}
+		}
+	}
+}
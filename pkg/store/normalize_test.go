@@ -0,0 +1,38 @@
+package store
+
+import (
+	"testing"
+
+	"lr/pkg/chunk"
+)
+
+func TestSearchNormalizesAcrossSources(t *testing.T) {
+	// "flat" scores everything high (as some embedding models do) while "spread" has
+	// one clear match; raw similarity ranks all of flat's chunks first
+	flat := NewVectorStore()
+	for _, e := range [][]float64{{1, 0.30, 0}, {1, 0.32, 0}, {1, 0.34, 0}, {1, 0.36, 0}} {
+		flat.Add(chunk.Chunk{Text: "flat", Source: "flat.go"}, e)
+	}
+	spread := NewVectorStore()
+	for _, e := range [][]float64{{1, 0, 0.5}, {0, 1, 0}, {0.2, 0, 1}, {0.4, 0, 1}} {
+		spread.Add(chunk.Chunk{Text: "spread", Source: "spread.go"}, e)
+	}
+
+	m := NewMultiSourceStore(t.TempDir())
+	m.Sources["flat"] = flat
+	m.Sources["spread"] = spread
+	query := []float64{1, 0, 0}
+
+	m.Normalize = NormalizeNone
+	if got := m.Search(query, 2, nil); got[0].Chunk.Source != "flat.go" || got[1].Chunk.Source != "flat.go" {
+		t.Fatalf("expected flat's chunks first with raw scores, got %s, %s", got[0].Chunk.Source, got[1].Chunk.Source)
+	}
+
+	for _, method := range []ScoreNormalization{NormalizeZScore, NormalizeMinMax} {
+		m.Normalize = method
+		got := m.Search(query, 2, nil)
+		if got[0].Chunk.Source == got[1].Chunk.Source {
+			t.Errorf("%s: expected each source's best match on top, got %s, %s", method, got[0].Chunk.Source, got[1].Chunk.Source)
+		}
+	}
+}
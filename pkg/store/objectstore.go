@@ -0,0 +1,372 @@
+package store
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// an index directory can live in an object store bucket (s3://bucket/prefix or
+// gs://bucket/prefix), e.g. written by ci and read by every developer. lr
+// talks to it over the s3 rest api: aws (or an s3-compatible endpoint such as
+// minio or r2) with signature v4 and the AWS_* credentials, and google cloud
+// storage through its s3-compatible xml api with an oauth access token
+
+// ObjectInfo is an object of an ObjectStore, its key relative to the prefix
+type ObjectInfo struct {
+	Key  string
+	ETag string
+	Size int64
+}
+
+// ObjectStore is a bucket prefix holding an index directory
+type ObjectStore struct {
+	URL    string // as configured, e.g. s3://team-indexes/lr
+	bucket string
+	prefix string // "" or ending in "/"
+
+	endpoint  string // scheme and host requests go to
+	pathStyle bool   // the bucket is the first path segment rather than a subdomain
+	region    string
+	auth      func(req *http.Request) error
+	client    *http.Client
+}
+
+// IsObjectStoreURL reports whether s names an object store (s3:// or gs://)
+func IsObjectStoreURL(s string) bool {
+	return strings.HasPrefix(s, "s3://") || strings.HasPrefix(s, "gs://")
+}
+
+// OpenObjectStore returns the object store rawURL names, with credentials from
+// the environment: AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY (and
+// AWS_SESSION_TOKEN), AWS_REGION and AWS_ENDPOINT_URL for s3;
+// GOOGLE_OAUTH_ACCESS_TOKEN, or gcloud's, for gs
+func OpenObjectStore(rawURL string) (*ObjectStore, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" || (u.Scheme != "s3" && u.Scheme != "gs") {
+		return nil, fmt.Errorf("invalid object store url %q (use s3://bucket/prefix or gs://bucket/prefix)", rawURL)
+	}
+	o := &ObjectStore{
+		URL:    rawURL,
+		bucket: u.Host,
+		prefix: strings.Trim(u.Path, "/"),
+		client: &http.Client{Timeout: 30 * time.Minute}, // indexes can be large
+	}
+	if o.prefix != "" {
+		o.prefix += "/"
+	}
+
+	if u.Scheme == "gs" {
+		token := &accessToken{fetch: printAccessToken}
+		if env := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); env != "" {
+			token = &accessToken{token: env} // the caller's to keep fresh
+		}
+		if _, err := token.get(); err != nil {
+			return nil, fmt.Errorf("gs:// needs GOOGLE_OAUTH_ACCESS_TOKEN or a logged in gcloud: %w", err)
+		}
+		o.endpoint, o.pathStyle = "https://storage.googleapis.com", true
+		o.auth = func(req *http.Request) error {
+			t, err := token.get()
+			if err != nil {
+				return err
+			}
+			req.Header.Set("Authorization", "Bearer "+t)
+			return nil
+		}
+		return o, nil
+	}
+
+	accessKey, secretKey := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey == "" || secretKey == "" {
+		return nil, fmt.Errorf("s3:// needs AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+	}
+	o.region = os.Getenv("AWS_REGION")
+	if o.region == "" {
+		o.region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	if o.region == "" {
+		o.region = "us-east-1"
+	}
+	if endpoint := os.Getenv("AWS_ENDPOINT_URL"); endpoint != "" {
+		o.endpoint, o.pathStyle = strings.TrimRight(endpoint, "/"), true
+	} else {
+		o.endpoint = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", o.bucket, o.region)
+	}
+	sessionToken := os.Getenv("AWS_SESSION_TOKEN")
+	o.auth = func(req *http.Request) error {
+		signV4(req, accessKey, secretKey, sessionToken, o.region, time.Now().UTC())
+		return nil
+	}
+	return o, nil
+}
+
+// gcloudTokenLifetime is how long a gcloud access token is used before asking
+// gcloud again: its tokens expire after an hour, and a sync or a long running
+// lr mcp outlives that
+const gcloudTokenLifetime = 45 * time.Minute
+
+// accessToken is an oauth access token, fetched again with fetch (when set)
+// once it is gcloudTokenLifetime old
+type accessToken struct {
+	mu      sync.Mutex
+	fetch   func() (string, error)
+	token   string
+	fetched time.Time
+}
+
+func (t *accessToken) get() (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.fetch != nil && (t.token == "" || time.Since(t.fetched) >= gcloudTokenLifetime) {
+		token, err := t.fetch()
+		if err != nil {
+			return "", fmt.Errorf("failed to get a gcloud access token: %w", err)
+		}
+		t.token, t.fetched = token, time.Now()
+	}
+	return t.token, nil
+}
+
+// printAccessToken asks gcloud for an access token. gcloud caches them, and
+// hands out a new one when the cached one is about to expire
+func printAccessToken() (string, error) {
+	out, err := exec.Command("gcloud", "auth", "print-access-token").Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// objectURL returns the url of key (relative to the prefix), with query
+func (o *ObjectStore) objectURL(key string, query url.Values) string {
+	path := "/" + escapePath(o.prefix+key)
+	if o.pathStyle {
+		path = "/" + o.bucket + path
+	}
+	u := o.endpoint + path
+	if len(query) > 0 {
+		u += "?" + canonicalQuery(query)
+	}
+	return u
+}
+
+// do sends an authenticated request, returning an error for non-2xx replies
+func (o *ObjectStore) do(ctx context.Context, method, key string, query url.Values, body io.Reader, size int64) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, o.objectURL(key, query), body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.ContentLength = size
+	}
+	if err := o.auth(req); err != nil {
+		return nil, err
+	}
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", method, o.URL+"/"+key, err)
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		var s3err struct {
+			Code    string
+			Message string
+		}
+		if xml.Unmarshal(msg, &s3err) == nil && s3err.Code != "" {
+			return nil, fmt.Errorf("%s %s: %s: %s", method, o.URL+"/"+key, s3err.Code, s3err.Message)
+		}
+		return nil, fmt.Errorf("%s %s: %s", method, o.URL+"/"+key, resp.Status)
+	}
+	return resp, nil
+}
+
+// List returns the objects under the prefix
+func (o *ObjectStore) List(ctx context.Context) ([]ObjectInfo, error) {
+	var objects []ObjectInfo
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {o.prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		// the bucket root, not an object
+		req := *o
+		req.prefix = ""
+		resp, err := req.do(ctx, http.MethodGet, "", query, nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		var page struct {
+			Contents []struct {
+				Key  string
+				ETag string
+				Size int64
+			}
+			IsTruncated           bool
+			NextContinuationToken string
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("list %s: %w", o.URL, err)
+		}
+		for _, c := range page.Contents {
+			key := strings.TrimPrefix(c.Key, o.prefix)
+			if key == "" || strings.HasSuffix(key, "/") {
+				continue
+			}
+			objects = append(objects, ObjectInfo{Key: key, ETag: strings.Trim(c.ETag, `"`), Size: c.Size})
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// Get writes the content of key to w and returns its etag
+func (o *ObjectStore) Get(ctx context.Context, key string, w io.Writer) (string, error) {
+	resp, err := o.do(ctx, http.MethodGet, key, nil, nil, 0)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if _, err := io.Copy(w, resp.Body); err != nil {
+		return "", fmt.Errorf("download %s: %w", key, err)
+	}
+	return strings.Trim(resp.Header.Get("ETag"), `"`), nil
+}
+
+// Put uploads size bytes from r as key and returns its etag
+func (o *ObjectStore) Put(ctx context.Context, key string, r io.Reader, size int64) (string, error) {
+	resp, err := o.do(ctx, http.MethodPut, key, nil, r, size)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	return strings.Trim(resp.Header.Get("ETag"), `"`), nil
+}
+
+// Delete removes key
+func (o *ObjectStore) Delete(ctx context.Context, key string) error {
+	resp, err := o.do(ctx, http.MethodDelete, key, nil, nil, 0)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// signV4 signs an s3 request with aws signature version 4. the payload isn't
+// hashed (UNSIGNED-PAYLOAD), so large uploads stream from disk
+func signV4(req *http.Request, accessKey, secretKey, sessionToken, region string, now time.Time) {
+	const payloadHash = "UNSIGNED-PAYLOAD"
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if sessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", sessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		if lower := strings.ToLower(name); strings.HasPrefix(lower, "x-amz-") {
+			headers[lower] = strings.TrimSpace(req.Header.Get(name))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hexSHA256(canonicalRequest)
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+// canonicalQuery encodes a query string the way signature v4 expects: sorted,
+// with every reserved character escaped
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		values := append([]string(nil), query[k]...)
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, escapeS3(k, true)+"="+escapeS3(v, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// escapePath escapes an object key for a url path, keeping its slashes
+func escapePath(key string) string {
+	return escapeS3(key, false)
+}
+
+// escapeS3 percent-encodes everything but the unreserved characters (and "/"
+// unless encodeSlash)
+func escapeS3(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || (c == '/' && !encodeSlash) {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func hexSHA256(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
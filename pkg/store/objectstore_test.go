@@ -0,0 +1,171 @@
+package store
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeS3 is a path-style s3 bucket in memory, enough for List/Get/Put/Delete
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=test/") {
+		http.Error(w, "unsigned", http.StatusForbidden)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	key := strings.TrimPrefix(r.URL.Path, "/bucket/")
+	etag := func(data []byte) string {
+		sum := md5.Sum(data)
+		return `"` + hex.EncodeToString(sum[:]) + `"`
+	}
+	switch {
+	case r.Method == http.MethodGet && r.URL.Query().Get("list-type") == "2":
+		prefix := r.URL.Query().Get("prefix")
+		var keys []string
+		for k := range f.objects {
+			if strings.HasPrefix(k, prefix) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		fmt.Fprint(w, "<ListBucketResult>")
+		for _, k := range keys {
+			fmt.Fprintf(w, "<Contents><Key>%s</Key><ETag>%s</ETag><Size>%d</Size></Contents>", k, etag(f.objects[k]), len(f.objects[k]))
+		}
+		fmt.Fprint(w, "<IsTruncated>false</IsTruncated></ListBucketResult>")
+	case r.Method == http.MethodGet:
+		data, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "<Error><Code>NoSuchKey</Code><Message>not found</Message></Error>")
+			return
+		}
+		w.Header().Set("ETag", etag(data))
+		w.Write(data)
+	case r.Method == http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		f.objects[key] = data
+		w.Header().Set("ETag", etag(data))
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func TestObjectStoreSync(t *testing.T) {
+	bucket := &fakeS3{objects: map[string][]byte{}}
+	server := httptest.NewServer(bucket)
+	defer server.Close()
+	t.Setenv("AWS_ACCESS_KEY_ID", "test")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_ENDPOINT_URL", server.URL)
+
+	o, err := OpenObjectStore("s3://bucket/lr")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// ci builds an index and pushes it; lock and temp files stay local
+	ci := t.TempDir()
+	os.WriteFile(filepath.Join(ci, "docs.lrindex"), []byte("v1"), 0644)
+	os.WriteFile(filepath.Join(ci, "docs.lock"), []byte("1"), 0644)
+	os.WriteFile(filepath.Join(ci, "docs.tmp.lrindex"), []byte("partial"), 0644)
+	os.MkdirAll(filepath.Join(ci, "archive"), 0755)
+	os.WriteFile(filepath.Join(ci, "archive", "docs_20250101.lrindex"), []byte("v0"), 0644)
+	if stats, err := o.Push(ctx, ci); err != nil || stats.Transferred != 2 {
+		t.Fatalf("push: %+v %v", stats, err)
+	}
+	if len(bucket.objects) != 2 || bucket.objects["lr/archive/docs_20250101.lrindex"] == nil {
+		t.Fatalf("unexpected objects after push: %v", bucket.objects)
+	}
+	if stats, _ := o.Push(ctx, ci); stats.Transferred != 0 {
+		t.Errorf("expected nothing to push when unchanged, got %+v", stats)
+	}
+
+	// a developer pulls it, then pulls again with nothing new
+	dev := t.TempDir()
+	if stats, err := o.Pull(ctx, dev); err != nil || stats.Transferred != 2 {
+		t.Fatalf("pull: %+v %v", stats, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dev, "docs.lrindex")); string(data) != "v1" {
+		t.Fatalf("expected pulled index, got %q", data)
+	}
+	if stats, _ := o.Pull(ctx, dev); stats.Transferred != 0 {
+		t.Errorf("expected cached files kept when etags match, got %+v", stats)
+	}
+
+	// ci rebuilds one index and removes the archive: the etag changes
+	os.WriteFile(filepath.Join(ci, "docs.lrindex"), []byte("v2 rebuilt"), 0644)
+	os.RemoveAll(filepath.Join(ci, "archive"))
+	if stats, err := o.Push(ctx, ci); err != nil || stats.Transferred != 1 || stats.Deleted != 1 {
+		t.Fatalf("push after rebuild: %+v %v", stats, err)
+	}
+	if stats, err := o.Pull(ctx, dev); err != nil || stats.Transferred != 1 || stats.Deleted != 1 {
+		t.Fatalf("pull after rebuild: %+v %v", stats, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dev, "docs.lrindex")); string(data) != "v2 rebuilt" {
+		t.Errorf("expected the rebuilt index, got %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(dev, "archive", "docs_20250101.lrindex")); !os.IsNotExist(err) {
+		t.Errorf("expected the removed object deleted locally")
+	}
+}
+
+func TestObjectStorePullRejectsEscapingKeys(t *testing.T) {
+	for _, key := range []string{"lr/../escaped.lrindex", "lr/archive/../../escaped.lrindex", "lr//tmp/escaped.lrindex"} {
+		bucket := &fakeS3{objects: map[string][]byte{key: []byte("planted")}}
+		server := httptest.NewServer(bucket)
+		t.Setenv("AWS_ACCESS_KEY_ID", "test")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+		t.Setenv("AWS_ENDPOINT_URL", server.URL)
+		o, err := OpenObjectStore("s3://bucket/lr")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		parent := t.TempDir()
+		dev := filepath.Join(parent, "dev")
+		if _, err := o.Pull(context.Background(), dev); err == nil || !strings.Contains(err.Error(), "outside the index directory") {
+			t.Errorf("pull of %q: expected the key refused, got %v", key, err)
+		}
+		if _, err := os.Stat(filepath.Join(parent, "escaped.lrindex")); !os.IsNotExist(err) {
+			t.Errorf("pull of %q wrote outside the index directory", key)
+		}
+		server.Close()
+	}
+}
+
+func TestAccessTokenRefresh(t *testing.T) {
+	fetches := 0
+	token := &accessToken{fetch: func() (string, error) {
+		fetches++
+		return fmt.Sprintf("token-%d", fetches), nil
+	}}
+	if got, _ := token.get(); got != "token-1" {
+		t.Fatalf("first token = %q", got)
+	}
+	if got, _ := token.get(); got != "token-1" || fetches != 1 {
+		t.Fatalf("expected the token reused while fresh, got %q after %d fetches", got, fetches)
+	}
+	token.fetched = token.fetched.Add(-gcloudTokenLifetime)
+	if got, _ := token.get(); got != "token-2" {
+		t.Errorf("expected a new token once expired, got %q", got)
+	}
+}
@@ -0,0 +1,252 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ObjectManifestFile records, in a local cache of an object store, the etag
+// of every object as last synced and the size and mtime of its local copy, so
+// Pull only downloads what changed remotely and Push only uploads what changed
+// locally
+const ObjectManifestFile = "objectstore.lrmeta"
+
+// syncedObject is a manifest entry
+type syncedObject struct {
+	ETag    string    `json:"etag"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+}
+
+// SyncStats counts what a Pull or Push transferred
+type SyncStats struct {
+	Transferred int
+	Deleted     int
+}
+
+// Pull brings dir up to date with the store: objects whose etag differs from
+// the manifest (or whose local copy is missing) are downloaded, and local
+// files whose object is gone are removed unless they were changed locally
+// since the last sync. local changes to objects that didn't change remotely
+// are kept for the next Push
+func (o *ObjectStore) Pull(ctx context.Context, dir string) (SyncStats, error) {
+	var stats SyncStats
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return stats, err
+	}
+	manifest, err := loadObjectManifest(dir)
+	if err != nil {
+		return stats, err
+	}
+	objects, err := o.List(ctx)
+	if err != nil {
+		return stats, err
+	}
+
+	remote := make(map[string]bool, len(objects))
+	for _, obj := range objects {
+		if skipSync(obj.Key) {
+			continue
+		}
+		local, err := localPath(dir, obj.Key)
+		if err != nil {
+			saveObjectManifest(dir, manifest)
+			return stats, fmt.Errorf("refusing to pull %s: %w", o.URL, err)
+		}
+		remote[obj.Key] = true
+		if synced, ok := manifest[obj.Key]; ok && synced.ETag == obj.ETag {
+			if _, err := os.Stat(local); err == nil {
+				continue
+			}
+		}
+		entry, err := o.download(ctx, obj, local)
+		if err != nil {
+			saveObjectManifest(dir, manifest) // keep what did sync
+			return stats, err
+		}
+		manifest[obj.Key] = entry
+		stats.Transferred++
+	}
+
+	for key, synced := range manifest {
+		if remote[key] {
+			continue
+		}
+		local, err := localPath(dir, key)
+		if err == nil && unchanged(local, synced) {
+			os.Remove(local)
+			stats.Deleted++
+		}
+		delete(manifest, key)
+	}
+	return stats, saveObjectManifest(dir, manifest)
+}
+
+// download writes obj to local through a temp file, so readers never see a
+// partial index
+func (o *ObjectStore) download(ctx context.Context, obj ObjectInfo, local string) (syncedObject, error) {
+	if err := os.MkdirAll(filepath.Dir(local), 0755); err != nil {
+		return syncedObject{}, err
+	}
+	tmp := local + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return syncedObject{}, err
+	}
+	etag, err := o.Get(ctx, obj.Key, f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, local)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return syncedObject{}, fmt.Errorf("failed to download %s: %w", obj.Key, err)
+	}
+	if etag == "" {
+		etag = obj.ETag
+	}
+	info, err := os.Stat(local)
+	if err != nil {
+		return syncedObject{}, err
+	}
+	return syncedObject{ETag: etag, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+// Push uploads the files in dir that are new or changed since the last sync,
+// and deletes the objects of synced files that were removed locally. lock,
+// checkpoint, temp and backup files stay local
+func (o *ObjectStore) Push(ctx context.Context, dir string) (SyncStats, error) {
+	var stats SyncStats
+	manifest, err := loadObjectManifest(dir)
+	if err != nil {
+		return stats, err
+	}
+
+	local := make(map[string]bool)
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		key := filepath.ToSlash(rel)
+		if skipSync(key) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		local[key] = true
+		if synced, ok := manifest[key]; ok && unchanged(path, synced) {
+			return nil
+		}
+		entry, err := o.upload(ctx, key, path)
+		if err != nil {
+			return err
+		}
+		manifest[key] = entry
+		stats.Transferred++
+		return nil
+	})
+	if err != nil {
+		saveObjectManifest(dir, manifest)
+		return stats, err
+	}
+
+	for key := range manifest {
+		if local[key] {
+			continue
+		}
+		if err := o.Delete(ctx, key); err != nil {
+			saveObjectManifest(dir, manifest)
+			return stats, err
+		}
+		delete(manifest, key)
+		stats.Deleted++
+	}
+	return stats, saveObjectManifest(dir, manifest)
+}
+
+// upload puts the file at path as key
+func (o *ObjectStore) upload(ctx context.Context, key, path string) (syncedObject, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return syncedObject{}, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return syncedObject{}, err
+	}
+	etag, err := o.Put(ctx, key, f, info.Size())
+	if err != nil {
+		return syncedObject{}, fmt.Errorf("failed to upload %s: %w", key, err)
+	}
+	return syncedObject{ETag: etag, Size: info.Size(), ModTime: info.ModTime()}, nil
+}
+
+// localPath returns where key is synced to in dir. keys come from the bucket,
+// so one that is absolute or climbs out with ".." (which anyone who can write
+// to the bucket could add) is an error rather than a path outside dir
+func localPath(dir, key string) (string, error) {
+	if !filepath.IsLocal(filepath.FromSlash(key)) {
+		return "", fmt.Errorf("object key %q is outside the index directory", key)
+	}
+	return filepath.Join(dir, filepath.FromSlash(key)), nil
+}
+
+// skipSync reports whether a key (a slash separated path relative to the
+// index directory) is local state that isn't synced
+func skipSync(key string) bool {
+	if key == ObjectManifestFile {
+		return true
+	}
+	for _, part := range strings.Split(key, "/") {
+		if strings.HasPrefix(part, "backup_") {
+			return true
+		}
+	}
+	return strings.HasSuffix(key, ".lock") || IsPartialIndexFile(key)
+}
+
+// unchanged reports whether the file at path is still as synced
+func unchanged(path string, synced syncedObject) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Size() == synced.Size && info.ModTime().Equal(synced.ModTime)
+}
+
+func loadObjectManifest(dir string) (map[string]syncedObject, error) {
+	manifest := make(map[string]syncedObject)
+	data, err := os.ReadFile(filepath.Join(dir, ObjectManifestFile))
+	if os.IsNotExist(err) {
+		return manifest, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", ObjectManifestFile, err)
+	}
+	return manifest, nil
+}
+
+func saveObjectManifest(dir string, manifest map[string]syncedObject) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, ObjectManifestFile), data, 0644)
+}
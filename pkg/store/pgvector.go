@@ -0,0 +1,309 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+
+	"lr/internal/errkind"
+	"lr/pkg/chunk"
+)
+
+// PGVectorStore keeps indexes in postgres with the pgvector extension, for
+// teams that want one shared, concurrently queried copy, sql-side filtering
+// and indexes bigger than memory. chunks of every index live in one table;
+// an hnsw index per embedding dimension keeps searches fast
+type PGVectorStore struct {
+	pool *pgxpool.Pool
+	// Where, if set, is an sql condition every search adds, over the columns
+	// index_name, source, text and metadata (jsonb), e.g.
+	// "metadata->>'type' = 'go' and source not like 'vendor/%'"
+	Where string
+}
+
+// pgInsertBatch is how many chunk inserts are sent to the server at once
+const pgInsertBatch = 200
+
+// pgHNSWMaxDims is the most dimensions pgvector can build an hnsw index over
+const pgHNSWMaxDims = 2000
+
+// pgSchema creates the tables. lr_chunks.chunk holds the whole chunk as json
+// so nothing is lost in the round trip; source, text and metadata are copies
+// to filter on
+const pgSchema = `
+CREATE EXTENSION IF NOT EXISTS vector;
+CREATE TABLE IF NOT EXISTS lr_indexes (
+	name text PRIMARY KEY,
+	metadata jsonb NOT NULL,
+	dimensions int NOT NULL,
+	updated_at timestamptz NOT NULL DEFAULT now()
+);
+CREATE TABLE IF NOT EXISTS lr_chunks (
+	index_name text NOT NULL REFERENCES lr_indexes(name) ON DELETE CASCADE,
+	ord int NOT NULL,
+	source text NOT NULL,
+	text text NOT NULL,
+	metadata jsonb NOT NULL,
+	chunk jsonb NOT NULL,
+	embedding vector NOT NULL,
+	PRIMARY KEY (index_name, ord)
+);
+CREATE INDEX IF NOT EXISTS lr_chunks_source ON lr_chunks (index_name, source);
+`
+
+// OpenPGVectorStore connects to the postgres database dsn names (a
+// postgres:// url or libpq keyword string) and creates lr's tables if needed
+func OpenPGVectorStore(ctx context.Context, dsn string) (*PGVectorStore, error) {
+	cfg, err := pgxpool.ParseConfig(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid postgres dsn: %w", err)
+	}
+	if cfg.ConnConfig.ConnectTimeout == 0 {
+		cfg.ConnConfig.ConnectTimeout = pgConnectTimeout
+	}
+	if cfg.ConnConfig.RuntimeParams["application_name"] == "" {
+		cfg.ConnConfig.RuntimeParams["application_name"] = "lr"
+	}
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	p := &PGVectorStore{pool: pool}
+	if _, err := pool.Exec(ctx, pgSchema); err != nil {
+		p.Close()
+		return nil, fmt.Errorf("failed to set up pgvector tables: %w", err)
+	}
+	return p, nil
+}
+
+// pgConnectTimeout bounds connecting when the dsn has no connect_timeout
+const pgConnectTimeout = 10 * time.Second
+
+// Close closes the connections
+func (p *PGVectorStore) Close() error {
+	p.pool.Close()
+	return nil
+}
+
+// SaveIndex replaces the index called name with vs, in one transaction
+func (p *PGVectorStore) SaveIndex(ctx context.Context, name string, vs *VectorStore) error {
+	tx, err := p.pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to save index %s: %w", name, err)
+	}
+	defer tx.Rollback(ctx) // a no-op once committed
+	if err := saveIndex(ctx, tx, name, vs); err != nil {
+		return fmt.Errorf("failed to save index %s: %w", name, err)
+	}
+	return tx.Commit(ctx)
+}
+
+// pgInsertChunk inserts a chunk; its embedding is a pgvector literal
+const pgInsertChunk = `INSERT INTO lr_chunks (index_name, ord, source, text, metadata, chunk, embedding)
+VALUES ($1, $2, $3, $4, $5, $6, $7::vector)`
+
+// saveIndex writes vs in tx
+func saveIndex(ctx context.Context, tx pgx.Tx, name string, vs *VectorStore) error {
+	dims := vs.Dimensions()
+	metadata, err := json.Marshal(vs.Metadata)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, "DELETE FROM lr_indexes WHERE name = $1", name); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, "INSERT INTO lr_indexes (name, metadata, dimensions) VALUES ($1, $2, $3)", name, string(metadata), dims); err != nil {
+		return err
+	}
+
+	// batches keep round trips down; they're all in the one transaction
+	for start := 0; start < len(vs.Chunks); start += pgInsertBatch {
+		batch := &pgx.Batch{}
+		for i := start; i < min(start+pgInsertBatch, len(vs.Chunks)); i++ {
+			c := vs.Chunks[i]
+			chunkJSON, err := json.Marshal(c)
+			if err != nil {
+				return err
+			}
+			meta := c.Metadata
+			if meta == nil {
+				meta = map[string]string{}
+			}
+			metaJSON, err := json.Marshal(meta)
+			if err != nil {
+				return err
+			}
+			batch.Queue(pgInsertChunk, name, i, c.Source, c.Text, string(metaJSON), string(chunkJSON), pgVector(vs.Embeddings[i]))
+		}
+		if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+			return err
+		}
+	}
+
+	// hnsw indexes need a fixed dimension, so there's one partial index per
+	// dimension in use (pgvector indexes up to pgHNSWMaxDims; larger ones are scanned)
+	if dims > 0 && dims <= pgHNSWMaxDims {
+		sql := fmt.Sprintf("CREATE INDEX IF NOT EXISTS lr_chunks_hnsw_%d ON lr_chunks USING hnsw ((embedding::vector(%d)) vector_cosine_ops) WHERE vector_dims(embedding) = %d", dims, dims, dims)
+		if _, err := tx.Exec(ctx, sql); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DropIndex removes the index called name, reporting whether it existed
+func (p *PGVectorStore) DropIndex(ctx context.Context, name string) (bool, error) {
+	tag, err := p.pool.Exec(ctx, "DELETE FROM lr_indexes WHERE name = $1", name)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// PGIndexInfo describes an index stored in postgres
+type PGIndexInfo struct {
+	Name       string
+	Chunks     int
+	Dimensions int
+	UpdatedAt  time.Time
+	Metadata   VectorStoreMetadata
+}
+
+// ListIndexes returns the indexes in the database, by name
+func (p *PGVectorStore) ListIndexes(ctx context.Context) ([]PGIndexInfo, error) {
+	rows, err := p.pool.Query(ctx, `SELECT i.name, i.dimensions, i.metadata, i.updated_at,
+		(SELECT count(*) FROM lr_chunks c WHERE c.index_name = i.name)
+		FROM lr_indexes i ORDER BY i.name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var infos []PGIndexInfo
+	for rows.Next() {
+		var info PGIndexInfo
+		var metadata []byte
+		if err := rows.Scan(&info.Name, &info.Dimensions, &metadata, &info.UpdatedAt, &info.Chunks); err != nil {
+			return nil, err
+		}
+		json.Unmarshal(metadata, &info.Metadata)
+		info.UpdatedAt = info.UpdatedAt.UTC()
+		infos = append(infos, info)
+	}
+	return infos, rows.Err()
+}
+
+// Search returns the topK chunks most similar to queryEmbedding in the named
+// indexes (all if none), narrowed by Where. indexes with other dimensions
+// can't match and are skipped, as MultiSourceStore.CheckDimensions does in memory
+func (p *PGVectorStore) Search(ctx context.Context, queryEmbedding []float64, topK int, sources []string) ([]SearchResult, error) {
+	if len(queryEmbedding) == 0 || topK <= 0 {
+		return nil, nil
+	}
+	sql, args := pgSearchQuery(queryEmbedding, topK, sources, p.Where)
+
+	// Where is sql of the caller's, so the search runs in a transaction that
+	// is read-only before it starts, as one prepared statement: the condition
+	// can read what the dsn's user can, but not write, nor end the
+	// transaction to run statements of its own
+	tx, err := p.pool.BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return nil, fmt.Errorf("pgvector search failed: %w", err)
+	}
+	defer tx.Rollback(ctx)
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, fmt.Errorf("pgvector search failed: %w", err)
+	}
+	defer rows.Close()
+
+	var results []SearchResult
+	for rows.Next() {
+		var indexName string
+		var chunkJSON []byte
+		var similarity float64
+		if err := rows.Scan(&indexName, &chunkJSON, &similarity); err != nil {
+			return nil, fmt.Errorf("pgvector search failed: %w", err)
+		}
+		var c chunk.Chunk
+		if err := json.Unmarshal(chunkJSON, &c); err != nil {
+			return nil, fmt.Errorf("pgvector search: bad chunk in %s: %w", indexName, err)
+		}
+		if c.Metadata == nil {
+			c.Metadata = map[string]string{}
+		}
+		c.Metadata["vector_source"] = indexName
+		results = append(results, SearchResult{Chunk: c, Similarity: similarity, Score: similarity})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("pgvector search failed: %w", err)
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Score > results[j].Score })
+	return collapseDuplicates(results), nil
+}
+
+// pgSearchQuery returns the search statement and its parameters: the
+// embedding, topK and sources are bound, where is added as written
+func pgSearchQuery(queryEmbedding []float64, topK int, sources []string, where string) (string, []any) {
+	dims := len(queryEmbedding)
+	args := []any{pgVector(queryEmbedding), topK}
+	distance := fmt.Sprintf("embedding::vector(%d) <=> $1::vector(%d)", dims, dims)
+
+	conditions := []string{fmt.Sprintf("vector_dims(embedding) = %d", dims)}
+	if len(sources) > 0 {
+		args = append(args, sources)
+		conditions = append(conditions, fmt.Sprintf("index_name = ANY($%d)", len(args)))
+	}
+	if where != "" {
+		conditions = append(conditions, "("+where+")")
+	}
+	sql := fmt.Sprintf("SELECT index_name, chunk, 1 - (%s) FROM lr_chunks WHERE %s ORDER BY %s LIMIT $2",
+		distance, strings.Join(conditions, " AND "), distance)
+	return sql, args
+}
+
+// CheckSources returns an ErrNoIndex error naming the sources the database
+// doesn't have
+func (p *PGVectorStore) CheckSources(ctx context.Context, sources []string) error {
+	infos, err := p.ListIndexes(ctx)
+	if err != nil {
+		return err
+	}
+	have := make(map[string]bool, len(infos))
+	for _, info := range infos {
+		have[info.Name] = true
+	}
+	var missing []string
+	for _, s := range sources {
+		if !have[s] {
+			missing = append(missing, s)
+		}
+	}
+	if len(infos) == 0 {
+		return errkind.With(fmt.Errorf("no indexes in postgres"), ErrNoIndex)
+	}
+	if len(missing) > 0 {
+		return errkind.With(fmt.Errorf("no index in postgres for %s", strings.Join(missing, ", ")), ErrNoIndex)
+	}
+	return nil
+}
+
+// pgVector formats an embedding as a pgvector literal
+func pgVector(v []float64) string {
+	var b strings.Builder
+	b.WriteByte('[')
+	for i, x := range v {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(strconv.FormatFloat(x, 'g', -1, 32))
+	}
+	b.WriteByte(']')
+	return b.String()
+}
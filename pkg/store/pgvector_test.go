@@ -0,0 +1,20 @@
+package store
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPGSearchQuery(t *testing.T) {
+	sources := []string{"docs", "it's; DROP TABLE lr_chunks"}
+	sql, args := pgSearchQuery([]float64{0.5, 0.25}, 5, sources, "source like 'pkg/%'")
+	if strings.Contains(sql, "DROP") || strings.Contains(sql, "0.5") {
+		t.Errorf("values interpolated into the statement: %s", sql)
+	}
+	if !strings.Contains(sql, "index_name = ANY($3)") || !strings.Contains(sql, "(source like 'pkg/%')") || !strings.Contains(sql, "LIMIT $2") {
+		t.Errorf("unexpected statement: %s", sql)
+	}
+	if len(args) != 3 || args[0] != "[0.5,0.25]" || args[1] != 5 {
+		t.Errorf("unexpected parameters: %v", args)
+	}
+}
@@ -0,0 +1,41 @@
+package store
+
+import "sort"
+
+// QueryEmbeddings is a question embedded with each embedding model among the
+// sources searched, so sources built with different models (e.g. one with
+// ollama, another with voyage) can be searched together. ByModel is keyed by
+// the model recorded in the index; sources whose model has no embedding there
+// use Default
+type QueryEmbeddings struct {
+	Default []float64
+	ByModel map[string][]float64
+}
+
+// For returns the embedding to search vs with
+func (q QueryEmbeddings) For(vs *VectorStore) []float64 {
+	if e, ok := q.ByModel[vs.Metadata.EmbeddingModel]; ok {
+		return e
+	}
+	return q.Default
+}
+
+// EmbeddingModels returns the embedding models recorded in the given sources
+// (or all loaded sources if none are given), each once
+func (m *MultiSourceStore) EmbeddingModels(sources []string) []string {
+	if len(sources) == 0 {
+		sources = m.ListSources()
+	}
+	seen := make(map[string]bool)
+	var models []string
+	for _, name := range sources {
+		vs, ok := m.Sources[name]
+		if !ok || vs.Metadata.EmbeddingModel == "" || seen[vs.Metadata.EmbeddingModel] {
+			continue
+		}
+		seen[vs.Metadata.EmbeddingModel] = true
+		models = append(models, vs.Metadata.EmbeddingModel)
+	}
+	sort.Strings(models)
+	return models
+}
@@ -0,0 +1,128 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+
+	"lr/pkg/chunk"
+)
+
+// "who calls this?" is poorly served by similarity: the callers of a function
+// rarely read like it. ExpandRefs follows the call graph recorded at index
+// time (see chunk.DefinesMetadataKey) from the retrieved chunks to the chunks
+// that call the functions they define, or define the functions they call.
+// the graph is by name, so methods of different types sharing a name are
+// linked too
+
+// RefDirection selects which side of the call graph ExpandRefs adds
+type RefDirection string
+
+const (
+	RefsNone    RefDirection = ""        // no call graph expansion
+	RefsCallers RefDirection = "callers" // chunks calling the retrieved functions
+	RefsCallees RefDirection = "callees" // chunks defining the functions the retrieved chunks call
+	RefsBoth    RefDirection = "both"
+)
+
+// RefsPerResult bounds the callers (and the callees) added for each result
+const RefsPerResult = 5
+
+// ParseRefDirection validates a call graph direction ("" is none)
+func ParseRefDirection(s string) (RefDirection, error) {
+	switch d := RefDirection(s); d {
+	case RefsNone, RefsCallers, RefsCallees, RefsBoth:
+		return d, nil
+	}
+	return "", fmt.Errorf("unknown refs direction %q (use callers, callees or both)", s)
+}
+
+// ExpandRefs appends to results, for each result, up to RefsPerResult chunks
+// calling the functions it defines and up to RefsPerResult defining the
+// functions it calls, the most similar to the query first, as dir selects.
+// they are marked with chunk.CallerOfMetadataKey or chunk.CalleeOfMetadataKey
+func (vs *VectorStore) ExpandRefs(queryEmbedding []float64, results []SearchResult, dir RefDirection) []SearchResult {
+	return expandRefs(QueryEmbeddings{Default: queryEmbedding}, results, dir, func(SearchResult) (*VectorStore, string) {
+		return vs, ""
+	})
+}
+
+// ExpandRefs is VectorStore.ExpandRefs, each result's references looked up in
+// its own source
+func (m *MultiSourceStore) ExpandRefs(q QueryEmbeddings, results []SearchResult, dir RefDirection) []SearchResult {
+	return expandRefs(q, results, dir, func(r SearchResult) (*VectorStore, string) {
+		source := r.Chunk.Metadata["vector_source"]
+		return m.Sources[source], source
+	})
+}
+
+// expandRefs implements ExpandRefs, storeOf returning the store a result was
+// found in and its source name
+func expandRefs(q QueryEmbeddings, results []SearchResult, dir RefDirection, storeOf func(SearchResult) (*VectorStore, string)) []SearchResult {
+	if dir == RefsNone {
+		return results
+	}
+	seen := make(map[string]bool, len(results))
+	for _, r := range results {
+		seen[linkKey(r.Chunk)] = true
+	}
+
+	expanded := results
+	for _, r := range results {
+		vs, source := storeOf(r)
+		if vs == nil {
+			continue
+		}
+		defined := refNames(chunk.Defines(r.Chunk))
+		what := strings.Join(defined, ", ")
+		if what == "" {
+			what = r.Chunk.Source
+		}
+
+		if len(defined) > 0 && (dir == RefsCallers || dir == RefsBoth) {
+			callers := vs.search(q.For(vs), RefsPerResult, func(c chunk.Chunk) bool {
+				return !seen[linkKey(c)] && shareName(chunk.Calls(c), defined)
+			})
+			markSeen(seen, callers)
+			expanded = appendLinked(expanded, callers, chunk.CallerOfMetadataKey, what, source)
+		}
+		if calls := refNames(chunk.Calls(r.Chunk)); len(calls) > 0 && (dir == RefsCallees || dir == RefsBoth) {
+			callees := vs.search(q.For(vs), RefsPerResult, func(c chunk.Chunk) bool {
+				return !seen[linkKey(c)] && shareName(chunk.Defines(c), calls)
+			})
+			markSeen(seen, callees)
+			expanded = appendLinked(expanded, callees, chunk.CalleeOfMetadataKey, what, source)
+		}
+	}
+	return expanded
+}
+
+// refNames leaves out the names too short or too common (entry points) to
+// link by
+func refNames(names []string) []string {
+	var kept []string
+	for _, name := range names {
+		if len(name) >= 3 && name != "main" && name != "init" {
+			kept = append(kept, name)
+		}
+	}
+	return kept
+}
+
+// shareName reports whether a and b have a name in common
+func shareName(a, b []string) bool {
+	for _, x := range a {
+		for _, y := range b {
+			if x == y {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// markSeen records the results as added
+func markSeen(seen map[string]bool, results []SearchResult) {
+	for _, r := range results {
+		seen[linkKey(r.Chunk)] = true
+	}
+}
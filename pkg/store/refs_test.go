@@ -0,0 +1,38 @@
+package store
+
+import (
+	"testing"
+
+	"lr/pkg/chunk"
+)
+
+func TestExpandRefs(t *testing.T) {
+	fn := func(source, defines, calls string) chunk.Chunk {
+		return chunk.Chunk{Text: source + defines, Source: source, Metadata: map[string]string{
+			chunk.DefinesMetadataKey: defines,
+			chunk.CallsMetadataKey:   calls,
+		}}
+	}
+	vs := NewVectorStore()
+	vs.Add(fn("auth.go", "Authenticate", "checkToken,Errorf"), []float64{1, 0})
+	vs.Add(fn("token.go", "checkToken", "Parse"), []float64{0, 1})
+	vs.Add(fn("server.go", "handleConnect", "Authenticate"), []float64{0.1, 1})
+	vs.Add(fn("client.go", "Connect", "dial"), []float64{0.2, 1})
+
+	results := vs.Search([]float64{1, 0}, 1)
+	callers := vs.ExpandRefs([]float64{1, 0}, results, RefsCallers)
+	if len(callers) != 2 || callers[1].Chunk.Source != "server.go" {
+		t.Fatalf("expected server.go added as a caller, got %v", sources(callers))
+	}
+	if got := callers[1].Chunk.Metadata[chunk.CallerOfMetadataKey]; got != "Authenticate" {
+		t.Errorf("expected the caller marked as calling Authenticate, got %q", got)
+	}
+
+	both := vs.ExpandRefs([]float64{1, 0}, results, RefsBoth)
+	if len(both) != 3 || both[2].Chunk.Source != "token.go" {
+		t.Errorf("expected server.go and token.go added, got %v", sources(both))
+	}
+	if got := vs.ExpandRefs([]float64{1, 0}, results, RefsNone); len(got) != 1 {
+		t.Errorf("expected no expansion, got %v", sources(got))
+	}
+}
@@ -0,0 +1,210 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"lr/internal/errkind"
+	"lr/pkg/chunk"
+)
+
+// segmentExt is the extension of segment files, kept distinct from .lrindex so
+// index globs never pick them up
+const segmentExt = ".lrseg"
+
+// Segment is one update to an index, stored in its own small file next to the
+// index (name.000001.lrseg) instead of rewriting the whole index. applying it
+// replaces the chunks of Sources with Chunks; a source with no new chunks was
+// deleted
+type Segment struct {
+	Seq        int           `json:"seq"`
+	WrittenAt  string        `json:"written_at"`
+	Sources    []string      `json:"sources"`
+	Chunks     []chunk.Chunk `json:"chunks"`
+	Embeddings [][]float64   `json:"embeddings"`
+}
+
+// Add adds a chunk and its embedding to the segment
+func (s *Segment) Add(c chunk.Chunk, embedding []float64) {
+	s.Chunks = append(s.Chunks, c)
+	s.Embeddings = append(s.Embeddings, embedding)
+}
+
+// ApplySegment applies seg to the store and records it as the last segment applied
+func (vs *VectorStore) ApplySegment(seg *Segment) {
+	vs.RemoveBySource(seg.Sources)
+	for i, c := range seg.Chunks {
+		vs.Add(c, seg.Embeddings[i])
+	}
+	vs.Metadata.SegmentSeq = seg.Seq
+	vs.Metadata.IndexedAt = seg.WrittenAt
+	vs.Metadata.ChunkCount = len(vs.Chunks)
+}
+
+// SegmentPath returns the path of segment seq of the index at indexPath
+func SegmentPath(indexPath string, seq int) string {
+	return fmt.Sprintf("%s.%06d%s", strings.TrimSuffix(indexPath, ".lrindex"), seq, segmentExt)
+}
+
+// SegmentPaths returns the segment files of the index at indexPath, oldest first
+func SegmentPaths(indexPath string) ([]string, error) {
+	prefix := strings.TrimSuffix(indexPath, ".lrindex") + "."
+	matches, err := filepath.Glob(prefix + "*" + segmentExt)
+	if err != nil {
+		return nil, err
+	}
+	seqs := make(map[string]int)
+	var paths []string
+	for _, m := range matches {
+		if seq, ok := segmentSeq(indexPath, m); ok {
+			seqs[m] = seq
+			paths = append(paths, m)
+		}
+	}
+	sort.Slice(paths, func(i, j int) bool { return seqs[paths[i]] < seqs[paths[j]] })
+	return paths, nil
+}
+
+// AppendSegment writes seg as the next segment of the index at indexPath and
+// applies it to vs, the index's current contents. the file appears atomically,
+// so readers see all of an update or none of it. vs is updated even if the write
+// fails; the next successful segment or compaction persists the change
+func AppendSegment(vs *VectorStore, indexPath string, seg *Segment) error {
+	seg.Seq = vs.Metadata.SegmentSeq + 1
+	seg.WrittenAt = time.Now().Format(time.RFC3339)
+	vs.ApplySegment(seg)
+
+	path := SegmentPath(indexPath, seg.Seq)
+	tempPath := path + ".tmp"
+	if err := writeSegment(tempPath, seg); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to write segment: %w", err)
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		os.Remove(tempPath)
+		return fmt.Errorf("failed to rename segment: %w", err)
+	}
+	return nil
+}
+
+// CompactSegments folds the segments applied to vs into the index at indexPath,
+// then deletes them
+func CompactSegments(vs *VectorStore, indexPath string) error {
+	if err := AtomicSave(vs, indexPath); err != nil {
+		return err
+	}
+	paths, err := SegmentPaths(indexPath)
+	if err != nil {
+		return err
+	}
+	for _, path := range paths {
+		if seq, ok := segmentSeq(indexPath, path); ok && seq <= vs.Metadata.SegmentSeq {
+			os.Remove(path)
+		}
+	}
+	return nil
+}
+
+// RemoveSegments deletes all segments of the index at indexPath
+func RemoveSegments(indexPath string) {
+	paths, _ := SegmentPaths(indexPath)
+	for _, path := range paths {
+		os.Remove(path)
+	}
+}
+
+// LoadWithSegments loads the index at indexPath with its segments applied. a
+// segment that disappears while loading was compacted into a newer index, so the
+// load starts over
+func LoadWithSegments(indexPath string) (*VectorStore, error) {
+	var err error
+	for attempt := 0; attempt < loadRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(loadRetryDelay)
+		}
+		var vs *VectorStore
+		if vs, err = loadWithSegmentsOnce(indexPath); err == nil {
+			return vs, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+func loadWithSegmentsOnce(indexPath string) (*VectorStore, error) {
+	vs := NewVectorStore()
+	if err := vs.Load(indexPath); err != nil {
+		return nil, err
+	}
+	paths, err := SegmentPaths(indexPath)
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		if seq, ok := segmentSeq(indexPath, path); !ok || seq <= vs.Metadata.SegmentSeq {
+			continue // already in the index
+		}
+		seg, err := readSegment(path)
+		if err != nil {
+			return nil, err
+		}
+		vs.ApplySegment(seg)
+	}
+	return vs, nil
+}
+
+// segmentSeq returns the sequence number of a segment of the index at indexPath
+func segmentSeq(indexPath, path string) (int, bool) {
+	prefix := strings.TrimSuffix(indexPath, ".lrindex") + "."
+	seq, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(path, prefix), segmentExt))
+	return seq, err == nil
+}
+
+// writeSegment writes seg as gzipped json and syncs it to disk
+func writeSegment(path string, seg *Segment) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	data, err := json.Marshal(seg)
+	if err != nil {
+		return err
+	}
+	if err := writeChecked(f, data); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// readSegment reads a segment written by writeSegment
+func readSegment(path string) (*Segment, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	data, err := readChecked(f, path)
+	if err != nil {
+		return nil, err
+	}
+	var seg Segment
+	if err := json.Unmarshal(data, &seg); err != nil {
+		return nil, errkind.With(fmt.Errorf("segment %s: %w", path, err), ErrCorruptIndex)
+	}
+	if len(seg.Chunks) != len(seg.Embeddings) {
+		return nil, errkind.With(fmt.Errorf("segment %s is inconsistent: %d chunks but %d embeddings", path, len(seg.Chunks), len(seg.Embeddings)), ErrCorruptIndex)
+	}
+	return &seg, nil
+}
@@ -0,0 +1,56 @@
+package store
+
+import (
+	"path/filepath"
+	"testing"
+
+	"lr/pkg/chunk"
+)
+
+func TestSegmentsAppendAndCompact(t *testing.T) {
+	indexPath := filepath.Join(t.TempDir(), "review.lrindex")
+	vs := NewVectorStore()
+	vs.Add(chunk.Chunk{Text: "a v1", Source: "a.go"}, []float64{1, 0})
+	vs.Add(chunk.Chunk{Text: "b v1", Source: "b.go"}, []float64{0, 1})
+	if err := AtomicSave(vs, indexPath); err != nil {
+		t.Fatal(err)
+	}
+
+	// a.go changes, then b.go is deleted
+	update := &Segment{Sources: []string{"a.go"}}
+	update.Add(chunk.Chunk{Text: "a v2", Source: "a.go"}, []float64{1, 1})
+	if err := AppendSegment(vs, indexPath, update); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+	if err := AppendSegment(vs, indexPath, &Segment{Sources: []string{"b.go"}}); err != nil {
+		t.Fatalf("append failed: %v", err)
+	}
+
+	check := func(when string) {
+		t.Helper()
+		loaded, err := LoadWithSegments(indexPath)
+		if err != nil {
+			t.Fatalf("%s: load failed: %v", when, err)
+		}
+		if len(loaded.Chunks) != 1 || loaded.Chunks[0].Text != "a v2" || loaded.Metadata.SegmentSeq != 2 {
+			t.Fatalf("%s: unexpected index: %+v (segment %d)", when, loaded.Chunks, loaded.Metadata.SegmentSeq)
+		}
+	}
+	check("with segments")
+
+	if err := CompactSegments(vs, indexPath); err != nil {
+		t.Fatalf("compact failed: %v", err)
+	}
+	if paths, _ := SegmentPaths(indexPath); len(paths) != 0 {
+		t.Fatalf("compaction left segments behind: %v", paths)
+	}
+	check("after compaction")
+
+	// segments already folded into the index are skipped, e.g. when a crash
+	// left them behind after the index was rewritten
+	stale := &Segment{Seq: 1, Sources: []string{"a.go"}}
+	if err := writeSegment(SegmentPath(indexPath, 1), stale); err != nil {
+		t.Fatal(err)
+	}
+	check("with a stale segment")
+}
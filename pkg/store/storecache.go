@@ -0,0 +1,164 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"lr/internal/errkind"
+)
+
+// DefaultStoreCacheTTL is how long a cached store may go unused before it is
+// dropped, e.g. the previous version of a re-indexed source
+const DefaultStoreCacheTTL = 10 * time.Minute
+
+// StoreCache keeps loaded vector stores in memory keyed by file path, and reloads
+// a store only when its file's mtime or size changes. concurrent loads of the
+// same file share one read, and stores unused for TTL are evicted. cached stores
+// are shared and must be treated as read-only
+type StoreCache struct {
+	TTL time.Duration // idle time before eviction; 0 keeps stores forever
+
+	mu      sync.Mutex
+	entries map[string]storeCacheEntry
+	loading map[string]*storeLoad
+	stats   StoreCacheStats
+}
+
+type storeCacheEntry struct {
+	modTime  time.Time
+	size     int64
+	vs       *VectorStore
+	lastUsed time.Time
+}
+
+// storeLoad is a load in progress that concurrent callers wait for
+type storeLoad struct {
+	done    chan struct{}
+	modTime time.Time
+	size    int64
+	vs      *VectorStore
+	err     error
+}
+
+// StoreCacheStats counts cache activity
+type StoreCacheStats struct {
+	Entries   int // stores currently cached
+	Hits      int // loads served from cache
+	Shared    int // loads that waited for a concurrent read of the same file
+	Loads     int // reads from disk
+	Evictions int // stores dropped after going unused for the TTL
+}
+
+// NewStoreCache creates an empty store cache with the default TTL
+func NewStoreCache() *StoreCache {
+	return &StoreCache{
+		TTL:     DefaultStoreCacheTTL,
+		entries: make(map[string]storeCacheEntry),
+		loading: make(map[string]*storeLoad),
+	}
+}
+
+// Load returns the store at path, from cache if the file is unchanged
+func (c *StoreCache) Load(path string) (*VectorStore, error) {
+	before, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	now := time.Now()
+	c.evictLocked(now)
+	if entry, ok := c.entries[path]; ok && entry.modTime.Equal(before.ModTime()) && entry.size == before.Size() {
+		entry.lastUsed = now
+		c.entries[path] = entry
+		c.stats.Hits++
+		c.mu.Unlock()
+		return entry.vs, nil
+	}
+	// someone is already reading this version of the file: wait for them
+	if load, ok := c.loading[path]; ok && load.modTime.Equal(before.ModTime()) && load.size == before.Size() {
+		c.stats.Shared++
+		c.mu.Unlock()
+		<-load.done
+		return load.vs, load.err
+	}
+	load := &storeLoad{done: make(chan struct{}), modTime: before.ModTime(), size: before.Size()}
+	c.loading[path] = load
+	c.stats.Loads++
+	c.mu.Unlock()
+
+	load.vs, load.err = loadUnchanged(path, before)
+
+	c.mu.Lock()
+	if c.loading[path] == load {
+		delete(c.loading, path)
+	}
+	if load.err == nil {
+		c.entries[path] = storeCacheEntry{modTime: load.modTime, size: load.size, vs: load.vs, lastUsed: time.Now()}
+	}
+	c.mu.Unlock()
+	close(load.done)
+	return load.vs, load.err
+}
+
+// Stats returns the cache's activity counts
+func (c *StoreCache) Stats() StoreCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Entries = len(c.entries)
+	return stats
+}
+
+// Clear drops all cached stores; loads in progress still complete
+func (c *StoreCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
+
+// evictLocked drops the stores unused for longer than the TTL
+func (c *StoreCache) evictLocked(now time.Time) {
+	if c.TTL <= 0 {
+		return
+	}
+	for path, entry := range c.entries {
+		if now.Sub(entry.lastUsed) > c.TTL {
+			delete(c.entries, path)
+			c.stats.Evictions++
+		}
+	}
+}
+
+// loadUnchanged loads the store at path, failing if the file changes from
+// before while it is read
+func loadUnchanged(path string, before os.FileInfo) (*VectorStore, error) {
+	vs, err := loadValidated(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// if the file changed while we were reading it, don't trust what we read
+	after, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !after.ModTime().Equal(before.ModTime()) || after.Size() != before.Size() {
+		return nil, fmt.Errorf("%s changed while loading", path)
+	}
+	return vs, nil
+}
+
+// loadValidated loads a store and checks it is internally consistent
+func loadValidated(path string) (*VectorStore, error) {
+	vs := NewVectorStore()
+	if err := vs.Load(path); err != nil {
+		return nil, err
+	}
+	if len(vs.Chunks) != len(vs.Embeddings) {
+		return nil, errkind.With(fmt.Errorf("%s is inconsistent: %d chunks but %d embeddings", path, len(vs.Chunks), len(vs.Embeddings)), ErrCorruptIndex)
+	}
+	return vs, nil
+}
@@ -0,0 +1,101 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"lr/pkg/chunk"
+)
+
+func saveTestStore(t *testing.T, path string, texts ...string) {
+	t.Helper()
+	vs := NewVectorStore()
+	for _, text := range texts {
+		vs.Add(chunk.Chunk{Text: text, Source: "a.go"}, []float64{0.1, 0.2, 0.3})
+	}
+	if err := vs.Save(path); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+}
+
+func TestStoreCacheSharesConcurrentLoads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.lrindex")
+	saveTestStore(t, path, "one")
+
+	cache := NewStoreCache()
+	var wg sync.WaitGroup
+	stores := make([]*VectorStore, 8)
+	for i := range stores {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			vs, err := cache.Load(path)
+			if err != nil {
+				t.Errorf("load failed: %v", err)
+			}
+			stores[i] = vs
+		}(i)
+	}
+	wg.Wait()
+
+	for _, vs := range stores[1:] {
+		if vs != stores[0] {
+			t.Fatal("concurrent loads returned different stores")
+		}
+	}
+	if stats := cache.Stats(); stats.Loads != 1 {
+		t.Fatalf("expected 1 read from disk, got %+v", stats)
+	}
+}
+
+func TestStoreCacheReloadsChangedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.lrindex")
+	saveTestStore(t, path, "one")
+
+	cache := NewStoreCache()
+	first, err := cache.Load(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if again, _ := cache.Load(path); again != first {
+		t.Fatal("unchanged file was reloaded")
+	}
+
+	saveTestStore(t, path, "one", "two")
+	later := time.Now().Add(time.Second)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	second, err := cache.Load(path)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if len(second.Chunks) != 2 {
+		t.Fatalf("expected the changed file's 2 chunks, got %d", len(second.Chunks))
+	}
+}
+
+func TestStoreCacheEvictsIdleStores(t *testing.T) {
+	dir := t.TempDir()
+	old, current := filepath.Join(dir, "old.lrindex"), filepath.Join(dir, "new.lrindex")
+	saveTestStore(t, old, "one")
+	saveTestStore(t, current, "two")
+
+	cache := NewStoreCache()
+	cache.TTL = 10 * time.Millisecond
+	if _, err := cache.Load(old); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, err := cache.Load(current); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	stats := cache.Stats()
+	if stats.Entries != 1 || stats.Evictions != 1 {
+		t.Fatalf("expected the idle store to be evicted, got %+v", stats)
+	}
+}
@@ -0,0 +1,353 @@
+// Package store holds embedded chunks in memory, persists them as .lrindex files
+// and searches one or many indexes by cosine similarity
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"lr/pkg/chunk"
+	"lr/pkg/loader"
+)
+
+// VectorStore is a simple in-memory vector database
+type VectorStore struct {
+	Chunks     []chunk.Chunk
+	Embeddings [][]float64
+	Metadata   VectorStoreMetadata
+	Summaries  []FileSummary `json:",omitempty"` // file summaries, for SearchHierarchical
+}
+
+// FileSummary is the chat model's summary of an indexed file and its embedding
+type FileSummary struct {
+	File      string    `json:"file"`
+	Summary   string    `json:"summary"`
+	Embedding []float64 `json:"embedding"`
+}
+
+// VectorStoreMetadata tracks information about the indexed source
+type VectorStoreMetadata struct {
+	IndexedAt      string               `json:"indexed_at"`
+	SourcePath     string               `json:"source_path"`
+	FileCount      int                  `json:"file_count"`
+	ChunkCount     int                  `json:"chunk_count"`
+	IndexedFiles   []string             `json:"indexed_files"`           // list of all indexed file paths
+	SkippedFiles   []loader.SkippedFile `json:"skipped_files"`           // files that were skipped with reasons
+	LastCommit     string               `json:"last_commit"`             // git commit hash for incremental updates
+	Branch         string               `json:"branch,omitempty"`        // git branch LastCommit was on ("" if detached)
+	ReviewIndex    bool                 `json:"review_index"`            // true if this is a temporary review session index
+	EmbeddingModel string               `json:"embedding_model"`         // model used for embeddings (e.g., nomic-embed-text)
+	SegmentSeq     int                  `json:"segment_seq,omitempty"`   // last segment folded into this index (see LoadWithSegments)
+	ImportedFrom   string               `json:"imported_from,omitempty"` // dump the index was imported from (see ImportDump)
+	Chunking       *chunk.Settings      `json:"chunking,omitempty"`      // how the files were chunked (nil for indexes that predate recording it)
+}
+
+// SearchResult represents a chunk with its similarity score
+type SearchResult struct {
+	Chunk      chunk.Chunk
+	Similarity float64 // raw cosine similarity
+	// Score ranks results; it equals Similarity except for down-weighted noise
+	// chunks and when MultiSourceStore.Search normalizes scores across sources
+	Score float64
+	// AlsoIn lists the other origins ("index:path") of duplicate chunks that
+	// MultiSourceStore.Search collapsed into this result
+	AlsoIn []string
+
+	embedding []float64
+}
+
+// NewVectorStore creates a new vector store
+func NewVectorStore() *VectorStore {
+	return &VectorStore{
+		Chunks:     make([]chunk.Chunk, 0),
+		Embeddings: make([][]float64, 0),
+	}
+}
+
+// Add adds a chunk and its embedding to the store
+func (vs *VectorStore) Add(c chunk.Chunk, embedding []float64) {
+	vs.Chunks = append(vs.Chunks, c)
+	vs.Embeddings = append(vs.Embeddings, embedding)
+}
+
+// RemoveBySource removes all chunks from files matching the given paths
+func (vs *VectorStore) RemoveBySource(paths []string) int {
+	if len(paths) == 0 {
+		return 0
+	}
+
+	// build lookup set
+	pathSet := make(map[string]bool)
+	for _, p := range paths {
+		pathSet[p] = true
+	}
+
+	// filter chunks and embeddings
+	newChunks := make([]chunk.Chunk, 0, len(vs.Chunks))
+	newEmbeddings := make([][]float64, 0, len(vs.Embeddings))
+	removed := 0
+
+	for i, chunk := range vs.Chunks {
+		if pathSet[chunk.Source] {
+			removed++
+		} else {
+			newChunks = append(newChunks, chunk)
+			newEmbeddings = append(newEmbeddings, vs.Embeddings[i])
+		}
+	}
+
+	vs.Chunks = newChunks
+	vs.Embeddings = newEmbeddings
+
+	summaries := vs.Summaries[:0]
+	for _, s := range vs.Summaries {
+		if !pathSet[s.File] {
+			summaries = append(summaries, s)
+		}
+	}
+	vs.Summaries = summaries
+	return removed
+}
+
+// RemoveExcludedFiles removes chunks from files that should be excluded (minified, bundled, etc.)
+func (vs *VectorStore) RemoveExcludedFiles() (removed int, files []string) {
+	newChunks := make([]chunk.Chunk, 0, len(vs.Chunks))
+	newEmbeddings := make([][]float64, 0, len(vs.Embeddings))
+	removedFiles := make(map[string]bool)
+
+	for i, chunk := range vs.Chunks {
+		if loader.ShouldExcludeFile(chunk.Source) {
+			if !removedFiles[chunk.Source] {
+				files = append(files, chunk.Source)
+				removedFiles[chunk.Source] = true
+			}
+			removed++
+		} else {
+			newChunks = append(newChunks, chunk)
+			newEmbeddings = append(newEmbeddings, vs.Embeddings[i])
+		}
+	}
+
+	vs.Chunks = newChunks
+	vs.Embeddings = newEmbeddings
+	return removed, files
+}
+
+// MemoryBytes estimates the memory the store takes when loaded: its
+// embeddings, the text, sources and metadata of its chunks, and its summaries
+func (vs *VectorStore) MemoryBytes() int64 {
+	const sliceHeader, stringHeader, mapEntry = 24, 16, 48
+	var n int64
+	for _, e := range vs.Embeddings {
+		n += sliceHeader + int64(len(e))*8
+	}
+	for _, c := range vs.Chunks {
+		n += int64(len(c.Text)+len(c.Source)) + 2*stringHeader
+		for k, v := range c.Metadata {
+			n += int64(len(k)+len(v)) + mapEntry
+		}
+	}
+	for _, s := range vs.Summaries {
+		n += int64(len(s.File)+len(s.Summary)) + sliceHeader + int64(len(s.Embedding))*8
+	}
+	for _, f := range vs.Metadata.IndexedFiles {
+		n += int64(len(f)) + stringHeader
+	}
+	return n
+}
+
+// NoiseWeight scales the score of chunks marked as noise (license headers,
+// import blocks, generated code) when they were down-weighted rather than dropped
+const NoiseWeight = 0.75
+
+// Search finds the most similar chunks to the query embedding. noise chunks
+// rank by their similarity scaled by NoiseWeight
+// a query embedding of the wrong size (another embedding model) matches nothing;
+// use CheckDimensions to find out why
+func (vs *VectorStore) Search(queryEmbedding []float64, topK int) []SearchResult {
+	return vs.search(queryEmbedding, topK, nil)
+}
+
+// SearchExcluding is Search without the chunks of files exclude matches
+func (vs *VectorStore) SearchExcluding(queryEmbedding []float64, topK int, exclude *PathFilter) []SearchResult {
+	return vs.searchHierarchical(queryEmbedding, topK, 0, exclude)
+}
+
+// SearchHierarchical searches in two stages: it picks the files whose summaries
+// are most similar to the query, then searches only their chunks (and those of
+// files without a summary). on a large index this skips most chunks and keeps
+// a file's chunks together when a question is about what a file does. it is
+// Search on indexes with no more summaries than files
+func (vs *VectorStore) SearchHierarchical(queryEmbedding []float64, topK, files int) []SearchResult {
+	return vs.searchHierarchical(queryEmbedding, topK, files, nil)
+}
+
+// searchHierarchical is SearchHierarchical without the chunks (and summaries)
+// of the files exclude matches
+func (vs *VectorStore) searchHierarchical(queryEmbedding []float64, topK, files int, exclude *PathFilter) []SearchResult {
+	included := func(c chunk.Chunk) bool {
+		return !exclude.Excludes(c.Source)
+	}
+	if exclude == nil {
+		included = nil
+	}
+	if files <= 0 || len(vs.Summaries) <= files || vs.dimensionError(queryEmbedding) != nil {
+		return vs.search(queryEmbedding, topK, included)
+	}
+
+	type fileScore struct {
+		file       string
+		similarity float64
+	}
+	ranked := make([]fileScore, 0, len(vs.Summaries))
+	summarized := make(map[string]bool, len(vs.Summaries))
+	for _, s := range vs.Summaries {
+		if exclude.Excludes(s.File) {
+			continue
+		}
+		summarized[s.File] = true
+		ranked = append(ranked, fileScore{s.File, CosineSimilarity(queryEmbedding, s.Embedding)})
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		return ranked[i].similarity > ranked[j].similarity
+	})
+	candidates := make(map[string]bool, files)
+	for _, f := range ranked[:min(files, len(ranked))] {
+		candidates[f.file] = true
+	}
+
+	return vs.search(queryEmbedding, topK, func(c chunk.Chunk) bool {
+		file := chunk.FilePath(c.Source)
+		return (candidates[file] || !summarized[file]) && !exclude.Excludes(c.Source)
+	})
+}
+
+// search ranks the chunks keep accepts (all if nil) by similarity to the query
+func (vs *VectorStore) search(queryEmbedding []float64, topK int, keep func(chunk.Chunk) bool) []SearchResult {
+	if vs.dimensionError(queryEmbedding) != nil {
+		return nil
+	}
+
+	var results []SearchResult
+
+	// calculate cosine similarity for each chunk
+	for i, embedding := range vs.Embeddings {
+		if keep != nil && !keep(vs.Chunks[i]) {
+			continue
+		}
+		similarity := CosineSimilarity(queryEmbedding, embedding)
+		score := similarity
+		if vs.Chunks[i].Metadata[chunk.NoiseMetadataKey] != "" {
+			score *= NoiseWeight
+		}
+		results = append(results, SearchResult{
+			Chunk:      vs.Chunks[i],
+			Similarity: similarity,
+			Score:      score,
+			embedding:  embedding,
+		})
+	}
+
+	// sort by score (descending)
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+
+	// return top k results
+	if topK > len(results) {
+		topK = len(results)
+	}
+
+	return results[:topK]
+}
+
+// Save saves the vector store to disk (gzip compressed if .lrindex extension)
+func (vs *VectorStore) Save(filepath string) error {
+	data, err := json.Marshal(vs)
+	if err != nil {
+		return err
+	}
+
+	// if filepath ends with .lrindex, save as gzipped
+	if strings.HasSuffix(filepath, ".lrindex") {
+		f, err := os.Create(filepath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+
+		if err := writeChecked(f, data); err != nil {
+			return err
+		}
+
+		// sync file to disk before returning
+		if err := f.Sync(); err != nil {
+			return fmt.Errorf("failed to sync file to disk: %w", err)
+		}
+
+		return nil
+	}
+
+	// otherwise save as plain json (backward compatibility)
+	return os.WriteFile(filepath, data, 0644)
+}
+
+// Load loads the vector store from disk (auto-detects gzip compression).
+// gzipped indexes are verified against their checksum (see writeChecked)
+func (vs *VectorStore) Load(filepath string) error {
+	f, err := os.Open(filepath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// if filepath ends with .lrindex or file starts with gzip magic bytes, decompress
+	gzipped := strings.HasSuffix(filepath, ".lrindex")
+	if !gzipped {
+		// try to detect gzip by magic bytes for backward compat
+		header := make([]byte, 2)
+		n, _ := f.Read(header)
+		gzipped = n == 2 && header[0] == 0x1f && header[1] == 0x8b
+		f.Seek(0, 0) // reset
+	}
+
+	var data []byte
+	if gzipped {
+		data, err = readChecked(f, filepath)
+		if err != nil {
+			return err
+		}
+	} else if data, err = io.ReadAll(f); err != nil {
+		return corrupt(filepath, err)
+	}
+	if err := json.Unmarshal(data, vs); err != nil {
+		return corrupt(filepath, err)
+	}
+	return nil
+}
+
+// CosineSimilarity calculates the cosine similarity between two vectors
+func CosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+
+	var dotProduct, normA, normB float64
+
+	for i := 0; i < len(a); i++ {
+		dotProduct += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	return dotProduct / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,127 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"lr/pkg/chunk"
+)
+
+func TestVectorStoreSave(t *testing.T) {
+	// create a simple vector store
+	vs := NewVectorStore()
+	vs.Add(chunk.Chunk{
+		Text:   "test chunk",
+		Source: "test.go",
+	}, []float64{0.1, 0.2, 0.3})
+
+	// save to temp file
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "test.lrindex")
+
+	t.Logf("saving to: %s", testFile)
+	err := vs.Save(testFile)
+	if err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	// check if file exists
+	info, err := os.Stat(testFile)
+	if os.IsNotExist(err) {
+		// list what's in the directory
+		entries, _ := os.ReadDir(tmpDir)
+		t.Logf("files in tmpDir:")
+		for _, e := range entries {
+			t.Logf("  - %s", e.Name())
+		}
+		t.Fatalf("file was not created: %s", testFile)
+	}
+	if err != nil {
+		t.Fatalf("stat failed: %v", err)
+	}
+
+	t.Logf("file created successfully, size: %d bytes", info.Size())
+
+	// try to load it back
+	vs2 := NewVectorStore()
+	if err := vs2.Load(testFile); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	if len(vs2.Chunks) != 1 {
+		t.Fatalf("expected 1 chunk, got %d", len(vs2.Chunks))
+	}
+
+	t.Log("save/load test passed!")
+}
+
+func TestSearchDimensionMismatch(t *testing.T) {
+	vs := NewVectorStore()
+	vs.Metadata.EmbeddingModel = "voyage-code-2"
+	vs.Add(chunk.Chunk{Text: "test chunk", Source: "test.go"}, []float64{0.1, 0.2, 0.3})
+
+	query := []float64{0.1, 0.2}
+	if results := vs.Search(query, 3); len(results) != 0 {
+		t.Errorf("expected no results for a mismatched query, got %d", len(results))
+	}
+
+	err := vs.CheckDimensions(query)
+	if !errors.Is(err, ErrDimensionMismatch) {
+		t.Fatalf("expected ErrDimensionMismatch, got %v", err)
+	}
+	var derr *DimensionError
+	if !errors.As(err, &derr) || derr.EmbeddingModel != "voyage-code-2" || derr.IndexDims != 3 || derr.QueryDims != 2 {
+		t.Errorf("unexpected error details: %+v", derr)
+	}
+	if err := vs.CheckDimensions([]float64{1, 2, 3}); err != nil {
+		t.Errorf("expected matching dimensions to pass, got %v", err)
+	}
+}
+
+func TestSearchDownWeightsNoise(t *testing.T) {
+	vs := NewVectorStore()
+	vs.Add(chunk.Chunk{Text: "// Copyright", Source: "a.go", Metadata: map[string]string{chunk.NoiseMetadataKey: "license"}}, []float64{1, 0})
+	vs.Add(chunk.Chunk{Text: "func A() {}", Source: "a.go"}, []float64{0.9, 0.3})
+
+	results := vs.Search([]float64{1, 0}, 2)
+	if results[0].Chunk.Text != "func A() {}" {
+		t.Fatalf("expected the noise chunk ranked below the code, got %q first", results[0].Chunk.Text)
+	}
+	if results[1].Similarity != 1 {
+		t.Errorf("expected the raw similarity of the noise chunk kept, got %v", results[1].Similarity)
+	}
+}
+
+func TestSearchHierarchical(t *testing.T) {
+	vs := NewVectorStore()
+	// the chunk closest to the query is in pub.go, whose summary is not
+	vs.Add(chunk.Chunk{Text: "func Connect() {}", Source: "conn.go"}, []float64{0.8, 0.6})
+	vs.Add(chunk.Chunk{Text: "func Publish() {}", Source: "pub.go"}, []float64{1, 0})
+	vs.Add(chunk.Chunk{Text: "func Helper() {}", Source: "util.go (part 2)"}, []float64{0.9, 0.1})
+	vs.Add(chunk.Chunk{Text: "# readme", Source: "README.md"}, []float64{0.5, 0.5})
+	vs.Summaries = []FileSummary{
+		{File: "conn.go", Embedding: []float64{1, 0}},
+		{File: "pub.go", Embedding: []float64{0, 1}},
+		{File: "util.go", Embedding: []float64{0.1, 1}},
+	}
+
+	var got []string
+	for _, r := range vs.SearchHierarchical([]float64{1, 0}, 10, 1) {
+		got = append(got, r.Chunk.Source)
+	}
+	// conn.go is the candidate; README.md has no summary so it is searched too
+	if len(got) != 2 || got[0] != "conn.go" || got[1] != "README.md" {
+		t.Errorf("expected conn.go and README.md, got %v", got)
+	}
+
+	if n := len(vs.SearchHierarchical([]float64{1, 0}, 10, 0)); n != 4 {
+		t.Errorf("expected every chunk searched with no candidate limit, got %d results", n)
+	}
+
+	vs.RemoveBySource([]string{"pub.go"})
+	if len(vs.Summaries) != 2 {
+		t.Errorf("expected the summary of a removed file dropped, got %d summaries", len(vs.Summaries))
+	}
+}